@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os/user"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var ackActiveReason string
+
+var ackActiveCmd = &cobra.Command{
+	Use:   "ack-active",
+	Short: "Acknowledge syncing while the validator is active",
+	Long: `Record an operator acknowledgement in the state file allowing syncs while the validator is running as its active
+identity (validator.enabled_when_active=true) for the next validator.active_ack_ttl.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if loadedConfig.Validator.ActiveAckTTL == 0 {
+			log.Warn("validator.active_ack_ttl is not set - acknowledgements are not required and will be ignored")
+		}
+
+		ack := &state.ActiveAck{
+			AckedAt: time.Now().UTC(),
+			Reason:  ackActiveReason,
+		}
+		if currentUser, err := user.Current(); err == nil {
+			ack.By = currentUser.Username
+		}
+
+		store := state.NewStore(loadedConfig.State.File)
+		err := store.Update(func(st *state.State) error {
+			st.ActiveAck = ack
+			return nil
+		})
+		if err != nil {
+			log.Fatal("failed to record acknowledgement", "state_file", store.Path(), "error", err)
+		}
+
+		logger := log.With("by", ack.By, "reason", ack.Reason)
+		if loadedConfig.Validator.ActiveAckTTL > 0 {
+			logger = logger.With("expires_at", ack.AckedAt.Add(loadedConfig.Validator.ActiveAckTTL).Format(time.RFC3339))
+		}
+		logger.Info("acknowledged syncing while active")
+	},
+}
+
+func init() {
+	ackActiveCmd.Flags().StringVar(&ackActiveReason, "reason", "", "Why syncing while active is acknowledged, recorded with the acknowledgement")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	approveReason  string
+	approveKeyFile string
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [PLAN_ID]",
+	Short: "Approve the sync plan held for approval",
+	Long: `Record an approval of the sync plan held under sync.approval in the state file. The plan only runs once
+sync.approval.required_approvals distinct approvers listed in sync.approval.approvers approved it. Each approval is
+signed with the approver's keypair (--key) and recorded as the approvers entry with its public key - an approval
+without a valid signature doesn't count, so it can't be recorded in another approver's name. PLAN_ID names the
+pending plan, the signature covers its full plan digest. Approvals only apply to the plan approved - a change of the
+target version, installed version or rendered commands is a new plan needing new approvals. Without PLAN_ID the
+pending plan and its approvals are printed.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		approvalConfig := &loadedConfig.Sync.Approval
+		if !approvalConfig.Enabled {
+			log.Warn("sync.approval is not enabled - approvals are not required and will be ignored")
+		}
+
+		store := state.NewStore(loadedConfig.State.File)
+		if len(args) == 0 {
+			st, err := store.Load()
+			if err != nil {
+				log.Fatal("failed to load state", "state_file", store.Path(), "error", err)
+			}
+			printPendingApproval(st.PendingApproval, approvalConfig)
+			return
+		}
+
+		if approveKeyFile == "" {
+			log.Fatal("--key is required to sign the approval")
+		}
+		key, err := solana.PrivateKeyFromSolanaKeygenFile(approveKeyFile)
+		if err != nil {
+			log.Fatal("failed to load approver keypair", "key", approveKeyFile, "error", err)
+		}
+		approver, ok := approvalConfig.ApproverByPubkey(key.PublicKey().String())
+		if !ok {
+			log.Fatal("key is not the pubkey of any sync.approval.approvers entry", "pubkey", key.PublicKey().String())
+		}
+
+		planID := args[0]
+		var approvedBy []string
+		err = store.Update(func(st *state.State) error {
+			pending := st.PendingApproval
+			if pending == nil {
+				return fmt.Errorf("no sync plan is awaiting approval")
+			}
+			if pending.PlanID != planID {
+				return fmt.Errorf("plan %s is not the pending plan %s (v%s -> v%s) - it changed or was never rendered",
+					planID, pending.PlanID, pending.FromVersion, pending.ToVersion)
+			}
+			if pending.PlanDigest == "" {
+				return fmt.Errorf("plan %s was held without a plan digest to sign - approve it once the next cycle holds it again", planID)
+			}
+			// the plan id only names the plan, the signature covers its full digest
+			signature, err := doublezero.SignApproval(key, pending.PlanDigest)
+			if err != nil {
+				return fmt.Errorf("failed to sign approval: %w", err)
+			}
+			counts := func(approval state.Approval) bool {
+				return doublezero.VerifyApproval(approvalConfig, pending.PlanDigest, approval)
+			}
+			// an unsigned or forged approval recorded in the approver's name doesn't count and mustn't block theirs
+			pending.Approvals = slices.DeleteFunc(pending.Approvals, func(approval state.Approval) bool {
+				return approval.By == approver.Name && !counts(approval)
+			})
+			err = pending.Approve(state.Approval{By: approver.Name, ApprovedAt: time.Now().UTC(), Reason: approveReason, Signature: signature})
+			if err != nil {
+				return err
+			}
+			approvedBy = pending.ApprovedBy(counts)
+			return nil
+		})
+		if err != nil {
+			log.Fatal("failed to record approval", "state_file", store.Path(), "error", err)
+		}
+
+		log.Info("approved sync plan", "plan_id", planID, "by", approver.Name, "reason", approveReason,
+			"approvals", len(approvedBy), "required_approvals", approvalConfig.RequiredApprovals)
+	},
+}
+
+// printPendingApproval prints the plan awaiting approval, its commands and approvals
+func printPendingApproval(pending *state.PendingApproval, approvalConfig *config.Approval) {
+	if pending == nil {
+		fmt.Println("No sync plan is awaiting approval")
+		return
+	}
+
+	fmt.Printf("Plan %s: v%s -> v%s, held since %s\n", pending.PlanID, pending.FromVersion, pending.ToVersion,
+		pending.RequestedAt.Format(time.RFC3339))
+	fmt.Println(strings.Join(pending.Commands, "\n"))
+	counts := func(approval state.Approval) bool {
+		return doublezero.VerifyApproval(approvalConfig, pending.PlanDigest, approval)
+	}
+	fmt.Printf("Approvals: %d of %d required\n", len(pending.ApprovedBy(counts)), approvalConfig.RequiredApprovals)
+	for _, approval := range pending.Approvals {
+		line := fmt.Sprintf("  %s at %s", approval.By, approval.ApprovedAt.Format(time.RFC3339))
+		if !counts(approval) {
+			line += " (not signed by a sync.approval.approvers key, not counted)"
+		}
+		if approval.Reason != "" {
+			line += " - " + approval.Reason
+		}
+		fmt.Println(line)
+	}
+}
+
+func init() {
+	approveCmd.Flags().StringVar(&approveReason, "reason", "", "Change reference or note recorded with the approval")
+	approveCmd.Flags().StringVar(&approveKeyFile, "key", "", "Approver's solana-keygen keypair file the approval is signed with, its pubkey must be listed in sync.approval.approvers")
+}
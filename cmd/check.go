@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/manager"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+	"github.com/spf13/cobra"
+)
+
+// check exit codes, following the monitoring plugin convention of small codes for known states
+const (
+	checkExitInSync    = 0
+	checkExitUpgrade   = 1
+	checkExitDowngrade = 2
+	checkExitError     = 3
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Compare the installed and recommended versions and exit with the result",
+	Long: `Compare the installed DoubleZero version with the recommended version for the configured cluster without
+executing anything, print the result and exit with:
+
+  0  in sync
+  1  upgrade needed
+  2  downgrade needed
+  3  error - the config, installed version or recommended version can't be read`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	// config errors exit with checkExitError rather than the generic fatal exit code
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		var err error
+		loadedConfig, err = config.NewFromConfigFile(resolveConfigFile())
+		if err != nil {
+			checkExit(checkExitError, "error: failed to load configuration: %v", err)
+		}
+		loadedConfig.Log.ConfigureWithLevelString(logLevel)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := manager.New(manager.Options{Config: loadedConfig})
+		if err != nil {
+			checkExit(checkExitError, "error: failed to create sync manager: %v", err)
+		}
+		code, message := checkStatus(m.Status(cmd.Context()))
+		checkExit(code, "%s", message)
+	},
+}
+
+// checkStatus returns the check exit code of the status and the comparison result to print
+func checkStatus(status *doublezero.Status) (code int, message string) {
+	switch {
+	case status.InstalledError != "":
+		return checkExitError, fmt.Sprintf("error: failed to get installed version: %s", status.InstalledError)
+	case status.RecommendationError != "":
+		return checkExitError, fmt.Sprintf("error: failed to get recommended version for %s: %s", status.Cluster, status.RecommendationError)
+	}
+
+	recommended := status.Recommendation.Version
+	switch status.Direction {
+	case versiondiff.DirectionUpgrade:
+		return checkExitUpgrade, fmt.Sprintf("upgrade needed: installed %s, recommended %s for %s", status.InstalledVersion, recommended, status.Cluster)
+	case versiondiff.DirectionDowngrade:
+		return checkExitDowngrade, fmt.Sprintf("downgrade needed: installed %s, recommended %s for %s", status.InstalledVersion, recommended, status.Cluster)
+	default:
+		return checkExitInSync, fmt.Sprintf("in sync: installed %s is the recommended version for %s", status.InstalledVersion, status.Cluster)
+	}
+}
+
+// checkExit prints the formatted message and exits with the given code
+func checkExit(code int, format string, args ...any) {
+	fmt.Printf(format+"\n", args...)
+	os.Exit(code)
+}
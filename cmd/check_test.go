@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+func TestCheckStatus(t *testing.T) {
+	recommendation := &doublezero.StatusRecommendation{Version: "0.8.1", PackageVersion: "0.8.1-1", Source: "static"}
+
+	tests := []struct {
+		name        string
+		status      doublezero.Status
+		wantCode    int
+		wantMessage string
+	}{
+		{
+			name:        "up to date",
+			status:      doublezero.Status{Cluster: "testnet", InstalledVersion: "0.8.1", Recommendation: recommendation, Direction: versiondiff.DirectionNoChange},
+			wantCode:    checkExitInSync,
+			wantMessage: "in sync",
+		},
+		{
+			name:        "update available",
+			status:      doublezero.Status{Cluster: "testnet", InstalledVersion: "0.6.9", Recommendation: recommendation, Direction: versiondiff.DirectionUpgrade},
+			wantCode:    checkExitUpgrade,
+			wantMessage: "upgrade needed",
+		},
+		{
+			name:        "downgrade needed",
+			status:      doublezero.Status{Cluster: "testnet", InstalledVersion: "0.9.0", Recommendation: recommendation, Direction: versiondiff.DirectionDowngrade},
+			wantCode:    checkExitDowngrade,
+			wantMessage: "downgrade needed",
+		},
+		{
+			name:        "installed version error",
+			status:      doublezero.Status{Cluster: "testnet", InstalledError: "exec: doublezero not found", Recommendation: recommendation},
+			wantCode:    checkExitError,
+			wantMessage: "failed to get installed version",
+		},
+		{
+			name:        "recommended version error",
+			status:      doublezero.Status{Cluster: "testnet", InstalledVersion: "0.8.1", RecommendationError: "source unavailable"},
+			wantCode:    checkExitError,
+			wantMessage: "failed to get recommended version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, message := checkStatus(&tt.status)
+			if code != tt.wantCode || !strings.Contains(message, tt.wantMessage) {
+				t.Errorf("checkStatus() = %d %q, want %d %q", code, message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+	"github.com/sol-strategies/doublezero-version-sync/internal/lockfile"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupMaxAge time.Duration
+	cleanupDryRun bool
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove leftover temporary files and stale state",
+	Long: `Remove what long-lived hosts accumulate: temporary files left next to the state, version cache and notification
+queue files by writes interrupted before they completed (e.g. by a crash or a full disk) older than --max-age, and the
+rendered command plans kept in the state file for targets that are no longer recommended or stepping stones. With
+retention configured, audit log entries, config file backups and an unused version source cache older than
+retention.max_age are removed, and the oldest audit entries and backups beyond retention.max_size. Safe to run from a
+timer while the syncer runs - younger temporary files may belong to a write in progress and are kept, and the audit log
+is only pruned holding the sync lock.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cleanupMaxAge < 0 {
+			log.Fatal("--max-age must be >= 0", "max_age", cleanupMaxAge)
+		}
+
+		files := []string{loadedConfig.State.File, loadedConfig.VersionSource.Cache.File}
+		if loadedConfig.Notifications.Queue.IsEnabled() {
+			files = append(files, loadedConfig.Notifications.Queue.File)
+		}
+
+		var removedCount int
+		var removedBytes int64
+		for _, file := range files {
+			count, bytes, err := removeStaleTempFiles(file, cleanupMaxAge, cleanupDryRun)
+			removedCount += count
+			removedBytes += bytes
+			if err != nil {
+				log.Error("failed to remove temporary files", "file", file, "error", err)
+			}
+		}
+
+		store := state.NewStore(loadedConfig.State.File)
+		var prunedPlans []string
+		prune := func(st *state.State) error {
+			// plans of stepping stones are rendered on the way to the recommendation
+			keep := slices.Clone(loadedConfig.DoubleZero.SteppingStones)
+			if st.LastRecommendation != nil {
+				keep = append(keep, st.LastRecommendation.PackageVersion)
+			}
+			if st.PendingApproval != nil {
+				keep = append(keep, st.PendingApproval.ToVersion)
+			}
+			prunedPlans = st.PruneCommandPlans(keep...)
+			return nil
+		}
+		if cleanupDryRun {
+			st, err := store.Load()
+			if err != nil {
+				log.Fatal("failed to load state", "state_file", store.Path(), "error", err)
+			}
+			_ = prune(&st)
+		} else if err := store.Update(prune); err != nil {
+			log.Fatal("failed to prune command plans", "state_file", store.Path(), "error", err)
+		}
+
+		var retainedEntries, retainedFiles int
+		var retainedBytes int64
+		if retention := loadedConfig.Retention; retention.IsEnabled() {
+			retainedEntries, retainedFiles, retainedBytes = applyRetention(retention, cleanupDryRun)
+		}
+
+		message := "cleanup finished"
+		if cleanupDryRun {
+			message = "cleanup dry run finished - nothing was removed"
+		}
+		log.Info(message, "temp_files", removedCount, "temp_bytes", removedBytes, "command_plans", prunedPlans,
+			"audit_entries", retainedEntries, "retained_files", retainedFiles, "retained_bytes", retainedBytes)
+	},
+}
+
+// applyRetention applies retention to the audit log, the config file backups migrate-config leaves next to the config
+// file and the version source cache, returning how many audit entries and files were removed and their total size.
+// The audit log is pruned holding the sync lock, so a cycle can't append to it meanwhile - it's skipped while a sync
+// holds the lock
+func applyRetention(retention config.Retention, dryRun bool) (int, int, int64) {
+	var before time.Time
+	if retention.MaxAge > 0 {
+		before = time.Now().Add(-retention.MaxAge)
+	}
+
+	var removedEntries, removedFiles int
+	var removedBytes int64
+	if pruned, bytes, err := pruneAuditLog(before, retention.ParsedMaxSize, dryRun); err != nil {
+		log.Error("failed to prune audit log", "file", loadedConfig.Audit.File, "error", err)
+	} else {
+		removedEntries, removedBytes = pruned, bytes
+	}
+
+	backups, err := filepath.Glob(loadedConfig.File + ".bak-*")
+	if err != nil {
+		log.Error("failed to list config file backups", "error", err)
+	}
+	count, bytes, err := removeRetainedFiles(backups, retention.MaxAge, retention.ParsedMaxSize, dryRun)
+	removedFiles, removedBytes = removedFiles+count, removedBytes+bytes
+	if err != nil {
+		log.Error("failed to remove config file backups", "error", err)
+	}
+
+	// the cache is rewritten on every fetch, one left unwritten for max_age is no longer used
+	count, bytes, err = removeRetainedFiles([]string{loadedConfig.VersionSource.Cache.File}, retention.MaxAge, 0, dryRun)
+	removedFiles, removedBytes = removedFiles+count, removedBytes+bytes
+	if err != nil {
+		log.Error("failed to remove version source cache", "error", err)
+	}
+
+	return removedEntries, removedFiles, removedBytes
+}
+
+// pruneAuditLog removes the audit log entries finished before the given time and the oldest beyond maxBytes, holding
+// the sync lock unless only counting them
+func pruneAuditLog(before time.Time, maxBytes int64, dryRun bool) (int, int64, error) {
+	auditLog := audit.New(audit.Options{File: loadedConfig.Audit.File})
+	if lockFile := loadedConfig.Sync.Lock.File; lockFile != "" && !dryRun {
+		lock, err := lockfile.Acquire(context.Background(), lockFile, loadedConfig.Sync.Lock.Wait, clock.Real{})
+		if errors.Is(err, lockfile.ErrLocked) {
+			log.Warn("a sync holds the sync lock - not pruning the audit log, run cleanup again later", "error", err)
+			return 0, 0, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		defer lock.Release()
+	}
+
+	count, bytes, err := auditLog.Prune(before, maxBytes, dryRun)
+	if count > 0 {
+		message := "pruned audit log"
+		if dryRun {
+			message = "would prune audit log"
+		}
+		log.Info(message, "file", auditLog.Path(), "entries", count, "bytes", bytes)
+	}
+	return count, bytes, err
+}
+
+// removeRetainedFiles removes the files last modified more than maxAge ago, when > 0, then the oldest until their
+// total size is at most maxBytes, when > 0, returning how many were removed and their size. Missing files are skipped
+func removeRetainedFiles(files []string, maxAge time.Duration, maxBytes int64, dryRun bool) (int, int64, error) {
+	type retainedFile struct {
+		path string
+		info os.FileInfo
+	}
+	var retained []retainedFile
+	var totalBytes int64
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		retained = append(retained, retainedFile{path: file, info: info})
+		totalBytes += info.Size()
+	}
+	slices.SortStableFunc(retained, func(a, b retainedFile) int { return a.info.ModTime().Compare(b.info.ModTime()) })
+
+	var removedCount int
+	var removedBytes int64
+	for _, r := range retained {
+		file, info := r.path, r.info
+		expired := maxAge > 0 && time.Since(info.ModTime()) > maxAge
+		oversized := maxBytes > 0 && totalBytes > maxBytes
+		if !expired && !oversized {
+			continue
+		}
+
+		logger := log.With("file", file, "bytes", info.Size(), "modified", info.ModTime().UTC().Format(time.RFC3339))
+		if dryRun {
+			logger.Info("would remove retained file")
+		} else if err := os.Remove(file); err != nil {
+			return removedCount, removedBytes, fmt.Errorf("failed to remove %s: %w", file, err)
+		} else {
+			logger.Info("removed retained file")
+		}
+		removedCount++
+		removedBytes += info.Size()
+		totalBytes -= info.Size()
+	}
+
+	return removedCount, removedBytes, nil
+}
+
+// removeStaleTempFiles removes the temporary files atomic writes of file leave behind when interrupted - file.tmp-*
+// next to it - last modified more than maxAge ago, returning how many were removed and their size
+func removeStaleTempFiles(file string, maxAge time.Duration, dryRun bool) (int, int64, error) {
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(file), filepath.Base(file)+".tmp-*"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list temporary files: %w", err)
+	}
+
+	var removedCount int
+	var removedBytes int64
+	for _, match := range matches {
+		info, err := os.Lstat(match)
+		if err != nil || !info.Mode().IsRegular() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+
+		logger := log.With("file", match, "bytes", info.Size(), "modified", info.ModTime().UTC().Format(time.RFC3339))
+		if dryRun {
+			logger.Info("would remove temporary file")
+		} else if err := os.Remove(match); err != nil {
+			return removedCount, removedBytes, fmt.Errorf("failed to remove %s: %w", match, err)
+		} else {
+			logger.Info("removed temporary file")
+		}
+		removedCount++
+		removedBytes += info.Size()
+	}
+
+	return removedCount, removedBytes, nil
+}
+
+func init() {
+	cleanupCmd.Flags().Var(duration.NewValue(time.Hour, &cleanupMaxAge), "max-age", "Only remove temporary files last modified longer ago than this")
+	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "Log what would be removed without removing anything")
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configInitOptions        config.ScaffoldOptions
+	configInitForce          bool
+	configInitNonInteractive bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration file",
+	// the config file doesn't have to exist, config init writes it
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		logConfig := config.Log{Level: "info", Format: "text"}
+		if err := logConfig.Validate(); err != nil {
+			log.Fatal("failed to configure logging", "error", err)
+		}
+		logConfig.ConfigureWithLevelString(logLevel)
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a starter config file",
+	Long: `Write a starter config file to --config (default: ~/doublezero-version-sync/config.yaml) with the defaults, sync
+commands installing the package with apt or dnf (the other commented out) and validator identity settings, commented
+out unless given. When stdin is a terminal, values not set with flags are prompted for, --non-interactive uses the
+defaults instead. An existing file is only overwritten with --force.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		resolvedConfigFile := resolveConfigFile()
+		if _, err := os.Stat(resolvedConfigFile); err == nil && !configInitForce {
+			log.Fatal("config file already exists - pass --force to overwrite it", "file", resolvedConfigFile)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatal("failed to check config file", "file", resolvedConfigFile, "error", err)
+		}
+
+		if !configInitNonInteractive && stdinIsTerminal() {
+			promptConfigInitOptions(cmd)
+		}
+
+		content, err := config.Scaffold(configInitOptions)
+		if err != nil {
+			log.Fatal("failed to generate config", "error", err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(resolvedConfigFile), 0o755); err != nil {
+			log.Fatal("failed to create config directory", "error", err)
+		}
+		if err := os.WriteFile(resolvedConfigFile, content, 0o644); err != nil {
+			log.Fatal("failed to write config file", "error", err)
+		}
+
+		log.Info("config file written - review the sync commands before running", "file", resolvedConfigFile,
+			"cluster", configInitOptions.ClusterName, "package_manager", configInitOptions.PackageManager)
+	},
+}
+
+// stdinIsTerminal returns whether stdin is an interactive terminal
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// promptConfigInitOptions prompts for the config init options not set with flags, keeping the current value on an empty answer or
+// end of input
+func promptConfigInitOptions(cmd *cobra.Command) {
+	reader := bufio.NewReader(os.Stdin)
+	prompt := func(flag, question string, value *string) {
+		if cmd.Flags().Changed(flag) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, *value)
+		answer, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Fatal("failed to read answer", "error", err)
+		}
+		if answer = strings.TrimSpace(answer); answer != "" {
+			*value = answer
+		}
+	}
+
+	prompt("cluster", "Cluster ("+strings.Join(constants.ValidClusterNames, ", ")+")", &configInitOptions.ClusterName)
+	prompt("package-manager", "Package manager ("+strings.Join(config.ValidScaffoldPackageManagers, ", ")+")", &configInitOptions.PackageManager)
+	prompt("active-identity", "Validator active identity keyfile (empty to leave the validator settings commented out)", &configInitOptions.ActiveIdentity)
+	if configInitOptions.ActiveIdentity != "" {
+		prompt("passive-identity", "Validator passive identity keyfile (empty for a single identity)", &configInitOptions.PassiveIdentity)
+		if configInitOptions.ValidatorRPCURL == "" {
+			configInitOptions.ValidatorRPCURL = "http://localhost:8899"
+		}
+		prompt("validator-rpc-url", "Validator RPC URL", &configInitOptions.ValidatorRPCURL)
+	}
+}
+
+func init() {
+	configInitCmd.Flags().StringVar(&configInitOptions.ClusterName, "cluster", constants.ClusterNameMainnetBeta, "Cluster name, one of "+strings.Join(constants.ValidClusterNames, ", "))
+	configInitCmd.Flags().StringVar(&configInitOptions.PackageManager, "package-manager", config.ScaffoldPackageManagerApt, "Package manager the sync commands install with, one of "+strings.Join(config.ValidScaffoldPackageManagers, ", "))
+	configInitCmd.Flags().StringVar(&configInitOptions.ValidatorRPCURL, "validator-rpc-url", "", "Validator RPC URL - defaults to http://localhost:8899 when an identity is set")
+	configInitCmd.Flags().StringVar(&configInitOptions.ActiveIdentity, "active-identity", "", "Path to the validator active identity keyfile - the validator settings are commented out without it")
+	configInitCmd.Flags().StringVar(&configInitOptions.PassiveIdentity, "passive-identity", "", "Path to the validator passive identity keyfile")
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "Overwrite an existing config file")
+	configInitCmd.Flags().BoolVar(&configInitNonInteractive, "non-interactive", false, "Don't prompt for values not set with flags, use their defaults")
+
+	configCmd.AddCommand(configInitCmd)
+}
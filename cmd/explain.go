@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/manager"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	explainSimulate bool
+	explainNow      string
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain the most recent (or a simulated) sync decision",
+	Long: `Walk through the most recent sync decision in plain language - the sources consulted, the versions resolved,
+each gate's verdict and why commands did or didn't run. With --simulate, a cycle is evaluated now without side effects.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if explainSimulate {
+			report.Explain(os.Stdout, simulate(cmd.Context(), explainNow))
+			return
+		}
+
+		st, err := state.NewStore(loadedConfig.State.File).Load()
+		if err != nil {
+			log.Fatal("failed to load state", "error", err)
+		}
+		if st.LastReport == nil {
+			log.Fatal("no sync decision recorded yet - run a sync first or use --simulate", "state_file", loadedConfig.State.File)
+		}
+
+		report.Explain(os.Stdout, st.LastReport)
+	},
+}
+
+// simulate evaluates a sync cycle without side effects, at the --now time when set, and returns its decision report
+func simulate(ctx context.Context, nowValue string) *report.Report {
+	clk, err := clockFromNowFlag(nowValue)
+	if err != nil {
+		log.Fatal("failed to parse --now", "error", err)
+	}
+
+	m, err := manager.New(manager.Options{Config: loadedConfig, Clock: clk})
+	if err != nil {
+		log.Fatal("failed to create sync manager", "error", err)
+	}
+
+	// a failed simulation still has a report worth explaining
+	rep, _ := m.Simulate(ctx)
+	return rep
+}
+
+func init() {
+	explainCmd.Flags().BoolVar(&explainSimulate, "simulate", false, "Evaluate a sync cycle now without running commands, requesting failovers, notifying or updating state")
+	explainCmd.Flags().StringVar(&explainNow, "now", "", "With --simulate, evaluate as if it were this time (RFC3339 or local \"2006-01-02 15:04\")")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+	"github.com/sol-strategies/doublezero-version-sync/internal/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterInterval      time.Duration
+	exporterListenAddress string
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Observe versions, validator identity and health on an interval and serve them as Prometheus metrics",
+	Long: `Run only the observation side of a sync - installed version, recommended version, validator identity and health,
+daemon status - on an interval and serve the results as Prometheus metrics. Sync commands, failover requests,
+notifications and state updates are never run, for monitoring-only deployments with minimal privileges.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exporterInterval <= 0 {
+			log.Fatal("--interval must be > 0")
+		}
+
+		e, err := exporter.New(exporter.Options{Config: loadedConfig})
+		if err != nil {
+			log.Fatal("failed to create exporter", "error", err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", e.Handler())
+		go func() {
+			log.Info("serving metrics", "address", exporterListenAddress, "path", "/metrics")
+			if err := http.ListenAndServe(exporterListenAddress, mux); err != nil {
+				log.Fatal("failed to serve metrics", "error", err)
+			}
+		}()
+
+		e.Run(cmd.Context(), exporterInterval)
+	},
+}
+
+func init() {
+	exporterCmd.Flags().VarP(duration.NewValue(time.Minute, &exporterInterval), "interval", "i", "How often to observe (e.g., 1m, 30s, 1h)")
+	exporterCmd.Flags().StringVar(&exporterListenAddress, "listen-address", ":9841", "Address to serve metrics on at /metrics")
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fetchVersionCluster string
+	fetchVersionSource  string
+	fetchVersionOutput  string
+)
+
+// fetchedVersion is the fetch-version output
+type fetchedVersion struct {
+	Cluster           string    `json:"cluster"`
+	Version           string    `json:"version"`
+	PackageVersion    string    `json:"package_version"`
+	RPMPackageVersion string    `json:"rpm_package_version,omitempty"`
+	Source            string    `json:"source"`
+	URL               string    `json:"url"`
+	FetchedAt         time.Time `json:"fetched_at"`
+	PublishedAt       time.Time `json:"published_at,omitzero"`
+}
+
+var fetchVersionCmd = &cobra.Command{
+	Use:   "fetch-version",
+	Short: "Print the recommended DoubleZero version",
+	Long: `Fetch and print the recommended DoubleZero version for a cluster, in its core (0.7.1) and package (0.7.1-1) forms,
+without touching the installed version, state or notifications. The cluster and version source come from the config
+file when it exists, overridden by --cluster and --source - without a config file --cluster is required.
+
+The text output is key=value lines that can be eval'd by shell scripts, --output json prints a JSON object.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	// the config file is optional here so scripts can use the version resolution on its own
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		resolvedConfigFile := resolveConfigFile()
+		_, err := os.Stat(resolvedConfigFile)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) || cmd.Flags().Changed("config") {
+			loadConfig(resolvedConfigFile, configOverrides(cmd))
+			return
+		}
+
+		logConfig := config.Log{Level: "info", Format: "text"}
+		if err := logConfig.Validate(); err != nil {
+			log.Fatal("failed to configure logging", "error", err)
+		}
+		logConfig.ConfigureWithLevelString(logLevel)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(constants.ValidOutputFormats, fetchVersionOutput) {
+			log.Fatal("--output must be one of " + strings.Join(constants.ValidOutputFormats, ", "))
+		}
+
+		cluster, sourceConfig, err := fetchVersionSourceConfig()
+		if err != nil {
+			log.Fatal("invalid version source", "error", err)
+		}
+
+		source, err := versionsource.NewFromConfig(cluster, sourceConfig, versionsource.Options{})
+		if err != nil {
+			log.Fatal("failed to create version source", "error", err)
+		}
+
+		recommendation, err := source.GetRecommendation(cmd.Context())
+		if err != nil {
+			log.Fatal("failed to fetch recommended version", "cluster", cluster, "error", err)
+		}
+
+		fetched := fetchedVersion{
+			Cluster:           cluster,
+			Version:           recommendation.Version.Core().String(),
+			PackageVersion:    recommendation.PackageVersion,
+			RPMPackageVersion: recommendation.RPMPackageVersion,
+			Source:            recommendation.Source,
+			URL:               recommendation.URL,
+			FetchedAt:         recommendation.FetchedAt,
+			PublishedAt:       recommendation.PublishedAt,
+		}
+
+		if fetchVersionOutput == constants.OutputFormatJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(fetched); err != nil {
+				log.Fatal("failed to write output", "error", err)
+			}
+			return
+		}
+
+		fmt.Printf("cluster=%s\n", fetched.Cluster)
+		fmt.Printf("version=%s\n", fetched.Version)
+		fmt.Printf("package_version=%s\n", fetched.PackageVersion)
+		fmt.Printf("rpm_package_version=%s\n", fetched.RPMPackageVersion)
+		fmt.Printf("source=%s\n", fetched.Source)
+	},
+}
+
+// fetchVersionSourceConfig returns the cluster and version source to fetch from - the config file's, with --cluster and
+// --source overriding them. A --source type configured in the file (at the top level or in version_source.sources)
+// keeps its settings, otherwise it gets the defaults. The on-disk cache is never used
+func fetchVersionSourceConfig() (string, config.VersionSource, error) {
+	var cluster string
+	var sourceConfig config.VersionSource
+	if loadedConfig != nil {
+		cluster = loadedConfig.Cluster.Name
+		sourceConfig = loadedConfig.VersionSource
+	} else {
+		sourceConfig = config.NewVersionSource(constants.VersionSourceTypeCloudsmithAPI)
+	}
+
+	if fetchVersionCluster != "" {
+		cluster = fetchVersionCluster
+	}
+	if cluster == "" {
+		return "", config.VersionSource{}, fmt.Errorf("--cluster is required without a config file")
+	}
+	if err := constants.ValidateClusterName(cluster); err != nil {
+		return "", config.VersionSource{}, fmt.Errorf("--cluster: %w", err)
+	}
+
+	if fetchVersionSource != "" {
+		configured := []config.VersionSource{sourceConfig}
+		if sourceConfig.IsChain() {
+			configured = sourceConfig.Sources
+		}
+		index := slices.IndexFunc(configured, func(v config.VersionSource) bool { return v.Type == fetchVersionSource })
+		if index >= 0 {
+			sourceConfig = configured[index]
+			sourceConfig.Sources = nil
+		} else {
+			sourceConfig = config.NewVersionSource(fetchVersionSource)
+		}
+	}
+	sourceConfig.Cache = config.VersionSourceCache{}
+
+	if err := sourceConfig.Validate(); err != nil {
+		return "", config.VersionSource{}, err
+	}
+	return cluster, sourceConfig, nil
+}
+
+func init() {
+	fetchVersionCmd.Flags().StringVar(&fetchVersionCluster, "cluster", "", "Cluster to fetch the recommended version for, one of "+strings.Join(constants.ValidClusterNames, ", ")+" - defaults to cluster.name")
+	fetchVersionCmd.Flags().StringVar(&fetchVersionSource, "source", "", "Version source type to fetch from, one of "+strings.Join(constants.ValidVersionSourceTypes, ", ")+" - defaults to version_source")
+	fetchVersionCmd.Flags().StringVarP(&fetchVersionOutput, "output", "o", constants.OutputFormatText, "Output format, one of "+strings.Join(constants.ValidOutputFormats, ", "))
+}
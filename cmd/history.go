@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit  int
+	historyOutput string
+)
+
+// historyDocument is the history --output json document
+type historyDocument struct {
+	LastRecommendation *state.Recommendation `json:"last_recommendation"`
+	LastSync           *state.SyncAttempt    `json:"last_sync"`
+	// History is most recent first
+	History []historyEntry `json:"history"`
+}
+
+// historyEntry is a sync attempt with its duration
+type historyEntry struct {
+	state.SyncAttempt
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the last observed recommendation and the recent sync attempts",
+	Long: `Print the last recommendation observed from the version source and the most recent sync attempts - cycles that
+ran the sync commands - with their timestamp, from and to versions, direction, result, duration and the commands run,
+as recorded in the state file. Printed as a table, or JSON with --output json.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(constants.ValidOutputFormats, historyOutput) {
+			log.Fatal("--output must be one of " + strings.Join(constants.ValidOutputFormats, ", "))
+		}
+
+		st, err := state.NewStore(loadedConfig.State.File).Load()
+		if err != nil {
+			log.Fatal("failed to load state", "error", err)
+		}
+
+		history := slices.Clone(st.History)
+		slices.Reverse(history)
+		if historyLimit > 0 && len(history) > historyLimit {
+			history = history[:historyLimit]
+		}
+
+		if historyOutput == constants.OutputFormatJSON {
+			entries := make([]historyEntry, 0, len(history))
+			for _, attempt := range history {
+				entries = append(entries, historyEntry{SyncAttempt: attempt, DurationSeconds: attempt.Duration().Seconds()})
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(historyDocument{LastRecommendation: st.LastRecommendation, LastSync: st.LastSync, History: entries}); err != nil {
+				log.Fatal("failed to write output", "error", err)
+			}
+			return
+		}
+
+		if st.LastRecommendation != nil {
+			fmt.Printf("Last recommendation: %s from %s, observed %s (first observed %s, %d consecutive cycles)\n",
+				st.LastRecommendation.PackageVersion, st.LastRecommendation.Source,
+				st.LastRecommendation.ObservedAt.Format(time.RFC3339), st.LastRecommendation.FirstObservedAt.Format(time.RFC3339),
+				st.ConsecutiveRecommendations)
+		} else {
+			fmt.Println("Last recommendation: none observed yet")
+		}
+
+		if len(history) == 0 {
+			fmt.Println("No sync attempts recorded yet")
+			return
+		}
+		fmt.Println("Sync attempts, most recent first:")
+		printHistory(history)
+	},
+}
+
+// printHistory prints the sync attempts as a table, with each failed attempt's error on the line below it
+func printHistory(history []state.SyncAttempt) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FINISHED\tFROM -> TO\tDIRECTION\tRESULT\tDURATION\tCOMMANDS")
+	for _, attempt := range history {
+		commands := make([]string, 0, len(attempt.Commands))
+		for _, command := range attempt.Commands {
+			commands = append(commands, command.Name+" ("+command.Status+")")
+		}
+		fmt.Fprintf(w, "%s\t%s -> %s\t%s\t%s\t%s\t%s\n", attempt.FinishedAt.Format(time.RFC3339),
+			attempt.FromVersion, attempt.ToVersion, orDash(attempt.Direction), attempt.Result,
+			attempt.Duration().Round(time.Second), orDash(strings.Join(commands, ", ")))
+		if attempt.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", attempt.Error)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal("failed to write output", "error", err)
+	}
+}
+
+// orDash returns s, or - when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of sync attempts to show, 0 for all recorded")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", constants.OutputFormatText, "Output format, one of "+strings.Join(constants.ValidOutputFormats, ", "))
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/configmigrate"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateConfigDryRun bool
+	migrateConfigList   bool
+	migrateConfigOutput string
+)
+
+var migrateConfigCmd = &cobra.Command{
+	Use:   "migrate-config",
+	Short: "Upgrade the config file to the current config schema",
+	Long: fmt.Sprintf(`Upgrade --config to the current config schema (%d), renaming and restructuring the keys changed since the
+schema_version the file records. The original file is kept next to it as <file>.bak-<timestamp> and the changed lines
+are printed. --dry-run only prints the changes, --list prints every config schema change - with --output json as a
+machine-readable changelog of the config surface.`, config.SchemaVersion),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	// the config file may not load before it's migrated
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		logConfig := config.Log{Level: "info", Format: "text"}
+		if err := logConfig.Validate(); err != nil {
+			log.Fatal("failed to configure logging", "error", err)
+		}
+		logConfig.ConfigureWithLevelString(logLevel)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(constants.ValidOutputFormats, migrateConfigOutput) {
+			log.Fatal("--output must be one of " + strings.Join(constants.ValidOutputFormats, ", "))
+		}
+
+		if migrateConfigList {
+			printMigrations()
+			return
+		}
+
+		resolvedConfigFile := resolveConfigFile()
+		contents, err := os.ReadFile(resolvedConfigFile)
+		if err != nil {
+			log.Fatal("failed to read config file", "error", err)
+		}
+
+		result, err := configmigrate.Migrate(resolvedConfigFile, contents)
+		if err != nil {
+			log.Fatal("failed to migrate config file", "file", resolvedConfigFile, "error", err)
+		}
+		if !result.Changed() {
+			log.Info("config file is on the current schema", "file", resolvedConfigFile, "schema_version", result.ToVersion)
+			return
+		}
+
+		for _, migration := range result.Applied {
+			log.Info("migrating", "schema_version", migration.Version, "description", migration.Description)
+		}
+		for _, line := range sync_commands.DiffLines(splitLines(result.Original), splitLines(result.Migrated)) {
+			if !strings.HasPrefix(line, "  ") {
+				fmt.Println(line)
+			}
+		}
+
+		if migrateConfigDryRun {
+			log.Info("dry run - config file not written", "file", resolvedConfigFile, "from", result.FromVersion, "to", result.ToVersion)
+			return
+		}
+
+		backupFile := resolvedConfigFile + ".bak-" + time.Now().UTC().Format("20060102T150405Z")
+		if err := os.WriteFile(backupFile, result.Original, 0o644); err != nil {
+			log.Fatal("failed to back up config file", "error", err)
+		}
+		if err := writeFileAtomic(resolvedConfigFile, result.Migrated); err != nil {
+			log.Fatal("failed to write migrated config file", "error", err)
+		}
+
+		log.Info("config file migrated", "file", resolvedConfigFile, "backup", backupFile, "from", result.FromVersion, "to", result.ToVersion)
+	},
+}
+
+// printMigrations prints every config schema change in the --output format
+func printMigrations() {
+	if migrateConfigOutput == constants.OutputFormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(configmigrate.Migrations()); err != nil {
+			log.Fatal("failed to write output", "error", err)
+		}
+		return
+	}
+
+	for _, migration := range configmigrate.Migrations() {
+		fmt.Printf("%d: %s\n", migration.Version, migration.Description)
+		for _, change := range migration.Changes {
+			fmt.Printf("   %s\n", change)
+		}
+	}
+}
+
+// splitLines splits file contents into lines, without the trailing newline
+func splitLines(contents []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+}
+
+// writeFileAtomic replaces file with contents, keeping its permissions
+func writeFileAtomic(file string, contents []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func init() {
+	migrateConfigCmd.Flags().BoolVar(&migrateConfigDryRun, "dry-run", false, "Print the changes without writing the config file")
+	migrateConfigCmd.Flags().BoolVar(&migrateConfigList, "list", false, "Print every config schema change instead of migrating")
+	migrateConfigCmd.Flags().StringVarP(&migrateConfigOutput, "output", "o", constants.OutputFormatText, "Output format of --list, one of "+strings.Join(constants.ValidOutputFormats, ", "))
+}
@@ -13,13 +13,15 @@ import (
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/file"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
 )
 
 // Config represents the mock validator server configuration
 type Config struct {
-	Port     int    `koanf:"port"`
-	Identity string `koanf:"identity_file"`
-	Health   Health `koanf:"health"`
+	Port        int    `koanf:"port"`
+	Identity    string `koanf:"identity_file"`
+	GenesisHash string `koanf:"genesis_hash"` // returned by getGenesisHash, defaults to the mainnet-beta genesis hash
+	Health      Health `koanf:"health"`
 }
 
 // Health represents the health check configuration
@@ -127,6 +129,16 @@ func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Handle getGenesisHash method
+	if req.Method == "getGenesisHash" {
+		genesisHash := s.config.GenesisHash
+		if genesisHash == "" {
+			genesisHash = constants.GenesisHashMainnetBeta
+		}
+		s.sendJSON(w, JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: genesisHash})
+		return
+	}
+
 	// Unknown method
 	s.sendRPCError(w, req.ID, -32601, fmt.Sprintf("Method not found: %s", req.Method))
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+// nowLayouts are the accepted --now formats, times without a zone are local
+var nowLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// clockFromNowFlag returns the system clock, or a clock running from the --now value when set
+func clockFromNowFlag(value string) (clock.Clock, error) {
+	if value == "" {
+		return clock.Real{}, nil
+	}
+
+	for _, layout := range nowLayouts {
+		now, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return clock.NewOffset(now), nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid --now %q - use RFC3339 (2025-01-05T02:00:00Z) or local time (2025-01-05 02:00)", value)
+}
@@ -9,8 +9,12 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/sol-strategies/doublezero-version-sync/internal/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// configKeyAnnotation annotates a flag with the config key it overrides, e.g. cluster.name
+const configKeyAnnotation = "config_key"
+
 //go:embed version.txt
 var versionFile string
 
@@ -31,25 +35,42 @@ It monitors the installed DoubleZero version and syncs it with the recommended v
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Resolve config file path (handle tilde expansion)
-		resolvedConfigFile := configFile
-		if strings.HasPrefix(configFile, "~/") {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				log.Fatal("failed to get user home directory", "error", err)
-			}
-			resolvedConfigFile = filepath.Join(homeDir, configFile[2:])
-		}
+		loadConfig(resolveConfigFile(), configOverrides(cmd))
+	},
+}
 
-		// Load configuration
-		var err error
-		loadedConfig, err = config.NewFromConfigFile(resolvedConfigFile)
-		if err != nil {
-			log.Fatal("failed to load configuration", "error", err)
+// resolveConfigFile returns the --config file path with a leading ~/ expanded to the user home directory
+func resolveConfigFile() string {
+	if !strings.HasPrefix(configFile, "~/") {
+		return configFile
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatal("failed to get user home directory", "error", err)
+	}
+	return filepath.Join(homeDir, configFile[2:])
+}
+
+// configOverrides returns the config values by key set with the command's flags annotated with the key they override
+func configOverrides(cmd *cobra.Command) map[string]string {
+	overrides := map[string]string{}
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if keys := flag.Annotations[configKeyAnnotation]; flag.Changed && len(keys) > 0 {
+			overrides[keys[0]] = flag.Value.String()
 		}
+	})
+	return overrides
+}
 
-		loadedConfig.Log.ConfigureWithLevelString(logLevel)
-	},
+// loadConfig loads the configuration from the given file, with the overrides taking precedence, and configures logging
+func loadConfig(resolvedConfigFile string, overrides map[string]string) {
+	var err error
+	loadedConfig, err = config.NewFromConfigFileWithOverrides(resolvedConfigFile, overrides)
+	if err != nil {
+		log.Fatal("failed to load configuration", "error", err)
+	}
+
+	loadedConfig.Log.ConfigureWithLevelString(logLevel)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -64,5 +85,22 @@ func init() {
 
 	// Add subcommands here
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(simulateCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(exporterCmd)
+	rootCmd.AddCommand(skipVersionCmd)
+	rootCmd.AddCommand(ackActiveCmd)
+	rootCmd.AddCommand(fetchVersionCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(templatesCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(cleanupCmd)
+	rootCmd.AddCommand(upgradePathCmd)
+	rootCmd.AddCommand(migrateConfigCmd)
 }
 
@@ -1,14 +1,55 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/fleet"
 	"github.com/sol-strategies/doublezero-version-sync/internal/manager"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var onIntervalDuration time.Duration
+// intervalEnv is the environment variable setting --on-interval when the flag isn't given
+const intervalEnv = config.EnvPrefix + "INTERVAL"
+
+var (
+	onIntervalDuration   time.Duration
+	noCache              bool
+	dryRun               bool
+	metricsAddress       string
+	runCluster           string
+	runBin               string
+	runValidatorRPCURL   string
+	runEnabledWhenActive bool
+	runOutput            string
+	runWaitLock          time.Duration
+)
+
+// runResultDocument is the run --output json document printed on stdout when a single run finishes
+type runResultDocument struct {
+	*report.Report
+	// TargetVersion is the package version the cycle targeted, empty without a recommendation
+	TargetVersion string `json:"target_version,omitempty"`
+	// DurationSeconds is how long the cycle took
+	DurationSeconds float64 `json:"duration_seconds"`
+	// ExitCode is the exit code the run exits with
+	ExitCode int `json:"exit_code"`
+}
 
 var runCmd = &cobra.Command{
 	Use:           "run",
@@ -19,15 +60,99 @@ var runCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 
+		if !slices.Contains(constants.ValidOutputFormats, runOutput) {
+			log.Fatal("--output must be one of " + strings.Join(constants.ValidOutputFormats, ", "))
+		}
+
+		if value, ok := os.LookupEnv(intervalEnv); ok && !cmd.Flags().Changed("on-interval") {
+			onIntervalDuration, err = duration.Parse(value)
+			if err != nil {
+				log.Fatal("invalid "+intervalEnv, "error", err)
+			}
+		}
+
+		if noCache {
+			loadedConfig.VersionSource.Cache.TTL = 0
+		}
+		if dryRun {
+			loadedConfig.Sync.DryRun = true
+		}
+
+		// the daemon shares the host with the validator, keep within the configured resource limits
+		loadedConfig.Runtime.Apply()
+		log.Debug("applied runtime limits", "gomaxprocs", runtime.GOMAXPROCS(0), "gc_percent", loadedConfig.Runtime.GCPercent,
+			"memory_limit", loadedConfig.Runtime.MemoryLimit, "watchdog_wedged_after", loadedConfig.Runtime.Watchdog.WedgedAfter)
+
 		m, err := manager.NewFromConfig(loadedConfig)
 		if err != nil {
 			log.Fatal("failed to create sync manager", "error", err)
 		}
 
+		// SIGINT and SIGTERM stop the sync, a running sync command gets runtime.shutdown_grace_period to finish - a
+		// second signal exits right away
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		context.AfterFunc(ctx, func() {
+			stop()
+			log.Warn("shutdown requested - stopping the sync, signal again to exit immediately", "grace_period", loadedConfig.Runtime.ShutdownGracePeriod.String())
+		})
+
+		if onIntervalDuration != 0 && runOutput != constants.OutputFormatText {
+			log.Fatal("--output is only supported for a single run, without --on-interval")
+		}
+
 		if onIntervalDuration != 0 {
-			err = m.RunOnInterval(onIntervalDuration)
+			m.ReloadOnSignal(syscall.SIGHUP)
+			if metricsAddress != "" {
+				mux := http.NewServeMux()
+				mux.Handle("/metrics", m.Handler())
+				mux.Handle("/healthz", m.HealthHandler())
+				mux.Handle("/readyz", m.ReadyHandler())
+				mux.Handle("/status", m.StatusPageHandler())
+				go func() {
+					log.Info("serving metrics, health and status", "address", metricsAddress, "paths", []string{"/metrics", "/healthz", "/readyz", "/status"})
+					if err := http.ListenAndServe(metricsAddress, mux); err != nil {
+						log.Fatal("failed to serve metrics", "error", err)
+					}
+				}()
+			}
+			if beaconHandler := m.FleetBeaconHandler(); beaconHandler != nil {
+				mux := http.NewServeMux()
+				mux.Handle(fleet.BeaconPath, beaconHandler)
+				go func() {
+					log.Info("serving fleet beacon", "address", loadedConfig.Fleet.ListenAddress, "path", fleet.BeaconPath)
+					if err := http.ListenAndServe(loadedConfig.Fleet.ListenAddress, mux); err != nil {
+						log.Fatal("failed to serve fleet beacon", "error", err)
+					}
+				}()
+			}
+			err = m.RunOnInterval(ctx, onIntervalDuration)
 		} else {
-			err = m.RunOnce()
+			// a single run exits with the reason code's exit code when the cycle ends in an error
+			var lastReport *report.Report
+			m.OnEvent(func(event events.Event) {
+				if event.Type == events.CycleFinished && event.Report != nil {
+					lastReport = event.Report
+				}
+			})
+			startedAt := time.Now()
+			err = m.RunOnce(ctx)
+
+			exitCode := 0
+			if err != nil {
+				reason := ""
+				if lastReport != nil {
+					reason = lastReport.Reason
+				}
+				log.Error("failed to run sync manager", "error", err, "reason", reason)
+				exitCode = report.ReasonExitCode(reason)
+			}
+			if runOutput == constants.OutputFormatJSON {
+				printRunResult(lastReport, startedAt, err, exitCode)
+			}
+			if err != nil {
+				os.Exit(exitCode)
+			}
 		}
 
 		if err != nil {
@@ -36,7 +161,50 @@ var runCmd = &cobra.Command{
 	},
 }
 
-func init() {
-	runCmd.Flags().DurationVarP(&onIntervalDuration, "on-interval", "i", 0, "Run continuously at the specified interval (e.g., 1m, 30s, 1h). If not specified, runs once and exits.")
+// printRunResult prints the result of a single run as JSON on stdout, keeping the logs on stderr - the decision report
+// of its cycle, or a failed report when it ended before a cycle ran
+func printRunResult(rep *report.Report, startedAt time.Time, err error, exitCode int) {
+	if rep == nil {
+		rep = report.New(loadedConfig.Cluster.Name, loadedConfig.Sync.DryRun, startedAt)
+		rep.Finish(time.Now(), report.OutcomeFailed, err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	document := runResultDocument{
+		Report:          rep,
+		TargetVersion:   rep.TargetVersion(),
+		DurationSeconds: rep.FinishedAt.Sub(rep.StartedAt).Seconds(),
+		ExitCode:        exitCode,
+	}
+	if err := encoder.Encode(document); err != nil {
+		log.Fatal("failed to write output", "error", err)
+	}
 }
 
+func init() {
+	runCmd.Flags().VarP(duration.NewValue(0, &onIntervalDuration), "on-interval", "i", "Run continuously at the specified interval (e.g., 1m, 30s, 1h, 1d), also accepted as --interval and "+intervalEnv+". If not specified, runs once and exits.")
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Evaluate every check and render every command without executing anything, same as sync.dry_run")
+	runCmd.Flags().StringVar(&metricsAddress, "metrics-listen-address", "", "Address to serve sync cycle metrics on at /metrics, liveness and readiness at /healthz and /readyz and an HTML status page at /status when running on an interval, e.g. :9842 (disabled by default)")
+	runCmd.Flags().StringVarP(&runOutput, "output", "o", constants.OutputFormatText, "Output format of a single run, one of "+strings.Join(constants.ValidOutputFormats, ", ")+" - json prints the cycle's result document on stdout when it finishes, logs stay on stderr")
+	runCmd.Flags().BoolVar(&noCache, "no-cache", false, "Always fetch the recommended version live, ignoring version_source.cache")
+
+	// config value overrides, taking precedence over their environment variables and the config file
+	runCmd.Flags().StringVar(&runCluster, "cluster", "", "Cluster to sync for, overrides cluster.name and "+config.EnvPrefix+"CLUSTER")
+	runCmd.Flags().StringVar(&runBin, "bin", "", "DoubleZero binary, overrides doublezero.bin and "+config.EnvPrefix+"BIN")
+	runCmd.Flags().StringVar(&runValidatorRPCURL, "validator-rpc-url", "", "Validator RPC URL, overrides validator.rpc_url and "+config.EnvPrefix+"VALIDATOR_RPC_URL")
+	runCmd.Flags().BoolVar(&runEnabledWhenActive, "enabled-when-active", false, "Allow syncing while the validator runs as its active identity, overrides validator.enabled_when_active and "+config.EnvPrefix+"ENABLED_WHEN_ACTIVE")
+	runCmd.Flags().Var(duration.NewValue(0, &runWaitLock), "wait-lock", "How long to wait for another process holding the sync lock file before the cycle is blocked, e.g. 10m - fails fast by default, overrides sync.lock.wait and "+config.EnvPrefix+"WAIT_LOCK")
+	runCmd.Flags().SetAnnotation("cluster", configKeyAnnotation, []string{"cluster.name"})
+	runCmd.Flags().SetAnnotation("bin", configKeyAnnotation, []string{"doublezero.bin"})
+	runCmd.Flags().SetAnnotation("validator-rpc-url", configKeyAnnotation, []string{"validator.rpc_url"})
+	runCmd.Flags().SetAnnotation("enabled-when-active", configKeyAnnotation, []string{"validator.enabled_when_active"})
+	runCmd.Flags().SetAnnotation("wait-lock", configKeyAnnotation, []string{"sync.lock.wait"})
+
+	// --interval is accepted for --on-interval, which also defaults to the environment variable
+	runCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "interval" {
+			name = "on-interval"
+		}
+		return pflag.NormalizedName(name)
+	})
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schedulePreviewInterval time.Duration
+	schedulePreviewCount    int
+	schedulePreviewNow      string
+	schedulePreviewAnchor   string
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Inspect the sync schedule",
+}
+
+var schedulePreviewCmd = &cobra.Command{
+	Use:           "preview",
+	Short:         "Preview the upcoming sync times when running on an interval",
+	Long:          `Print the upcoming sync times for run --on-interval, aligned to sync.anchor unless --anchor is set, and which of sync.windows they fall inside. Use --now to preview from another time.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		clk, err := clockFromNowFlag(schedulePreviewNow)
+		if err != nil {
+			log.Fatal("failed to parse --now", "error", err)
+		}
+		if schedulePreviewInterval <= 0 {
+			log.Fatal("--interval must be > 0")
+		}
+
+		anchor := loadedConfig.Sync.Anchor
+		if schedulePreviewAnchor != "" {
+			anchor = schedulePreviewAnchor
+		}
+		if err := constants.ValidateSyncAnchor(anchor); err != nil {
+			log.Fatal("invalid --anchor", "error", err)
+		}
+
+		windows, err := maintenance.NewSchedule(loadedConfig.Sync.Windows)
+		if err != nil {
+			log.Fatal("invalid sync.windows", "error", err)
+		}
+		var adjustTo *maintenance.Schedule
+		if loadedConfig.Sync.UnreachableWindows == constants.SyncUnreachableWindowsAdjust {
+			adjustTo = windows
+		}
+
+		// boundaries are computed in UTC like run --on-interval does, then shown in local time
+		now := clk.Now()
+		fmt.Printf("Upcoming syncs every %s anchored to %s from %s:\n", schedulePreviewInterval, anchor, now.Format(time.RFC3339))
+		for i, run := range manager.NextRuns(anchor, now.UTC(), schedulePreviewInterval, schedulePreviewCount, adjustTo) {
+			line := fmt.Sprintf("  %d. %s (in %s)", i+1, run.Local().Format("Mon 2006-01-02 15:04:05 MST"), run.Sub(now).Round(time.Second))
+			if name, open := windows.Open(run); windows.IsEnabled() && open {
+				line += " in window " + name
+			}
+			fmt.Println(line)
+		}
+
+		if !windows.IsEnabled() {
+			return
+		}
+		if adjustTo != nil {
+			fmt.Println("A cycle also runs when each of sync.windows opens (sync.unreachable_windows: adjust)")
+			return
+		}
+		reach := manager.CheckWindows(windows, anchor, now.UTC(), schedulePreviewInterval)
+		if err := reach.Err(anchor, schedulePreviewInterval); err != nil {
+			log.Fatal("run --on-interval would refuse to start", "error", err)
+		}
+		if len(reach.Unreached) > 0 {
+			log.Warn("no cycle ever falls inside some of sync.windows - changes are only applied in the others", "unreached", reach.Unreached, "reached", reach.Reached)
+		}
+	},
+}
+
+func init() {
+	schedulePreviewCmd.Flags().VarP(duration.NewValue(0, &schedulePreviewInterval), "interval", "i", "Sync interval to preview, as passed to run --on-interval (e.g., 1m, 30s, 1h, 1d)")
+	schedulePreviewCmd.Flags().IntVarP(&schedulePreviewCount, "count", "n", 5, "Number of upcoming syncs to show")
+	schedulePreviewCmd.Flags().StringVar(&schedulePreviewNow, "now", "", "Preview as if it were this time (RFC3339 or local \"2006-01-02 15:04\")")
+	schedulePreviewCmd.Flags().StringVar(&schedulePreviewAnchor, "anchor", "", "Anchor to preview - one of midnight, startup, epoch or a daily HH:MM time (UTC), defaults to sync.anchor")
+	schedulePreviewCmd.MarkFlagRequired("interval")
+
+	scheduleCmd.AddCommand(schedulePreviewCmd)
+}
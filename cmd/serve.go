@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionserver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveVersionsInterval      time.Duration
+	serveVersionsListenAddress string
+	serveVersionsClusters      []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a service for other hosts and tools",
+}
+
+var serveVersionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "Resolve the recommended versions of every cluster on an interval and serve them as JSON",
+	Long: `Resolve the recommended version of every cluster from version_source on an interval and serve them as JSON at
+/versions (all clusters) and /versions/<cluster>, with Cache-Control, ETag and Last-Modified headers expiring at the
+next resolution. Run one internal resolver and point a fleet's http_json version source at it. A cluster that fails to
+resolve keeps serving its last resolved version alongside the error.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if serveVersionsInterval <= 0 {
+			log.Fatal("--interval must be > 0")
+		}
+
+		s, err := versionserver.New(versionserver.Options{
+			VersionSourceConfig: loadedConfig.VersionSource,
+			Clusters:            serveVersionsClusters,
+			Interval:            serveVersionsInterval,
+		})
+		if err != nil {
+			log.Fatal("failed to create version server", "error", err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(versionserver.PathPrefix, s.Handler())
+		mux.Handle(versionserver.PathPrefix+"/", s.Handler())
+		go func() {
+			log.Info("serving versions", "address", serveVersionsListenAddress, "path", versionserver.PathPrefix)
+			if err := http.ListenAndServe(serveVersionsListenAddress, mux); err != nil {
+				log.Fatal("failed to serve versions", "error", err)
+			}
+		}()
+
+		s.Run(cmd.Context())
+	},
+}
+
+func init() {
+	serveVersionsCmd.Flags().VarP(duration.NewValue(5*time.Minute, &serveVersionsInterval), "interval", "i", "How often to resolve the versions, also how long clients may cache them (e.g., 1m, 30s, 1h)")
+	serveVersionsCmd.Flags().StringVar(&serveVersionsListenAddress, "listen-address", ":9842", "Address to serve the versions on at /versions")
+	serveVersionsCmd.Flags().StringSliceVar(&serveVersionsClusters, "cluster", nil, "Clusters to resolve, repeatable - defaults to every cluster")
+
+	serveCmd.AddCommand(serveVersionsCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var simulateNow string
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Evaluate a sync cycle without side effects and explain the decision",
+	Long: `Evaluate a sync cycle without running commands, requesting failovers, notifying or updating state, and walk
+through the decision in plain language. Use --now to ask what would happen at another time. Same as explain --simulate.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		report.Explain(os.Stdout, simulate(cmd.Context(), simulateNow))
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateNow, "now", "", "Evaluate as if it were this time (RFC3339 or local \"2006-01-02 15:04\")")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	skipVersionReason string
+	skipVersionUndo   bool
+)
+
+var skipVersionCmd = &cobra.Command{
+	Use:   "skip-version VERSION",
+	Short: "Don't sync to a version until a newer one is recommended",
+	Long: `Record a persistent skip of VERSION in the state file - the syncer won't act on it as a target until a newer version
+is recommended, and the skip is shown by explain and notified the first time it prevents a sync. A version without a
+release (e.g. 0.8.1) skips every release of it. Use --undo to remove a skip.`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		skippedVersion := args[0]
+		if _, err := goversion.NewVersion(skippedVersion); err != nil {
+			log.Fatal("invalid version", "version", skippedVersion, "error", err)
+		}
+
+		store := state.NewStore(loadedConfig.State.File)
+		err := store.Update(func(st *state.State) error {
+			if skipVersionUndo {
+				if !st.Unskip(skippedVersion) {
+					return fmt.Errorf("version %s is not skipped", skippedVersion)
+				}
+				return nil
+			}
+
+			st.Skip(state.SkippedVersion{
+				Version:   skippedVersion,
+				Reason:    skipVersionReason,
+				SkippedAt: time.Now().UTC(),
+			})
+			return nil
+		})
+		if err != nil {
+			log.Fatal("failed to update skipped versions", "state_file", store.Path(), "error", err)
+		}
+
+		if skipVersionUndo {
+			log.Info("removed version skip", "version", skippedVersion)
+		} else {
+			log.Info("skipping version until a newer one is recommended", "version", skippedVersion, "reason", skipVersionReason)
+		}
+	},
+}
+
+func init() {
+	skipVersionCmd.Flags().StringVar(&skipVersionReason, "reason", "", "Why the version is skipped, shown in explain and notifications")
+	skipVersionCmd.Flags().BoolVar(&skipVersionUndo, "undo", false, "Remove the skip of VERSION instead of recording one")
+}
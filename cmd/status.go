@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var statusOutput string
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the installed and recommended versions, validator identity and last sync",
+	Long: `Print the installed DoubleZero version, the recommended version for the configured cluster, the
+doublezero.version_constraint evaluation, the validator identity state and the last sync result from the state file.
+Nothing is executed, notified or recorded.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(constants.ValidOutputFormats, statusOutput) {
+			log.Fatal("--output must be one of " + strings.Join(constants.ValidOutputFormats, ", "))
+		}
+
+		m, err := manager.New(manager.Options{Config: loadedConfig})
+		if err != nil {
+			log.Fatal("failed to create sync manager", "error", err)
+		}
+		status := m.Status(cmd.Context())
+
+		if statusOutput == constants.OutputFormatJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(status); err != nil {
+				log.Fatal("failed to write output", "error", err)
+			}
+			return
+		}
+
+		printStatus(status)
+	},
+}
+
+// printStatus prints the status as aligned label: value lines
+func printStatus(status *doublezero.Status) {
+	line := func(label, value string) {
+		fmt.Printf("%-20s %s\n", label+":", value)
+	}
+	orError := func(value, errorMessage string) string {
+		if errorMessage != "" {
+			return "unknown - " + errorMessage
+		}
+		return value
+	}
+
+	line("Cluster", status.Cluster)
+	line("Installed version", orError(status.InstalledVersion, status.InstalledError))
+
+	recommended := ""
+	if status.Recommendation != nil {
+		recommended = fmt.Sprintf("%s (package %s from %s)", status.Recommendation.Version,
+			status.Recommendation.PackageVersion, status.Recommendation.Source)
+	}
+	line("Recommended version", orError(recommended, status.RecommendationError))
+	if status.Direction != "" {
+		line("Sync", status.Direction)
+	}
+
+	if status.VersionConstraint != nil {
+		satisfied := "satisfied"
+		if !status.VersionConstraint.Satisfied {
+			satisfied = "not satisfied - the recommended version would be blocked"
+		}
+		line("Version constraint", status.VersionConstraint.Constraint+" "+satisfied)
+	} else {
+		line("Version constraint", "none")
+	}
+
+	switch {
+	case status.FreezeError != "":
+		line("Change freeze", "unknown - "+status.FreezeError)
+	case status.Freeze != nil:
+		line("Change freeze", fmt.Sprintf("%s until %s - version changes deferred", status.Freeze.Name, status.Freeze.End.UTC().Format(time.RFC3339)))
+	}
+
+	if status.RepoLag != nil {
+		line("Repository lag", fmt.Sprintf("%s not in the %s repository since %s (%s) - version change pending", status.RepoLag.PackageVersion,
+			status.RepoLag.Repository, status.RepoLag.Since.UTC().Format(time.RFC3339), time.Since(status.RepoLag.Since).Round(time.Second)))
+	}
+
+	if status.Validator != nil {
+		validator := status.Validator.Identity
+		if status.Validator.Role != "" {
+			validator += " (" + status.Validator.Role + ")"
+		}
+		if status.Validator.IdentitySource != constants.ValidatorIdentitySourceRPC {
+			validator += " from " + status.Validator.IdentitySource
+		}
+		line("Validator identity", orError(validator, status.Validator.Error))
+	} else {
+		line("Validator identity", "no validator configured")
+	}
+
+	switch {
+	case status.StateError != "":
+		line("Last sync", "unknown - "+status.StateError)
+	case status.LastSync == nil:
+		line("Last sync", "none recorded yet")
+	default:
+		lastSync := fmt.Sprintf("%s %s -> %s at %s", status.LastSync.Result, status.LastSync.FromVersion,
+			status.LastSync.ToVersion, status.LastSync.FinishedAt.Format(time.RFC3339))
+		if status.LastSync.Error != "" {
+			lastSync += " - " + status.LastSync.Error
+		}
+		line("Last sync", lastSync)
+	}
+	if status.LastCycle != nil {
+		lastCycle := status.LastCycle.Outcome
+		if status.LastCycle.Reason != "" {
+			lastCycle += " (" + status.LastCycle.Reason + ")"
+		}
+		lastCycle += " at " + status.LastCycle.FinishedAt.Format(time.RFC3339)
+		if status.LastCycle.Error != "" {
+			lastCycle += " - " + status.LastCycle.Error
+		}
+		line("Last cycle", lastCycle)
+	}
+	if status.PendingApproval != nil {
+		line("Pending approval", fmt.Sprintf("plan %s v%s -> v%s with %d approvals - see approve",
+			status.PendingApproval.PlanID, status.PendingApproval.FromVersion, status.PendingApproval.ToVersion,
+			len(status.PendingApproval.Approvals)))
+	}
+	if status.PendingReboot != nil {
+		line("Pending reboot", fmt.Sprintf("after v%s -> v%s since %s - runs at the next cycle inside sync.windows",
+			status.PendingReboot.FromVersion, status.PendingReboot.ToVersion, status.PendingReboot.RequiredAt.Format(time.RFC3339)))
+	}
+}
+
+func init() {
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", constants.OutputFormatText, "Output format, one of "+strings.Join(constants.ValidOutputFormats, ", "))
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/spf13/cobra"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Inspect the command templates",
+}
+
+var templatesLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the command templates against the template data schema",
+	Long: `Check every field referenced by the sync, failover and reboot command templates against the current template data
+schema. References to deprecated fields, which still render, are warnings. References to unknown or removed fields,
+which fail to render, are errors and exit with status 1. Run it after upgrading to catch fields renamed or removed
+since the config was written.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		type namedCommand struct {
+			path    string
+			command *sync_commands.Command
+		}
+		var commands []namedCommand
+		for i := range loadedConfig.Sync.Commands {
+			commands = append(commands, namedCommand{fmt.Sprintf("sync.commands[%d]", i), &loadedConfig.Sync.Commands[i]})
+		}
+		if loadedConfig.Failover.Command != nil {
+			commands = append(commands, namedCommand{"failover.command", loadedConfig.Failover.Command})
+		}
+		if loadedConfig.Reboot.Command != nil {
+			commands = append(commands, namedCommand{"reboot.command", loadedConfig.Reboot.Command})
+		}
+
+		errorCount, warningCount := 0, 0
+		for _, named := range commands {
+			issues, err := named.command.LintTemplates()
+			if err != nil {
+				fmt.Printf("error    %s (%s): %v\n", named.path, named.command.Name, err)
+				errorCount++
+				continue
+			}
+			for _, issue := range issues {
+				fmt.Printf("%-8s %s (%s) %s\n", issue.Severity, named.path, named.command.Name, issue)
+				if issue.Severity == sync_commands.TemplateIssueError {
+					errorCount++
+				} else {
+					warningCount++
+				}
+			}
+		}
+
+		fmt.Printf("%d commands checked against template data schema v%d: %d errors, %d warnings\n",
+			len(commands), sync_commands.TemplateDataSchemaVersion, errorCount, warningCount)
+		if errorCount > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	templatesCmd.AddCommand(templatesLintCmd)
+}
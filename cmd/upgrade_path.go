@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradePathFrom   string
+	upgradePathTo     string
+	upgradePathOutput string
+)
+
+var upgradePathCmd = &cobra.Command{
+	Use:   "upgrade-path",
+	Short: "Show the upgrade path the syncer would follow and the policies applied to it",
+	Long: `Print the step by step path from the installed version (or --from) to the recommended version (or --to), each
+step with its rendered sync commands, plan id and the evaluation of doublezero.version_constraint,
+doublezero.skip_versions, sync.allow_downgrade, operator skips and sync.approval. The sync commands install the target
+package directly, so a path has one step, or one per hop through doublezero.stepping_stones, none when already on the
+target. Exits 1 when a policy blocks the path.
+Nothing is executed, notified or recorded - see explain for the gates that depend on when a cycle runs.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(constants.ValidOutputFormats, upgradePathOutput) {
+			log.Fatal("--output must be one of " + strings.Join(constants.ValidOutputFormats, ", "))
+		}
+
+		m, err := manager.New(manager.Options{Config: loadedConfig})
+		if err != nil {
+			log.Fatal("failed to create sync manager", "error", err)
+		}
+		path, err := m.UpgradePath(cmd.Context(), upgradePathFrom, upgradePathTo)
+		if err != nil {
+			log.Fatal("failed to compute upgrade path", "error", err)
+		}
+
+		if upgradePathOutput == constants.OutputFormatJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(path); err != nil {
+				log.Fatal("failed to write output", "error", err)
+			}
+		} else {
+			printUpgradePath(path)
+		}
+
+		if !path.Followed {
+			os.Exit(1)
+		}
+	},
+}
+
+// printUpgradePath prints the path, its steps, their policies and commands
+func printUpgradePath(path *doublezero.UpgradePath) {
+	followed := "followed"
+	if !path.Followed {
+		followed = "blocked"
+	}
+	fmt.Printf("Upgrade path on %s: %s -> %s (%s) - %s\n", path.Cluster, path.From, path.To, path.Direction, followed)
+	if len(path.Steps) == 0 {
+		fmt.Println("Already on the target version - no steps")
+		return
+	}
+
+	for i, step := range path.Steps {
+		fmt.Printf("\nStep %d: %s -> %s (%s), plan %s\n", i+1, step.From, step.To, step.Direction, step.PlanID)
+		for _, policy := range step.Policies {
+			verdict := "allowed"
+			if !policy.Allowed {
+				verdict = "BLOCKED"
+			}
+			fmt.Printf("  %-8s %s: %s\n", verdict, policy.Name, policy.Detail)
+		}
+		fmt.Println("  Commands:")
+		for _, command := range step.Commands {
+			fmt.Println("    " + command)
+		}
+	}
+}
+
+func init() {
+	upgradePathCmd.Flags().StringVar(&upgradePathFrom, "from", "", "Version to start from, defaults to the installed version")
+	upgradePathCmd.Flags().StringVar(&upgradePathTo, "to", "", "Version to end at, defaults to the recommended version")
+	upgradePathCmd.Flags().StringVarP(&upgradePathOutput, "output", "o", constants.OutputFormatText, "Output format, one of "+strings.Join(constants.ValidOutputFormats, ", "))
+}
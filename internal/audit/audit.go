@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// KindSync is the kind of a sync command
+	KindSync = "sync"
+	// KindFailover is the kind of the failover command
+	KindFailover = "failover"
+	// KindReboot is the kind of the reboot command
+	KindReboot = "reboot"
+	// KindRefreshRepos is the kind of the sync.refresh_repos package cache refresh
+	KindRefreshRepos = "refresh_repos"
+	// KindRollback is the kind of a sync command re-run to roll a failed sync back
+	KindRollback = "rollback"
+)
+
+// Entry is the audit record of a command executed during a sync
+type Entry struct {
+	Cluster  string `json:"cluster"`
+	Hostname string `json:"hostname"`
+	// Kind is what the command ran for - one of sync, failover, reboot, refresh_repos, rollback
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// CommandLine is the rendered command and its arguments, the environment is left out as it may hold secrets
+	CommandLine string    `json:"command_line"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	// ExitCode is the exit code of the command, -1 when it couldn't be started or was killed by a signal
+	ExitCode int `json:"exit_code"`
+	// Status is the result status of the command - one of executed, allowed_failure, failed
+	Status string `json:"status"`
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+	// StdoutTruncated and StderrTruncated are set when only the end of the output was kept
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+	FromVersion     string `json:"from_version"`
+	ToVersion       string `json:"to_version"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Options represents the options for creating a new audit Log
+type Options struct {
+	// File is the path of the JSONL audit log, created when missing
+	File string
+	// MaxOutputBytes is how much of the end of each command's stdout and stderr is kept
+	MaxOutputBytes int
+}
+
+// Log is an append-only JSONL log of the commands executed during syncs
+type Log struct {
+	file           string
+	maxOutputBytes int
+	hostname       string
+
+	mu sync.Mutex
+}
+
+// New creates a new audit Log
+func New(opts Options) *Log {
+	hostname, _ := os.Hostname()
+	return &Log{
+		file:           opts.File,
+		maxOutputBytes: opts.MaxOutputBytes,
+		hostname:       hostname,
+	}
+}
+
+// Path returns the path of the audit log
+func (l *Log) Path() string {
+	return l.file
+}
+
+// Record appends the entry to the audit log as a single JSON line, synced to disk before returning. The file is
+// opened for each entry, so it can be rotated by renaming it
+func (l *Log) Record(entry Entry) error {
+	entry.Hostname = l.hostname
+	entry.StartedAt = entry.StartedAt.UTC()
+	entry.FinishedAt = entry.FinishedAt.UTC()
+	entry.Stdout, entry.StdoutTruncated = truncate(entry.Stdout, l.maxOutputBytes)
+	entry.Stderr, entry.StderrTruncated = truncate(entry.Stderr, l.maxOutputBytes)
+
+	// command lines are recorded as they ran, without escaping shell redirections as HTML
+	var line bytes.Buffer
+	encoder := json.NewEncoder(&line)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(entry); err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Prune removes the entries that finished before the given time, when not zero, then the oldest entries until the
+// audit log is at most maxBytes, when > 0, returning how many entries were removed and their size. Lines that aren't
+// entries are only removed to fit maxBytes. The audit log is rewritten atomically, unless dryRun only counts. Entries
+// appended by another process while it's rewritten are lost, so hold the sync lock to prune a log a daemon writes to
+func (l *Log) Prune(before time.Time, maxBytes int64, dryRun bool) (int, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	contents, err := os.ReadFile(l.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	var kept [][]byte
+	var keptBytes, removedBytes int64
+	var removedCount int
+	for _, line := range bytes.SplitAfter(contents, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var entry struct {
+			FinishedAt time.Time `json:"finished_at"`
+		}
+		if !before.IsZero() && json.Unmarshal(line, &entry) == nil && !entry.FinishedAt.IsZero() && entry.FinishedAt.Before(before) {
+			removedCount++
+			removedBytes += int64(len(line))
+			continue
+		}
+		kept = append(kept, line)
+		keptBytes += int64(len(line))
+	}
+	for maxBytes > 0 && keptBytes > maxBytes {
+		removedCount++
+		removedBytes += int64(len(kept[0]))
+		keptBytes -= int64(len(kept[0]))
+		kept = kept[1:]
+	}
+	if removedCount == 0 || dryRun {
+		return removedCount, removedBytes, nil
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(l.file), filepath.Base(l.file)+".tmp-*")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create temporary audit log: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(bytes.Join(kept, nil)); err != nil {
+		tmpFile.Close()
+		return 0, 0, fmt.Errorf("failed to write temporary audit log: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return 0, 0, fmt.Errorf("failed to write temporary audit log: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), l.file); err != nil {
+		return 0, 0, fmt.Errorf("failed to write audit log %s: %w", l.file, err)
+	}
+	return removedCount, removedBytes, nil
+}
+
+// truncate returns the last max bytes of output and whether it was cut, the end is where failures are reported
+func truncate(output string, max int) (string, bool) {
+	if len(output) <= max {
+		return output, false
+	}
+	return output[len(output)-max:], true
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAppendsEntries(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	log := New(Options{File: file, MaxOutputBytes: 4})
+
+	startedAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Kind: KindSync, Name: "install", CommandLine: "apt-get install -y doublezero=0.7.1-1", StartedAt: startedAt,
+			FinishedAt: startedAt.Add(time.Second), Status: "executed", Stdout: "ok", FromVersion: "0.6.9", ToVersion: "0.7.1-1"},
+		{Kind: KindReboot, Name: "reboot", CommandLine: "systemctl reboot", StartedAt: startedAt, FinishedAt: startedAt,
+			ExitCode: 1, Status: "failed", Stderr: "permission denied", Error: "exit status 1"},
+	}
+	for _, entry := range entries {
+		if err := log.Record(entry); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	var got []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %q is not a JSON entry: %v", scanner.Text(), err)
+		}
+		got = append(got, entry)
+	}
+
+	if len(got) != len(entries) {
+		t.Fatalf("got %d entries, want %d", len(got), len(entries))
+	}
+	if got[0].Stdout != "ok" || got[0].StdoutTruncated || got[0].ToVersion != "0.7.1-1" {
+		t.Errorf("first entry = %+v, want the untruncated sync command", got[0])
+	}
+	if got[1].Stderr != "nied" || !got[1].StderrTruncated || got[1].ExitCode != 1 {
+		t.Errorf("second entry = %+v, want the end of stderr and exit code 1", got[1])
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("audit log mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        string
+		max           int
+		want          string
+		wantTruncated bool
+	}{
+		{name: "shorter", output: "abc", max: 4, want: "abc"},
+		{name: "exact", output: "abcd", max: 4, want: "abcd"},
+		{name: "longer keeps the end", output: "abcdef", max: 4, want: "cdef", wantTruncated: true},
+		{name: "zero keeps nothing", output: "abc", max: 0, want: "", wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncate(tt.output, tt.max)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("truncate() = %q, %v, want %q, %v", got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	finishedAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Kind: KindSync, Name: "install", FinishedAt: finishedAt, Status: "executed"},
+		{Kind: KindSync, Name: "restart", FinishedAt: finishedAt.Add(24 * time.Hour), Status: "executed"},
+		{Kind: KindReboot, Name: "reboot", FinishedAt: finishedAt.Add(48 * time.Hour), Status: "executed"},
+	}
+
+	tests := []struct {
+		name        string
+		before      time.Time
+		maxEntries  int
+		dryRun      bool
+		wantRemoved int
+		wantKept    []string
+	}{
+		{name: "no limits keep everything", wantKept: []string{"install", "restart", "reboot"}},
+		{name: "entries finished before are removed", before: finishedAt.Add(time.Hour), wantRemoved: 1, wantKept: []string{"restart", "reboot"}},
+		{name: "oldest entries beyond max size are removed", maxEntries: 1, wantRemoved: 2, wantKept: []string{"reboot"}},
+		{name: "age then size", before: finishedAt.Add(time.Hour), maxEntries: 2, wantRemoved: 1, wantKept: []string{"restart", "reboot"}},
+		{name: "dry run only counts", maxEntries: 1, dryRun: true, wantRemoved: 2, wantKept: []string{"install", "restart", "reboot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "audit.jsonl")
+			log := New(Options{File: file, MaxOutputBytes: 4096})
+			for _, entry := range entries {
+				if err := log.Record(entry); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// max size fits exactly the newest maxEntries lines
+			var maxBytes int64
+			if tt.maxEntries > 0 {
+				contents, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				lines := strings.SplitAfter(strings.TrimSpace(string(contents))+"\n", "\n")
+				for _, line := range lines[len(lines)-1-tt.maxEntries:] {
+					maxBytes += int64(len(line))
+				}
+			}
+			removed, removedBytes, err := log.Prune(tt.before, maxBytes, tt.dryRun)
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("Prune() removed %d entries, want %d", removed, tt.wantRemoved)
+			}
+
+			contents, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.dryRun && int64(len(contents)) != info.Size()-removedBytes {
+				t.Errorf("audit log is %d bytes, want %d after removing %d", len(contents), info.Size()-removedBytes, removedBytes)
+			}
+			if maxBytes > 0 && !tt.dryRun && int64(len(contents)) > maxBytes {
+				t.Errorf("audit log is %d bytes, want at most %d", len(contents), maxBytes)
+			}
+			var kept []string
+			for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+				var entry Entry
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("line %q is not a JSON entry: %v", line, err)
+				}
+				kept = append(kept, entry.Name)
+			}
+			if strings.Join(kept, ",") != strings.Join(tt.wantKept, ",") {
+				t.Errorf("kept %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the current time and sleeping so time dependent logic can be tested deterministically
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// Sleep pauses for at least the given duration
+	Sleep(d time.Duration)
+}
+
+// Real is the Clock backed by the system time
+type Real struct{}
+
+// Now returns the current system time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep pauses the current goroutine for the given duration
+func (Real) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Offset is a Clock running at real speed from a chosen start time, used to ask what would happen at another time
+type Offset struct {
+	offset time.Duration
+}
+
+// NewOffset creates a new Offset clock whose current time is now
+func NewOffset(now time.Time) *Offset {
+	return &Offset{offset: time.Until(now)}
+}
+
+// Now returns the system time shifted by the offset
+func (o *Offset) Now() time.Time {
+	return time.Now().Add(o.offset)
+}
+
+// Sleep pauses the current goroutine for the given duration
+func (o *Offset) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Fake is a Clock whose time only moves when advanced - Sleep advances it instantly
+// It is safe for concurrent use
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake creates a new Fake clock set to the given time
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the fake current time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep advances the fake time by the given duration without blocking
+func (f *Fake) Sleep(d time.Duration) {
+	f.Advance(d)
+}
+
+// Advance moves the fake time forward by the given duration
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Set sets the fake time
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// SleepContext pauses on the clock for the given duration, returning early with the context's error once ctx is done
+// The clock's Sleep can't be interrupted, it finishes in the background
+func SleepContext(ctx context.Context, c Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	slept := make(chan struct{})
+	go func() {
+		c.Sleep(d)
+		close(slept)
+	}()
+	select {
+	case <-slept:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,48 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 53, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	c.Sleep(7 * time.Minute)
+	if got, want := c.Now(), start.Add(7*time.Minute); !got.Equal(want) {
+		t.Errorf("Now() after Sleep = %s, want %s", got, want)
+	}
+
+	c.Set(start)
+	c.Advance(time.Second)
+	if got, want := c.Now(), start.Add(time.Second); !got.Equal(want) {
+		t.Errorf("Now() after Set and Advance = %s, want %s", got, want)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	start := time.Date(2025, 1, 5, 2, 0, 0, 0, time.UTC)
+	c := NewOffset(start)
+
+	if got := c.Now().Sub(start); got < 0 || got > time.Second {
+		t.Errorf("Now() = %s, want about %s", c.Now(), start)
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := SleepContext(ctx, Real{}, time.Hour); err != context.Canceled {
+		t.Errorf("SleepContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	c := NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	if err := SleepContext(context.Background(), c, time.Minute); err != nil {
+		t.Errorf("SleepContext() error = %v", err)
+	}
+	if got, want := c.Now(), time.Date(2025, 1, 1, 9, 1, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Now() after SleepContext = %s, want %s", got, want)
+	}
+}
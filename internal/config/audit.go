@@ -0,0 +1,21 @@
+package config
+
+import "fmt"
+
+// Audit represents the audit log of the commands executed during syncs
+type Audit struct {
+	// Enabled appends every sync, failover and reboot command that ran to File, defaults to false
+	Enabled bool `koanf:"enabled"`
+	// File is the path of the JSONL audit log, defaults to audit.jsonl next to the config file
+	File string `koanf:"file"`
+	// MaxOutputBytes is how much of the end of each command's stdout and stderr is recorded, defaults to 4096
+	MaxOutputBytes int `koanf:"max_output_bytes"`
+}
+
+// Validate validates the audit log configuration
+func (a *Audit) Validate() error {
+	if a.MaxOutputBytes < 0 {
+		return fmt.Errorf("audit.max_output_bytes must be >= 0 - got: %d", a.MaxOutputBytes)
+	}
+	return nil
+}
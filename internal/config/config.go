@@ -7,12 +7,15 @@ import (
 
 	"github.com/charmbracelet/log"
 	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/file"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/freeze"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
 )
 
 // Config represents the complete configuration
 type Config struct {
+	// SchemaVersion is the config schema version the file was written for, see migrate-config
+	SchemaVersion int `koanf:"schema_version"`
 	// Log configuration
 	Log Log `koanf:"log"`
 	// Validator is the validator configuration
@@ -21,12 +24,44 @@ type Config struct {
 	Cluster Cluster `koanf:"cluster"`
 	// DoubleZero is the DoubleZero configuration
 	DoubleZero DoubleZero `koanf:"doublezero"`
+	// VersionSource is the recommended version source configuration
+	VersionSource VersionSource `koanf:"version_source"`
 	// Sync is the version sync configuration
 	Sync Sync `koanf:"sync"`
+	// Failover is the external failover integration configuration
+	Failover Failover `koanf:"failover"`
+	// Reboot is the host reboot-required detection configuration
+	Reboot Reboot `koanf:"reboot"`
+	// HostMaintenance is the host-wide maintenance mode integration configuration
+	HostMaintenance HostMaintenance `koanf:"host_maintenance"`
+	// Freeze is the change freeze calendar configuration
+	Freeze Freeze `koanf:"freeze"`
+	// Fleet is the peer exchange configuration of controller-less fleets
+	Fleet Fleet `koanf:"fleet"`
+	// State is the persistent state configuration
+	State State `koanf:"state"`
+	// Notifications is the notifications configuration
+	Notifications Notifications `koanf:"notifications"`
+	// Health is the run --on-interval health and readiness endpoint configuration
+	Health Health `koanf:"health"`
+	// Runtime is the daemon resource usage limits configuration
+	Runtime Runtime `koanf:"runtime"`
+	// Audit is the audit log of executed commands configuration
+	Audit Audit `koanf:"audit"`
+	// Retention is how much of the audit log, config file backups and version source cache cleanup keeps
+	Retention Retention `koanf:"retention"`
+	// ConfigReload is the run --on-interval config reload configuration
+	ConfigReload ConfigReload `koanf:"config_reload"`
+	// Include is a list of file globs of config overlays merged over this file in order, e.g. per-host identities
+	Include []string `koanf:"include"`
 	// File is the file that the config was loaded from
 	File string `koanf:"-"`
+	// IncludedFiles are the files matched by Include, in the order they were merged
+	IncludedFiles []string `koanf:"-"`
 
 	logger *log.Logger
+	// overrides are config values by key, e.g. from command line flags, taking precedence over the environment
+	overrides map[string]string
 }
 
 // New creates a new Config
@@ -39,11 +74,18 @@ func New() (config *Config, err error) {
 
 // NewFromConfigFile creates a new Config from a config file path
 func NewFromConfigFile(configFile string) (*Config, error) {
+	return NewFromConfigFileWithOverrides(configFile, nil)
+}
+
+// NewFromConfigFileWithOverrides creates a new Config from a config file path, with config values by key (e.g.
+// cluster.name) overriding the environment, config file and defaults
+func NewFromConfigFileWithOverrides(configFile string, overrides map[string]string) (*Config, error) {
 	// Create new config
 	cfg, err := New()
 	if err != nil {
 		return nil, err
 	}
+	cfg.overrides = overrides
 
 	// Load from file
 	if err := cfg.LoadFromFile(configFile); err != nil {
@@ -72,29 +114,49 @@ func (c *Config) LoadFromFile(filePath string) error {
 	// Set defaults in koanf first
 	c.setKoanfDefaults(k)
 
-	// Load YAML config file (this will merge with defaults)
-	if err := k.Load(file.Provider(c.File), yaml.Parser()); err != nil {
+	// Read and render the config file with host facts, then load it as YAML, TOML or JSON by file extension (this will
+	// merge with defaults)
+	fileKoanf, err := loadFile(c.File)
+	if err != nil {
 		return fmt.Errorf("error loading config file: %w", err)
 	}
+	if err := k.Merge(fileKoanf); err != nil {
+		return fmt.Errorf("error loading config file: %w", err)
+	}
+
+	// Overlays take precedence over the file
+	if err := c.loadOverlays(k); err != nil {
+		return err
+	}
+
+	// Environment variables then overrides take precedence over the file
+	if err := loadOverrides(k, envOverrides()); err != nil {
+		return fmt.Errorf("error loading config from environment: %w", err)
+	}
+	if err := loadOverrides(k, c.overrides); err != nil {
+		return fmt.Errorf("error loading config overrides: %w", err)
+	}
 
 	// Unmarshal into this config struct
-	if err := k.Unmarshal("", c); err != nil {
+	if err := unmarshal(k, "", c); err != nil {
 		return fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Append commands from included fragments
+	if err := c.Sync.loadIncludes(filepath.Dir(c.File)); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // Initialize processes and validates the loaded configuration
 func (c *Config) Initialize() error {
-	// Load validator identities if RPC URL is configured (identity files are required if RPC URL is set)
-	if c.Validator.RPCURL != "" {
-		if c.Validator.Identities.ActiveKeyPairFile == "" || c.Validator.Identities.PassiveKeyPairFile == "" {
-			return fmt.Errorf("validator.rpc_url is configured but validator.identities.active and validator.identities.passive must be provided")
-		}
-		if err := c.Validator.Identities.Load(); err != nil {
-			return fmt.Errorf("failed to load validator identities: %w", err)
-		}
+	// Check validator identities are configured if RPC URL or another identity source is configured (the active
+	// identity file is required, the passive identity file is optional for single-identity setups without hot-spare failover)
+	// The identity files are loaded by the components that need their public keys
+	if c.Validator.IsEnabled() && !c.Validator.Identities.IsConfigured() {
+		return fmt.Errorf("validator.rpc_url or validator.identity_source is configured but validator.identities.active or active_pubkey must be provided")
 	}
 
 	// Resolve paths to absolute paths
@@ -124,23 +186,110 @@ func (c *Config) resolvePaths() error {
 	}
 
 	// Resolve validator identity paths if configured
-	if c.Validator.RPCURL != "" {
+	if c.Validator.IsEnabled() {
 		if c.Validator.Identities.ActiveKeyPairFile != "" {
-			resolvedActive, err := ResolvePath(c.Validator.Identities.ActiveKeyPairFile, configDir)
+			resolvedActive, err := resolveKeyRef(c.Validator.Identities.ActiveKeyPairFile, configDir)
 			if err != nil {
 				return fmt.Errorf("failed to resolve validator.identities.active path: %w", err)
 			}
 			c.Validator.Identities.ActiveKeyPairFile = resolvedActive
 		}
 		if c.Validator.Identities.PassiveKeyPairFile != "" {
-			resolvedPassive, err := ResolvePath(c.Validator.Identities.PassiveKeyPairFile, configDir)
+			resolvedPassive, err := resolveKeyRef(c.Validator.Identities.PassiveKeyPairFile, configDir)
 			if err != nil {
 				return fmt.Errorf("failed to resolve validator.identities.passive path: %w", err)
 			}
 			c.Validator.Identities.PassiveKeyPairFile = resolvedPassive
 		}
+		if c.Validator.IdentitySource.File != "" {
+			resolvedSource, err := ResolvePath(c.Validator.IdentitySource.File, configDir)
+			if err != nil {
+				return fmt.Errorf("failed to resolve validator.identity_source.file path: %w", err)
+			}
+			c.Validator.IdentitySource.File = resolvedSource
+		}
+	}
+
+	// Resolve the host maintenance flag file if configured
+	if c.HostMaintenance.File != "" {
+		resolvedFlagFile, err := ResolvePath(c.HostMaintenance.File, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve host_maintenance.file path: %w", err)
+		}
+		c.HostMaintenance.File = resolvedFlagFile
+	}
+
+	// Resolve the freeze calendar files, leaving URLs as they are
+	for i, calendar := range c.Freeze.Calendars {
+		if calendar == "" || freeze.IsURL(calendar) {
+			continue
+		}
+		resolvedCalendar, err := ResolvePath(calendar, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve freeze.calendars[%d] path: %w", i, err)
+		}
+		c.Freeze.Calendars[i] = resolvedCalendar
 	}
 
+	// Resolve the fleet shared secret file if configured
+	if c.Fleet.SecretFile != "" {
+		resolvedSecretFile, err := ResolvePath(c.Fleet.SecretFile, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve fleet.secret_file path: %w", err)
+		}
+		c.Fleet.SecretFile = resolvedSecretFile
+	}
+
+	// Resolve the state file, defaulting to state.json next to the config file
+	if c.State.File == "" {
+		c.State.File = filepath.Join(configDir, "state.json")
+	}
+	resolvedStateFile, err := ResolvePath(c.State.File, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve state.file path: %w", err)
+	}
+	c.State.File = resolvedStateFile
+
+	// Resolve the sync lock file, defaulting to sync.lock next to the config file
+	if c.Sync.Lock.File == "" {
+		c.Sync.Lock.File = filepath.Join(configDir, "sync.lock")
+	}
+	resolvedLockFile, err := ResolvePath(c.Sync.Lock.File, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve sync.lock.file path: %w", err)
+	}
+	c.Sync.Lock.File = resolvedLockFile
+
+	// Resolve the version source cache file, defaulting to version-cache.json next to the config file
+	if c.VersionSource.Cache.File == "" {
+		c.VersionSource.Cache.File = filepath.Join(configDir, "version-cache.json")
+	}
+	resolvedCacheFile, err := ResolvePath(c.VersionSource.Cache.File, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve version_source.cache.file path: %w", err)
+	}
+	c.VersionSource.Cache.File = resolvedCacheFile
+
+	// Resolve the notification queue file, defaulting to notification-queue.json next to the config file
+	if c.Notifications.Queue.File == "" {
+		c.Notifications.Queue.File = filepath.Join(configDir, "notification-queue.json")
+	}
+	resolvedQueueFile, err := ResolvePath(c.Notifications.Queue.File, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve notifications.queue.file path: %w", err)
+	}
+	c.Notifications.Queue.File = resolvedQueueFile
+
+	// Resolve the audit log file, defaulting to audit.jsonl next to the config file
+	if c.Audit.File == "" {
+		c.Audit.File = filepath.Join(configDir, "audit.jsonl")
+	}
+	resolvedAuditFile, err := ResolvePath(c.Audit.File, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve audit.file path: %w", err)
+	}
+	c.Audit.File = resolvedAuditFile
+
 	// Resolve DoubleZero.Bin if it's a file path
 	if IsFilePath(c.DoubleZero.Bin) {
 		originalBin := c.DoubleZero.Bin
@@ -157,7 +306,12 @@ func (c *Config) resolvePaths() error {
 
 // validate validates the configuration
 func (c *Config) validate() error {
-	err := c.Log.Validate()
+	err := c.validateSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	err = c.Log.Validate()
 	if err != nil {
 		return err
 	}
@@ -172,7 +326,12 @@ func (c *Config) validate() error {
 		return err
 	}
 
-	err = c.DoubleZero.Validate()
+	err = c.DoubleZero.Validate(c.Cluster.Name)
+	if err != nil {
+		return err
+	}
+
+	err = c.VersionSource.Validate()
 	if err != nil {
 		return err
 	}
@@ -182,6 +341,75 @@ func (c *Config) validate() error {
 		return err
 	}
 
+	err = c.Failover.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Reboot.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.HostMaintenance.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Freeze.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Fleet.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.State.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Notifications.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Health.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Runtime.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Audit.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Retention.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.ConfigReload.Validate()
+	if err != nil {
+		return err
+	}
+
+	// Failover swaps between active and passive identities so both must be configured
+	if c.Failover.IsEnabled() && (!c.Validator.IsEnabled() || c.Validator.Identities.IsSingleIdentity()) {
+		return fmt.Errorf("failover requires validator.rpc_url or validator.identity_source and both validator.identities.active and validator.identities.passive")
+	}
+	// a static identity never changes, so a failover could never be verified
+	if c.Failover.IsEnabled() && c.Validator.IdentitySource.Type == constants.ValidatorIdentitySourceStatic {
+		return fmt.Errorf("failover can't verify the validator became passive with validator.identity_source.type=static")
+	}
+
 	return nil
 }
 
@@ -191,4 +419,75 @@ func (c *Config) setKoanfDefaults(k *koanf.Koanf) {
 	k.Set("log.level", "info")
 	k.Set("log.format", "text")
 	// Note: validator.rpc_url defaults to empty string (not set) so validator check is optional
+	k.Set("validator.rpc_max_requests_per_second", 10)
+	k.Set("validator.identity_source.type", "rpc")
+	k.Set("validator.on_unreachable", "fail")
+	k.Set("validator.wait_for_passive.poll_interval", "10s")
+	k.Set("validator.active_ack_ttl", "0s")
+	k.Set("validator.identities.watch_interval", "10s")
+	k.Set("doublezero.daemon.check", "none")
+	k.Set("doublezero.watch_interval", "10s")
+	k.Set("doublezero.daemon.process_name", "doublezerod")
+	k.Set("doublezero.daemon.systemd_unit", "doublezerod")
+	k.Set("doublezero.daemon.socket_path", "/var/run/doublezerod/doublezerod.sock")
+	k.Set("doublezero.daemon.start_timeout", "30s")
+	k.Set("version_source.type", "cloudsmith_api")
+	k.Set("version_source.format", "deb")
+	k.Set("version_source.timeout", "30s")
+	k.Set("version_source.arch", "auto")
+	k.Set("version_source.distro", "any-distro")
+	k.Set("version_source.data_format", "borsh_string")
+	k.Set("version_source.cache.ttl", "0s")
+	k.Set("version_source.cache.max_stale", "24h")
+	k.Set("sync.anchor", "midnight")
+	k.Set("sync.overrun_policy", "skip_next")
+	k.Set("sync.dry_run", false)
+	k.Set("sync.run_on_start", false)
+	k.Set("sync.allow_downgrade", true)
+	k.Set("sync.confirm_cycles", 1)
+	k.Set("sync.min_version_age", "0s")
+	k.Set("sync.verify_installed", true)
+	k.Set("sync.wait_for_package", "0s")
+	k.Set("sync.post_checks.timeout", "2m")
+	k.Set("sync.post_checks.retry_interval", "5s")
+	k.Set("sync.post_checks.max_retry_interval", "30s")
+	k.Set("sync.diagnostics.timeout", "10s")
+	k.Set("sync.approval.required_approvals", 2)
+	k.Set("sync.unreachable_windows", "fail")
+	k.Set("sync.lock.wait", "0s")
+	k.Set("sync.retry.max_attempts", 0)
+	k.Set("sync.retry.initial_backoff", "30s")
+	k.Set("sync.retry.max_backoff", "10m")
+	k.Set("sync.retry.reasons", report.RetryableReasons)
+	k.Set("sync.refresh_repos.enabled", false)
+	k.Set("sync.refresh_repos.package_manager", constants.PackageManagerApt)
+	k.Set("sync.refresh_repos.min_interval", "6h")
+	k.Set("failover.policy", "disabled")
+	k.Set("failover.verify_timeout", "5m")
+	k.Set("failover.verify_poll_interval", "10s")
+	k.Set("failover.request_timeout", "30s")
+	k.Set("reboot.policy", "disabled")
+	k.Set("reboot.required_files", []string{"/var/run/reboot-required"})
+	k.Set("host_maintenance.policy", "disabled")
+	k.Set("host_maintenance.timeout", "5s")
+	k.Set("freeze.timeout", "10s")
+	k.Set("fleet.quorum", 0)
+	k.Set("fleet.max_beacon_age", "5m")
+	k.Set("fleet.timeout", "5s")
+	k.Set("state.history_size", 100)
+	k.Set("notifications.locale", constants.NotificationLocaleDefault)
+	k.Set("notifications.queue.max_size", 100)
+	k.Set("notifications.queue.initial_backoff", "30s")
+	k.Set("notifications.queue.max_backoff", "30m")
+	k.Set("notifications.queue.max_age", "24h")
+	k.Set("health.max_consecutive_failures", 3)
+	k.Set("health.stuck_after", "0s")
+	k.Set("runtime.max_procs", 0)
+	k.Set("runtime.gc_percent", 0)
+	k.Set("runtime.watchdog.wedged_after", "0s")
+	k.Set("runtime.shutdown_grace_period", "60s")
+	k.Set("audit.enabled", false)
+	k.Set("audit.max_output_bytes", 4096)
+	k.Set("retention.max_age", "0s")
+	k.Set("config_reload.watch_interval", "0s")
 }
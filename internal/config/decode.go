@@ -0,0 +1,39 @@
+package config
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/knadh/koanf"
+	"github.com/mitchellh/mapstructure"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+)
+
+// unmarshal unmarshals the koanf config at path into o, decoding the config's custom types
+func unmarshal(k *koanf.Koanf, path string, o interface{}) error {
+	return k.UnmarshalWithConf(path, o, koanf.UnmarshalConf{
+		Tag: "koanf",
+		DecoderConfig: &mapstructure.DecoderConfig{
+			// koanf's default hooks, plus the custom types
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				durationHookFunc(),
+				mapstructure.StringToSliceHookFunc(","),
+				mapstructure.TextUnmarshallerHookFunc(),
+				versionConstraintHookFunc(),
+			),
+			Result:           o,
+			TagName:          "koanf",
+			WeaklyTypedInput: true,
+		},
+	})
+}
+
+// durationHookFunc decodes a duration string with duration.Parse, accepting the d and w units on top of Go's
+func durationHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(time.Duration(0)) {
+			return data, nil
+		}
+		return duration.Parse(data.(string))
+	}
+}
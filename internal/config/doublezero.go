@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
 )
 
 // DoubleZero represents the DoubleZero configuration
@@ -12,22 +16,100 @@ type DoubleZero struct {
 	// If not specified, defaults to "doublezero"
 	// Examples: "./scripts/mock-doublezero.sh", "doublezero", "/usr/bin/doublezero"
 	Bin string `koanf:"bin"`
-	// VersionConstraint is the constraint for the DoubleZero version
-	// Example: ">= 0.6.9, < 7.0.0"
-	VersionConstraint string `koanf:"version_constraint"`
-	// ParsedVersionConstraint is the parsed version constraint
+	// VersionConstraint is the constraint for the DoubleZero version, either for every cluster or keyed by cluster name
+	// with a default entry for the others
+	// Example: ">= 0.6.9, < 7.0.0" or {mainnet-beta: ">= 0.6.9, < 0.7.2", default: ">= 0.6.9"}
+	VersionConstraint VersionConstraint `koanf:"version_constraint"`
+	// ParsedVersionConstraint is the parsed version constraint for the configured cluster, nil when there is none
 	ParsedVersionConstraint version.Constraints `koanf:"-"`
+	// SkipVersions are known-bad versions that are never synced to even if recommended - a version without a release
+	// (e.g. "0.7.2") skips every release of it, one with a release (e.g. "0.7.2-1") only that release
+	SkipVersions []string `koanf:"skip_versions"`
+	// SteppingStones are releases an upgrade must pass through (e.g. "0.7.0-1") - an upgrade past one is done in hops,
+	// installing and verifying each stepping stone in between before moving on to the target
+	SteppingStones []string `koanf:"stepping_stones"`
+	// ParsedSteppingStones are the parsed stepping stones, lowest first
+	ParsedSteppingStones []*version.Version `koanf:"-"`
+	// Daemon is the DoubleZero daemon running check configuration
+	Daemon Daemon `koanf:"daemon"`
+	// WatchInterval enables watching Bin with inotify between cycles of run --on-interval, running a cycle right away
+	// when it changed out of band (e.g. a manual upgrade). It's how often Bin is checked instead when it can't be
+	// watched. Defaults to 10s, 0 disables the watch
+	WatchInterval time.Duration `koanf:"watch_interval"`
 }
 
-// Validate validates the DoubleZero configuration
-func (d *DoubleZero) Validate() error {
-	// Parse version constraint if provided
-	if d.VersionConstraint != "" {
-		parsedConstraint, err := version.NewConstraint(d.VersionConstraint)
+// Daemon represents the DoubleZero daemon running check configuration
+type Daemon struct {
+	// Check is how to verify the daemon is running before and after a sync - one of none, process, systemd, socket
+	// Defaults to none
+	Check string `koanf:"check"`
+	// ProcessName is the daemon process name for the process check, matched against the basename of the process
+	// executable or argv[0] - defaults to doublezerod
+	ProcessName string `koanf:"process_name"`
+	// SystemdUnit is the daemon systemd unit for the systemd check, defaults to doublezerod
+	SystemdUnit string `koanf:"systemd_unit"`
+	// SocketPath is the daemon unix socket for the socket check, defaults to /var/run/doublezerod/doublezerod.sock
+	SocketPath string `koanf:"socket_path"`
+	// StartTimeout is how long to wait for the daemon to be running after sync commands execute, defaults to 30s
+	StartTimeout time.Duration `koanf:"start_timeout"`
+}
+
+// Validate validates the DoubleZero configuration, resolving the version constraint for the given cluster
+func (d *DoubleZero) Validate(clusterName string) error {
+	if err := d.VersionConstraint.validateClusterNames(); err != nil {
+		return err
+	}
+
+	// Parse every configured version constraint so mistakes surface on every host, keeping the cluster's own
+	for constraintCluster, constraint := range d.VersionConstraint.ByCluster {
+		if constraint == "" {
+			continue
+		}
+		if _, err := version.NewConstraint(constraint); err != nil {
+			return fmt.Errorf("failed to parse doublezero.version_constraint for %s: %w", constraintCluster, err)
+		}
+	}
+	d.ParsedVersionConstraint = nil
+	if constraint := d.VersionConstraint.ForCluster(clusterName); constraint != "" {
+		parsedConstraint, err := version.NewConstraint(constraint)
 		if err != nil {
-			return fmt.Errorf("failed to parse doublezero.version_constraint: %w", err)
+			return fmt.Errorf("failed to parse doublezero.version_constraint for %s: %w", clusterName, err)
 		}
 		d.ParsedVersionConstraint = parsedConstraint
 	}
+
+	for i, skipVersion := range d.SkipVersions {
+		if _, err := version.NewVersion(skipVersion); err != nil {
+			return fmt.Errorf("doublezero.skip_versions[%d] is not a valid version - got: %s", i, skipVersion)
+		}
+	}
+
+	d.ParsedSteppingStones = nil
+	for i, steppingStone := range d.SteppingStones {
+		parsed, err := version.NewVersion(steppingStone)
+		if err != nil {
+			return fmt.Errorf("doublezero.stepping_stones[%d] is not a valid version - got: %s", i, steppingStone)
+		}
+		for _, other := range d.ParsedSteppingStones {
+			if other.Core().Equal(parsed.Core()) {
+				return fmt.Errorf("doublezero.stepping_stones[%d] %s is not unique - %s is already listed", i, steppingStone, other.Original())
+			}
+		}
+		d.ParsedSteppingStones = append(d.ParsedSteppingStones, parsed)
+	}
+	slices.SortFunc(d.ParsedSteppingStones, func(a, b *version.Version) int { return a.Compare(b) })
+
+	// Validate daemon check
+	if !slices.Contains(constants.ValidDaemonChecks, d.Daemon.Check) {
+		return fmt.Errorf("doublezero.daemon.check must be one of %s - got: %s", strings.Join(constants.ValidDaemonChecks, ", "), d.Daemon.Check)
+	}
+	if d.Daemon.StartTimeout < 0 {
+		return fmt.Errorf("doublezero.daemon.start_timeout must be >= 0 - got: %s", d.Daemon.StartTimeout)
+	}
+
+	if d.WatchInterval < 0 {
+		return fmt.Errorf("doublezero.watch_interval must be >= 0 - got: %s", d.WatchInterval)
+	}
+
 	return nil
 }
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// EnvPrefix prefixes the environment variables overriding config file values
+const EnvPrefix = "DOUBLEZERO_VERSION_SYNC_"
+
+// envKeys maps the environment variables overriding config file values, without EnvPrefix, to their keys
+var envKeys = map[string]string{
+	"CLUSTER":             "cluster.name",
+	"BIN":                 "doublezero.bin",
+	"VALIDATOR_RPC_URL":   "validator.rpc_url",
+	"ENABLED_WHEN_ACTIVE": "validator.enabled_when_active",
+	"WAIT_LOCK":           "sync.lock.wait",
+}
+
+// envOverrides returns the config values by key set in the environment
+func envOverrides() map[string]string {
+	overrides := map[string]string{}
+	for name, key := range envKeys {
+		if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+			overrides[key] = value
+		}
+	}
+	return overrides
+}
+
+// loadOverrides merges config values by key over those already loaded
+func loadOverrides(k *koanf.Koanf, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(overrides))
+	for key, value := range overrides {
+		values[key] = value
+	}
+	return k.Load(confmap.Provider(values, "."), nil)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// Failover represents the external failover integration configuration
+type Failover struct {
+	// Policy controls whether the failover hook is invoked when a sync is required but the validator is active
+	// One of disabled, auto, prompt - defaults to disabled
+	Policy string `koanf:"policy"`
+	// Command is an optional command to run to request an identity swap from existing failover tooling
+	Command *sync_commands.Command `koanf:"command"`
+	// URL is an optional URL that is sent a JSON POST request to request an identity swap
+	URL string `koanf:"url" redact:"url"`
+	// RequestTimeout is the maximum time URL may take to respond, defaults to 30s
+	RequestTimeout time.Duration `koanf:"request_timeout"`
+	// VerifyTimeout is how long to wait for the validator to become passive after requesting failover, defaults to 5m
+	VerifyTimeout time.Duration `koanf:"verify_timeout"`
+	// VerifyPollInterval is how often the validator identity is polled while verifying failover, defaults to 10s
+	VerifyPollInterval time.Duration `koanf:"verify_poll_interval"`
+}
+
+// IsEnabled returns true if the failover hook is enabled
+func (f *Failover) IsEnabled() bool {
+	return f.Policy != constants.FailoverPolicyDisabled
+}
+
+// Validate validates the failover configuration
+func (f *Failover) Validate() error {
+	if !slices.Contains(constants.ValidFailoverPolicies, f.Policy) {
+		return fmt.Errorf("failover.policy must be one of %s - got: %s", strings.Join(constants.ValidFailoverPolicies, ", "), f.Policy)
+	}
+
+	if !f.IsEnabled() {
+		return nil
+	}
+
+	if f.Command == nil && f.URL == "" {
+		return fmt.Errorf("failover.command or failover.url must be provided when failover.policy is %s", f.Policy)
+	}
+
+	if f.URL != "" {
+		if _, err := url.ParseRequestURI(f.URL); err != nil {
+			return fmt.Errorf("failover.url %s is not a valid URL: %w", f.URL, err)
+		}
+		if f.RequestTimeout <= 0 {
+			return fmt.Errorf("failover.request_timeout must be > 0 - got: %s", f.RequestTimeout)
+		}
+	}
+
+	if f.VerifyTimeout <= 0 {
+		return fmt.Errorf("failover.verify_timeout must be > 0 - got: %s", f.VerifyTimeout)
+	}
+
+	if f.VerifyPollInterval <= 0 {
+		return fmt.Errorf("failover.verify_poll_interval must be > 0 - got: %s", f.VerifyPollInterval)
+	}
+
+	return nil
+}
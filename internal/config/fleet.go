@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Fleet represents the peer exchange configuration, letting hosts of a controller-less fleet share their installed
+// versions and wait for peers to succeed on a new version before upgrading
+type Fleet struct {
+	// ListenAddress is the address run --on-interval serves this host's beacon on at /fleet/beacon, e.g. :9843
+	// Empty to not serve it
+	ListenAddress string `koanf:"listen_address"`
+	// Peers are the beacon URLs of the peers, e.g. http://10.0.0.2:9843/fleet/beacon
+	Peers []string `koanf:"peers"`
+	// SecretFile is the path of the shared secret beacons are signed and verified with (HMAC-SHA256)
+	SecretFile string `koanf:"secret_file"`
+	// Quorum is how many peers must report success on the target version before this host syncs to it
+	// 0, the default, doesn't wait - e.g. for the hosts upgrading first
+	Quorum int `koanf:"quorum"`
+	// MaxBeaconAge is how old a peer beacon may be before it is ignored, defaults to 5m
+	MaxBeaconAge time.Duration `koanf:"max_beacon_age"`
+	// Timeout is the maximum time a peer may take to serve its beacon, defaults to 5s
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+// IsEnabled returns true if this host serves its beacon or waits for a quorum of peers
+func (f *Fleet) IsEnabled() bool {
+	return f.ListenAddress != "" || f.Quorum > 0
+}
+
+// Validate validates the fleet configuration
+func (f *Fleet) Validate() error {
+	if f.Quorum < 0 {
+		return fmt.Errorf("fleet.quorum must be >= 0 - got: %d", f.Quorum)
+	}
+	if f.Quorum > len(f.Peers) {
+		return fmt.Errorf("fleet.quorum must be at most the number of fleet.peers (%d) - got: %d", len(f.Peers), f.Quorum)
+	}
+	for i, peer := range f.Peers {
+		if !isHTTPURL(peer) {
+			return fmt.Errorf("fleet.peers[%d] must be an http(s) URL - got: %s", i, peer)
+		}
+	}
+
+	if !f.IsEnabled() {
+		return nil
+	}
+
+	if f.SecretFile == "" {
+		return fmt.Errorf("fleet.secret_file must be provided when fleet.listen_address or fleet.quorum is set")
+	}
+	if f.MaxBeaconAge <= 0 {
+		return fmt.Errorf("fleet.max_beacon_age must be > 0 - got: %s", f.MaxBeaconAge)
+	}
+	if f.Timeout <= 0 {
+		return fmt.Errorf("fleet.timeout must be > 0 - got: %s", f.Timeout)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+// ParserForFile returns the koanf parser for a config file by its extension - .toml and .json files are parsed as
+// TOML and JSON, anything else as YAML
+func ParserForFile(path string) koanf.Parser {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return toml.Parser()
+	case ".json":
+		return json.Parser()
+	default:
+		return yaml.Parser()
+	}
+}
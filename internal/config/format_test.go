@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadFromFile_Formats(t *testing.T) {
+	fixtures := map[string]string{
+		"config.yaml": `
+cluster:
+  name: mainnet-beta
+log:
+  level: debug
+doublezero:
+  version_constraint:
+    mainnet-beta: ">= 0.6.9, < 0.7.2"
+    default: ">= 0.6.9"
+  skip_versions: ["0.7.2", "0.7.3-1"]
+  daemon:
+    check: socket
+    start_timeout: 45s
+sync:
+  confirm_cycles: 2
+  min_version_age: 6h
+  cohort: canary
+  cohorts:
+    canary: 0s
+    wave-2: 2h
+  commands:
+    - name: install
+      cmd: apt-get
+      args: ["install", "-y", "doublezero={{ .PackageVersionTo }}"]
+      environment:
+        DEBIAN_FRONTEND: noninteractive
+`,
+		"config.toml": `
+[cluster]
+name = "mainnet-beta"
+
+[log]
+level = "debug"
+
+[doublezero]
+skip_versions = ["0.7.2", "0.7.3-1"]
+
+[doublezero.version_constraint]
+mainnet-beta = ">= 0.6.9, < 0.7.2"
+default = ">= 0.6.9"
+
+[doublezero.daemon]
+check = "socket"
+start_timeout = "45s"
+
+[sync]
+confirm_cycles = 2
+min_version_age = "6h"
+cohort = "canary"
+
+[sync.cohorts]
+canary = "0s"
+wave-2 = "2h"
+
+[[sync.commands]]
+name = "install"
+cmd = "apt-get"
+args = ["install", "-y", "doublezero={{ .PackageVersionTo }}"]
+
+[sync.commands.environment]
+DEBIAN_FRONTEND = "noninteractive"
+`,
+		"config.json": `{
+  "cluster": {"name": "mainnet-beta"},
+  "log": {"level": "debug"},
+  "doublezero": {
+    "version_constraint": {"mainnet-beta": ">= 0.6.9, < 0.7.2", "default": ">= 0.6.9"},
+    "skip_versions": ["0.7.2", "0.7.3-1"],
+    "daemon": {"check": "socket", "start_timeout": "45s"}
+  },
+  "sync": {
+    "confirm_cycles": 2,
+    "min_version_age": "6h",
+    "cohort": "canary",
+    "cohorts": {"canary": "0s", "wave-2": "2h"},
+    "commands": [{
+      "name": "install",
+      "cmd": "apt-get",
+      "args": ["install", "-y", "doublezero={{ .PackageVersionTo }}"],
+      "environment": {"DEBIAN_FRONTEND": "noninteractive"}
+    }]
+  }
+}`,
+	}
+
+	dir := t.TempDir()
+	load := func(name string) *Config {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(fixtures[name]), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cfg, err := NewFromConfigFile(file)
+		if err != nil {
+			t.Fatalf("NewFromConfigFile(%s) error = %v", name, err)
+		}
+		// the file loaded is the only difference, the parsed constraint and logger hold funcs DeepEqual can't compare
+		if cfg.DoubleZero.ParsedVersionConstraint.String() != ">= 0.6.9, < 0.7.2" {
+			t.Errorf("NewFromConfigFile(%s) constraint = %s, want the mainnet-beta one", name, cfg.DoubleZero.ParsedVersionConstraint)
+		}
+		cfg.File, cfg.DoubleZero.ParsedVersionConstraint, cfg.logger = "", nil, nil
+		return cfg
+	}
+
+	want := load("config.yaml")
+	if want.Cluster.Name != "mainnet-beta" || len(want.Sync.Commands) != 1 || want.Sync.Cohorts["wave-2"] != 2*time.Hour {
+		t.Fatalf("YAML fixture decoded to %+v, want its settings", want)
+	}
+	for _, name := range []string{"config.toml", "config.json"} {
+		t.Run(filepath.Ext(name), func(t *testing.T) {
+			if got := load(name); !reflect.DeepEqual(got, want) {
+				t.Errorf("NewFromConfigFile(%s) = %+v, want the YAML fixture's %+v", name, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/freeze"
+)
+
+// Freeze represents the change freeze calendar configuration
+type Freeze struct {
+	// Periods are the freeze periods, e.g. a year-end change freeze - none by default
+	Periods []FreezePeriod `koanf:"periods"`
+	// Calendars are YAML or iCalendar (.ics) files or http(s) URLs listing more freeze periods, read on every cycle
+	// Relative files are resolved relative to the config file directory
+	Calendars []string `koanf:"calendars" redact:"url"`
+	// Timeout is the maximum time a calendar URL may take to respond, defaults to 10s
+	Timeout time.Duration `koanf:"timeout"`
+	// ParsedPeriods are the parsed Periods
+	ParsedPeriods []freeze.Period `koanf:"-"`
+}
+
+// FreezePeriod is a freeze period defined in the config
+type FreezePeriod struct {
+	// Name is the name of the freeze, shown in the status and notifications
+	Name string `koanf:"name"`
+	// Start is when the freeze starts, an RFC3339 time or a UTC date
+	Start string `koanf:"start"`
+	// End is when the freeze ends, an RFC3339 time (exclusive) or a UTC date (inclusive)
+	End string `koanf:"end"`
+}
+
+// IsEnabled returns true if any freeze period or calendar is configured
+func (f *Freeze) IsEnabled() bool {
+	return len(f.Periods) > 0 || len(f.Calendars) > 0
+}
+
+// Validate validates the change freeze configuration
+func (f *Freeze) Validate() error {
+	f.ParsedPeriods = nil
+	for i, entry := range f.Periods {
+		period, err := freeze.ParsePeriod(entry.Name, entry.Start, entry.End, "config")
+		if err != nil {
+			return fmt.Errorf("freeze.periods[%d]: %w", i, err)
+		}
+		f.ParsedPeriods = append(f.ParsedPeriods, period)
+	}
+
+	for i, calendar := range f.Calendars {
+		if calendar == "" {
+			return fmt.Errorf("freeze.calendars[%d] must not be empty", i)
+		}
+		if freeze.IsURL(calendar) {
+			if _, err := url.ParseRequestURI(calendar); err != nil {
+				return fmt.Errorf("freeze.calendars[%d] %s is not a valid URL: %w", i, calendar, err)
+			}
+		}
+	}
+
+	if f.Timeout <= 0 {
+		return fmt.Errorf("freeze.timeout must be > 0 - got: %s", f.Timeout)
+	}
+
+	return nil
+}
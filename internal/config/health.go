@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Health represents the run --on-interval health and readiness endpoint configuration
+type Health struct {
+	// MaxConsecutiveFailures is how many cycles in a row may fail before /readyz reports not ready and sync_failing is
+	// raised, defaults to 3
+	MaxConsecutiveFailures int `koanf:"max_consecutive_failures"`
+	// StuckAfter is how long a cycle may run, or the next one be overdue, before /healthz reports the daemon wedged
+	// Defaults to 0 - twice the interval
+	StuckAfter time.Duration `koanf:"stuck_after"`
+}
+
+// Validate validates the health endpoint configuration
+func (h *Health) Validate() error {
+	if h.MaxConsecutiveFailures < 1 {
+		return fmt.Errorf("health.max_consecutive_failures must be >= 1 - got: %d", h.MaxConsecutiveFailures)
+	}
+	if h.StuckAfter < 0 {
+		return fmt.Errorf("health.stuck_after must be >= 0 - got: %s", h.StuckAfter)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// HostMaintenance represents the host-wide maintenance mode integration configuration
+type HostMaintenance struct {
+	// Policy controls what a sync does while the host is in maintenance mode - one of disabled, block (hold version
+	// changes pending) or prioritize (apply them even outside sync.windows). Defaults to disabled
+	Policy string `koanf:"policy"`
+	// File is a flag file whose existence signals host maintenance mode, e.g. /etc/node-maintenance
+	File string `koanf:"file"`
+	// URL is an endpoint responding with a 2xx status while the host is in maintenance mode and 404 otherwise
+	URL string `koanf:"url" redact:"url"`
+	// Timeout is the maximum time URL may take to respond, defaults to 5s
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+// IsEnabled returns true if host maintenance mode is checked
+func (h *HostMaintenance) IsEnabled() bool {
+	return h.Policy != "" && h.Policy != constants.HostMaintenancePolicyDisabled
+}
+
+// Validate validates the host maintenance configuration
+func (h *HostMaintenance) Validate() error {
+	if !slices.Contains(constants.ValidHostMaintenancePolicies, h.Policy) {
+		return fmt.Errorf("host_maintenance.policy must be one of %s - got: %s", strings.Join(constants.ValidHostMaintenancePolicies, ", "), h.Policy)
+	}
+
+	if !h.IsEnabled() {
+		return nil
+	}
+
+	if h.File == "" && h.URL == "" {
+		return fmt.Errorf("host_maintenance.file or host_maintenance.url must be provided when host_maintenance.policy is %s", h.Policy)
+	}
+	if h.URL != "" {
+		if _, err := url.ParseRequestURI(h.URL); err != nil {
+			return fmt.Errorf("host_maintenance.url %s is not a valid URL: %w", h.URL, err)
+		}
+	}
+	if h.Timeout <= 0 {
+		return fmt.Errorf("host_maintenance.timeout must be > 0 - got: %s", h.Timeout)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/rawbytes"
+)
+
+// loadFile reads, renders and parses a config file into a new koanf instance
+func loadFile(path string) (*koanf.Koanf, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := renderTemplate(filepath.Base(path), contents)
+	if err != nil {
+		return nil, err
+	}
+
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(rendered), ParserForFile(path)); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// globIncludes returns the files matched by the include globs of the config key, resolved relative to the config file
+// directory, in glob order and lexical order within a glob. A glob matching no file is an error
+func globIncludes(key string, patterns []string, configDir string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		resolvedPattern, err := ResolvePath(pattern, configDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s %s: %w", key, pattern, err)
+		}
+
+		matches, err := filepath.Glob(resolvedPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s glob %s: %w", key, pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s %s did not match any files", key, pattern)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// loadOverlays merges the files matched by the include globs over k in order, e.g. a per-host overlay over a fleet-wide
+// base config. Files matched by a single glob are merged in lexical order. Maps are merged key by key and any other value,
+// lists included, is replaced
+func (c *Config) loadOverlays(k *koanf.Koanf) error {
+	c.IncludedFiles = nil
+	matches, err := globIncludes("include", k.Strings("include"), filepath.Dir(c.File))
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		overlay, err := loadFile(match)
+		if err != nil {
+			return fmt.Errorf("error loading include file %s: %w", match, err)
+		}
+		if overlay.Exists("include") {
+			return fmt.Errorf("include file %s cannot have includes", match)
+		}
+		if err := k.Merge(overlay); err != nil {
+			return fmt.Errorf("error merging include file %s: %w", match, err)
+		}
+		c.IncludedFiles = append(c.IncludedFiles, match)
+	}
+
+	return nil
+}
+
+// Files returns the config file followed by the include files merged over it and the sync.include command fragments,
+// every file the config was loaded from
+func (c *Config) Files() []string {
+	files := append([]string{c.File}, c.IncludedFiles...)
+	return append(files, c.Sync.IncludedFiles...)
+}
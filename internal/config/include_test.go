@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadFromFileIncludes(t *testing.T) {
+	t.Setenv("DZVS_TEST_PACKAGE", "doublezero")
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yml": "include: [\"host.d/*.yml\"]\nsync:\n  include: [\"commands.d/*.yml\"]\n" +
+			"  commands:\n    - name: stop\n      cmd: systemctl\n      args: [\"stop\", \"doublezerod\"]\n",
+		"host.d/b.yml": "cluster:\n  name: mainnet-beta\n",
+		"host.d/a.yml": "cluster:\n  name: testnet\nlog:\n  level: debug\n",
+		"commands.d/install.yml": "commands:\n  - name: install\n    cmd: apt-get\n" +
+			"    args: [\"install\", \"-y\", \"${{ env \"DZVS_TEST_PACKAGE\" }}={{ .PackageVersionTo }}\"]\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cfg.LoadFromFile(filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	// overlays merge in lexical order, the later one winning
+	if cfg.Cluster.Name != "mainnet-beta" || cfg.Log.Level != "debug" {
+		t.Errorf("cluster.name = %s, log.level = %s, want the overlays merged in order", cfg.Cluster.Name, cfg.Log.Level)
+	}
+
+	// fragment commands are appended with their config templates rendered and sync command templates left as is
+	if len(cfg.Sync.Commands) != 2 || cfg.Sync.Commands[1].Name != "install" {
+		t.Fatalf("sync.commands = %+v, want stop then install", cfg.Sync.Commands)
+	}
+	if got, want := cfg.Sync.Commands[1].Args[2], "doublezero={{ .PackageVersionTo }}"; got != want {
+		t.Errorf("install arg = %q, want %q", got, want)
+	}
+
+	// every file the config was loaded from is watched for reload
+	want := []string{
+		filepath.Join(dir, "config.yml"),
+		filepath.Join(dir, "host.d/a.yml"),
+		filepath.Join(dir, "host.d/b.yml"),
+		filepath.Join(dir, "commands.d/install.yml"),
+	}
+	if got := cfg.Files(); !slices.Equal(got, want) {
+		t.Errorf("Files() = %v, want %v", got, want)
+	}
+}
+
+func TestGlobIncludes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yml", "a.yml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "lexical order within a glob", patterns: []string{"*.yml"}, want: []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yml")}},
+		{name: "glob order", patterns: []string{"b.yml", "a.yml"}, want: []string{filepath.Join(dir, "b.yml"), filepath.Join(dir, "a.yml")}},
+		{name: "no match", patterns: []string{"*.toml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := globIncludes("include", tt.patterns, dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("globIncludes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("globIncludes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
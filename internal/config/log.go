@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
 )
 
 var (
@@ -22,10 +23,14 @@ type Log struct {
 	Level string `koanf:"level"`
 	// Format is the log format - one of "text" or "json" or "logfmt", defaults to text
 	Format string `koanf:"format"`
+	// Levels overrides the level of loggers by prefix (e.g. versionsource, rpc), regardless of Level and --log-level
+	Levels map[string]string `koanf:"levels"`
 	// ParsedLevel is the parsed log level
 	ParsedLevel log.Level `koanf:"-"`
 	// ParsedFormat is the parsed log format
 	ParsedFormatter log.Formatter `koanf:"-"`
+	// ParsedLevels is the parsed per-prefix log levels
+	ParsedLevels map[string]log.Level `koanf:"-"`
 }
 
 // Validate validates the log configuration
@@ -43,6 +48,18 @@ func (l *Log) Validate() (err error) {
 		return fmt.Errorf("log.format must be one of text, json, logfmt - got: %s", l.Format)
 	}
 
+	// try to parse the per-prefix levels
+	l.ParsedLevels = make(map[string]log.Level, len(l.Levels))
+	for prefix, level := range l.Levels {
+		if prefix == "" {
+			return fmt.Errorf("log.levels prefix must not be empty")
+		}
+		l.ParsedLevels[prefix], err = log.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("log.levels.%s must be one of debug, info, warn, error, fatal - got: %s", prefix, level)
+		}
+	}
+
 	return nil
 }
 
@@ -74,6 +91,9 @@ func (l *Log) ConfigureWithLevelString(logLevel string) {
 	// Set the global log level
 	log.SetLevel(l.ParsedLevel)
 
+	// Set the per-prefix overrides applied to loggers created from here on
+	logging.SetLevels(l.ParsedLevels)
+
 	// set the time function to ensure all logs are in UTC and in nanos
 	log.SetTimeFunction(func() time.Time {
 		return time.Now().UTC()
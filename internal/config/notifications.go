@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// Notifications represents the notifications configuration
+type Notifications struct {
+	// Webhooks are generic webhooks that are sent events as JSON POST requests
+	Webhooks []Webhook `koanf:"webhooks"`
+	// Slack are Slack incoming webhooks that are sent events formatted as blocks
+	Slack []ChatWebhook `koanf:"slack"`
+	// Discord are Discord webhooks that are sent events formatted as embeds
+	Discord []ChatWebhook `koanf:"discord"`
+	// PagerDuty are PagerDuty Events API v2 integrations triggered while syncs keep failing and resolved on recovery
+	PagerDuty []PagerDuty `koanf:"pagerduty"`
+	// Alertmanager are Alertmanager instances sent a firing alert while syncs keep failing and resolved on recovery
+	Alertmanager []Alertmanager `koanf:"alertmanager"`
+	// Queue is the queue of notifications that failed to deliver
+	Queue NotificationQueue `koanf:"queue"`
+	// Locale is the message catalog locale notifiers send in unless they set their own, defaults to en (built in)
+	Locale string `koanf:"locale"`
+	// Catalog are message templates by locale and event type, overriding the built-in en titles and messages - event
+	// types a locale doesn't set fall back to them
+	Catalog map[string]map[string]NotificationMessage `koanf:"catalog"`
+}
+
+// NotificationMessage represents the templates of an event's user-facing text, Go templates rendered with the event
+type NotificationMessage struct {
+	// Title is the title chat notifiers send the event with
+	Title string `koanf:"title"`
+	// Message replaces the event message in chat notifiers and the PagerDuty and Alertmanager summaries
+	Message string `koanf:"message"`
+}
+
+// NotificationQueue represents the persistent queue of notifications that failed to deliver, retried with backoff
+type NotificationQueue struct {
+	// File is the path of the JSON queue file, defaults to notification-queue.json next to the config file
+	File string `koanf:"file"`
+	// MaxSize is the most notifications queued, the oldest are dropped beyond it - 0 disables queueing
+	MaxSize int `koanf:"max_size"`
+	// InitialBackoff is how long after a failed delivery it is retried, doubling with each failed retry
+	InitialBackoff time.Duration `koanf:"initial_backoff"`
+	// MaxBackoff caps the time between retries
+	MaxBackoff time.Duration `koanf:"max_backoff"`
+	// MaxAge is how long after it was raised a notification is dropped rather than retried
+	MaxAge time.Duration `koanf:"max_age"`
+}
+
+// IsEnabled returns true when failed notifications are queued for retry
+func (q *NotificationQueue) IsEnabled() bool {
+	return q.MaxSize > 0
+}
+
+// Validate validates the notification queue configuration
+func (q *NotificationQueue) Validate() error {
+	if q.MaxSize < 0 {
+		return fmt.Errorf("notifications.queue.max_size must be >= 0 - got: %d", q.MaxSize)
+	}
+	if !q.IsEnabled() {
+		return nil
+	}
+	if q.InitialBackoff <= 0 {
+		return fmt.Errorf("notifications.queue.initial_backoff must be > 0 - got: %s", q.InitialBackoff)
+	}
+	if q.MaxBackoff < q.InitialBackoff {
+		return fmt.Errorf("notifications.queue.max_backoff must be >= notifications.queue.initial_backoff %s - got: %s", q.InitialBackoff, q.MaxBackoff)
+	}
+	if q.MaxAge <= 0 {
+		return fmt.Errorf("notifications.queue.max_age must be > 0 - got: %s", q.MaxAge)
+	}
+	return nil
+}
+
+// Webhook represents a generic webhook notifier
+type Webhook struct {
+	// URL is the URL events are POSTed to
+	URL string `koanf:"url" redact:"url"`
+	// MinSeverity is the minimum severity of events sent - one of info, warning, critical, defaults to warning
+	MinSeverity string `koanf:"min_severity"`
+	// Events are the event types sent, all when empty
+	Events []string `koanf:"events"`
+	// Format is how events are sent - one of json (the event as is) or cloudevents (a CloudEvents 1.0 structured mode
+	// JSON event, e.g. for Knative or Argo Events), defaults to json
+	Format string `koanf:"format"`
+}
+
+// ChatWebhook represents a Slack or Discord webhook notifier
+type ChatWebhook struct {
+	// URL is the webhook URL formatted events are POSTed to
+	URL string `koanf:"url" redact:"url"`
+	// MinSeverity is the minimum severity of events sent - one of info, warning, critical, defaults to info
+	MinSeverity string `koanf:"min_severity"`
+	// Events are the event types sent, all when empty
+	Events []string `koanf:"events"`
+	// Templates override the message title of an event type, a Go template rendered with the event
+	Templates map[string]string `koanf:"templates"`
+	// Locale is the message catalog locale, defaults to notifications.locale
+	Locale string `koanf:"locale"`
+}
+
+// Validate validates the chat webhook configuration, key is its config key e.g. notifications.slack[0]
+func (w *ChatWebhook) Validate(key string) error {
+	if _, err := url.ParseRequestURI(w.URL); err != nil {
+		return fmt.Errorf("%s.url %s is not a valid URL: %w", key, w.URL, err)
+	}
+
+	// list entries don't get koanf defaults
+	if w.MinSeverity == "" {
+		w.MinSeverity = constants.NotificationSeverityInfo
+	}
+	if !slices.Contains(constants.ValidNotificationSeverities, w.MinSeverity) {
+		return fmt.Errorf("%s.min_severity must be one of %s - got: %s", key, strings.Join(constants.ValidNotificationSeverities, ", "), w.MinSeverity)
+	}
+	if err := validateNotificationEvents(key, w.Events); err != nil {
+		return err
+	}
+
+	for eventType, text := range w.Templates {
+		if !slices.Contains(constants.ValidNotificationEvents, eventType) {
+			return fmt.Errorf("%s.templates key must be one of %s - got: %s", key, strings.Join(constants.ValidNotificationEvents, ", "), eventType)
+		}
+		if _, err := template.New(eventType).Parse(text); err != nil {
+			return fmt.Errorf("%s.templates.%s is not a valid template: %w", key, eventType, err)
+		}
+	}
+	return nil
+}
+
+// PagerDuty represents a PagerDuty Events API v2 integration
+type PagerDuty struct {
+	// RoutingKey is the integration key of the PagerDuty service
+	RoutingKey string `koanf:"routing_key" redact:"true"`
+	// URL is the Events API v2 enqueue URL, defaults to DefaultPagerDutyURL
+	URL string `koanf:"url" redact:"url"`
+	// Locale is the message catalog locale of the incident summary, defaults to notifications.locale
+	Locale string `koanf:"locale"`
+}
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 enqueue URL
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Validate validates the PagerDuty configuration, key is its config key e.g. notifications.pagerduty[0]
+func (p *PagerDuty) Validate(key string) error {
+	if p.RoutingKey == "" {
+		return fmt.Errorf("%s.routing_key is required", key)
+	}
+	// list entries don't get koanf defaults
+	if p.URL == "" {
+		p.URL = DefaultPagerDutyURL
+	}
+	if _, err := url.ParseRequestURI(p.URL); err != nil {
+		return fmt.Errorf("%s.url %s is not a valid URL: %w", key, p.URL, err)
+	}
+	return nil
+}
+
+// alertmanagerLabelName matches valid Prometheus label names
+var alertmanagerLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Alertmanager represents an Alertmanager v2 API integration
+type Alertmanager struct {
+	// URL is the Alertmanager base URL, alerts are POSTed to its /api/v2/alerts
+	URL string `koanf:"url" redact:"url"`
+	// Labels are added to the alert labels, e.g. to route it
+	Labels map[string]string `koanf:"labels"`
+	// Locale is the message catalog locale of the alert summary, defaults to notifications.locale
+	Locale string `koanf:"locale"`
+}
+
+// Validate validates the Alertmanager configuration, key is its config key e.g. notifications.alertmanager[0]
+func (a *Alertmanager) Validate(key string) error {
+	if _, err := url.ParseRequestURI(a.URL); err != nil {
+		return fmt.Errorf("%s.url %s is not a valid URL: %w", key, a.URL, err)
+	}
+	for name := range a.Labels {
+		if !alertmanagerLabelName.MatchString(name) {
+			return fmt.Errorf("%s.labels name %s is not a valid label name", key, name)
+		}
+	}
+	return nil
+}
+
+// validateNotificationEvents validates the events list of the notifier with config key key
+func validateNotificationEvents(key string, events []string) error {
+	for _, eventType := range events {
+		if !slices.Contains(constants.ValidNotificationEvents, eventType) {
+			return fmt.Errorf("%s.events must be one of %s - got: %s", key, strings.Join(constants.ValidNotificationEvents, ", "), eventType)
+		}
+	}
+	return nil
+}
+
+// validateCatalog validates the message catalog templates
+func (n *Notifications) validateCatalog() error {
+	for locale, messages := range n.Catalog {
+		for eventType, message := range messages {
+			if !slices.Contains(constants.ValidNotificationEvents, eventType) {
+				return fmt.Errorf("notifications.catalog.%s key must be one of %s - got: %s", locale, strings.Join(constants.ValidNotificationEvents, ", "), eventType)
+			}
+			for field, text := range map[string]string{"title": message.Title, "message": message.Message} {
+				if _, err := template.New(eventType).Parse(text); err != nil {
+					return fmt.Errorf("notifications.catalog.%s.%s.%s is not a valid template: %w", locale, eventType, field, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateLocale defaults the locale of the notifier with config key key to notifications.locale and checks the
+// catalog has it
+func (n *Notifications) validateLocale(key string, locale *string) error {
+	if *locale == "" {
+		*locale = n.Locale
+	}
+	if _, ok := n.Catalog[*locale]; !ok && *locale != constants.NotificationLocaleDefault {
+		return fmt.Errorf("%s is not in notifications.catalog and isn't the built-in %s - got: %s", key, constants.NotificationLocaleDefault, *locale)
+	}
+	return nil
+}
+
+// Validate validates the notifications configuration
+func (n *Notifications) Validate() error {
+	if err := n.validateCatalog(); err != nil {
+		return err
+	}
+	if err := n.validateLocale("notifications.locale", &n.Locale); err != nil {
+		return err
+	}
+
+	for i := range n.Webhooks {
+		webhook := &n.Webhooks[i]
+		if _, err := url.ParseRequestURI(webhook.URL); err != nil {
+			return fmt.Errorf("notifications.webhooks[%d].url %s is not a valid URL: %w", i, webhook.URL, err)
+		}
+
+		// list entries don't get koanf defaults
+		if webhook.MinSeverity == "" {
+			webhook.MinSeverity = constants.NotificationSeverityWarning
+		}
+		if !slices.Contains(constants.ValidNotificationSeverities, webhook.MinSeverity) {
+			return fmt.Errorf("notifications.webhooks[%d].min_severity must be one of %s - got: %s", i, strings.Join(constants.ValidNotificationSeverities, ", "), webhook.MinSeverity)
+		}
+		if err := validateNotificationEvents(fmt.Sprintf("notifications.webhooks[%d]", i), webhook.Events); err != nil {
+			return err
+		}
+		if webhook.Format == "" {
+			webhook.Format = constants.WebhookFormatJSON
+		}
+		if !slices.Contains(constants.ValidWebhookFormats, webhook.Format) {
+			return fmt.Errorf("notifications.webhooks[%d].format must be one of %s - got: %s", i, strings.Join(constants.ValidWebhookFormats, ", "), webhook.Format)
+		}
+	}
+	for i := range n.Slack {
+		if err := n.Slack[i].Validate(fmt.Sprintf("notifications.slack[%d]", i)); err != nil {
+			return err
+		}
+		if err := n.validateLocale(fmt.Sprintf("notifications.slack[%d].locale", i), &n.Slack[i].Locale); err != nil {
+			return err
+		}
+	}
+	for i := range n.Discord {
+		if err := n.Discord[i].Validate(fmt.Sprintf("notifications.discord[%d]", i)); err != nil {
+			return err
+		}
+		if err := n.validateLocale(fmt.Sprintf("notifications.discord[%d].locale", i), &n.Discord[i].Locale); err != nil {
+			return err
+		}
+	}
+	for i := range n.PagerDuty {
+		if err := n.PagerDuty[i].Validate(fmt.Sprintf("notifications.pagerduty[%d]", i)); err != nil {
+			return err
+		}
+		if err := n.validateLocale(fmt.Sprintf("notifications.pagerduty[%d].locale", i), &n.PagerDuty[i].Locale); err != nil {
+			return err
+		}
+	}
+	for i := range n.Alertmanager {
+		if err := n.Alertmanager[i].Validate(fmt.Sprintf("notifications.alertmanager[%d]", i)); err != nil {
+			return err
+		}
+		if err := n.validateLocale(fmt.Sprintf("notifications.alertmanager[%d].locale", i), &n.Alertmanager[i].Locale); err != nil {
+			return err
+		}
+	}
+
+	return n.Queue.Validate()
+}
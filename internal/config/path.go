@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
 )
 
 // ResolvePath resolves a file path to an absolute path
@@ -69,3 +71,13 @@ func IsFilePath(path string) bool {
 	return false
 }
 
+
+// resolveKeyRef resolves the path of a file identity keypair reference, dropping any file:// scheme - env:// and
+// exec:// references are returned as-is
+func resolveKeyRef(ref string, baseDir string) (string, error) {
+	provider, value := SplitKeyRef(ref)
+	if provider != constants.IdentityKeyProviderFile {
+		return ref, nil
+	}
+	return ResolvePath(value, baseDir)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// Reboot represents the host reboot-required detection configuration
+type Reboot struct {
+	// Policy controls what happens when a sync leaves the host requiring a reboot - one of disabled, notify, command
+	// Defaults to disabled
+	Policy string `koanf:"policy"`
+	// RequiredFiles are files whose existence signals a reboot is required, defaults to /var/run/reboot-required
+	RequiredFiles []string `koanf:"required_files"`
+	// Command is the command run after a successful sync that requires a reboot with the command policy, e.g. to
+	// schedule the reboot with shutdown -r 03:00
+	Command *sync_commands.Command `koanf:"command"`
+}
+
+// IsEnabled returns true if reboot-required detection is enabled
+func (r *Reboot) IsEnabled() bool {
+	return r.Policy != "" && r.Policy != constants.RebootPolicyDisabled
+}
+
+// Validate validates the reboot configuration
+func (r *Reboot) Validate() error {
+	if !slices.Contains(constants.ValidRebootPolicies, r.Policy) {
+		return fmt.Errorf("reboot.policy must be one of %s - got: %s", strings.Join(constants.ValidRebootPolicies, ", "), r.Policy)
+	}
+
+	if r.Policy == constants.RebootPolicyCommand && r.Command == nil {
+		return fmt.Errorf("reboot.command must be provided when reboot.policy is %s", r.Policy)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+const (
+	// redactedValue replaces secret values in the redacted config
+	redactedValue = "<redacted>"
+	// maxRedactedStringLength is the length strings are truncated to in the redacted config
+	maxRedactedStringLength = 256
+	// maxRedactedListLength is the number of items lists are truncated to in the redacted config
+	maxRedactedListLength = 20
+)
+
+// Redacted returns a representation of the config that is safe to log, keyed by config key
+// Fields tagged redact:"true" are masked (map keys are kept), fields tagged redact:"url" keep only the URL scheme and
+// host, and long strings and lists are truncated. Fields not loaded from the config file (e.g. keypairs) are omitted
+func (c *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+// redactStruct returns the redacted koanf-tagged fields of a struct
+func redactStruct(v reflect.Value) map[string]any {
+	redacted := map[string]any{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := field.Tag.Get("koanf")
+		if !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		redacted[key] = redactValue(v.Field(i), field.Tag.Get("redact"))
+	}
+	return redacted
+}
+
+// redactValue returns the redacted representation of a value with the given redact mode
+func redactValue(v reflect.Value, mode string) any {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), mode)
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Slice, reflect.Array:
+		items := []any{}
+		for i := 0; i < v.Len() && i < maxRedactedListLength; i++ {
+			items = append(items, redactValue(v.Index(i), mode))
+		}
+		if v.Len() > maxRedactedListLength {
+			items = append(items, fmt.Sprintf("... %d more", v.Len()-maxRedactedListLength))
+		}
+		return items
+	case reflect.Map:
+		redacted := map[string]any{}
+		iter := v.MapRange()
+		for iter.Next() {
+			redacted[fmt.Sprint(iter.Key().Interface())] = redactValue(iter.Value(), mode)
+		}
+		return redacted
+	case reflect.String:
+		return redactString(v.String(), mode)
+	default:
+		return v.Interface()
+	}
+}
+
+// redactString masks or truncates a string according to the redact mode - empty strings are kept so unset values
+// remain distinguishable
+func redactString(s, mode string) string {
+	switch {
+	case s == "":
+		return s
+	case mode == "true":
+		return redactedValue
+	case mode == "url":
+		return redactURL(s)
+	case len(s) > maxRedactedStringLength:
+		return fmt.Sprintf("%s... (%d more bytes)", s[:maxRedactedStringLength], len(s)-maxRedactedStringLength)
+	default:
+		return s
+	}
+}
+
+// redactURL keeps the scheme and host of a URL, masking any credentials, path and query which may carry tokens
+func redactURL(s string) string {
+	parsedURL, err := url.Parse(s)
+	if err != nil || parsedURL.Host == "" {
+		return redactedValue
+	}
+	redacted := parsedURL.Scheme + "://" + parsedURL.Host
+	if parsedURL.User != nil || (parsedURL.Path != "" && parsedURL.Path != "/") || parsedURL.RawQuery != "" || parsedURL.Fragment != "" {
+		redacted += "/" + redactedValue
+	}
+	return redacted
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfigReload represents the run --on-interval config reload configuration
+type ConfigReload struct {
+	// WatchInterval is how often run --on-interval checks the config file for changes between cycles, reloading it
+	// when it changed. Defaults to 0 - only reloaded on SIGHUP
+	WatchInterval time.Duration `koanf:"watch_interval"`
+}
+
+// Validate validates the config reload configuration
+func (r *ConfigReload) Validate() error {
+	if r.WatchInterval < 0 {
+		return fmt.Errorf("config_reload.watch_interval must be >= 0 - got: %s", r.WatchInterval)
+	}
+	return nil
+}
+
+// Reload loads the config file again with the same overrides and returns it, leaving c unchanged
+func (c *Config) Reload() (*Config, error) {
+	return NewFromConfigFileWithOverrides(c.File, c.overrides)
+}
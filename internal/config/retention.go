@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retention represents how much of the files the tool accumulates the cleanup subcommand keeps - the audit log
+// entries, config file backups left by migrate-config and the version source cache
+type Retention struct {
+	// MaxAge is how long audit log entries and config file backups are kept, and a version source cache file left
+	// unwritten, defaults to 0 - kept forever
+	MaxAge time.Duration `koanf:"max_age"`
+	// MaxSize caps the size of the audit log and the total size of the config file backups, each, the oldest removed
+	// first - e.g. 100MiB. Defaults to empty - no limit
+	MaxSize string `koanf:"max_size"`
+	// ParsedMaxSize is the parsed max size in bytes, 0 when unset
+	ParsedMaxSize int64 `koanf:"-"`
+}
+
+// IsEnabled returns true if a retention limit is configured
+func (r *Retention) IsEnabled() bool {
+	return r.MaxAge > 0 || r.ParsedMaxSize > 0
+}
+
+// Validate validates the retention configuration
+func (r *Retention) Validate() (err error) {
+	if r.MaxAge < 0 {
+		return fmt.Errorf("retention.max_age must be >= 0 - got: %s", r.MaxAge)
+	}
+
+	r.ParsedMaxSize = 0
+	if r.MaxSize != "" {
+		r.ParsedMaxSize, err = parseByteSize(r.MaxSize)
+		if err != nil {
+			return fmt.Errorf("retention.max_size %s is not a valid size: %w", r.MaxSize, err)
+		}
+	}
+	return nil
+}
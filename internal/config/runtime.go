@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteSizeUnits are the memory_limit suffixes and their multipliers, longest first so KiB matches before B
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+	{"B", 1},
+}
+
+// Runtime represents the limits the daemon puts on its own resource usage, it shares the host with a latency-sensitive
+// validator
+type Runtime struct {
+	// MaxProcs caps the CPUs running Go code at once (GOMAXPROCS), defaults to 0 - the GOMAXPROCS env var or all CPUs
+	MaxProcs int `koanf:"max_procs"`
+	// GCPercent is the garbage collection target percentage (GOGC), defaults to 0 - the GOGC env var or 100
+	// -1 disables garbage collection except to stay under MemoryLimit
+	GCPercent int `koanf:"gc_percent"`
+	// MemoryLimit is the soft memory limit the garbage collector works to stay under (GOMEMLIMIT), e.g. 256MiB
+	// Defaults to empty - the GOMEMLIMIT env var or no limit
+	MemoryLimit string `koanf:"memory_limit"`
+	// Watchdog abandons wedged sync cycles
+	Watchdog Watchdog `koanf:"watchdog"`
+	// ShutdownGracePeriod is how long a sync command running when the daemon is asked to shut down (SIGINT or SIGTERM)
+	// may take to finish before it is killed, defaults to 60s
+	ShutdownGracePeriod time.Duration `koanf:"shutdown_grace_period"`
+	// ParsedMemoryLimit is the parsed memory limit in bytes, 0 when unset
+	ParsedMemoryLimit int64 `koanf:"-"`
+}
+
+// Watchdog represents the sync cycle watchdog configuration
+type Watchdog struct {
+	// WedgedAfter is how long a sync cycle may run before it is considered wedged, logged with the goroutine stacks
+	// and abandoned for a fresh one - defaults to 0, disabled
+	WedgedAfter time.Duration `koanf:"wedged_after"`
+}
+
+// IsEnabled returns true when wedged sync cycles are abandoned
+func (w *Watchdog) IsEnabled() bool {
+	return w.WedgedAfter > 0
+}
+
+// Validate validates the runtime configuration
+func (r *Runtime) Validate() (err error) {
+	if r.MaxProcs < 0 {
+		return fmt.Errorf("runtime.max_procs must be >= 0 - got: %d", r.MaxProcs)
+	}
+	if r.GCPercent < -1 {
+		return fmt.Errorf("runtime.gc_percent must be >= -1 - got: %d", r.GCPercent)
+	}
+
+	r.ParsedMemoryLimit = 0
+	if r.MemoryLimit != "" {
+		r.ParsedMemoryLimit, err = parseByteSize(r.MemoryLimit)
+		if err != nil {
+			return fmt.Errorf("runtime.memory_limit %s is not a valid size: %w", r.MemoryLimit, err)
+		}
+	}
+	if r.GCPercent == -1 && r.ParsedMemoryLimit == 0 {
+		return fmt.Errorf("runtime.gc_percent -1 disables garbage collection and requires runtime.memory_limit")
+	}
+
+	if r.Watchdog.WedgedAfter < 0 {
+		return fmt.Errorf("runtime.watchdog.wedged_after must be >= 0 - got: %s", r.Watchdog.WedgedAfter)
+	}
+	if r.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("runtime.shutdown_grace_period must be >= 0 - got: %s", r.ShutdownGracePeriod)
+	}
+	return nil
+}
+
+// Apply applies the configured limits to the running process, leaving those not configured to the Go runtime and its
+// environment variables
+func (r *Runtime) Apply() {
+	if r.MaxProcs > 0 {
+		runtime.GOMAXPROCS(r.MaxProcs)
+	}
+	if r.GCPercent != 0 {
+		debug.SetGCPercent(r.GCPercent)
+	}
+	if r.ParsedMemoryLimit > 0 {
+		debug.SetMemoryLimit(r.ParsedMemoryLimit)
+	}
+}
+
+// parseByteSize parses a size with an optional B, KB, MB, GB, KiB, MiB or GiB suffix, e.g. 256MiB
+func parseByteSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(size, unit.suffix) {
+			size = strings.TrimSpace(strings.TrimSuffix(size, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("want a whole number with an optional B, KB, MB, GB, KiB, MiB or GiB suffix")
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("must be > 0")
+	}
+	if value > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("too large")
+	}
+	return value * multiplier, nil
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+const (
+	// ScaffoldPackageManagerApt generates sync commands installing the deb package with apt-get
+	ScaffoldPackageManagerApt = constants.PackageManagerApt
+	// ScaffoldPackageManagerDnf generates sync commands installing the rpm package with dnf
+	ScaffoldPackageManagerDnf = constants.PackageManagerDnf
+)
+
+// ValidScaffoldPackageManagers are the package managers config init generates sync commands for
+var ValidScaffoldPackageManagers = []string{ScaffoldPackageManagerApt, ScaffoldPackageManagerDnf}
+
+// ScaffoldOptions are the choices config init fills a starter config with
+type ScaffoldOptions struct {
+	// ClusterName is the cluster.name, one of constants.ValidClusterNames
+	ClusterName string
+	// PackageManager selects the active sync commands and version_source.format, one of ValidScaffoldPackageManagers
+	PackageManager string
+	// ValidatorRPCURL is the validator.rpc_url, the validator section is commented out when it and both identities are empty
+	ValidatorRPCURL string
+	// ActiveIdentity is the validator.identities.active file path
+	ActiveIdentity string
+	// PassiveIdentity is the validator.identities.passive file path
+	PassiveIdentity string
+}
+
+// Validate validates the scaffold options
+func (o *ScaffoldOptions) Validate() error {
+	if !slices.Contains(constants.ValidClusterNames, o.ClusterName) {
+		return fmt.Errorf("cluster must be one of %s - got: %q", strings.Join(constants.ValidClusterNames, ", "), o.ClusterName)
+	}
+	if !slices.Contains(ValidScaffoldPackageManagers, o.PackageManager) {
+		return fmt.Errorf("package manager must be one of %s - got: %q", strings.Join(ValidScaffoldPackageManagers, ", "), o.PackageManager)
+	}
+	if o.PassiveIdentity != "" && o.ActiveIdentity == "" {
+		return fmt.Errorf("a passive identity requires an active identity")
+	}
+	return nil
+}
+
+// validatorEnabled returns whether the scaffold has validator settings to write uncommented
+func (o *ScaffoldOptions) validatorEnabled() bool {
+	return o.ValidatorRPCURL != "" || o.ActiveIdentity != ""
+}
+
+// scaffoldTemplate is the starter config, with [[ ]] delimiters so the {{ }} sync command templates are kept as is
+var scaffoldTemplate = template.Must(template.New("config.yaml").Delims("[[", "]]").Parse(`# doublezero-version-sync configuration generated by config init
+# see https://github.com/sol-strategies/doublezero-version-sync#configuration for every option
+
+schema_version: [[ .SchemaVersion ]] # upgrade with migrate-config when a release changes the config schema
+
+log:
+  level: info  # optional, default: info, one of debug|info|warn|error|fatal
+  format: text # optional, default: text, one of text|logfmt|json
+
+[[- if .Validator ]]
+
+validator:
+  enabled_when_active: false # optional, default: false - sync only when validator is passive
+  rpc_url: [[ or .Options.ValidatorRPCURL "http://localhost:8899" ]] # optional, default: empty - the validator identity check is disabled when empty
+[[- if .Options.ActiveIdentity ]]
+  identities:
+    active: [[ .Options.ActiveIdentity ]] # required - path to validator active identity keyfile
+[[- if .Options.PassiveIdentity ]]
+    passive: [[ .Options.PassiveIdentity ]] # optional - path to validator passive identity keyfile
+[[- else ]]
+    # passive: /path/to/passive-identity.json # optional - path to validator passive identity keyfile
+[[- end ]]
+[[- end ]]
+[[- else ]]
+
+# uncomment to only sync while the validator runs as its passive identity
+# validator:
+#   enabled_when_active: false # optional, default: false - sync only when validator is passive
+#   rpc_url: http://localhost:8899 # optional, default: empty - the validator identity check is disabled when empty
+#   identities:
+#     active: /path/to/active-identity.json   # required - path to validator active identity keyfile
+#     passive: /path/to/passive-identity.json # optional - path to validator passive identity keyfile
+[[- end ]]
+
+cluster:
+  name: [[ .Options.ClusterName ]] # one of [[ .ClusterNames ]]
+
+doublezero:
+  bin: doublezero # optional, default: doublezero
+  # version_constraint: ">= 0.6.9" # optional - only sync to versions satisfying this constraint
+
+version_source:
+  type: cloudsmith_api # optional, default: cloudsmith_api, one of cloudsmith_api|cloudsmith_index|static|http_json|dns_txt|solana_account
+  format: [[ .Format ]]          # optional, default: deb, one of deb|rpm
+
+sync:
+  refresh_repos:
+    enabled: true                   # optional, default: false - refresh the package cache before the sync commands run
+    package_manager: [[ .Options.PackageManager ]]            # optional, default: apt, one of apt|dnf - runs apt-get update or dnf makecache
+    min_interval: 6h                # optional, default: 6h - refresh at most this often, however many cycles run
+  # Commands to run when there is a version change, in the order they are declared. cmd, args and environment values
+  # are templates, see templates lint for the fields available
+  commands:
+[[- if eq .Options.PackageManager "dnf" ]]
+    - name: install doublezero
+      cmd: dnf
+      args: ["install", "-y", "doublezero-{{ .RPMPackageVersionTo }}"]
+    # apt (Debian/Ubuntu) hosts install the deb package instead, with version_source.format: deb
+    # - name: install doublezero
+    #   cmd: apt-get
+    #   args: ["install", "-y", "--allow-downgrades", "doublezero={{ .PackageVersionTo }}"]
+    #   environment:
+    #     DEBIAN_FRONTEND: noninteractive
+[[- else ]]
+    - name: install doublezero
+      cmd: apt-get
+      args: ["install", "-y", "--allow-downgrades", "doublezero={{ .PackageVersionTo }}"]
+      environment:
+        DEBIAN_FRONTEND: noninteractive
+    # dnf (RHEL-family) hosts install the rpm package instead, with version_source.format: rpm
+    # - name: install doublezero
+    #   cmd: dnf
+    #   args: ["install", "-y", "doublezero-{{ .RPMPackageVersionTo }}"]
+[[- end ]]
+    - name: restart doublezerod
+      cmd: systemctl
+      args: ["restart", "doublezerod"]
+`))
+
+// Scaffold renders a starter config file for the given options
+func Scaffold(options ScaffoldOptions) ([]byte, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	format := constants.PackageFormatDeb
+	if options.PackageManager == ScaffoldPackageManagerDnf {
+		format = constants.PackageFormatRPM
+	}
+
+	var buf bytes.Buffer
+	err := scaffoldTemplate.Execute(&buf, struct {
+		Options       ScaffoldOptions
+		Validator     bool
+		Format        string
+		ClusterNames  string
+		SchemaVersion int
+	}{
+		Options:       options,
+		Validator:     options.validatorEnabled(),
+		Format:        format,
+		ClusterNames:  strings.Join(constants.ValidClusterNames, "|"),
+		SchemaVersion: SchemaVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
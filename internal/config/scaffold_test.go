@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScaffold_Loads(t *testing.T) {
+	// identity keypair files are only read once the validator is checked
+	active, passive := "/etc/solana/active-identity.json", "/etc/solana/passive-identity.json"
+
+	tests := []struct {
+		name               string
+		options            ScaffoldOptions
+		wantValidatorRPC   string
+		wantValidatorCheck bool
+	}{
+		{
+			name:    "apt without validator",
+			options: ScaffoldOptions{ClusterName: "testnet", PackageManager: ScaffoldPackageManagerApt},
+		},
+		{
+			name:    "dnf without validator",
+			options: ScaffoldOptions{ClusterName: "mainnet-beta", PackageManager: ScaffoldPackageManagerDnf},
+		},
+		{
+			name: "validator with both identities",
+			options: ScaffoldOptions{ClusterName: "testnet", PackageManager: ScaffoldPackageManagerApt,
+				ValidatorRPCURL: "http://127.0.0.1:8899", ActiveIdentity: active, PassiveIdentity: passive},
+			wantValidatorRPC:   "http://127.0.0.1:8899",
+			wantValidatorCheck: true,
+		},
+		{
+			name: "validator identity without rpc url",
+			options: ScaffoldOptions{ClusterName: "testnet", PackageManager: ScaffoldPackageManagerApt,
+				ActiveIdentity: active},
+			wantValidatorRPC:   "http://localhost:8899",
+			wantValidatorCheck: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents, err := Scaffold(tt.options)
+			if err != nil {
+				t.Fatalf("Scaffold() error = %v", err)
+			}
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, contents, 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cfg, err := NewFromConfigFile(file)
+			if err != nil {
+				t.Fatalf("NewFromConfigFile() error = %v, config:\n%s", err, contents)
+			}
+			if cfg.Cluster.Name != tt.options.ClusterName {
+				t.Errorf("cluster.name = %s, want %s", cfg.Cluster.Name, tt.options.ClusterName)
+			}
+			if cfg.Validator.RPCURL != tt.wantValidatorRPC || cfg.Validator.IsEnabled() != tt.wantValidatorCheck {
+				t.Errorf("validator.rpc_url = %q, enabled %v, want %q, enabled %v",
+					cfg.Validator.RPCURL, cfg.Validator.IsEnabled(), tt.wantValidatorRPC, tt.wantValidatorCheck)
+			}
+			if len(cfg.Sync.Commands) != 2 {
+				t.Errorf("sync.commands = %+v, want install and restart", cfg.Sync.Commands)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "fmt"
+
+// SchemaVersion is the current config schema version - bumped with a migration whenever keys are renamed or blocks
+// restructured, so migrate-config can upgrade older config files. Files without schema_version are schema 1
+const SchemaVersion = 1
+
+// validateSchemaVersion checks the config isn't written for a newer schema, and warns when it is for an older one
+func (c *Config) validateSchemaVersion() error {
+	schemaVersion := max(c.SchemaVersion, 1)
+	if schemaVersion > SchemaVersion {
+		return fmt.Errorf("schema_version %d is newer than the latest supported schema %d - upgrade doublezero-version-sync", c.SchemaVersion, SchemaVersion)
+	}
+	if schemaVersion < SchemaVersion {
+		c.logger.Warn("config schema is out of date - run migrate-config to upgrade it", "schema_version", schemaVersion, "latest", SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package config
+
+import "fmt"
+
+// State represents the persistent state configuration
+type State struct {
+	// File is the path of the JSON state file, defaults to state.json next to the config file
+	File string `koanf:"file"`
+	// HistorySize is how many of the most recent sync attempts are kept in the state file for the history command,
+	// defaults to 100 - 0 keeps only the last one
+	HistorySize int `koanf:"history_size"`
+}
+
+// Validate validates the persistent state configuration
+func (s *State) Validate() error {
+	if s.HistorySize < 0 {
+		return fmt.Errorf("state.history_size must be >= 0 - got: %d", s.HistorySize)
+	}
+	return nil
+}
@@ -1,6 +1,21 @@
 package config
 
 import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/diagnostics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/healthchecks"
+	"github.com/sol-strategies/doublezero-version-sync/internal/jitter"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
 	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
 )
 
@@ -8,10 +23,464 @@ import (
 type Sync struct {
 	// Commands are the commands to run when there is a version change
 	Commands []sync_commands.Command `koanf:"commands"`
+	// Include is a list of file globs of YAML fragments whose commands are appended to Commands in order
+	// Relative globs are resolved relative to the config file directory
+	Include []string `koanf:"include"`
+	// IncludedFiles are the files matched by Include, in the order their commands were appended
+	IncludedFiles []string `koanf:"-"`
+	// AllowRecommendationRollback allows acting on a recommended version lower than the previously observed recommendation
+	// Defaults to false - a recommendation rollback is treated as anomalous and blocks the sync
+	AllowRecommendationRollback bool `koanf:"allow_recommendation_rollback"`
+	// Anchor is what run --on-interval aligns interval boundaries to - one of midnight, startup, epoch, or a daily HH:MM
+	// time (UTC). Defaults to midnight
+	Anchor string `koanf:"anchor"`
+	// RunOnStart makes run --on-interval run a cycle as soon as it starts, then align the following cycles to the
+	// interval boundaries. Defaults to false - the first cycle waits for the first boundary
+	RunOnStart bool `koanf:"run_on_start"`
+	// OverrunPolicy is what run --on-interval does when a cycle is still running at the next boundary - one of
+	// skip_next, queue_one, abort_current. Defaults to skip_next
+	OverrunPolicy string `koanf:"overrun_policy"`
+	// DryRun evaluates every check and renders every command with its template data on each cycle without executing
+	// anything - no commands, failover requests, notifications or state updates. Defaults to false
+	DryRun bool `koanf:"dry_run"`
+	// AllowDowngrade allows executing commands when the target version is lower than the installed version
+	// Defaults to true - set false on fleets that never want automated downgrades
+	AllowDowngrade bool `koanf:"allow_downgrade"`
+	// Windows are the maintenance windows version changes are applied in, outside them a change stays pending
+	// None by default - changes are applied on any cycle
+	Windows []maintenance.Window `koanf:"windows"`
+	// ConfirmCycles is the number of consecutive cycles that must return the same target version before acting on it
+	// Defaults to 1 - act on the first cycle that returns it
+	ConfirmCycles int `koanf:"confirm_cycles"`
+	// MinVersionAge is how long a recommended version must have been published before acting on it, letting a release
+	// bake (e.g. on testnet) first. The publish time comes from the version source, or when it doesn't know it, the time
+	// the version was first recommended. Defaults to 0 - act immediately
+	MinVersionAge time.Duration `koanf:"min_version_age"`
+	// Cohort is the rollout cohort this node belongs to, one of the Cohorts names - it acts on a new target version only
+	// once the cohort's delay has passed since the version was first observed. None by default - act immediately
+	Cohort string `koanf:"cohort"`
+	// Cohorts maps rollout cohort names (e.g. canary, wave-2) to their delay after a new target version is first observed
+	Cohorts map[string]time.Duration `koanf:"cohorts"`
+	// Jitter is a min-max range (e.g. 0-45m) each node picks a delay from, waited after a new target version is first
+	// observed so a fleet doesn't upgrade all at once. The delay is deterministic per node, seeded from the hostname
+	// mixed with the validator identity, so hot-spare nodes sharing an identity pick different delays. Defaults to none
+	Jitter string `koanf:"jitter"`
+	// VerifyInstalled fails the sync when the binary doesn't report the target version after the commands ran
+	// Defaults to true
+	VerifyInstalled bool `koanf:"verify_installed"`
+	// VerifyPublished checks the target package version is published in the package repository before syncing
+	VerifyPublished VerifyPublished `koanf:"verify_published"`
+	// WaitForPackage is how long a cycle polls the sync.verify_published repository for a target package that isn't
+	// published yet before holding the change pending, e.g. while mirrors catch up with a release. Defaults to 0s - no wait
+	WaitForPackage time.Duration `koanf:"wait_for_package"`
+	// PreChecks are read-only commands or HTTP probes that must all succeed before the sync commands run, e.g.
+	// doublezero-agent status or a validator health endpoint - none by default
+	PreChecks []healthchecks.Check `koanf:"pre_checks"`
+	// PostChecks are read-only commands or HTTP probes retried after the sync commands run until they all succeed,
+	// failing the sync when they don't within their timeout
+	PostChecks PostChecks `koanf:"post_checks"`
+	// Diagnostics are read-only commands whose output is captured before and after the sync commands run
+	Diagnostics Diagnostics `koanf:"diagnostics"`
+	// Approval holds each sync plan until enough operators approve it with the approve subcommand
+	Approval Approval `koanf:"approval"`
+	// Lock is the lock file held while a cycle syncs, so a one-off run can't race a daemon running the sync commands
+	Lock SyncLock `koanf:"lock"`
+	// Retry retries a cycle that failed for a retryable reason with exponential backoff under run --on-interval, before
+	// the next interval boundary
+	Retry SyncRetry `koanf:"retry"`
+	// RefreshRepos refreshes the package manager cache before the sync commands run, at most once per MinInterval
+	RefreshRepos RefreshRepos `koanf:"refresh_repos"`
+	// UnreachableWindows is what run --on-interval does when none of Windows is ever open at an interval boundary - one
+	// of fail, adjust (also run a cycle when each window opens). Defaults to fail
+	UnreachableWindows string `koanf:"unreachable_windows"`
+}
+
+// SyncLock represents the sync lock file configuration
+type SyncLock struct {
+	// File is the path of the lock file, defaults to sync.lock next to the config file
+	File string `koanf:"file"`
+	// Wait is how long a cycle waits for another process holding the lock before it's blocked, defaults to 0 - fail fast
+	Wait time.Duration `koanf:"wait"`
+}
+
+// SyncRetry represents the retry configuration of failed cycles under run --on-interval
+type SyncRetry struct {
+	// MaxAttempts is how many times a failed cycle is retried before waiting for the next interval boundary, defaults to
+	// 0 - failed cycles aren't retried
+	MaxAttempts int `koanf:"max_attempts"`
+	// InitialBackoff is the wait before the first retry, doubling after each failed retry, defaults to 30s
+	InitialBackoff time.Duration `koanf:"initial_backoff"`
+	// MaxBackoff caps the wait between retries, defaults to 10m
+	MaxBackoff time.Duration `koanf:"max_backoff"`
+	// Reasons are the reason codes of the failed cycles retried, defaults to report.RetryableReasons - a cycle ending
+	// on any other reason, e.g. CONSTRAINT_UNSATISFIED or GATE_IDENTITY_ACTIVE, waits for the next interval boundary
+	Reasons []string `koanf:"reasons"`
+}
+
+// IsEnabled returns true if failed cycles are retried
+func (r *SyncRetry) IsEnabled() bool {
+	return r.MaxAttempts > 0
+}
+
+// IsRetryable returns true if a cycle that failed for the given reason is retried
+func (r *SyncRetry) IsRetryable(reason string) bool {
+	return r.IsEnabled() && slices.Contains(r.Reasons, reason)
+}
+
+// Backoff returns the wait before the given retry attempt, starting at 1
+func (r *SyncRetry) Backoff(attempt int) time.Duration {
+	backoff := r.InitialBackoff
+	for i := 1; i < attempt && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, r.MaxBackoff)
+}
+
+// Validate validates the failed cycle retry configuration
+func (r *SyncRetry) Validate() error {
+	if r.MaxAttempts < 0 {
+		return fmt.Errorf("sync.retry.max_attempts must be >= 0 - got: %d", r.MaxAttempts)
+	}
+	if !r.IsEnabled() {
+		return nil
+	}
+	if r.InitialBackoff <= 0 {
+		return fmt.Errorf("sync.retry.initial_backoff must be > 0 - got: %s", r.InitialBackoff)
+	}
+	if r.MaxBackoff < r.InitialBackoff {
+		return fmt.Errorf("sync.retry.max_backoff must be >= sync.retry.initial_backoff %s - got: %s", r.InitialBackoff, r.MaxBackoff)
+	}
+	for i, reason := range r.Reasons {
+		if !report.IsReason(reason) {
+			return fmt.Errorf("sync.retry.reasons[%d] must be a reason code - got: %s", i, reason)
+		}
+	}
+	return nil
+}
+
+// RefreshRepos represents the package cache refresh configuration
+type RefreshRepos struct {
+	// Enabled refreshes the package cache before the sync commands run, defaults to false
+	Enabled bool `koanf:"enabled"`
+	// PackageManager is the package manager whose cache is refreshed - apt (apt-get update) or dnf (dnf makecache)
+	// Defaults to apt
+	PackageManager string `koanf:"package_manager"`
+	// MinInterval is the minimum time between refreshes, counted from the last successful one across cycles and
+	// restarts, defaults to 6h
+	MinInterval time.Duration `koanf:"min_interval"`
+}
+
+// Approval represents the sync plan approval configuration
+type Approval struct {
+	// Enabled holds each sync plan until it has RequiredApprovals approvals from distinct operators, defaults to false
+	Enabled bool `koanf:"enabled"`
+	// RequiredApprovals is how many distinct operators must approve a plan before it executes, defaults to 2
+	RequiredApprovals int `koanf:"required_approvals"`
+	// Approvers are the operators allowed to approve plans, each signing their approvals with their own key so an
+	// approval can't be recorded in another operator's name - at least RequiredApprovals are required when enabled
+	Approvers []Approver `koanf:"approvers"`
+}
+
+// Approver represents an operator allowed to approve sync plans
+type Approver struct {
+	// Name identifies the approver in approvals, logs and notifications
+	Name string `koanf:"name"`
+	// Pubkey is the base58 ed25519 public key the approver signs approvals with, e.g. of a solana-keygen keypair
+	Pubkey string `koanf:"pubkey"`
+}
+
+// Diagnostics represents the diagnostic snapshot configuration
+type Diagnostics struct {
+	// Commands are the read-only diagnostic commands to capture, e.g. doublezero status - none by default
+	Commands []diagnostics.Command `koanf:"commands"`
+	// Timeout is the maximum time each diagnostic command may run, defaults to 10s
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+// PostChecks represents the post-sync health check configuration
+type PostChecks struct {
+	// Checks are the checks that must all succeed, e.g. doublezero status reporting up - none by default
+	Checks []healthchecks.Check `koanf:"checks"`
+	// Timeout is how long the checks are retried before the sync is failed, defaults to 2m
+	Timeout time.Duration `koanf:"timeout"`
+	// RetryInterval is the wait before the first retry, doubling after each failed attempt, defaults to 5s
+	RetryInterval time.Duration `koanf:"retry_interval"`
+	// MaxRetryInterval caps the wait between retries, defaults to 30s
+	MaxRetryInterval time.Duration `koanf:"max_retry_interval"`
+}
+
+// VerifyPublished represents the target package published check configuration
+type VerifyPublished struct {
+	// Enabled fails the sync before any command runs when the target package version isn't in the repository index
+	// Defaults to false
+	Enabled bool `koanf:"enabled"`
+	// Repository is the repository index checked - a cloudsmith_index version source, only its format, arch, distro,
+	// distro_version, base_url, package_name and timeout are used
+	Repository VersionSource `koanf:"repository"`
+}
+
+// syncFragment is the structure of a YAML fragment included via sync.include
+type syncFragment struct {
+	Commands []sync_commands.Command `koanf:"commands"`
 }
 
 // Validate validates the sync configuration
 func (s *Sync) Validate() error {
-	// This function is kept for any other sync-specific validation that might be needed
+	if err := constants.ValidateSyncAnchor(s.Anchor); err != nil {
+		return fmt.Errorf("sync.anchor: %w", err)
+	}
+
+	if !slices.Contains(constants.ValidSyncOverrunPolicies, s.OverrunPolicy) {
+		return fmt.Errorf("sync.overrun_policy must be one of %s - got: %s", strings.Join(constants.ValidSyncOverrunPolicies, ", "), s.OverrunPolicy)
+	}
+
+	if s.ConfirmCycles < 1 {
+		return fmt.Errorf("sync.confirm_cycles must be >= 1 - got: %d", s.ConfirmCycles)
+	}
+
+	if s.MinVersionAge < 0 {
+		return fmt.Errorf("sync.min_version_age must be >= 0 - got: %s", s.MinVersionAge)
+	}
+
+	for name, delay := range s.Cohorts {
+		if delay < 0 {
+			return fmt.Errorf("sync.cohorts.%s must be >= 0 - got: %s", name, delay)
+		}
+	}
+	if _, ok := s.Cohorts[s.Cohort]; s.Cohort != "" && !ok {
+		names := slices.Sorted(maps.Keys(s.Cohorts))
+		return fmt.Errorf("sync.cohort must be one of the sync.cohorts names [%s] - got: %s", strings.Join(names, ", "), s.Cohort)
+	}
+
+	if _, err := jitter.ParseRange(s.Jitter); err != nil {
+		return fmt.Errorf("sync.jitter: %w", err)
+	}
+
+	if _, err := maintenance.NewSchedule(s.Windows); err != nil {
+		return fmt.Errorf("sync.windows%w", err)
+	}
+
+	if !slices.Contains(constants.ValidSyncUnreachableWindows, s.UnreachableWindows) {
+		return fmt.Errorf("sync.unreachable_windows must be one of %s - got: %s", strings.Join(constants.ValidSyncUnreachableWindows, ", "), s.UnreachableWindows)
+	}
+
+	if s.Lock.Wait < 0 {
+		return fmt.Errorf("sync.lock.wait must be >= 0 - got: %s", s.Lock.Wait)
+	}
+
+	if err := s.Retry.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.RefreshRepos.Validate(); err != nil {
+		return err
+	}
+
+	if s.WaitForPackage < 0 {
+		return fmt.Errorf("sync.wait_for_package must be >= 0 - got: %s", s.WaitForPackage)
+	}
+	if s.WaitForPackage > 0 && !s.VerifyPublished.Enabled {
+		return fmt.Errorf("sync.wait_for_package requires sync.verify_published.enabled - got: %s", s.WaitForPackage)
+	}
+
+	if s.VerifyPublished.Enabled {
+		repository := &s.VerifyPublished.Repository
+		repository.Type = constants.VersionSourceTypeCloudsmithIndex
+		repository.setDefaults()
+		if err := repository.validateSource("sync.verify_published.repository"); err != nil {
+			return err
+		}
+	}
+
+	// run the commands in dependency order everywhere they are rendered, planned or executed
+	commands, err := sync_commands.Order(s.Commands)
+	if err != nil {
+		return fmt.Errorf("sync.commands: %w", err)
+	}
+	s.Commands = commands
+
+	if err := validateHealthChecks("sync.pre_checks", s.PreChecks); err != nil {
+		return err
+	}
+
+	if err := s.PostChecks.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Diagnostics.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Approval.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the package cache refresh configuration
+func (r *RefreshRepos) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+
+	if !slices.Contains(constants.ValidPackageManagers, r.PackageManager) {
+		return fmt.Errorf("sync.refresh_repos.package_manager must be one of %s - got: %s", strings.Join(constants.ValidPackageManagers, ", "), r.PackageManager)
+	}
+
+	if r.MinInterval < 0 {
+		return fmt.Errorf("sync.refresh_repos.min_interval must be >= 0 - got: %s", r.MinInterval)
+	}
+
+	return nil
+}
+
+// Validate validates the sync plan approval configuration
+func (a *Approval) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+
+	if a.RequiredApprovals < 1 {
+		return fmt.Errorf("sync.approval.required_approvals must be >= 1 - got: %d", a.RequiredApprovals)
+	}
+
+	if len(a.Approvers) < a.RequiredApprovals {
+		return fmt.Errorf("sync.approval.approvers lists %d approvers, fewer than required_approvals (%d)", len(a.Approvers), a.RequiredApprovals)
+	}
+	for i, approver := range a.Approvers {
+		if approver.Name == "" {
+			return fmt.Errorf("sync.approval.approvers[%d].name is required", i)
+		}
+		if _, err := solana.PublicKeyFromBase58(approver.Pubkey); err != nil {
+			return fmt.Errorf("sync.approval.approvers[%d].pubkey must be a base58 public key - got: %s", i, approver.Pubkey)
+		}
+		for _, previous := range a.Approvers[:i] {
+			if previous.Name == approver.Name {
+				return fmt.Errorf("sync.approval.approvers[%d].name %s is not unique", i, approver.Name)
+			}
+			if previous.Pubkey == approver.Pubkey {
+				return fmt.Errorf("sync.approval.approvers[%d].pubkey is also the key of %s - each approver needs their own key", i, previous.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ApproverByName returns the approver with the given name, false when none is configured
+func (a *Approval) ApproverByName(name string) (Approver, bool) {
+	i := slices.IndexFunc(a.Approvers, func(approver Approver) bool { return approver.Name == name })
+	if i < 0 {
+		return Approver{}, false
+	}
+	return a.Approvers[i], true
+}
+
+// ApproverByPubkey returns the approver signing with the given public key, false when none is configured
+func (a *Approval) ApproverByPubkey(pubkey string) (Approver, bool) {
+	i := slices.IndexFunc(a.Approvers, func(approver Approver) bool { return approver.Pubkey == pubkey })
+	if i < 0 {
+		return Approver{}, false
+	}
+	return a.Approvers[i], true
+}
+
+// Validate validates the post-sync health check configuration
+func (p *PostChecks) Validate() error {
+	if p.Timeout <= 0 {
+		return fmt.Errorf("sync.post_checks.timeout must be > 0 - got: %s", p.Timeout)
+	}
+	if p.RetryInterval <= 0 {
+		return fmt.Errorf("sync.post_checks.retry_interval must be > 0 - got: %s", p.RetryInterval)
+	}
+	if p.MaxRetryInterval < p.RetryInterval {
+		return fmt.Errorf("sync.post_checks.max_retry_interval must be >= retry_interval (%s) - got: %s", p.RetryInterval, p.MaxRetryInterval)
+	}
+
+	return validateHealthChecks("sync.post_checks.checks", p.Checks)
+}
+
+// validateHealthChecks validates the health checks configured under the given key
+func validateHealthChecks(key string, checks []healthchecks.Check) error {
+	names := map[string]bool{}
+	for i, check := range checks {
+		if check.Name == "" {
+			return fmt.Errorf("%s[%d].name is required", key, i)
+		}
+		if names[check.Name] {
+			return fmt.Errorf("%s[%d].name %s is not unique", key, i, check.Name)
+		}
+		names[check.Name] = true
+
+		if (check.Cmd == "") == (check.URL == "") {
+			return fmt.Errorf("%s[%d] must set exactly one of cmd or url", key, i)
+		}
+		if check.URL != "" {
+			if _, err := url.ParseRequestURI(check.URL); err != nil {
+				return fmt.Errorf("%s[%d].url %s is not a valid URL: %w", key, i, check.URL, err)
+			}
+		}
+		if check.ExpectStatus != 0 && (check.ExpectStatus < 100 || check.ExpectStatus > 599) {
+			return fmt.Errorf("%s[%d].expect_status must be a valid HTTP status - got: %d", key, i, check.ExpectStatus)
+		}
+		if _, err := regexp.Compile(check.ExpectOutput); err != nil {
+			return fmt.Errorf("%s[%d].expect_output is not a valid regular expression: %w", key, i, err)
+		}
+		if check.Timeout < 0 {
+			return fmt.Errorf("%s[%d].timeout must be >= 0 - got: %s", key, i, check.Timeout)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the diagnostic snapshot configuration
+func (d *Diagnostics) Validate() error {
+	if d.Timeout <= 0 {
+		return fmt.Errorf("sync.diagnostics.timeout must be > 0 - got: %s", d.Timeout)
+	}
+
+	names := map[string]bool{}
+	for i, command := range d.Commands {
+		if command.Name == "" {
+			return fmt.Errorf("sync.diagnostics.commands[%d].name is required", i)
+		}
+		if command.Cmd == "" {
+			return fmt.Errorf("sync.diagnostics.commands[%d].cmd is required", i)
+		}
+		if names[command.Name] {
+			return fmt.Errorf("sync.diagnostics.commands[%d].name %s is not unique", i, command.Name)
+		}
+		names[command.Name] = true
+	}
+
+	return nil
+}
+
+// loadIncludes appends the commands from all fragments matched by the include globs
+// Files matched by a single glob are loaded in lexical order
+func (s *Sync) loadIncludes(configDir string) error {
+	s.IncludedFiles = nil
+	matches, err := globIncludes("sync.include", s.Include, configDir)
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		k, err := loadFile(match)
+		if err != nil {
+			return fmt.Errorf("error loading sync.include file %s: %w", match, err)
+		}
+
+		var fragment syncFragment
+		if err := k.Unmarshal("", &fragment); err != nil {
+			return fmt.Errorf("error unmarshaling sync.include file %s: %w", match, err)
+		}
+
+		s.Commands = append(s.Commands, fragment.Commands...)
+		s.IncludedFiles = append(s.IncludedFiles, match)
+	}
+
 	return nil
 }
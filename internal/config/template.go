@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+const (
+	// templateLeftDelim and templateRightDelim delimit config file template actions so they don't clash with
+	// sync command templates, which use the default {{ }} delimiters and are rendered at sync time
+	templateLeftDelim  = "${{"
+	templateRightDelim = "}}"
+)
+
+// TemplateFacts are the host facts available to the config file templating pass
+type TemplateFacts struct {
+	// Hostname is the host name reported by the kernel
+	Hostname string
+	// OS is the operating system (runtime.GOOS)
+	OS string
+	// Arch is the architecture (runtime.GOARCH)
+	Arch string
+	// Env is the process environment
+	Env map[string]string
+}
+
+// newTemplateFacts gathers the host facts for the config file templating pass
+func newTemplateFacts() (TemplateFacts, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return TemplateFacts{}, fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		env[name] = value
+	}
+
+	return TemplateFacts{
+		Hostname: hostname,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Env:      env,
+	}, nil
+}
+
+// renderTemplate renders the config file contents with host facts, e.g. ${{ .Hostname }} or ${{ env "CLUSTER" }}
+// Referencing a missing fact is an error so a typo can't silently produce an empty value - envOr opts in to a fallback
+// for an environment variable that may not be set
+func renderTemplate(name string, contents []byte) ([]byte, error) {
+	facts, err := newTemplateFacts()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).
+		Delims(templateLeftDelim, templateRightDelim).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"env": func(name string) (string, error) {
+				value, ok := facts.Env[name]
+				if !ok {
+					return "", fmt.Errorf("environment variable %s is not set", name)
+				}
+				return value, nil
+			},
+			"envOr": func(name, fallback string) string {
+				if value, ok := facts.Env[name]; ok && value != "" {
+					return value
+				}
+				return fallback
+			},
+			"default": func(fallback, value string) string {
+				if value == "" {
+					return fallback
+				}
+				return value
+			},
+		}).
+		Parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, facts); err != nil {
+		return nil, fmt.Errorf("failed to render config template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
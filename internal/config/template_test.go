@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	t.Setenv("DZVS_TEST_CLUSTER", "testnet")
+	t.Setenv("DZVS_TEST_EMPTY", "")
+	os.Unsetenv("DZVS_TEST_MISSING")
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+		wantErr  string
+	}{
+		{name: "env function", contents: `name: ${{ env "DZVS_TEST_CLUSTER" }}`, want: "name: testnet"},
+		{name: "env field", contents: `name: ${{ .Env.DZVS_TEST_CLUSTER }}`, want: "name: testnet"},
+		{name: "host facts", contents: `host: ${{ .Hostname }}`, want: "host: " + hostname},
+		{name: "env function missing", contents: `name: ${{ env "DZVS_TEST_MISSING" }}`, wantErr: "DZVS_TEST_MISSING is not set"},
+		{name: "env field missing", contents: `name: ${{ .Env.DZVS_TEST_MISSING }}`, wantErr: "DZVS_TEST_MISSING"},
+		{name: "unknown fact", contents: `name: ${{ .Region }}`, wantErr: "Region"},
+		{name: "envOr missing", contents: `name: ${{ envOr "DZVS_TEST_MISSING" "mainnet-beta" }}`, want: "name: mainnet-beta"},
+		{name: "envOr empty", contents: `name: ${{ envOr "DZVS_TEST_EMPTY" "mainnet-beta" }}`, want: "name: mainnet-beta"},
+		{name: "envOr set", contents: `name: ${{ envOr "DZVS_TEST_CLUSTER" "mainnet-beta" }}`, want: "name: testnet"},
+		{name: "default empty", contents: `name: ${{ default "mainnet-beta" .Env.DZVS_TEST_EMPTY }}`, want: "name: mainnet-beta"},
+		{name: "default set", contents: `name: ${{ default "mainnet-beta" .Env.DZVS_TEST_CLUSTER }}`, want: "name: testnet"},
+		{name: "default missing", contents: `name: ${{ default "mainnet-beta" .Env.DZVS_TEST_MISSING }}`, wantErr: "DZVS_TEST_MISSING"},
+		{
+			name:     "sync command templates pass through",
+			contents: `args: ["install", "doublezero={{ .PackageVersionTo }}", "${{ env "DZVS_TEST_CLUSTER" }}"]`,
+			want:     `args: ["install", "doublezero={{ .PackageVersionTo }}", "testnet"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate("config.yml", []byte(tt.contents))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderTemplate() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderTemplate() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
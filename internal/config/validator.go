@@ -3,50 +3,123 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
 )
 
 // Validator represents the validator configuration
 type Validator struct {
 	// RPCURL is the URL of the validator's RPC endpoint
-	RPCURL string `koanf:"rpc_url"`
+	RPCURL string `koanf:"rpc_url" redact:"url"`
 	// EnabledWhenActive allows sync when validator is running as active identity
 	// Defaults to false - sync only allowed when validator is passive
 	EnabledWhenActive bool `koanf:"enabled_when_active"`
+	// ActiveAckTTL requires an operator acknowledgement (the ack-active command) recorded within this long for a sync
+	// to proceed while the validator is active under enabled_when_active, so it can't silently stay enabled forever
+	// Defaults to 0 - no acknowledgement required
+	ActiveAckTTL time.Duration `koanf:"active_ack_ttl"`
 	// Identities are the paths to the active and passive identity keyfiles
 	Identities Identities `koanf:"identities"`
+	// RPCMaxRequestsPerSecond caps the rate of requests sent to the validator RPC, 0 means unlimited
+	// Defaults to 10
+	RPCMaxRequestsPerSecond float64 `koanf:"rpc_max_requests_per_second"`
+	// OnUnreachable is the behavior when the validator RPC cannot be reached - one of fail, skip_gate, monitor_only
+	// Defaults to fail
+	OnUnreachable string `koanf:"on_unreachable"`
+	// VerifyCluster checks the validator's genesis hash belongs to the configured cluster before syncing, so a copied
+	// config can't apply one cluster's recommendations to another cluster's host. Defaults to false
+	VerifyCluster bool `koanf:"verify_cluster"`
+	// WaitForPassive optionally waits for the validator to become passive before syncing
+	WaitForPassive WaitForPassive `koanf:"wait_for_passive"`
+	// IdentitySource is where the identity the validator runs as is read from, the RPC by default
+	IdentitySource IdentitySource `koanf:"identity_source"`
+}
+
+// IdentitySource represents where the identity the validator is running as is read from
+type IdentitySource struct {
+	// Type is one of rpc (the validator RPC getIdentity), file (a keypair or public key file the validator or its
+	// failover tooling writes, e.g. the identity symlink swapped on failover) or static. Defaults to rpc
+	Type string `koanf:"type"`
+	// File is the path to the file read by the file type, relative to the config file directory
+	File string `koanf:"file"`
+	// Identity is the base58 public key the validator is assumed to run as by the static type
+	Identity string `koanf:"identity"`
+}
+
+// WaitForPassive represents the wait-for-passive configuration
+type WaitForPassive struct {
+	// Timeout is how long to wait for the validator to become passive when a sync is required, 0 disables waiting
+	Timeout time.Duration `koanf:"timeout"`
+	// PollInterval is how often the validator identity is polled while waiting, defaults to 10s
+	PollInterval time.Duration `koanf:"poll_interval"`
 }
 
 // Identities represents the validator identity configuration
 type Identities struct {
-	// Active is the path to the active identity keyfile
-	ActiveKeyPairFile string `koanf:"active"`
-	// Passive is the path to the passive identity keyfile
-	PassiveKeyPairFile string `koanf:"passive"`
-	// ActiveKeyPair is the loaded active keypair
-	ActiveKeyPair solana.PrivateKey `koanf:"-"`
-	// PassiveKeyPair is the loaded passive keypair
-	PassiveKeyPair solana.PrivateKey `koanf:"-"`
+	// Active is the path to the active identity keypair file, or a file holding just its base58 public key
+	// Only the public key is kept, the file is re-read when it changes so rotated identities are picked up
+	// Also accepts env://VAR to read the keypair or public key from an environment variable, exec://command args... to
+	// read it from a command's output (e.g. a Vault agent or HSM wrapper) and file://path for an explicit file
+	ActiveKeyPairFile string `koanf:"active" redact:"true"`
+	// Passive is the path to the passive identity keypair or public key file, or an env://, exec:// or file:// reference
+	// Optional - when not set the validator is treated as a single-identity (non-failover) setup
+	PassiveKeyPairFile string `koanf:"passive" redact:"true"`
+	// ActivePubkey is the base58 public key of the active identity, used instead of Active so no private key is ever
+	// read - the identity check only compares public keys
+	ActivePubkey string `koanf:"active_pubkey"`
+	// PassivePubkey is the base58 public key of the passive identity, used instead of Passive
+	PassivePubkey string `koanf:"passive_pubkey"`
+	// WatchInterval is how often run --on-interval checks the identity files for rotation between cycles, running a
+	// cycle early to re-evaluate gating when they change. Defaults to 10s, 0 only reloads them on each cycle
+	WatchInterval time.Duration `koanf:"watch_interval"`
 }
 
-// Load loads the identity keypairs from files
-func (i *Identities) Load() (err error) {
-	// Load active identity
-	i.ActiveKeyPair, err = solana.PrivateKeyFromSolanaKeygenFile(i.ActiveKeyPairFile)
-	if err != nil {
-		return fmt.Errorf("failed to load active keypair from %s: %w", i.ActiveKeyPairFile, err)
+// SplitKeyRef splits an identity keypair reference into its provider and value - a path without a provider scheme is
+// a file, e.g. env://VALIDATOR_IDENTITY is the env provider reading VALIDATOR_IDENTITY
+func SplitKeyRef(ref string) (provider, value string) {
+	provider, value, found := strings.Cut(ref, "://")
+	if !found {
+		return constants.IdentityKeyProviderFile, ref
 	}
+	return provider, value
+}
 
-	// Load passive identity
-	i.PassiveKeyPair, err = solana.PrivateKeyFromSolanaKeygenFile(i.PassiveKeyPairFile)
-	if err != nil {
-		return fmt.Errorf("failed to load passive keypair from %s: %w", i.PassiveKeyPairFile, err)
+// validateKeyRef validates the identity keypair reference set at key
+func validateKeyRef(key, ref string) error {
+	provider, value := SplitKeyRef(ref)
+	if !slices.Contains(constants.ValidIdentityKeyProviders, provider) {
+		return fmt.Errorf("%s provider must be one of %s - got: %s", key, strings.Join(constants.ValidIdentityKeyProviders, ", "), provider)
+	}
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s must set a %s after %s://", key, map[string]string{
+			constants.IdentityKeyProviderFile: "path",
+			constants.IdentityKeyProviderEnv:  "variable name",
+			constants.IdentityKeyProviderExec: "command",
+		}[provider], provider)
 	}
-
 	return nil
 }
 
+// IsEnabled returns true if the validator identity is checked - when validator.rpc_url is set or the identity is read
+// from another source than the RPC
+func (v *Validator) IsEnabled() bool {
+	return v.RPCURL != "" || (v.IdentitySource.Type != "" && v.IdentitySource.Type != constants.ValidatorIdentitySourceRPC)
+}
+
+// IsConfigured returns true if the active identity is configured, as a keypair file or a public key
+func (i *Identities) IsConfigured() bool {
+	return i.ActiveKeyPairFile != "" || i.ActivePubkey != ""
+}
+
+// IsSingleIdentity returns true if only the active identity is configured (no hot-spare failover)
+func (i *Identities) IsSingleIdentity() bool {
+	return i.PassiveKeyPairFile == "" && i.PassivePubkey == ""
+}
+
 // Validate validates the validator configuration
 func (v *Validator) Validate() error {
 	// Validate RPC URL
@@ -57,5 +130,99 @@ func (v *Validator) Validate() error {
 		}
 	}
 
+	// Validate RPC rate limit
+	if v.RPCMaxRequestsPerSecond < 0 {
+		return fmt.Errorf("validator.rpc_max_requests_per_second must be >= 0 - got: %v", v.RPCMaxRequestsPerSecond)
+	}
+
+	// Validate wait for passive
+	if v.WaitForPassive.Timeout < 0 {
+		return fmt.Errorf("validator.wait_for_passive.timeout must be >= 0 - got: %s", v.WaitForPassive.Timeout)
+	}
+	if v.WaitForPassive.Timeout > 0 && v.WaitForPassive.PollInterval <= 0 {
+		return fmt.Errorf("validator.wait_for_passive.poll_interval must be > 0 - got: %s", v.WaitForPassive.PollInterval)
+	}
+
+	// Validate identity public keys, each set instead of its keypair file
+	if v.Identities.ActivePubkey != "" && v.Identities.ActiveKeyPairFile != "" {
+		return fmt.Errorf("validator.identities.active_pubkey and validator.identities.active are mutually exclusive")
+	}
+	if v.Identities.PassivePubkey != "" && v.Identities.PassiveKeyPairFile != "" {
+		return fmt.Errorf("validator.identities.passive_pubkey and validator.identities.passive are mutually exclusive")
+	}
+	if v.Identities.ActivePubkey != "" {
+		if _, err := solana.PublicKeyFromBase58(v.Identities.ActivePubkey); err != nil {
+			return fmt.Errorf("validator.identities.active_pubkey must be a base58 public key - got: %q", v.Identities.ActivePubkey)
+		}
+	}
+	if v.Identities.PassivePubkey != "" {
+		if _, err := solana.PublicKeyFromBase58(v.Identities.PassivePubkey); err != nil {
+			return fmt.Errorf("validator.identities.passive_pubkey must be a base58 public key - got: %q", v.Identities.PassivePubkey)
+		}
+	}
+
+	// Validate identity keypair references
+	if v.Identities.ActiveKeyPairFile != "" {
+		if err := validateKeyRef("validator.identities.active", v.Identities.ActiveKeyPairFile); err != nil {
+			return err
+		}
+	}
+	if v.Identities.PassiveKeyPairFile != "" {
+		if err := validateKeyRef("validator.identities.passive", v.Identities.PassiveKeyPairFile); err != nil {
+			return err
+		}
+	}
+
+	// Validate identity watching
+	if v.Identities.WatchInterval < 0 {
+		return fmt.Errorf("validator.identities.watch_interval must be >= 0 - got: %s", v.Identities.WatchInterval)
+	}
+
+	// Validate active sync acknowledgement
+	if v.ActiveAckTTL < 0 {
+		return fmt.Errorf("validator.active_ack_ttl must be >= 0 - got: %s", v.ActiveAckTTL)
+	}
+
+	// Validate the identity source
+	if err := v.IdentitySource.Validate(); err != nil {
+		return err
+	}
+	// the genesis hash is only known to the validator RPC
+	if v.VerifyCluster && v.RPCURL == "" && v.IsEnabled() {
+		return fmt.Errorf("validator.verify_cluster requires validator.rpc_url")
+	}
+
+	// Validate unreachable behavior
+	if !slices.Contains(constants.ValidValidatorOnUnreachableValues, v.OnUnreachable) {
+		return fmt.Errorf("validator.on_unreachable must be one of %s - got: %s", strings.Join(constants.ValidValidatorOnUnreachableValues, ", "), v.OnUnreachable)
+	}
+
+	return nil
+}
+
+// Validate validates the identity source configuration
+func (i *IdentitySource) Validate() error {
+	if !slices.Contains(constants.ValidValidatorIdentitySources, i.Type) {
+		return fmt.Errorf("validator.identity_source.type must be one of %s - got: %s", strings.Join(constants.ValidValidatorIdentitySources, ", "), i.Type)
+	}
+
+	switch i.Type {
+	case constants.ValidatorIdentitySourceFile:
+		if i.File == "" {
+			return fmt.Errorf("validator.identity_source.file is required with validator.identity_source.type=file")
+		}
+	case constants.ValidatorIdentitySourceStatic:
+		if _, err := solana.PublicKeyFromBase58(i.Identity); err != nil {
+			return fmt.Errorf("validator.identity_source.identity must be a base58 public key with validator.identity_source.type=static - got: %q", i.Identity)
+		}
+	}
+
+	if i.Type != constants.ValidatorIdentitySourceFile && i.File != "" {
+		return fmt.Errorf("validator.identity_source.file is only valid with validator.identity_source.type=file")
+	}
+	if i.Type != constants.ValidatorIdentitySourceStatic && i.Identity != "" {
+		return fmt.Errorf("validator.identity_source.identity is only valid with validator.identity_source.type=static")
+	}
+
 	return nil
 }
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// VersionConstraintDefaultKey is the doublezero.version_constraint map key of the constraint applied on clusters
+// without their own entry
+const VersionConstraintDefaultKey = "default"
+
+// VersionConstraint is the doublezero.version_constraint - either a single constraint applied on every cluster,
+// or a map of constraints keyed by cluster name so one config template can express a risk tolerance per cluster
+type VersionConstraint struct {
+	// Value is the constraint applied on every cluster, set when configured as a string
+	Value string
+	// ByCluster are the constraints keyed by cluster name, set when configured as a map - the
+	// VersionConstraintDefaultKey entry applies on clusters without their own
+	ByCluster map[string]string
+}
+
+// validateClusterNames returns an error if a per-cluster constraint is keyed by an unknown cluster name
+func (v VersionConstraint) validateClusterNames() error {
+	for clusterName := range v.ByCluster {
+		if clusterName != VersionConstraintDefaultKey && !slices.Contains(constants.ValidClusterNames, clusterName) {
+			return fmt.Errorf("doublezero.version_constraint has unknown cluster %s - must be one of %s or %s", clusterName,
+				strings.Join(constants.ValidClusterNames, ", "), VersionConstraintDefaultKey)
+		}
+	}
+	return nil
+}
+
+// ForCluster returns the constraint applied on the cluster - its own entry, otherwise the global one, empty when
+// there is none
+func (v VersionConstraint) ForCluster(clusterName string) string {
+	if v.ByCluster == nil {
+		return v.Value
+	}
+	if constraint, ok := v.ByCluster[clusterName]; ok {
+		return constraint
+	}
+	return v.ByCluster[VersionConstraintDefaultKey]
+}
+
+// versionConstraintHookFunc decodes a doublezero.version_constraint string or map of cluster name to string
+func versionConstraintHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != reflect.TypeOf(VersionConstraint{}) {
+			return data, nil
+		}
+
+		switch value := data.(type) {
+		case string:
+			return VersionConstraint{Value: value}, nil
+		case map[string]interface{}:
+			byCluster := make(map[string]string, len(value))
+			for clusterName, constraint := range value {
+				constraintString, ok := constraint.(string)
+				if !ok {
+					return nil, fmt.Errorf("doublezero.version_constraint.%s must be a string - got: %v", clusterName, constraint)
+				}
+				byCluster[clusterName] = constraintString
+			}
+			return VersionConstraint{ByCluster: byCluster}, nil
+		default:
+			return nil, fmt.Errorf("doublezero.version_constraint must be a string or a map of cluster name to string - got: %v", data)
+		}
+	}
+}
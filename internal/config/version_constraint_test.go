@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDoubleZeroValidate_VersionConstraint(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster string
+		// yaml is the doublezero.version_constraint value
+		yaml           string
+		wantConstraint string
+		wantErr        bool
+	}{
+		{name: "global constraint", cluster: "testnet", yaml: `">= 0.6.9"`, wantConstraint: ">= 0.6.9"},
+		{name: "picked by cluster", cluster: "mainnet-beta",
+			yaml: "\n    testnet: \">= 0.6.9\"\n    mainnet-beta: \">= 0.6.9, < 0.7.2\"", wantConstraint: ">= 0.6.9, < 0.7.2"},
+		{name: "other cluster picked by cluster", cluster: "testnet",
+			yaml: "\n    testnet: \">= 0.6.9\"\n    mainnet-beta: \">= 0.6.9, < 0.7.2\"", wantConstraint: ">= 0.6.9"},
+		{name: "non-matching cluster falls back to the global constraint", cluster: "testnet",
+			yaml: "\n    mainnet-beta: \">= 0.6.9, < 0.7.2\"\n    default: \">= 0.6.0\"", wantConstraint: ">= 0.6.0"},
+		{name: "non-matching cluster without a global constraint", cluster: "testnet",
+			yaml: "\n    mainnet-beta: \">= 0.6.9, < 0.7.2\""},
+		{name: "unknown cluster", cluster: "testnet", yaml: "\n    devnet: \">= 0.6.9\"", wantErr: true},
+		{name: "invalid constraint of another cluster", cluster: "testnet",
+			yaml: "\n    testnet: \">= 0.6.9\"\n    mainnet-beta: \"not a constraint\"", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte("doublezero:\n  version_constraint: "+tt.yaml+"\n"), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cfg, err := New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := cfg.LoadFromFile(file); err != nil {
+				t.Fatalf("LoadFromFile() error = %v", err)
+			}
+
+			err = cfg.DoubleZero.Validate(tt.cluster)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%s) error = %v, want error %v", tt.cluster, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := ""
+			if cfg.DoubleZero.ParsedVersionConstraint != nil {
+				got = cfg.DoubleZero.ParsedVersionConstraint.String()
+			}
+			if got != tt.wantConstraint {
+				t.Errorf("Validate(%s) constraint = %q, want %q", tt.cluster, got, tt.wantConstraint)
+			}
+		})
+	}
+}
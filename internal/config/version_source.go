@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/jsonpath"
+)
+
+const (
+	// defaultVersionSourceFormat is the repository index format used when a fallback source doesn't set one
+	defaultVersionSourceFormat = constants.PackageFormatDeb
+	// defaultVersionSourceTimeout is the fetch timeout used when a fallback source doesn't set one
+	defaultVersionSourceTimeout = 30 * time.Second
+	// defaultVersionSourceArch is the package architecture used when a fallback source doesn't set one
+	defaultVersionSourceArch = constants.PackageArchAuto
+	// defaultVersionSourceDistro is the repository distribution used when a fallback source doesn't set one
+	defaultVersionSourceDistro = constants.PackageDistroAny
+	// defaultVersionSourceDataFormat is the account data format used when a fallback source doesn't set one
+	defaultVersionSourceDataFormat = constants.AccountDataFormatBorshString
+)
+
+// VersionSource represents the recommended version source configuration
+type VersionSource struct {
+	// Type is the version source backend - one of cloudsmith_api, cloudsmith_index
+	// Defaults to cloudsmith_api
+	Type string `koanf:"type"`
+	// Format is the repository index format read by the cloudsmith_index source - one of deb, rpm
+	// Defaults to deb
+	Format string `koanf:"format"`
+	// Timeout is the maximum time to spend fetching from this source, defaults to 30s
+	Timeout time.Duration `koanf:"timeout"`
+	// Arch is the package architecture read by the cloudsmith_index source - one of auto, amd64, arm64
+	// Defaults to auto, the architecture of the running binary
+	Arch string `koanf:"arch"`
+	// Distro is the repository distribution read by the cloudsmith_index source (e.g. ubuntu, debian, el),
+	// or auto to detect it from /etc/os-release. Defaults to any-distro
+	Distro string `koanf:"distro"`
+	// DistroVersion is the repository distribution version read by the cloudsmith_index source - the codename for deb
+	// (e.g. noble) or the major version for rpm (e.g. 9). Defaults to any-version, or detected when Distro is auto
+	DistroVersion string `koanf:"distro_version"`
+	// BaseURL overrides the Cloudsmith API (cloudsmith_api) or package download (cloudsmith_index) base URL,
+	// e.g. to point at a mirror or internal proxy. Defaults to the public Malbec Labs Cloudsmith URL
+	BaseURL string `koanf:"base_url"`
+	// Version is the pinned package version returned by the static source (e.g. "0.7.1-1")
+	Version string `koanf:"version"`
+	// VersionEnv is the name of an environment variable holding the pinned package version for the static source,
+	// read on every cycle. Mutually exclusive with Version
+	VersionEnv string `koanf:"version_env"`
+	// URL is the URL of the JSON document read by the http_json source
+	URL string `koanf:"url" redact:"url"`
+	// JSONPath is the JQ-style path of the version in the http_json document, e.g. .clusters["mainnet-beta"].recommended
+	JSONPath string `koanf:"json_path"`
+	// Headers are extra request headers sent by the http_json source, e.g. for authentication
+	Headers map[string]string `koanf:"headers" redact:"true"`
+	// Record is the DNS name whose TXT record holds the version for the dns_txt source, e.g. mainnet-beta.versions.example.com
+	Record string `koanf:"record"`
+	// Nameserver is the host:port of the DNS server queried by the dns_txt source, defaults to the system resolver
+	Nameserver string `koanf:"nameserver"`
+	// RPCURL is the Solana RPC endpoint queried by the solana_account source, defaults to the cluster's public endpoint
+	RPCURL string `koanf:"rpc_url" redact:"url"`
+	// Account is the address of the account holding the version for the solana_account source
+	// Mutually exclusive with Program
+	Account string `koanf:"account"`
+	// Program is the program whose program-derived address, from Seeds, holds the version for the solana_account source
+	Program string `koanf:"program"`
+	// Seeds are the UTF-8 seeds deriving the account address from Program
+	Seeds []string `koanf:"seeds"`
+	// DataOffset is the byte offset of the version in the account data read by the solana_account source
+	DataOffset int `koanf:"data_offset"`
+	// DataFormat is the encoding of the version in the account data - one of borsh_string, utf8
+	// Defaults to borsh_string
+	DataFormat string `koanf:"data_format"`
+	// PackageName is the name of the package to look up, defaults to doublezero
+	PackageName string `koanf:"package_name"`
+	// Sources is an optional ordered list of version sources tried in turn until one succeeds
+	// When set, Type, Format and Timeout at this level are ignored
+	Sources []VersionSource `koanf:"sources"`
+	// Cache is the on-disk cache of the recommended version, only read at the top level
+	Cache VersionSourceCache `koanf:"cache"`
+}
+
+// VersionSourceCache represents the recommended version cache configuration
+type VersionSourceCache struct {
+	// TTL is how long a cached recommendation is used before fetching again, 0 disables the cache
+	TTL time.Duration `koanf:"ttl"`
+	// File is the path of the JSON cache file, defaults to version-cache.json next to the config file
+	File string `koanf:"file"`
+	// MaxStale is how long after it was fetched an expired cached recommendation is still used when fetching it live
+	// fails, e.g. through a source outage. Defaults to 24h, 0 never uses an expired recommendation
+	MaxStale time.Duration `koanf:"max_stale"`
+}
+
+// IsEnabled returns true if the recommended version is cached
+func (c *VersionSourceCache) IsEnabled() bool {
+	return c.TTL > 0
+}
+
+// NewVersionSource returns a version source configuration of the given type with the defaults applied
+func NewVersionSource(sourceType string) VersionSource {
+	v := VersionSource{Type: sourceType}
+	v.setDefaults()
+	return v
+}
+
+// IsChain returns true if an ordered list of fallback sources is configured
+func (v *VersionSource) IsChain() bool {
+	return len(v.Sources) > 0
+}
+
+// Validate validates the version source configuration
+func (v *VersionSource) Validate() error {
+	if v.Cache.TTL < 0 {
+		return fmt.Errorf("version_source.cache.ttl must be >= 0 - got: %s", v.Cache.TTL)
+	}
+	if v.Cache.MaxStale < 0 {
+		return fmt.Errorf("version_source.cache.max_stale must be >= 0 - got: %s", v.Cache.MaxStale)
+	}
+
+	if !v.IsChain() {
+		return v.validateSource("version_source")
+	}
+
+	for i := range v.Sources {
+		source := &v.Sources[i]
+		if source.IsChain() {
+			return fmt.Errorf("version_source.sources[%d] cannot have nested sources", i)
+		}
+
+		// list entries don't get koanf defaults
+		source.setDefaults()
+
+		if err := source.validateSource(fmt.Sprintf("version_source.sources[%d]", i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setDefaults defaults unset fields of a source that doesn't get koanf defaults
+func (v *VersionSource) setDefaults() {
+	if v.Format == "" {
+		v.Format = defaultVersionSourceFormat
+	}
+	if v.Timeout == 0 {
+		v.Timeout = defaultVersionSourceTimeout
+	}
+	if v.Arch == "" {
+		v.Arch = defaultVersionSourceArch
+	}
+	if v.Distro == "" {
+		v.Distro = defaultVersionSourceDistro
+	}
+	if v.DataFormat == "" {
+		v.DataFormat = defaultVersionSourceDataFormat
+	}
+}
+
+// validateSource validates a single (non-chain) version source, prefixing errors with the given config key
+func (v *VersionSource) validateSource(key string) error {
+	if !slices.Contains(constants.ValidVersionSourceTypes, v.Type) {
+		return fmt.Errorf("%s.type must be one of %s - got: %s", key, strings.Join(constants.ValidVersionSourceTypes, ", "), v.Type)
+	}
+
+	if !slices.Contains(constants.ValidPackageFormats, v.Format) {
+		return fmt.Errorf("%s.format must be one of %s - got: %s", key, strings.Join(constants.ValidPackageFormats, ", "), v.Format)
+	}
+
+	if v.Timeout <= 0 {
+		return fmt.Errorf("%s.timeout must be > 0 - got: %s", key, v.Timeout)
+	}
+
+	if !slices.Contains(constants.ValidPackageArchs, v.Arch) {
+		return fmt.Errorf("%s.arch must be one of %s - got: %s", key, strings.Join(constants.ValidPackageArchs, ", "), v.Arch)
+	}
+
+	if v.Distro == constants.PackageDistroAuto && v.DistroVersion != "" {
+		return fmt.Errorf("%s.distro_version cannot be set when %s.distro is %s", key, key, constants.PackageDistroAuto)
+	}
+
+	if v.Type == constants.VersionSourceTypeStatic {
+		if (v.Version == "") == (v.VersionEnv == "") {
+			return fmt.Errorf("%s with type %s requires exactly one of version or version_env", key, v.Type)
+		}
+		if v.Version != "" {
+			if _, err := version.NewVersion(v.Version); err != nil {
+				return fmt.Errorf("%s.version is not a valid version - got: %s", key, v.Version)
+			}
+		}
+	}
+
+	if v.Type == constants.VersionSourceTypeHTTPJSON {
+		if !isHTTPURL(v.URL) {
+			return fmt.Errorf("%s.url must be an http(s) URL for type %s - got: %s", key, v.Type, v.URL)
+		}
+		if _, err := jsonpath.Parse(v.JSONPath); err != nil {
+			return fmt.Errorf("%s.json_path is invalid: %w", key, err)
+		}
+	}
+
+	if v.Type == constants.VersionSourceTypeDNSTXT {
+		if v.Record == "" {
+			return fmt.Errorf("%s.record is required for type %s", key, v.Type)
+		}
+		if v.Nameserver != "" {
+			if _, _, err := net.SplitHostPort(v.Nameserver); err != nil {
+				v.Nameserver = net.JoinHostPort(v.Nameserver, "53")
+			}
+		}
+	}
+
+	if v.Type == constants.VersionSourceTypeSolanaAccount {
+		if err := v.validateSolanaAccount(key); err != nil {
+			return err
+		}
+	}
+
+	if v.BaseURL != "" {
+		if !isHTTPURL(v.BaseURL) {
+			return fmt.Errorf("%s.base_url must be an http(s) URL - got: %s", key, v.BaseURL)
+		}
+		v.BaseURL = strings.TrimSuffix(v.BaseURL, "/")
+	}
+
+	return nil
+}
+
+// validateSolanaAccount validates the options of a solana_account source, prefixing errors with the given config key
+func (v *VersionSource) validateSolanaAccount(key string) error {
+	if v.RPCURL != "" && !isHTTPURL(v.RPCURL) {
+		return fmt.Errorf("%s.rpc_url must be an http(s) URL - got: %s", key, v.RPCURL)
+	}
+
+	if (v.Account == "") == (v.Program == "") {
+		return fmt.Errorf("%s with type %s requires exactly one of account or program", key, v.Type)
+	}
+	if v.Account != "" {
+		if _, err := solana.PublicKeyFromBase58(v.Account); err != nil {
+			return fmt.Errorf("%s.account is not a valid address - got: %s", key, v.Account)
+		}
+		if len(v.Seeds) > 0 {
+			return fmt.Errorf("%s.seeds can only be set with %s.program", key, key)
+		}
+	}
+	if v.Program != "" {
+		if _, err := solana.PublicKeyFromBase58(v.Program); err != nil {
+			return fmt.Errorf("%s.program is not a valid address - got: %s", key, v.Program)
+		}
+		if len(v.Seeds) == 0 {
+			return fmt.Errorf("%s.seeds is required with %s.program", key, key)
+		}
+		for i, seed := range v.Seeds {
+			if len(seed) > solana.MaxSeedLength {
+				return fmt.Errorf("%s.seeds[%d] must be at most %d bytes - got: %d", key, i, solana.MaxSeedLength, len(seed))
+			}
+		}
+	}
+
+	if v.DataOffset < 0 {
+		return fmt.Errorf("%s.data_offset must be >= 0 - got: %d", key, v.DataOffset)
+	}
+	if !slices.Contains(constants.ValidAccountDataFormats, v.DataFormat) {
+		return fmt.Errorf("%s.data_format must be one of %s - got: %s", key, strings.Join(constants.ValidAccountDataFormats, ", "), v.DataFormat)
+	}
+
+	return nil
+}
+
+// isHTTPURL returns true if s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	parsedURL, err := url.Parse(s)
+	return err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && parsedURL.Host != ""
+}
@@ -0,0 +1,254 @@
+package configmigrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaVersionKey is the top-level key recording the config schema version
+const schemaVersionKey = "schema_version"
+
+// Migration upgrades a config document from the previous schema version to Version
+type Migration struct {
+	// Version is the schema version the migration upgrades to
+	Version int `json:"version"`
+	// Description is what changed in the config surface
+	Description string `json:"description"`
+	// Changes are the keys renamed, moved or removed, e.g. "sync.prechecks -> sync.pre_checks"
+	Changes []string `json:"changes,omitempty"`
+
+	apply func(root *yaml.Node) error
+}
+
+// migrations are every config schema change in order, the last one's Version is config.SchemaVersion
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Record the config schema version in schema_version",
+		apply:       func(root *yaml.Node) error { return nil },
+	},
+}
+
+// Migrations returns every config schema change in order, a machine-readable changelog of the config surface
+func Migrations() []Migration {
+	return migrations
+}
+
+// Result is the outcome of migrating a config file
+type Result struct {
+	// FromVersion is the schema version of the original file, 0 when it didn't record one
+	FromVersion int
+	// ToVersion is the schema version of the migrated file
+	ToVersion int
+	// Applied are the migrations applied, in order
+	Applied []Migration
+	// Original is the original file contents
+	Original []byte
+	// Migrated is the migrated file contents, equal to Original when there was nothing to migrate
+	Migrated []byte
+}
+
+// Changed returns true if the migrated contents differ from the original
+func (r *Result) Changed() bool {
+	return !bytes.Equal(r.Original, r.Migrated)
+}
+
+// Migrate upgrades the contents of the config file at path to config.SchemaVersion, applying every migration newer
+// than its schema_version and recording the new version. YAML files keep their comments and ${{ }} templates, TOML and
+// JSON files are rewritten from their parsed values
+func Migrate(path string, contents []byte) (*Result, error) {
+	return migrate(path, contents, migrations)
+}
+
+// migrate upgrades the config file contents with the given migrations
+func migrate(path string, contents []byte, migrations []Migration) (*Result, error) {
+	format := strings.ToLower(filepath.Ext(path))
+	root, err := decode(format, path, contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	fromVersion, err := schemaVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{FromVersion: fromVersion, ToVersion: fromVersion, Original: contents, Migrated: contents}
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if fromVersion > latest {
+		return nil, fmt.Errorf("%s is %d, newer than the latest supported schema %d - upgrade doublezero-version-sync", schemaVersionKey, fromVersion, latest)
+	}
+	if fromVersion == latest {
+		return result, nil
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= fromVersion {
+			continue
+		}
+		if err := migration.apply(root); err != nil {
+			return nil, fmt.Errorf("failed to migrate to schema %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		result.Applied = append(result.Applied, migration)
+	}
+	setSchemaVersion(root, latest)
+
+	result.ToVersion = latest
+	result.Migrated, err = encode(format, path, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	return result, nil
+}
+
+// decode parses the config file contents into the root mapping node of a YAML document
+func decode(format, path string, contents []byte) (*yaml.Node, error) {
+	if format == ".toml" || format == ".json" {
+		values, err := config.ParserForFile(path).Unmarshal(contents)
+		if err != nil {
+			return nil, err
+		}
+		var root yaml.Node
+		if err := root.Encode(values); err != nil {
+			return nil, err
+		}
+		return &root, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config is not a mapping of keys")
+	}
+	// keep the document node so its head and foot comments are written back
+	return &document, nil
+}
+
+// encode writes the root node back in the config file's format
+func encode(format, path string, root *yaml.Node) ([]byte, error) {
+	if format == ".toml" || format == ".json" {
+		var values map[string]any
+		if err := root.Decode(&values); err != nil {
+			return nil, err
+		}
+		if format == ".json" {
+			contents, err := json.MarshalIndent(values, "", "  ")
+			return append(contents, '\n'), err
+		}
+		return config.ParserForFile(path).Marshal(values)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mapping returns the top-level mapping node of a decoded document
+func mapping(root *yaml.Node) *yaml.Node {
+	if root.Kind == yaml.DocumentNode {
+		return root.Content[0]
+	}
+	return root
+}
+
+// schemaVersion returns the schema_version recorded in the document, 0 when there is none
+func schemaVersion(root *yaml.Node) (int, error) {
+	_, value := lookup(mapping(root), schemaVersionKey)
+	if value == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value.Value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer - got: %s", schemaVersionKey, value.Value)
+	}
+	return version, nil
+}
+
+// setSchemaVersion records the schema version, as the first key of the document when it wasn't recorded yet
+func setSchemaVersion(root *yaml.Node, version int) {
+	node := mapping(root)
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if index, existing := lookup(node, schemaVersionKey); existing != nil {
+		node.Content[index+1] = value
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: schemaVersionKey}
+	node.Content = append([]*yaml.Node{key, value}, node.Content...)
+}
+
+// lookup returns the index of key in a mapping node and its value node, nil when the key isn't set
+func lookup(node *yaml.Node, key string) (int, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return -1, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i, node.Content[i+1]
+		}
+	}
+	return -1, nil
+}
+
+// moveKey moves the value at the dotted path from to the dotted path to, creating the parent mappings of to as
+// needed - renaming a key within a block or moving it to another block. Returns an error when to is already set
+func moveKey(root *yaml.Node, from, to string) error {
+	fromParent, fromKey := parent(mapping(root), from, false)
+	index, value := lookup(fromParent, fromKey)
+	if value == nil {
+		return nil
+	}
+
+	toParent, toKey := parent(mapping(root), to, true)
+	if toParent == nil {
+		return fmt.Errorf("cannot move %s to %s - a parent of %s isn't a mapping", from, to, to)
+	}
+	if _, existing := lookup(toParent, toKey); existing != nil {
+		return fmt.Errorf("cannot move %s to %s - %s is already set", from, to, to)
+	}
+
+	keyNode := fromParent.Content[index]
+	fromParent.Content = append(fromParent.Content[:index], fromParent.Content[index+2:]...)
+	keyNode.Value = toKey
+	toParent.Content = append(toParent.Content, keyNode, value)
+	return nil
+}
+
+// parent returns the mapping node holding the last key of the dotted path and that key, creating missing parent
+// mappings when create is set. Returns a nil node when a parent is missing or not a mapping
+func parent(node *yaml.Node, path string, create bool) (*yaml.Node, string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		_, child := lookup(node, key)
+		if child == nil && create && node != nil && node.Kind == yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		if child == nil || child.Kind != yaml.MappingNode {
+			return nil, ""
+		}
+		node = child
+	}
+	return node, keys[len(keys)-1]
+}
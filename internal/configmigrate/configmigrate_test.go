@@ -0,0 +1,135 @@
+package configmigrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrations_EndAtCurrentSchema(t *testing.T) {
+	for i, migration := range Migrations() {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, migration.Version, i+1)
+		}
+	}
+	if latest := Migrations()[len(Migrations())-1].Version; latest != config.SchemaVersion {
+		t.Errorf("latest migration is %d, want config.SchemaVersion %d", latest, config.SchemaVersion)
+	}
+}
+
+// testMigrations rename sync.prechecks and move the top-level bin into the doublezero block
+var testMigrations = []Migration{
+	{Version: 1, apply: func(root *yaml.Node) error { return nil }},
+	{Version: 2, apply: func(root *yaml.Node) error {
+		if err := moveKey(root, "sync.prechecks", "sync.pre_checks"); err != nil {
+			return err
+		}
+		return moveKey(root, "bin", "doublezero.bin")
+	}},
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contents    string
+		want        string
+		wantFrom    int
+		wantApplied int
+		wantErr     bool
+	}{
+		{
+			name: "yaml keeps comments and templates",
+			path: "config.yml",
+			contents: `# fleet config
+cluster:
+  name: ${{ env "CLUSTER" }} # from the unit
+bin: /usr/bin/doublezero
+sync:
+  prechecks:
+    - name: ok
+`,
+			want: `schema_version: 2
+# fleet config
+cluster:
+  name: ${{ env "CLUSTER" }} # from the unit
+sync:
+  pre_checks:
+    - name: ok
+doublezero:
+  bin: /usr/bin/doublezero
+`,
+			wantFrom:    0,
+			wantApplied: 2,
+		},
+		{
+			name:        "yaml from an older schema",
+			path:        "config.yaml",
+			contents:    "schema_version: 1\nsync:\n  prechecks: []\n",
+			want:        "schema_version: 2\nsync:\n  pre_checks: []\n",
+			wantFrom:    1,
+			wantApplied: 1,
+		},
+		{
+			name:     "already current",
+			path:     "config.yml",
+			contents: "schema_version: 2\nsync:\n  pre_checks: []\n",
+			want:     "schema_version: 2\nsync:\n  pre_checks: []\n",
+			wantFrom: 2,
+		},
+		{
+			name:        "json",
+			path:        "config.json",
+			contents:    `{"bin": "/usr/bin/doublezero", "cluster": {"name": "testnet"}}`,
+			want:        "{\n  \"cluster\": {\n    \"name\": \"testnet\"\n  },\n  \"doublezero\": {\n    \"bin\": \"/usr/bin/doublezero\"\n  },\n  \"schema_version\": 2\n}\n",
+			wantApplied: 2,
+		},
+		{
+			name:     "newer than supported",
+			path:     "config.yml",
+			contents: "schema_version: 3\n",
+			wantErr:  true,
+		},
+		{
+			name:     "destination already set",
+			path:     "config.yml",
+			contents: "bin: a\ndoublezero:\n  bin: b\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := migrate(tt.path, []byte(tt.contents), testMigrations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := string(result.Migrated); got != tt.want {
+				t.Errorf("migrate() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if result.FromVersion != tt.wantFrom || result.ToVersion != 2 || len(result.Applied) != tt.wantApplied {
+				t.Errorf("migrate() from %d to %d applying %d, want from %d to 2 applying %d",
+					result.FromVersion, result.ToVersion, len(result.Applied), tt.wantFrom, tt.wantApplied)
+			}
+			if result.Changed() != (tt.contents != tt.want) {
+				t.Errorf("Changed() = %v", result.Changed())
+			}
+		})
+	}
+}
+
+func TestMigrate_TOML(t *testing.T) {
+	result, err := migrate("config.toml", []byte("bin = \"/usr/bin/doublezero\"\n"), testMigrations)
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	got := string(result.Migrated)
+	if !strings.Contains(got, "schema_version = 2") || !strings.Contains(got, "[doublezero]") || strings.Contains(got, "\nbin") {
+		t.Errorf("migrate() =\n%s\nwant bin moved to [doublezero] and schema_version = 2", got)
+	}
+}
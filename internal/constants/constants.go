@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 )
 
 const (
@@ -13,9 +14,373 @@ const (
 	ClusterNameTestnet = "testnet"
 )
 
+const (
+	// GenesisHashMainnetBeta is the genesis hash of the Solana Mainnet Beta cluster
+	GenesisHashMainnetBeta = "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d"
+	// GenesisHashTestnet is the genesis hash of the Solana Testnet cluster
+	GenesisHashTestnet = "4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY"
+)
+
+// ClusterGenesisHashes maps each cluster name to the genesis hash of its Solana cluster
+var ClusterGenesisHashes = map[string]string{
+	ClusterNameMainnetBeta: GenesisHashMainnetBeta,
+	ClusterNameTestnet:     GenesisHashTestnet,
+}
+
+// ClusterPublicRPCURLs maps each cluster name to the public RPC endpoint of its Solana cluster
+var ClusterPublicRPCURLs = map[string]string{
+	ClusterNameMainnetBeta: "https://api.mainnet-beta.solana.com",
+	ClusterNameTestnet:     "https://api.testnet.solana.com",
+}
+
+const (
+	// ValidatorRoleActive is a validator running as its configured active identity
+	ValidatorRoleActive = "active"
+	// ValidatorRolePassive is a validator running as its configured passive identity
+	ValidatorRolePassive = "passive"
+	// ValidatorRoleUnknown is a validator running as neither configured identity, or whose identities can't be loaded
+	ValidatorRoleUnknown = "unknown"
+)
+
+const (
+	// ValidatorOnUnreachableFail fails the sync when the validator RPC is unreachable
+	ValidatorOnUnreachableFail = "fail"
+	// ValidatorOnUnreachableSkipGate skips the validator identity check when the validator RPC is unreachable
+	ValidatorOnUnreachableSkipGate = "skip_gate"
+	// ValidatorOnUnreachableMonitorOnly runs all checks but does not execute sync commands when the validator RPC is unreachable
+	ValidatorOnUnreachableMonitorOnly = "monitor_only"
+)
+
+const (
+	// ValidatorIdentitySourceRPC reads the identity the validator runs as from its RPC getIdentity
+	ValidatorIdentitySourceRPC = "rpc"
+	// ValidatorIdentitySourceFile reads the identity the validator runs as from a keypair or public key file it, or its
+	// failover tooling, writes
+	ValidatorIdentitySourceFile = "file"
+	// ValidatorIdentitySourceStatic assumes the validator always runs as a configured identity
+	ValidatorIdentitySourceStatic = "static"
+)
+
+const (
+	// IdentityKeyProviderFile reads an identity keypair or public key from a file, the default for a plain path
+	IdentityKeyProviderFile = "file"
+	// IdentityKeyProviderEnv reads an identity keypair or public key from an environment variable
+	IdentityKeyProviderEnv = "env"
+	// IdentityKeyProviderExec reads an identity keypair or public key from the output of a command
+	IdentityKeyProviderExec = "exec"
+)
+
+const (
+	// FailoverPolicyDisabled never invokes the failover hook
+	FailoverPolicyDisabled = "disabled"
+	// FailoverPolicyAuto invokes the failover hook without confirmation
+	FailoverPolicyAuto = "auto"
+	// FailoverPolicyPrompt asks for interactive confirmation before invoking the failover hook
+	FailoverPolicyPrompt = "prompt"
+)
+
+const (
+	// SyncAnchorMidnight aligns interval boundaries to midnight UTC, restarting the count each day
+	SyncAnchorMidnight = "midnight"
+	// SyncAnchorStartup aligns interval boundaries to process start, running the first cycle immediately
+	SyncAnchorStartup = "startup"
+	// SyncAnchorEpoch aligns interval boundaries to the unix epoch, an unbroken cadence across days
+	SyncAnchorEpoch = "epoch"
+	// SyncAnchorTimeLayout is the layout of a custom daily anchor time (UTC), restarting the count each day at that time
+	SyncAnchorTimeLayout = "15:04"
+)
+
+const (
+	// SyncOverrunPolicySkipNext waits for the next boundary after a cycle that ran past one, skipping the missed cycles
+	SyncOverrunPolicySkipNext = "skip_next"
+	// SyncOverrunPolicyQueueOne runs one cycle immediately after a cycle that ran past a boundary, then realigns
+	SyncOverrunPolicyQueueOne = "queue_one"
+	// SyncOverrunPolicyAbortCurrent stops a cycle before its next sync command once the next boundary arrives
+	SyncOverrunPolicyAbortCurrent = "abort_current"
+)
+
+const (
+	// SyncUnreachableWindowsFail refuses to run on an interval whose boundaries never fall inside any of sync.windows
+	SyncUnreachableWindowsFail = "fail"
+	// SyncUnreachableWindowsAdjust also runs a cycle when each of sync.windows opens, on top of the interval boundaries
+	SyncUnreachableWindowsAdjust = "adjust"
+)
+
+const (
+	// RebootPolicyDisabled never checks whether the host requires a reboot
+	RebootPolicyDisabled = "disabled"
+	// RebootPolicyNotify checks whether the host requires a reboot after a sync and notifies when it does
+	RebootPolicyNotify = "notify"
+	// RebootPolicyCommand notifies like notify and runs the configured reboot command after a successful sync
+	RebootPolicyCommand = "command"
+)
+
+const (
+	// HostMaintenancePolicyDisabled never checks whether the host is in maintenance mode
+	HostMaintenancePolicyDisabled = "disabled"
+	// HostMaintenancePolicyBlock holds version changes pending while the host is in maintenance mode
+	HostMaintenancePolicyBlock = "block"
+	// HostMaintenancePolicyPrioritize applies version changes while the host is in maintenance mode, even outside
+	// sync.windows
+	HostMaintenancePolicyPrioritize = "prioritize"
+)
+
+const (
+	// DaemonCheckNone disables the DoubleZero daemon check
+	DaemonCheckNone = "none"
+	// DaemonCheckProcess checks for a running daemon process by name
+	DaemonCheckProcess = "process"
+	// DaemonCheckSystemd checks the daemon systemd unit is active
+	DaemonCheckSystemd = "systemd"
+	// DaemonCheckSocket checks the daemon unix socket accepts connections
+	DaemonCheckSocket = "socket"
+)
+
+const (
+	// VersionSourceTypeCloudsmithAPI resolves the recommended version from the Cloudsmith packages API
+	VersionSourceTypeCloudsmithAPI = "cloudsmith_api"
+	// VersionSourceTypeCloudsmithIndex resolves the recommended version from the Cloudsmith repository index metadata
+	VersionSourceTypeCloudsmithIndex = "cloudsmith_index"
+	// VersionSourceTypeStatic uses a version pinned in config or an environment variable without any network fetch
+	VersionSourceTypeStatic = "static"
+	// VersionSourceTypeHTTPJSON extracts the recommended version from a JSON document served over HTTP(S)
+	VersionSourceTypeHTTPJSON = "http_json"
+	// VersionSourceTypeDNSTXT reads the recommended version from a DNS TXT record
+	VersionSourceTypeDNSTXT = "dns_txt"
+	// VersionSourceTypeSolanaAccount decodes the recommended version from the data of a Solana account on the cluster
+	VersionSourceTypeSolanaAccount = "solana_account"
+)
+
+const (
+	// AccountDataFormatBorshString is a Borsh-encoded string - a little-endian u32 length followed by UTF-8 bytes
+	AccountDataFormatBorshString = "borsh_string"
+	// AccountDataFormatUTF8 is a UTF-8 string running to the first NUL byte or the end of the account data
+	AccountDataFormatUTF8 = "utf8"
+)
+
+const (
+	// PackageFormatDeb is the Debian package format
+	PackageFormatDeb = "deb"
+	// PackageFormatRPM is the RPM package format
+	PackageFormatRPM = "rpm"
+)
+
+const (
+	// PackageManagerApt refreshes the package cache with apt-get update
+	PackageManagerApt = "apt"
+	// PackageManagerDnf refreshes the package cache with dnf makecache
+	PackageManagerDnf = "dnf"
+)
+
+const (
+	// SyncCommandOnFailureAbort fails the sync when the command fails
+	SyncCommandOnFailureAbort = "abort"
+	// SyncCommandOnFailureContinue carries on with the next command when the command fails, like allow_failure
+	SyncCommandOnFailureContinue = "continue"
+	// SyncCommandOnFailureRollback re-runs the sync commands targeting the installed version, then fails the sync
+	SyncCommandOnFailureRollback = "rollback"
+)
+
+const (
+	// PackageArchAuto detects the package architecture from the running binary
+	PackageArchAuto = "auto"
+	// PackageArchAMD64 is the 64-bit x86 architecture (x86_64 in RPM repositories)
+	PackageArchAMD64 = "amd64"
+	// PackageArchARM64 is the 64-bit ARM architecture (aarch64 in RPM repositories)
+	PackageArchARM64 = "arm64"
+)
+
+const (
+	// PackageDistroAuto detects the distribution and its version from /etc/os-release
+	PackageDistroAuto = "auto"
+	// PackageDistroAny is the repository distribution that packages uploaded for any distribution are published under
+	PackageDistroAny = "any-distro"
+	// PackageDistroVersionAny is the repository distribution version that packages uploaded for any version are published under
+	PackageDistroVersionAny = "any-version"
+)
+
+const (
+	// NotificationSeverityInfo is for informational events
+	NotificationSeverityInfo = "info"
+	// NotificationSeverityWarning is for events that may need attention
+	NotificationSeverityWarning = "warning"
+	// NotificationSeverityCritical is for anomalous events that need attention
+	NotificationSeverityCritical = "critical"
+)
+
+// NotificationLocaleDefault is the locale of the built-in notification message catalog
+const NotificationLocaleDefault = "en"
+
+const (
+	// WebhookFormatJSON sends webhook events as the event JSON
+	WebhookFormatJSON = "json"
+	// WebhookFormatCloudEvents sends webhook events as CloudEvents 1.0 in structured mode JSON
+	WebhookFormatCloudEvents = "cloudevents"
+)
+
+const (
+	// NotificationEventRecommendationRollback is raised when the recommended version decreases
+	NotificationEventRecommendationRollback = "recommendation_rollback"
+	// NotificationEventVersionSkipped is raised when an operator skip prevents syncing to the recommended version
+	NotificationEventVersionSkipped = "version_skipped"
+	// NotificationEventSynced is raised when the sync commands installed the target version
+	NotificationEventSynced = "synced"
+	// NotificationEventSyncFailed is raised when the sync commands or the daemon check after them fail
+	NotificationEventSyncFailed = "sync_failed"
+	// NotificationEventRebootRequired is raised when the sync commands leave the host requiring a reboot
+	NotificationEventRebootRequired = "reboot_required"
+	// NotificationEventApprovalRequired is raised when a sync plan is held for operator approval under sync.approval
+	NotificationEventApprovalRequired = "approval_required"
+	// NotificationEventFreezeDrift is raised when a change freeze period defers a version change
+	NotificationEventFreezeDrift = "freeze_drift"
+	// NotificationEventRepoLag is raised when the target package version isn't published in the package repository yet
+	NotificationEventRepoLag = "repo_lag"
+	// NotificationEventSyncFailing is raised on every failed cycle once health.max_consecutive_failures cycles in a row
+	// failed when running on an interval
+	NotificationEventSyncFailing = "sync_failing"
+	// NotificationEventSyncRecovered is raised when a cycle succeeds after sync_failing was raised
+	NotificationEventSyncRecovered = "sync_recovered"
+)
+
+const (
+	// OutputFormatText prints shell-friendly key=value lines
+	OutputFormatText = "text"
+	// OutputFormatJSON prints a JSON object
+	OutputFormatJSON = "json"
+)
+
 // ValidClusterNames is a list of valid cluster names
 var ValidClusterNames = []string{ClusterNameMainnetBeta, ClusterNameTestnet}
 
+// ValidValidatorOnUnreachableValues is a list of valid validator.on_unreachable values
+var ValidValidatorOnUnreachableValues = []string{
+	ValidatorOnUnreachableFail,
+	ValidatorOnUnreachableSkipGate,
+	ValidatorOnUnreachableMonitorOnly,
+}
+
+// ValidValidatorIdentitySources is a list of valid validator.identity_source.type values
+var ValidValidatorIdentitySources = []string{
+	ValidatorIdentitySourceRPC,
+	ValidatorIdentitySourceFile,
+	ValidatorIdentitySourceStatic,
+}
+
+// ValidIdentityKeyProviders is a list of valid validator.identities.active and passive provider schemes
+var ValidIdentityKeyProviders = []string{
+	IdentityKeyProviderFile,
+	IdentityKeyProviderEnv,
+	IdentityKeyProviderExec,
+}
+
+// ValidFailoverPolicies is a list of valid failover.policy values
+var ValidFailoverPolicies = []string{
+	FailoverPolicyDisabled,
+	FailoverPolicyAuto,
+	FailoverPolicyPrompt,
+}
+
+// ValidSyncAnchors is a list of valid named sync.anchor values, a daily HH:MM time is also valid
+var ValidSyncAnchors = []string{
+	SyncAnchorMidnight,
+	SyncAnchorStartup,
+	SyncAnchorEpoch,
+}
+
+// ValidSyncOverrunPolicies is a list of valid sync.overrun_policy values
+var ValidSyncOverrunPolicies = []string{
+	SyncOverrunPolicySkipNext,
+	SyncOverrunPolicyQueueOne,
+	SyncOverrunPolicyAbortCurrent,
+}
+
+// ValidSyncUnreachableWindows is a list of valid sync.unreachable_windows values
+var ValidSyncUnreachableWindows = []string{
+	SyncUnreachableWindowsFail,
+	SyncUnreachableWindowsAdjust,
+}
+
+// ValidRebootPolicies is a list of valid reboot.policy values
+var ValidRebootPolicies = []string{
+	RebootPolicyDisabled,
+	RebootPolicyNotify,
+	RebootPolicyCommand,
+}
+
+// ValidHostMaintenancePolicies is a list of valid host_maintenance.policy values
+var ValidHostMaintenancePolicies = []string{
+	HostMaintenancePolicyDisabled,
+	HostMaintenancePolicyBlock,
+	HostMaintenancePolicyPrioritize,
+}
+
+// ValidDaemonChecks is a list of valid doublezero.daemon.check values
+var ValidDaemonChecks = []string{
+	DaemonCheckNone,
+	DaemonCheckProcess,
+	DaemonCheckSystemd,
+	DaemonCheckSocket,
+}
+
+// ValidVersionSourceTypes is a list of valid version_source.type values
+var ValidVersionSourceTypes = []string{
+	VersionSourceTypeCloudsmithAPI,
+	VersionSourceTypeCloudsmithIndex,
+	VersionSourceTypeStatic,
+	VersionSourceTypeHTTPJSON,
+	VersionSourceTypeDNSTXT,
+	VersionSourceTypeSolanaAccount,
+}
+
+// ValidAccountDataFormats is a list of valid version_source.data_format values
+var ValidAccountDataFormats = []string{AccountDataFormatBorshString, AccountDataFormatUTF8}
+
+// ValidPackageFormats is a list of valid package formats
+var ValidPackageFormats = []string{PackageFormatDeb, PackageFormatRPM}
+
+// ValidPackageManagers is a list of valid sync.refresh_repos.package_manager values
+var ValidPackageManagers = []string{PackageManagerApt, PackageManagerDnf}
+
+// ValidSyncCommandOnFailures is a list of valid command on_failure values
+var ValidSyncCommandOnFailures = []string{
+	SyncCommandOnFailureAbort,
+	SyncCommandOnFailureContinue,
+	SyncCommandOnFailureRollback,
+}
+
+// ValidPackageArchs is a list of valid version_source.arch values
+var ValidPackageArchs = []string{PackageArchAuto, PackageArchAMD64, PackageArchARM64}
+
+// ValidNotificationSeverities is a list of valid notification severities
+var ValidNotificationSeverities = []string{
+	NotificationSeverityInfo,
+	NotificationSeverityWarning,
+	NotificationSeverityCritical,
+}
+
+// ValidWebhookFormats is a list of valid notifications.webhooks format values
+var ValidWebhookFormats = []string{
+	WebhookFormatJSON,
+	WebhookFormatCloudEvents,
+}
+
+// ValidNotificationEvents is a list of valid notification event types
+var ValidNotificationEvents = []string{
+	NotificationEventRecommendationRollback,
+	NotificationEventVersionSkipped,
+	NotificationEventSynced,
+	NotificationEventSyncFailed,
+	NotificationEventRebootRequired,
+	NotificationEventApprovalRequired,
+	NotificationEventFreezeDrift,
+	NotificationEventRepoLag,
+	NotificationEventSyncFailing,
+	NotificationEventSyncRecovered,
+}
+
+// ValidOutputFormats is a list of valid --output formats
+var ValidOutputFormats = []string{OutputFormatText, OutputFormatJSON}
+
 // ValidateClusterName validates a cluster name
 func ValidateClusterName(clusterName string) (err error) {
 	if !slices.Contains(ValidClusterNames, clusterName) {
@@ -23,3 +388,14 @@ func ValidateClusterName(clusterName string) (err error) {
 	}
 	return nil
 }
+
+// ValidateSyncAnchor validates a sync anchor - one of the named anchors or a daily HH:MM time
+func ValidateSyncAnchor(anchor string) error {
+	if slices.Contains(ValidSyncAnchors, anchor) {
+		return nil
+	}
+	if _, err := time.Parse(SyncAnchorTimeLayout, anchor); err != nil {
+		return fmt.Errorf("invalid sync anchor: %s - must be one of %s or a daily HH:MM time", anchor, strings.Join(ValidSyncAnchors, ", "))
+	}
+	return nil
+}
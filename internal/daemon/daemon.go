@@ -0,0 +1,172 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// Options represents the options for creating a new daemon Checker
+type Options struct {
+	// Check is the check method - one of none, process, systemd, socket
+	Check string
+	// ProcessName is the daemon process name used by the process check
+	ProcessName string
+	// SystemdUnit is the systemd unit used by the systemd check
+	SystemdUnit string
+	// SocketPath is the unix socket path used by the socket check
+	SocketPath string
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock is used when waiting for the daemon, defaults to the system clock
+	Clock clock.Clock
+}
+
+// Checker checks whether the DoubleZero daemon is running
+type Checker struct {
+	opts   Options
+	logger *log.Logger
+	clock  clock.Clock
+	// procDir is where the process check looks for processes
+	procDir string
+}
+
+// New creates a new daemon Checker
+func New(opts Options) *Checker {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
+	return &Checker{
+		opts:    opts,
+		logger:  logging.WithPrefix(opts.Logger, "daemon"),
+		clock:   opts.Clock,
+		procDir: "/proc",
+	}
+}
+
+// IsEnabled returns true if a daemon check is configured
+func (c *Checker) IsEnabled() bool {
+	return c.opts.Check != "" && c.opts.Check != constants.DaemonCheckNone
+}
+
+// CheckRunning returns nil if the daemon is running, or an error describing why it is not. Returns early once ctx is done
+func (c *Checker) CheckRunning(ctx context.Context) error {
+	switch c.opts.Check {
+	case constants.DaemonCheckProcess:
+		return c.checkProcess(ctx)
+	case constants.DaemonCheckSystemd:
+		return c.checkSystemd(ctx)
+	case constants.DaemonCheckSocket:
+		return c.checkSocket(ctx)
+	default:
+		return nil
+	}
+}
+
+// WaitRunning polls CheckRunning until it succeeds or the timeout elapses, returning the last error on timeout, or the
+// context's error once ctx is done
+func (c *Checker) WaitRunning(ctx context.Context, timeout, pollInterval time.Duration) error {
+	deadline := c.clock.Now().Add(timeout)
+	for {
+		err := c.CheckRunning(ctx)
+		if err == nil || !c.clock.Now().Add(pollInterval).Before(deadline) {
+			return err
+		}
+		c.logger.Debug("daemon not running yet - retrying", "error", err, "remaining", deadline.Sub(c.clock.Now()).Truncate(time.Second).String())
+		if sleepErr := clock.SleepContext(ctx, c.clock, pollInterval); sleepErr != nil {
+			return fmt.Errorf("stopped waiting for the daemon: %w", sleepErr)
+		}
+	}
+}
+
+// checkProcess looks for a process with the configured name - via /proc on linux, pgrep elsewhere. On linux the name
+// is matched against the basename of the process executable, or of its argv[0] when the executable can't be read
+// (e.g. another user's process when not running as root) - /proc/<pid>/comm is truncated to 15 characters
+func (c *Checker) checkProcess(ctx context.Context) error {
+	if runtime.GOOS != "linux" {
+		out, err := exec.CommandContext(ctx, "pgrep", "-x", c.opts.ProcessName).Output()
+		if err != nil {
+			return fmt.Errorf("no %s process found", c.opts.ProcessName)
+		}
+		c.logger.Debug("found daemon process", "name", c.opts.ProcessName, "pids", strings.Fields(string(out)))
+		return nil
+	}
+
+	pidDirs, err := filepath.Glob(filepath.Join(c.procDir, "[0-9]*"))
+	if err != nil {
+		return fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	for _, pidDir := range pidDirs {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped looking for a %s process: %w", c.opts.ProcessName, err)
+		}
+		if processName(pidDir) == c.opts.ProcessName {
+			c.logger.Debug("found daemon process", "name", c.opts.ProcessName, "pid", filepath.Base(pidDir))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no %s process found", c.opts.ProcessName)
+}
+
+// processName returns the name of the process in the /proc/<pid> directory - the basename of its executable, or of its
+// argv[0] when the executable can't be read. Empty when neither can be read, processes can exit while we're scanning
+func processName(pidDir string) string {
+	if exe, err := os.Readlink(filepath.Join(pidDir, "exe")); err == nil {
+		// the executable was replaced since the process started, e.g. by a package upgrade
+		return filepath.Base(strings.TrimSuffix(exe, " (deleted)"))
+	}
+
+	cmdline, err := os.ReadFile(filepath.Join(pidDir, "cmdline"))
+	if err != nil {
+		return ""
+	}
+	argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+	if argv0 == "" {
+		// kernel threads have no command line
+		return ""
+	}
+	return filepath.Base(argv0)
+}
+
+// checkSystemd checks the configured systemd unit is active
+func (c *Checker) checkSystemd(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, "systemctl", "is-active", c.opts.SystemdUnit).Output()
+	state := strings.TrimSpace(string(out))
+	if err != nil || state != "active" {
+		if state == "" {
+			state = "unknown"
+		}
+		return fmt.Errorf("systemd unit %s is not active (state: %s)", c.opts.SystemdUnit, state)
+	}
+	c.logger.Debug("daemon systemd unit is active", "unit", c.opts.SystemdUnit)
+	return nil
+}
+
+// checkSocket checks the configured unix socket accepts connections
+func (c *Checker) checkSocket(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "unix", c.opts.SocketPath)
+	if err != nil {
+		return fmt.Errorf("daemon socket %s is not accepting connections: %w", c.opts.SocketPath, err)
+	}
+	conn.Close()
+	c.logger.Debug("daemon socket is accepting connections", "socket", c.opts.SocketPath)
+	return nil
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestCheckRunning_Socket(t *testing.T) {
+	// unix socket paths are limited to ~108 characters, t.TempDir() can be longer
+	dir, err := os.MkdirTemp("", "dz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "doublezerod.sock")
+
+	checker := New(Options{Check: constants.DaemonCheckSocket, SocketPath: socket, Logger: log.New(io.Discard)})
+	if err := checker.CheckRunning(context.Background()); err == nil {
+		t.Error("CheckRunning() error = nil without a listener, want not accepting connections")
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	if err := checker.CheckRunning(context.Background()); err != nil {
+		t.Errorf("CheckRunning() error = %v, want the listening socket accepted", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := checker.CheckRunning(ctx); err == nil {
+		t.Error("CheckRunning() error = nil once ctx is done, want it cancelled")
+	}
+}
+
+// fakeProcess is a /proc/<pid> entry - exe is the executable link target, not set when empty, and argv the command line
+type fakeProcess struct {
+	exe  string
+	comm string
+	argv []string
+}
+
+func TestCheckRunning_Process(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the process check reads /proc on linux only")
+	}
+
+	tests := []struct {
+		name        string
+		processName string
+		processes   []fakeProcess
+		wantRunning bool
+	}{
+		{
+			name:        "executable basename",
+			processName: "doublezerod",
+			processes:   []fakeProcess{{exe: "/usr/bin/doublezerod", comm: "doublezerod", argv: []string{"/usr/bin/doublezerod", "-v"}}},
+			wantRunning: true,
+		},
+		{
+			name:        "name longer than the 15 characters comm keeps",
+			processName: "doublezero-activator",
+			processes:   []fakeProcess{{exe: "/usr/bin/doublezero-activator", comm: "doublezero-acti", argv: []string{"doublezero-activator"}}},
+			wantRunning: true,
+		},
+		{
+			name:        "executable replaced by an upgrade",
+			processName: "doublezerod",
+			processes:   []fakeProcess{{exe: "/usr/bin/doublezerod (deleted)", comm: "doublezerod"}},
+			wantRunning: true,
+		},
+		{
+			name:        "argv[0] when the executable can't be read",
+			processName: "doublezerod",
+			processes:   []fakeProcess{{comm: "doublezerod", argv: []string{"/opt/doublezero/bin/doublezerod", "-sock-file", "/run/dz.sock"}}},
+			wantRunning: true,
+		},
+		{
+			name:        "truncated comm of another process doesn't match",
+			processName: "doublezero-acti",
+			processes:   []fakeProcess{{exe: "/usr/bin/doublezero-activator", comm: "doublezero-acti"}},
+		},
+		{
+			name:        "prefix of another process name doesn't match",
+			processName: "doublezero",
+			processes:   []fakeProcess{{exe: "/usr/bin/doublezerod", comm: "doublezerod"}},
+		},
+		{
+			name:        "kernel thread",
+			processName: "kthreadd",
+			processes:   []fakeProcess{{comm: "kthreadd"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			procDir := t.TempDir()
+			for i, process := range tt.processes {
+				pidDir := filepath.Join(procDir, strings.Repeat("1", i+1))
+				if err := os.Mkdir(pidDir, 0o755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if process.exe != "" {
+					if err := os.Symlink(process.exe, filepath.Join(pidDir, "exe")); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+				files := map[string]string{"comm": process.comm + "\n", "cmdline": ""}
+				if len(process.argv) > 0 {
+					files["cmdline"] = strings.Join(process.argv, "\x00") + "\x00"
+				}
+				for name, contents := range files {
+					if err := os.WriteFile(filepath.Join(pidDir, name), []byte(contents), 0o644); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+			}
+
+			checker := New(Options{Check: constants.DaemonCheckProcess, ProcessName: tt.processName, Logger: log.New(io.Discard)})
+			checker.procDir = procDir
+			if err := checker.CheckRunning(context.Background()); (err == nil) != tt.wantRunning {
+				t.Errorf("CheckRunning() error = %v, want running %v", err, tt.wantRunning)
+			}
+		})
+	}
+}
+
+func TestWaitRunning(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	checker := New(Options{Check: constants.DaemonCheckSocket, SocketPath: filepath.Join(dir, "missing.sock"),
+		Logger: log.New(io.Discard), Clock: fakeClock})
+
+	if err := checker.WaitRunning(context.Background(), 30*time.Second, time.Second); err == nil {
+		t.Error("WaitRunning() error = nil, want the last check error after the timeout")
+	}
+	if waited := fakeClock.Now().Sub(start); waited != 29*time.Second {
+		t.Errorf("waited %s, want polls until the next one would pass the timeout", waited)
+	}
+}
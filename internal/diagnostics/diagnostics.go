@@ -0,0 +1,165 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// maxOutputBytes caps the output kept per command so snapshots stay small enough to persist and notify
+const maxOutputBytes = 16 * 1024
+
+// Command is a read-only diagnostic command whose output is captured around a sync
+type Command struct {
+	// Name identifies the command in snapshots and diffs
+	Name string `koanf:"name"`
+	// Cmd is the command to run
+	Cmd string `koanf:"cmd"`
+	// Args are the arguments passed to Cmd
+	Args []string `koanf:"args"`
+}
+
+// Snapshot is the captured output of every diagnostic command at a point in time
+type Snapshot struct {
+	// TakenAt is when the capture started
+	TakenAt time.Time `json:"taken_at"`
+	// Outputs are the outputs of each command, in configured order
+	Outputs []Output `json:"outputs"`
+}
+
+// Output is the captured output of a single diagnostic command
+type Output struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Options represents the options for creating a new Collector
+type Options struct {
+	// Commands are the diagnostic commands to capture
+	Commands []Command
+	// Timeout is the maximum time each command may run
+	Timeout time.Duration
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock timestamps snapshots, defaults to the system clock
+	Clock clock.Clock
+}
+
+// Collector captures snapshots of the configured diagnostic commands
+type Collector struct {
+	commands []Command
+	timeout  time.Duration
+	logger   *log.Logger
+	clock    clock.Clock
+}
+
+// New creates a new Collector
+func New(opts Options) *Collector {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
+	return &Collector{
+		commands: opts.Commands,
+		timeout:  opts.Timeout,
+		logger:   logging.WithPrefix(opts.Logger, "diagnostics"),
+		clock:    opts.Clock,
+	}
+}
+
+// IsEnabled returns true if any diagnostic commands are configured
+func (c *Collector) IsEnabled() bool {
+	return len(c.commands) > 0
+}
+
+// Capture runs every diagnostic command and returns their outputs - a failing command is recorded in its output
+// rather than failing the capture
+func (c *Collector) Capture() *Snapshot {
+	snapshot := &Snapshot{TakenAt: c.clock.Now().UTC()}
+	for _, command := range c.commands {
+		snapshot.Outputs = append(snapshot.Outputs, c.run(command))
+	}
+	return snapshot
+}
+
+// run runs a single diagnostic command with the configured timeout
+func (c *Collector) run(command Command) Output {
+	output := Output{
+		Name:    command.Name,
+		Command: strings.Join(append([]string{command.Cmd}, command.Args...), " "),
+	}
+
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	combinedOutput, err := exec.CommandContext(ctx, command.Cmd, command.Args...).CombinedOutput()
+	if len(combinedOutput) > maxOutputBytes {
+		combinedOutput = append(combinedOutput[:maxOutputBytes], []byte("\n... (truncated)")...)
+	}
+	output.Output = strings.TrimRight(string(combinedOutput), "\n")
+
+	if ctx.Err() != nil {
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+	if err != nil {
+		output.Error = err.Error()
+		c.logger.Warn("diagnostic command failed", "name", command.Name, "command", output.Command, "error", err)
+		return output
+	}
+
+	c.logger.Debug("captured diagnostic command", "name", command.Name, "command", output.Command)
+	return output
+}
+
+// Diff returns a line diff of each command's output from before to after, headed by the command name - commands
+// whose output and error are unchanged are omitted. Returns nil when nothing changed
+func Diff(before, after *Snapshot) []string {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	beforeOutputs := make(map[string]Output, len(before.Outputs))
+	for _, output := range before.Outputs {
+		beforeOutputs[output.Name] = output
+	}
+
+	var diff []string
+	for _, afterOutput := range after.Outputs {
+		beforeOutput := beforeOutputs[afterOutput.Name]
+		outputDiff := sync_commands.DiffLines(beforeOutput.lines(), afterOutput.lines())
+		if outputDiff == nil {
+			continue
+		}
+		diff = append(diff, fmt.Sprintf("== %s (%s) ==", afterOutput.Name, afterOutput.Command))
+		diff = append(diff, outputDiff...)
+	}
+	return diff
+}
+
+// lines returns the output split into lines for diffing, with any error as a final line
+func (o Output) lines() []string {
+	var lines []string
+	if o.Output != "" {
+		lines = strings.Split(o.Output, "\n")
+	}
+	if o.Error != "" {
+		lines = append(lines, "error: "+o.Error)
+	}
+	return lines
+}
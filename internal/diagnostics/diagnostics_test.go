@@ -0,0 +1,70 @@
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCapture(t *testing.T) {
+	collector := New(Options{
+		Commands: []Command{
+			{Name: "status", Cmd: "echo", Args: []string{"connected"}},
+			{Name: "failing", Cmd: "sh", Args: []string{"-c", "echo partial; exit 3"}},
+			{Name: "slow", Cmd: "sleep", Args: []string{"5"}},
+		},
+		Timeout: 200 * time.Millisecond,
+	})
+
+	snapshot := collector.Capture()
+	if len(snapshot.Outputs) != 3 {
+		t.Fatalf("Capture() outputs = %d, want 3", len(snapshot.Outputs))
+	}
+
+	status := snapshot.Outputs[0]
+	if status.Command != "echo connected" || status.Output != "connected" || status.Error != "" {
+		t.Errorf("status output = %+v, want echo connected / connected without error", status)
+	}
+
+	failing := snapshot.Outputs[1]
+	if failing.Output != "partial" || failing.Error == "" {
+		t.Errorf("failing output = %+v, want partial with an error", failing)
+	}
+
+	slow := snapshot.Outputs[2]
+	if !strings.Contains(slow.Error, "timed out") {
+		t.Errorf("slow error = %q, want a timeout", slow.Error)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := &Snapshot{Outputs: []Output{
+		{Name: "status", Command: "doublezero status", Output: "tunnel: up\nversion: 0.6.9"},
+		{Name: "routes", Command: "ip route", Output: "default via 10.0.0.1"},
+	}}
+
+	if diff := Diff(before, before); diff != nil {
+		t.Errorf("Diff() of equal snapshots = %v, want nil", diff)
+	}
+
+	after := &Snapshot{Outputs: []Output{
+		{Name: "status", Command: "doublezero status", Output: "tunnel: down\nversion: 0.7.1", Error: "exit status 1"},
+		{Name: "routes", Command: "ip route", Output: "default via 10.0.0.1"},
+	}}
+	want := []string{
+		"== status (doublezero status) ==",
+		"- tunnel: up",
+		"- version: 0.6.9",
+		"+ tunnel: down",
+		"+ version: 0.7.1",
+		"+ error: exit status 1",
+	}
+	diff := Diff(before, after)
+	if strings.Join(diff, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Diff() =\n%s\nwant\n%s", strings.Join(diff, "\n"), strings.Join(want, "\n"))
+	}
+
+	if diff := Diff(nil, after); diff != nil {
+		t.Errorf("Diff() without a before snapshot = %v, want nil", diff)
+	}
+}
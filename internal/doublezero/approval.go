@@ -0,0 +1,154 @@
+package doublezero
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// errApprovalPending is returned while the sync plan doesn't have the approvals sync.approval requires
+var errApprovalPending = errors.New("approval pending")
+
+// planIDLength is the number of hex characters of the plan digest shown as its plan id
+const planIDLength = 12
+
+// PlanDigest returns the digest of a sync plan - a sha256 hash of the cluster, the installed and target versions and
+// the rendered commands, so an approval only ever applies to the exact commands that were approved
+func PlanDigest(cluster, fromVersion, toVersion string, plan sync_commands.Plan) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", cluster, fromVersion, toVersion)
+	for _, line := range plan.Lines() {
+		fmt.Fprintln(hash, line)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// PlanID returns the short id of a plan digest operators refer to the plan by - approvals are signed and verified
+// against the full digest, the id is too short to rule out a colliding plan
+func PlanID(planDigest string) string {
+	return planDigest[:min(planIDLength, len(planDigest))]
+}
+
+// ApprovalMessage returns the message an approver signs to approve the plan - the plan digest already covers the
+// cluster, versions and rendered commands
+func ApprovalMessage(planDigest string) []byte {
+	return []byte("doublezero-version-sync approve plan " + planDigest)
+}
+
+// SignApproval signs the approval of the plan digest with the approver's private key, returning the base58 signature
+// recorded with the approval
+func SignApproval(key solana.PrivateKey, planDigest string) (string, error) {
+	signature, err := key.Sign(ApprovalMessage(planDigest))
+	if err != nil {
+		return "", err
+	}
+	return signature.String(), nil
+}
+
+// VerifyApproval returns true if the approval of the plan digest is signed by the key of the sync.approval.approvers
+// entry it was recorded by - an unsigned approval, one by an unlisted approver or one signed for another plan doesn't
+// count
+func VerifyApproval(approvalConfig *config.Approval, planDigest string, approval state.Approval) bool {
+	approver, ok := approvalConfig.ApproverByName(approval.By)
+	if !ok || approval.Signature == "" {
+		return false
+	}
+	pubkey, err := solana.PublicKeyFromBase58(approver.Pubkey)
+	if err != nil {
+		return false
+	}
+	signature, err := solana.SignatureFromBase58(approval.Signature)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(pubkey, ApprovalMessage(planDigest))
+}
+
+// checkApproval holds the sync plan until sync.approval.required_approvals distinct operators approved it with the
+// approve subcommand, signed with their sync.approval.approvers key over the plan digest, returning a description of
+// its approvals. A plan that differs from the pending one replaces it, dropping its approvals, and is notified once.
+// Returns errApprovalPending while approvals are missing. Simulated cycles evaluate against the stored state without
+// updating it or notifying.
+func (dz *DoubleZero) checkApproval(logger *log.Logger, versionDiff versiondiff.VersionDiff, plan sync_commands.Plan) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("the sync plan could not be rendered - refusing to sync without an approved plan")
+	}
+
+	approvalConfig := &dz.syncConfig.Approval
+	planDigest := PlanDigest(dz.State.Cluster, versionDiff.From.Original(), versionDiff.To.Original(), plan)
+	planID := PlanID(planDigest)
+	var approvedBy []string
+
+	record := func(st *state.State) error {
+		// pending state recorded without a digest predates signing the digest and is replaced too
+		if st.PendingApproval == nil || st.PendingApproval.PlanDigest != planDigest {
+			if st.PendingApproval != nil {
+				logger.Warn("sync plan changed - approvals of the previous plan no longer apply",
+					"previous_plan_id", st.PendingApproval.PlanID, "plan_id", planID)
+			}
+			st.PendingApproval = &state.PendingApproval{
+				PlanID:      planID,
+				PlanDigest:  planDigest,
+				FromVersion: versionDiff.From.Original(),
+				ToVersion:   versionDiff.To.Original(),
+				Commands:    plan.Lines(),
+				RequestedAt: dz.clock.Now().UTC(),
+			}
+		}
+
+		pending := st.PendingApproval
+		approvedBy = pending.ApprovedBy(func(approval state.Approval) bool {
+			return VerifyApproval(approvalConfig, planDigest, approval)
+		})
+		if len(approvedBy) < approvalConfig.RequiredApprovals && !pending.Notified && !dz.simulate {
+			pending.Notified = true
+			dz.notifier.Notify(notify.Event{
+				Type:     notify.EventApprovalRequired,
+				Severity: constants.NotificationSeverityWarning,
+				Message: fmt.Sprintf("DoubleZero sync v%s -> v%s needs %d approvals before it runs - review and approve plan %s",
+					versionDiff.From.Core().String(), versionDiff.To.Core().String(), approvalConfig.RequiredApprovals, planID),
+				Fields: map[string]string{
+					"plan_id":            planID,
+					"version_from":       versionDiff.From.Original(),
+					"version_to":         versionDiff.To.Original(),
+					"required_approvals": fmt.Sprintf("%d", approvalConfig.RequiredApprovals),
+					"commands":           strings.Join(pending.Commands, "\n"),
+				},
+			})
+		}
+		return nil
+	}
+
+	if dz.simulate {
+		st, err := dz.stateStore.Load()
+		if err != nil {
+			return "", fmt.Errorf("failed to load state: %w", err)
+		}
+		if err := record(&st); err != nil {
+			return "", err
+		}
+	} else if err := dz.stateStore.Update(record); err != nil {
+		return "", fmt.Errorf("failed to update state: %w", err)
+	}
+
+	if len(approvedBy) < approvalConfig.RequiredApprovals {
+		logger.Info("sync plan awaiting approval - waiting", "plan_id", planID,
+			"approved_by", approvedBy, "required_approvals", approvalConfig.RequiredApprovals)
+		return "", fmt.Errorf("%w - plan %s approved by %d of %d required operators, approve with: doublezero-version-sync approve %s",
+			errApprovalPending, planID, len(approvedBy), approvalConfig.RequiredApprovals, planID)
+	}
+
+	logger.Info("sync plan approved", "plan_id", planID, "approved_by", approvedBy)
+	return fmt.Sprintf("plan %s approved by %s", planID, strings.Join(approvedBy, ", ")), nil
+}
@@ -0,0 +1,168 @@
+package doublezero
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+func TestVerifyApproval(t *testing.T) {
+	alice, bob := solana.NewWallet().PrivateKey, solana.NewWallet().PrivateKey
+	approvalConfig := &config.Approval{Enabled: true, RequiredApprovals: 1, Approvers: []config.Approver{
+		{Name: "alice", Pubkey: alice.PublicKey().String()},
+		{Name: "bob", Pubkey: bob.PublicKey().String()},
+	}}
+	plan := sync_commands.Plan{{Name: "install", Cmd: "apt-get", Args: []string{"install", "doublezero=0.8.1-1"}}}
+	planDigest := PlanDigest("testnet", "0.6.9", "0.8.1-1", plan)
+	otherDigest := PlanDigest("mainnet-beta", "0.6.9", "0.8.1-1", plan)
+	sign := func(key solana.PrivateKey, planDigest string) string {
+		signature, err := SignApproval(key, planDigest)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return signature
+	}
+
+	tests := []struct {
+		name     string
+		approval state.Approval
+		want     bool
+	}{
+		{name: "signed by the approver's key", approval: state.Approval{By: "alice", Signature: sign(alice, planDigest)}, want: true},
+		{name: "unsigned", approval: state.Approval{By: "alice"}},
+		{name: "signed by another approver's key", approval: state.Approval{By: "alice", Signature: sign(bob, planDigest)}},
+		{name: "signed for another plan", approval: state.Approval{By: "alice", Signature: sign(alice, otherDigest)}},
+		{name: "signed for the plan id only", approval: state.Approval{By: "alice", Signature: sign(alice, PlanID(planDigest))}},
+		{name: "unlisted approver", approval: state.Approval{By: "mallory", Signature: sign(alice, planDigest)}},
+		{name: "malformed signature", approval: state.Approval{By: "alice", Signature: "not-a-signature"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyApproval(approvalConfig, planDigest, tt.approval); got != tt.want {
+				t.Errorf("VerifyApproval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckApproval(t *testing.T) {
+	alice, bob := solana.NewWallet().PrivateKey, solana.NewWallet().PrivateKey
+	approvers := []config.Approver{
+		{Name: "alice", Pubkey: alice.PublicKey().String()},
+		{Name: "bob", Pubkey: bob.PublicKey().String()},
+	}
+	versionDiff := versiondiff.VersionDiff{From: version.Must(version.NewVersion("0.6.9")), To: version.Must(version.NewVersion("0.8.1-1"))}
+	plan := sync_commands.Plan{{Name: "install", Cmd: "apt-get", Args: []string{"install", "doublezero=0.8.1-1"}}}
+	planDigest := PlanDigest("testnet", "0.6.9", "0.8.1-1", plan)
+	planID := PlanID(planDigest)
+	otherDigest := PlanDigest("testnet", "0.6.9", "0.8.2-1", plan)
+	approve := func(name string, key solana.PrivateKey, planDigest string) state.Approval {
+		signature, err := SignApproval(key, planDigest)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return state.Approval{By: name, Signature: signature}
+	}
+
+	tests := []struct {
+		name         string
+		pending      *state.PendingApproval
+		simulate     bool
+		wantErr      bool
+		wantNotified bool
+		wantPlanID   string
+	}{
+		{name: "first held plan is notified", wantErr: true, wantNotified: true, wantPlanID: planID},
+		{
+			name:       "one of two approvals is pending",
+			pending:    &state.PendingApproval{PlanID: planID, PlanDigest: planDigest, Notified: true, Approvals: []state.Approval{approve("alice", alice, planDigest)}},
+			wantErr:    true,
+			wantPlanID: planID,
+		},
+		{
+			name: "two signed approvals run the plan",
+			pending: &state.PendingApproval{PlanID: planID, PlanDigest: planDigest, Notified: true, Approvals: []state.Approval{
+				approve("alice", alice, planDigest), approve("bob", bob, planDigest),
+			}},
+			wantPlanID: planID,
+		},
+		{
+			name: "an approval forged in another approver's name doesn't count",
+			pending: &state.PendingApproval{PlanID: planID, PlanDigest: planDigest, Notified: true, Approvals: []state.Approval{
+				approve("alice", alice, planDigest), approve("bob", alice, planDigest),
+			}},
+			wantErr:    true,
+			wantPlanID: planID,
+		},
+		{
+			name: "approvals of a changed plan are dropped",
+			pending: &state.PendingApproval{PlanID: PlanID(otherDigest), PlanDigest: otherDigest, Notified: true, Approvals: []state.Approval{
+				approve("alice", alice, otherDigest), approve("bob", bob, otherDigest),
+			}},
+			wantErr:      true,
+			wantNotified: true,
+			wantPlanID:   planID,
+		},
+		{
+			name: "a plan held without a digest is replaced",
+			pending: &state.PendingApproval{PlanID: planID, Notified: true, Approvals: []state.Approval{
+				approve("alice", alice, planID), approve("bob", bob, planID),
+			}},
+			wantErr:      true,
+			wantNotified: true,
+			wantPlanID:   planID,
+		},
+		{
+			name:       "simulation neither records nor notifies the held plan",
+			simulate:   true,
+			wantErr:    true,
+			wantPlanID: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dz, notifier := newTestDoubleZero(t, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			dz.syncConfig.Approval = config.Approval{Enabled: true, RequiredApprovals: 2, Approvers: approvers}
+			dz.simulate = tt.simulate
+			if tt.pending != nil {
+				if err := dz.stateStore.Update(func(st *state.State) error {
+					st.PendingApproval = tt.pending
+					return nil
+				}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			_, err := dz.checkApproval(dz.logger, versionDiff, plan)
+			if tt.wantErr != (err != nil) || (err != nil && !errors.Is(err, errApprovalPending)) {
+				t.Fatalf("checkApproval() error = %v, want pending %v", err, tt.wantErr)
+			}
+			if notified := len(notifier.events) == 1 && notifier.events[0].Type == notify.EventApprovalRequired; notified != tt.wantNotified {
+				t.Errorf("notified %+v, want approval_required notified %v", notifier.events, tt.wantNotified)
+			}
+
+			st, err := dz.stateStore.Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			gotPlanID := ""
+			if st.PendingApproval != nil {
+				gotPlanID = st.PendingApproval.PlanID
+			}
+			if gotPlanID != tt.wantPlanID {
+				t.Errorf("pending plan = %q, want %q", gotPlanID, tt.wantPlanID)
+			}
+			if tt.pending != nil && tt.pending.PlanDigest != planDigest && len(st.PendingApproval.Approvals) != 0 {
+				t.Errorf("approvals = %+v, want the previous plan's approvals dropped", st.PendingApproval.Approvals)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package doublezero
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// recordAudit appends a command that ran to the audit log when enabled - a command that didn't run (disabled or
+// skipped by its check) isn't recorded. Failing to record is logged rather than failing the sync, the change is made
+func (dz *DoubleZero) recordAudit(logger *log.Logger, kind string, data sync_commands.CommandTemplateData, result sync_commands.Result, err error) {
+	if dz.auditLog == nil || result.Execution == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Cluster:     dz.State.Cluster,
+		Kind:        kind,
+		Name:        result.Name,
+		CommandLine: result.Execution.CommandLine,
+		StartedAt:   result.Execution.StartedAt,
+		FinishedAt:  result.Execution.FinishedAt,
+		ExitCode:    result.Execution.ExitCode,
+		Status:      result.Status,
+		Stdout:      result.Execution.Stdout,
+		Stderr:      result.Execution.Stderr,
+		FromVersion: data.VersionFrom,
+		ToVersion:   data.PackageVersionTo,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if recordErr := dz.auditLog.Record(entry); recordErr != nil {
+		logger.Error("failed to record command in the audit log", "path", dz.auditLog.Path(), "command", result.Name, "error", recordErr)
+	}
+}
@@ -0,0 +1,198 @@
+package doublezero
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+)
+
+// binaryStamp identifies the doublezero binary on disk - it changes when the binary is replaced, or its symlink or PATH
+// entry points somewhere else
+type binaryStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+	// version is the installed version the binary was last known to report
+	version string
+}
+
+// binaryWatchSettle is how long the binary has to go without filesystem events before a change is reported, so a
+// binary still being written isn't run to read its version
+var binaryWatchSettle = time.Second
+
+// statBinary returns the stamp of the binary, looked up in PATH when it isn't a path and with symlinks resolved
+func statBinary(bin string) (binaryStamp, error) {
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return binaryStamp{}, fmt.Errorf("failed to find doublezero binary %s: %w", bin, err)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return binaryStamp{}, fmt.Errorf("failed to resolve doublezero binary %s: %w", path, err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return binaryStamp{}, fmt.Errorf("failed to stat doublezero binary %s: %w", resolved, err)
+	}
+	return binaryStamp{path: resolved, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// stampBinary records the binary as it is after a cycle, so the changes its sync commands made aren't taken for out
+// of band changes
+func (dz *DoubleZero) stampBinary(rep *report.Report) {
+	stamp, err := statBinary(dz.bin)
+	if err != nil {
+		dz.logger.Debug("not watching doublezero binary", "error", err)
+		dz.binary = nil
+		return
+	}
+
+	stamp.version = rep.InstalledVersion
+	if rep.Outcome == report.OutcomeSynced && rep.Recommendation != nil {
+		stamp.version = rep.TargetVersion()
+	}
+	dz.binary = &stamp
+}
+
+// CheckBinary returns whether the doublezero binary changed since the last cycle without the syncer changing it, e.g.
+// after a manual upgrade. A change refreshes the installed version and publishes an OutOfBandChange event. Changes
+// are only detected between cycles, after the first one
+func (dz *DoubleZero) CheckBinary() (bool, error) {
+	if dz.binary == nil {
+		return false, nil
+	}
+
+	stamp, err := statBinary(dz.bin)
+	if err != nil {
+		return false, err
+	}
+	previous := *dz.binary
+	stamp.version = previous.version
+	if stamp == previous {
+		return false, nil
+	}
+
+	dz.binary = &stamp
+	installedVersion, err := dz.getInstalledVersion()
+	if err != nil {
+		return true, fmt.Errorf("doublezero binary changed but its version can't be read: %w", err)
+	}
+	dz.State.Version = installedVersion
+	dz.State.VersionString = installedVersion.String()
+	stamp.version = installedVersion.Original()
+
+	dz.logger.Warn("doublezero binary changed out of band", "bin", stamp.path,
+		"previous_version", previous.version, "installed_version", stamp.version)
+	dz.publish(events.Event{Type: events.OutOfBandChange, FromVersion: previous.version, ToVersion: stamp.version})
+	return true, nil
+}
+
+// WatchBinary watches the doublezero binary with inotify until ctx is done, sending on the returned channel once a
+// change to it settled so CheckBinary can tell whether it was out of band. The resolved binary and the directories of
+// the path it's looked up at and of the resolved path are watched - a package upgrade renames a new file over the
+// binary, which only its directory sees - and the watches follow the binary when its symlink is repointed. Returns a
+// nil channel before the first cycle stamped the binary
+func (dz *DoubleZero) WatchBinary(ctx context.Context) (<-chan struct{}, error) {
+	if dz.binary == nil {
+		return nil, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create doublezero binary watcher: %w", err)
+	}
+	w := &binaryWatcher{bin: dz.bin, watcher: watcher, watched: map[string]bool{}}
+	if err := w.follow(); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	changed := make(chan struct{}, 1)
+	go w.run(ctx, dz.logger, changed)
+	return changed, nil
+}
+
+// binaryWatcher watches the paths the doublezero binary is found at
+type binaryWatcher struct {
+	bin     string
+	watcher *fsnotify.Watcher
+	// watched are the paths added to the watcher
+	watched map[string]bool
+	// names are the paths whose events are changes to the binary - the path it's looked up at and the resolved one
+	names map[string]bool
+}
+
+// follow resolves the binary and watches it and the directories of its looked up and resolved paths, dropping the
+// watches of paths it no longer resolves through
+func (w *binaryWatcher) follow() error {
+	path, err := exec.LookPath(w.bin)
+	if err != nil {
+		return fmt.Errorf("failed to find doublezero binary %s: %w", w.bin, err)
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return fmt.Errorf("failed to resolve doublezero binary %s: %w", w.bin, err)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve doublezero binary %s: %w", path, err)
+	}
+
+	watched := map[string]bool{filepath.Dir(path): true, filepath.Dir(resolved): true, resolved: true}
+	for p := range watched {
+		// re-adding a path already watched is a no-op, and re-watches a binary that was renamed over
+		if err := w.watcher.Add(p); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", p, err)
+		}
+	}
+	for p := range w.watched {
+		if !watched[p] {
+			_ = w.watcher.Remove(p)
+		}
+	}
+	w.watched = watched
+	w.names = map[string]bool{path: true, resolved: true}
+	return nil
+}
+
+// run forwards settled changes to the binary to changed until ctx is done, closing the watcher
+func (w *binaryWatcher) run(ctx context.Context, logger *log.Logger, changed chan<- struct{}) {
+	defer w.watcher.Close()
+
+	settle := time.NewTimer(binaryWatchSettle)
+	settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.names[event.Name] {
+				settle.Reset(binaryWatchSettle)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("doublezero binary watcher failed", "error", err)
+		case <-settle.C:
+			// the binary may be gone until it's reinstalled - keep the watches on its directories then
+			if err := w.follow(); err != nil {
+				logger.Debug("failed to follow doublezero binary", "error", err)
+			}
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
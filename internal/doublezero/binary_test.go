@@ -0,0 +1,143 @@
+package doublezero
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+)
+
+// writeBinary atomically replaces file with a doublezero binary reporting version, the way a package upgrade does
+func writeBinary(t *testing.T, file, version string) {
+	t.Helper()
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, []byte("#!/bin/sh\necho \"DoubleZero "+version+"\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// waitChanged returns whether changed receives within wait
+func waitChanged(changed <-chan struct{}, wait time.Duration) bool {
+	select {
+	case <-changed:
+		return true
+	case <-time.After(wait):
+		return false
+	}
+}
+
+func TestWatchBinary(t *testing.T) {
+	settle := binaryWatchSettle
+	binaryWatchSettle = 10 * time.Millisecond
+	t.Cleanup(func() { binaryWatchSettle = settle })
+
+	tests := []struct {
+		name string
+		// replace changes the binary installed at bin, a symlink to a binary in dir
+		replace     func(t *testing.T, bin, dir string)
+		wantChanged bool
+		wantVersion string
+	}{
+		{
+			name: "binary replaced",
+			replace: func(t *testing.T, bin, dir string) {
+				writeBinary(t, filepath.Join(dir, "doublezero-0.7.1"), "0.8.0-10")
+			},
+			wantChanged: true,
+			wantVersion: "0.8.0-10",
+		},
+		{
+			name: "symlink repointed",
+			replace: func(t *testing.T, bin, dir string) {
+				// to a directory that wasn't watched
+				target := filepath.Join(t.TempDir(), "doublezero-0.8.0")
+				writeBinary(t, target, "0.8.0-10")
+				if err := os.Symlink(target, bin+".new"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := os.Rename(bin+".new", bin); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			wantChanged: true,
+			wantVersion: "0.8.0-10",
+		},
+		{
+			name: "other file in its directory changed",
+			replace: func(t *testing.T, bin, dir string) {
+				writeBinary(t, filepath.Join(dir, "doublezero-0.6.0"), "0.6.0-1")
+			},
+			wantChanged: false,
+			wantVersion: "0.7.1-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			linkDir := t.TempDir()
+			bin := filepath.Join(linkDir, "doublezero")
+			writeBinary(t, filepath.Join(dir, "doublezero-0.7.1"), "0.7.1-1")
+			if err := os.Symlink(filepath.Join(dir, "doublezero-0.7.1"), bin); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			dz, _ := newTestDoubleZero(t, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			dz.bin = bin
+			dz.events = events.NewBus()
+			var published []events.Event
+			dz.events.Subscribe(func(event events.Event) {
+				published = append(published, event)
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changed, err := dz.WatchBinary(ctx)
+			if err != nil || changed != nil {
+				t.Fatalf("WatchBinary() before the first cycle = %v, %v, want nil, nil", changed, err)
+			}
+
+			dz.stampBinary(&report.Report{InstalledVersion: "0.7.1-1"})
+			if changed, err = dz.WatchBinary(ctx); err != nil {
+				t.Fatalf("WatchBinary() error = %v", err)
+			}
+			tt.replace(t, bin, dir)
+
+			if got := waitChanged(changed, time.Second); got != tt.wantChanged {
+				t.Fatalf("WatchBinary() sent change = %v, want %v", got, tt.wantChanged)
+			}
+			outOfBand, err := dz.CheckBinary()
+			if err != nil {
+				t.Fatalf("CheckBinary() error = %v", err)
+			}
+			if outOfBand != tt.wantChanged {
+				t.Errorf("CheckBinary() = %v, want %v", outOfBand, tt.wantChanged)
+			}
+			if dz.binary.version != tt.wantVersion {
+				t.Errorf("binary version = %s, want %s", dz.binary.version, tt.wantVersion)
+			}
+			if tt.wantChanged && (len(published) != 1 || published[0].Type != events.OutOfBandChange) {
+				t.Errorf("published = %+v, want one %s event", published, events.OutOfBandChange)
+			}
+			if !tt.wantChanged {
+				return
+			}
+
+			// the watch follows the binary it now resolves to
+			writeBinary(t, dz.binary.path, "0.9.0-100")
+			if !waitChanged(changed, time.Second) {
+				t.Fatalf("WatchBinary() sent no change after the followed binary was replaced")
+			}
+			if outOfBand, err := dz.CheckBinary(); err != nil || !outOfBand {
+				t.Errorf("CheckBinary() = %v, %v, want true", outOfBand, err)
+			}
+		})
+	}
+}
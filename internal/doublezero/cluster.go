@@ -0,0 +1,38 @@
+package doublezero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// errClusterUnverified is returned when the validator's cluster can't be checked and validator.on_unreachable allows
+// continuing without it
+var errClusterUnverified = errors.New("validator cluster unverified")
+
+// checkClusterGenesis checks the validator's genesis hash is the configured cluster's, returning the genesis hash
+// Returns an error naming the validator's actual cluster when it belongs to another one
+func (dz *DoubleZero) checkClusterGenesis(ctx context.Context) (string, error) {
+	genesisHash, err := dz.validatorRPCClient.GetGenesisHash(ctx)
+	if err != nil {
+		if dz.validatorConfig.OnUnreachable == constants.ValidatorOnUnreachableFail {
+			return "", fmt.Errorf("failed to verify validator cluster: %w", err)
+		}
+		return "", fmt.Errorf("%w: %w", errClusterUnverified, err)
+	}
+
+	if genesisHash == constants.ClusterGenesisHashes[dz.State.Cluster] {
+		return genesisHash, nil
+	}
+
+	actualCluster := "an unknown cluster"
+	for clusterName, clusterGenesisHash := range constants.ClusterGenesisHashes {
+		if genesisHash == clusterGenesisHash {
+			actualCluster = clusterName
+		}
+	}
+	return "", fmt.Errorf("validator genesis hash %s belongs to %s, not the configured cluster %s - check cluster.name",
+		genesisHash, actualCluster, dz.State.Cluster)
+}
@@ -0,0 +1,63 @@
+package doublezero
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/diagnostics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// captureDiagnostics captures the diagnostic commands before the sync commands run, nil when none are configured
+func (dz *DoubleZero) captureDiagnostics(logger *log.Logger) *diagnostics.Snapshot {
+	if !dz.diagnostics.IsEnabled() {
+		return nil
+	}
+	logger.Info("capturing diagnostics before sync")
+	return dz.diagnostics.Capture()
+}
+
+// finishSync captures the diagnostic commands after the sync commands ran, recording both snapshots and their diff in
+// the report, and notifies whether the sync succeeded or failed
+func (dz *DoubleZero) finishSync(logger *log.Logger, rep *report.Report, versionDiff versiondiff.VersionDiff, before *diagnostics.Snapshot, syncErr error) {
+	var diff []string
+	if before != nil {
+		logger.Info("capturing diagnostics after sync")
+		after := dz.diagnostics.Capture()
+		diff = diagnostics.Diff(before, after)
+		rep.Diagnostics = &report.Diagnostics{Before: before, After: after, Diff: diff}
+	}
+
+	if syncErr == nil {
+		dz.notifier.Notify(notify.Event{
+			Type:     notify.EventSynced,
+			Severity: constants.NotificationSeverityInfo,
+			Message:  fmt.Sprintf("DoubleZero synced v%s -> v%s", versionDiff.From.Core().String(), versionDiff.To.Core().String()),
+			Fields: map[string]string{
+				"version_from": versionDiff.From.Core().String(),
+				"version_to":   versionDiff.To.Core().String(),
+				"direction":    versionDiff.Direction(),
+			},
+		})
+		return
+	}
+
+	fields := map[string]string{
+		"version_from": versionDiff.From.Core().String(),
+		"version_to":   versionDiff.To.Core().String(),
+		"error":        syncErr.Error(),
+	}
+	if len(diff) > 0 {
+		fields["diagnostics_diff"] = strings.Join(diff, "\n")
+	}
+	dz.notifier.Notify(notify.Event{
+		Type:     notify.EventSyncFailed,
+		Severity: constants.NotificationSeverityCritical,
+		Message:  fmt.Sprintf("DoubleZero sync v%s -> v%s failed: %s", versionDiff.From.Core().String(), versionDiff.To.Core().String(), syncErr),
+		Fields:   fields,
+	})
+}
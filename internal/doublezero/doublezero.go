@@ -1,45 +1,164 @@
 package doublezero
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
-	"regexp"
+	"io"
+	"net/http"
+	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
 	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/daemon"
+	"github.com/sol-strategies/doublezero-version-sync/internal/diagnostics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/fleet"
+	"github.com/sol-strategies/doublezero-version-sync/internal/freeze"
+	"github.com/sol-strategies/doublezero-version-sync/internal/healthchecks"
+	"github.com/sol-strategies/doublezero-version-sync/internal/hostmaintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/identity"
+	"github.com/sol-strategies/doublezero-version-sync/internal/installed"
+	"github.com/sol-strategies/doublezero-version-sync/internal/jitter"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/reboot"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
 	"github.com/sol-strategies/doublezero-version-sync/internal/rpc"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
 	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
 	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
 	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
 )
 
-var (
-	// versionPattern extracts version strings like "0.7.1", "0.7.1-1", etc.
-	// Handles formats like "DoubleZero 0.7.1", "0.7.1-1", etc.
-	versionPattern = regexp.MustCompile(`(\d+\.\d+\.\d+(?:-\d+)?)`)
-)
+// errMonitorOnly is returned by checks that allow the sync to continue but forbid executing commands
+var errMonitorOnly = errors.New("monitor only")
+
+// reasonError is an error carrying the reason code of the gate it ends a cycle at, for gates with several reasons
+type reasonError struct {
+	reason string
+	err    error
+}
+
+// withReason attaches a reason code to an error
+func withReason(reason string, err error) error {
+	return &reasonError{reason: reason, err: err}
+}
+
+// Error returns the wrapped error's message
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+// setGateReason overrides the reason code of the gate just recorded when the error carries one
+func setGateReason(rep *report.Report, err error) {
+	var reasonErr *reasonError
+	if errors.As(err, &reasonErr) {
+		rep.SetReason(reasonErr.reason)
+	}
+}
+
+// ErrInstalledVersionMismatch is returned when the binary doesn't report the target version after the sync commands ran
+var ErrInstalledVersionMismatch = errors.New("installed version does not match target after sync")
 
 // Options represents the options for creating a new DoubleZero instance
 type Options struct {
-	Cluster          string
-	SyncConfig       config.Sync
-	DoubleZeroConfig config.DoubleZero
-	ValidatorConfig  config.Validator
+	Cluster               string
+	SyncConfig            config.Sync
+	DoubleZeroConfig      config.DoubleZero
+	ValidatorConfig       config.Validator
+	FailoverConfig        config.Failover
+	VersionSourceConfig   config.VersionSource
+	RebootConfig          config.Reboot
+	HostMaintenanceConfig config.HostMaintenance
+	FreezeConfig          config.Freeze
+	FleetConfig           config.Fleet
+	StateConfig           config.State
+	StateStore            *state.Store
+	Notifier              *notify.Dispatcher
+	// Events receives the sync lifecycle events, defaults to a bus without subscribers
+	Events *events.Bus
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock is used for timestamps and waits, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport for the version source, validator RPC and failover requests,
+	// defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// AuditLog records every command executed during syncs, nil to not record them
+	AuditLog *audit.Log
+	// ShutdownGracePeriod is how long a command running when the cycle's context is done may take to finish before it
+	// is killed, see runtime.shutdown_grace_period
+	ShutdownGracePeriod time.Duration
 }
 
 // DoubleZero represents the DoubleZero instance - its state can be refreshed with the RefreshState method
 type DoubleZero struct {
 	State State
 
-	syncConfig         config.Sync
-	logger             *log.Logger
-	versionSource      *versionsource.Source
-	validatorConfig    config.Validator
-	doubleZeroConfig   config.DoubleZero
-	validatorRPCClient *rpc.Client
-	bin                string
+	syncConfig            config.Sync
+	logger                *log.Logger
+	parentLogger          *log.Logger
+	clock                 clock.Clock
+	httpClient            *http.Client
+	versionSource         versionsource.VersionSource
+	publishedChecker      *versionsource.CloudsmithIndexSource
+	validatorConfig       config.Validator
+	doubleZeroConfig      config.DoubleZero
+	failoverConfig        config.Failover
+	rebootConfig          config.Reboot
+	hostMaintenanceConfig config.HostMaintenance
+	stateConfig           config.State
+	validatorRPCClient    *rpc.Client
+	windows               *maintenance.Schedule
+	jitter                jitter.Range
+	identities            *identity.Loader
+	identitySource        identity.Source
+	daemonChecker         *daemon.Checker
+	preChecker            *healthchecks.Checker
+	postChecker           *healthchecks.Checker
+	diagnostics           *diagnostics.Collector
+	rebootChecker         *reboot.Checker
+	// hostMaintenance is nil when host_maintenance.policy is disabled
+	hostMaintenance *hostmaintenance.Checker
+	// refreshReposCommand is nil when sync.refresh_repos is disabled
+	refreshReposCommand *sync_commands.Command
+	// freeze is nil without freeze periods or calendars
+	freeze *freeze.Calendar
+	// fleet is nil when fleet.quorum is 0
+	fleet       *fleet.Exchange
+	fleetQuorum int
+	stateStore  *state.Store
+	auditLog    *audit.Log
+	notifier    *notify.Dispatcher
+	events      *events.Bus
+	bin         string
+	// binary is the doublezero binary as it was after the last cycle, to tell out of band changes apart
+	binary *binaryStamp
+	// simulate is set while a simulated cycle runs, suppressing all side effects
+	simulate bool
+	// deadline is when a running cycle stops before its next sync command, zero for none
+	deadline time.Time
+	// abandoned is set by the watchdog when it gave up on a wedged cycle, see Abandon
+	abandoned atomic.Bool
+	// shutdownGracePeriod is how long a running command may take to finish after the cycle's context is done
+	shutdownGracePeriod time.Duration
+	// promptIn and promptOut are the terminal the failover.policy prompt asks on, stdin and stderr
+	promptIn  *os.File
+	promptOut io.Writer
 }
 
 // State represents the state of the DoubleZero installation
@@ -47,10 +166,22 @@ type State struct {
 	Cluster       string
 	VersionString string
 	Version       *version.Version
+	// Recommendation is the most recent recommendation fetched from the version source
+	Recommendation *versionsource.Recommendation
 }
 
 // New creates a new DoubleZero instance
 func New(opts Options) (dz *DoubleZero, err error) {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if opts.Events == nil {
+		opts.Events = events.NewBus()
+	}
+
 	bin := opts.DoubleZeroConfig.Bin
 	if bin == "" {
 		bin = "doublezero"
@@ -60,41 +191,372 @@ func New(opts Options) (dz *DoubleZero, err error) {
 		State: State{
 			Cluster: opts.Cluster,
 		},
-		syncConfig:       opts.SyncConfig,
-		logger:           log.WithPrefix("doublezero"),
-		validatorConfig:  opts.ValidatorConfig,
-		doubleZeroConfig: opts.DoubleZeroConfig,
-		versionSource:    versionsource.New(opts.Cluster),
-		bin:              bin,
+		syncConfig:            opts.SyncConfig,
+		logger:                logging.WithPrefix(opts.Logger, "doublezero"),
+		parentLogger:          opts.Logger,
+		clock:                 opts.Clock,
+		shutdownGracePeriod:   opts.ShutdownGracePeriod,
+		httpClient:            &http.Client{Transport: opts.Transport},
+		validatorConfig:       opts.ValidatorConfig,
+		doubleZeroConfig:      opts.DoubleZeroConfig,
+		failoverConfig:        opts.FailoverConfig,
+		rebootConfig:          opts.RebootConfig,
+		hostMaintenanceConfig: opts.HostMaintenanceConfig,
+		stateConfig:           opts.StateConfig,
+		stateStore:            opts.StateStore,
+		auditLog:              opts.AuditLog,
+		notifier:              opts.Notifier,
+		events:                opts.Events,
+		bin:                   bin,
+		promptIn:              os.Stdin,
+		promptOut:             os.Stderr,
+		daemonChecker: daemon.New(daemon.Options{
+			Check:       opts.DoubleZeroConfig.Daemon.Check,
+			ProcessName: opts.DoubleZeroConfig.Daemon.ProcessName,
+			SystemdUnit: opts.DoubleZeroConfig.Daemon.SystemdUnit,
+			SocketPath:  opts.DoubleZeroConfig.Daemon.SocketPath,
+			Logger:      opts.Logger,
+			Clock:       opts.Clock,
+		}),
+		preChecker: healthchecks.New(healthchecks.Options{
+			Checks:    opts.SyncConfig.PreChecks,
+			Kind:      "pre-check",
+			Logger:    opts.Logger,
+			Clock:     opts.Clock,
+			Transport: opts.Transport,
+		}),
+		postChecker: healthchecks.New(healthchecks.Options{
+			Checks:    opts.SyncConfig.PostChecks.Checks,
+			Kind:      "post-check",
+			Logger:    opts.Logger,
+			Clock:     opts.Clock,
+			Transport: opts.Transport,
+		}),
+		diagnostics: diagnostics.New(diagnostics.Options{
+			Commands: opts.SyncConfig.Diagnostics.Commands,
+			Timeout:  opts.SyncConfig.Diagnostics.Timeout,
+			Logger:   opts.Logger,
+			Clock:    opts.Clock,
+		}),
+		rebootChecker: reboot.New(reboot.Options{
+			RequiredFiles: opts.RebootConfig.RequiredFiles,
+			Logger:        opts.Logger,
+		}),
+	}
+
+	// Set up the host maintenance mode check if enabled
+	if opts.HostMaintenanceConfig.IsEnabled() {
+		dz.hostMaintenance = hostmaintenance.New(hostmaintenance.Options{
+			File:      opts.HostMaintenanceConfig.File,
+			URL:       opts.HostMaintenanceConfig.URL,
+			Timeout:   opts.HostMaintenanceConfig.Timeout,
+			Logger:    opts.Logger,
+			Transport: opts.Transport,
+		})
+	}
+
+	// Set up the change freeze calendar if configured
+	if opts.FreezeConfig.IsEnabled() {
+		dz.freeze = freeze.New(freeze.Options{
+			Periods:   opts.FreezeConfig.ParsedPeriods,
+			Calendars: opts.FreezeConfig.Calendars,
+			Timeout:   opts.FreezeConfig.Timeout,
+			Logger:    opts.Logger,
+			Transport: opts.Transport,
+		})
 	}
 
-	// Set up RPC client if validator is configured (both RPC URL and identity keypairs must be loaded)
-	if opts.ValidatorConfig.RPCURL != "" && opts.ValidatorConfig.Identities.ActiveKeyPair != nil && opts.ValidatorConfig.Identities.PassiveKeyPair != nil {
-		dz.validatorRPCClient = rpc.NewClient(opts.ValidatorConfig.RPCURL)
+	// Set up the peer exchange if this host waits for a quorum of peers
+	if opts.FleetConfig.Quorum > 0 {
+		secret, err := fleet.ReadSecret(opts.FleetConfig.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		dz.fleet = fleet.New(fleet.Options{
+			Peers:        opts.FleetConfig.Peers,
+			Secret:       secret,
+			Cluster:      opts.Cluster,
+			MaxBeaconAge: opts.FleetConfig.MaxBeaconAge,
+			Timeout:      opts.FleetConfig.Timeout,
+			Logger:       opts.Logger,
+			Clock:        opts.Clock,
+			Transport:    opts.Transport,
+		})
+		dz.fleetQuorum = opts.FleetConfig.Quorum
+	}
+
+	// Set up the configured version source
+	dz.versionSource, err = versionsource.NewFromConfig(opts.Cluster, opts.VersionSourceConfig, versionsource.Options{
+		Logger:    opts.Logger,
+		Clock:     opts.Clock,
+		Transport: opts.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up the repository index the target package is verified against if enabled
+	if opts.SyncConfig.VerifyPublished.Enabled {
+		dz.publishedChecker, err = versionsource.NewCloudsmithIndexFromConfig(opts.Cluster, opts.SyncConfig.VerifyPublished.Repository, versionsource.Options{
+			Logger:    opts.Logger,
+			Clock:     opts.Clock,
+			Transport: opts.Transport,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up sync.verify_published: %w", err)
+		}
+	}
+
+	// Set up the identity source if validator is configured (RPC URL or another identity source, and at least the
+	// active identity must be configured)
+	if opts.ValidatorConfig.IsEnabled() && opts.ValidatorConfig.Identities.IsConfigured() {
+		if opts.ValidatorConfig.RPCURL != "" {
+			dz.validatorRPCClient = rpc.NewClient(rpc.Options{
+				URL:                  opts.ValidatorConfig.RPCURL,
+				MaxRequestsPerSecond: opts.ValidatorConfig.RPCMaxRequestsPerSecond,
+				Logger:               opts.Logger,
+				Clock:                opts.Clock,
+				Transport:            opts.Transport,
+			})
+		}
+
+		// a nil *rpc.Client must not become a non-nil interface
+		var rpcClient identity.RPCClient
+		if dz.validatorRPCClient != nil {
+			rpcClient = dz.validatorRPCClient
+		}
+		dz.identitySource, err = identity.NewSourceFromConfig(opts.ValidatorConfig.IdentitySource, rpcClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up validator identity source: %w", err)
+		}
+		if dz.identitySource.Type() == constants.ValidatorIdentitySourceStatic {
+			dz.logger.Warn("validator identity is not observed - assuming it always runs as validator.identity_source.identity",
+				"identity", opts.ValidatorConfig.IdentitySource.Identity)
+		}
+
+		// load the identities now so unreadable files fail at startup rather than on the first sync
+		dz.identities = identity.New(identity.Options{
+			ActiveFile:       opts.ValidatorConfig.Identities.ActiveKeyPairFile,
+			PassiveFile:      opts.ValidatorConfig.Identities.PassiveKeyPairFile,
+			ActivePublicKey:  opts.ValidatorConfig.Identities.ActivePubkey,
+			PassivePublicKey: opts.ValidatorConfig.Identities.PassivePubkey,
+			Logger:           opts.Logger,
+		})
+		if _, err = dz.identities.Keys(); err != nil {
+			return nil, fmt.Errorf("failed to load validator identities: %w", err)
+		}
+	}
+
+	// Parse the maintenance windows version changes are applied in
+	dz.windows, err = maintenance.NewSchedule(opts.SyncConfig.Windows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync.windows%w", err)
+	}
+
+	// Parse the range this node's delay after a new target version is picked from
+	dz.jitter, err = jitter.ParseRange(opts.SyncConfig.Jitter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync.jitter: %w", err)
 	}
 
 	// Parse commands after copying the config
 	for i := range dz.syncConfig.Commands {
+		dz.syncConfig.Commands[i].SetLogger(opts.Logger)
+		dz.syncConfig.Commands[i].SetClock(opts.Clock)
 		err = dz.syncConfig.Commands[i].Parse()
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse command %d (%s): %w", i, dz.syncConfig.Commands[i].Name, err)
 		}
 	}
 
+	// Build the package cache refresh command if enabled
+	if dz.syncConfig.RefreshRepos.Enabled {
+		command := newRefreshReposCommand(dz.syncConfig.RefreshRepos.PackageManager)
+		command.SetLogger(opts.Logger)
+		command.SetClock(opts.Clock)
+		if err = command.Parse(); err != nil {
+			return nil, fmt.Errorf("failed to parse refresh repos command: %w", err)
+		}
+		dz.refreshReposCommand = &command
+	}
+
+	// Parse failover command if configured
+	if dz.failoverConfig.Command != nil {
+		dz.failoverConfig.Command.SetLogger(opts.Logger)
+		dz.failoverConfig.Command.SetClock(opts.Clock)
+		err = dz.failoverConfig.Command.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse failover command (%s): %w", dz.failoverConfig.Command.Name, err)
+		}
+	}
+
+	// Parse reboot command if configured
+	if dz.rebootConfig.Command != nil {
+		dz.rebootConfig.Command.SetLogger(opts.Logger)
+		dz.rebootConfig.Command.SetClock(opts.Clock)
+		err = dz.rebootConfig.Command.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse reboot command (%s): %w", dz.rebootConfig.Command.Name, err)
+		}
+	}
+
 	return dz, nil
 }
 
-// SyncVersion syncs the DoubleZero version
-func (dz *DoubleZero) SyncVersion() (err error) {
+// publish fills in the common event fields and publishes the event to the subscribers
+func (dz *DoubleZero) publish(event events.Event) {
+	event.Time = dz.clock.Now().UTC()
+	event.Cluster = dz.State.Cluster
+	event.Simulated = dz.simulate
+	dz.events.Publish(event)
+}
+
+// SetDeadline sets when a running cycle stops before its next sync command - a command already running is never
+// interrupted. The zero time clears it
+func (dz *DoubleZero) SetDeadline(deadline time.Time) {
+	dz.deadline = deadline
+}
+
+// Abandon marks the running cycle as given up on, safe to call while it runs - it runs no further sync commands and,
+// once it returns, neither publishes its outcome nor saves its report. The instance must not be used afterwards
+func (dz *DoubleZero) Abandon() {
+	dz.abandoned.Store(true)
+}
+
+// RefreshIdentities reloads the validator identity files if they changed on disk, returning true if an identity public
+// key changed since they were last loaded. Always false without a validator configured
+func (dz *DoubleZero) RefreshIdentities() (bool, error) {
+	if dz.identities == nil {
+		return false, nil
+	}
+	_, changed, err := dz.identities.Refresh()
+	return changed, err
+}
+
+// SyncVersion syncs the DoubleZero version and records the decision report in the state file. An upgrade through
+// doublezero.stepping_stones runs a cycle per hop, each verified and recorded before the next one runs, stopping at the
+// first hop that doesn't sync or leaves the host requiring a reboot - a later cycle resumes from the installed version
+// When ctx is done the cycle stops at its next wait or before its next sync command, see runtime.shutdown_grace_period
+// for a command already running
+func (dz *DoubleZero) SyncVersion(ctx context.Context) error {
+	for hop := 0; ; hop++ {
+		rep, err := dz.runCycle(ctx, false)
+		if err != nil || rep.Outcome != report.OutcomeSynced || rep.SteppingStone == "" || dz.abandoned.Load() || ctx.Err() != nil {
+			return err
+		}
+		// each stepping stone is installed at most once, so a hop that didn't move the installed version can't loop
+		if hop >= len(dz.doubleZeroConfig.ParsedSteppingStones) {
+			return nil
+		}
+		if rep.Reboot != nil && rep.Reboot.Required {
+			dz.logger.Warn("stepping stone requires a reboot - continuing the upgrade on a later cycle", "stepping_stone", rep.SteppingStone)
+			return nil
+		}
+		dz.logger.Info("upgraded to stepping stone - continuing to the next hop", "stepping_stone", rep.SteppingStone,
+			"target", rep.Recommendation.PackageVersion)
+	}
+}
+
+// Simulate evaluates a sync cycle without side effects - no commands, failover requests, notifications or state
+// updates - and returns its decision report
+func (dz *DoubleZero) Simulate(ctx context.Context) (*report.Report, error) {
+	return dz.runCycle(ctx, true)
+}
+
+// runCycle runs a sync cycle and returns its decision report - a real cycle holds sync.lock.file and its report is saved
+// to the state file, along with the sync attempt when it ran the sync commands
+func (dz *DoubleZero) runCycle(ctx context.Context, simulate bool) (*report.Report, error) {
+	dz.simulate = simulate
+	defer func() { dz.simulate = false }()
+
+	rep := report.New(dz.State.Cluster, simulate, dz.clock.Now())
+	dz.publish(events.Event{Type: events.CycleStarted})
+	outcome := ""
+	release, lockErr := dz.acquireSyncLock(ctx, rep)
+	err := lockErr
+	if lockErr == nil {
+		// released once the cycle's state is saved
+		defer release()
+		outcome, err = dz.syncVersion(ctx, rep)
+		if !dz.abandoned.Load() {
+			dz.runPendingReboot(ctx, dz.logger, rep)
+		}
+	}
+	rep.Finish(dz.clock.Now(), outcome, err)
+	if dz.abandoned.Load() {
+		dz.logger.Warn("abandoned sync cycle finished - discarding its outcome", "outcome", rep.Outcome, "reason", rep.Reason, "error", err)
+		return rep, err
+	}
+	dz.stampBinary(rep)
+	if rep.Reason != "" {
+		dz.logger.Info("sync cycle finished", "outcome", rep.Outcome, "reason", rep.Reason)
+	}
+
+	dz.publish(events.Event{Type: events.CycleFinished, FromVersion: rep.InstalledVersion, ToVersion: rep.TargetVersion(), Report: rep, Err: err})
+
+	// a cycle that didn't get the sync lock leaves the state file to the process holding it
+	if !simulate && lockErr == nil {
+		if saveErr := dz.stateStore.Update(func(st *state.State) error {
+			st.LastReport = rep
+			// only cycles that reached the sync commands are sync attempts
+			if len(rep.Commands) > 0 {
+				st.RecordSync(state.SyncAttempt{
+					StartedAt:   rep.StartedAt,
+					FinishedAt:  rep.FinishedAt,
+					FromVersion: rep.InstalledVersion,
+					ToVersion:   rep.TargetVersion(),
+					Direction:   syncDirection(rep.InstalledVersion, rep.TargetVersion()),
+					Result:      rep.Outcome,
+					Error:       rep.Error,
+					Commands:    rep.Commands,
+				}, dz.stateConfig.HistorySize)
+			}
+			// an approval is consumed by the sync it approved
+			if rep.Outcome == report.OutcomeSynced {
+				st.PendingApproval = nil
+			}
+			return nil
+		}); saveErr != nil {
+			dz.logger.Warn("failed to save sync decision report", "path", dz.stateStore.Path(), "error", saveErr)
+		}
+	}
+
+	return rep, err
+}
+
+// syncDirection returns the direction of a sync between two versions, empty when either doesn't parse
+func syncDirection(from, to string) string {
+	fromVersion, err := version.NewVersion(from)
+	if err != nil {
+		return ""
+	}
+	toVersion, err := version.NewVersion(to)
+	if err != nil {
+		return ""
+	}
+	return versiondiff.VersionDiff{From: fromVersion, To: toVersion}.Direction()
+}
+
+// syncVersion runs the sync pipeline, recording each gate's verdict in the report, and returns the cycle outcome
+func (dz *DoubleZero) syncVersion(ctx context.Context, rep *report.Report) (outcome string, err error) {
 	// refresh the DoubleZero state
 	err = dz.refreshState()
 	if err != nil {
-		return err
+		return "", err
 	}
+	rep.InstalledVersion = dz.State.Version.Original()
 
-	syncLogger := log.WithPrefix("sync").With(
+	syncLogger := logging.WithPrefix(dz.parentLogger, "sync").With(
 		"cluster", dz.State.Cluster,
 	)
+	if dz.simulate {
+		syncLogger = syncLogger.With("simulation", true)
+	}
+
+	// RPC results are only reused within a single sync cycle
+	if dz.validatorRPCClient != nil {
+		dz.validatorRPCClient.ResetCache()
+	}
 
 	// set a version we'll target as part of a diff
 	syncLogger.Debug("creating version diff", "from", dz.State.Version, "fromString", dz.State.VersionString)
@@ -103,35 +565,315 @@ func (dz *DoubleZero) SyncVersion() (err error) {
 	}
 
 	// get the recommended version for the cluster
-	versionDiff.To, err = dz.versionSource.GetRecommendedVersion()
+	recommendation, err := dz.versionSource.GetRecommendation(ctx)
 	if err != nil {
-		return err
+		rep.AddGate(report.GateVersionSource, report.VerdictFail, "no recommendation: %s", err)
+		return "", err
 	}
+	dz.State.Recommendation = recommendation
+	versionDiff.To = recommendation.Version
+	rep.Recommendation = reportRecommendation(recommendation)
+	rep.AddGate(report.GateVersionSource, report.VerdictPass, "%s recommended %s", recommendation.Source, recommendation.PackageVersion)
 
-	syncLogger.Debug("recommended version from source", "version", versionDiff.To.String())
+	// Check the validator belongs to the configured cluster before acting on the cluster's recommendation
+	if dz.validatorRPCClient != nil && dz.validatorConfig.VerifyCluster {
+		genesisHash, err := dz.checkClusterGenesis(ctx)
+		switch {
+		case errors.Is(err, errClusterUnverified):
+			syncLogger.Warn("could not verify validator cluster - continuing", "error", err, "on_unreachable", dz.validatorConfig.OnUnreachable)
+			rep.AddGate(report.GateClusterGenesis, report.VerdictSkip, "%s (validator.on_unreachable=%s)", err, dz.validatorConfig.OnUnreachable)
+		case err != nil:
+			rep.AddGate(report.GateClusterGenesis, report.VerdictBlock, "%s", err)
+			return "", err
+		default:
+			rep.AddGate(report.GateClusterGenesis, report.VerdictPass, "validator genesis hash %s is %s", genesisHash, dz.State.Cluster)
+		}
+	} else {
+		rep.AddGate(report.GateClusterGenesis, report.VerdictSkip, "validator.verify_cluster disabled or no validator configured")
+	}
 
-	syncLogger.Debugf("final target sync version: %s", versionDiff.To.Core().String())
-	syncLogger = syncLogger.With("targetVersion", versionDiff.To.Core().String())
+	syncLogger.Debug("recommended version from source", "version", versionDiff.To.String(), "source", recommendation.Source)
 
-	// Check if validator is configured and verify its identity
-	if dz.validatorRPCClient != nil {
-		if err := dz.checkValidatorIdentity(syncLogger); err != nil {
-			return err
-		}
+	// record the recommendation - one lower than the previous recommendation is anomalous
+	consecutiveRecommendations, firstObservedAt, err := dz.recordRecommendation(syncLogger, recommendation)
+	if err != nil {
+		rep.AddGate(report.GateRecommendationRollback, report.VerdictBlock, "%s", err)
+		return "", err
 	}
+	rep.AddGate(report.GateRecommendationRollback, report.VerdictPass, "recommendation is not lower than the previous one, or rollbacks are allowed")
+
+	syncLogger.Debugf("final target sync version: %s", versionDiff.To.Core().String())
+	syncLogger = syncLogger.With("targetVersion", versionDiff.To.Core().String())
 
 	// Check version constraint if configured
-	if dz.doubleZeroConfig.VersionConstraint != "" {
+	if dz.doubleZeroConfig.ParsedVersionConstraint != nil {
 		if !dz.doubleZeroConfig.ParsedVersionConstraint.Check(versionDiff.To.Core()) {
-			return fmt.Errorf("target version %s does not satisfy doublezero.version_constraint %s", versionDiff.To.Core().String(), dz.doubleZeroConfig.ParsedVersionConstraint.String())
+			err = fmt.Errorf("target version %s does not satisfy doublezero.version_constraint %s", versionDiff.To.Core().String(), dz.doubleZeroConfig.ParsedVersionConstraint.String())
+			rep.AddGate(report.GateVersionConstraint, report.VerdictBlock, "%s", err)
+			return "", err
 		}
 		syncLogger.Debug("target version satisfies version constraint", "constraint", dz.doubleZeroConfig.ParsedVersionConstraint.String())
+		rep.AddGate(report.GateVersionConstraint, report.VerdictPass, "%s satisfies %s", versionDiff.To.Core().String(), dz.doubleZeroConfig.ParsedVersionConstraint.String())
+	} else {
+		rep.AddGate(report.GateVersionConstraint, report.VerdictSkip, "no version constraint configured")
 	}
 
+	// don't act on a target listed in doublezero.skip_versions or skipped by an operator
+	if skipVersion, ok := dz.configSkippedVersion(recommendation); ok {
+		syncLogger.Warn("target version listed in doublezero.skip_versions - not syncing", "skipped_version", skipVersion)
+		rep.AddGate(report.GateSkippedVersion, report.VerdictDone, "%s is listed in doublezero.skip_versions", skipVersion)
+		return report.OutcomeNothingToDo, nil
+	}
+	skip, err := dz.checkSkipped(syncLogger, recommendation)
+	if err != nil {
+		rep.AddGate(report.GateSkippedVersion, report.VerdictFail, "%s", err)
+		return "", err
+	}
+	if skip != nil {
+		syncLogger.Warn("target version skipped by operator - not syncing until a newer version is recommended",
+			"skipped_version", skip.Version, "reason", skip.Reason, "skipped_at", skip.SkippedAt.Format(time.RFC3339))
+		rep.AddGate(report.GateSkippedVersion, report.VerdictDone, "%s skipped at %s: %s", skip.Version, skip.SkippedAt.Format(time.RFC3339), skipReason(skip))
+		return report.OutcomeNothingToDo, nil
+	}
+	rep.AddGate(report.GateSkippedVersion, report.VerdictPass, "target is not skipped")
+
 	// if already on the target version, do nothing
 	if versionDiff.IsSameVersion() {
 		syncLogger.Info("DoubleZero already running target version - nothing to do")
-		return nil
+		rep.AddGate(report.GateSameVersion, report.VerdictDone, "installed version %s is already the target", versionDiff.From.Core().String())
+		return report.OutcomeNothingToDo, nil
+	}
+	rep.AddGate(report.GateSameVersion, report.VerdictPass, "%s required v%s -> v%s", versionDiff.Direction(), versionDiff.From.Core().String(), versionDiff.To.Core().String())
+
+	// refuse automated downgrades unless allowed
+	switch {
+	case versionDiff.Direction() != versiondiff.DirectionDowngrade:
+		rep.AddGate(report.GateDowngrade, report.VerdictPass, "not a downgrade")
+	case !dz.syncConfig.AllowDowngrade:
+		err = fmt.Errorf("target version %s is a downgrade from installed version %s (set sync.allow_downgrade=true to allow)", versionDiff.To.Core().String(), versionDiff.From.Core().String())
+		rep.AddGate(report.GateDowngrade, report.VerdictBlock, "%s", err)
+		return "", err
+	default:
+		syncLogger.Warn("target version is a downgrade - proceeding (sync.allow_downgrade=true)")
+		rep.AddGate(report.GateDowngrade, report.VerdictPass, "downgrade allowed by sync.allow_downgrade")
+	}
+
+	// upgrade through the next stepping stone first when the target is past one, each hop a sync of its own
+	if steppingStone := dz.nextSteppingStone(versionDiff); steppingStone != nil {
+		if constraint := dz.doubleZeroConfig.ParsedVersionConstraint; constraint != nil && !constraint.Check(steppingStone.Core()) {
+			err = fmt.Errorf("stepping stone %s does not satisfy doublezero.version_constraint %s", steppingStone.Original(), constraint.String())
+			rep.AddGate(report.GateSteppingStone, report.VerdictBlock, "%s", err)
+			return "", err
+		}
+		syncLogger.Info("upgrading through stepping stone first", "stepping_stone", steppingStone.Original())
+		rep.AddGate(report.GateSteppingStone, report.VerdictPass, "v%s -> v%s passes through %s - upgrading to it first",
+			versionDiff.From.Core().String(), versionDiff.To.Core().String(), steppingStone.Original())
+		rep.SteppingStone = steppingStone.Original()
+		versionDiff.To = steppingStone
+		syncLogger = syncLogger.With("steppingStone", steppingStone.Core().String())
+	} else if len(dz.doubleZeroConfig.ParsedSteppingStones) > 0 {
+		rep.AddGate(report.GateSteppingStone, report.VerdictSkip, "no doublezero.stepping_stones between v%s and v%s",
+			versionDiff.From.Core().String(), versionDiff.To.Core().String())
+	} else {
+		rep.AddGate(report.GateSteppingStone, report.VerdictSkip, "no doublezero.stepping_stones configured")
+	}
+
+	// surface config or template edits that change what the upcoming sync will execute
+	plan, planDiff, planErr := dz.checkCommandPlan(syncLogger, versionDiff)
+	if planErr != nil {
+		syncLogger.Warn("failed to check rendered command plan", "error", planErr)
+	}
+	rep.CommandPlanDiff = planDiff
+
+	// only act once the same target has been recommended on enough consecutive cycles
+	if consecutiveRecommendations < dz.syncConfig.ConfirmCycles {
+		syncLogger.Info("target version not yet confirmed - waiting for more cycles",
+			"consecutive", consecutiveRecommendations, "confirm_cycles", dz.syncConfig.ConfirmCycles)
+		rep.AddGate(report.GateConfirmCycles, report.VerdictDone, "recommended on %d of %d required consecutive cycles", consecutiveRecommendations, dz.syncConfig.ConfirmCycles)
+		return report.OutcomeNothingToDo, nil
+	}
+	rep.AddGate(report.GateConfirmCycles, report.VerdictPass, "recommended on %d consecutive cycles, %d required", consecutiveRecommendations, dz.syncConfig.ConfirmCycles)
+
+	// only act once the target has been published long enough to bake
+	if dz.syncConfig.MinVersionAge > 0 {
+		publishedAt, basis := recommendation.PublishedAt, "published"
+		if publishedAt.IsZero() {
+			publishedAt, basis = firstObservedAt, "first recommended"
+		}
+		age := dz.clock.Now().Sub(publishedAt)
+		if age < dz.syncConfig.MinVersionAge {
+			remaining := (dz.syncConfig.MinVersionAge - age).Round(time.Second)
+			syncLogger.Info("target version has not baked long enough - waiting",
+				"basis", basis, "since", publishedAt.Format(time.RFC3339), "min_version_age", dz.syncConfig.MinVersionAge.String(), "remaining", remaining.String())
+			rep.AddGate(report.GateMinVersionAge, report.VerdictDone, "%s %s ago, %s required - %s remaining",
+				basis, age.Round(time.Second), dz.syncConfig.MinVersionAge, remaining)
+			return report.OutcomeNothingToDo, nil
+		}
+		rep.AddGate(report.GateMinVersionAge, report.VerdictPass, "%s %s ago, %s required", basis, age.Round(time.Second), dz.syncConfig.MinVersionAge)
+	} else {
+		rep.AddGate(report.GateMinVersionAge, report.VerdictSkip, "no sync.min_version_age configured")
+	}
+
+	// staged rollouts only act once this node's cohort delay has passed since the target was first observed
+	releasedAt := firstObservedAt
+	if dz.syncConfig.Cohort != "" {
+		cohortDelay := dz.syncConfig.Cohorts[dz.syncConfig.Cohort]
+		releasedAt = firstObservedAt.Add(cohortDelay)
+		now := dz.clock.Now()
+		if now.Before(releasedAt) {
+			remaining := releasedAt.Sub(now).Round(time.Second)
+			syncLogger.Info("target version not yet released to this cohort - waiting",
+				"cohort", dz.syncConfig.Cohort, "delay", cohortDelay.String(), "first_observed", firstObservedAt.Format(time.RFC3339), "remaining", remaining.String())
+			rep.AddGate(report.GateCohort, report.VerdictDone, "cohort %s acts %s after the target was first observed at %s - %s remaining",
+				dz.syncConfig.Cohort, cohortDelay, firstObservedAt.UTC().Format(time.RFC3339), remaining)
+			return report.OutcomeNothingToDo, nil
+		}
+		rep.AddGate(report.GateCohort, report.VerdictPass, "cohort %s delay of %s passed at %s", dz.syncConfig.Cohort, cohortDelay, releasedAt.UTC().Format(time.RFC3339))
+	} else {
+		rep.AddGate(report.GateCohort, report.VerdictSkip, "no sync.cohort configured")
+	}
+
+	// controller-less fleets wait for enough peers to succeed on the target first
+	if dz.fleet != nil {
+		succeeded, others := dz.fleet.Quorum(ctx, versionDiff.To)
+		if len(succeeded) < dz.fleetQuorum {
+			syncLogger.Info("waiting for fleet peers to succeed on the target version",
+				"succeeded", len(succeeded), "quorum", dz.fleetQuorum, "peers", fleet.Summary(succeeded), "others", fleet.Summary(others))
+			rep.AddGate(report.GateFleetQuorum, report.VerdictDone, "%d of %d required peers report success on v%s (%s) - others: %s",
+				len(succeeded), dz.fleetQuorum, versionDiff.To.Core().String(), fleet.Summary(succeeded), fleet.Summary(others))
+			return report.OutcomeNothingToDo, nil
+		}
+		rep.AddGate(report.GateFleetQuorum, report.VerdictPass, "%d of %d required peers report success on v%s (%s)",
+			len(succeeded), dz.fleetQuorum, versionDiff.To.Core().String(), fleet.Summary(succeeded))
+	} else {
+		rep.AddGate(report.GateFleetQuorum, report.VerdictSkip, "no fleet.quorum configured")
+	}
+
+	// defer version changes inside a change freeze period
+	if dz.freeze != nil {
+		period, err := dz.activeFreeze(ctx, syncLogger, versionDiff)
+		switch {
+		case err != nil:
+			rep.AddGate(report.GateFreeze, report.VerdictFail, "%s", err)
+			return "", err
+		case period != nil:
+			rep.Freeze = period
+			syncLogger.Warn("inside change freeze - change pending until it ends", "freeze", period.Name, "until", period.End.UTC().Format(time.RFC3339))
+			rep.AddGate(report.GateFreeze, report.VerdictDone, "inside %s until %s - change pending", period.Name, period.End.UTC().Format(time.RFC3339))
+			return report.OutcomeNothingToDo, nil
+		default:
+			rep.AddGate(report.GateFreeze, report.VerdictPass, "not inside a change freeze period")
+		}
+	} else {
+		rep.AddGate(report.GateFreeze, report.VerdictSkip, "no freeze periods or calendars configured")
+	}
+
+	// spread the fleet (or cohort) out by waiting this node's delay after the target was released to it
+	if dz.jitter.IsEnabled() {
+		detail, err := dz.waitJitter(ctx, syncLogger, versionDiff, releasedAt)
+		switch {
+		case errors.Is(err, errJitterPending):
+			rep.AddGate(report.GateJitter, report.VerdictDone, "%s", err)
+			return report.OutcomeNothingToDo, nil
+		case err != nil:
+			rep.AddGate(report.GateJitter, report.VerdictBlock, "%s", err)
+			return "", err
+		default:
+			rep.AddGate(report.GateJitter, report.VerdictPass, "%s", detail)
+		}
+	} else {
+		rep.AddGate(report.GateJitter, report.VerdictSkip, "no sync.jitter configured")
+	}
+
+	// honor host-wide maintenance mode signalled by the operator's maintenance tooling
+	hostInMaintenance := false
+	if dz.hostMaintenance != nil {
+		status, err := dz.hostMaintenance.Check(ctx)
+		switch {
+		case err != nil:
+			rep.AddGate(report.GateHostMaintenance, report.VerdictFail, "%s", err)
+			return "", err
+		case !status.Active:
+			rep.AddGate(report.GateHostMaintenance, report.VerdictPass, "host is not in maintenance mode")
+		case dz.hostMaintenanceConfig.Policy == constants.HostMaintenancePolicyBlock:
+			syncLogger.Info("host in maintenance mode - change pending until it ends", "reason", status.Reason)
+			rep.AddGate(report.GateHostMaintenance, report.VerdictDone, "%s - change pending until host maintenance ends (host_maintenance.policy=block)", status.Reason)
+			return report.OutcomeNothingToDo, nil
+		default:
+			hostInMaintenance = true
+			syncLogger.Info("host in maintenance mode - applying change now", "reason", status.Reason)
+			rep.AddGate(report.GateHostMaintenance, report.VerdictPass, "%s - applying change now (host_maintenance.policy=prioritize)", status.Reason)
+		}
+	} else {
+		rep.AddGate(report.GateHostMaintenance, report.VerdictSkip, "host_maintenance disabled")
+	}
+
+	// only apply the change inside a maintenance window, or while the host is in maintenance mode when prioritized
+	if dz.windows.IsEnabled() && hostInMaintenance {
+		rep.AddGate(report.GateMaintenanceWindow, report.VerdictPass, "host in maintenance mode overrides sync.windows")
+	} else if dz.windows.IsEnabled() {
+		now := dz.clock.Now()
+		name, open := dz.windows.Open(now)
+		if !open {
+			nextOpen := "none within a week"
+			if next := dz.windows.NextOpen(now); !next.IsZero() {
+				nextOpen = next.UTC().Format(time.RFC3339)
+			}
+			syncLogger.Info("change pending, waiting for window", "next_window", nextOpen)
+			rep.AddGate(report.GateMaintenanceWindow, report.VerdictDone, "outside sync.windows - change pending, next window opens %s", nextOpen)
+			return report.OutcomeNothingToDo, nil
+		}
+		rep.AddGate(report.GateMaintenanceWindow, report.VerdictPass, "inside maintenance window %s", name)
+	} else {
+		rep.AddGate(report.GateMaintenanceWindow, report.VerdictSkip, "no sync.windows configured")
+	}
+
+	// fail fast when the recommendation is ahead of the package repository
+	if dz.publishedChecker != nil {
+		published := recommendation
+		if rep.SteppingStone != "" {
+			published = &versionsource.Recommendation{Version: versionDiff.To, PackageVersion: versionDiff.To.Original()}
+		}
+		packageVersion, waited, err := dz.waitPublished(ctx, syncLogger, published)
+		waitedDetail := ""
+		if waited > 0 {
+			waitedDetail = fmt.Sprintf(" after waiting %s", waited.Round(time.Second))
+		}
+		switch {
+		case errors.Is(err, versionsource.ErrNotPublished):
+			rep.RepoLag = dz.recordRepoLag(syncLogger, versionDiff, packageVersion, dz.clock.Now().Add(-waited))
+			syncLogger.Warn("target package not in the package repository yet - change pending", "package_version", packageVersion,
+				"repository", rep.RepoLag.Repository, "since", rep.RepoLag.Since.Format(time.RFC3339), "waited", waited.Round(time.Second).String())
+			rep.AddGate(report.GatePackagePublished, report.VerdictDone, "repository lag since %s - %s%s, change pending until it's published",
+				rep.RepoLag.Since.Format(time.RFC3339), err, waitedDetail)
+			rep.SetReason(report.ReasonRepoLag)
+			return report.OutcomeNothingToDo, nil
+		case err != nil:
+			rep.AddGate(report.GatePackagePublished, report.VerdictBlock, "%s", err)
+			return "", err
+		}
+		dz.clearRepoLag(syncLogger)
+		rep.AddGate(report.GatePackagePublished, report.VerdictPass, "%s is published in the %s repository%s", packageVersion,
+			dz.syncConfig.VerifyPublished.Repository.Format, waitedDetail)
+	} else {
+		rep.AddGate(report.GatePackagePublished, report.VerdictSkip, "sync.verify_published disabled")
+	}
+
+	// hold the exact commands about to run until enough operators approved them
+	if dz.syncConfig.Approval.Enabled {
+		detail, err := dz.checkApproval(syncLogger, versionDiff, plan)
+		switch {
+		case errors.Is(err, errApprovalPending):
+			rep.AddGate(report.GateApproval, report.VerdictDone, "%s", err)
+			return report.OutcomeNothingToDo, nil
+		case err != nil:
+			rep.AddGate(report.GateApproval, report.VerdictBlock, "%s", err)
+			return "", err
+		default:
+			rep.AddGate(report.GateApproval, report.VerdictPass, "%s", detail)
+		}
+	} else {
+		rep.AddGate(report.GateApproval, report.VerdictSkip, "sync.approval disabled")
 	}
 
 	// by now we know we need to sync
@@ -143,30 +885,231 @@ func (dz *DoubleZero) SyncVersion() (err error) {
 		),
 	)
 
+	// record what the source actually said for post-incident review
+	syncLogger.Info("recommendation evidence",
+		"source", recommendation.Source,
+		"url", recommendation.URL,
+		"fetched_at", recommendation.FetchedAt.Format(time.RFC3339),
+		"evidence", recommendation.Evidence,
+	)
+
+	// Check if validator is configured and verify its identity
+	if monitorOnly, err := dz.checkValidatorIdentityGate(ctx, syncLogger, rep, versionDiff); err != nil {
+		return "", err
+	} else if monitorOnly {
+		return report.OutcomeNothingToDo, nil
+	}
+
+	// Check the daemon is running before touching packages
+	if dz.daemonChecker.IsEnabled() {
+		if err := dz.daemonChecker.CheckRunning(ctx); err != nil {
+			err = fmt.Errorf("doublezero daemon check failed before sync: %w", err)
+			rep.AddGate(report.GateDaemonPreCheck, report.VerdictBlock, "%s", err)
+			return "", err
+		}
+		syncLogger.Debug("doublezero daemon is running")
+		rep.AddGate(report.GateDaemonPreCheck, report.VerdictPass, "daemon is running (%s check)", dz.doubleZeroConfig.Daemon.Check)
+	} else {
+		rep.AddGate(report.GateDaemonPreCheck, report.VerdictSkip, "daemon check disabled")
+	}
+
+	// Check the configured health probes (e.g. tunnel health) pass before touching packages
+	if dz.preChecker.IsEnabled() {
+		if err := dz.preChecker.Run(ctx); err != nil {
+			err = fmt.Errorf("pre-checks failed before sync: %w", err)
+			rep.AddGate(report.GatePreChecks, report.VerdictBlock, "%s", err)
+			return "", err
+		}
+		syncLogger.Debug("all pre-checks passed")
+		rep.AddGate(report.GatePreChecks, report.VerdictPass, "%d pre-checks passed", dz.preChecker.Count())
+	} else {
+		rep.AddGate(report.GatePreChecks, report.VerdictSkip, "no pre-checks configured")
+	}
+
 	commandsCount := len(dz.syncConfig.Commands)
 	if commandsCount == 0 {
 		syncLogger.Warn("no configured commands to execute - skipping")
-		return nil
+		rep.AddGate(report.GateCommands, report.VerdictSkip, "no sync commands configured")
+		return report.OutcomeNothingToDo, nil
+	}
+
+	if dz.simulate {
+		syncLogger.Info("simulation - not executing commands")
+		for cmd_i, cmd := range dz.syncConfig.Commands {
+			rendered, err := cmd.Render(dz.commandTemplateData(versionDiff, cmd_i, commandsCount))
+			if err != nil {
+				err = fmt.Errorf("failed to render command %s: %w", cmd.Name, err)
+				rep.AddGate(report.GateCommands, report.VerdictFail, "%s", err)
+				return "", err
+			}
+			commandLine := strings.Join(append([]string{rendered.Cmd}, rendered.Args...), " ")
+			syncLogger.Info("would run command", "name", rendered.Name, "cmd", rendered.Cmd, "args", rendered.Args,
+				"environment", rendered.Environment, "disabled", rendered.Disabled, "allow_failure", rendered.AllowFailure, "rollback", rendered.Rollback)
+			rep.AddRenderedCommand(cmd.Name, "would_run", commandLine)
+		}
+		if err := dz.refreshRepos(ctx, syncLogger, rep, dz.commandTemplateData(versionDiff, 0, 1)); err != nil {
+			return "", err
+		}
+		rep.AddGate(report.GateCommands, report.VerdictSkip, "simulation - %d commands would run", commandsCount)
+		return report.OutcomeWouldSync, nil
 	}
 
+	// snapshot diagnostics around the commands, notifying with their diff if the sync fails from here on, then check
+	// whether the commands left the host requiring a reboot
+	diagnosticsBefore := dz.captureDiagnostics(syncLogger)
+	defer func() {
+		dz.finishSync(syncLogger, rep, versionDiff, diagnosticsBefore, err)
+		dz.checkReboot(ctx, syncLogger, rep, versionDiff, err)
+	}()
+
 	// create the commands
 	syncLogger.Infof("executing commands")
+	dz.publish(events.Event{Type: events.SyncStarted, FromVersion: versionDiff.From.Original(), ToVersion: versionDiff.To.Original()})
+	resultCounts := map[string]int{}
+	commandCtx, cancelCommands := dz.commandContext(ctx, syncLogger)
+	defer cancelCommands()
+	if err = dz.refreshRepos(commandCtx, syncLogger, rep, dz.commandTemplateData(versionDiff, 0, 1)); err != nil {
+		return "", err
+	}
 	for cmd_i, cmd := range dz.syncConfig.Commands {
-		err := cmd.ExecuteWithData(sync_commands.CommandTemplateData{
-			CommandIndex:     cmd_i,
-			CommandsCount:    commandsCount,
-			ClusterName:      dz.State.Cluster,
-			VersionFrom:      versionDiff.From.Core().String(),
-			VersionTo:        versionDiff.To.Core().String(),
-			PackageVersionTo: versionDiff.To.Original(),
+		if dz.abandoned.Load() {
+			err = fmt.Errorf("aborted before command %s - the cycle was abandoned by the watchdog (runtime.watchdog)", cmd.Name)
+			rep.AddGate(report.GateCommands, report.VerdictFail, "%s", err)
+			return "", err
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("aborted before command %s - shutting down", cmd.Name)
+			rep.AddGate(report.GateCommands, report.VerdictFail, "%s", err)
+			return "", err
+		}
+		if !dz.deadline.IsZero() && !dz.clock.Now().Before(dz.deadline) {
+			err = fmt.Errorf("aborted before command %s - next sync boundary %s reached (sync.overrun_policy=%s)",
+				cmd.Name, dz.deadline.Format(time.RFC3339), constants.SyncOverrunPolicyAbortCurrent)
+			rep.AddGate(report.GateCommands, report.VerdictFail, "%s", err)
+			return "", err
+		}
+		data := dz.commandTemplateData(versionDiff, cmd_i, commandsCount)
+		result, err := cmd.ExecuteWithData(commandCtx, data)
+		dz.recordAudit(syncLogger, audit.KindSync, data, result, err)
+		resultCounts[result.Status]++
+		if result.Execution != nil {
+			rep.AddExecutedCommand(result.Name, result.Status, result.Execution.ExitCode, result.Execution.FinishedAt.Sub(result.Execution.StartedAt))
+		} else {
+			rep.AddCommand(result.Name, result.Status)
+		}
+		dz.publish(events.Event{
+			Type:        events.CommandFinished,
+			FromVersion: versionDiff.From.Original(),
+			ToVersion:   versionDiff.To.Original(),
+			Command:     &report.CommandResult{Name: result.Name, Status: result.Status},
+			Err:         err,
 		})
+		if err != nil && cmd.RollsBackOnFailure() {
+			rollbackTo, rollbackErr := dz.rollback(commandCtx, syncLogger, rep, versionDiff)
+			if rollbackErr != nil {
+				err = fmt.Errorf("%w - rollback to %s failed: %w", err, rollbackTo, rollbackErr)
+			} else {
+				err = fmt.Errorf("%w - rolled back to %s", err, rollbackTo)
+			}
+		}
 		if err != nil {
-			return err
+			rep.AddGate(report.GateCommands, report.VerdictFail, "command %s failed: %s", cmd.Name, err)
+			return "", err
 		}
 	}
 
-	syncLogger.Infof("commands executed successfully")
-	return nil
+	syncLogger.Info("commands executed successfully",
+		"executed", resultCounts[sync_commands.ResultStatusExecuted],
+		"skipped", resultCounts[sync_commands.ResultStatusSkipped],
+		"disabled", resultCounts[sync_commands.ResultStatusDisabled],
+		"allowed_failures", resultCounts[sync_commands.ResultStatusAllowedFailure],
+	)
+	rep.AddGate(report.GateCommands, report.VerdictPass, "%d executed, %d skipped, %d disabled, %d allowed failures",
+		resultCounts[sync_commands.ResultStatusExecuted], resultCounts[sync_commands.ResultStatusSkipped],
+		resultCounts[sync_commands.ResultStatusDisabled], resultCounts[sync_commands.ResultStatusAllowedFailure])
+
+	// Check the daemon came back after the sync commands
+	if dz.daemonChecker.IsEnabled() {
+		if err := dz.daemonChecker.WaitRunning(ctx, dz.doubleZeroConfig.Daemon.StartTimeout, time.Second); err != nil {
+			err = fmt.Errorf("doublezero daemon check failed after sync: %w", err)
+			rep.AddGate(report.GateDaemonPostCheck, report.VerdictFail, "%s", err)
+			return "", err
+		}
+		syncLogger.Info("doublezero daemon is running after sync")
+		rep.AddGate(report.GateDaemonPostCheck, report.VerdictPass, "daemon is running")
+	} else {
+		rep.AddGate(report.GateDaemonPostCheck, report.VerdictSkip, "daemon check disabled")
+	}
+
+	// Attest the change took - retrying the health probes while the new version settles
+	if dz.postChecker.IsEnabled() {
+		postChecks := dz.syncConfig.PostChecks
+		if err := dz.postChecker.WaitHealthy(ctx, postChecks.Timeout, postChecks.RetryInterval, postChecks.MaxRetryInterval); err != nil {
+			err = fmt.Errorf("post-checks failed after sync: %w", err)
+			rep.AddGate(report.GatePostChecks, report.VerdictFail, "%s", err)
+			return "", err
+		}
+		syncLogger.Info("all post-checks passed after sync")
+		rep.AddGate(report.GatePostChecks, report.VerdictPass, "%d post-checks passed", dz.postChecker.Count())
+	} else {
+		rep.AddGate(report.GatePostChecks, report.VerdictSkip, "no post-checks configured")
+	}
+
+	// Check the commands actually installed the target version, a command set that silently no-ops isn't a sync
+	if !dz.syncConfig.VerifyInstalled {
+		rep.AddGate(report.GateVerifyInstalled, report.VerdictSkip, "installed version verification disabled")
+		return report.OutcomeSynced, nil
+	}
+	installedVersion, err := dz.getInstalledVersion()
+	if err != nil {
+		err = fmt.Errorf("failed to get installed DoubleZero version after sync: %w", err)
+		rep.AddGate(report.GateVerifyInstalled, report.VerdictFail, "%s", err)
+		return "", err
+	}
+	if !installedVersion.Core().Equal(versionDiff.To.Core()) {
+		err = fmt.Errorf("%w - %s reports %s, want %s", ErrInstalledVersionMismatch, dz.bin, installedVersion.Core().String(), versionDiff.To.Core().String())
+		rep.AddGate(report.GateVerifyInstalled, report.VerdictFail, "%s", err)
+		return "", err
+	}
+	syncLogger.Info("verified installed version matches target", "version", installedVersion.Core().String())
+	rep.AddGate(report.GateVerifyInstalled, report.VerdictPass, "%s reports %s", dz.bin, installedVersion.Core().String())
+
+	return report.OutcomeSynced, nil
+}
+
+// reportRecommendation returns the report summary of a recommendation
+func reportRecommendation(recommendation *versionsource.Recommendation) *report.Recommendation {
+	summary := &report.Recommendation{
+		PackageVersion: recommendation.PackageVersion,
+		Source:         recommendation.Source,
+		URL:            recommendation.URL,
+		FetchedAt:      recommendation.FetchedAt,
+		FromCache:      recommendation.FromCache,
+	}
+	for _, failed := range recommendation.FailedSources {
+		summary.FailedSources = append(summary.FailedSources, report.FailedSource{Source: failed.Source, Error: failed.Error})
+	}
+	return summary
+}
+
+// commandTemplateData returns the template data for the command at the given index
+func (dz *DoubleZero) commandTemplateData(versionDiff versiondiff.VersionDiff, commandIndex, commandsCount int) sync_commands.CommandTemplateData {
+	// the RPM release is only known for the recommended version, not for a stepping stone on the way to it
+	rpmPackageVersionTo := versionDiff.To.Original()
+	if recommendation := dz.State.Recommendation; recommendation != nil && recommendation.RPMPackageVersion != "" &&
+		recommendation.Version.Equal(versionDiff.To) {
+		rpmPackageVersionTo = recommendation.RPMPackageVersion
+	}
+
+	return sync_commands.CommandTemplateData{
+		CommandIndex:        commandIndex,
+		CommandsCount:       commandsCount,
+		ClusterName:         dz.State.Cluster,
+		VersionFrom:         versionDiff.From.Core().String(),
+		VersionTo:           versionDiff.To.Core().String(),
+		PackageVersionTo:    versionDiff.To.Original(),
+		RPMPackageVersionTo: rpmPackageVersionTo,
+	}
 }
 
 // refreshState refreshes the DoubleZero state
@@ -187,82 +1130,12 @@ func (dz *DoubleZero) refreshState() error {
 }
 
 // getInstalledVersion gets the currently installed DoubleZero version from the configured binary
-// The binary is the source of truth for the installed version
-// Executes the binary with --version flag and parses the output
 func (dz *DoubleZero) getInstalledVersion() (*version.Version, error) {
-	cmd := exec.Command(dz.bin, "--version")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("bin command failed: %w", err)
-	}
-
-	// Parse the output - look for version patterns
-	outputStr := strings.TrimSpace(string(output))
-	matches := versionPattern.FindStringSubmatch(outputStr)
-	if len(matches) > 1 {
-		versionStr := matches[1]
-		v, err := version.NewVersion(versionStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse version from bin output: %w", err)
-		}
-		dz.logger.Debug("found installed version from bin", "bin", dz.bin, "version", v.String(), "output", outputStr)
-		return v, nil
-	}
-
-	return nil, fmt.Errorf("could not extract version from bin output: %s", outputStr)
-}
-
-// checkValidatorIdentity checks the validator's identity and ensures sync is allowed
-// Returns an error if validator is running with unknown identity or active identity (unless enabled)
-func (dz *DoubleZero) checkValidatorIdentity(logger *log.Logger) error {
-	validatorIdentity, err := dz.validatorRPCClient.GetIdentity()
+	v, output, err := installed.GetVersion(dz.bin)
 	if err != nil {
-		return fmt.Errorf("failed to get validator identity: %w", err)
+		return nil, err
 	}
 
-	activeIdentityPK := dz.validatorConfig.Identities.ActiveKeyPair.PublicKey().String()
-	passiveIdentityPK := dz.validatorConfig.Identities.PassiveKeyPair.PublicKey().String()
-	isActive := dz.isValidatorActive(validatorIdentity, activeIdentityPK)
-	isPassive := dz.isValidatorPassive(validatorIdentity, passiveIdentityPK)
-	isUnknown := dz.isValidatorUnknown(validatorIdentity, activeIdentityPK, passiveIdentityPK)
-
-	// Check if validator is running with unknown identity
-	if isUnknown {
-		return fmt.Errorf("validator identity %s does not match configured active (%s) or passive (%s) identities", validatorIdentity, activeIdentityPK, passiveIdentityPK)
-	}
-	// Check if validator is running as active identity and enabled_when_active is false - sync not allowed
-	if isActive && !dz.validatorConfig.EnabledWhenActive {
-		logger.Warnf("validator is running as active identity and we don't run with scissors 🏃✂️")
-		return fmt.Errorf("sync not allowed when validator is active (set validator.enabled_when_active=true to allow)")
-	}
-
-	// Check if validator is running as active identity and enabled_when_active is true - sync allowed
-	if isActive && dz.validatorConfig.EnabledWhenActive {
-		logger.Warn("validator is running as active identity - proceeding with sync (enabled_when_active=true)")
-		return nil
-	}
-
-	// Validator is running as passive identity
-	if isPassive {
-		logger.Info("validator is running as passive identity - proceeding with sync")
-		return nil
-	}
-
-	// This should never happen, but handle it just in case
-	return fmt.Errorf("unexpected validator identity state")
-}
-
-// isValidatorActive returns true if the validator is running as the active identity
-func (dz *DoubleZero) isValidatorActive(validatorIdentity, activeIdentityPK string) bool {
-	return validatorIdentity == activeIdentityPK
-}
-
-// isValidatorPassive returns true if the validator is running as the passive identity
-func (dz *DoubleZero) isValidatorPassive(validatorIdentity, passiveIdentityPK string) bool {
-	return validatorIdentity == passiveIdentityPK
-}
-
-// isValidatorUnknown returns true if the validator is running with an unknown identity
-func (dz *DoubleZero) isValidatorUnknown(validatorIdentity, activeIdentityPK, passiveIdentityPK string) bool {
-	return validatorIdentity != activeIdentityPK && validatorIdentity != passiveIdentityPK
+	dz.logger.Debug("found installed version from bin", "bin", dz.bin, "version", v.String(), "output", output)
+	return v, nil
 }
@@ -0,0 +1,50 @@
+package doublezero
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+)
+
+// recordingNotifier records the events it's notified of
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Name() string        { return "recording" }
+func (n *recordingNotifier) MinSeverity() string { return constants.NotificationSeverityInfo }
+func (n *recordingNotifier) Events() []string    { return nil }
+func (n *recordingNotifier) Notify(event notify.Event) error {
+	n.events = append(n.events, event)
+	return nil
+}
+
+// newTestDoubleZero returns a DoubleZero on testnet with a state file in a temporary directory, a fake clock at now
+// and a notifier recording its events, for tests of a single sync step
+func newTestDoubleZero(t *testing.T, now time.Time) (*DoubleZero, *recordingNotifier) {
+	t.Helper()
+	windows, err := maintenance.NewSchedule(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger := log.New(io.Discard)
+	notifier := &recordingNotifier{}
+	dz := &DoubleZero{
+		State:        State{Cluster: constants.ClusterNameTestnet},
+		logger:       logger,
+		parentLogger: logger,
+		clock:        clock.NewFake(now),
+		windows:      windows,
+		stateStore:   state.NewStore(filepath.Join(t.TempDir(), "state.json")),
+		notifier:     notify.NewDispatcher(notify.Options{Logger: logger}, notifier),
+	}
+	return dz, notifier
+}
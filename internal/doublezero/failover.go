@@ -0,0 +1,123 @@
+package doublezero
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// failoverRequest is the JSON body sent to failover.url
+type failoverRequest struct {
+	Cluster           string `json:"cluster"`
+	ValidatorIdentity string `json:"validator_identity"`
+	VersionFrom       string `json:"version_from"`
+	VersionTo         string `json:"version_to"`
+}
+
+// requestFailover asks the operator's failover tooling to swap the validator to its passive identity
+// and returns true if the validator became passive within failover.verify_timeout
+func (dz *DoubleZero) requestFailover(ctx context.Context, logger *log.Logger, validatorIdentity, passiveIdentityPK string, data sync_commands.CommandTemplateData) (bool, error) {
+	failoverLogger := logging.WithPrefix(logger, "failover")
+
+	if dz.failoverConfig.Policy == constants.FailoverPolicyPrompt && !dz.confirmFailover(ctx, failoverLogger, validatorIdentity, data) {
+		failoverLogger.Warn("failover not confirmed - skipping")
+		return false, nil
+	}
+
+	if dz.failoverConfig.Command != nil {
+		failoverLogger.Info("running failover command", "name", dz.failoverConfig.Command.Name)
+		commandCtx, cancel := dz.commandContext(ctx, failoverLogger)
+		result, err := dz.failoverConfig.Command.ExecuteWithData(commandCtx, data)
+		cancel()
+		dz.recordAudit(failoverLogger, audit.KindFailover, data, result, err)
+		if err != nil {
+			return false, fmt.Errorf("failover command failed: %w", err)
+		}
+	}
+
+	if dz.failoverConfig.URL != "" {
+		failoverLogger.Info("requesting failover", "url", dz.failoverConfig.URL)
+		if err := dz.postFailoverRequest(ctx, dz.failoverConfig.URL, dz.failoverConfig.RequestTimeout, failoverRequest{
+			Cluster:           data.ClusterName,
+			ValidatorIdentity: validatorIdentity,
+			VersionFrom:       data.VersionFrom,
+			VersionTo:         data.VersionTo,
+		}); err != nil {
+			return false, fmt.Errorf("failover request failed: %w", err)
+		}
+	}
+
+	// verify the identity swap actually happened before proceeding
+	return dz.waitForPassive(ctx, failoverLogger, passiveIdentityPK, dz.failoverConfig.VerifyTimeout, dz.failoverConfig.VerifyPollInterval), nil
+}
+
+// postFailoverRequest sends the failover request to the given URL, giving up after timeout
+func (dz *DoubleZero) postFailoverRequest(ctx context.Context, url string, timeout time.Duration, body failoverRequest) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := dz.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// confirmFailover asks for interactive confirmation on the prompt terminal - it never confirms when its input is not a
+// terminal, or when ctx is done before an answer. A read cut short by ctx is left to finish with the next line typed
+func (dz *DoubleZero) confirmFailover(ctx context.Context, logger *log.Logger, validatorIdentity string, data sync_commands.CommandTemplateData) bool {
+	stat, err := dz.promptIn.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		logger.Warn("failover.policy is prompt but stdin is not a terminal - cannot confirm")
+		return false
+	}
+
+	fmt.Fprintf(dz.promptOut, "Validator %s is active and DoubleZero v%s -> v%s is required. Request failover? [y/N]: ",
+		validatorIdentity, data.VersionFrom, data.VersionTo)
+	answers := make(chan string, 1)
+	go func() {
+		answer, err := bufio.NewReader(dz.promptIn).ReadString('\n')
+		if err != nil {
+			answer = ""
+		}
+		answers <- answer
+	}()
+
+	select {
+	case <-ctx.Done():
+		logger.Warn("stopped waiting for failover confirmation", "error", ctx.Err())
+		return false
+	case answer := <-answers:
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	}
+}
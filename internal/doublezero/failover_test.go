@@ -0,0 +1,158 @@
+package doublezero
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+const (
+	testActiveIdentity  = "ActiveVa1idatorIdentity1111111111111111111111"
+	testPassiveIdentity = "PassiveVa1idatorIdentity111111111111111111111"
+)
+
+// fileIdentitySource reports the identity written to a file, so a failover command can swap it
+type fileIdentitySource struct {
+	file string
+}
+
+func (s *fileIdentitySource) Identity(context.Context) (string, error) {
+	identity, err := os.ReadFile(s.file)
+	return strings.TrimSpace(string(identity)), err
+}
+
+func (s *fileIdentitySource) Type() string { return constants.ValidatorIdentitySourceFile }
+
+func TestRequestFailover(t *testing.T) {
+	data := sync_commands.CommandTemplateData{ClusterName: constants.ClusterNameTestnet, VersionFrom: "0.6.9", VersionTo: "0.8.1"}
+
+	tests := []struct {
+		name string
+		// policy defaults to auto
+		policy string
+		// command swaps the identity file to its argument when set
+		command string
+		// urlStatus is the failover.url response status, no URL when 0
+		urlStatus int
+		// urlDelay is how long failover.url takes to respond
+		urlDelay       time.Duration
+		urlSwapsTo     string
+		nonTTYPrompt   bool
+		wantPassive    bool
+		wantErr        string
+		wantRequested  bool
+		wantCommandRan bool
+	}{
+		{name: "command swaps to passive", command: testPassiveIdentity, wantPassive: true, wantCommandRan: true},
+		{name: "url swaps to passive", urlStatus: http.StatusOK, urlSwapsTo: testPassiveIdentity, wantPassive: true, wantRequested: true},
+		{name: "url error status fails", urlStatus: http.StatusServiceUnavailable, wantErr: "request failed with status: 503", wantRequested: true},
+		{name: "url slower than request_timeout fails", urlStatus: http.StatusOK, urlDelay: time.Second, wantErr: "context deadline exceeded", wantRequested: true},
+		{name: "validator staying active times out verification", command: testActiveIdentity, wantPassive: false, wantCommandRan: true},
+		{name: "prompt without a terminal never confirms", policy: constants.FailoverPolicyPrompt, command: testPassiveIdentity, nonTTYPrompt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			identityFile := filepath.Join(dir, "identity")
+			if err := os.WriteFile(identityFile, []byte(testActiveIdentity), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			dz, _ := newTestDoubleZero(t, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			dz.identitySource = &fileIdentitySource{file: identityFile}
+			dz.httpClient = &http.Client{}
+			dz.failoverConfig = config.Failover{Policy: constants.FailoverPolicyAuto, RequestTimeout: 100 * time.Millisecond,
+				VerifyTimeout: time.Minute, VerifyPollInterval: 10 * time.Second}
+			if tt.policy != "" {
+				dz.failoverConfig.Policy = tt.policy
+			}
+
+			commandRan := filepath.Join(dir, "command-ran")
+			if tt.command != "" {
+				dz.failoverConfig.Command = &sync_commands.Command{Name: "request failover", Cmd: "sh",
+					Args: []string{"-c", "touch " + commandRan + " && printf %s " + tt.command + " > " + identityFile}}
+				if err := dz.failoverConfig.Command.Parse(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				dz.failoverConfig.Command.SetLogger(dz.logger)
+				dz.failoverConfig.Command.SetClock(dz.clock)
+			}
+
+			requested := make(chan failoverRequest, 1)
+			release := make(chan struct{})
+			if tt.urlStatus != 0 {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body failoverRequest
+					if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+						requested <- body
+					}
+					if tt.urlDelay > 0 {
+						select {
+						case <-release:
+						case <-time.After(tt.urlDelay):
+						}
+					}
+					if tt.urlSwapsTo != "" {
+						_ = os.WriteFile(identityFile, []byte(tt.urlSwapsTo), 0o644)
+					}
+					w.WriteHeader(tt.urlStatus)
+				}))
+				defer server.Close()
+				// unblocks a delayed response before the server is closed
+				defer close(release)
+				dz.failoverConfig.URL = server.URL
+			}
+
+			if tt.nonTTYPrompt {
+				reader, writer, err := os.Pipe()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				defer reader.Close()
+				defer writer.Close()
+				dz.promptIn = reader
+				dz.promptOut = writer
+			}
+
+			passive, err := dz.requestFailover(context.Background(), dz.logger, testActiveIdentity, testPassiveIdentity, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("requestFailover() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("requestFailover() error = %v", err)
+			}
+			if passive != tt.wantPassive {
+				t.Errorf("requestFailover() passive = %v, want %v", passive, tt.wantPassive)
+			}
+
+			if _, err := os.Stat(commandRan); (err == nil) != tt.wantCommandRan {
+				t.Errorf("failover command ran = %v, want %v", err == nil, tt.wantCommandRan)
+			}
+			select {
+			case body := <-requested:
+				if !tt.wantRequested {
+					t.Errorf("failover url requested with %+v, want no request", body)
+				}
+				want := failoverRequest{Cluster: constants.ClusterNameTestnet, ValidatorIdentity: testActiveIdentity, VersionFrom: "0.6.9", VersionTo: "0.8.1"}
+				if body != want {
+					t.Errorf("failover request = %+v, want %+v", body, want)
+				}
+			default:
+				if tt.wantRequested {
+					t.Error("failover url not requested")
+				}
+			}
+		})
+	}
+}
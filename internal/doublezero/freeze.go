@@ -0,0 +1,49 @@
+package doublezero
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/freeze"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// activeFreeze returns the change freeze period in effect now, nil outside one. The first time a freeze defers a
+// version change to a target it is notified as drift. Simulated cycles evaluate without updating the state or notifying.
+func (dz *DoubleZero) activeFreeze(ctx context.Context, logger *log.Logger, versionDiff versiondiff.VersionDiff) (*freeze.Period, error) {
+	period, err := dz.freeze.Active(ctx, dz.clock.Now())
+	if err != nil || period == nil || dz.simulate {
+		return period, err
+	}
+
+	// only notify once per freeze period and target
+	notifiedFor := fmt.Sprintf("%s@%s", period.Name, versionDiff.To.Original())
+	err = dz.stateStore.Update(func(st *state.State) error {
+		if st.FreezeNotifiedFor == notifiedFor {
+			return nil
+		}
+		st.FreezeNotifiedFor = notifiedFor
+		dz.notifier.Notify(notify.Event{
+			Type:     notify.EventFreezeDrift,
+			Severity: constants.NotificationSeverityWarning,
+			Message: fmt.Sprintf("DoubleZero %s is behind the target version %s - change deferred by %s until %s",
+				versionDiff.From.Original(), versionDiff.To.Original(), period.Name, period.End.UTC().Format(time.RFC3339)),
+			Fields: map[string]string{
+				"version_from": versionDiff.From.Original(),
+				"version_to":   versionDiff.To.Original(),
+				"freeze":       period.Name,
+				"freeze_end":   period.End.UTC().Format(time.RFC3339),
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		logger.Warn("failed to record freeze drift notification", "error", err)
+	}
+	return period, nil
+}
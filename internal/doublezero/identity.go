@@ -0,0 +1,224 @@
+package doublezero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// checkValidatorIdentityGate records the validator identity gate, returning true when the cycle continues in monitor
+// only mode without executing commands
+func (dz *DoubleZero) checkValidatorIdentityGate(ctx context.Context, logger *log.Logger, rep *report.Report, versionDiff versiondiff.VersionDiff) (monitorOnly bool, err error) {
+	if dz.identitySource == nil {
+		rep.AddGate(report.GateValidatorIdentity, report.VerdictSkip, "no validator configured")
+		return false, nil
+	}
+
+	err = dz.checkValidatorIdentity(ctx, logger, dz.commandTemplateData(versionDiff, 0, 1))
+	switch {
+	case errors.Is(err, errMonitorOnly):
+		logger.Warn("monitor only mode - not executing commands")
+		rep.AddGate(report.GateValidatorIdentity, report.VerdictDone, "validator identity %s unreachable - monitor only (validator.on_unreachable=monitor_only)", dz.identitySource.Type())
+		rep.SetReason(report.ReasonValidatorUnreachable)
+		return true, nil
+	case err != nil:
+		rep.AddGate(report.GateValidatorIdentity, report.VerdictBlock, "%s", err)
+		setGateReason(rep, err)
+		return false, err
+	}
+	rep.AddGate(report.GateValidatorIdentity, report.VerdictPass, "validator identity allows a sync")
+	return false, nil
+}
+
+// checkValidatorIdentity checks the validator's identity and ensures sync is allowed
+// Returns an error if validator is running with unknown identity or active identity (unless enabled)
+// The template data is passed to the failover hook if one is configured
+func (dz *DoubleZero) checkValidatorIdentity(ctx context.Context, logger *log.Logger, data sync_commands.CommandTemplateData) error {
+	validatorIdentity, err := dz.identitySource.Identity(ctx)
+	if err != nil {
+		return dz.handleValidatorUnreachable(logger, err)
+	}
+
+	// identity files are re-read when they change, picking up rotated identities between cycles
+	keys, err := dz.identities.Keys()
+	if err != nil {
+		return fmt.Errorf("failed to load validator identities: %w", err)
+	}
+	activeIdentityPK := keys.Active.String()
+
+	// Single-identity setups only verify the validator runs the configured identity, which is always treated as active
+	if dz.validatorConfig.Identities.IsSingleIdentity() {
+		if !dz.isValidatorActive(validatorIdentity, activeIdentityPK) {
+			return withReason(report.ReasonIdentityMismatch, fmt.Errorf("validator identity %s does not match configured identity (%s)", validatorIdentity, activeIdentityPK))
+		}
+		if !dz.validatorConfig.EnabledWhenActive {
+			logger.Warnf("validator is running as its only configured identity and we don't run with scissors 🏃✂️")
+			return fmt.Errorf("sync not allowed when validator is active (set validator.enabled_when_active=true to allow)")
+		}
+		if err := dz.checkActiveAck(logger); err != nil {
+			return err
+		}
+		logger.Warn("validator identity verified (single identity) - proceeding with sync (enabled_when_active=true)")
+		return nil
+	}
+
+	passiveIdentityPK := keys.Passive.String()
+	isActive := dz.isValidatorActive(validatorIdentity, activeIdentityPK)
+	isPassive := dz.isValidatorPassive(validatorIdentity, passiveIdentityPK)
+	isUnknown := dz.isValidatorUnknown(validatorIdentity, activeIdentityPK, passiveIdentityPK)
+
+	// Check if validator is running with unknown identity
+	if isUnknown {
+		return withReason(report.ReasonIdentityMismatch, fmt.Errorf("validator identity %s does not match configured active (%s) or passive (%s) identities", validatorIdentity, activeIdentityPK, passiveIdentityPK))
+	}
+	// Optionally request a failover from external tooling, or wait for the validator to become passive
+	// (e.g. after an operator-initiated failover)
+	if isActive && !dz.validatorConfig.EnabledWhenActive {
+		waitConfig := dz.validatorConfig.WaitForPassive
+		switch {
+		case dz.simulate && (dz.failoverConfig.IsEnabled() || waitConfig.Timeout > 0):
+			return fmt.Errorf("validator is running as active identity - a real run would request a failover or wait for it to become passive first")
+		case dz.failoverConfig.IsEnabled():
+			isPassive, err = dz.requestFailover(ctx, logger, validatorIdentity, passiveIdentityPK, data)
+			if err != nil {
+				return err
+			}
+		case waitConfig.Timeout > 0:
+			logger.Info("validator is running as active identity - waiting for it to become passive")
+			isPassive = dz.waitForPassive(ctx, logger, passiveIdentityPK, waitConfig.Timeout, waitConfig.PollInterval)
+		}
+		isActive = !isPassive
+	}
+
+	// Check if validator is running as active identity and enabled_when_active is false - sync not allowed
+	if isActive && !dz.validatorConfig.EnabledWhenActive {
+		logger.Warnf("validator is running as active identity and we don't run with scissors 🏃✂️")
+		return fmt.Errorf("sync not allowed when validator is active (set validator.enabled_when_active=true to allow)")
+	}
+
+	// Check if validator is running as active identity and enabled_when_active is true - sync allowed
+	if isActive && dz.validatorConfig.EnabledWhenActive {
+		if err := dz.checkActiveAck(logger); err != nil {
+			return err
+		}
+		logger.Warn("validator is running as active identity - proceeding with sync (enabled_when_active=true)")
+		return nil
+	}
+
+	// Validator is running as passive identity
+	if isPassive {
+		logger.Info("validator is running as passive identity - proceeding with sync")
+		return nil
+	}
+
+	// This should never happen, but handle it just in case
+	return fmt.Errorf("unexpected validator identity state")
+}
+
+// waitForPassive polls the validator identity until it matches the passive identity or the timeout elapses
+// Returns true if the validator became passive within the timeout
+func (dz *DoubleZero) waitForPassive(ctx context.Context, logger *log.Logger, passiveIdentityPK string, timeout, pollInterval time.Duration) bool {
+	deadline := dz.clock.Now().Add(timeout)
+	logger.Debug("waiting for validator to become passive", "timeout", timeout.String(), "poll_interval", pollInterval.String())
+
+	for dz.clock.Now().Before(deadline) {
+		if err := clock.SleepContext(ctx, dz.clock, pollInterval); err != nil {
+			logger.Warn("stopped waiting for validator to become passive", "error", err)
+			return false
+		}
+
+		// each poll must hit the validator, not the per-cycle cache
+		if dz.validatorRPCClient != nil {
+			dz.validatorRPCClient.ResetCache()
+		}
+		validatorIdentity, err := dz.identitySource.Identity(ctx)
+		if err != nil {
+			logger.Warn("failed to get validator identity while waiting for passive", "error", err)
+			continue
+		}
+
+		if dz.isValidatorPassive(validatorIdentity, passiveIdentityPK) {
+			logger.Info("validator became passive", "identity", validatorIdentity)
+			return true
+		}
+		logger.Debug("validator not yet passive", "identity", validatorIdentity, "remaining", deadline.Sub(dz.clock.Now()).Truncate(time.Second).String())
+	}
+
+	logger.Warn("timed out waiting for validator to become passive", "timeout", timeout.String())
+	return false
+}
+
+// checkActiveAck returns an error if validator.active_ack_ttl requires an operator acknowledgement to sync while the
+// validator is active and none was recorded within it, unless inside one of sync.windows
+func (dz *DoubleZero) checkActiveAck(logger *log.Logger) error {
+	ttl := dz.validatorConfig.ActiveAckTTL
+	if ttl == 0 {
+		return nil
+	}
+
+	// a maintenance window is an acknowledgement in itself
+	if dz.windows.IsEnabled() {
+		if name, open := dz.windows.Open(dz.clock.Now()); open {
+			logger.Info("sync while active allowed inside maintenance window", "window", name)
+			return nil
+		}
+	}
+
+	st, err := dz.stateStore.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if st.ActiveAck == nil {
+		return withReason(report.ReasonActiveAckRequired, fmt.Errorf("sync while active requires an acknowledgement within validator.active_ack_ttl (%s) - none recorded, run ack-active to acknowledge", ttl))
+	}
+
+	age := dz.clock.Now().Sub(st.ActiveAck.AckedAt)
+	if age > ttl {
+		return withReason(report.ReasonActiveAckRequired, fmt.Errorf("sync while active requires an acknowledgement within validator.active_ack_ttl (%s) - last acknowledged %s ago by %s, run ack-active to acknowledge again",
+			ttl, age.Round(time.Second), st.ActiveAck.By))
+	}
+
+	logger.Info("sync while active acknowledged", "acked_at", st.ActiveAck.AckedAt.Format(time.RFC3339), "by", st.ActiveAck.By,
+		"reason", st.ActiveAck.Reason, "expires_in", (ttl - age).Round(time.Second).String())
+	return nil
+}
+
+// handleValidatorUnreachable applies the configured validator.on_unreachable behavior to a failed identity lookup from
+// any identity source
+func (dz *DoubleZero) handleValidatorUnreachable(logger *log.Logger, err error) error {
+	source := dz.identitySource.Type()
+	switch dz.validatorConfig.OnUnreachable {
+	case constants.ValidatorOnUnreachableSkipGate:
+		logger.Warn("validator identity unreachable - skipping identity check (on_unreachable=skip_gate)", "identity_source", source, "error", err)
+		return nil
+	case constants.ValidatorOnUnreachableMonitorOnly:
+		logger.Warn("validator identity unreachable - continuing in monitor only mode (on_unreachable=monitor_only)", "identity_source", source, "error", err)
+		return errMonitorOnly
+	default:
+		return withReason(report.ReasonValidatorUnreachable, fmt.Errorf("failed to get validator identity: %w", err))
+	}
+}
+
+// isValidatorActive returns true if the validator is running as the active identity
+func (dz *DoubleZero) isValidatorActive(validatorIdentity, activeIdentityPK string) bool {
+	return validatorIdentity == activeIdentityPK
+}
+
+// isValidatorPassive returns true if the validator is running as the passive identity
+func (dz *DoubleZero) isValidatorPassive(validatorIdentity, passiveIdentityPK string) bool {
+	return validatorIdentity == passiveIdentityPK
+}
+
+// isValidatorUnknown returns true if the validator is running with an unknown identity
+func (dz *DoubleZero) isValidatorUnknown(validatorIdentity, activeIdentityPK, passiveIdentityPK string) bool {
+	return validatorIdentity != activeIdentityPK && validatorIdentity != passiveIdentityPK
+}
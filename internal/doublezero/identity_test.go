@@ -0,0 +1,274 @@
+package doublezero
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/identity"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// reasonOf returns the reason code attached to err, if any
+func reasonOf(err error) string {
+	var reasonErr *reasonError
+	if errors.As(err, &reasonErr) {
+		return reasonErr.reason
+	}
+	return ""
+}
+
+// recordActiveAck records an operator acknowledgement made at ackedAt
+func recordActiveAck(t *testing.T, dz *DoubleZero, ackedAt time.Time) {
+	t.Helper()
+	if err := dz.stateStore.Update(func(st *state.State) error {
+		st.ActiveAck = &state.ActiveAck{AckedAt: ackedAt, By: "alice", Reason: "planned upgrade"}
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckActiveAck(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		windows []maintenance.Window
+		// ackAge is how long ago the acknowledgement was recorded, none when 0
+		ackAge     time.Duration
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "no ttl needs no acknowledgement", ttl: 0},
+		{name: "no acknowledgement recorded", ttl: 24 * time.Hour, wantErr: true, wantReason: report.ReasonActiveAckRequired},
+		{name: "acknowledged inside the ttl", ttl: 24 * time.Hour, ackAge: time.Hour},
+		{name: "acknowledged at the end of the ttl", ttl: 24 * time.Hour, ackAge: 24 * time.Hour},
+		{name: "acknowledgement expired", ttl: 24 * time.Hour, ackAge: 25 * time.Hour, wantErr: true, wantReason: report.ReasonActiveAckRequired},
+		{
+			name:    "open window overrides an expired acknowledgement",
+			ttl:     24 * time.Hour,
+			windows: []maintenance.Window{{Name: "morning", Start: "08:00", End: "10:00"}},
+			ackAge:  25 * time.Hour,
+		},
+		{
+			name:    "open window overrides a missing acknowledgement",
+			ttl:     24 * time.Hour,
+			windows: []maintenance.Window{{Name: "morning", Start: "08:00", End: "10:00"}},
+		},
+		{
+			name:       "closed window doesn't override a missing acknowledgement",
+			ttl:        24 * time.Hour,
+			windows:    []maintenance.Window{{Name: "evening", Start: "22:00", End: "23:00"}},
+			wantErr:    true,
+			wantReason: report.ReasonActiveAckRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dz, _ := newTestDoubleZero(t, now)
+			dz.validatorConfig.ActiveAckTTL = tt.ttl
+			windows, err := maintenance.NewSchedule(tt.windows)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dz.windows = windows
+			if tt.ackAge > 0 {
+				recordActiveAck(t, dz, now.Add(-tt.ackAge))
+			}
+
+			err = dz.checkActiveAck(dz.logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkActiveAck() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := reasonOf(err); got != tt.wantReason {
+				t.Errorf("checkActiveAck() reason = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckValidatorIdentitySingleIdentity(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	active := solana.NewWallet().PublicKey().String()
+	other := solana.NewWallet().PublicKey().String()
+
+	tests := []struct {
+		name              string
+		validatorIdentity string
+		enabledWhenActive bool
+		// ackAge is how long ago the acknowledgement was recorded, none when 0
+		ackAge     time.Duration
+		wantErr    bool
+		wantReason string
+	}{
+		{name: "acknowledged inside the ttl", validatorIdentity: active, enabledWhenActive: true, ackAge: time.Hour},
+		{name: "acknowledgement expired", validatorIdentity: active, enabledWhenActive: true, ackAge: 25 * time.Hour,
+			wantErr: true, wantReason: report.ReasonActiveAckRequired},
+		{name: "not acknowledged", validatorIdentity: active, enabledWhenActive: true,
+			wantErr: true, wantReason: report.ReasonActiveAckRequired},
+		{name: "not enabled when active", validatorIdentity: active, ackAge: time.Hour, wantErr: true},
+		{name: "other identity", validatorIdentity: other, enabledWhenActive: true, ackAge: time.Hour,
+			wantErr: true, wantReason: report.ReasonIdentityMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identityFile := filepath.Join(t.TempDir(), "identity")
+			if err := os.WriteFile(identityFile, []byte(tt.validatorIdentity), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			dz, _ := newTestDoubleZero(t, now)
+			dz.identitySource = &fileIdentitySource{file: identityFile}
+			dz.identities = identity.New(identity.Options{ActivePublicKey: active, Logger: dz.logger})
+			dz.validatorConfig = config.Validator{
+				Identities:        config.Identities{ActivePubkey: active},
+				EnabledWhenActive: tt.enabledWhenActive,
+				ActiveAckTTL:      24 * time.Hour,
+			}
+			if tt.ackAge > 0 {
+				recordActiveAck(t, dz, now.Add(-tt.ackAge))
+			}
+
+			err := dz.checkValidatorIdentity(context.Background(), dz.logger, sync_commands.CommandTemplateData{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkValidatorIdentity() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := reasonOf(err); got != tt.wantReason {
+				t.Errorf("checkValidatorIdentity() reason = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+// sequenceIdentitySource reports the identities in turn, the last one from then on - an empty identity is a failed
+// lookup
+type sequenceIdentitySource struct {
+	identities []string
+	lookups    int
+}
+
+func (s *sequenceIdentitySource) Identity(context.Context) (string, error) {
+	identity := s.identities[min(s.lookups, len(s.identities)-1)]
+	s.lookups++
+	if identity == "" {
+		return "", errors.New("validator unreachable")
+	}
+	return identity, nil
+}
+
+func (s *sequenceIdentitySource) Type() string { return constants.ValidatorIdentitySourceRPC }
+
+func TestWaitForPassive(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		identities []string
+		cancelled  bool
+		want       bool
+		// waited is how long the fake clock moved while waiting
+		waited      time.Duration
+		wantLookups int
+	}{
+		{
+			name:        "becomes passive on the third poll",
+			identities:  []string{testActiveIdentity, testActiveIdentity, testPassiveIdentity},
+			want:        true,
+			waited:      30 * time.Second,
+			wantLookups: 3,
+		},
+		{
+			name:        "unreachable polls are retried",
+			identities:  []string{"", testPassiveIdentity},
+			want:        true,
+			waited:      20 * time.Second,
+			wantLookups: 2,
+		},
+		{
+			name:        "stays active until the timeout",
+			identities:  []string{testActiveIdentity},
+			waited:      time.Minute,
+			wantLookups: 6,
+		},
+		{
+			name:       "cancelled before the first poll",
+			identities: []string{testPassiveIdentity},
+			cancelled:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dz, _ := newTestDoubleZero(t, start)
+			source := &sequenceIdentitySource{identities: tt.identities}
+			dz.identitySource = source
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			if got := dz.waitForPassive(ctx, dz.logger, testPassiveIdentity, time.Minute, 10*time.Second); got != tt.want {
+				t.Errorf("waitForPassive() = %v, want %v", got, tt.want)
+			}
+			if got := dz.clock.(*clock.Fake).Now().Sub(start); got != tt.waited {
+				t.Errorf("waited %s, want %s", got, tt.waited)
+			}
+			if source.lookups != tt.wantLookups {
+				t.Errorf("identity looked up %d times, want %d", source.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestCheckValidatorIdentityGateUnreachable(t *testing.T) {
+	versionDiff := versiondiff.VersionDiff{From: version.Must(version.NewVersion("0.6.9")), To: version.Must(version.NewVersion("0.8.1-1"))}
+
+	tests := []struct {
+		name            string
+		onUnreachable   string
+		wantErr         bool
+		wantMonitorOnly bool
+		wantVerdict     string
+		wantReason      string
+	}{
+		{name: "fail", onUnreachable: constants.ValidatorOnUnreachableFail, wantErr: true,
+			wantVerdict: report.VerdictBlock, wantReason: report.ReasonValidatorUnreachable},
+		{name: "skip_gate", onUnreachable: constants.ValidatorOnUnreachableSkipGate, wantVerdict: report.VerdictPass},
+		{name: "monitor_only", onUnreachable: constants.ValidatorOnUnreachableMonitorOnly, wantMonitorOnly: true,
+			wantVerdict: report.VerdictDone, wantReason: report.ReasonValidatorUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dz, _ := newTestDoubleZero(t, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			dz.identitySource = &sequenceIdentitySource{identities: []string{""}}
+			dz.validatorConfig.OnUnreachable = tt.onUnreachable
+			rep := report.New(dz.State.Cluster, false, dz.clock.Now())
+
+			monitorOnly, err := dz.checkValidatorIdentityGate(context.Background(), dz.logger, rep, versionDiff)
+			if (err != nil) != tt.wantErr || monitorOnly != tt.wantMonitorOnly {
+				t.Fatalf("checkValidatorIdentityGate() = %v, %v, want monitor only %v, error %v", monitorOnly, err, tt.wantMonitorOnly, tt.wantErr)
+			}
+			if len(rep.Gates) != 1 || rep.Gates[0].Name != report.GateValidatorIdentity {
+				t.Fatalf("gates = %+v, want the validator identity gate", rep.Gates)
+			}
+			if gate := rep.Gates[0]; gate.Verdict != tt.wantVerdict || gate.Reason != tt.wantReason {
+				t.Errorf("gate verdict = %s, reason = %q, want %s, %q", gate.Verdict, gate.Reason, tt.wantVerdict, tt.wantReason)
+			}
+		})
+	}
+}
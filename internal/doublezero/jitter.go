@@ -0,0 +1,73 @@
+package doublezero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// errJitterPending is returned when this node's jitter delay can't elapse within the current cycle
+var errJitterPending = errors.New("jitter pending")
+
+// hostname returns the hostname jitter delays are seeded with, replaced in tests
+var hostname = os.Hostname
+
+// jitterSeed returns what this node's jitter delay is derived from and its kind - the hostname, mixed with the active
+// validator identity when a validator is configured. Hot-spare nodes share the active identity, the hostname keeps
+// them from picking the same delay
+func (dz *DoubleZero) jitterSeed() (string, string, error) {
+	host, err := hostname()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get hostname to seed sync.jitter: %w", err)
+	}
+
+	if dz.identities != nil {
+		keys, err := dz.identities.Keys()
+		if err == nil {
+			return keys.Active.String() + "/" + host, "identity and hostname", nil
+		}
+		dz.logger.Warn("failed to load validator identity for sync.jitter - seeding from the hostname only", "error", err)
+	}
+	return host, "hostname", nil
+}
+
+// waitJitter waits until this node's jitter delay has passed since the target version was released to it - when it was
+// first observed, plus any sync.cohort delay - returning a description of the wait. Returns errJitterPending when the
+// delay would run past the cycle's deadline - a later cycle picks it up, or the context's error when ctx is done while
+// waiting. Simulations report the wait without sleeping
+func (dz *DoubleZero) waitJitter(ctx context.Context, logger *log.Logger, versionDiff versiondiff.VersionDiff, releasedAt time.Time) (string, error) {
+	seed, source, err := dz.jitterSeed()
+	if err != nil {
+		return "", err
+	}
+
+	delay := dz.jitter.Delay(seed)
+	syncAt := releasedAt.Add(delay)
+	remaining := syncAt.Sub(dz.clock.Now()).Round(time.Second)
+	logger = logger.With("jitter", dz.jitter.String(), "delay", delay.String(), "seed", source, "released", releasedAt.Format(time.RFC3339))
+
+	switch {
+	case remaining <= 0:
+		logger.Debug("jitter delay has passed")
+		return fmt.Sprintf("%s delay (seeded from %s) passed at %s", delay, source, syncAt.UTC().Format(time.RFC3339)), nil
+	case dz.simulate:
+		logger.Info("simulation - not waiting for jitter delay", "remaining", remaining.String())
+		return fmt.Sprintf("simulation - a real run would wait %s more of its %s delay (seeded from %s)", remaining, delay, source), nil
+	case !dz.deadline.IsZero() && syncAt.After(dz.deadline):
+		logger.Info("jitter delay runs past this cycle - waiting for a later cycle", "remaining", remaining.String(), "deadline", dz.deadline.Format(time.RFC3339))
+		return "", fmt.Errorf("%w - %s of %s delay (seeded from %s) remaining for v%s, past this cycle's deadline",
+			errJitterPending, remaining, delay, source, versionDiff.To.Core().String())
+	}
+
+	logger.Info("⏳ waiting for jitter delay before syncing", "remaining", remaining.String(), "sync_at", syncAt.UTC().Format(time.RFC3339))
+	if err := clock.SleepContext(ctx, dz.clock, remaining); err != nil {
+		return "", fmt.Errorf("stopped waiting for jitter delay: %w", err)
+	}
+	return fmt.Sprintf("waited %s of %s delay (seeded from %s)", remaining, delay, source), nil
+}
@@ -0,0 +1,49 @@
+package doublezero
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/identity"
+)
+
+func TestJitterSeed(t *testing.T) {
+	active := solana.NewWallet().PublicKey().String()
+
+	tests := []struct {
+		name        string
+		hostname    string
+		hostnameErr error
+		validator   bool
+		wantSeed    string
+		wantSource  string
+		wantErr     bool
+	}{
+		{name: "hot spare a", hostname: "spare-a", validator: true, wantSeed: active + "/spare-a", wantSource: "identity and hostname"},
+		{name: "hot spare b", hostname: "spare-b", validator: true, wantSeed: active + "/spare-b", wantSource: "identity and hostname"},
+		{name: "no validator", hostname: "spare-a", wantSeed: "spare-a", wantSource: "hostname"},
+		{name: "hostname unavailable", hostnameErr: errors.New("uname failed"), validator: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := hostname
+			hostname = func() (string, error) { return tt.hostname, tt.hostnameErr }
+			t.Cleanup(func() { hostname = original })
+
+			dz, _ := newTestDoubleZero(t, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			if tt.validator {
+				dz.identities = identity.New(identity.Options{ActivePublicKey: active, Logger: dz.logger})
+			}
+
+			seed, source, err := dz.jitterSeed()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("jitterSeed() error = %v, want error %v", err, tt.wantErr)
+			}
+			if seed != tt.wantSeed || source != tt.wantSource {
+				t.Errorf("jitterSeed() = %q, %q, want %q, %q", seed, source, tt.wantSeed, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package doublezero
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/lockfile"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+)
+
+// acquireSyncLock takes sync.lock.file for a real cycle, waiting up to sync.lock.wait for another process holding it,
+// so a one-off run can't race a daemon and two daemons never run the sync commands at once. The returned release
+// function must be called once the cycle's state is saved
+func (dz *DoubleZero) acquireSyncLock(ctx context.Context, rep *report.Report) (release func(), err error) {
+	lockConfig := dz.syncConfig.Lock
+	switch {
+	case dz.simulate:
+		rep.AddGate(report.GateSyncLock, report.VerdictSkip, "simulated cycles don't take the sync lock")
+		return func() {}, nil
+	case lockConfig.File == "":
+		rep.AddGate(report.GateSyncLock, report.VerdictSkip, "no sync.lock.file configured")
+		return func() {}, nil
+	}
+
+	lock, err := lockfile.Acquire(ctx, lockConfig.File, lockConfig.Wait, dz.clock)
+	switch {
+	case errors.Is(err, lockfile.ErrLocked):
+		dz.logger.Warn("another process is syncing - not running this cycle", "lock_file", lockConfig.File, "wait", lockConfig.Wait.String(), "error", err)
+		rep.AddGate(report.GateSyncLock, report.VerdictBlock, "sync lock %s (waited %s)", err, lockConfig.Wait)
+		return nil, err
+	case err != nil:
+		rep.AddGate(report.GateSyncLock, report.VerdictFail, "%s", err)
+		return nil, err
+	}
+
+	rep.AddGate(report.GateSyncLock, report.VerdictPass, "holding %s", lockConfig.File)
+	return func() {
+		if err := lock.Release(); err != nil {
+			dz.logger.Warn("failed to release sync lock", "lock_file", lockConfig.File, "error", err)
+		}
+	}, nil
+}
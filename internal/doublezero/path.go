@@ -0,0 +1,195 @@
+package doublezero
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+// UpgradePath is the path the syncer follows from one version to another. The sync commands install the target package
+// directly, so a path has a single step unless an upgrade passes through doublezero.stepping_stones, then a step per
+// hop - none when already on the target - each evaluated against the version policies
+type UpgradePath struct {
+	Cluster string `json:"cluster"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	// Direction is upgrade, downgrade or no change
+	Direction string `json:"direction"`
+	// Followed is whether the syncer would follow the path, false when a policy blocks a step
+	Followed bool       `json:"followed"`
+	Steps    []PathStep `json:"steps"`
+}
+
+// PathStep is a step of an upgrade path, the sync commands run once to a stepping stone or the target
+type PathStep struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Direction string `json:"direction"`
+	// Policies are the version policies evaluated against the step
+	Policies []PathPolicy `json:"policies"`
+	// Commands are the rendered sync commands the step runs
+	Commands []string `json:"commands"`
+	// PlanID is the sync plan id approvals under sync.approval apply to
+	PlanID string `json:"plan_id"`
+}
+
+// PathPolicy is the evaluation of a version policy against a path step
+type PathPolicy struct {
+	// Name is the config key, or state entry, of the policy
+	Name    string `json:"name"`
+	Allowed bool   `json:"allowed"`
+	Detail  string `json:"detail"`
+}
+
+// UpgradePath computes the path from the from version, the installed version when empty, to the to version, the
+// recommended version when empty, with the version policies, operator skips and approvals evaluated against each step
+// Nothing is executed, notified or recorded - gates depending on the moment a cycle runs (windows, validator identity,
+// confirm cycles, version age) are left to explain and simulate
+func (dz *DoubleZero) UpgradePath(ctx context.Context, from, to string) (*UpgradePath, error) {
+	fromVersion, err := dz.pathVersion(from, "installed", dz.getInstalledVersion)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := dz.pathVersion(to, "recommended", func() (*version.Version, error) {
+		recommendation, err := dz.versionSource.GetRecommendation(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dz.State.Recommendation = recommendation
+		return recommendation.Version, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versionDiff := versiondiff.VersionDiff{From: fromVersion, To: toVersion}
+	path := &UpgradePath{
+		Cluster:   dz.State.Cluster,
+		From:      fromVersion.Original(),
+		To:        toVersion.Original(),
+		Direction: versionDiff.Direction(),
+		Followed:  true,
+		Steps:     []PathStep{},
+	}
+	if versionDiff.IsSameVersion() {
+		return path, nil
+	}
+
+	for _, hop := range dz.steppingStones(versionDiff) {
+		step, err := dz.pathStep(hop)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range step.Policies {
+			path.Followed = path.Followed && policy.Allowed
+		}
+		path.Steps = append(path.Steps, step)
+	}
+	return path, nil
+}
+
+// pathStep renders the sync commands of a step and evaluates the version policies against it
+func (dz *DoubleZero) pathStep(versionDiff versiondiff.VersionDiff) (PathStep, error) {
+	step := PathStep{From: versionDiff.From.Original(), To: versionDiff.To.Original(), Direction: versionDiff.Direction()}
+	commandsCount := len(dz.syncConfig.Commands)
+	plan := make(sync_commands.Plan, 0, commandsCount)
+	for i := range dz.syncConfig.Commands {
+		rendered, err := dz.syncConfig.Commands[i].Render(dz.commandTemplateData(versionDiff, i, commandsCount))
+		if err != nil {
+			return step, fmt.Errorf("failed to render command %d (%s): %w", i, dz.syncConfig.Commands[i].Name, err)
+		}
+		plan = append(plan, rendered)
+	}
+	step.Commands = plan.Lines()
+	planDigest := PlanDigest(dz.State.Cluster, step.From, step.To, plan)
+	step.PlanID = PlanID(planDigest)
+
+	policies, err := dz.pathPolicies(versionDiff, planDigest)
+	if err != nil {
+		return step, err
+	}
+	step.Policies = policies
+	return step, nil
+}
+
+// pathVersion parses version, or when empty returns the one get observes
+func (dz *DoubleZero) pathVersion(v, description string, get func() (*version.Version, error)) (*version.Version, error) {
+	if v != "" {
+		parsed, err := version.NewVersion(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version %s: %w", v, err)
+		}
+		return parsed, nil
+	}
+	observed, err := get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the %s DoubleZero version: %w", description, err)
+	}
+	return observed, nil
+}
+
+// pathPolicies evaluates the version policies, operator skips and approvals against a step
+func (dz *DoubleZero) pathPolicies(versionDiff versiondiff.VersionDiff, planDigest string) ([]PathPolicy, error) {
+	target := versionDiff.To.Core().String()
+	var policies []PathPolicy
+
+	if constraint := dz.doubleZeroConfig.ParsedVersionConstraint; constraint != nil {
+		policy := PathPolicy{Name: "doublezero.version_constraint", Allowed: constraint.Check(versionDiff.To.Core())}
+		policy.Detail = fmt.Sprintf("%s satisfies %s", target, constraint.String())
+		if !policy.Allowed {
+			policy.Detail = fmt.Sprintf("%s does not satisfy %s", target, constraint.String())
+		}
+		policies = append(policies, policy)
+	}
+
+	skipPolicy := PathPolicy{Name: "doublezero.skip_versions", Allowed: true, Detail: "target is not listed"}
+	if skipVersion, ok := dz.configSkippedVersion(&versionsource.Recommendation{Version: versionDiff.To}); ok {
+		skipPolicy = PathPolicy{Name: skipPolicy.Name, Detail: fmt.Sprintf("%s is listed", skipVersion)}
+	}
+	policies = append(policies, skipPolicy)
+
+	downgradePolicy := PathPolicy{Name: "sync.allow_downgrade", Allowed: true, Detail: "not a downgrade"}
+	if versionDiff.Direction() == versiondiff.DirectionDowngrade {
+		downgradePolicy.Allowed = dz.syncConfig.AllowDowngrade
+		downgradePolicy.Detail = "downgrade allowed"
+		if !downgradePolicy.Allowed {
+			downgradePolicy.Detail = "downgrade refused, set sync.allow_downgrade=true to allow"
+		}
+	}
+	policies = append(policies, downgradePolicy)
+
+	st, err := dz.stateStore.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	operatorSkipPolicy := PathPolicy{Name: "skip-version", Allowed: true, Detail: "target is not skipped by an operator"}
+	for _, skip := range st.SkippedVersions {
+		if versiondiff.Matches(skip.Version, versionDiff.To) {
+			operatorSkipPolicy = PathPolicy{Name: operatorSkipPolicy.Name, Detail: fmt.Sprintf("%s skipped: %s", skip.Version, skipReason(&skip))}
+			break
+		}
+	}
+	policies = append(policies, operatorSkipPolicy)
+
+	if approvalConfig := &dz.syncConfig.Approval; approvalConfig.Enabled {
+		var approvedBy []string
+		if st.PendingApproval != nil && st.PendingApproval.PlanDigest == planDigest {
+			approvedBy = st.PendingApproval.ApprovedBy(func(approval state.Approval) bool {
+				return VerifyApproval(approvalConfig, planDigest, approval)
+			})
+		}
+		policies = append(policies, PathPolicy{
+			Name:    "sync.approval",
+			Allowed: len(approvedBy) >= approvalConfig.RequiredApprovals,
+			Detail:  fmt.Sprintf("plan %s approved by %d of %d required operators", PlanID(planDigest), len(approvedBy), approvalConfig.RequiredApprovals),
+		})
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,113 @@
+package doublezero
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+func TestUpgradePath(t *testing.T) {
+	tests := []struct {
+		name              string
+		from, to          string
+		steppingStones    []string
+		skipVersions      []string
+		versionConstraint string
+		// wantSteps are the step targets in order
+		wantSteps    []string
+		wantFollowed bool
+		// wantBlocked is the policy blocking the step to the version, if any
+		wantBlocked map[string]string
+	}{
+		{
+			name:         "single hop",
+			from:         "0.6.9",
+			to:           "0.8.1-1",
+			wantSteps:    []string{"0.8.1-1"},
+			wantFollowed: true,
+		},
+		{
+			name:           "multi-hop through every stepping stone in between",
+			from:           "0.6.9",
+			to:             "0.8.1-1",
+			steppingStones: []string{"0.7.5-1", "0.6.0-1", "0.7.0-1", "0.9.0-1"},
+			wantSteps:      []string{"0.7.0-1", "0.7.5-1", "0.8.1-1"},
+			wantFollowed:   true,
+		},
+		{
+			name:           "intermediate version skipped",
+			from:           "0.6.9",
+			to:             "0.8.1-1",
+			steppingStones: []string{"0.7.0-1"},
+			skipVersions:   []string{"0.7.0"},
+			wantSteps:      []string{"0.7.0-1", "0.8.1-1"},
+			wantBlocked:    map[string]string{"0.7.0-1": "doublezero.skip_versions"},
+		},
+		{
+			name:              "intermediate version outside the constraint",
+			from:              "0.6.9",
+			to:                "0.8.1-1",
+			steppingStones:    []string{"0.7.0-1"},
+			versionConstraint: ">= 0.7.2",
+			wantSteps:         []string{"0.7.0-1", "0.8.1-1"},
+			wantBlocked:       map[string]string{"0.7.0-1": "doublezero.version_constraint"},
+		},
+		{
+			name:         "already on the target",
+			from:         "0.8.1-1",
+			to:           "0.8.1-1",
+			wantFollowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dz, _ := newTestDoubleZero(t, time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			dz.doubleZeroConfig = config.DoubleZero{
+				SteppingStones:    tt.steppingStones,
+				SkipVersions:      tt.skipVersions,
+				VersionConstraint: config.VersionConstraint{Value: tt.versionConstraint},
+				Daemon:            config.Daemon{Check: constants.DaemonCheckNone},
+			}
+			if err := dz.doubleZeroConfig.Validate(dz.State.Cluster); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			install := sync_commands.Command{Name: "install", Cmd: "apt-get", Args: []string{"install", "doublezero={{ .PackageVersionTo }}"}}
+			if err := install.Parse(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dz.syncConfig.Commands = []sync_commands.Command{install}
+
+			path, err := dz.UpgradePath(context.Background(), tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("UpgradePath() error = %v", err)
+			}
+			if path.Followed != tt.wantFollowed {
+				t.Errorf("UpgradePath() followed = %v, want %v", path.Followed, tt.wantFollowed)
+			}
+			if len(path.Steps) != len(tt.wantSteps) {
+				t.Fatalf("UpgradePath() steps = %+v, want steps to %v", path.Steps, tt.wantSteps)
+			}
+
+			from := tt.from
+			for i, step := range path.Steps {
+				if step.From != from || step.To != tt.wantSteps[i] {
+					t.Errorf("step %d = %s -> %s, want %s -> %s", i+1, step.From, step.To, from, tt.wantSteps[i])
+				}
+				if want := "doublezero=" + step.To; len(step.Commands) != 1 || !strings.Contains(step.Commands[0], want) {
+					t.Errorf("step %d commands = %v, want the install of %s", i+1, step.Commands, want)
+				}
+				for _, policy := range step.Policies {
+					if blocked := !policy.Allowed; blocked != (tt.wantBlocked[step.To] == policy.Name) {
+						t.Errorf("step %d policy %s allowed = %v (%s), want blocked by %q", i+1, policy.Name, policy.Allowed, policy.Detail, tt.wantBlocked[step.To])
+					}
+				}
+				from = step.To
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package doublezero
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// checkCommandPlan renders the sync command plan for the target version and compares it against the plan last rendered
+// for the same target, so config or template edits that change what an upcoming sync will execute are surfaced
+// Returns the plan and its diff against the previous plan, nil when unchanged or rendered for the first time
+// Simulated cycles compare against the stored plan without updating it.
+func (dz *DoubleZero) checkCommandPlan(logger *log.Logger, versionDiff versiondiff.VersionDiff) (sync_commands.Plan, []string, error) {
+	commandsCount := len(dz.syncConfig.Commands)
+	plan := make(sync_commands.Plan, 0, commandsCount)
+	for i := range dz.syncConfig.Commands {
+		rendered, err := dz.syncConfig.Commands[i].Render(dz.commandTemplateData(versionDiff, i, commandsCount))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render command %d (%s): %w", i, dz.syncConfig.Commands[i].Name, err)
+		}
+		plan = append(plan, rendered)
+	}
+
+	target := versionDiff.To.Original()
+	var diff []string
+	record := func(st *state.State) error {
+		if previous, ok := st.CommandPlans[target]; ok {
+			diff = sync_commands.DiffLines(previous.Lines(), plan.Lines())
+		}
+
+		if st.CommandPlans == nil {
+			st.CommandPlans = make(map[string]sync_commands.Plan)
+		}
+		st.CommandPlans[target] = plan
+		return nil
+	}
+
+	if dz.simulate {
+		st, err := dz.stateStore.Load()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load state: %w", err)
+		}
+		if err := record(&st); err != nil {
+			return nil, nil, err
+		}
+	} else if err := dz.stateStore.Update(record); err != nil {
+		return nil, nil, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	if diff != nil {
+		logger.Warn("rendered command plan changed since it was last rendered for this target - review before it executes\n" + strings.Join(diff, "\n"))
+	} else {
+		logger.Debug("rendered command plan", "commands", plan.Lines())
+	}
+
+	return plan, diff, nil
+}
@@ -0,0 +1,143 @@
+package doublezero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+// checkPublished verifies the recommended package version is published in the sync.verify_published repository index
+// and returns the package version checked - the RPM release when checking an rpm repository, or any release of the
+// target version when the version source doesn't know it
+func (dz *DoubleZero) checkPublished(ctx context.Context, logger *log.Logger, recommendation *versionsource.Recommendation) (string, error) {
+	packageVersion := recommendation.PackageVersion
+	if dz.syncConfig.VerifyPublished.Repository.Format == constants.PackageFormatRPM {
+		packageVersion = recommendation.RPMPackageVersion
+		if packageVersion == "" {
+			packageVersion = recommendation.Version.Core().String()
+		}
+	}
+
+	evidence, err := dz.publishedChecker.CheckPublished(ctx, packageVersion)
+	if err != nil {
+		return packageVersion, fmt.Errorf("target package not verified as published: %w", err)
+	}
+
+	logger.Debug("target package is published", "package_version", packageVersion, "evidence", evidence)
+	return packageVersion, nil
+}
+
+// packagePollInterval is how often the sync.verify_published repository is checked again while waiting for the target
+// package to be published
+const packagePollInterval = time.Minute
+
+// waitPublished checks the target package is published like checkPublished, polling the repository every
+// packagePollInterval while it isn't for up to sync.wait_for_package - never past the cycle's deadline. Returns the
+// package version checked and how long it waited. Simulations report whether it's published without waiting
+func (dz *DoubleZero) waitPublished(ctx context.Context, logger *log.Logger, recommendation *versionsource.Recommendation) (string, time.Duration, error) {
+	packageVersion, err := dz.checkPublished(ctx, logger, recommendation)
+	if !errors.Is(err, versionsource.ErrNotPublished) || dz.syncConfig.WaitForPackage <= 0 || dz.simulate {
+		return packageVersion, 0, err
+	}
+
+	startedAt := dz.clock.Now()
+	waitUntil := startedAt.Add(dz.syncConfig.WaitForPackage)
+	if !dz.deadline.IsZero() && dz.deadline.Before(waitUntil) {
+		waitUntil = dz.deadline
+	}
+	logger.Info("⏳ waiting for the target package to be published", "package_version", packageVersion,
+		"wait_for_package", dz.syncConfig.WaitForPackage.String(), "until", waitUntil.UTC().Format(time.RFC3339))
+	for {
+		remaining := waitUntil.Sub(dz.clock.Now())
+		if remaining <= 0 {
+			return packageVersion, dz.clock.Now().Sub(startedAt), err
+		}
+		if sleepErr := clock.SleepContext(ctx, dz.clock, min(packagePollInterval, remaining)); sleepErr != nil {
+			return packageVersion, dz.clock.Now().Sub(startedAt), fmt.Errorf("stopped waiting for the target package to be published: %w", sleepErr)
+		}
+		packageVersion, err = dz.checkPublished(ctx, logger, recommendation)
+		if !errors.Is(err, versionsource.ErrNotPublished) {
+			return packageVersion, dz.clock.Now().Sub(startedAt), err
+		}
+		logger.Debug("target package not published yet", "package_version", packageVersion, "remaining", waitUntil.Sub(dz.clock.Now()).Round(time.Second).String())
+	}
+}
+
+// recordRepoLag returns the repository lag of the target package version the sync.verify_published repository
+// doesn't publish yet, since it was first found missing - at since for the first cycle finding it missing. The first
+// time it's found missing it is recorded in the state and notified. Simulated cycles evaluate without updating the state
+// or notifying
+func (dz *DoubleZero) recordRepoLag(logger *log.Logger, versionDiff versiondiff.VersionDiff, packageVersion string, since time.Time) *report.RepoLag {
+	lag := &report.RepoLag{PackageVersion: packageVersion, Repository: dz.publishedChecker.Repository(), Since: since.UTC()}
+	record := func(st *state.State) (first bool) {
+		if prev := st.RepoLag; prev != nil && prev.PackageVersion == lag.PackageVersion && prev.Repository == lag.Repository {
+			lag.Since = prev.Since
+			return false
+		}
+		st.RepoLag = lag
+		return true
+	}
+
+	if dz.simulate {
+		if st, err := dz.stateStore.Load(); err == nil {
+			record(&st)
+		}
+		return lag
+	}
+
+	err := dz.stateStore.Update(func(st *state.State) error {
+		if !record(st) {
+			return nil
+		}
+		dz.notifier.Notify(notify.Event{
+			Type:     notify.EventRepoLag,
+			Severity: constants.NotificationSeverityWarning,
+			Message: fmt.Sprintf("DoubleZero %s is the target version but isn't published in the %s repository yet - change pending until it is",
+				packageVersion, lag.Repository),
+			Fields: map[string]string{
+				"version_from":    versionDiff.From.Original(),
+				"version_to":      versionDiff.To.Original(),
+				"package_version": packageVersion,
+				"repository":      lag.Repository,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		logger.Warn("failed to record repository lag", "error", err)
+	}
+	return lag
+}
+
+// clearRepoLag removes the repository lag recorded in the state once the target package is published
+func (dz *DoubleZero) clearRepoLag(logger *log.Logger) {
+	if dz.simulate {
+		return
+	}
+	st, err := dz.stateStore.Load()
+	if err != nil || st.RepoLag == nil {
+		return
+	}
+
+	err = dz.stateStore.Update(func(st *state.State) error {
+		if st.RepoLag != nil {
+			logger.Info("target package published, repository lag over", "package_version", st.RepoLag.PackageVersion,
+				"lagged", dz.clock.Now().Sub(st.RepoLag.Since).Round(time.Second).String())
+		}
+		st.RepoLag = nil
+		return nil
+	})
+	if err != nil {
+		logger.Warn("failed to clear repository lag", "error", err)
+	}
+}
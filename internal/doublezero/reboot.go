@@ -0,0 +1,183 @@
+package doublezero
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/reboot"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// checkReboot checks whether the sync commands left the host requiring a reboot, recording it in the report and
+// notifying when they did. With the command policy the reboot command runs after a successful sync when inside
+// sync.windows if any are configured, otherwise it's persisted as pending and run by the next cycle inside one - its
+// failure is logged and notified but doesn't fail the sync, which already happened
+func (dz *DoubleZero) checkReboot(ctx context.Context, logger *log.Logger, rep *report.Report, versionDiff versiondiff.VersionDiff, syncErr error) {
+	if !dz.rebootConfig.IsEnabled() {
+		return
+	}
+
+	status, err := dz.rebootChecker.Check()
+	if err != nil {
+		logger.Warn("failed to check whether a reboot is required", "error", err)
+		return
+	}
+	rep.Reboot = &status
+	if !status.Required {
+		logger.Debug("no reboot required after sync")
+		return
+	}
+
+	logger.Warn("host requires a reboot after sync", "reasons", status.Reasons, "packages", status.Packages)
+	fields := map[string]string{
+		"version_from": versionDiff.From.Core().String(),
+		"version_to":   versionDiff.To.Core().String(),
+		"reasons":      strings.Join(status.Reasons, ", "),
+		"packages":     strings.Join(status.Packages, ", "),
+	}
+	message := fmt.Sprintf("host requires a reboot after DoubleZero sync v%s -> v%s: %s",
+		versionDiff.From.Core().String(), versionDiff.To.Core().String(), strings.Join(status.Reasons, ", "))
+
+	now := dz.clock.Now()
+	_, inWindow := dz.windows.Open(now)
+	switch {
+	case dz.rebootConfig.Policy != constants.RebootPolicyCommand || syncErr != nil:
+	case ctx.Err() != nil:
+		logger.Warn("shutting down - deferring the reboot command to the next cycle")
+		fields["reboot_command"] = "shutting_down"
+		message += " - reboot command deferred to the next cycle, doublezero-version-sync was shutting down"
+		dz.deferReboot(logger, versionDiff, status)
+	case !inWindow:
+		// the sync ran past the end of its window - hold the reboot for the next cycle inside one
+		logger.Warn("sync finished outside sync.windows - deferring the reboot command to the next window")
+		fields["reboot_command"] = "outside_window"
+		message += " - reboot command deferred to the next cycle inside sync.windows"
+		if next := dz.windows.NextOpen(now); !next.IsZero() {
+			fields["reboot_command_due"] = next.UTC().Format(time.RFC3339)
+			message += fmt.Sprintf(", next window opens %s", next.UTC().Format(time.RFC3339))
+		}
+		dz.deferReboot(logger, versionDiff, status)
+	default:
+		// this reboot supersedes any reboot an earlier sync left pending
+		message += dz.runRebootCommand(ctx, logger, versionDiff, fields)
+		dz.clearPendingReboot(logger)
+	}
+
+	dz.notifier.Notify(notify.Event{
+		Type:     notify.EventRebootRequired,
+		Severity: constants.NotificationSeverityWarning,
+		Message:  message,
+		Fields:   fields,
+	})
+}
+
+// runPendingReboot runs the reboot command deferred by an earlier sync once a cycle finishes inside sync.windows. The
+// pending reboot is dropped without running the command when the host no longer requires a reboot (e.g. an operator
+// rebooted it), and isn't retried when the command fails. Simulated cycles leave it pending
+func (dz *DoubleZero) runPendingReboot(ctx context.Context, logger *log.Logger, rep *report.Report) {
+	if dz.simulate || dz.rebootConfig.Policy != constants.RebootPolicyCommand || ctx.Err() != nil {
+		return
+	}
+	st, err := dz.stateStore.Load()
+	if err != nil {
+		logger.Warn("failed to read pending reboot", "error", err)
+		return
+	}
+	pending := st.PendingReboot
+	if pending == nil {
+		return
+	}
+	if _, inWindow := dz.windows.Open(dz.clock.Now()); !inWindow {
+		logger.Debug("outside sync.windows - reboot still pending", "required_at", pending.RequiredAt)
+		return
+	}
+
+	status, err := dz.rebootChecker.Check()
+	if err != nil {
+		logger.Warn("failed to check whether the pending reboot is still required", "error", err)
+		return
+	}
+	defer dz.clearPendingReboot(logger)
+	if !status.Required {
+		logger.Info("host no longer requires a reboot - dropping the pending reboot", "required_at", pending.RequiredAt)
+		return
+	}
+	rep.Reboot = &status
+
+	from, fromErr := version.NewVersion(pending.FromVersion)
+	to, toErr := version.NewVersion(pending.ToVersion)
+	if fromErr != nil || toErr != nil {
+		logger.Warn("invalid pending reboot versions - dropping it", "from", pending.FromVersion, "to", pending.ToVersion)
+		return
+	}
+	versionDiff := versiondiff.VersionDiff{From: from, To: to}
+	fields := map[string]string{
+		"version_from": versionDiff.From.Core().String(),
+		"version_to":   versionDiff.To.Core().String(),
+		"reasons":      strings.Join(status.Reasons, ", "),
+		"packages":     strings.Join(status.Packages, ", "),
+		"required_at":  pending.RequiredAt.UTC().Format(time.RFC3339),
+	}
+	message := fmt.Sprintf("host still requires a reboot after DoubleZero sync v%s -> v%s: %s - deferred",
+		versionDiff.From.Core().String(), versionDiff.To.Core().String(), strings.Join(status.Reasons, ", "))
+	message += dz.runRebootCommand(ctx, logger, versionDiff, fields)
+
+	dz.notifier.Notify(notify.Event{
+		Type:     notify.EventRebootRequired,
+		Severity: constants.NotificationSeverityWarning,
+		Message:  message,
+		Fields:   fields,
+	})
+}
+
+// runRebootCommand runs the reboot command for the version change, recording it in the audit log and its status in
+// fields, and returns the suffix describing its result for the notification message
+func (dz *DoubleZero) runRebootCommand(ctx context.Context, logger *log.Logger, versionDiff versiondiff.VersionDiff, fields map[string]string) string {
+	data := dz.commandTemplateData(versionDiff, 0, 1)
+	result, err := dz.rebootConfig.Command.ExecuteWithData(ctx, data)
+	dz.recordAudit(logger, audit.KindReboot, data, result, err)
+	fields["reboot_command"] = result.Status
+	if err != nil {
+		logger.Error("reboot command failed", "error", err)
+		fields["reboot_command_error"] = err.Error()
+		return " - reboot command failed"
+	}
+	logger.Info("reboot command ran", "status", result.Status)
+	return " - reboot command ran"
+}
+
+// deferReboot persists the reboot the version change requires as pending, replacing any earlier one
+func (dz *DoubleZero) deferReboot(logger *log.Logger, versionDiff versiondiff.VersionDiff, status reboot.Status) {
+	err := dz.stateStore.Update(func(st *state.State) error {
+		st.PendingReboot = &state.PendingReboot{
+			RequiredAt:  dz.clock.Now().UTC(),
+			FromVersion: versionDiff.From.Original(),
+			ToVersion:   versionDiff.To.Original(),
+			Reasons:     status.Reasons,
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Warn("failed to record pending reboot", "error", err)
+	}
+}
+
+// clearPendingReboot removes the pending reboot from the state, if any
+func (dz *DoubleZero) clearPendingReboot(logger *log.Logger) {
+	err := dz.stateStore.Update(func(st *state.State) error {
+		st.PendingReboot = nil
+		return nil
+	})
+	if err != nil {
+		logger.Warn("failed to clear pending reboot", "error", err)
+	}
+}
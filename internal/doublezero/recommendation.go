@@ -0,0 +1,103 @@
+package doublezero
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+// recordRecommendation compares the recommendation against the previously observed one and records it,
+// returning how many consecutive cycles have now returned it and when the first of them observed it
+// A recommendation lower than the previous one is anomalous - it raises a critical notification and blocks the sync
+// unless sync.allow_recommendation_rollback is set. A blocked rollback is not recorded so it keeps being blocked.
+// Simulated cycles evaluate against the stored state without updating it or notifying.
+func (dz *DoubleZero) recordRecommendation(logger *log.Logger, recommendation *versionsource.Recommendation) (consecutive int, firstObservedAt time.Time, err error) {
+	var blockedErr error
+
+	record := func(st *state.State) error {
+		previous := st.LastRecommendation
+		if previous != nil {
+			previousVersion, err := version.NewVersion(previous.PackageVersion)
+			if err != nil {
+				return fmt.Errorf("failed to parse previous recommendation %s from state: %w", previous.PackageVersion, err)
+			}
+
+			// the full package version is compared so an older release of the same version (e.g. 0.6.3-2 to 0.6.3-1)
+			// is a rollback too
+			if recommendation.Version.LessThan(previousVersion) {
+				message := fmt.Sprintf("recommended DoubleZero version went backwards from %s to %s",
+					previousVersion.Original(), recommendation.Version.Original())
+
+				// only notify once per rollback target
+				if st.RollbackNotifiedFor != recommendation.PackageVersion && !dz.simulate {
+					st.RollbackNotifiedFor = recommendation.PackageVersion
+					dz.notifier.Notify(notify.Event{
+						Type:     notify.EventRecommendationRollback,
+						Severity: constants.NotificationSeverityCritical,
+						Message:  message,
+						Fields: map[string]string{
+							"previous_version":    previous.PackageVersion,
+							"previous_source":     previous.Source,
+							"recommended_version": recommendation.PackageVersion,
+							"source":              recommendation.Source,
+						},
+					})
+				}
+
+				if !dz.syncConfig.AllowRecommendationRollback {
+					logger.Error(message + " - refusing to act")
+					blockedErr = fmt.Errorf("%s (set sync.allow_recommendation_rollback=true to allow)", message)
+					return nil
+				}
+				logger.Warn(message + " - proceeding (sync.allow_recommendation_rollback=true)")
+			}
+		}
+
+		// count consecutive cycles returning the same recommendation
+		firstObservedAt = recommendation.FetchedAt
+		if previous != nil && previous.PackageVersion == recommendation.PackageVersion {
+			st.ConsecutiveRecommendations++
+			firstObservedAt = previous.FirstObservedAt
+			if firstObservedAt.IsZero() {
+				// recorded before first observations were tracked
+				firstObservedAt = previous.ObservedAt
+			}
+		} else {
+			st.ConsecutiveRecommendations = 1
+		}
+		consecutive = st.ConsecutiveRecommendations
+
+		st.LastRecommendation = &state.Recommendation{
+			PackageVersion:  recommendation.PackageVersion,
+			Source:          recommendation.Source,
+			ObservedAt:      recommendation.FetchedAt,
+			FirstObservedAt: firstObservedAt,
+		}
+		return nil
+	}
+
+	if dz.simulate {
+		st, err := dz.stateStore.Load()
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("failed to load state: %w", err)
+		}
+		err = record(&st)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		return consecutive, firstObservedAt, blockedErr
+	}
+
+	err = dz.stateStore.Update(record)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	return consecutive, firstObservedAt, blockedErr
+}
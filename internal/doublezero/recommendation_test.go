@@ -0,0 +1,104 @@
+package doublezero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+func TestRecordRecommendation(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// recommendations are recommended on consecutive cycles an hour apart
+		recommendations []string
+		simulate        bool
+		allowRollback   bool
+		// wantConsecutive is the count after each cycle, 0 when the recommendation is a blocked rollback
+		wantConsecutive []int
+		// wantFirstObserved is how many cycles in the last recommendation was first observed
+		wantFirstObserved int
+	}{
+		{
+			name:            "same recommendation counts up",
+			recommendations: []string{"0.8.1-1", "0.8.1-1", "0.8.1-1"},
+			wantConsecutive: []int{1, 2, 3},
+		},
+		{
+			name:              "new recommendation restarts the count",
+			recommendations:   []string{"0.8.1-1", "0.8.1-1", "0.8.2-1", "0.8.2-1"},
+			wantConsecutive:   []int{1, 2, 1, 2},
+			wantFirstObserved: 2,
+		},
+		{
+			name:            "new release of the same version restarts the count",
+			recommendations: []string{"0.8.1-1", "0.8.1-2"},
+			wantConsecutive: []int{1, 1},
+			// the second cycle
+			wantFirstObserved: 1,
+		},
+		{
+			name:            "blocked rollback isn't recorded",
+			recommendations: []string{"0.8.2-1", "0.8.1-1", "0.8.1-1", "0.8.2-1"},
+			wantConsecutive: []int{1, 0, 0, 2},
+		},
+		{
+			name:            "older release of the same version is a blocked rollback",
+			recommendations: []string{"0.6.3-2", "0.6.3-1", "0.6.3-2"},
+			wantConsecutive: []int{1, 0, 2},
+		},
+		{
+			name:            "newer release of the same version isn't a rollback",
+			recommendations: []string{"0.6.3-9", "0.6.3-10"},
+			wantConsecutive: []int{1, 1},
+			// the second cycle
+			wantFirstObserved: 1,
+		},
+		{
+			name:              "allowed rollback restarts the count",
+			recommendations:   []string{"0.8.2-1", "0.8.1-1", "0.8.1-1"},
+			allowRollback:     true,
+			wantConsecutive:   []int{1, 1, 2},
+			wantFirstObserved: 1,
+		},
+		{
+			name:            "simulated cycles don't count",
+			recommendations: []string{"0.8.1-1", "0.8.1-1", "0.8.1-1"},
+			simulate:        true,
+			wantConsecutive: []int{1, 1, 1},
+			// each cycle observes it first
+			wantFirstObserved: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dz, _ := newTestDoubleZero(t, start)
+			dz.simulate = tt.simulate
+			dz.syncConfig.AllowRecommendationRollback = tt.allowRollback
+
+			var firstObservedAt time.Time
+			for i, packageVersion := range tt.recommendations {
+				recommendation := &versionsource.Recommendation{
+					Version:        version.Must(version.NewVersion(packageVersion)),
+					PackageVersion: packageVersion,
+					Source:         "static",
+					FetchedAt:      start.Add(time.Duration(i) * time.Hour),
+				}
+				consecutive, observedAt, err := dz.recordRecommendation(dz.logger, recommendation)
+				if blocked := err != nil; blocked != (tt.wantConsecutive[i] == 0) {
+					t.Fatalf("recordRecommendation(%s) on cycle %d error = %v, want blocked %v", packageVersion, i+1, err, !blocked)
+				}
+				if consecutive != tt.wantConsecutive[i] {
+					t.Errorf("recordRecommendation(%s) on cycle %d consecutive = %d, want %d", packageVersion, i+1, consecutive, tt.wantConsecutive[i])
+				}
+				firstObservedAt = observedAt
+			}
+			if want := start.Add(time.Duration(tt.wantFirstObserved) * time.Hour); !firstObservedAt.Equal(want) {
+				t.Errorf("firstObservedAt = %s, want %s", firstObservedAt, want)
+			}
+		})
+	}
+}
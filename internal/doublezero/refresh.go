@@ -0,0 +1,86 @@
+package doublezero
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// newRefreshReposCommand returns the package cache refresh command of the package manager
+func newRefreshReposCommand(packageManager string) sync_commands.Command {
+	if packageManager == constants.PackageManagerDnf {
+		return sync_commands.Command{Name: "refresh repos", Cmd: "dnf", Args: []string{"makecache"}}
+	}
+	return sync_commands.Command{
+		Name:        "refresh repos",
+		Cmd:         "apt-get",
+		Args:        []string{"update"},
+		Environment: map[string]string{"DEBIAN_FRONTEND": "noninteractive"},
+	}
+}
+
+// refreshRepos refreshes the package cache before the sync commands run, unless it was refreshed within
+// sync.refresh_repos.min_interval by any cycle. Only a successful refresh is recorded in the state, so a failed one is
+// retried by the next cycle. Simulated cycles report whether it would run without running it
+func (dz *DoubleZero) refreshRepos(ctx context.Context, logger *log.Logger, rep *report.Report, data sync_commands.CommandTemplateData) error {
+	if dz.refreshReposCommand == nil {
+		rep.AddGate(report.GateRefreshRepos, report.VerdictSkip, "package cache refresh disabled")
+		return nil
+	}
+
+	refreshLogger := logging.WithPrefix(logger, "refresh_repos")
+	command := dz.refreshReposCommand
+	commandLine := strings.Join(append([]string{command.Cmd}, command.Args...), " ")
+	minInterval := dz.syncConfig.RefreshRepos.MinInterval
+
+	st, err := dz.stateStore.Load()
+	if err != nil {
+		err = fmt.Errorf("failed to load the last package cache refresh: %w", err)
+		rep.AddGate(report.GateRefreshRepos, report.VerdictFail, "%s", err)
+		return err
+	}
+	if st.LastRepoRefresh != nil {
+		if since := dz.clock.Now().Sub(*st.LastRepoRefresh); since < minInterval {
+			refreshLogger.Debug("package cache refreshed recently - skipping", "refreshed_at", st.LastRepoRefresh.Format(time.RFC3339), "min_interval", minInterval)
+			rep.AddGate(report.GateRefreshRepos, report.VerdictSkip, "refreshed %s ago, within sync.refresh_repos.min_interval (%s)",
+				since.Round(time.Second), minInterval)
+			return nil
+		}
+	}
+
+	if dz.simulate {
+		refreshLogger.Info("would refresh package cache", "cmd", commandLine)
+		rep.AddGate(report.GateRefreshRepos, report.VerdictSkip, "simulation - would run %s", commandLine)
+		return nil
+	}
+
+	refreshLogger.Info("refreshing package cache", "cmd", commandLine)
+	refreshedAt := dz.clock.Now().UTC()
+	result, err := command.ExecuteWithData(ctx, data)
+	dz.recordAudit(refreshLogger, audit.KindRefreshRepos, data, result, err)
+	if err != nil {
+		err = fmt.Errorf("package cache refresh failed: %w", err)
+		rep.AddGate(report.GateRefreshRepos, report.VerdictFail, "%s", err)
+		return err
+	}
+
+	// a refresh that isn't recorded only runs again sooner, so it doesn't fail the sync
+	err = dz.stateStore.Update(func(st *state.State) error {
+		st.LastRepoRefresh = &refreshedAt
+		return nil
+	})
+	if err != nil {
+		refreshLogger.Warn("failed to record package cache refresh", "error", err)
+	}
+	rep.AddGate(report.GateRefreshRepos, report.VerdictPass, "ran %s, next refresh after %s", commandLine, minInterval)
+	return nil
+}
@@ -0,0 +1,57 @@
+package doublezero
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// rollbackDiff returns the version change undoing versionDiff. The installed binary only reports its version, so the
+// package version is the one the installed version was last synced to when the state has it
+func (dz *DoubleZero) rollbackDiff(versionDiff versiondiff.VersionDiff) versiondiff.VersionDiff {
+	rollbackDiff := versiondiff.VersionDiff{From: versionDiff.To, To: versionDiff.From}
+	st, err := dz.stateStore.Load()
+	if err != nil || st.LastSync == nil || st.LastSync.Result != report.OutcomeSynced {
+		return rollbackDiff
+	}
+	if synced, err := version.NewVersion(st.LastSync.ToVersion); err == nil && synced.Core().Equal(versionDiff.From.Core()) {
+		rollbackDiff.To = synced
+	}
+	return rollbackDiff
+}
+
+// rollback re-runs the sync commands targeting the version installed before the sync, after a command with
+// on_failure: rollback failed. It stops at the first rollback command that fails unless that command continues on
+// failure - a failed rollback isn't rolled back again. Returns the package version rolled back to
+func (dz *DoubleZero) rollback(ctx context.Context, logger *log.Logger, rep *report.Report, versionDiff versiondiff.VersionDiff) (string, error) {
+	rollbackDiff := dz.rollbackDiff(versionDiff)
+	rollbackLogger := logging.WithPrefix(logger, "rollback")
+	rollbackLogger.Warn("rolling back", "version_to", rollbackDiff.To.Original())
+
+	commandsCount := len(dz.syncConfig.Commands)
+	for cmd_i, cmd := range dz.syncConfig.Commands {
+		cmd.SetLogger(rollbackLogger)
+		data := dz.commandTemplateData(rollbackDiff, cmd_i, commandsCount)
+		result, err := cmd.ExecuteWithData(ctx, data)
+		dz.recordAudit(rollbackLogger, audit.KindRollback, data, result, err)
+		name := fmt.Sprintf("%s (rollback)", result.Name)
+		if result.Execution != nil {
+			rep.AddExecutedCommand(name, result.Status, result.Execution.ExitCode, result.Execution.FinishedAt.Sub(result.Execution.StartedAt))
+		} else {
+			rep.AddCommand(name, result.Status)
+		}
+		if err != nil {
+			rollbackLogger.Error("rollback failed", "command", cmd.Name, "error", err)
+			return rollbackDiff.To.Original(), fmt.Errorf("command %s failed: %w", cmd.Name, err)
+		}
+	}
+
+	rollbackLogger.Info("rolled back", "version_to", rollbackDiff.To.Original())
+	return rollbackDiff.To.Original(), nil
+}
@@ -0,0 +1,27 @@
+package doublezero
+
+import (
+	"context"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+// commandContext returns the context commands run on, done runtime.shutdown_grace_period on dz.clock after ctx - a
+// command running when the daemon is asked to shut down gets the grace period to finish before it is killed. The
+// returned cancel function must be called once the commands ran
+func (dz *DoubleZero) commandContext(ctx context.Context, logger *log.Logger) (context.Context, context.CancelFunc) {
+	commandCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		logger.Warn("shutting down - letting a running command finish", "grace_period", dz.shutdownGracePeriod.String())
+		// the commands finishing first cancels commandCtx, ending the wait
+		if clock.SleepContext(commandCtx, dz.clock, dz.shutdownGracePeriod) == nil {
+			logger.Error("shutdown grace period elapsed - killing the running command", "grace_period", dz.shutdownGracePeriod.String())
+			cancel()
+		}
+	})
+	return commandCtx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,74 @@
+package doublezero
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+// gatedClock is a fake clock whose Sleep reports the duration on slept and only returns once release is closed
+type gatedClock struct {
+	*clock.Fake
+	slept   chan time.Duration
+	release chan struct{}
+}
+
+func (c *gatedClock) Sleep(d time.Duration) {
+	c.slept <- d
+	<-c.release
+	c.Advance(d)
+}
+
+func TestCommandContext(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("grace period elapsed", func(t *testing.T) {
+		dz, _ := newTestDoubleZero(t, start)
+		dz.shutdownGracePeriod = time.Minute
+		ctx, shutdown := context.WithCancel(context.Background())
+		commandCtx, done := dz.commandContext(ctx, dz.logger)
+		defer done()
+
+		if commandCtx.Err() != nil {
+			t.Fatal("command context done before shutdown")
+		}
+		shutdown()
+		select {
+		case <-commandCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("command context not done once the grace period elapsed")
+		}
+		if waited := dz.clock.Now().Sub(start); waited != time.Minute {
+			t.Errorf("waited %s on the clock, want the grace period", waited)
+		}
+	})
+
+	t.Run("commands finish within the grace period", func(t *testing.T) {
+		dz, _ := newTestDoubleZero(t, start)
+		dz.shutdownGracePeriod = time.Minute
+		gated := &gatedClock{Fake: clock.NewFake(start), slept: make(chan time.Duration, 1), release: make(chan struct{})}
+		defer close(gated.release)
+		dz.clock = gated
+		ctx, shutdown := context.WithCancel(context.Background())
+		commandCtx, done := dz.commandContext(ctx, dz.logger)
+
+		shutdown()
+		select {
+		case d := <-gated.slept:
+			if d != time.Minute {
+				t.Errorf("grace period slept %s, want %s", d, time.Minute)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("grace period not started on shutdown")
+		}
+		if commandCtx.Err() != nil {
+			t.Fatal("command context done during the grace period")
+		}
+		done()
+		if commandCtx.Err() == nil {
+			t.Error("command context not done once the commands ran")
+		}
+	})
+}
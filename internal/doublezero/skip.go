@@ -0,0 +1,87 @@
+package doublezero
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+// checkSkipped returns the operator skip matching the recommended version, nil when it isn't skipped
+// Skips of versions older than the recommendation are dropped - a newer recommendation supersedes them. The first time
+// a skip prevents a sync it is notified. Simulated cycles evaluate against the stored state without updating it or notifying.
+func (dz *DoubleZero) checkSkipped(logger *log.Logger, recommendation *versionsource.Recommendation) (*state.SkippedVersion, error) {
+	var matched *state.SkippedVersion
+
+	record := func(st *state.State) error {
+		kept := st.SkippedVersions[:0]
+		for _, skip := range st.SkippedVersions {
+			skipVersion, err := version.NewVersion(skip.Version)
+			if err == nil && skipVersion.Core().LessThan(recommendation.Version.Core()) {
+				logger.Info("dropping skip superseded by a newer recommendation", "skipped_version", skip.Version, "recommended_version", recommendation.PackageVersion)
+				continue
+			}
+
+			if matched == nil && versiondiff.Matches(skip.Version, recommendation.Version) {
+				if !skip.Notified && !dz.simulate {
+					skip.Notified = true
+					dz.notifier.Notify(notify.Event{
+						Type:     notify.EventVersionSkipped,
+						Severity: constants.NotificationSeverityWarning,
+						Message:  fmt.Sprintf("not syncing to recommended DoubleZero version %s - skipped by operator: %s", recommendation.PackageVersion, skipReason(&skip)),
+						Fields: map[string]string{
+							"skipped_version":     skip.Version,
+							"recommended_version": recommendation.PackageVersion,
+							"reason":              skip.Reason,
+						},
+					})
+				}
+				matchedSkip := skip
+				matched = &matchedSkip
+			}
+			kept = append(kept, skip)
+		}
+		st.SkippedVersions = kept
+		return nil
+	}
+
+	if dz.simulate {
+		st, err := dz.stateStore.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load state: %w", err)
+		}
+		if err := record(&st); err != nil {
+			return nil, err
+		}
+		return matched, nil
+	}
+
+	if err := dz.stateStore.Update(record); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	return matched, nil
+}
+
+// configSkippedVersion returns the doublezero.skip_versions entry matching the recommended version, if any
+func (dz *DoubleZero) configSkippedVersion(recommendation *versionsource.Recommendation) (string, bool) {
+	for _, skipVersion := range dz.doubleZeroConfig.SkipVersions {
+		if versiondiff.Matches(skipVersion, recommendation.Version) {
+			return skipVersion, true
+		}
+	}
+	return "", false
+}
+
+// skipReason returns the reason of a skip for display
+func skipReason(skip *state.SkippedVersion) string {
+	if skip.Reason == "" {
+		return "no reason given"
+	}
+	return skip.Reason
+}
@@ -0,0 +1,159 @@
+package doublezero
+
+import (
+	"context"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/freeze"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// Status is a read-only view of the installed and recommended versions, the validator and the last sync
+type Status struct {
+	// Cluster is the configured cluster
+	Cluster string `json:"cluster"`
+	// InstalledVersion is the installed DoubleZero version, empty when it can't be read
+	InstalledVersion string `json:"installed_version,omitempty"`
+	// InstalledError is why the installed version can't be read
+	InstalledError string `json:"installed_error,omitempty"`
+	// Recommendation is the recommended version for the cluster, nil when it can't be fetched
+	Recommendation *StatusRecommendation `json:"recommendation,omitempty"`
+	// RecommendationError is why the recommended version can't be fetched
+	RecommendationError string `json:"recommendation_error,omitempty"`
+	// Direction is upgrade, downgrade or no change from the installed to the recommended version, empty when either is
+	// unknown
+	Direction string `json:"direction,omitempty"`
+	// VersionConstraint is the doublezero.version_constraint evaluation of the recommended version, nil without one
+	VersionConstraint *StatusConstraint `json:"version_constraint,omitempty"`
+	// Freeze is the change freeze period in effect, deferring version changes until it ends - nil outside one
+	Freeze *freeze.Period `json:"freeze,omitempty"`
+	// FreezeError is why the freeze calendars can't be read
+	FreezeError string `json:"freeze_error,omitempty"`
+	// RepoLag is the target package the sync.verify_published repository didn't publish on the last check, nil when it
+	// did or isn't checked
+	RepoLag *report.RepoLag `json:"repo_lag,omitempty"`
+	// Validator is the validator identity state, nil without a validator configured
+	Validator *StatusValidator `json:"validator,omitempty"`
+	// LastSync is the last sync cycle that ran the sync commands
+	LastSync *state.SyncAttempt `json:"last_sync,omitempty"`
+	// LastCycle is the outcome of the last sync cycle
+	LastCycle *StatusCycle `json:"last_cycle,omitempty"`
+	// PendingApproval is the sync plan awaiting approvals under sync.approval
+	PendingApproval *state.PendingApproval `json:"pending_approval,omitempty"`
+	// PendingReboot is the reboot command reboot.policy command deferred to the next cycle inside sync.windows
+	PendingReboot *state.PendingReboot `json:"pending_reboot,omitempty"`
+	// StateError is why the state file can't be read
+	StateError string `json:"state_error,omitempty"`
+}
+
+// StatusRecommendation is the recommended version in its core and package forms
+type StatusRecommendation struct {
+	Version        string `json:"version"`
+	PackageVersion string `json:"package_version"`
+	Source         string `json:"source"`
+}
+
+// StatusConstraint is the evaluation of doublezero.version_constraint against the recommended version
+type StatusConstraint struct {
+	Constraint string `json:"constraint"`
+	Satisfied  bool   `json:"satisfied"`
+}
+
+// StatusValidator is the identity the validator runs as and its configured role
+type StatusValidator struct {
+	Identity string `json:"identity,omitempty"`
+	// Role is active, passive or unknown
+	Role  string `json:"role,omitempty"`
+	Error string `json:"error,omitempty"`
+	// IdentitySource is where the identity was read from, one of rpc, file, static
+	IdentitySource string `json:"identity_source"`
+}
+
+// StatusCycle is the outcome of a sync cycle
+type StatusCycle struct {
+	FinishedAt time.Time `json:"finished_at"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Status observes the installed and recommended versions and the validator identity, and reads the last sync from the
+// state file - nothing is executed, notified or recorded. Failures are reported in the status rather than returned
+func (dz *DoubleZero) Status(ctx context.Context) *Status {
+	status := &Status{Cluster: dz.State.Cluster}
+	versionDiff := versiondiff.VersionDiff{}
+
+	installedVersion, err := dz.getInstalledVersion()
+	if err != nil {
+		status.InstalledError = err.Error()
+	} else {
+		versionDiff.From = installedVersion
+		status.InstalledVersion = installedVersion.Original()
+	}
+
+	recommendation, err := dz.versionSource.GetRecommendation(ctx)
+	if err != nil {
+		status.RecommendationError = err.Error()
+	} else {
+		versionDiff.To = recommendation.Version
+		status.Recommendation = &StatusRecommendation{
+			Version:        recommendation.Version.Core().String(),
+			PackageVersion: recommendation.PackageVersion,
+			Source:         recommendation.Source,
+		}
+		if constraint := dz.doubleZeroConfig.ParsedVersionConstraint; constraint != nil {
+			status.VersionConstraint = &StatusConstraint{
+				Constraint: constraint.String(),
+				Satisfied:  constraint.Check(recommendation.Version.Core()),
+			}
+		}
+	}
+
+	if versionDiff.From != nil && versionDiff.To != nil {
+		status.Direction = versionDiff.Direction()
+	}
+
+	if dz.freeze != nil {
+		status.Freeze, err = dz.freeze.Active(ctx, dz.clock.Now())
+		if err != nil {
+			status.FreezeError = err.Error()
+		}
+	}
+
+	if dz.identitySource != nil {
+		if dz.validatorRPCClient != nil {
+			dz.validatorRPCClient.ResetCache()
+		}
+		status.Validator = &StatusValidator{IdentitySource: dz.identitySource.Type()}
+		validatorIdentity, err := dz.identitySource.Identity(ctx)
+		if err != nil {
+			status.Validator.Error = err.Error()
+		} else {
+			status.Validator.Identity = validatorIdentity
+			status.Validator.Role, err = dz.identities.Role(validatorIdentity)
+			if err != nil {
+				status.Validator.Error = err.Error()
+			}
+		}
+	}
+
+	st, err := dz.stateStore.Load()
+	if err != nil {
+		status.StateError = err.Error()
+		return status
+	}
+	status.LastSync = st.LastSync
+	status.RepoLag = st.RepoLag
+	status.PendingReboot = st.PendingReboot
+	if dz.syncConfig.Approval.Enabled {
+		status.PendingApproval = st.PendingApproval
+	}
+	if st.LastReport != nil {
+		status.LastCycle = &StatusCycle{FinishedAt: st.LastReport.FinishedAt, Outcome: st.LastReport.Outcome,
+			Reason: st.LastReport.Reason, Error: st.LastReport.Error}
+	}
+
+	return status
+}
@@ -0,0 +1,35 @@
+package doublezero
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+)
+
+// nextSteppingStone returns the lowest doublezero.stepping_stones release an upgrade must pass through before reaching
+// its target - one above the installed version and below the target - nil when the upgrade can go straight there or
+// it isn't an upgrade
+func (dz *DoubleZero) nextSteppingStone(versionDiff versiondiff.VersionDiff) *version.Version {
+	if versionDiff.Direction() != versiondiff.DirectionUpgrade {
+		return nil
+	}
+	for _, steppingStone := range dz.doubleZeroConfig.ParsedSteppingStones {
+		if steppingStone.Core().GreaterThan(versionDiff.From.Core()) && steppingStone.Core().LessThan(versionDiff.To.Core()) {
+			return steppingStone
+		}
+	}
+	return nil
+}
+
+// steppingStones returns the hops of an upgrade from one version to another through each stepping stone in between,
+// the last one to the target
+func (dz *DoubleZero) steppingStones(versionDiff versiondiff.VersionDiff) []versiondiff.VersionDiff {
+	var hops []versiondiff.VersionDiff
+	for {
+		steppingStone := dz.nextSteppingStone(versionDiff)
+		if steppingStone == nil {
+			return append(hops, versionDiff)
+		}
+		hops = append(hops, versiondiff.VersionDiff{From: versionDiff.From, To: steppingStone})
+		versionDiff.From = steppingStone
+	}
+}
@@ -0,0 +1,130 @@
+package duration
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Day is the duration of the d unit
+	Day = 24 * time.Hour
+	// Week is the duration of the w unit
+	Week = 7 * Day
+)
+
+// longUnits are the units Parse accepts on top of time.ParseDuration's
+var longUnits = map[string]time.Duration{"d": Day, "w": Week}
+
+// Parse parses a duration like time.ParseDuration - a sequence of decimal numbers each with a unit, e.g. 90s, 15m or
+// 4h30m - also accepting the d (24h) and w (7d) units, e.g. 1d or 1w2d12h, and a bare 0
+func Parse(s string) (time.Duration, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	if s == "0" || s == "+0" || s == "-0" {
+		return 0, nil
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	if s == "" {
+		return 0, invalid(original)
+	}
+
+	var total time.Duration
+	for s != "" {
+		numberEnd := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if numberEnd <= 0 {
+			return 0, invalid(original)
+		}
+		unitEnd := strings.IndexFunc(s[numberEnd:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if unitEnd < 0 {
+			unitEnd = len(s) - numberEnd
+		}
+		number, unit := s[:numberEnd], s[numberEnd:numberEnd+unitEnd]
+		s = s[numberEnd+unitEnd:]
+
+		var part time.Duration
+		if multiplier, ok := longUnits[unit]; ok {
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil || value*float64(multiplier) > math.MaxInt64 {
+				return 0, invalid(original)
+			}
+			part = time.Duration(value * float64(multiplier))
+		} else {
+			parsed, err := time.ParseDuration(number + unit)
+			if err != nil {
+				return 0, invalid(original)
+			}
+			part = parsed
+		}
+
+		if total > math.MaxInt64-part {
+			return 0, invalid(original)
+		}
+		total += part
+	}
+
+	if negative {
+		return -total, nil
+	}
+	return total, nil
+}
+
+// invalid returns the error of a duration that doesn't parse
+func invalid(s string) error {
+	return fmt.Errorf("invalid duration %q - want numbers with a unit of ns, us, ms, s, m, h, d or w, e.g. 90s, 15m, 4h30m or 1d", s)
+}
+
+// Format formats a duration like time.Duration.String, with whole days as d, e.g. 1d12h0m0s or 2d
+func Format(d time.Duration) string {
+	if d < Day && d > -Day {
+		return d.String()
+	}
+
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	days, rest := d/Day, d%Day
+	if rest == 0 {
+		return fmt.Sprintf("%s%dd", sign, days)
+	}
+	return fmt.Sprintf("%s%dd%s", sign, days, rest)
+}
+
+// Value is a command line flag value of a duration accepting Parse's units
+type Value struct {
+	d *time.Duration
+}
+
+// NewValue returns a flag value setting d, initialized to value
+func NewValue(value time.Duration, d *time.Duration) *Value {
+	*d = value
+	return &Value{d: d}
+}
+
+// Set parses s into the duration
+func (v *Value) Set(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*v.d = parsed
+	return nil
+}
+
+// String returns the duration, a bare 0 when unset so usage doesn't show it as a default
+func (v *Value) String() string {
+	if v.d == nil || *v.d == 0 {
+		return "0"
+	}
+	return Format(*v.d)
+}
+
+// Type returns the name of the flag value type shown in usage
+func (v *Value) Type() string {
+	return "duration"
+}
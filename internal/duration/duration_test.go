@@ -0,0 +1,67 @@
+package duration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "90s", want: 90 * time.Second},
+		{input: "15m", want: 15 * time.Minute},
+		{input: "4h30m", want: 4*time.Hour + 30*time.Minute},
+		{input: "1d", want: Day},
+		{input: "1.5d", want: 36 * time.Hour},
+		{input: "1w2d12h", want: Week + 2*Day + 12*time.Hour},
+		{input: "250ms", want: 250 * time.Millisecond},
+		{input: " 2h ", want: 2 * time.Hour},
+		{input: "-1d", want: -Day},
+		{input: "0", want: 0},
+		{input: "", wantErr: true},
+		{input: "d", wantErr: true},
+		{input: "1", wantErr: true},
+		{input: "5x", wantErr: true},
+		{input: "1d-2h", wantErr: true},
+		{input: "1..5d", wantErr: true},
+		{input: "99999999w", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 90 * time.Second, want: "1m30s"},
+		{d: Day, want: "1d"},
+		{d: Day + 12*time.Hour, want: "1d12h0m0s"},
+		{d: -2 * Day, want: "-2d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := Format(tt.d); got != tt.want {
+				t.Errorf("Format(%s) = %s, want %s", tt.d, got, tt.want)
+			}
+			if parsed, err := Parse(Format(tt.d)); err != nil || parsed != tt.d {
+				t.Errorf("Parse(Format(%s)) = %s, %v, want the duration back", tt.d, parsed, err)
+			}
+		})
+	}
+}
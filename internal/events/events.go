@@ -0,0 +1,165 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+)
+
+// Type is the type of a sync lifecycle event
+type Type string
+
+const (
+	// CycleStarted is published when a sync cycle starts
+	CycleStarted Type = "cycle_started"
+	// SyncStarted is published when a cycle is about to run the sync commands
+	SyncStarted Type = "sync_started"
+	// CommandFinished is published after each sync command runs, with its result
+	CommandFinished Type = "command_finished"
+	// CycleFinished is published when a sync cycle finishes, with its decision report
+	CycleFinished Type = "cycle_finished"
+	// Notification is published for every notification raised, whether or not a notifier delivers it
+	Notification Type = "notification"
+	// OutOfBandChange is published when the doublezero binary changed between cycles without the syncer changing it,
+	// with the installed version before the change as FromVersion and after it as ToVersion
+	OutOfBandChange Type = "out_of_band_change"
+)
+
+// Event is a sync lifecycle event
+type Event struct {
+	// Type is the event type
+	Type Type
+	// Time is when the event was published
+	Time time.Time
+	// Cluster is the cluster the syncer runs on
+	Cluster string
+	// Simulated is true for events of simulated and dry run cycles
+	Simulated bool
+	// FromVersion is the installed version, set once known
+	FromVersion string
+	// ToVersion is the target package version, set once known
+	ToVersion string
+	// Command is the sync command result of CommandFinished events
+	Command *report.CommandResult
+	// Report is the decision report of CycleFinished events
+	Report *report.Report
+	// Err is the error of failed CommandFinished and CycleFinished events
+	Err error
+	// Notification is the notification of Notification events
+	Notification *notify.Event
+}
+
+// Bus delivers published events to its subscribers - embedders subscribe through the Manager
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]func(Event)
+}
+
+// NewBus creates a new Bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: map[int]func(Event){}}
+}
+
+// Subscribe calls handler with every event published until the returned unsubscribe function is called
+// Handlers are called in the publishing goroutine, in the order events are published, and must not block
+func (b *Bus) Subscribe(handler func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Channel returns a channel receiving every event published until the returned cancel function is called, which
+// closes it. Events published while the channel's buffer is full are dropped rather than blocking the sync
+func (b *Bus) Channel(buffer int) (<-chan Event, func()) {
+	subscriber := &channelSubscriber{ch: make(chan Event, buffer)}
+	unsubscribe := b.Subscribe(subscriber.send)
+
+	var once sync.Once
+	return subscriber.ch, func() {
+		once.Do(func() {
+			unsubscribe()
+			subscriber.close()
+		})
+	}
+}
+
+// Publish delivers the event to every subscriber
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	handlers := make([]func(Event), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Name returns the notifier name, the bus receives every notification as a Notification event
+func (b *Bus) Name() string {
+	return "events"
+}
+
+// MinSeverity returns the minimum severity of notifications published, all of them
+func (b *Bus) MinSeverity() string {
+	return constants.NotificationSeverityInfo
+}
+
+// Events returns the notification types published, all of them
+func (b *Bus) Events() []string {
+	return nil
+}
+
+// Notify publishes the notification as a Notification event
+func (b *Bus) Notify(notification notify.Event) error {
+	b.Publish(Event{
+		Type:         Notification,
+		Time:         notification.Time,
+		Cluster:      notification.Cluster,
+		Notification: &notification,
+	})
+	return nil
+}
+
+// channelSubscriber delivers events to a channel without blocking, until closed
+type channelSubscriber struct {
+	mu     sync.Mutex
+	ch     chan Event
+	closed bool
+}
+
+// send delivers the event unless the channel is closed or full
+func (s *channelSubscriber) send(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- event:
+	default:
+	}
+}
+
+// close closes the channel, later events are discarded
+func (s *channelSubscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+)
+
+func TestSubscribe(t *testing.T) {
+	bus := NewBus()
+
+	var got []Type
+	unsubscribe := bus.Subscribe(func(event Event) { got = append(got, event.Type) })
+	bus.Publish(Event{Type: CycleStarted})
+	if err := bus.Notify(notify.Event{Type: notify.EventSyncFailed}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	unsubscribe()
+	bus.Publish(Event{Type: CycleFinished})
+
+	if len(got) != 2 || got[0] != CycleStarted || got[1] != Notification {
+		t.Errorf("handler received %v, want [%s %s] before unsubscribing", got, CycleStarted, Notification)
+	}
+}
+
+func TestChannelDropsWhenFullAndClosesOnCancel(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Channel(1)
+
+	bus.Publish(Event{Type: CycleStarted})
+	// the buffer is full, this one is dropped rather than blocking
+	bus.Publish(Event{Type: SyncStarted})
+
+	if event := <-ch; event.Type != CycleStarted {
+		t.Errorf("received %s, want %s", event.Type, CycleStarted)
+	}
+
+	cancel()
+	cancel()
+	bus.Publish(Event{Type: CycleFinished})
+	if event, ok := <-ch; ok {
+		t.Errorf("received %s after cancel, want a closed channel", event.Type)
+	}
+}
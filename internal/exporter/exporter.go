@@ -0,0 +1,262 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/daemon"
+	"github.com/sol-strategies/doublezero-version-sync/internal/identity"
+	"github.com/sol-strategies/doublezero-version-sync/internal/installed"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/metrics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/reboot"
+	"github.com/sol-strategies/doublezero-version-sync/internal/rpc"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versiondiff"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+const (
+	// check names reported by the check_success metric
+	checkInstalledVersion   = "installed_version"
+	checkRecommendedVersion = "recommended_version"
+	checkValidatorIdentity  = "validator_identity"
+	checkValidatorHealth    = "validator_health"
+	checkDaemon             = "daemon"
+	checkReboot             = "reboot"
+)
+
+// Options represents the options for creating a new Exporter
+type Options struct {
+	// Config is the loaded configuration
+	Config *config.Config
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock drives the observation interval and timestamps, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport for the version source and validator RPC, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Exporter runs only the observation side of a sync cycle - installed version, recommended version, validator
+// identity and health, daemon status - and exposes the results as metrics. It never executes sync commands,
+// requests failovers, notifies or writes state.
+type Exporter struct {
+	cfg                *config.Config
+	logger             *log.Logger
+	clock              clock.Clock
+	bin                string
+	versionSource      versionsource.VersionSource
+	validatorRPCClient *rpc.Client
+	identities         *identity.Loader
+	identitySource     identity.Source
+	daemonChecker      *daemon.Checker
+	rebootChecker      *reboot.Checker
+	registry           *metrics.Registry
+
+	installedVersionInfo   *metrics.Gauge
+	recommendedVersionInfo *metrics.Gauge
+	upToDate               *metrics.Gauge
+	validatorIdentityInfo  *metrics.Gauge
+	validatorHealthy       *metrics.Gauge
+	daemonRunning          *metrics.Gauge
+	rebootRequired         *metrics.Gauge
+	checkSuccess           *metrics.Gauge
+	lastObservation        *metrics.Gauge
+}
+
+// New creates a new Exporter with the given options
+func New(opts Options) (e *Exporter, err error) {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
+	cfg := opts.Config
+	bin := cfg.DoubleZero.Bin
+	if bin == "" {
+		bin = "doublezero"
+	}
+
+	registry := metrics.NewRegistry()
+	e = &Exporter{
+		cfg:      cfg,
+		logger:   logging.WithPrefix(opts.Logger, "exporter"),
+		clock:    opts.Clock,
+		bin:      bin,
+		registry: registry,
+		daemonChecker: daemon.New(daemon.Options{
+			Check:       cfg.DoubleZero.Daemon.Check,
+			ProcessName: cfg.DoubleZero.Daemon.ProcessName,
+			SystemdUnit: cfg.DoubleZero.Daemon.SystemdUnit,
+			SocketPath:  cfg.DoubleZero.Daemon.SocketPath,
+			Logger:      opts.Logger,
+			Clock:       opts.Clock,
+		}),
+		rebootChecker: reboot.New(reboot.Options{
+			RequiredFiles: cfg.Reboot.RequiredFiles,
+			Logger:        opts.Logger,
+		}),
+
+		installedVersionInfo:   registry.NewGauge(metrics.Namespace+"installed_version_info", "Installed DoubleZero version, always 1.", "version"),
+		recommendedVersionInfo: registry.NewGauge(metrics.Namespace+"recommended_version_info", "Recommended DoubleZero version for the cluster, always 1.", "cluster", "version", "rpm_version", "source"),
+		upToDate:               registry.NewGauge(metrics.Namespace+"up_to_date", "1 if the installed version is the recommended version, 0 otherwise."),
+		validatorIdentityInfo:  registry.NewGauge(metrics.Namespace+"validator_identity_info", "Identity the validator is running as and its configured role (active, passive or unknown), always 1.", "identity", "role"),
+		validatorHealthy:       registry.NewGauge(metrics.Namespace+"validator_healthy", "1 if the validator RPC reports itself healthy, 0 otherwise."),
+		daemonRunning:          registry.NewGauge(metrics.Namespace+"daemon_running", "1 if the DoubleZero daemon check passes, 0 otherwise."),
+		rebootRequired:         registry.NewGauge(metrics.Namespace+"reboot_required", "1 if the host requires a reboot, 0 otherwise."),
+		checkSuccess:           registry.NewGauge(metrics.Namespace+"check_success", "1 if the named check succeeded on the last observation, 0 otherwise.", "check"),
+		lastObservation:        registry.NewGauge(metrics.Namespace+"last_observation_timestamp_seconds", "Unix time of the last observation."),
+	}
+
+	e.versionSource, err = versionsource.NewFromConfig(cfg.Cluster.Name, cfg.VersionSource, versionsource.Options{
+		Logger:    opts.Logger,
+		Clock:     opts.Clock,
+		Transport: opts.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the validator is only observed when its RPC URL or another identity source and at least the active identity are
+	// configured, its health only with the RPC URL
+	if cfg.Validator.IsEnabled() && cfg.Validator.Identities.IsConfigured() {
+		var rpcClient identity.RPCClient
+		if cfg.Validator.RPCURL != "" {
+			e.validatorRPCClient = rpc.NewClient(rpc.Options{
+				URL:                  cfg.Validator.RPCURL,
+				MaxRequestsPerSecond: cfg.Validator.RPCMaxRequestsPerSecond,
+				Logger:               opts.Logger,
+				Clock:                opts.Clock,
+				Transport:            opts.Transport,
+			})
+			rpcClient = e.validatorRPCClient
+		}
+		e.identitySource, err = identity.NewSourceFromConfig(cfg.Validator.IdentitySource, rpcClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up validator identity source: %w", err)
+		}
+		e.identities = identity.New(identity.Options{
+			ActiveFile:       cfg.Validator.Identities.ActiveKeyPairFile,
+			PassiveFile:      cfg.Validator.Identities.PassiveKeyPairFile,
+			ActivePublicKey:  cfg.Validator.Identities.ActivePubkey,
+			PassivePublicKey: cfg.Validator.Identities.PassivePubkey,
+			Logger:           opts.Logger,
+		})
+		if _, err = e.identities.Keys(); err != nil {
+			return nil, fmt.Errorf("failed to load validator identities: %w", err)
+		}
+	}
+
+	return e, nil
+}
+
+// Handler returns an http.Handler serving the exported metrics
+func (e *Exporter) Handler() http.Handler {
+	return e.registry.Handler()
+}
+
+// Run observes on the given interval until ctx is done, the first observation is made immediately
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	e.logger.Info("🔭 starting doublezero-version-sync exporter", "interval", interval.String())
+	for {
+		e.Observe(ctx)
+		if err := clock.SleepContext(ctx, e.clock, interval); err != nil {
+			return
+		}
+	}
+}
+
+// Observe runs every check once and updates the metrics - failures are logged and reported as failed checks
+func (e *Exporter) Observe(ctx context.Context) {
+	installedVersion, output, err := installed.GetVersion(e.bin)
+	e.checkSuccess.SetBool(err == nil, checkInstalledVersion)
+	e.installedVersionInfo.Reset()
+	if err != nil {
+		e.logger.Warn("failed to get installed DoubleZero version", "bin", e.bin, "error", err)
+	} else {
+		e.logger.Debug("found installed version from bin", "bin", e.bin, "version", installedVersion.String(), "output", output)
+		e.installedVersionInfo.Set(1, installedVersion.Original())
+	}
+
+	recommendation, err := e.versionSource.GetRecommendation(ctx)
+	e.checkSuccess.SetBool(err == nil, checkRecommendedVersion)
+	e.recommendedVersionInfo.Reset()
+	if err != nil {
+		e.logger.Warn("failed to get recommended DoubleZero version", "error", err)
+	} else {
+		e.recommendedVersionInfo.Set(1, e.cfg.Cluster.Name, recommendation.PackageVersion, recommendation.RPMPackageVersion, recommendation.Source)
+	}
+
+	e.upToDate.Reset()
+	if installedVersion != nil && recommendation != nil {
+		e.upToDate.SetBool(versiondiff.VersionDiff{From: installedVersion, To: recommendation.Version}.IsSameVersion())
+	}
+
+	if e.identitySource != nil {
+		e.observeValidator(ctx)
+	}
+
+	if e.daemonChecker.IsEnabled() {
+		err := e.daemonChecker.CheckRunning(ctx)
+		if err != nil {
+			e.logger.Warn("doublezero daemon check failed", "error", err)
+		}
+		e.checkSuccess.SetBool(err == nil, checkDaemon)
+		e.daemonRunning.SetBool(err == nil)
+	}
+
+	if e.cfg.Reboot.IsEnabled() {
+		status, err := e.rebootChecker.Check()
+		if err != nil {
+			e.logger.Warn("failed to check whether a reboot is required", "error", err)
+		}
+		e.checkSuccess.SetBool(err == nil, checkReboot)
+		e.rebootRequired.SetBool(status.Required)
+	}
+
+	e.lastObservation.Set(float64(e.clock.Now().Unix()))
+}
+
+// observeValidator updates the validator identity and health metrics
+func (e *Exporter) observeValidator(ctx context.Context) {
+	// each observation must hit the validator, not the previous observation's cache
+	if e.validatorRPCClient != nil {
+		e.validatorRPCClient.ResetCache()
+	}
+
+	identity, err := e.identitySource.Identity(ctx)
+	e.checkSuccess.SetBool(err == nil, checkValidatorIdentity)
+	e.validatorIdentityInfo.Reset()
+	if err != nil {
+		e.logger.Warn("failed to get validator identity", "error", err)
+	} else {
+		e.validatorIdentityInfo.Set(1, identity, e.identityRole(identity))
+	}
+
+	// health is only known to the validator RPC
+	if e.validatorRPCClient == nil {
+		return
+	}
+	err = e.validatorRPCClient.GetHealth(ctx)
+	if err != nil {
+		e.logger.Warn("validator is not healthy", "error", err)
+	}
+	e.checkSuccess.SetBool(err == nil, checkValidatorHealth)
+	e.validatorHealthy.SetBool(err == nil)
+}
+
+// identityRole returns the configured role of the given validator identity
+func (e *Exporter) identityRole(validatorIdentity string) string {
+	role, err := e.identities.Role(validatorIdentity)
+	if err != nil {
+		e.logger.Warn("failed to load validator identities", "error", err)
+	}
+	return role
+}
@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/rpc"
+)
+
+// writeBin writes a fake doublezero binary printing the given version and returns its path.
+func writeBin(t *testing.T, installedVersion string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero "+installedVersion+"\"\n"), 0o755); err != nil {
+		t.Fatalf("failed to write fake bin: %v", err)
+	}
+	return bin
+}
+
+// writeKeypair writes a solana-keygen keypair file for the given private key and returns its path.
+func writeKeypair(t *testing.T, privateKey solana.PrivateKey) string {
+	t.Helper()
+	keypair := make([]int, len(privateKey))
+	for i, b := range privateKey {
+		keypair[i] = int(b)
+	}
+	contents, err := json.Marshal(keypair)
+	if err != nil {
+		t.Fatalf("failed to marshal keypair: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), privateKey.PublicKey().String()+".json")
+	if err := os.WriteFile(file, contents, 0o600); err != nil {
+		t.Fatalf("failed to write keypair: %v", err)
+	}
+	return file
+}
+
+// newValidatorServer returns a test validator RPC answering getIdentity and getHealth.
+func newValidatorServer(identity string, healthy bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpc.JSONRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		resp := rpc.JSONRPCResponse{JSONRPC: "2.0", ID: 1}
+		switch {
+		case req.Method == "getIdentity":
+			resp.Result = map[string]interface{}{"identity": identity}
+		case healthy:
+			resp.Result = "ok"
+		default:
+			resp.Error = &rpc.RPCError{Code: -32005, Message: "Node is behind"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestObserve(t *testing.T) {
+	active := solana.NewWallet().PrivateKey
+	passive := solana.NewWallet().PrivateKey
+
+	validator := newValidatorServer(passive.PublicKey().String(), false)
+	defer validator.Close()
+
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: writeBin(t, "0.6.9")},
+		VersionSource: config.VersionSource{
+			Type:    constants.VersionSourceTypeStatic,
+			Version: "0.7.1-1",
+		},
+		Validator: config.Validator{
+			RPCURL: validator.URL,
+			Identities: config.Identities{
+				ActiveKeyPairFile:  writeKeypair(t, active),
+				PassiveKeyPairFile: writeKeypair(t, passive),
+			},
+		},
+	}
+
+	observedAt := time.Date(2025, 1, 5, 2, 0, 0, 0, time.UTC)
+	e, err := New(Options{Config: cfg, Clock: clock.NewFake(observedAt)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.Observe(context.Background())
+
+	rec := httptest.NewRecorder()
+	e.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`doublezero_version_sync_installed_version_info{version="0.6.9"} 1`,
+		`doublezero_version_sync_recommended_version_info{cluster="testnet",version="0.7.1-1",rpm_version="",source="static"} 1`,
+		`doublezero_version_sync_up_to_date 0`,
+		`doublezero_version_sync_validator_identity_info{identity="` + passive.PublicKey().String() + `",role="passive"} 1`,
+		`doublezero_version_sync_validator_healthy 0`,
+		`doublezero_version_sync_check_success{check="validator_health"} 0`,
+		`doublezero_version_sync_check_success{check="installed_version"} 1`,
+		`doublezero_version_sync_last_observation_timestamp_seconds 1.7360424e+09`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics missing %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "daemon_running") {
+		t.Errorf("daemon_running exported without a daemon check configured:\n%s", body)
+	}
+	if strings.Contains(body, "reboot_required") {
+		t.Errorf("reboot_required exported without a reboot policy configured:\n%s", body)
+	}
+}
@@ -0,0 +1,304 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+)
+
+const (
+	// BeaconPath is the path a host serves its beacon on
+	BeaconPath = "/fleet/beacon"
+	// SignatureHeader is the response header carrying the beacon's HMAC-SHA256 signature, as sha256=<hex>
+	SignatureHeader = "X-Beacon-Signature"
+	// DefaultTimeout is the maximum time a peer may take to serve its beacon when no timeout is configured
+	DefaultTimeout = 5 * time.Second
+	// maxBeaconSize caps the size of a peer beacon
+	maxBeaconSize = 64 * 1024
+)
+
+// Beacon is what a host shares with its peers - its installed version and the outcome of its last sync
+type Beacon struct {
+	// Host is the hostname of the host
+	Host string `json:"host"`
+	// Cluster is the configured cluster
+	Cluster string `json:"cluster"`
+	// InstalledVersion is the installed DoubleZero version as of the last cycle, empty before the first one finished
+	InstalledVersion string `json:"installed_version,omitempty"`
+	// LastSync is the last cycle that ran the sync commands, absent when none did since the process started
+	LastSync *BeaconSync `json:"last_sync,omitempty"`
+	// SentAt is when the beacon was served
+	SentAt time.Time `json:"sent_at"`
+}
+
+// BeaconSync is the outcome of a cycle that ran the sync commands
+type BeaconSync struct {
+	ToVersion  string    `json:"to_version"`
+	Result     string    `json:"result"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// SucceededOn returns true if the host runs target and its last sync to target, if any, didn't fail
+func (b *Beacon) SucceededOn(target *version.Version) bool {
+	if !sameCore(b.InstalledVersion, target) {
+		return false
+	}
+	return b.LastSync == nil || b.LastSync.Result != report.OutcomeFailed || !sameCore(b.LastSync.ToVersion, target)
+}
+
+// sameCore returns true if v is a version with the same core (major.minor.patch) as target
+func sameCore(v string, target *version.Version) bool {
+	parsed, err := version.NewVersion(v)
+	return err == nil && parsed.Core().Equal(target.Core())
+}
+
+// ReadSecret reads the shared secret signing beacons from file, ignoring surrounding whitespace
+func ReadSecret(file string) ([]byte, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet secret: %w", err)
+	}
+	secret := bytes.TrimSpace(contents)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("fleet secret file %s is empty", file)
+	}
+	return secret, nil
+}
+
+// sign returns the signature of body with secret, as sha256=<hex>
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publisher serves this host's beacon, updated from the report of every sync cycle
+type Publisher struct {
+	secret []byte
+	clock  clock.Clock
+
+	mu     sync.Mutex
+	beacon Beacon
+}
+
+// NewPublisher creates a new beacon Publisher for the host in cluster, signing beacons with secret
+func NewPublisher(host, cluster string, secret []byte, clk clock.Clock) *Publisher {
+	if clk == nil {
+		clk = clock.Real{}
+	}
+	return &Publisher{
+		secret: secret,
+		clock:  clk,
+		beacon: Beacon{Host: host, Cluster: cluster},
+	}
+}
+
+// RecordCycle updates the beacon from the report of a finished cycle - simulations are ignored
+func (p *Publisher) RecordCycle(rep *report.Report) {
+	if rep == nil || rep.Simulated {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if rep.InstalledVersion != "" {
+		p.beacon.InstalledVersion = rep.InstalledVersion
+	}
+	if len(rep.Commands) == 0 {
+		return
+	}
+	p.beacon.LastSync = &BeaconSync{ToVersion: rep.TargetVersion(), Result: rep.Outcome, FinishedAt: rep.FinishedAt}
+	if rep.Outcome == report.OutcomeSynced {
+		p.beacon.InstalledVersion = rep.TargetVersion()
+	}
+}
+
+// Handler returns an http.Handler serving the signed beacon as JSON
+func (p *Publisher) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		beacon := p.beacon
+		p.mu.Unlock()
+		beacon.SentAt = p.clock.Now().UTC()
+
+		body, err := json.Marshal(beacon)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(SignatureHeader, sign(p.secret, body))
+		_, _ = w.Write(body)
+	})
+}
+
+// Options represents the options for creating a new peer Exchange
+type Options struct {
+	// Peers are the beacon URLs of the peers, e.g. http://10.0.0.2:9843/fleet/beacon
+	Peers []string
+	// Secret is the shared secret beacons are signed with
+	Secret []byte
+	// Cluster is the configured cluster, beacons of other clusters are rejected
+	Cluster string
+	// MaxBeaconAge is how old a beacon may be, beacons older than this are rejected
+	MaxBeaconAge time.Duration
+	// Timeout is the maximum time a peer may take to serve its beacon, defaults to DefaultTimeout
+	Timeout time.Duration
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock checks beacon ages, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport used for peers, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// PeerStatus is the beacon of a peer, or why it couldn't be read
+type PeerStatus struct {
+	Peer   string
+	Beacon *Beacon
+	Err    error
+}
+
+// Exchange reads the beacons of the configured peers
+type Exchange struct {
+	peers        []string
+	secret       []byte
+	cluster      string
+	maxBeaconAge time.Duration
+	logger       *log.Logger
+	clock        clock.Clock
+	client       *http.Client
+}
+
+// New creates a new peer Exchange
+func New(opts Options) *Exchange {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Exchange{
+		peers:        opts.Peers,
+		secret:       opts.Secret,
+		cluster:      opts.Cluster,
+		maxBeaconAge: opts.MaxBeaconAge,
+		logger:       logging.WithPrefix(opts.Logger, "fleet"),
+		clock:        opts.Clock,
+		client:       &http.Client{Timeout: opts.Timeout, Transport: opts.Transport},
+	}
+}
+
+// Poll reads the beacons of all peers concurrently, in peer order
+func (e *Exchange) Poll(ctx context.Context) []PeerStatus {
+	statuses := make([]PeerStatus, len(e.peers))
+	var wg sync.WaitGroup
+	for i, peer := range e.peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			beacon, err := e.fetch(ctx, peer)
+			statuses[i] = PeerStatus{Peer: peer, Beacon: beacon, Err: err}
+			if err != nil {
+				e.logger.Warn("failed to read peer beacon", "peer", peer, "error", err)
+			} else {
+				e.logger.Debug("read peer beacon", "peer", peer, "host", beacon.Host, "installed_version", beacon.InstalledVersion)
+			}
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// fetch reads and verifies the beacon of a peer
+func (e *Exchange) fetch(ctx context.Context, peer string) (*Beacon, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("beacon returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBeaconSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read beacon: %w", err)
+	}
+	signature := resp.Header.Get(SignatureHeader)
+	if !hmac.Equal([]byte(signature), []byte(sign(e.secret, body))) {
+		return nil, errors.New("beacon signature is missing or invalid")
+	}
+
+	var beacon Beacon
+	if err := json.Unmarshal(body, &beacon); err != nil {
+		return nil, fmt.Errorf("failed to parse beacon: %w", err)
+	}
+	if beacon.Cluster != e.cluster {
+		return nil, fmt.Errorf("beacon is for cluster %s, not %s", beacon.Cluster, e.cluster)
+	}
+	if age := e.clock.Now().Sub(beacon.SentAt); e.maxBeaconAge > 0 && age > e.maxBeaconAge {
+		return nil, fmt.Errorf("beacon sent at %s is older than %s", beacon.SentAt.Format(time.RFC3339), e.maxBeaconAge)
+	}
+
+	return &beacon, nil
+}
+
+// Quorum polls the peers and returns the hosts reporting success on target, and a summary of the others
+func (e *Exchange) Quorum(ctx context.Context, target *version.Version) (succeeded []string, others []string) {
+	for _, status := range e.Poll(ctx) {
+		switch {
+		case status.Err != nil:
+			others = append(others, fmt.Sprintf("%s: %s", status.Peer, status.Err))
+		case status.Beacon.SucceededOn(target):
+			succeeded = append(succeeded, status.Beacon.Host)
+		default:
+			installed := status.Beacon.InstalledVersion
+			if installed == "" {
+				installed = "unknown"
+			}
+			others = append(others, fmt.Sprintf("%s: on %s", status.Beacon.Host, installed))
+		}
+	}
+	return succeeded, others
+}
+
+// Summary joins the peer summaries returned by Quorum for logging and reports
+func Summary(hosts []string) string {
+	if len(hosts) == 0 {
+		return "none"
+	}
+	return strings.Join(hosts, ", ")
+}
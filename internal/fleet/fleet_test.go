@@ -0,0 +1,78 @@
+package fleet
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+)
+
+// newPeer returns a test server serving the beacon of a host that ran a cycle with the given report
+func newPeer(t *testing.T, host, cluster string, secret []byte, clk clock.Clock, rep *report.Report) *httptest.Server {
+	publisher := NewPublisher(host, cluster, secret, clk)
+	publisher.RecordCycle(rep)
+	server := httptest.NewServer(publisher.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// syncedReport is the report of a cycle that ran the sync commands from one version to another with the given outcome
+func syncedReport(from, to, outcome string) *report.Report {
+	return &report.Report{
+		InstalledVersion: from,
+		Recommendation:   &report.Recommendation{PackageVersion: to},
+		Commands:         []report.CommandResult{{Name: "install"}},
+		Outcome:          outcome,
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	secret := []byte("fleet-secret")
+	clk := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	target := version.Must(version.NewVersion("0.8.0-1"))
+
+	upgraded := newPeer(t, "upgraded", "testnet", secret, clk, syncedReport("0.7.1", "0.8.0-1", report.OutcomeSynced))
+	alreadyOn := newPeer(t, "already-on", "testnet", secret, clk, &report.Report{InstalledVersion: "0.8.0", Outcome: report.OutcomeNothingToDo})
+	failed := newPeer(t, "failed", "testnet", secret, clk, syncedReport("0.7.1", "0.8.0-1", report.OutcomeFailed))
+	behind := newPeer(t, "behind", "testnet", secret, clk, &report.Report{InstalledVersion: "0.7.1", Outcome: report.OutcomeNothingToDo})
+	otherSecret := newPeer(t, "other-secret", "testnet", []byte("wrong"), clk, syncedReport("0.7.1", "0.8.0-1", report.OutcomeSynced))
+	otherCluster := newPeer(t, "other-cluster", "mainnet-beta", secret, clk, syncedReport("0.7.1", "0.8.0-1", report.OutcomeSynced))
+
+	exchange := New(Options{
+		Peers:        []string{upgraded.URL, alreadyOn.URL, failed.URL, behind.URL, otherSecret.URL, otherCluster.URL},
+		Secret:       secret,
+		Cluster:      "testnet",
+		MaxBeaconAge: 5 * time.Minute,
+		Clock:        clk,
+	})
+
+	succeeded, others := exchange.Quorum(context.Background(), target)
+	if len(succeeded) != 2 || succeeded[0] != "upgraded" || succeeded[1] != "already-on" {
+		t.Errorf("Quorum() succeeded = %v, want [upgraded already-on]", succeeded)
+	}
+	if len(others) != 4 {
+		t.Errorf("Quorum() others = %v, want the failed, behind, wrongly signed and other cluster peers", others)
+	}
+
+	// beacons are only fresh for max_beacon_age
+	stale := New(Options{Peers: []string{upgraded.URL}, Secret: secret, Cluster: "testnet", MaxBeaconAge: time.Minute, Clock: clock.NewFake(clk.Now().Add(time.Hour))})
+	if succeeded, _ := stale.Quorum(context.Background(), target); len(succeeded) != 0 {
+		t.Errorf("Quorum() succeeded = %v for a stale beacon, want none", succeeded)
+	}
+}
+
+func TestRecordCycle_IgnoresSimulations(t *testing.T) {
+	publisher := NewPublisher("host", "testnet", []byte("secret"), nil)
+	publisher.RecordCycle(&report.Report{InstalledVersion: "0.7.1", Outcome: report.OutcomeNothingToDo})
+	rep := syncedReport("0.7.1", "0.8.0-1", report.OutcomeWouldSync)
+	rep.Simulated = true
+	publisher.RecordCycle(rep)
+
+	if publisher.beacon.InstalledVersion != "0.7.1" || publisher.beacon.LastSync != nil {
+		t.Errorf("beacon = %+v, want the simulation ignored", publisher.beacon)
+	}
+}
@@ -0,0 +1,217 @@
+package freeze
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTimeout is the maximum time a calendar URL may take to respond when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+// Period is a time-boxed change freeze, during which version changes are deferred
+type Period struct {
+	// Name is the name of the freeze, e.g. year-end change freeze
+	Name string `json:"name"`
+	// Start is when the freeze starts
+	Start time.Time `json:"start"`
+	// End is when the freeze ends, exclusive
+	End time.Time `json:"end"`
+	// Source is where the period is defined - config or the calendar file or URL
+	Source string `json:"source,omitempty"`
+}
+
+// Contains returns true if t is inside the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// ParsePeriod parses a period from its name and start and end times, each RFC3339 (e.g. 2025-12-15T00:00:00Z) or a
+// UTC date (e.g. 2025-12-15) - a date-only end includes that whole day
+func ParsePeriod(name, start, end, source string) (Period, error) {
+	period := Period{Name: name, Source: source}
+	if name == "" {
+		return period, fmt.Errorf("name is required")
+	}
+
+	var err error
+	if period.Start, _, err = parseTime(start); err != nil {
+		return period, fmt.Errorf("start: %w", err)
+	}
+	var dateOnly bool
+	if period.End, dateOnly, err = parseTime(end); err != nil {
+		return period, fmt.Errorf("end: %w", err)
+	}
+	if dateOnly {
+		period.End = period.End.AddDate(0, 0, 1)
+	}
+	if !period.End.After(period.Start) {
+		return period, fmt.Errorf("end %s must be after start %s", end, start)
+	}
+	return period, nil
+}
+
+// parseTime parses an RFC3339 time or a UTC date, returning whether it was a date
+func parseTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("must be an RFC3339 time or a date (2006-01-02) - got: %s", value)
+	}
+	return t, false, nil
+}
+
+// Options represents the options for creating a new Calendar
+type Options struct {
+	// Periods are the freeze periods defined in the config
+	Periods []Period
+	// Calendars are YAML or iCalendar (.ics) files or http(s) URLs listing freeze periods, read on every check
+	Calendars []string
+	// Timeout is the maximum time a calendar URL may take to respond, defaults to DefaultTimeout
+	Timeout time.Duration
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Transport is the HTTP transport used for calendar URLs, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Calendar is the change freeze calendar - the periods from the config and the imported calendars
+type Calendar struct {
+	periods    []Period
+	calendars  []string
+	timeout    time.Duration
+	logger     *log.Logger
+	httpClient *http.Client
+}
+
+// New creates a new freeze Calendar
+func New(opts Options) *Calendar {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Calendar{
+		periods:    opts.Periods,
+		calendars:  opts.Calendars,
+		timeout:    opts.Timeout,
+		logger:     logging.WithPrefix(opts.Logger, "freeze"),
+		httpClient: &http.Client{Transport: opts.Transport},
+	}
+}
+
+// Active returns the freeze period in effect at now, the one ending last when several overlap, nil outside any
+// A calendar that can't be read or parsed is an error, as it may hold a freeze in effect
+func (c *Calendar) Active(ctx context.Context, now time.Time) (*Period, error) {
+	periods, err := c.Periods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var active *Period
+	for _, period := range periods {
+		if period.Contains(now) && (active == nil || period.End.After(active.End)) {
+			active = &period
+		}
+	}
+	c.logger.Debug("checked change freeze", "periods", len(periods), "active", active != nil)
+	return active, nil
+}
+
+// Periods returns the periods from the config followed by those of each calendar
+func (c *Calendar) Periods(ctx context.Context) ([]Period, error) {
+	periods := append([]Period{}, c.periods...)
+	for _, calendar := range c.calendars {
+		contents, err := c.read(ctx, calendar)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := Parse(contents, calendar)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse freeze calendar %s: %w", calendar, err)
+		}
+		periods = append(periods, parsed...)
+	}
+	return periods, nil
+}
+
+// read returns the contents of a calendar file or URL
+func (c *Calendar) read(ctx context.Context, calendar string) ([]byte, error) {
+	if !IsURL(calendar) {
+		contents, err := os.ReadFile(calendar)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read freeze calendar: %w", err)
+		}
+		return contents, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, calendar, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create freeze calendar request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch freeze calendar %s: %w", calendar, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("freeze calendar %s responded with unexpected status %d", calendar, resp.StatusCode)
+	}
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read freeze calendar %s: %w", calendar, err)
+	}
+	return contents, nil
+}
+
+// IsURL returns true if the calendar is an http(s) URL rather than a file
+func IsURL(calendar string) bool {
+	return strings.HasPrefix(calendar, "http://") || strings.HasPrefix(calendar, "https://")
+}
+
+// yamlCalendar is the structure of a YAML freeze calendar
+type yamlCalendar struct {
+	Periods []struct {
+		Name  string `yaml:"name"`
+		Start string `yaml:"start"`
+		End   string `yaml:"end"`
+	} `yaml:"periods"`
+}
+
+// Parse parses the freeze periods of a calendar - iCalendar when it starts with BEGIN:VCALENDAR, otherwise YAML with a
+// periods list of name, start and end like freeze.periods
+func Parse(contents []byte, source string) ([]Period, error) {
+	if strings.HasPrefix(strings.TrimSpace(string(contents)), "BEGIN:VCALENDAR") {
+		return parseICal(string(contents), source)
+	}
+
+	var calendar yamlCalendar
+	if err := yaml.Unmarshal(contents, &calendar); err != nil {
+		return nil, err
+	}
+	periods := make([]Period, 0, len(calendar.Periods))
+	for i, entry := range calendar.Periods {
+		period, err := ParsePeriod(entry.Name, entry.Start, entry.End, source)
+		if err != nil {
+			return nil, fmt.Errorf("periods[%d]: %w", i, err)
+		}
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
@@ -0,0 +1,172 @@
+package freeze
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		period    string
+		start     string
+		end       string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "dates include the end day",
+			period:    "year-end",
+			start:     "2025-12-15",
+			end:       "2026-01-04",
+			wantStart: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "RFC3339 times",
+			period:    "launch",
+			start:     "2025-06-01T12:00:00Z",
+			end:       "2025-06-01T18:00:00+02:00",
+			wantStart: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 6, 1, 16, 0, 0, 0, time.UTC),
+		},
+		{name: "missing name", start: "2025-12-15", end: "2025-12-16", wantErr: true},
+		{name: "invalid start", period: "x", start: "15/12/2025", end: "2025-12-16", wantErr: true},
+		{name: "end before start", period: "x", start: "2025-12-15", end: "2025-12-14", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, err := ParsePeriod(tt.period, tt.start, tt.end, "config")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePeriod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !period.Start.Equal(tt.wantStart) || !period.End.Equal(tt.wantEnd) {
+				t.Errorf("ParsePeriod() = %s - %s, want %s - %s", period.Start, period.End, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		contents string
+		want     []Period
+		wantErr  bool
+	}{
+		{
+			name:     "yaml",
+			contents: "periods:\n  - name: year-end\n    start: 2025-12-15\n    end: 2026-01-04\n",
+			want:     []Period{{Name: "year-end", Start: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:     "yaml invalid period",
+			contents: "periods:\n  - name: year-end\n    start: 2025-12-15\n",
+			wantErr:  true,
+		},
+		{
+			name: "ical",
+			contents: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:Year-end change\r\n  freeze\\, all teams\r\nDTSTART;VALUE=DATE:20251215\r\nDTEND;VALUE=DATE:20260105\r\nEND:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:Launch\r\nDTSTART;TZID=America/New_York:20250601T090000\r\nDTEND:20250601T200000Z\r\nEND:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:Audit day\r\nDTSTART;VALUE=DATE:20250301\r\nEND:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:Cancelled\r\nSTATUS:CANCELLED\r\nDTSTART;VALUE=DATE:20250401\r\nEND:VEVENT\r\n" +
+				"END:VCALENDAR\r\n",
+			want: []Period{
+				{Name: "Year-end change freeze, all teams", Start: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+				{Name: "Launch", Start: time.Date(2025, 6, 1, 9, 0, 0, 0, newYork), End: time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)},
+				{Name: "Audit day", Start: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:     "ical event without start",
+			contents: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods, err := Parse([]byte(tt.contents), "calendar")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(periods) != len(tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", periods, tt.want)
+			}
+			for i, period := range periods {
+				want := tt.want[i]
+				if period.Name != want.Name || !period.Start.Equal(want.Start) || !period.End.Equal(want.End) || period.Source != "calendar" {
+					t.Errorf("Parse()[%d] = %+v, want %+v", i, period, want)
+				}
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	dir := t.TempDir()
+	calendarFile := filepath.Join(dir, "freeze.yaml")
+	if err := os.WriteFile(calendarFile, []byte("periods:\n  - name: year-end\n    start: 2025-12-15\n    end: 2026-01-04\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/freeze.ics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:holidays\nDTSTART;VALUE=DATE:20251220\nDTEND;VALUE=DATE:20260110\nEND:VEVENT\nEND:VCALENDAR\n"))
+	}))
+	defer server.Close()
+
+	launch, err := ParsePeriod("launch", "2025-06-01", "2025-06-01", "config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		calendars []string
+		now       time.Time
+		want      string
+		wantErr   bool
+	}{
+		{name: "outside every period", calendars: []string{calendarFile}, now: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "config period", now: time.Date(2025, 6, 1, 23, 59, 0, 0, time.UTC), want: "launch"},
+		{name: "calendar file", calendars: []string{calendarFile}, now: time.Date(2025, 12, 16, 0, 0, 0, 0, time.UTC), want: "year-end"},
+		{name: "overlap picks the one ending last", calendars: []string{calendarFile, server.URL + "/freeze.ics"}, now: time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC), want: "holidays"},
+		{name: "unavailable calendar", calendars: []string{server.URL + "/missing.ics"}, now: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := New(Options{Periods: []Period{launch}, Calendars: tt.calendars})
+			active, err := calendar.Active(context.Background(), tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Active() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := ""
+			if active != nil {
+				got = active.Name
+			}
+			if got != tt.want {
+				t.Errorf("Active() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package freeze
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// icalUnescaper unescapes iCalendar TEXT values
+var icalUnescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+// icalProperty is a content line of an iCalendar file
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseICal parses the events of an iCalendar file as freeze periods named by their SUMMARY. All-day events end at the
+// end of their DTEND date (exclusive) or last a day without one, cancelled events and events without an end are
+// ignored. Recurring events (RRULE) only count their first occurrence
+func parseICal(contents, source string) ([]Period, error) {
+	var periods []Period
+	var event []icalProperty
+	inEvent := false
+	for i, line := range unfoldICal(contents) {
+		property, err := parseICalLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch {
+		case property.name == "BEGIN" && property.value == "VEVENT":
+			inEvent, event = true, nil
+		case property.name == "END" && property.value == "VEVENT":
+			inEvent = false
+			period, ok, err := icalEventPeriod(event, source)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				periods = append(periods, period)
+			}
+		case inEvent:
+			event = append(event, property)
+		}
+	}
+	return periods, nil
+}
+
+// unfoldICal splits an iCalendar file into content lines, joining folded lines (continued with a leading space or tab)
+func unfoldICal(contents string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseICalLine parses a NAME;PARAM=VALUE:value content line
+func parseICalLine(line string) (icalProperty, error) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return icalProperty{}, fmt.Errorf("%q is not a content line", line)
+	}
+	parts := strings.Split(head, ";")
+	property := icalProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: value}
+	for _, param := range parts[1:] {
+		key, paramValue, _ := strings.Cut(param, "=")
+		property.params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+	return property, nil
+}
+
+// icalEventPeriod returns the freeze period of an event, false when it's cancelled or has no end
+func icalEventPeriod(event []icalProperty, source string) (Period, bool, error) {
+	period := Period{Source: source}
+	var start, end *icalProperty
+	for _, property := range event {
+		switch property.name {
+		case "SUMMARY":
+			period.Name = icalUnescaper.Replace(property.value)
+		case "DTSTART":
+			start = &property
+		case "DTEND":
+			end = &property
+		case "STATUS":
+			if strings.EqualFold(property.value, "CANCELLED") {
+				return period, false, nil
+			}
+		}
+	}
+	if period.Name == "" {
+		period.Name = "unnamed freeze"
+	}
+	if start == nil {
+		return period, false, fmt.Errorf("event %s has no DTSTART", period.Name)
+	}
+
+	var err error
+	var allDay bool
+	if period.Start, allDay, err = parseICalTime(start); err != nil {
+		return period, false, fmt.Errorf("event %s DTSTART: %w", period.Name, err)
+	}
+	switch {
+	case end != nil:
+		if period.End, _, err = parseICalTime(end); err != nil {
+			return period, false, fmt.Errorf("event %s DTEND: %w", period.Name, err)
+		}
+	case allDay:
+		period.End = period.Start.AddDate(0, 0, 1)
+	default:
+		return period, false, nil
+	}
+	return period, period.End.After(period.Start), nil
+}
+
+// parseICalTime parses a DATE or DATE-TIME value - UTC with a Z suffix, in its TZID time zone, otherwise taken as UTC -
+// returning whether it was a date
+func parseICalTime(property *icalProperty) (time.Time, bool, error) {
+	value := property.value
+	if property.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+
+	location := time.UTC
+	if tzid := property.params["TZID"]; tzid != "" && !strings.HasSuffix(value, "Z") {
+		var err error
+		if location, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %s: %w", tzid, err)
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", strings.TrimSuffix(value, "Z"), location)
+	return t, false, err
+}
@@ -0,0 +1,249 @@
+package healthchecks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// DefaultTimeout is the maximum time a check may run when it doesn't configure one
+const DefaultTimeout = 10 * time.Second
+
+const (
+	// maxOutputLength caps the command output quoted in a failure
+	maxOutputLength = 200
+	// maxBodyBytes caps the response body read when matching ExpectOutput
+	maxBodyBytes = 1024 * 1024
+)
+
+// Check is a read-only health probe, e.g. run before or after the sync commands
+// Exactly one of Cmd or URL must be set
+type Check struct {
+	// Name identifies the check in logs and reports
+	Name string `koanf:"name"`
+	// Cmd is a command that exits 0 when healthy
+	Cmd string `koanf:"cmd"`
+	// Args are the arguments passed to Cmd
+	Args []string `koanf:"args"`
+	// URL is fetched with a GET request, healthy when it responds with ExpectStatus
+	URL string `koanf:"url"`
+	// ExpectStatus is the HTTP status URL must respond with, defaults to any 2xx status
+	ExpectStatus int `koanf:"expect_status"`
+	// ExpectOutput is an optional regular expression the command output or response body must also match, e.g. up
+	ExpectOutput string `koanf:"expect_output"`
+	// Timeout is the maximum time the check may run, defaults to DefaultTimeout
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+// Probe returns a short description of what the check runs or fetches
+func (c Check) Probe() string {
+	if c.URL != "" {
+		return "GET " + c.URL
+	}
+	return strings.Join(append([]string{c.Cmd}, c.Args...), " ")
+}
+
+// Options represents the options for creating a new Checker
+type Options struct {
+	// Checks are the checks to run
+	Checks []Check
+	// Kind names the checks in logs and errors, e.g. pre-check or post-check
+	Kind string
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock is used when waiting for the checks to pass, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport used by URL checks, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Checker runs a set of health checks
+type Checker struct {
+	checks     []Check
+	kind       string
+	logger     *log.Logger
+	clock      clock.Clock
+	httpClient *http.Client
+}
+
+// New creates a new Checker, logging with the check kind as prefix (e.g. prechecks for pre-check)
+func New(opts Options) *Checker {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if opts.Kind == "" {
+		opts.Kind = "check"
+	}
+
+	return &Checker{
+		checks:     opts.Checks,
+		kind:       opts.Kind,
+		logger:     logging.WithPrefix(opts.Logger, strings.ReplaceAll(opts.Kind, "-", "")+"s"),
+		clock:      opts.Clock,
+		httpClient: &http.Client{Transport: opts.Transport},
+	}
+}
+
+// IsEnabled returns true if any checks are configured
+func (c *Checker) IsEnabled() bool {
+	return len(c.checks) > 0
+}
+
+// Count returns the number of configured checks
+func (c *Checker) Count() int {
+	return len(c.checks)
+}
+
+// Run runs every check, returning an error listing each failed check or nil when all passed
+// All checks run even after one fails so a single report covers everything unhealthy, unless ctx is done
+func (c *Checker) Run(ctx context.Context) error {
+	var failures []string
+	for _, check := range c.checks {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped running %ss: %w", c.kind, err)
+		}
+		if err := c.run(ctx, check); err != nil {
+			c.logger.Warn(c.kind+" failed", "name", check.Name, "probe", check.Probe(), "error", err)
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Name, err))
+			continue
+		}
+		c.logger.Debug(c.kind+" passed", "name", check.Name, "probe", check.Probe())
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d %ss failed - %s", len(failures), len(c.checks), c.kind, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// WaitHealthy runs the checks until they all pass or the timeout elapses, returning the last failure on timeout
+// The wait between attempts starts at retryInterval and doubles after each failed attempt up to maxRetryInterval
+// Returns the context's error once ctx is done
+func (c *Checker) WaitHealthy(ctx context.Context, timeout, retryInterval, maxRetryInterval time.Duration) error {
+	deadline := c.clock.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		err := c.Run(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("stopped waiting for %ss: %w", c.kind, ctxErr)
+		}
+		if !c.clock.Now().Add(retryInterval).Before(deadline) {
+			return fmt.Errorf("not healthy after %d attempts within %s: %w", attempt, timeout, err)
+		}
+
+		c.logger.Info(c.kind+"s not passing yet - retrying", "attempt", attempt, "retry_in", retryInterval.String(),
+			"remaining", deadline.Sub(c.clock.Now()).Truncate(time.Second).String())
+		if sleepErr := clock.SleepContext(ctx, c.clock, retryInterval); sleepErr != nil {
+			return fmt.Errorf("stopped waiting for %ss: %w", c.kind, sleepErr)
+		}
+		retryInterval = min(retryInterval*2, maxRetryInterval)
+	}
+}
+
+// run runs a single check with its timeout, within ctx
+func (c *Checker) run(parent context.Context, check Check) error {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var (
+		output string
+		err    error
+	)
+	if check.URL != "" {
+		output, err = c.runHTTP(ctx, check)
+	} else {
+		output, err = runCommand(ctx, check)
+	}
+
+	if err := parent.Err(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if check.ExpectOutput != "" {
+		expectOutput, err := regexp.Compile(check.ExpectOutput)
+		if err != nil {
+			return fmt.Errorf("invalid expect_output: %w", err)
+		}
+		if !expectOutput.MatchString(output) {
+			return fmt.Errorf("output does not match %q: %s", check.ExpectOutput, summarize(output))
+		}
+	}
+	return nil
+}
+
+// runHTTP fetches the check URL and compares the response status, returning the response body
+func (c *Checker) runHTTP(ctx context.Context, check Check) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	healthy := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if check.ExpectStatus != 0 {
+		healthy = resp.StatusCode == check.ExpectStatus
+	}
+	if !healthy {
+		want := "2xx"
+		if check.ExpectStatus != 0 {
+			want = fmt.Sprint(check.ExpectStatus)
+		}
+		return "", fmt.Errorf("got status %d, want %s", resp.StatusCode, want)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	return string(body), nil
+}
+
+// runCommand runs the check command, healthy when it exits 0, returning its combined output
+func runCommand(ctx context.Context, check Check) (string, error) {
+	output, err := exec.CommandContext(ctx, check.Cmd, check.Args...).CombinedOutput()
+	if err != nil {
+		if summary := summarize(string(output)); summary != "" {
+			return "", fmt.Errorf("%w: %s", err, summary)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// summarize quotes output on one line so failures read well in logs, reports and notifications
+func summarize(output string) string {
+	summary := strings.Join(strings.Fields(output), " ")
+	if len(summary) > maxOutputLength {
+		summary = summary[:maxOutputLength] + "..."
+	}
+	return summary
+}
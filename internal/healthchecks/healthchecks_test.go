@@ -0,0 +1,164 @@
+package healthchecks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthy":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"status":"up"}`))
+		case "/maintenance":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantErr    bool
+		wantFailed []string
+	}{
+		{
+			name: "all pass",
+			checks: []Check{
+				{Name: "agent", Cmd: "true"},
+				{Name: "health", URL: server.URL + "/healthy", ExpectOutput: `"status":"up"`},
+				{Name: "maintenance", URL: server.URL + "/maintenance", ExpectStatus: http.StatusServiceUnavailable},
+				{Name: "status", Cmd: "echo", Args: []string{"tunnel up"}, ExpectOutput: `\bup\b`},
+			},
+		},
+		{
+			name: "failing command, unexpected status and unexpected output are all reported",
+			checks: []Check{
+				{Name: "agent", Cmd: "sh", Args: []string{"-c", "echo tunnel down; exit 1"}},
+				{Name: "health", URL: server.URL + "/healthy"},
+				{Name: "missing", URL: server.URL + "/missing"},
+				{Name: "status", Cmd: "echo", Args: []string{"tunnel down"}, ExpectOutput: `\bup\b`},
+			},
+			wantErr: true,
+			wantFailed: []string{
+				"3 of 4 checks failed",
+				"agent: exit status 1: tunnel down",
+				"missing: got status 404, want 2xx",
+				`status: output does not match "\\bup\\b": tunnel down`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(Options{Checks: tt.checks}).Run(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantFailed {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Run() error = %q, want it to contain %q", err, want)
+				}
+			}
+			if err != nil && strings.Contains(err.Error(), "health:") {
+				t.Errorf("Run() error = %q, passing check reported as failed", err)
+			}
+		})
+	}
+}
+
+func TestWaitHealthy(t *testing.T) {
+	// the check passes once the marker file exists, which the fake clock creates on its third sleep
+	marker := filepath.Join(t.TempDir(), "up")
+	checks := []Check{{Name: "status", Cmd: "test", Args: []string{"-e", marker}}}
+
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		wantErr     bool
+		wantSleeps  []time.Duration
+		createAfter int
+	}{
+		{
+			name:        "converges with backoff",
+			timeout:     time.Minute,
+			createAfter: 3,
+			wantSleeps:  []time.Duration{5 * time.Second, 10 * time.Second, 12 * time.Second},
+		},
+		{
+			name:        "gives up at the timeout",
+			timeout:     20 * time.Second,
+			createAfter: 10,
+			wantErr:     true,
+			wantSleeps:  []time.Duration{5 * time.Second, 10 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(marker)
+			fakeClock := &sleepRecorder{Fake: clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))}
+			fakeClock.onSleep = func() {
+				if len(fakeClock.sleeps) == tt.createAfter {
+					if err := os.WriteFile(marker, nil, 0o644); err != nil {
+						t.Fatalf("failed to create marker: %v", err)
+					}
+				}
+			}
+
+			err := New(Options{Checks: checks, Kind: "post-check", Clock: fakeClock}).WaitHealthy(context.Background(), tt.timeout, 5*time.Second, 12*time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WaitHealthy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(fakeClock.sleeps) != len(tt.wantSleeps) {
+				t.Fatalf("WaitHealthy() slept %v, want %v", fakeClock.sleeps, tt.wantSleeps)
+			}
+			for i := range tt.wantSleeps {
+				if fakeClock.sleeps[i] != tt.wantSleeps[i] {
+					t.Errorf("WaitHealthy() slept %v, want %v", fakeClock.sleeps, tt.wantSleeps)
+					break
+				}
+			}
+		})
+	}
+}
+
+// sleepRecorder is a fake clock recording each sleep
+type sleepRecorder struct {
+	*clock.Fake
+	sleeps  []time.Duration
+	onSleep func()
+}
+
+func (s *sleepRecorder) Sleep(d time.Duration) {
+	s.sleeps = append(s.sleeps, d)
+	s.Fake.Sleep(d)
+	s.onSleep()
+}
+
+func TestWaitHealthyStopsWhenContextDone(t *testing.T) {
+	checks := []Check{{Name: "status", Cmd: "false"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	startedAt := time.Now()
+	err := New(Options{Checks: checks, Kind: "post-check"}).WaitHealthy(ctx, time.Hour, time.Minute, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitHealthy() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(startedAt); elapsed > 10*time.Second {
+		t.Errorf("WaitHealthy() returned after %s, want right after the context is done", elapsed)
+	}
+}
@@ -0,0 +1,121 @@
+package hostmaintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// DefaultTimeout is the maximum time the maintenance endpoint may take to respond when no timeout is configured
+const DefaultTimeout = 5 * time.Second
+
+// Status is whether the host is in maintenance mode and what signalled it
+type Status struct {
+	// Active is true when the host is in maintenance mode
+	Active bool `json:"active"`
+	// Reason describes the signal, e.g. /etc/node-maintenance exists
+	Reason string `json:"reason,omitempty"`
+}
+
+// Options represents the options for creating a new host maintenance Checker
+type Options struct {
+	// File is a flag file whose existence signals host maintenance mode, e.g. /etc/node-maintenance
+	File string
+	// URL is an endpoint responding with a 2xx status while the host is in maintenance mode and 404 otherwise
+	URL string
+	// Timeout is the maximum time URL may take to respond, defaults to DefaultTimeout
+	Timeout time.Duration
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Transport is the HTTP transport used for URL, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Checker checks whether the host is in maintenance mode, as signalled by operators' maintenance tooling
+type Checker struct {
+	file       string
+	url        string
+	timeout    time.Duration
+	logger     *log.Logger
+	httpClient *http.Client
+}
+
+// New creates a new host maintenance Checker
+func New(opts Options) *Checker {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Checker{
+		file:       opts.File,
+		url:        opts.URL,
+		timeout:    opts.Timeout,
+		logger:     logging.WithPrefix(opts.Logger, "hostmaintenance"),
+		httpClient: &http.Client{Transport: opts.Transport},
+	}
+}
+
+// Check returns whether the host is in maintenance mode - because the flag file exists or the endpoint responds with a
+// 2xx status. An endpoint responding with any status but 2xx or 404, or not at all, is an error
+func (c *Checker) Check(ctx context.Context) (Status, error) {
+	var status Status
+
+	if c.file != "" {
+		_, err := os.Stat(c.file)
+		switch {
+		case err == nil:
+			status = Status{Active: true, Reason: fmt.Sprintf("%s exists", c.file)}
+		case !errors.Is(err, fs.ErrNotExist):
+			return status, fmt.Errorf("failed to check %s: %w", c.file, err)
+		}
+	}
+
+	if c.url != "" && !status.Active {
+		active, err := c.checkURL(ctx)
+		if err != nil {
+			return status, err
+		}
+		if active {
+			status = Status{Active: true, Reason: fmt.Sprintf("%s reports maintenance mode", c.url)}
+		}
+	}
+
+	c.logger.Debug("checked host maintenance mode", "active", status.Active, "reason", status.Reason)
+	return status, nil
+}
+
+// checkURL returns whether the endpoint reports maintenance mode
+func (c *Checker) checkURL(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create host maintenance request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check host maintenance mode at %s: %w", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("host maintenance endpoint %s responded with unexpected status %d", c.url, resp.StatusCode)
+	}
+}
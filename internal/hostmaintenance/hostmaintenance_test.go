@@ -0,0 +1,60 @@
+package hostmaintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	statusServer := func(status int) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	tests := []struct {
+		name       string
+		flagFile   bool
+		status     int
+		wantActive bool
+		wantErr    bool
+	}{
+		{name: "nothing signalled"},
+		{name: "flag file exists", flagFile: true, wantActive: true},
+		{name: "endpoint reports maintenance", status: http.StatusOK, wantActive: true},
+		{name: "endpoint reports no maintenance", status: http.StatusNotFound},
+		{name: "endpoint errors", status: http.StatusInternalServerError, wantErr: true},
+		{name: "flag file wins over a failing endpoint", flagFile: true, status: http.StatusInternalServerError, wantActive: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{File: filepath.Join(t.TempDir(), "node-maintenance")}
+			if tt.flagFile {
+				if err := os.WriteFile(opts.File, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.status != 0 {
+				opts.URL = statusServer(tt.status).URL
+			}
+
+			status, err := New(opts).Check(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status.Active != tt.wantActive {
+				t.Errorf("Check() active = %v, want %v", status.Active, tt.wantActive)
+			}
+			if status.Active && status.Reason == "" {
+				t.Error("Check() reason is empty while active")
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// ExecTimeout is the maximum time an exec:// identity command may take
+const ExecTimeout = 30 * time.Second
+
+// Keys are the public keys of the validator's configured identities
+type Keys struct {
+	// Active is the active identity public key
+	Active solana.PublicKey
+	// Passive is the passive identity public key, the zero key for single-identity setups
+	Passive solana.PublicKey
+}
+
+// Options represents the options for creating a new Loader
+type Options struct {
+	// ActiveFile is the path to the active identity keypair or public key file, or an env:// or exec:// reference
+	ActiveFile string
+	// PassiveFile is the path to the passive identity keypair or public key file, or an env:// or exec:// reference,
+	// empty for single-identity setups
+	PassiveFile string
+	// ActivePublicKey is the base58 active identity public key, used instead of ActiveFile so no private key is read
+	ActivePublicKey string
+	// PassivePublicKey is the base58 passive identity public key, used instead of PassiveFile
+	PassivePublicKey string
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+}
+
+// Loader loads the identity public keys from their files, reloading a file when it changes on disk so rotated
+// identities are picked up without a restart. exec:// commands are run again on every refresh
+// It is safe for concurrent use
+type Loader struct {
+	activeFile       string
+	passiveFile      string
+	activePublicKey  string
+	passivePublicKey string
+	logger           *log.Logger
+
+	mu     sync.Mutex
+	loaded bool
+	keys   Keys
+	stamps map[string]fileStamp
+}
+
+// fileStamp identifies the version of a file on disk that keys were loaded from
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// New creates a new Loader - no files are read until Keys is called
+func New(opts Options) *Loader {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+
+	return &Loader{
+		activeFile:       opts.ActiveFile,
+		passiveFile:      opts.PassiveFile,
+		activePublicKey:  opts.ActivePublicKey,
+		passivePublicKey: opts.PassivePublicKey,
+		logger:           logging.WithPrefix(opts.Logger, "identity"),
+		stamps:           map[string]fileStamp{},
+	}
+}
+
+// IsSingleIdentity returns true if only the active identity is configured (no hot-spare failover)
+func (l *Loader) IsSingleIdentity() bool {
+	return l.passiveFile == "" && l.passivePublicKey == ""
+}
+
+// Keys returns the identity public keys, (re)loading any file that changed since it was last read
+func (l *Loader) Keys() (Keys, error) {
+	keys, _, err := l.Refresh()
+	return keys, err
+}
+
+// Role returns the configured role (active, passive or unknown) of the identity the validator is running as
+func (l *Loader) Role(validatorIdentity string) (string, error) {
+	keys, err := l.Keys()
+	if err != nil {
+		return constants.ValidatorRoleUnknown, err
+	}
+
+	switch {
+	case validatorIdentity == keys.Active.String():
+		return constants.ValidatorRoleActive, nil
+	case !l.IsSingleIdentity() && validatorIdentity == keys.Passive.String():
+		return constants.ValidatorRolePassive, nil
+	default:
+		return constants.ValidatorRoleUnknown, nil
+	}
+}
+
+// Refresh (re)loads any identity file that changed since it was last read, returning the identity public keys and
+// whether either of them changed from the previously loaded ones - the first load is not a change
+func (l *Loader) Refresh() (keys Keys, changed bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous, previouslyLoaded := l.keys, l.loaded
+	if err := l.load(l.activePublicKey, l.activeFile, &l.keys.Active); err != nil {
+		return Keys{}, false, fmt.Errorf("failed to load active identity: %w", err)
+	}
+	if !l.IsSingleIdentity() {
+		if err := l.load(l.passivePublicKey, l.passiveFile, &l.keys.Passive); err != nil {
+			return Keys{}, false, fmt.Errorf("failed to load passive identity: %w", err)
+		}
+	}
+	l.loaded = true
+
+	return l.keys, previouslyLoaded && l.keys != previous, nil
+}
+
+// load sets key to the configured public key, or (re)loads it from the referenced keypair file when none is
+func (l *Loader) load(publicKey, ref string, key *solana.PublicKey) error {
+	if publicKey == "" {
+		return l.reload(ref, key)
+	}
+	parsed, err := solana.PublicKeyFromBase58(publicKey)
+	if err != nil {
+		return fmt.Errorf("%q is not a base58 public key: %w", publicKey, err)
+	}
+	*key = parsed
+	return nil
+}
+
+// reload loads the public key referenced by ref into key - a file when it changed since it was last loaded, an
+// environment variable only once as it doesn't change while running, and a command's output every time
+func (l *Loader) reload(ref string, key *solana.PublicKey) error {
+	provider, value := config.SplitKeyRef(ref)
+	previous, loaded := l.stamps[ref]
+
+	var stamp fileStamp
+	var publicKey solana.PublicKey
+	switch provider {
+	case constants.IdentityKeyProviderEnv:
+		if loaded {
+			return nil
+		}
+		contents := []byte(os.Getenv(value))
+		defer zero(contents)
+		if len(bytes.TrimSpace(contents)) == 0 {
+			return fmt.Errorf("environment variable %s is not set", value)
+		}
+		var err error
+		if publicKey, err = ParsePublicKey(ref, contents); err != nil {
+			return err
+		}
+	case constants.IdentityKeyProviderExec:
+		var err error
+		if publicKey, err = loadExecPublicKey(value); err != nil {
+			return err
+		}
+	default:
+		info, err := os.Stat(value)
+		if err != nil {
+			return err
+		}
+		stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		if loaded && previous == stamp {
+			return nil
+		}
+		if publicKey, err = LoadPublicKey(value); err != nil {
+			return err
+		}
+	}
+
+	source := keyRefName(ref)
+	if loaded && !publicKey.Equals(*key) {
+		l.logger.Info("identity changed - loaded new public key", "source", source, "previous", key.String(), "public_key", publicKey.String())
+	} else if !loaded {
+		l.logger.Debug("loaded identity public key", "source", source, "public_key", publicKey.String())
+	}
+	*key = publicKey
+	l.stamps[ref] = stamp
+	return nil
+}
+
+// keyRefName returns an identity keypair reference safe to log - the command of an exec:// reference without its
+// arguments, which may carry credentials
+func keyRefName(ref string) string {
+	provider, value := config.SplitKeyRef(ref)
+	if provider != constants.IdentityKeyProviderExec {
+		return value
+	}
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return provider + "://" + fields[0]
+	}
+	return ref
+}
+
+// loadExecPublicKey runs command - a program and its arguments, not run in a shell - and loads the public key from
+// its output, zeroing the output before returning
+func loadExecPublicKey(command string) (solana.PublicKey, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return solana.PublicKey{}, fmt.Errorf("exec:// identity command is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ExecTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	defer zero(output)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("identity command %s failed: %w: %s", fields[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return ParsePublicKey("exec://"+fields[0], output)
+}
+
+// LoadPublicKey loads the public key from a file holding either a base58 public key, or a solana-keygen keypair
+// (a JSON array of the 64 secret and public key bytes) - the private key is never kept and every copy of the file
+// contents is zeroed before returning
+func LoadPublicKey(file string) (solana.PublicKey, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	defer zero(contents)
+
+	return ParsePublicKey(file, contents)
+}
+
+// ParsePublicKey parses the public key from contents holding either a base58 public key or a solana-keygen keypair,
+// named name in errors - the private key is never kept and every decoded copy of it is zeroed before returning, the
+// caller zeroes contents
+func ParsePublicKey(name string, contents []byte) (solana.PublicKey, error) {
+	trimmed := bytes.TrimSpace(contents)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		publicKey, err := solana.PublicKeyFromBase58(string(trimmed))
+		if err != nil {
+			return solana.PublicKey{}, fmt.Errorf("%s is neither a keypair nor a base58 public key: %w", name, err)
+		}
+		return publicKey, nil
+	}
+
+	var keypair []int
+	defer func() { zero(keypair) }()
+	if err := json.Unmarshal(trimmed, &keypair); err != nil {
+		return solana.PublicKey{}, fmt.Errorf("%s is not a valid keypair: %w", name, err)
+	}
+	if len(keypair) != 64 {
+		return solana.PublicKey{}, fmt.Errorf("%s is not a valid keypair: got %d bytes, want 64", name, len(keypair))
+	}
+
+	// the public key is the second half of the keypair
+	var publicKey solana.PublicKey
+	for i, b := range keypair[32:] {
+		if b < 0 || b > 255 {
+			return solana.PublicKey{}, fmt.Errorf("%s is not a valid keypair: byte %d out of range", name, 32+i)
+		}
+		publicKey[i] = byte(b)
+	}
+	return publicKey, nil
+}
+
+// zero overwrites every element of s with its zero value
+func zero[T byte | int](s []T) {
+	for i := range s {
+		s[i] = 0
+	}
+}
@@ -0,0 +1,167 @@
+package identity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// writeKeypair writes a solana-keygen keypair file for the given private key
+func writeKeypair(t *testing.T, file string, privateKey solana.PrivateKey) {
+	t.Helper()
+	keypair := make([]int, len(privateKey))
+	for i, b := range privateKey {
+		keypair[i] = int(b)
+	}
+	contents, err := json.Marshal(keypair)
+	if err != nil {
+		t.Fatalf("failed to marshal keypair: %v", err)
+	}
+	if err := os.WriteFile(file, contents, 0o600); err != nil {
+		t.Fatalf("failed to write keypair: %v", err)
+	}
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	dir := t.TempDir()
+	privateKey := solana.NewWallet().PrivateKey
+
+	keypairFile := filepath.Join(dir, "keypair.json")
+	writeKeypair(t, keypairFile, privateKey)
+	publicKeyFile := filepath.Join(dir, "pubkey")
+	if err := os.WriteFile(publicKeyFile, []byte(privateKey.PublicKey().String()+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	invalidFile := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidFile, []byte("[1, 2, 3]"), 0o600); err != nil {
+		t.Fatalf("failed to write invalid keypair: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{name: "keypair", file: keypairFile},
+		{name: "base58 public key", file: publicKeyFile},
+		{name: "short keypair", file: invalidFile, wantErr: true},
+		{name: "missing file", file: filepath.Join(dir, "missing.json"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadPublicKey(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equals(privateKey.PublicKey()) {
+				t.Errorf("LoadPublicKey() = %s, want %s", got, privateKey.PublicKey())
+			}
+		})
+	}
+}
+
+func TestKeysReloadsRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	activeFile := filepath.Join(dir, "active.json")
+	first := solana.NewWallet().PrivateKey
+	writeKeypair(t, activeFile, first)
+
+	loader := New(Options{ActiveFile: activeFile})
+	keys, err := loader.Keys()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !keys.Active.Equals(first.PublicKey()) {
+		t.Fatalf("Keys().Active = %s, want %s", keys.Active, first.PublicKey())
+	}
+
+	rotated := solana.NewWallet().PrivateKey
+	writeKeypair(t, activeFile, rotated)
+	// make sure the rotation is visible even on filesystems with coarse modification times
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(activeFile, later, later); err != nil {
+		t.Fatalf("failed to touch keypair: %v", err)
+	}
+
+	keys, changed, err := loader.Refresh()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed {
+		t.Error("Refresh() changed = false after rotation, want true")
+	}
+	if !keys.Active.Equals(rotated.PublicKey()) {
+		t.Errorf("Refresh().Active = %s after rotation, want %s", keys.Active, rotated.PublicKey())
+	}
+
+	if _, changed, _ := loader.Refresh(); changed {
+		t.Error("Refresh() changed = true without a rotation, want false")
+	}
+}
+
+func TestKeysFromProviders(t *testing.T) {
+	dir := t.TempDir()
+	active := solana.NewWallet().PrivateKey
+	passive := solana.NewWallet().PrivateKey
+
+	keypairFile := filepath.Join(dir, "active.json")
+	writeKeypair(t, keypairFile, active)
+	keypair, err := os.ReadFile(keypairFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_ACTIVE_IDENTITY", string(keypair))
+
+	tests := []struct {
+		name       string
+		activeRef  string
+		passiveRef string
+		wantErr    bool
+	}{
+		{name: "file scheme", activeRef: "file://" + keypairFile, passiveRef: "exec://echo " + passive.PublicKey().String()},
+		{name: "env", activeRef: "env://TEST_ACTIVE_IDENTITY", passiveRef: "exec://echo " + passive.PublicKey().String()},
+		{name: "unset env", activeRef: "env://TEST_UNSET_IDENTITY", wantErr: true},
+		{name: "failing command", activeRef: "exec://false", wantErr: true},
+		{name: "command printing garbage", activeRef: "exec://echo not-a-key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := New(Options{ActiveFile: tt.activeRef, PassiveFile: tt.passiveRef}).Keys()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Keys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !keys.Active.Equals(active.PublicKey()) || !keys.Passive.Equals(passive.PublicKey()) {
+				t.Errorf("Keys() = %s, %s, want %s, %s", keys.Active, keys.Passive, active.PublicKey(), passive.PublicKey())
+			}
+		})
+	}
+}
+
+func TestKeysFromPublicKeys(t *testing.T) {
+	active := solana.NewWallet().PublicKey()
+	passive := solana.NewWallet().PublicKey()
+
+	loader := New(Options{ActivePublicKey: active.String(), PassivePublicKey: passive.String()})
+	if loader.IsSingleIdentity() {
+		t.Error("IsSingleIdentity() = true with a passive public key, want false")
+	}
+	keys, err := loader.Keys()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !keys.Active.Equals(active) || !keys.Passive.Equals(passive) {
+		t.Errorf("Keys() = %s, %s, want %s, %s", keys.Active, keys.Passive, active, passive)
+	}
+	if role, _ := loader.Role(passive.String()); role != "passive" {
+		t.Errorf("Role() = %s, want passive", role)
+	}
+}
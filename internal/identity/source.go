@@ -0,0 +1,90 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// Source reports the identity the validator is running as
+type Source interface {
+	// Identity returns the base58 public key of the identity the validator is running as, giving up when ctx is done
+	Identity(ctx context.Context) (string, error)
+	// Type returns the source type, one of the validator.identity_source.type values
+	Type() string
+}
+
+// RPCClient gets the identity from the validator RPC, e.g. *rpc.Client
+type RPCClient interface {
+	GetIdentity(ctx context.Context) (string, error)
+}
+
+// NewSourceFromConfig creates the identity source configured under validator.identity_source, client is used by the
+// rpc type
+func NewSourceFromConfig(cfg config.IdentitySource, client RPCClient) (Source, error) {
+	switch cfg.Type {
+	case constants.ValidatorIdentitySourceRPC, "":
+		if client == nil {
+			return nil, fmt.Errorf("validator.identity_source.type=rpc requires validator.rpc_url")
+		}
+		return &RPCSource{client: client}, nil
+	case constants.ValidatorIdentitySourceFile:
+		return &FileSource{file: cfg.File}, nil
+	case constants.ValidatorIdentitySourceStatic:
+		return &StaticSource{identity: cfg.Identity}, nil
+	default:
+		return nil, fmt.Errorf("unsupported validator.identity_source.type: %s", cfg.Type)
+	}
+}
+
+// RPCSource reads the identity from the validator RPC getIdentity
+type RPCSource struct {
+	client RPCClient
+}
+
+// Identity returns the identity the validator RPC reports
+func (s *RPCSource) Identity(ctx context.Context) (string, error) {
+	return s.client.GetIdentity(ctx)
+}
+
+// Type returns rpc
+func (s *RPCSource) Type() string {
+	return constants.ValidatorIdentitySourceRPC
+}
+
+// FileSource reads the identity from a keypair or base58 public key file the validator, or its failover tooling,
+// writes - e.g. the identity symlink swapped to the passive keypair on failover. The file is read on every call
+type FileSource struct {
+	file string
+}
+
+// Identity returns the public key in the file
+func (s *FileSource) Identity(context.Context) (string, error) {
+	publicKey, err := LoadPublicKey(s.file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read identity file: %w", err)
+	}
+	return publicKey.String(), nil
+}
+
+// Type returns file
+func (s *FileSource) Type() string {
+	return constants.ValidatorIdentitySourceFile
+}
+
+// StaticSource always returns the configured identity, for setups where the running identity can't be observed
+type StaticSource struct {
+	identity string
+}
+
+// Identity returns the configured identity
+func (s *StaticSource) Identity(context.Context) (string, error) {
+	return s.identity, nil
+}
+
+// Type returns static
+func (s *StaticSource) Type() string {
+	return constants.ValidatorIdentitySourceStatic
+}
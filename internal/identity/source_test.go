@@ -0,0 +1,97 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// fakeRPCClient returns a fixed identity or error
+type fakeRPCClient struct {
+	identity string
+	err      error
+}
+
+func (c fakeRPCClient) GetIdentity(context.Context) (string, error) {
+	return c.identity, c.err
+}
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	active := solana.NewWallet().PrivateKey
+	passive := solana.NewWallet().PrivateKey
+
+	// the identity file is swapped to the passive keypair on failover
+	identityFile := filepath.Join(dir, "identity.json")
+	writeKeypair(t, filepath.Join(dir, "passive.json"), passive)
+	if err := os.Symlink(filepath.Join(dir, "passive.json"), identityFile); err != nil {
+		t.Fatalf("failed to link identity: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.IdentitySource
+		client  RPCClient
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "rpc",
+			cfg:    config.IdentitySource{Type: constants.ValidatorIdentitySourceRPC},
+			client: fakeRPCClient{identity: active.PublicKey().String()},
+			want:   active.PublicKey().String(),
+		},
+		{
+			name:    "rpc unreachable",
+			cfg:     config.IdentitySource{Type: constants.ValidatorIdentitySourceRPC},
+			client:  fakeRPCClient{err: errors.New("connection refused")},
+			wantErr: true,
+		},
+		{
+			name: "file",
+			cfg:  config.IdentitySource{Type: constants.ValidatorIdentitySourceFile, File: identityFile},
+			want: passive.PublicKey().String(),
+		},
+		{
+			name:    "missing file",
+			cfg:     config.IdentitySource{Type: constants.ValidatorIdentitySourceFile, File: filepath.Join(dir, "missing.json")},
+			wantErr: true,
+		},
+		{
+			name: "static",
+			cfg:  config.IdentitySource{Type: constants.ValidatorIdentitySourceStatic, Identity: active.PublicKey().String()},
+			want: active.PublicKey().String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewSourceFromConfig(tt.cfg, tt.client)
+			if err != nil {
+				t.Fatalf("NewSourceFromConfig() error = %v", err)
+			}
+			if source.Type() != tt.cfg.Type {
+				t.Errorf("Type() = %s, want %s", source.Type(), tt.cfg.Type)
+			}
+			got, err := source.Identity(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Identity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Identity() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSourceFromConfigRPCWithoutClient(t *testing.T) {
+	if _, err := NewSourceFromConfig(config.IdentitySource{Type: constants.ValidatorIdentitySourceRPC}, nil); err == nil {
+		t.Error("NewSourceFromConfig() error = nil, want an error without an RPC client")
+	}
+}
@@ -0,0 +1,39 @@
+package installed
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// versionPattern extracts version strings like "0.7.1", "0.7.1-1", etc.
+// Handles formats like "DoubleZero 0.7.1", "0.7.1-1", etc.
+var versionPattern = regexp.MustCompile(`(\d+\.\d+\.\d+(?:-\d+)?)`)
+
+// GetVersion gets the installed DoubleZero version from the given binary
+// The binary is the source of truth for the installed version
+// Executes the binary with --version flag and parses the output, which is also returned for logging
+func GetVersion(bin string) (v *version.Version, output string, err error) {
+	cmd := exec.Command(bin, "--version")
+	outputBytes, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, "", fmt.Errorf("bin command failed: %w", err)
+	}
+
+	// Parse the output - look for version patterns
+	output = strings.TrimSpace(string(outputBytes))
+	matches := versionPattern.FindStringSubmatch(output)
+	if len(matches) < 2 {
+		return nil, output, fmt.Errorf("could not extract version from bin output: %s", output)
+	}
+
+	v, err = version.NewVersion(matches[1])
+	if err != nil {
+		return nil, output, fmt.Errorf("failed to parse version from bin output: %w", err)
+	}
+
+	return v, output, nil
+}
@@ -0,0 +1,68 @@
+package jitter
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+)
+
+// Range is a range of delays a node picks its delay from
+type Range struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// ParseRange parses a min-max duration range like 0-45m or 5m-1h - a single duration d is the range 0-d and an empty
+// string is no jitter
+func ParseRange(s string) (Range, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Range{}, nil
+	}
+
+	minString, maxString, isRange := strings.Cut(s, "-")
+	if !isRange {
+		minString, maxString = "0s", s
+	}
+
+	var r Range
+	var err error
+	if r.Min, err = duration.Parse(minString); err != nil {
+		return Range{}, fmt.Errorf("invalid minimum %q: %w", minString, err)
+	}
+	if r.Max, err = duration.Parse(maxString); err != nil {
+		return Range{}, fmt.Errorf("invalid maximum %q: %w", maxString, err)
+	}
+	if r.Min < 0 || r.Max < r.Min {
+		return Range{}, fmt.Errorf("must be a min-max range with 0 <= min <= max - got: %s", s)
+	}
+
+	return r, nil
+}
+
+// IsEnabled returns true if the range has a non-zero maximum
+func (r Range) IsEnabled() bool {
+	return r.Max > 0
+}
+
+// String returns the range as min-max
+func (r Range) String() string {
+	return r.Min.String() + "-" + r.Max.String()
+}
+
+// Delay returns the delay within the range for the given seed - the same seed always gets the same delay, and seeds
+// like node identities are spread uniformly across the range
+func (r Range) Delay(seed string) time.Duration {
+	span := r.Max - r.Min
+	if span <= 0 {
+		return r.Min
+	}
+	sum := sha256.Sum256([]byte(seed))
+	// second resolution is plenty for spreading upgrades and keeps logged delays readable
+	seconds := uint64(span / time.Second)
+	return r.Min + time.Duration(binary.BigEndian.Uint64(sum[:8])%(seconds+1))*time.Second
+}
@@ -0,0 +1,54 @@
+package jitter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Range
+		wantErr bool
+	}{
+		{name: "empty is disabled", in: ""},
+		{name: "range", in: "0-45m", want: Range{Max: 45 * time.Minute}},
+		{name: "range with a minimum", in: "5m-1h", want: Range{Min: 5 * time.Minute, Max: time.Hour}},
+		{name: "single duration is from zero", in: "30m", want: Range{Max: 30 * time.Minute}},
+		{name: "min above max", in: "1h-5m", wantErr: true},
+		{name: "invalid duration", in: "0-soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRange(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRange(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelayIsDeterministicAndInRange(t *testing.T) {
+	r := Range{Min: 5 * time.Minute, Max: 45 * time.Minute}
+	seeds := []string{"node-a", "node-b", "node-c", "node-d", "node-e", "node-f"}
+
+	distinct := map[time.Duration]bool{}
+	for _, seed := range seeds {
+		delay := r.Delay(seed)
+		if delay < r.Min || delay > r.Max {
+			t.Errorf("Delay(%q) = %s, want within %s", seed, delay, r)
+		}
+		if again := r.Delay(seed); again != delay {
+			t.Errorf("Delay(%q) = %s then %s, want the same delay", seed, delay, again)
+		}
+		distinct[delay] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("Delay() gave every seed the same delay, want them spread across %s", r)
+	}
+}
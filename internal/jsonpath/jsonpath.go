@@ -0,0 +1,103 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed JQ-style path expression such as .clusters["mainnet-beta"].recommended or .releases[0].version
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// step is a single object key or array index lookup
+type step struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Parse parses a path expression made of .key, ["key"], ['key'] and [index] steps - "." alone selects the whole document
+func Parse(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	rest := strings.TrimSpace(expr)
+	if rest == "" {
+		return nil, fmt.Errorf("empty path expression")
+	}
+	if rest == "." {
+		return p, nil
+	}
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("invalid path expression %s: empty key", expr)
+			}
+			p.steps = append(p.steps, step{key: key})
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path expression %s: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+				p.steps = append(p.steps, step{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path expression %s: [%s] must be a quoted key or a non-negative index", expr, inner)
+			}
+			p.steps = append(p.steps, step{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid path expression %s: expected . or [ at %q", expr, rest)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the path expression
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Lookup returns the value at the path in a document decoded with encoding/json into an any
+func (p *Path) Lookup(document any) (any, error) {
+	value := document
+	for i, s := range p.steps {
+		if s.isIndex {
+			array, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: step %d expects an array, got %T", p.expr, i+1, value)
+			}
+			if s.index >= len(array) {
+				return nil, fmt.Errorf("%s: step %d index %d out of range (length %d)", p.expr, i+1, s.index, len(array))
+			}
+			value = array[s.index]
+			continue
+		}
+
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: step %d expects an object, got %T", p.expr, i+1, value)
+		}
+		value, ok = object[s.key]
+		if !ok {
+			return nil, fmt.Errorf("%s: key %q not found", p.expr, s.key)
+		}
+	}
+	return value, nil
+}
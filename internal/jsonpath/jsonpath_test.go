@@ -0,0 +1,58 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const document = `{
+	"clusters": {
+		"mainnet-beta": {"recommended": "0.7.1-1"},
+		"testnet": {"recommended": "0.7.2-1"}
+	},
+	"releases": [{"version": "0.7.0-1"}, {"version": "0.6.9-1"}]
+}`
+
+func TestLookup(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		t.Fatalf("failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		expr    string
+		want    any
+		wantErr bool
+	}{
+		{expr: `.clusters["mainnet-beta"].recommended`, want: "0.7.1-1"},
+		{expr: `.clusters['testnet'].recommended`, want: "0.7.2-1"},
+		{expr: `.releases[1].version`, want: "0.6.9-1"},
+		{expr: `.releases[2].version`, wantErr: true},
+		{expr: `.clusters.devnet`, wantErr: true},
+		{expr: `.releases.version`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := path.Lookup(doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "clusters", ".clusters[", ".releases[-1]", ".clusters[mainnet]", "..x"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", expr)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package lockfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+// pollInterval is how often a held lock is retried while waiting for it
+const pollInterval = time.Second
+
+// ErrLocked is returned when the lock is held by another process
+var ErrLocked = errors.New("held by another process")
+
+// Lock is an exclusive flock on a file, held until it's released or the process exits
+type Lock struct {
+	file *os.File
+}
+
+// Acquire takes an exclusive lock on the file at path, creating it and its directory. When another process holds it,
+// it's retried until wait has elapsed or ctx is done - a wait of 0 fails right away. The error wraps ErrLocked and
+// names the holder recorded in the file when the lock is held
+func Acquire(ctx context.Context, path string, wait time.Duration, c clock.Clock) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	deadline := c.Now().Add(wait)
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		remaining := deadline.Sub(c.Now())
+		if remaining <= 0 {
+			file.Close()
+			return nil, fmt.Errorf("%s is %w (%s)", path, ErrLocked, holder(path))
+		}
+		if err := clock.SleepContext(ctx, c, min(pollInterval, remaining)); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("stopped waiting for %s, %w (%s): %w", path, ErrLocked, holder(path), err)
+		}
+	}
+
+	// record the holder for processes that find it locked, best effort
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(fmt.Sprintf("pid %d since %s\n", os.Getpid(), c.Now().UTC().Format(time.RFC3339))), 0)
+	}
+	return &Lock{file: file}, nil
+}
+
+// Release releases the lock, keeping the file so the next holder locks the same inode
+func (l *Lock) Release() error {
+	l.file.Truncate(0)
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock %s: %w", l.file.Name(), err)
+	}
+	return l.file.Close()
+}
+
+// holder returns the holder recorded in the lock file, "unknown holder" when it can't be read
+func holder(path string) string {
+	contents, err := os.ReadFile(path)
+	if text := strings.TrimSpace(string(contents)); err == nil && text != "" {
+		return text
+	}
+	return "unknown holder"
+}
@@ -0,0 +1,70 @@
+package lockfile
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "sync.lock")
+
+	lock, err := Acquire(context.Background(), path, 0, clock.Real{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// flock locks belong to the open file, so a second open in the same process conflicts like another process would
+	tests := []struct {
+		name    string
+		ctx     func() context.Context
+		wait    time.Duration
+		wantErr error
+	}{
+		{name: "fail fast", ctx: context.Background, wantErr: ErrLocked},
+		{name: "wait elapses", ctx: context.Background, wait: 50 * time.Millisecond, wantErr: ErrLocked},
+		{
+			name: "context done while waiting",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			wait:    time.Minute,
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Acquire(tt.ctx(), path, tt.wait, clock.Real{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Acquire() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), "pid ") {
+				t.Errorf("Acquire() error = %v, want the holder's pid", err)
+			}
+		})
+	}
+
+	// waiting picks the lock up once it's released
+	released := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		released <- lock.Release()
+	}()
+	second, err := Acquire(context.Background(), path, 10*time.Second, clock.Real{})
+	if err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+	if err := <-released; err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := second.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
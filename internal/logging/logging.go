@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+var (
+	levelsMu sync.RWMutex
+	levels   = map[string]log.Level{}
+)
+
+// SetLevels sets the per-prefix log level overrides applied by WithPrefix, replacing any previously set
+func SetLevels(prefixLevels map[string]log.Level) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	levels = make(map[string]log.Level, len(prefixLevels))
+	for prefix, level := range prefixLevels {
+		levels[prefix] = level
+	}
+}
+
+// WithPrefix returns a child of parent with the given prefix, at the level overridden for that prefix if any
+// Overrides match the full prefix first, then its base name up to the first ':' or '[' (e.g. command for command[install])
+func WithPrefix(parent *log.Logger, prefix string) *log.Logger {
+	logger := parent.WithPrefix(prefix)
+	if level, ok := LevelFor(prefix); ok {
+		logger.SetLevel(level)
+	}
+	return logger
+}
+
+// LevelFor returns the level overridden for the given prefix and whether there is one
+func LevelFor(prefix string) (log.Level, bool) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	if level, ok := levels[prefix]; ok {
+		return level, true
+	}
+	if i := strings.IndexAny(prefix, ":["); i > 0 {
+		level, ok := levels[prefix[:i]]
+		return level, ok
+	}
+	return 0, false
+}
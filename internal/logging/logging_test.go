@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/log"
+)
+
+func TestWithPrefix(t *testing.T) {
+	SetLevels(map[string]log.Level{
+		"versionsource": log.DebugLevel,
+		"rpc":           log.WarnLevel,
+		"command":       log.ErrorLevel,
+	})
+	t.Cleanup(func() { SetLevels(nil) })
+
+	tests := []struct {
+		prefix string
+		want   log.Level
+	}{
+		{prefix: "versionsource", want: log.DebugLevel},
+		{prefix: "rpc", want: log.WarnLevel},
+		{prefix: "command[install]", want: log.ErrorLevel},
+		{prefix: "manager", want: log.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			parent := log.NewWithOptions(&bytes.Buffer{}, log.Options{Level: log.InfoLevel})
+			if got := WithPrefix(parent, tt.prefix).GetLevel(); got != tt.want {
+				t.Errorf("WithPrefix(%q) level = %s, want %s", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPrefixDoesNotChangeParent(t *testing.T) {
+	SetLevels(map[string]log.Level{"versionsource": log.DebugLevel})
+	t.Cleanup(func() { SetLevels(nil) })
+
+	var buf bytes.Buffer
+	parent := log.NewWithOptions(&buf, log.Options{Level: log.InfoLevel})
+	WithPrefix(parent, "versionsource").Debug("from versionsource")
+	parent.Debug("from parent")
+
+	if !strings.Contains(buf.String(), "from versionsource") {
+		t.Errorf("expected versionsource debug line, got: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "from parent") {
+		t.Errorf("expected parent debug line to be filtered, got: %s", buf.String())
+	}
+}
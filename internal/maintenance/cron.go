@@ -0,0 +1,116 @@
+package maintenance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed standard 5-field cron expression: minute hour day-of-month month day-of-week
+type cronSchedule struct {
+	minutes     []bool
+	hours       []bool
+	daysOfMonth []bool
+	months      []bool
+	daysOfWeek  []bool
+	// domAny and dowAny record unrestricted (*) day fields - when both day fields are restricted a time matches
+	// either of them, as in cron
+	domAny bool
+	dowAny bool
+}
+
+// parseCron parses a standard 5-field cron expression, each field supporting *, values, ranges (a-b), steps (*/n,
+// a-b/n) and comma separated lists. Day-of-week is 0-7 where 0 and 7 are Sunday
+func parseCron(expression string) (*cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week) - got %d", expression, len(fields))
+	}
+
+	var (
+		s   cronSchedule
+		err error
+	)
+	if s.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid cron minute %q: %w", fields[0], err)
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid cron hour %q: %w", fields[1], err)
+	}
+	if s.daysOfMonth, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid cron day-of-month %q: %w", fields[2], err)
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid cron month %q: %w", fields[3], err)
+	}
+	if s.daysOfWeek, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid cron day-of-week %q: %w", fields[4], err)
+	}
+	s.daysOfWeek[0] = s.daysOfWeek[0] || s.daysOfWeek[7]
+	s.domAny = strings.HasPrefix(fields[2], "*")
+	s.dowAny = strings.HasPrefix(fields[4], "*")
+
+	return &s, nil
+}
+
+// parseCronField parses a single cron field into a lookup of the values it matches, indexed by value
+func parseCronField(field string, minValue, maxValue int) ([]bool, error) {
+	matches := make([]bool, maxValue+1)
+	for _, part := range strings.Split(field, ",") {
+		valueRange, stepString, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepString)
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q", stepString)
+			}
+		}
+
+		low, high := minValue, maxValue
+		if valueRange != "*" {
+			lowString, highString, isRange := strings.Cut(valueRange, "-")
+			var err error
+			if low, err = strconv.Atoi(lowString); err != nil {
+				return nil, fmt.Errorf("invalid value %q", lowString)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highString); err != nil {
+					return nil, fmt.Errorf("invalid value %q", highString)
+				}
+			} else if hasStep {
+				high = maxValue
+			}
+		}
+		if low < minValue || high > maxValue || low > high {
+			return nil, fmt.Errorf("%s is outside %d-%d", valueRange, minValue, maxValue)
+		}
+
+		for value := low; value <= high; value += step {
+			matches[value] = true
+		}
+	}
+	return matches, nil
+}
+
+// matches returns true if the minute containing t matches the schedule, in t's location
+func (s *cronSchedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[t.Month()] {
+		return false
+	}
+
+	domMatches := s.daysOfMonth[t.Day()]
+	dowMatches := s.daysOfWeek[t.Weekday()]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatches
+	case s.dowAny:
+		return domMatches
+	default:
+		return domMatches || dowMatches
+	}
+}
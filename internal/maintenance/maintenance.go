@@ -0,0 +1,229 @@
+package maintenance
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the layout of window start and end times
+const TimeLayout = "15:04"
+
+// maxLookahead bounds the search for the next window opening
+const maxLookahead = 8 * 24 * time.Hour
+
+// ValidDays is the list of valid window day names
+var ValidDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Window is a recurring period in which version changes may be applied - either a daily time range on the given
+// days, or a cron expression for when the window opens with its duration
+type Window struct {
+	// Name identifies the window in logs and reports, defaults to its position
+	Name string `koanf:"name"`
+	// Days are the days (sun, mon, ..., sat) the time range starts on, every day when empty
+	Days []string `koanf:"days"`
+	// Start is the HH:MM time the window opens
+	Start string `koanf:"start"`
+	// End is the HH:MM time the window closes, before Start for windows crossing midnight
+	End string `koanf:"end"`
+	// Cron is a 5-field cron expression for when the window opens, mutually exclusive with Days, Start and End
+	Cron string `koanf:"cron"`
+	// Duration is how long a cron window stays open
+	Duration time.Duration `koanf:"duration"`
+	// Timezone is the IANA timezone the window is defined in, defaults to UTC
+	Timezone string `koanf:"timezone"`
+}
+
+// Schedule is a set of parsed windows
+type Schedule struct {
+	windows []window
+}
+
+// window is a parsed Window
+type window struct {
+	name     string
+	location *time.Location
+	// days is indexed by time.Weekday
+	days     [7]bool
+	start    int
+	end      int
+	cron     *cronSchedule
+	duration time.Duration
+}
+
+// NewSchedule parses the given windows - a schedule without windows allows changes at any time
+func NewSchedule(windows []Window) (*Schedule, error) {
+	s := &Schedule{}
+	for i, w := range windows {
+		parsed, err := parseWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		if parsed.name == "" {
+			parsed.name = fmt.Sprintf("window[%d]", i)
+		}
+		s.windows = append(s.windows, parsed)
+	}
+	return s, nil
+}
+
+// parseWindow parses a single window
+func parseWindow(w Window) (parsed window, err error) {
+	parsed.name = w.Name
+	parsed.location = time.UTC
+	if w.Timezone != "" {
+		parsed.location, err = time.LoadLocation(w.Timezone)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid timezone %s: %w", w.Timezone, err)
+		}
+	}
+
+	if w.Cron != "" {
+		if w.Start != "" || w.End != "" || len(w.Days) > 0 {
+			return parsed, fmt.Errorf("cron is mutually exclusive with days, start and end")
+		}
+		if w.Duration <= 0 || w.Duration > 7*24*time.Hour {
+			return parsed, fmt.Errorf("duration must be > 0 and at most 168h with cron - got: %s", w.Duration)
+		}
+		parsed.cron, err = parseCron(w.Cron)
+		parsed.duration = w.Duration
+		return parsed, err
+	}
+
+	if w.Start == "" || w.End == "" {
+		return parsed, fmt.Errorf("must set either start and end, or cron and duration")
+	}
+	if w.Duration != 0 {
+		return parsed, fmt.Errorf("duration is only valid with cron")
+	}
+	if parsed.start, err = parseMinuteOfDay(w.Start); err != nil {
+		return parsed, fmt.Errorf("invalid start: %w", err)
+	}
+	if parsed.end, err = parseMinuteOfDay(w.End); err != nil {
+		return parsed, fmt.Errorf("invalid end: %w", err)
+	}
+	if parsed.start == parsed.end {
+		return parsed, fmt.Errorf("start and end must differ - got: %s", w.Start)
+	}
+
+	if len(w.Days) == 0 {
+		parsed.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, day := range w.Days {
+		i := slices.Index(ValidDays, strings.ToLower(day))
+		if i < 0 {
+			return parsed, fmt.Errorf("invalid day %s - must be one of %s", day, strings.Join(ValidDays, ", "))
+		}
+		parsed.days[i] = true
+	}
+
+	return parsed, nil
+}
+
+// parseMinuteOfDay parses an HH:MM time into minutes since midnight
+func parseMinuteOfDay(s string) (int, error) {
+	t, err := time.Parse(TimeLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not an HH:MM time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// IsEnabled returns true if any windows are configured
+func (s *Schedule) IsEnabled() bool {
+	return len(s.windows) > 0
+}
+
+// Open returns the name of a window open at t and true, or false when t is outside every window
+// A schedule without windows is always open
+func (s *Schedule) Open(t time.Time) (string, bool) {
+	if !s.IsEnabled() {
+		return "", true
+	}
+	for _, w := range s.windows {
+		if w.contains(t) {
+			return w.name, true
+		}
+	}
+	return "", false
+}
+
+// NextOpen returns when the next window opens after t, or the zero time when none opens within a week
+func (s *Schedule) NextOpen(t time.Time) time.Time {
+	for m := t.Truncate(time.Minute).Add(time.Minute); m.Sub(t) <= maxLookahead; m = m.Add(time.Minute) {
+		for _, w := range s.windows {
+			if w.opensAt(m) {
+				return m
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Opening is a period a window is open
+type Opening struct {
+	Window string
+	Start  time.Time
+	End    time.Time
+}
+
+// Openings returns the periods windows open within horizon after from, in order of when they open
+func (s *Schedule) Openings(from time.Time, horizon time.Duration) []Opening {
+	var openings []Opening
+	for m := from.Truncate(time.Minute).Add(time.Minute); m.Sub(from) <= horizon; m = m.Add(time.Minute) {
+		for _, w := range s.windows {
+			if w.opensAt(m) {
+				openings = append(openings, Opening{Window: w.name, Start: m, End: w.closesAfter(m)})
+			}
+		}
+	}
+	return openings
+}
+
+// closesAfter returns when the window that opened at t closes
+func (w window) closesAfter(t time.Time) time.Time {
+	if w.cron != nil {
+		return t.Add(w.duration)
+	}
+	length := w.end - w.start
+	if length < 0 {
+		length += 24 * 60
+	}
+	local := t.In(w.location)
+	// a date with a time of day, rather than an offset from the start, keeps a window across a DST change ending at its end time
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, w.start+length, 0, 0, w.location)
+}
+
+// contains returns true if the window is open at t
+func (w window) contains(t time.Time) bool {
+	local := t.In(w.location)
+
+	if w.cron != nil {
+		// open if the window opened within its duration before t
+		opened := local.Truncate(time.Minute)
+		for ; local.Sub(opened) < w.duration; opened = opened.Add(-time.Minute) {
+			if w.cron.matches(opened) {
+				return true
+			}
+		}
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	if w.start < w.end {
+		return w.days[local.Weekday()] && minute >= w.start && minute < w.end
+	}
+	// the window crosses midnight - it either opened today or yesterday
+	yesterday := (local.Weekday() + 6) % 7
+	return (w.days[local.Weekday()] && minute >= w.start) || (w.days[yesterday] && minute < w.end)
+}
+
+// opensAt returns true if the window opens at the minute containing t
+func (w window) opensAt(t time.Time) bool {
+	local := t.In(w.location)
+	if w.cron != nil {
+		return w.cron.matches(local)
+	}
+	return w.days[local.Weekday()] && local.Hour()*60+local.Minute() == w.start
+}
@@ -0,0 +1,136 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleOpen(t *testing.T) {
+	// 2025-01-06 is a Monday
+	monday := func(hour, minute int) time.Time { return time.Date(2025, 1, 6, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		windows []Window
+		at      time.Time
+		want    bool
+	}{
+		{name: "no windows is always open", at: monday(12, 0), want: true},
+		{
+			name:    "inside a weekday range",
+			windows: []Window{{Days: []string{"mon", "wed"}, Start: "02:00", End: "04:00"}},
+			at:      monday(3, 59),
+			want:    true,
+		},
+		{
+			name:    "end is exclusive",
+			windows: []Window{{Days: []string{"mon"}, Start: "02:00", End: "04:00"}},
+			at:      monday(4, 0),
+		},
+		{
+			name:    "wrong day",
+			windows: []Window{{Days: []string{"tue"}, Start: "02:00", End: "04:00"}},
+			at:      monday(3, 0),
+		},
+		{
+			name:    "range crossing midnight is open the morning after its start day",
+			windows: []Window{{Days: []string{"sun"}, Start: "22:00", End: "02:00"}},
+			at:      monday(1, 30),
+			want:    true,
+		},
+		{
+			name:    "range in another timezone",
+			windows: []Window{{Start: "09:00", End: "10:00", Timezone: "America/New_York"}},
+			at:      monday(14, 30),
+			want:    true,
+		},
+		{
+			name:    "inside a cron window",
+			windows: []Window{{Cron: "30 1 * * 1-5", Duration: 2 * time.Hour}},
+			at:      monday(3, 29),
+			want:    true,
+		},
+		{
+			name:    "after a cron window closes",
+			windows: []Window{{Cron: "30 1 * * 1-5", Duration: 2 * time.Hour}},
+			at:      monday(3, 30),
+		},
+		{
+			name:    "cron window on another day",
+			windows: []Window{{Cron: "30 1 * * 6,0", Duration: 2 * time.Hour}},
+			at:      monday(2, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSchedule(tt.windows)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, got := s.Open(tt.at); got != tt.want {
+				t.Errorf("Open(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextOpen(t *testing.T) {
+	s, err := NewSchedule([]Window{
+		{Days: []string{"sat"}, Start: "02:00", End: "04:00"},
+		{Cron: "0 */6 * * 3", Duration: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Monday noon - the cron window opens first, at midnight on Wednesday
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	if got, want := s.NextOpen(now), time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextOpen() = %s, want %s", got, want)
+	}
+}
+
+func TestScheduleOpenings(t *testing.T) {
+	s, err := NewSchedule([]Window{
+		{Name: "overnight", Days: []string{"sat"}, Start: "23:00", End: "01:00"},
+		{Name: "weekly", Cron: "0 3 * * 0", Duration: 5 * time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Monday noon, a week of openings
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	want := []Opening{
+		{Window: "overnight", Start: time.Date(2025, 1, 11, 23, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 12, 1, 0, 0, 0, time.UTC)},
+		{Window: "weekly", Start: time.Date(2025, 1, 12, 3, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 12, 3, 5, 0, 0, time.UTC)},
+	}
+	got := s.Openings(now, 7*24*time.Hour)
+	if len(got) != len(want) {
+		t.Fatalf("Openings() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Window != want[i].Window || !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("Openings()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNewScheduleInvalid(t *testing.T) {
+	for _, w := range []Window{
+		{Start: "02:00"},
+		{Start: "02:00", End: "02:00"},
+		{Start: "25:00", End: "02:00"},
+		{Days: []string{"someday"}, Start: "02:00", End: "04:00"},
+		{Start: "02:00", End: "04:00", Timezone: "Mars/Olympus_Mons"},
+		{Cron: "0 2 * *", Duration: time.Hour},
+		{Cron: "0 2 * * 8", Duration: time.Hour},
+		{Cron: "0 2 * * *"},
+		{Cron: "0 2 * * *", Duration: time.Hour, Start: "02:00"},
+	} {
+		if _, err := NewSchedule([]Window{w}); err == nil {
+			t.Errorf("NewSchedule(%+v) expected an error", w)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+)
+
+const (
+	// HealthStatusOK is the status of a live, or ready, daemon
+	HealthStatusOK = "ok"
+	// HealthStatusUnhealthy is the status of a wedged daemon - a cycle running too long or the next one overdue
+	HealthStatusUnhealthy = "unhealthy"
+	// HealthStatusNotReady is the status of a live daemon whose cycles keep failing
+	HealthStatusNotReady = "not_ready"
+)
+
+// HealthCycle is the outcome of the last sync cycle in a health response
+type HealthCycle struct {
+	FinishedAt time.Time `json:"finished_at"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// HealthStatus is the response body of the /healthz and /readyz endpoints
+type HealthStatus struct {
+	// Status is one of ok, unhealthy, not_ready
+	Status string `json:"status"`
+	// Problems are why the daemon isn't ok
+	Problems []string `json:"problems,omitempty"`
+	// CycleStartedAt is when the running cycle started, absent between cycles
+	CycleStartedAt *time.Time `json:"cycle_started_at,omitempty"`
+	// NextSyncAt is when the next cycle is scheduled, absent while a cycle runs
+	NextSyncAt *time.Time `json:"next_sync_at,omitempty"`
+	// ConsecutiveFailures is how many cycles in a row failed
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// LastCycle is the outcome of the last sync cycle this process ran
+	LastCycle *HealthCycle `json:"last_cycle,omitempty"`
+	// LastSync is the last sync cycle that ran the sync commands, as recorded in the state file
+	LastSync *state.SyncAttempt `json:"last_sync,omitempty"`
+}
+
+// health tracks the progress of the interval loop for the health and readiness endpoints
+// Cycles hold the state file lock, so nothing here reads the state file while serving a request
+type health struct {
+	cfg   config.Health
+	clock clock.Clock
+
+	mu                  sync.Mutex
+	interval            time.Duration
+	cycleStartedAt      time.Time
+	nextSyncAt          time.Time
+	consecutiveFailures int
+	lastCycle           *HealthCycle
+	lastSync            *state.SyncAttempt
+}
+
+// setConfig replaces the health configuration, e.g. on a config reload
+func (h *health) setConfig(cfg config.Health) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg = cfg
+}
+
+// scheduled records the interval and when the next cycle runs
+func (h *health) scheduled(interval time.Duration, nextSyncAt time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.interval = interval
+	h.nextSyncAt = nextSyncAt
+}
+
+// cycleStarted records a cycle starting
+func (h *health) cycleStarted(startedAt time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cycleStartedAt = startedAt
+	h.nextSyncAt = time.Time{}
+}
+
+// cycleFinished records a cycle finishing with err, returning how many cycles in a row failed before it and including it
+func (h *health) cycleFinished(err error) (before, after int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cycleStartedAt = time.Time{}
+	before = h.consecutiveFailures
+	if err != nil {
+		h.consecutiveFailures++
+	} else {
+		h.consecutiveFailures = 0
+	}
+	return before, h.consecutiveFailures
+}
+
+// recordLastSync records the last sync attempt read from the state file
+func (h *health) recordLastSync(lastSync *state.SyncAttempt) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSync = lastSync
+}
+
+// recordCycle records the outcome of a finished cycle from its decision report
+func (h *health) recordCycle(event events.Event) {
+	if event.Type != events.CycleFinished || event.Report == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCycle = &HealthCycle{FinishedAt: event.Report.FinishedAt, Outcome: event.Report.Outcome,
+		Reason: event.Report.Reason, Error: event.Report.Error}
+}
+
+// status returns the liveness, or with ready the readiness, of the interval loop
+// It is unhealthy when a cycle runs, or the next one is overdue, longer than health.stuck_after (twice the interval by
+// default), and not ready when health.max_consecutive_failures cycles in a row failed
+func (h *health) status(ready bool) HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.clock.Now()
+	status := HealthStatus{Status: HealthStatusOK, ConsecutiveFailures: h.consecutiveFailures, LastCycle: h.lastCycle, LastSync: h.lastSync}
+	stuckAfter := h.cfg.StuckAfter
+	if stuckAfter == 0 {
+		stuckAfter = 2 * h.interval
+	}
+
+	if !h.cycleStartedAt.IsZero() {
+		startedAt := h.cycleStartedAt.UTC()
+		status.CycleStartedAt = &startedAt
+		if running := now.Sub(h.cycleStartedAt); stuckAfter > 0 && running > stuckAfter {
+			status.Problems = append(status.Problems, fmt.Sprintf("sync cycle running for %s, longer than %s", running.Round(time.Second), stuckAfter))
+		}
+	}
+	if !h.nextSyncAt.IsZero() {
+		nextSyncAt := h.nextSyncAt.UTC()
+		status.NextSyncAt = &nextSyncAt
+		if overdue := now.Sub(h.nextSyncAt); stuckAfter > 0 && overdue > stuckAfter {
+			status.Problems = append(status.Problems, fmt.Sprintf("next sync cycle overdue by %s, longer than %s", overdue.Round(time.Second), stuckAfter))
+		}
+	}
+	if len(status.Problems) > 0 {
+		status.Status = HealthStatusUnhealthy
+		return status
+	}
+
+	if ready && h.consecutiveFailures >= max(h.cfg.MaxConsecutiveFailures, 1) {
+		status.Status = HealthStatusNotReady
+		status.Problems = append(status.Problems, fmt.Sprintf("last %d sync cycles failed", h.consecutiveFailures))
+	}
+
+	return status
+}
+
+// handler returns an http.Handler serving the liveness, or with ready the readiness, as JSON - 200 when ok, otherwise 503
+func (h *health) handler(ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := h.status(ready)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if status.Status != HealthStatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(status)
+	})
+}
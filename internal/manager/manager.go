@@ -1,114 +1,777 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
 	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
 	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/fleet"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/metrics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
 )
 
+// ErrCycleWedged is returned when the watchdog abandoned a sync cycle that ran longer than runtime.watchdog.wedged_after
+var ErrCycleWedged = errors.New("sync cycle wedged")
+
+// Options represents the options for creating a new Manager
+type Options struct {
+	// Config is the loaded configuration
+	Config *config.Config
+	// Logger is the parent logger for the manager and everything it creates, defaults to the global logger
+	Logger *log.Logger
+	// Clock drives interval scheduling and all timestamps, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport for all outbound requests, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
 // Manager manages the DoubleZero version sync process
 type Manager struct {
 	cfg        *config.Config
 	logger     *log.Logger
+	clock      clock.Clock
 	doublezero *doublezero.DoubleZero
+	stateStore *state.Store
+	events     *events.Bus
+	notifier   *notify.Dispatcher
+	// anchor is what interval boundaries are aligned to, see sync.anchor
+	anchor string
+	// startedAt is when the manager was created, the startup anchor
+	startedAt time.Time
+	// windows are sync.windows, checked against the interval when running on one
+	windows *maintenance.Schedule
+	// adjustTo are the windows cycles also run when they open, sync.windows with sync.unreachable_windows adjust
+	adjustTo *maintenance.Schedule
+	// health tracks the interval loop for the health and readiness endpoints
+	health *health
+	// statusPage is the HTML status page, showing the last cycle and the state file as of its end
+	statusPage *statusPage
+	// beacon is this host's fleet beacon, nil unless fleet.listen_address is set
+	beacon *fleet.Publisher
+	// doublezeroOptions create a fresh DoubleZero instance when the watchdog abandons a wedged cycle
+	doublezeroOptions doublezero.Options
+	// restartDoubleZero is set when the watchdog abandoned a cycle but creating a fresh instance failed
+	restartDoubleZero bool
+	// onInterval is set when running on an interval, the only mode consecutive failures are tracked across cycles
+	onInterval bool
+	// failing is set once sync_failing was raised, until sync_recovered is
+	failing bool
+	// lastReason is the reason code of the last finished cycle, empty when it synced
+	lastReason string
+	// retryAttempts counts the sync.retry retries of a failed cycle since the last interval boundary
+	retryAttempts int
+	// interval is the RunOnInterval interval, kept across config reloads
+	interval time.Duration
+	// reloadOnSignal is set when the config is reloaded on a signal, see ReloadOnSignal
+	reloadOnSignal bool
+	// reloadRequested is set by a reload signal until the config is reloaded between cycles
+	reloadRequested atomic.Bool
+	// configStamp is the config file as last loaded, to reload it when it changed with config_reload.watch_interval
+	configStamp configStamp
+
+	registry          *metrics.Registry
+	cycleSuccess      *metrics.Gauge
+	cycleDuration     *metrics.Gauge
+	lastCycle         *metrics.Gauge
+	versionMismatch   *metrics.Gauge
+	repoLag           *metrics.Gauge
+	cycleOverruns     *metrics.Counter
+	boundariesSkipped *metrics.Counter
+	notificationQueue *metrics.Gauge
+	lastCycleReason   *metrics.Gauge
+	watchdogAbandoned *metrics.Counter
+	configReloads     *metrics.Counter
+	cycleRetries      *metrics.Counter
 }
 
 // NewFromConfig creates a new Manager from an already loaded config
 func NewFromConfig(cfg *config.Config) (m *Manager, err error) {
+	return New(Options{Config: cfg})
+}
+
+// New creates a new Manager with the given options
+func New(opts Options) (m *Manager, err error) {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
+	cfg := opts.Config
+	registry := metrics.NewRegistry()
 	m = &Manager{
-		cfg:    cfg,
-		logger: log.WithPrefix("manager"),
+		cfg:        cfg,
+		logger:     logging.WithPrefix(opts.Logger, "manager"),
+		clock:      opts.Clock,
+		anchor:     cfg.Sync.Anchor,
+		startedAt:  opts.Clock.Now().UTC(),
+		stateStore: state.NewStore(cfg.State.File),
+		events:     events.NewBus(),
+		health:     &health{cfg: cfg.Health, clock: opts.Clock},
+
+		registry:          registry,
+		cycleSuccess:      registry.NewGauge(metrics.Namespace+"cycle_success", "1 if the last sync cycle succeeded, 0 otherwise."),
+		cycleDuration:     registry.NewGauge(metrics.Namespace+"cycle_duration_seconds", "Duration of the last sync cycle."),
+		lastCycle:         registry.NewGauge(metrics.Namespace+"last_cycle_timestamp_seconds", "Unix time the last sync cycle finished."),
+		versionMismatch:   registry.NewGauge(metrics.Namespace+"installed_version_mismatch", "1 if the installed version didn't match the target after the last sync commands ran, 0 otherwise."),
+		repoLag:           registry.NewGauge(metrics.Namespace+"repo_lag_seconds", "Seconds the target package has been missing from the sync.verify_published repository, 0 when it's published or not checked."),
+		cycleOverruns:     registry.NewCounter(metrics.Namespace+"cycle_overruns_total", "Sync cycles still running when the next interval boundary arrived.", "policy"),
+		boundariesSkipped: registry.NewCounter(metrics.Namespace+"boundaries_skipped_total", "Interval boundaries whose cycle was skipped because a previous cycle overran."),
+		notificationQueue: registry.NewGauge(metrics.Namespace+"notification_queue_depth", "Notifications that failed to deliver, queued for retry."),
+		lastCycleReason:   registry.NewGauge(metrics.Namespace+"last_cycle_reason", "1 for the reason code the last sync cycle ended without syncing, absent when it synced.", "reason"),
+		watchdogAbandoned: registry.NewCounter(metrics.Namespace+"watchdog_abandoned_cycles_total", "Sync cycles abandoned by the watchdog after running longer than runtime.watchdog.wedged_after."),
+		configReloads:     registry.NewCounter(metrics.Namespace+"config_reloads_total", "Config reloads on a signal or config file change, by result.", "result"),
+		cycleRetries:      registry.NewCounter(metrics.Namespace+"cycle_retries_total", "Failed sync cycles retried under sync.retry before the next interval boundary, by reason.", "reason"),
 	}
+	m.cycleOverruns.Add(0, cfg.Sync.OverrunPolicy)
 
-	// Create DoubleZero instance
-	m.doublezero, err = doublezero.New(doublezero.Options{
-		Cluster:          cfg.Cluster.Name,
-		SyncConfig:       cfg.Sync,
-		DoubleZeroConfig: cfg.DoubleZero,
-		ValidatorConfig:  cfg.Validator,
+	m.windows, err = maintenance.NewSchedule(cfg.Sync.Windows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync.windows%w", err)
+	}
+	if cfg.Sync.UnreachableWindows == constants.SyncUnreachableWindowsAdjust {
+		m.adjustTo = m.windows
+	}
+	m.boundariesSkipped.Add(0)
+	m.watchdogAbandoned.Add(0)
+	m.configReloads.Add(0, "success")
+	m.configReloads.Add(0, "failure")
+
+	// the config file and its include files as loaded, to tell when one changes
+	if cfg.File != "" {
+		if m.configStamp, err = statConfig(cfg.Files()...); err != nil {
+			m.logger.Warn("failed to check config file", "file", cfg.File, "error", err)
+		}
+	}
+
+	// notifications are published as events too
+	m.notifier = notify.NewFromConfig(cfg.Notifications, notify.Options{
+		Cluster:   cfg.Cluster.Name,
+		Logger:    opts.Logger,
+		Clock:     opts.Clock,
+		Transport: opts.Transport,
 	})
+	m.notifier.AddNotifier(m.events)
+	m.events.Subscribe(m.recordCycleReason)
+	m.events.Subscribe(m.health.recordCycle)
+	m.statusPage = newStatusPage(cfg.Cluster.Name, m.health)
+	m.events.Subscribe(m.statusPage.recordCycle)
+
+	// the fleet beacon shares the outcome of every cycle with the peers
+	if cfg.Fleet.ListenAddress != "" {
+		secret, err := fleet.ReadSecret(cfg.Fleet.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname: %w", err)
+		}
+		m.beacon = fleet.NewPublisher(hostname, cfg.Cluster.Name, secret, opts.Clock)
+		m.events.Subscribe(func(event events.Event) {
+			if event.Type == events.CycleFinished {
+				m.beacon.RecordCycle(event.Report)
+			}
+		})
+	}
 
+	// every command executed during syncs is recorded in the audit log when enabled
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		auditLog = audit.New(audit.Options{File: cfg.Audit.File, MaxOutputBytes: cfg.Audit.MaxOutputBytes})
+	}
+
+	// Create DoubleZero instance
+	m.doublezeroOptions = doublezero.Options{
+		Cluster:               cfg.Cluster.Name,
+		SyncConfig:            cfg.Sync,
+		DoubleZeroConfig:      cfg.DoubleZero,
+		ValidatorConfig:       cfg.Validator,
+		FailoverConfig:        cfg.Failover,
+		VersionSourceConfig:   cfg.VersionSource,
+		RebootConfig:          cfg.Reboot,
+		HostMaintenanceConfig: cfg.HostMaintenance,
+		FreezeConfig:          cfg.Freeze,
+		FleetConfig:           cfg.Fleet,
+		StateConfig:           cfg.State,
+		StateStore:            m.stateStore,
+		AuditLog:              auditLog,
+		Notifier:              m.notifier,
+		Events:                m.events,
+		Logger:                opts.Logger,
+		Clock:                 opts.Clock,
+		Transport:             opts.Transport,
+		ShutdownGracePeriod:   cfg.Runtime.ShutdownGracePeriod,
+	}
+	m.doublezero, err = doublezero.New(m.doublezeroOptions)
 	if err != nil {
 		return nil, err
 	}
 
 	// manager created
 	m.logger.Debug("created manager from config",
-		"config", cfg,
+		"config", cfg.Redacted(),
 		"doublezero_bin", cfg.DoubleZero.Bin,
 		"validator_rpc_url", cfg.Validator.RPCURL,
-		"validator_has_identities", cfg.Validator.Identities.ActiveKeyPair != nil && cfg.Validator.Identities.PassiveKeyPair != nil)
+		"validator_identity_source", cfg.Validator.IdentitySource.Type,
+		"validator_has_identities", cfg.Validator.Identities.IsConfigured(),
+		"validator_single_identity", cfg.Validator.Identities.IsSingleIdentity())
 	return m, nil
 }
 
-// RunOnce runs a single sync check and exits
-func (m *Manager) RunOnce() error {
+// RunOnce runs a single sync check and exits, the cycle stops early when ctx is done
+func (m *Manager) RunOnce(ctx context.Context) error {
 	m.logger.Info("🚀 starting doublezero-version-sync (single run mode)")
-	return m.doublezero.SyncVersion()
+	m.logLastSync()
+	return m.syncVersion(ctx)
+}
+
+// logLastSync logs the last sync attempt recorded in the state file, so what happened before a restart is visible
+func (m *Manager) logLastSync() {
+	st, err := m.stateStore.Load()
+	if err != nil {
+		m.logger.Warn("failed to load state", "path", m.stateStore.Path(), "error", err)
+		return
+	}
+	m.health.recordLastSync(st.LastSync)
+	m.statusPage.recordState(st)
+	if st.LastSync == nil {
+		m.logger.Debug("no sync attempt recorded yet", "path", m.stateStore.Path())
+		return
+	}
+	m.logger.Info("last sync",
+		"from", st.LastSync.FromVersion,
+		"to", st.LastSync.ToVersion,
+		"result", st.LastSync.Result,
+		"finished_at", st.LastSync.FinishedAt.Format(time.RFC3339),
+		"error", st.LastSync.Error)
+}
+
+// Events returns a channel receiving the sync lifecycle events - cycles starting and finishing with their decision
+// report, sync commands running and notifications - until the returned cancel function is called, which closes it.
+// Events published while the channel's buffer is full are dropped rather than blocking the sync
+func (m *Manager) Events(buffer int) (<-chan events.Event, func()) {
+	return m.events.Channel(buffer)
+}
+
+// OnEvent calls handler with every sync lifecycle event until the returned unsubscribe function is called
+// The handler is called in the sync goroutine and must not block
+func (m *Manager) OnEvent(handler func(events.Event)) (unsubscribe func()) {
+	return m.events.Subscribe(handler)
+}
+
+// Handler returns an http.Handler serving the sync cycle metrics
+func (m *Manager) Handler() http.Handler {
+	return m.registry.Handler()
+}
+
+// HealthHandler returns an http.Handler serving the liveness of RunOnInterval as JSON, 503 when a cycle is wedged or
+// the next one overdue
+func (m *Manager) HealthHandler() http.Handler {
+	return m.health.handler(false)
+}
+
+// ReadyHandler returns an http.Handler serving the readiness of RunOnInterval as JSON, 503 when it isn't live or
+// health.max_consecutive_failures cycles in a row failed
+func (m *Manager) ReadyHandler() http.Handler {
+	return m.health.handler(true)
+}
+
+// StatusPageHandler returns an http.Handler serving a read-only HTML status page - the installed and recommended
+// versions, how long the installed version has drifted from the recommendation, the health of RunOnInterval and the next
+// sync time, the gate report of the last cycle and the most recent sync attempts
+func (m *Manager) StatusPageHandler() http.Handler {
+	return m.statusPage.handler()
+}
+
+// FleetBeaconHandler returns an http.Handler serving this host's signed fleet beacon, nil unless fleet.listen_address
+// is set
+func (m *Manager) FleetBeaconHandler() http.Handler {
+	if m.beacon == nil {
+		return nil
+	}
+	return m.beacon.Handler()
+}
+
+// syncVersion runs a sync cycle, or evaluates one without executing anything when sync.dry_run is set, and records
+// its metrics
+func (m *Manager) syncVersion(ctx context.Context) (err error) {
+	startedAt := m.clock.Now()
+	m.health.cycleStarted(startedAt)
+	m.lastReason = ""
+	defer func() {
+		m.notifyFailing(m.health.cycleFinished(err))
+		finishedAt := m.clock.Now()
+		if st, loadErr := m.stateStore.Load(); loadErr == nil {
+			m.health.recordLastSync(st.LastSync)
+			m.statusPage.recordState(st)
+			m.repoLag.Set(0)
+			if st.RepoLag != nil {
+				m.repoLag.Set(finishedAt.Sub(st.RepoLag.Since).Seconds())
+			}
+		}
+		m.cycleSuccess.SetBool(err == nil)
+		m.versionMismatch.SetBool(errors.Is(err, doublezero.ErrInstalledVersionMismatch))
+		m.cycleDuration.Set(finishedAt.Sub(startedAt).Seconds())
+		m.lastCycle.Set(float64(finishedAt.Unix()))
+		m.updateNotificationQueueDepth()
+	}()
+
+	if !m.cfg.Sync.DryRun {
+		m.notifier.Retry()
+		return m.runCycle(func(dz *doublezero.DoubleZero) error {
+			return dz.SyncVersion(ctx)
+		})
+	}
+
+	m.logger.Warn("dry run - evaluating sync cycle without executing anything (sync.dry_run=true)")
+	return m.runCycle(func(dz *doublezero.DoubleZero) error {
+		rep, err := dz.Simulate(ctx)
+		m.logger.Info("dry run finished", "outcome", rep.Outcome)
+		return err
+	})
+}
+
+// runCycle runs cycle against the DoubleZero instance. With runtime.watchdog enabled it runs on its own goroutine and
+// when still running after wedged_after (wall time) it is logged with the goroutine stacks and abandoned - it runs no
+// further sync commands and its outcome is discarded - and replaced with a fresh instance for the next cycle
+func (m *Manager) runCycle(cycle func(dz *doublezero.DoubleZero) error) error {
+	if m.restartDoubleZero {
+		if err := m.restartWedgedDoubleZero(); err != nil {
+			return err
+		}
+	}
+
+	wedgedAfter := m.cfg.Runtime.Watchdog.WedgedAfter
+	if !m.cfg.Runtime.Watchdog.IsEnabled() {
+		return cycle(m.doublezero)
+	}
+
+	dz := m.doublezero
+	done := make(chan error, 1)
+	go func() {
+		done <- cycle(dz)
+	}()
+
+	timer := time.NewTimer(wedgedAfter)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	stack := make([]byte, 1<<20)
+	stack = stack[:runtime.Stack(stack, true)]
+	m.logger.Error("sync cycle wedged - abandoning it and restarting the sync goroutine", "wedged_after", wedgedAfter.String(), "goroutines", string(stack))
+	dz.Abandon()
+	m.watchdogAbandoned.Inc()
+	m.restartDoubleZero = true
+
+	err := fmt.Errorf("%w - still running after %s (runtime.watchdog.wedged_after)", ErrCycleWedged, wedgedAfter)
+	if restartErr := m.restartWedgedDoubleZero(); restartErr != nil {
+		return errors.Join(err, restartErr)
+	}
+	return err
+}
+
+// restartWedgedDoubleZero replaces the DoubleZero instance of an abandoned cycle with a fresh one
+func (m *Manager) restartWedgedDoubleZero() error {
+	dz, err := doublezero.New(m.doublezeroOptions)
+	if err != nil {
+		return fmt.Errorf("failed to restart the sync goroutine after the watchdog abandoned a wedged cycle: %w", err)
+	}
+	m.doublezero = dz
+	m.restartDoubleZero = false
+	return nil
+}
+
+// Simulate evaluates a single sync cycle without side effects and returns its decision report
+func (m *Manager) Simulate(ctx context.Context) (*report.Report, error) {
+	m.logger.Info("🔍 simulating doublezero-version-sync cycle")
+	return m.doublezero.Simulate(ctx)
+}
+
+// Status observes the installed and recommended versions, the validator identity and the last sync without side effects
+func (m *Manager) Status(ctx context.Context) *doublezero.Status {
+	return m.doublezero.Status(ctx)
+}
+
+// UpgradePath computes the path from the from version to the to version, the installed and recommended versions when
+// empty, without side effects
+func (m *Manager) UpgradePath(ctx context.Context, from, to string) (*doublezero.UpgradePath, error) {
+	return m.doublezero.UpgradePath(ctx, from, to)
 }
 
 // RunOnInterval runs the sync manager continuously at the specified interval, errors are logged but not returned after parsing the interval duration string
-func (m *Manager) RunOnInterval(intervalDuration time.Duration) (err error) {
-	m.logger.Info("🚀 starting doublezero-version-sync (continuous mode)", "interval", intervalDuration.String())
+// It returns nil once ctx is done, after the running cycle stopped - see runtime.shutdown_grace_period
+func (m *Manager) RunOnInterval(ctx context.Context, intervalDuration time.Duration) (err error) {
+	m.logger.Info("🚀 starting doublezero-version-sync (continuous mode)", "interval", intervalDuration.String(), "anchor", m.anchor)
+	m.onInterval = true
+	m.interval = intervalDuration
+	if err := m.checkWindows(intervalDuration); err != nil {
+		return err
+	}
+	m.logLastSync()
 
-	// Calculate the next boundary time based on the interval
-	now := time.Now().UTC()
+	// Calculate the next boundary time based on the interval, cycles anchored to startup or with sync.run_on_start run
+	// immediately - the boundary following the first cycle's start is its deadline, so the next ones align to the anchor
+	now := m.clock.Now().UTC()
 	nextSyncTime := m.calculateNextBoundary(now, intervalDuration)
+	switch {
+	case m.anchor == constants.SyncAnchorStartup:
+		nextSyncTime = now
+	case m.cfg.Sync.RunOnStart:
+		m.logger.Info("running sync on start, then aligning to interval boundaries", "next_boundary", nextSyncTime.Format("2006-01-02T15:04:05Z"))
+		nextSyncTime = now
+	}
+	m.health.scheduled(intervalDuration, nextSyncTime)
 
 	// Wait until the first boundary before starting
 	if nextSyncTime.After(now) {
 		waitDuration := nextSyncTime.Sub(now)
 		m.logger.Info("waiting until next interval boundary", "wait", waitDuration.String(), "next_sync", nextSyncTime.Format("2006-01-02T15:04:05Z"))
-		time.Sleep(waitDuration)
+		m.waitForNextSync(ctx, nextSyncTime)
 	}
 
 	// Run sync on a loop, aligning to interval boundaries
 	for {
-		m.runSyncVersionInterval(intervalDuration)
+		if ctx.Err() != nil {
+			m.logger.Info("👋 shutting down")
+			return nil
+		}
 
-		// Calculate next boundary time
-		now = time.Now().UTC()
-		nextSyncTime = m.calculateNextBoundary(now, intervalDuration)
-		waitDuration := nextSyncTime.Sub(now)
+		// the boundary following a cycle's start is its deadline, running past it is an overrun
+		deadline := m.calculateNextBoundary(m.clock.Now().UTC(), intervalDuration)
+		if m.cfg.Sync.OverrunPolicy == constants.SyncOverrunPolicyAbortCurrent {
+			m.doublezero.SetDeadline(deadline)
+		}
+
+		m.logger.Info("running sync")
+		err := m.syncVersion(ctx)
 
-		if waitDuration > 0 {
-			time.Sleep(waitDuration)
+		now = m.clock.Now().UTC()
+		nextSyncTime = m.nextSyncAfterCycle(deadline, now, intervalDuration)
+		if retryAt, ok := m.retryAt(err, now, nextSyncTime); ok {
+			nextSyncTime = retryAt
+		}
+		m.logCycleResult(err, now, nextSyncTime)
+		m.health.scheduled(intervalDuration, nextSyncTime)
+
+		if nextSyncTime.After(now) {
+			m.waitForNextSync(ctx, nextSyncTime)
 		}
 	}
 }
 
-// calculateNextBoundary calculates the next time boundary based on the interval duration
-// For example, if interval is 10m and current time is 9:53, it returns 10:00
-// Boundaries align with clock times (e.g., for 5m: :00, :05, :10, :15, etc.)
+// retryAt returns when a failed interval cycle is retried under sync.retry, with exponential backoff, false when it
+// isn't - it didn't fail, failed for a reason that isn't retryable, ran out of attempts or the next sync comes first
+func (m *Manager) retryAt(err error, now, nextSyncTime time.Time) (time.Time, bool) {
+	retry := m.cfg.Sync.Retry
+	if err == nil || !retry.IsEnabled() {
+		m.retryAttempts = 0
+		return time.Time{}, false
+	}
+	if !retry.IsRetryable(m.lastReason) {
+		m.logger.Debug("failed sync not retryable - waiting for the next sync", "reason", m.lastReason)
+		m.retryAttempts = 0
+		return time.Time{}, false
+	}
+	if m.retryAttempts >= retry.MaxAttempts {
+		m.logger.Warn("failed sync retries exhausted - waiting for the next sync", "reason", m.lastReason, "attempts", m.retryAttempts)
+		m.retryAttempts = 0
+		return time.Time{}, false
+	}
+
+	backoff := retry.Backoff(m.retryAttempts + 1)
+	retryAt := now.Add(backoff)
+	if !retryAt.Before(nextSyncTime) {
+		m.retryAttempts = 0
+		return time.Time{}, false
+	}
+	m.retryAttempts++
+	m.cycleRetries.Inc(m.lastReason)
+	m.logger.Info("retrying failed sync", "reason", m.lastReason, "attempt", m.retryAttempts, "max_attempts", retry.MaxAttempts,
+		"backoff", backoff.String())
+	return retryAt, true
+}
+
+// notifyFailing raises sync_failing on every failed cycle once health.max_consecutive_failures cycles in a row failed,
+// and sync_recovered on the first cycle to succeed after, given the consecutive failures before and after the cycle
+// Only when running on an interval - a single run has no cycles before it to count - and not in dry runs
+func (m *Manager) notifyFailing(failedBefore, failedAfter int) {
+	if !m.onInterval || m.cfg.Sync.DryRun {
+		return
+	}
+
+	if failedAfter >= m.cfg.Health.MaxConsecutiveFailures && failedAfter > 0 {
+		m.failing = true
+		fields := map[string]string{"consecutive_failures": fmt.Sprintf("%d", failedAfter)}
+		message := fmt.Sprintf("DoubleZero sync failing - last %d sync cycles failed", failedAfter)
+		if lastCycle := m.health.status(false).LastCycle; lastCycle != nil && lastCycle.Error != "" {
+			fields["reason"] = lastCycle.Reason
+			fields["error"] = lastCycle.Error
+			message += ": " + lastCycle.Error
+		}
+		m.notifier.Notify(notify.Event{
+			Type:     notify.EventSyncFailing,
+			Severity: constants.NotificationSeverityCritical,
+			Message:  message,
+			Fields:   fields,
+		})
+		return
+	}
+
+	if failedAfter == 0 && m.failing {
+		m.failing = false
+		m.notifier.Notify(notify.Event{
+			Type:     notify.EventSyncRecovered,
+			Severity: constants.NotificationSeverityInfo,
+			Message:  fmt.Sprintf("DoubleZero sync recovered after %d failed sync cycles", failedBefore),
+			Fields:   map[string]string{"consecutive_failures": fmt.Sprintf("%d", failedBefore)},
+		})
+	}
+}
+
+// recordCycleReason sets the last cycle reason metric from a finished cycle's report
+func (m *Manager) recordCycleReason(event events.Event) {
+	if event.Type != events.CycleFinished || event.Report == nil {
+		return
+	}
+	m.lastReason = event.Report.Reason
+	m.lastCycleReason.Reset()
+	if event.Report.Reason != "" {
+		m.lastCycleReason.Set(1, event.Report.Reason)
+	}
+}
+
+// updateNotificationQueueDepth sets the notification queue depth metric
+func (m *Manager) updateNotificationQueueDepth() {
+	depth, err := m.notifier.QueueDepth()
+	if err != nil {
+		m.logger.Warn("failed to read notification queue depth", "error", err)
+		return
+	}
+	m.notificationQueue.Set(float64(depth))
+}
+
+// waitForNextSync sleeps until nextSyncTime, returning early when the validator identity files are rotated or the
+// doublezero binary changed out of band, so the next cycle re-evaluates gating and drift right away. The binary is
+// watched with inotify, falling back to checking it every doublezero.watch_interval when it can't be. Queued
+// notifications are retried while waiting, endpoints may come back long before the next cycle. The config is reloaded
+// on a reload signal or when the file changed, waiting on for the same next sync time. Returns early once ctx is done
+func (m *Manager) waitForNextSync(ctx context.Context, nextSyncTime time.Time) {
+	watchConfig := m.cfg.ConfigReload.WatchInterval > 0 && m.cfg.File != ""
+	watchInterval := m.cfg.Validator.Identities.WatchInterval
+	watchIdentities := watchInterval > 0 && m.cfg.Validator.IsEnabled()
+	watchBinary := m.cfg.DoubleZero.WatchInterval > 0
+	retryNotifications := !m.cfg.Sync.DryRun && m.cfg.Notifications.Queue.IsEnabled()
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	var binaryChanged <-chan struct{}
+	pollBinary := false
+	if watchBinary {
+		var err error
+		binaryChanged, err = m.doublezero.WatchBinary(watchCtx)
+		if err != nil {
+			m.logger.Warn("failed to watch doublezero binary - checking it every doublezero.watch_interval", "error", err)
+			pollBinary = true
+		}
+	}
+
+	var pollInterval time.Duration
+	for _, interval := range []struct {
+		enabled  bool
+		interval time.Duration
+	}{
+		{watchIdentities, watchInterval},
+		{pollBinary, m.cfg.DoubleZero.WatchInterval},
+		{retryNotifications, m.cfg.Notifications.Queue.InitialBackoff},
+		{watchConfig, m.cfg.ConfigReload.WatchInterval},
+		{m.reloadOnSignal, signalCheckInterval},
+	} {
+		if interval.enabled && (pollInterval == 0 || interval.interval < pollInterval) {
+			pollInterval = interval.interval
+		}
+	}
+	if pollInterval == 0 && binaryChanged == nil {
+		clock.SleepContext(ctx, m.clock, nextSyncTime.Sub(m.clock.Now()))
+		return
+	}
+
+	for {
+		remaining := nextSyncTime.Sub(m.clock.Now())
+		if remaining <= 0 {
+			return
+		}
+		if pollInterval > 0 {
+			remaining = min(remaining, pollInterval)
+		}
+		woken, err := m.sleepUntilWoken(ctx, remaining, binaryChanged)
+		if err != nil {
+			return
+		}
+
+		// the reloaded config may watch at other intervals
+		if m.checkConfigReload() {
+			stopWatch()
+			m.waitForNextSync(ctx, nextSyncTime)
+			return
+		}
+		if retryNotifications {
+			m.notifier.Retry()
+			m.updateNotificationQueueDepth()
+		}
+		if woken || pollBinary {
+			changed, err := m.doublezero.CheckBinary()
+			if err != nil {
+				m.logger.Warn("failed to check doublezero binary", "error", err)
+			}
+			if changed {
+				m.logger.Info("doublezero binary changed - re-evaluating sync now", "next_sync_was", nextSyncTime.Format(time.RFC3339))
+				return
+			}
+		}
+		if !watchIdentities {
+			continue
+		}
+
+		changed, err := m.doublezero.RefreshIdentities()
+		if err != nil {
+			m.logger.Warn("failed to reload validator identities", "error", err)
+			continue
+		}
+		if changed {
+			m.logger.Info("validator identities changed - re-evaluating sync now", "next_sync_was", nextSyncTime.Format(time.RFC3339))
+			return
+		}
+	}
+}
+
+// sleepUntilWoken sleeps on the manager's clock for d, returning early with woken set when wake receives. Returns
+// ctx's error once it's done
+func (m *Manager) sleepUntilWoken(ctx context.Context, d time.Duration, wake <-chan struct{}) (bool, error) {
+	sleepCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	slept := make(chan error, 1)
+	go func() {
+		slept <- clock.SleepContext(sleepCtx, m.clock, d)
+	}()
+	select {
+	case err := <-slept:
+		return false, err
+	case <-wake:
+		return true, nil
+	}
+}
+
+// nextSyncAfterCycle returns when the next cycle runs after one that finished at now, applying sync.overrun_policy
+// when it ran past its deadline - skip_next waits for the following boundary, queue_one runs one cycle immediately and
+// abort_current, whose cycle stopped at the deadline, runs the cycle it made way for immediately
+func (m *Manager) nextSyncAfterCycle(deadline, now time.Time, intervalDuration time.Duration) time.Time {
+	if now.Before(deadline) {
+		return deadline
+	}
+
+	missedBoundaries := 0
+	for boundary := deadline; !boundary.After(now); boundary = m.calculateNextBoundary(boundary, intervalDuration) {
+		missedBoundaries++
+	}
+
+	policy := m.cfg.Sync.OverrunPolicy
+	m.cycleOverruns.Inc(policy)
+
+	if policy == constants.SyncOverrunPolicyQueueOne || policy == constants.SyncOverrunPolicyAbortCurrent {
+		m.logger.Warn("sync cycle ran past the next boundary - running the next cycle now",
+			"deadline", deadline.Format(time.RFC3339), "missed_boundaries", missedBoundaries, "overrun_policy", policy)
+		m.boundariesSkipped.Add(float64(missedBoundaries - 1))
+		return now
+	}
+
+	m.logger.Warn("sync cycle ran past the next boundary - skipping to the following one",
+		"deadline", deadline.Format(time.RFC3339), "skipped_boundaries", missedBoundaries, "overrun_policy", policy)
+	m.boundariesSkipped.Add(float64(missedBoundaries))
+	return m.calculateNextBoundary(now, intervalDuration)
+}
+
+// calculateNextBoundary calculates the next time boundary based on the interval duration and the manager's anchor,
+// the next opening of one of sync.windows when it comes first and sync.unreachable_windows is adjust
 func (m *Manager) calculateNextBoundary(now time.Time, intervalDuration time.Duration) time.Time {
-	// Truncate to the start of the day (midnight)
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return nextRun(m.anchor, now, m.startedAt, intervalDuration, m.adjustTo)
+}
 
-	// Calculate duration since midnight
-	durationSinceMidnight := now.Sub(startOfDay)
+// NextRuns returns the next count sync times after now when running on the given interval aligned to the given anchor,
+// including the openings of the windows of adjustTo when not nil
+// A startup anchor previews a process started at now
+func NextRuns(anchor string, now time.Time, intervalDuration time.Duration, count int, adjustTo *maintenance.Schedule) []time.Time {
+	startedAt := now
+	runs := make([]time.Time, 0, count)
+	for range count {
+		now = nextRun(anchor, now, startedAt, intervalDuration, adjustTo)
+		runs = append(runs, now)
+	}
+	return runs
+}
 
-	// Truncate to the previous interval boundary
-	truncatedDuration := durationSinceMidnight.Truncate(intervalDuration)
+// NextBoundary calculates the next time boundary based on the interval duration
+// For example, if interval is 10m and current time is 9:53, it returns 10:00
+// Boundaries align with clock times (e.g., for 5m: :00, :05, :10, :15, etc.)
+func NextBoundary(now time.Time, intervalDuration time.Duration) time.Time {
+	return NextBoundaryFrom(AnchorTime(constants.SyncAnchorMidnight, now, time.Time{}), now, intervalDuration)
+}
 
-	// Add one interval to get the next boundary
-	nextBoundaryDuration := truncatedDuration + intervalDuration
+// AnchorTime returns the time that boundaries following now are aligned to for the given sync.anchor - the start of
+// now's day for midnight (the default), the most recent daily HH:MM at or before now for a custom time, the unix
+// epoch for epoch and startedAt for startup
+func AnchorTime(anchor string, now, startedAt time.Time) time.Time {
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	// Calculate the next boundary time
-	nextBoundary := startOfDay.Add(nextBoundaryDuration)
+	switch anchor {
+	case constants.SyncAnchorEpoch:
+		return time.Unix(0, 0).In(now.Location())
+	case constants.SyncAnchorStartup:
+		return startedAt
+	case constants.SyncAnchorMidnight, "":
+		return startOfDay
+	}
 
-	return nextBoundary
+	anchorTime, err := time.Parse(constants.SyncAnchorTimeLayout, anchor)
+	if err != nil {
+		return startOfDay
+	}
+	dailyAnchor := startOfDay.Add(time.Duration(anchorTime.Hour())*time.Hour + time.Duration(anchorTime.Minute())*time.Minute)
+	if dailyAnchor.After(now) {
+		dailyAnchor = dailyAnchor.AddDate(0, 0, -1)
+	}
+	return dailyAnchor
 }
 
-// runSyncVersionInterval runs the sync version and logs the result without returning an error - used with on interval mode
-func (m *Manager) runSyncVersionInterval(intervalDuration time.Duration) {
-	m.logger.Info("running sync")
-	err := m.doublezero.SyncVersion()
-	now := time.Now().UTC()
-	nextSyncTime := m.calculateNextBoundary(now, intervalDuration)
+// NextBoundaryFrom calculates the first boundary after now of intervals aligned to the given anchor time
+func NextBoundaryFrom(anchorTime, now time.Time, intervalDuration time.Duration) time.Time {
+	// Truncate the time since the anchor to the previous interval boundary and add one interval to get the next one
+	return anchorTime.Add(now.Sub(anchorTime).Truncate(intervalDuration) + intervalDuration)
+}
 
+// logCycleResult logs the result of an interval cycle and when the next one runs
+func (m *Manager) logCycleResult(err error, now, nextSyncTime time.Time) {
 	// Set result string
 	resultString := "succeeded"
 	if err != nil {
@@ -0,0 +1,1652 @@
+package manager
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/lockfile"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/metrics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+func TestCalculateNextBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "10m interval mid-interval",
+			now:      time.Date(2025, 1, 1, 9, 53, 0, 0, time.UTC),
+			interval: 10 * time.Minute,
+			want:     time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "on a boundary moves to the next one",
+			now:      time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+			interval: 5 * time.Minute,
+			want:     time.Date(2025, 1, 1, 10, 5, 0, 0, time.UTC),
+		},
+		{
+			name:     "1h interval crosses midnight",
+			now:      time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC),
+			interval: time.Hour,
+			want:     time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := clock.NewFake(tt.now)
+			m := &Manager{clock: fakeClock}
+			if got := m.calculateNextBoundary(fakeClock.Now(), tt.interval); !got.Equal(tt.want) {
+				t.Errorf("calculateNextBoundary() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	now := time.Date(2025, 1, 5, 1, 40, 0, 0, time.UTC)
+	got := NextRuns(constants.SyncAnchorMidnight, now, 15*time.Minute, 3, nil)
+	want := []time.Time{
+		time.Date(2025, 1, 5, 1, 45, 0, 0, time.UTC),
+		time.Date(2025, 1, 5, 2, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 5, 2, 15, 0, 0, time.UTC),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("NextRuns() returned %d runs, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("NextRuns()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCalculateNextBoundaryAnchors(t *testing.T) {
+	startedAt := time.Date(2025, 1, 1, 8, 17, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		anchor   string
+		now      time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "midnight restarts 7h boundaries each day",
+			anchor:   constants.SyncAnchorMidnight,
+			now:      time.Date(2025, 1, 2, 0, 30, 0, 0, time.UTC),
+			interval: 7 * time.Hour,
+			want:     time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "epoch keeps an unbroken 7h cadence across days",
+			anchor:   constants.SyncAnchorEpoch,
+			now:      time.Date(2025, 1, 3, 0, 30, 0, 0, time.UTC),
+			interval: 7 * time.Hour,
+			want:     time.Date(2025, 1, 3, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "startup aligns to process start",
+			anchor:   constants.SyncAnchorStartup,
+			now:      time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+			interval: 30 * time.Minute,
+			want:     time.Date(2025, 1, 1, 9, 17, 0, 0, time.UTC),
+		},
+		{
+			name:     "custom time after now today anchors to yesterday",
+			anchor:   "06:30",
+			now:      time.Date(2025, 1, 2, 5, 0, 0, 0, time.UTC),
+			interval: 4 * time.Hour,
+			want:     time.Date(2025, 1, 2, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "custom time before now",
+			anchor:   "06:30",
+			now:      time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC),
+			interval: 4 * time.Hour,
+			want:     time.Date(2025, 1, 2, 10, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{clock: clock.NewFake(tt.now), anchor: tt.anchor, startedAt: startedAt}
+			if got := m.calculateNextBoundary(tt.now, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("calculateNextBoundary() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWindows(t *testing.T) {
+	// Monday noon
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	weekly := maintenance.Window{Name: "weekly", Cron: "0 3 * * 0", Duration: 5 * time.Minute}
+	nightly := maintenance.Window{Name: "nightly", Start: "05:30", End: "06:30"}
+
+	tests := []struct {
+		name          string
+		windows       []maintenance.Window
+		anchor        string
+		interval      time.Duration
+		wantReached   []string
+		wantUnreached []string
+	}{
+		{
+			name:          "6h boundaries never hit a 5 minute weekly window",
+			windows:       []maintenance.Window{weekly},
+			anchor:        constants.SyncAnchorMidnight,
+			interval:      6 * time.Hour,
+			wantUnreached: []string{"weekly"},
+		},
+		{
+			name:        "5m boundaries hit it",
+			windows:     []maintenance.Window{weekly},
+			anchor:      constants.SyncAnchorMidnight,
+			interval:    5 * time.Minute,
+			wantReached: []string{"weekly"},
+		},
+		{
+			name:        "an anchor moves the boundaries into it",
+			windows:     []maintenance.Window{weekly},
+			anchor:      "03:00",
+			interval:    6 * time.Hour,
+			wantReached: []string{"weekly"},
+		},
+		{
+			name:          "some windows reached",
+			windows:       []maintenance.Window{weekly, nightly},
+			anchor:        constants.SyncAnchorMidnight,
+			interval:      6 * time.Hour,
+			wantReached:   []string{"nightly"},
+			wantUnreached: []string{"weekly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := maintenance.NewSchedule(tt.windows)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			reach := CheckWindows(schedule, tt.anchor, now, tt.interval)
+			if !slices.Equal(reach.Reached, tt.wantReached) || !slices.Equal(reach.Unreached, tt.wantUnreached) {
+				t.Errorf("CheckWindows() reached %v unreached %v, want %v and %v", reach.Reached, reach.Unreached, tt.wantReached, tt.wantUnreached)
+			}
+			if err := reach.Err(tt.anchor, tt.interval); (err != nil) != (len(tt.wantReached) == 0) {
+				t.Errorf("Err() = %v", err)
+			}
+		})
+	}
+}
+
+func TestCalculateNextBoundaryAdjustedToWindows(t *testing.T) {
+	schedule, err := maintenance.NewSchedule([]maintenance.Window{{Name: "weekly", Cron: "0 3 * * 0", Duration: 5 * time.Minute}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := &Manager{anchor: constants.SyncAnchorMidnight, adjustTo: schedule}
+
+	// Sunday, the window opens between the midnight and 06:00 boundaries
+	sunday := time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)
+	now := sunday
+	for _, want := range []time.Time{sunday.Add(3 * time.Hour), sunday.Add(6 * time.Hour)} {
+		got := m.calculateNextBoundary(now, 6*time.Hour)
+		if !got.Equal(want) {
+			t.Errorf("calculateNextBoundary(%s) = %s, want %s", now, got, want)
+		}
+		now = got
+	}
+}
+
+func TestNextSyncAfterCycle(t *testing.T) {
+	deadline := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		policy      string
+		finishedAt  time.Time
+		want        time.Time
+		wantSkipped string
+	}{
+		{
+			name:        "finished before the deadline waits for it",
+			policy:      constants.SyncOverrunPolicySkipNext,
+			finishedAt:  time.Date(2025, 1, 1, 9, 58, 0, 0, time.UTC),
+			want:        deadline,
+			wantSkipped: "0",
+		},
+		{
+			name:        "skip_next waits for the boundary after the overrun",
+			policy:      constants.SyncOverrunPolicySkipNext,
+			finishedAt:  time.Date(2025, 1, 1, 10, 12, 0, 0, time.UTC),
+			want:        time.Date(2025, 1, 1, 10, 15, 0, 0, time.UTC),
+			wantSkipped: "1",
+		},
+		{
+			name:        "queue_one runs immediately",
+			policy:      constants.SyncOverrunPolicyQueueOne,
+			finishedAt:  time.Date(2025, 1, 1, 10, 20, 0, 0, time.UTC),
+			want:        time.Date(2025, 1, 1, 10, 20, 0, 0, time.UTC),
+			wantSkipped: "1",
+		},
+		{
+			name:        "abort_current runs the cycle it made way for immediately",
+			policy:      constants.SyncOverrunPolicyAbortCurrent,
+			finishedAt:  deadline,
+			want:        deadline,
+			wantSkipped: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Sync:          config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: tt.policy},
+				VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+			}
+			m, err := New(Options{Config: cfg, Clock: clock.NewFake(tt.finishedAt)})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := m.nextSyncAfterCycle(deadline, tt.finishedAt, 15*time.Minute); !got.Equal(tt.want) {
+				t.Errorf("nextSyncAfterCycle() = %s, want %s", got, tt.want)
+			}
+
+			rec := httptest.NewRecorder()
+			m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			if want := "doublezero_version_sync_boundaries_skipped_total " + tt.wantSkipped + "\n"; !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("metrics missing %q, got:\n%s", want, rec.Body.String())
+			}
+		})
+	}
+}
+
+// writeKeypair writes a solana-keygen keypair file for a new identity to the given path
+func writeKeypair(t *testing.T, file string) {
+	t.Helper()
+	privateKey := solana.NewWallet().PrivateKey
+	keypair := make([]int, len(privateKey))
+	for i, b := range privateKey {
+		keypair[i] = int(b)
+	}
+	contents, err := json.Marshal(keypair)
+	if err != nil {
+		t.Fatalf("failed to marshal keypair: %v", err)
+	}
+	if err := os.WriteFile(file, contents, 0o600); err != nil {
+		t.Fatalf("failed to write keypair: %v", err)
+	}
+}
+
+// rotatingClock is a fake clock that calls onSleep after each sleep
+type rotatingClock struct {
+	*clock.Fake
+	sleeps  int
+	onSleep func(sleeps int)
+}
+
+func (c *rotatingClock) Sleep(d time.Duration) {
+	c.Fake.Sleep(d)
+	c.sleeps++
+	c.onSleep(c.sleeps)
+}
+
+func TestWaitForNextSyncReturnsOnIdentityRotation(t *testing.T) {
+	activeFile := filepath.Join(t.TempDir(), "active.json")
+	writeKeypair(t, activeFile)
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := &rotatingClock{Fake: clock.NewFake(start)}
+	fakeClock.onSleep = func(sleeps int) {
+		if sleeps == 3 {
+			writeKeypair(t, activeFile)
+			// make sure the rotation is visible even on filesystems with coarse modification times
+			later := time.Now().Add(time.Minute)
+			if err := os.Chtimes(activeFile, later, later); err != nil {
+				t.Fatalf("failed to touch keypair: %v", err)
+			}
+		}
+	}
+
+	cfg := &config.Config{
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext},
+		Validator: config.Validator{
+			RPCURL:     "http://127.0.0.1:1",
+			Identities: config.Identities{ActiveKeyPairFile: activeFile, WatchInterval: 10 * time.Second},
+		},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: fakeClock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.waitForNextSync(context.Background(), start.Add(time.Hour))
+	if got, want := fakeClock.Now(), start.Add(30*time.Second); !got.Equal(want) {
+		t.Errorf("waitForNextSync() returned at %s, want %s right after the rotation", got, want)
+	}
+}
+
+func TestWaitForNextSyncReturnsOnBinaryChange(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "doublezero")
+	writeBin := func(version string) {
+		if err := os.WriteFile(bin+".tmp", []byte("#!/bin/sh\necho \"DoubleZero "+version+"\"\n"), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.Rename(bin+".tmp", bin); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	writeBin("0.7.1")
+
+	cfg := &config.Config{
+		Cluster:       config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero:    config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}, WatchInterval: time.Hour},
+		Sync:          config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	outOfBand := make(chan events.Event, 1)
+	m.OnEvent(func(event events.Event) {
+		if event.Type == events.OutOfBandChange {
+			outOfBand <- event
+		}
+	})
+	// the first cycle records the binary it left installed
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	// a manual upgrade while waiting, long before doublezero.watch_interval
+	m.clock = clock.Real{}
+	waited := make(chan struct{})
+	go func() {
+		m.waitForNextSync(context.Background(), time.Now().Add(time.Minute))
+		close(waited)
+	}()
+	// upgraded again until noticed, the watch starts in the background and reports a change once it settled for a second
+	time.Sleep(100 * time.Millisecond)
+	for deadline := time.After(10 * time.Second); ; {
+		writeBin("0.8.0")
+		select {
+		case <-waited:
+		case <-time.After(2 * time.Second):
+			continue
+		case <-deadline:
+			t.Fatal("waitForNextSync() didn't return after the binary changed")
+		}
+		break
+	}
+
+	select {
+	case event := <-outOfBand:
+		if event.FromVersion != "0.7.1" || event.ToVersion != "0.8.0" {
+			t.Errorf("out of band change = %s -> %s, want 0.7.1 -> 0.8.0", event.FromVersion, event.ToVersion)
+		}
+	default:
+		t.Error("no out of band change published")
+	}
+}
+
+func TestRunOnIntervalReturnsWhenContextDone(t *testing.T) {
+	cfg := &config.Config{
+		Cluster:       config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero:    config.DoubleZero{Bin: filepath.Join(t.TempDir(), "missing-doublezero")},
+		Sync:          config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext},
+		State:         config.State{File: filepath.Join(t.TempDir(), "state.json")},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ch, cancelEvents := m.Events(10)
+	defer cancelEvents()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.RunOnInterval(ctx, time.Hour); err != nil {
+		t.Fatalf("RunOnInterval() error = %v, want nil on shutdown", err)
+	}
+	select {
+	case event := <-ch:
+		t.Errorf("RunOnInterval() published %s, want no cycle run after shutdown", event.Type)
+	default:
+	}
+}
+
+func TestRunOnIntervalRunOnStart(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 20, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		anchor     string
+		runOnStart bool
+		want       time.Time
+	}{
+		{name: "waits for the first boundary", anchor: constants.SyncAnchorMidnight, want: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{name: "run on start", anchor: constants.SyncAnchorMidnight, runOnStart: true, want: start},
+		{name: "startup anchor", anchor: constants.SyncAnchorStartup, want: start},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Cluster:       config.Cluster{Name: constants.ClusterNameTestnet},
+				DoubleZero:    config.DoubleZero{Bin: filepath.Join(t.TempDir(), "missing-doublezero")},
+				Sync:          config.Sync{Anchor: tt.anchor, OverrunPolicy: constants.SyncOverrunPolicySkipNext, RunOnStart: tt.runOnStart},
+				State:         config.State{File: filepath.Join(t.TempDir(), "state.json")},
+				VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+			}
+			fakeClock := clock.NewFake(start)
+			m, err := New(Options{Config: cfg, Clock: fakeClock})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// stop after the first cycle started, recording when
+			var firstCycle time.Time
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			unsubscribe := m.OnEvent(func(event events.Event) {
+				if event.Type == events.CycleStarted && firstCycle.IsZero() {
+					firstCycle = fakeClock.Now()
+					cancel()
+				}
+			})
+			defer unsubscribe()
+
+			if err := m.RunOnInterval(ctx, time.Hour); err != nil {
+				t.Fatalf("RunOnInterval() error = %v", err)
+			}
+			if !firstCycle.Equal(tt.want) {
+				t.Errorf("first cycle started at %s, want %s", firstCycle, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsPublishesCycleLifecycle(t *testing.T) {
+	cfg := &config.Config{
+		Cluster:       config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero:    config.DoubleZero{Bin: filepath.Join(t.TempDir(), "missing-doublezero")},
+		Sync:          config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext},
+		State:         config.State{File: filepath.Join(t.TempDir(), "state.json")},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ch, cancel := m.Events(10)
+	defer cancel()
+	if err := m.RunOnce(context.Background()); err == nil {
+		t.Fatal("RunOnce() error = nil, want the missing binary error")
+	}
+
+	started, finished := <-ch, <-ch
+	if started.Type != events.CycleStarted || started.Cluster != constants.ClusterNameTestnet {
+		t.Errorf("first event = %+v, want %s on %s", started, events.CycleStarted, constants.ClusterNameTestnet)
+	}
+	if finished.Type != events.CycleFinished || finished.Err == nil || finished.Report == nil || finished.Report.Outcome != report.OutcomeFailed {
+		t.Errorf("second event = %+v, want a failed %s with its report", finished, events.CycleFinished)
+	}
+}
+
+func TestHealthStatus(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		setup      func(h *health)
+		wantHealth string
+		wantReady  string
+	}{
+		{
+			name:       "waiting for the next cycle",
+			setup:      func(h *health) { h.scheduled(10*time.Minute, now.Add(5*time.Minute)) },
+			wantHealth: HealthStatusOK,
+			wantReady:  HealthStatusOK,
+		},
+		{
+			name: "cycle running within twice the interval",
+			setup: func(h *health) {
+				h.scheduled(10*time.Minute, now.Add(-15*time.Minute))
+				h.cycleStarted(now.Add(-15 * time.Minute))
+			},
+			wantHealth: HealthStatusOK,
+			wantReady:  HealthStatusOK,
+		},
+		{
+			name: "cycle wedged",
+			setup: func(h *health) {
+				h.scheduled(10*time.Minute, now.Add(-25*time.Minute))
+				h.cycleStarted(now.Add(-25 * time.Minute))
+			},
+			wantHealth: HealthStatusUnhealthy,
+			wantReady:  HealthStatusUnhealthy,
+		},
+		{
+			name:       "next cycle overdue",
+			setup:      func(h *health) { h.scheduled(10*time.Minute, now.Add(-21*time.Minute)) },
+			wantHealth: HealthStatusUnhealthy,
+			wantReady:  HealthStatusUnhealthy,
+		},
+		{
+			name: "repeated failures",
+			setup: func(h *health) {
+				for range 3 {
+					h.cycleStarted(now)
+					h.cycleFinished(errors.New("command failed"))
+				}
+				h.scheduled(10*time.Minute, now.Add(10*time.Minute))
+			},
+			wantHealth: HealthStatusOK,
+			wantReady:  HealthStatusNotReady,
+		},
+		{
+			name: "a success resets failures",
+			setup: func(h *health) {
+				for _, err := range []error{errors.New("command failed"), errors.New("command failed"), errors.New("command failed"), nil} {
+					h.cycleStarted(now)
+					h.cycleFinished(err)
+				}
+				h.scheduled(10*time.Minute, now.Add(10*time.Minute))
+			},
+			wantHealth: HealthStatusOK,
+			wantReady:  HealthStatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &health{cfg: config.Health{MaxConsecutiveFailures: 3}, clock: clock.NewFake(now)}
+			tt.setup(h)
+			if got := h.status(false); got.Status != tt.wantHealth {
+				t.Errorf("liveness = %s %v, want %s", got.Status, got.Problems, tt.wantHealth)
+			}
+			if got := h.status(true); got.Status != tt.wantReady {
+				t.Errorf("readiness = %s %v, want %s", got.Status, got.Problems, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestStatusPage(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := &health{cfg: config.Health{MaxConsecutiveFailures: 3}, clock: clock.NewFake(now)}
+	h.scheduled(10*time.Minute, now.Add(5*time.Minute))
+	page := newStatusPage(constants.ClusterNameTestnet, h)
+
+	page.recordState(state.State{
+		LastRecommendation: &state.Recommendation{PackageVersion: "0.8.0-1", Source: "static", FirstObservedAt: now.Add(-2 * time.Hour)},
+		History:            []state.SyncAttempt{{FromVersion: "0.7.0", ToVersion: "0.7.1-1", Result: report.OutcomeSynced, FinishedAt: now.Add(-48 * time.Hour)}},
+	})
+	rep := report.New(constants.ClusterNameTestnet, false, now.Add(-time.Minute))
+	rep.InstalledVersion = "0.7.1"
+	rep.AddGate(report.GateMaintenanceWindow, report.VerdictBlock, "outside sync.windows")
+	rep.Outcome = report.OutcomeBlocked
+	page.recordCycle(events.Event{Type: events.CycleFinished, Report: rep})
+
+	recorder := httptest.NewRecorder()
+	page.handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/status", nil))
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"<td>0.7.1</td>",
+		"0.8.0-1 from static",
+		"behind since 2025-01-01T10:00:00Z (2h0m0s)",
+		"2025-01-01T12:05:00Z",
+		"maintenance_window",
+		"WINDOW_CLOSED",
+		"0.7.1-1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page doesn't contain %q:\n%s", want, body)
+		}
+	}
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Content-Type = %s, want text/html", got)
+	}
+}
+
+func TestRunCycleWatchdogAbandonsWedgedCycle(t *testing.T) {
+	cfg := &config.Config{
+		Cluster:       config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero:    config.DoubleZero{Bin: filepath.Join(t.TempDir(), "missing-doublezero")},
+		Sync:          config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext},
+		State:         config.State{File: filepath.Join(t.TempDir(), "state.json")},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+		Runtime:       config.Runtime{Watchdog: config.Watchdog{WedgedAfter: 50 * time.Millisecond}},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	errFast := errors.New("fast")
+	if err := m.runCycle(func(dz *doublezero.DoubleZero) error { return errFast }); !errors.Is(err, errFast) {
+		t.Errorf("runCycle() error = %v, want the cycle's error", err)
+	}
+
+	wedged := m.doublezero
+	release := make(chan struct{})
+	defer close(release)
+	err = m.runCycle(func(dz *doublezero.DoubleZero) error {
+		<-release
+		return nil
+	})
+	if !errors.Is(err, ErrCycleWedged) {
+		t.Errorf("runCycle() error = %v, want %v", err, ErrCycleWedged)
+	}
+	if m.doublezero == wedged {
+		t.Error("the wedged DoubleZero instance wasn't replaced with a fresh one")
+	}
+}
+
+func TestNotifyFailing(t *testing.T) {
+	cfg := &config.Config{
+		Cluster:       config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero:    config.DoubleZero{Bin: filepath.Join(t.TempDir(), "missing-doublezero")},
+		Sync:          config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext},
+		State:         config.State{File: filepath.Join(t.TempDir(), "state.json")},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+		Health:        config.Health{MaxConsecutiveFailures: 2},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var raised []string
+	m.OnEvent(func(event events.Event) {
+		if event.Type == events.Notification {
+			raised = append(raised, event.Notification.Type+"="+event.Notification.Fields["consecutive_failures"])
+		}
+	})
+
+	m.notifyFailing(0, 1)
+	m.notifyFailing(1, 0)
+	if len(raised) != 0 {
+		t.Fatalf("raised %v outside interval mode, want nothing", raised)
+	}
+
+	m.onInterval = true
+	for _, failures := range [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {0, 0}} {
+		m.notifyFailing(failures[0], failures[1])
+	}
+	want := []string{"sync_failing=2", "sync_failing=3", "sync_recovered=3"}
+	if strings.Join(raised, ",") != strings.Join(want, ",") {
+		t.Errorf("raised %v, want %v", raised, want)
+	}
+}
+
+func TestRunOnceUpgradesThroughSteppingStones(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin := filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	doubleZeroConfig := config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}, SteppingStones: []string{"0.8.0-1", "0.7.0-1", "0.5.0-1"}}
+	if err := doubleZeroConfig.Validate(constants.ClusterNameTestnet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: doubleZeroConfig,
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1, VerifyInstalled: true,
+			Commands: []sync_commands.Command{{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}}},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	st, err := state.NewStore(cfg.State.File).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var hops []string
+	for _, attempt := range st.History {
+		hops = append(hops, attempt.FromVersion+" -> "+attempt.ToVersion+" "+attempt.Result)
+	}
+	want := []string{"0.6.9 -> 0.7.0-1 synced", "0.7.0 -> 0.8.0-1 synced", "0.8.0 -> 0.8.1-1 synced"}
+	if !slices.Equal(hops, want) {
+		t.Errorf("history = %q, want %q", hops, want)
+	}
+	if st.LastReport == nil || st.LastReport.SteppingStone != "" || st.LastReport.Outcome != report.OutcomeSynced {
+		t.Errorf("last report = %+v, want a sync to the recommendation", st.LastReport)
+	}
+}
+
+func TestRunOnceBlockedBySyncLock(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin := filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+			Lock:     config.SyncLock{File: filepath.Join(dir, "sync.lock")},
+			Commands: []sync_commands.Command{{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}}},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rep *report.Report
+	m.OnEvent(func(event events.Event) {
+		if event.Type == events.CycleFinished {
+			rep = event.Report
+		}
+	})
+
+	// another process syncing holds the lock
+	lock, err := lockfile.Acquire(context.Background(), cfg.Sync.Lock.File, 0, clock.Real{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.RunOnce(context.Background()); !errors.Is(err, lockfile.ErrLocked) {
+		t.Fatalf("RunOnce() error = %v, want %v", err, lockfile.ErrLocked)
+	}
+	if rep == nil || rep.Outcome != report.OutcomeBlocked || rep.Reason != report.ReasonSyncLocked {
+		t.Errorf("report = %+v, want blocked with %s", rep, report.ReasonSyncLocked)
+	}
+	if contents, _ := os.ReadFile(installed); string(contents) != "0.6.9" {
+		t.Errorf("installed = %s, want the sync commands not to run", contents)
+	}
+	if _, err := os.Stat(cfg.State.File); !os.IsNotExist(err) {
+		t.Errorf("state file written while another process held the lock, stat error = %v", err)
+	}
+
+	// once released the next run syncs
+	if err := lock.Release(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if contents, _ := os.ReadFile(installed); string(contents) != "0.8.1" {
+		t.Errorf("installed = %s, want 0.8.1", contents)
+	}
+}
+
+func TestRunOnceDeferredByFreeze(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin := filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	freezeConfig := config.Freeze{Periods: []config.FreezePeriod{{Name: "year-end change freeze", Start: "2024-12-15", End: "2025-01-04"}}, Timeout: time.Second}
+	if err := freezeConfig.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+			Commands: []sync_commands.Command{{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}}},
+		Freeze:        freezeConfig,
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if contents, _ := os.ReadFile(installed); string(contents) != "0.6.9" {
+		t.Errorf("installed = %s, want the sync commands not to run during the freeze", contents)
+	}
+
+	st, err := state.NewStore(cfg.State.File).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rep := st.LastReport; rep == nil || rep.Reason != report.ReasonChangeFreeze || rep.Freeze == nil || rep.Freeze.Name != "year-end change freeze" {
+		t.Errorf("last report = %+v, want deferred by the year-end change freeze", rep)
+	}
+	if want := "year-end change freeze@0.8.1-1"; st.FreezeNotifiedFor != want {
+		t.Errorf("freeze notified for %q, want %q", st.FreezeNotifiedFor, want)
+	}
+}
+
+func TestRunOnceRefreshesReposAtMostOncePerInterval(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	bin := filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	refreshes := filepath.Join(dir, "refreshes")
+	if err := os.WriteFile(filepath.Join(dir, "apt-get"), []byte("#!/bin/sh\necho \"$@\" >> "+refreshes+"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+			RefreshRepos: config.RefreshRepos{Enabled: true, PackageManager: constants.PackageManagerApt, MinInterval: time.Hour},
+			Commands:     []sync_commands.Command{{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}}},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	m, err := New(Options{Config: cfg, Clock: fakeClock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// each cycle finds the old version installed so the sync commands run
+	tests := []struct {
+		name          string
+		advance       time.Duration
+		wantRefreshes int
+		wantVerdict   string
+	}{
+		{name: "first sync refreshes", wantRefreshes: 1, wantVerdict: report.VerdictPass},
+		{name: "within min_interval", advance: 30 * time.Minute, wantRefreshes: 1, wantVerdict: report.VerdictSkip},
+		{name: "after min_interval", advance: 45 * time.Minute, wantRefreshes: 2, wantVerdict: report.VerdictPass},
+	}
+	for _, tt := range tests {
+		fakeClock.Advance(tt.advance)
+		if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := m.RunOnce(context.Background()); err != nil {
+			t.Fatalf("%s: RunOnce() error = %v", tt.name, err)
+		}
+
+		contents, _ := os.ReadFile(refreshes)
+		if got := strings.Count(string(contents), "update\n"); got != tt.wantRefreshes {
+			t.Errorf("%s: refreshes = %d, want %d", tt.name, got, tt.wantRefreshes)
+		}
+		st, err := state.NewStore(cfg.State.File).Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		verdict := ""
+		for _, gate := range st.LastReport.Gates {
+			if gate.Name == report.GateRefreshRepos {
+				verdict = gate.Verdict
+			}
+		}
+		if verdict != tt.wantVerdict {
+			t.Errorf("%s: refresh_repos verdict = %q, want %q", tt.name, verdict, tt.wantVerdict)
+		}
+	}
+}
+
+func TestRunOnceRollsBackFailedSync(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin := filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the restart fails on the new version only, so the rollback re-installs and restarts the old one
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+			Commands: []sync_commands.Command{
+				{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .PackageVersionTo }} > " + installed}},
+				{Name: "restart", Cmd: "sh", Args: []string{"-c", "[ \"$(cat " + installed + ")\" = 0.6.9 ]"},
+					OnFailure: constants.SyncCommandOnFailureRollback},
+			}},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = m.RunOnce(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "rolled back to 0.6.9") {
+		t.Fatalf("RunOnce() error = %v, want the failed sync rolled back to 0.6.9", err)
+	}
+	if contents, _ := os.ReadFile(installed); string(contents) != "0.6.9" {
+		t.Errorf("installed = %q, want 0.6.9", contents)
+	}
+	st, err := state.NewStore(cfg.State.File).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var commands []string
+	for _, command := range st.LastReport.Commands {
+		commands = append(commands, command.Name+"="+command.Status)
+	}
+	want := "install=executed restart=failed install (rollback)=executed restart (rollback)=executed"
+	if got := strings.Join(commands, " "); got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+}
+
+func TestRunOnceRunsPendingRebootInNextWindow(t *testing.T) {
+	tests := []struct {
+		name         string
+		stillNeeded  bool
+		wantRebooted bool
+	}{
+		{name: "still required runs the reboot command", stillNeeded: true, wantRebooted: true},
+		{name: "no longer required drops the pending reboot", stillNeeded: false, wantRebooted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			installed := filepath.Join(dir, "installed")
+			if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			bin := filepath.Join(dir, "doublezero")
+			if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// host maintenance lets the sync run outside the window, leaving the reboot to the next one
+			maintenanceFile := filepath.Join(dir, "maintenance")
+			if err := os.WriteFile(maintenanceFile, nil, 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			requiredFile := filepath.Join(dir, "reboot-required")
+			rebooted := filepath.Join(dir, "rebooted")
+
+			cfg := &config.Config{
+				Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+				DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+				Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+					Windows: []maintenance.Window{{Name: "nightly", Start: "02:00", End: "04:00"}},
+					Commands: []sync_commands.Command{{Name: "install", Cmd: "sh",
+						Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed + " && touch " + requiredFile}}}},
+				Reboot: config.Reboot{Policy: constants.RebootPolicyCommand, RequiredFiles: []string{requiredFile},
+					Command: &sync_commands.Command{Name: "reboot", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + rebooted}}},
+				HostMaintenance: config.HostMaintenance{Policy: constants.HostMaintenancePolicyPrioritize, File: maintenanceFile, Timeout: time.Second},
+				State:           config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+				VersionSource:   config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+			}
+			fakeClock := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+			m, err := New(Options{Config: cfg, Clock: fakeClock})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := m.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+			if _, err := os.Stat(rebooted); err == nil {
+				t.Fatalf("reboot command ran outside sync.windows")
+			}
+			st, err := state.NewStore(cfg.State.File).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if st.PendingReboot == nil || st.PendingReboot.FromVersion != "0.6.9" || st.PendingReboot.ToVersion != "0.8.1-1" {
+				t.Fatalf("pending reboot = %+v, want 0.6.9 -> 0.8.1-1", st.PendingReboot)
+			}
+
+			// the next cycle runs once the window opens, with nothing left to sync
+			if err := os.Remove(maintenanceFile); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.stillNeeded {
+				if err := os.Remove(requiredFile); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			fakeClock.Advance(17*time.Hour + 30*time.Minute)
+			if err := m.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+
+			contents, err := os.ReadFile(rebooted)
+			if tt.wantRebooted && (err != nil || string(contents) != "0.8.1") {
+				t.Errorf("reboot command wrote %q (error %v), want 0.8.1", contents, err)
+			}
+			if !tt.wantRebooted && err == nil {
+				t.Errorf("reboot command ran, want the pending reboot dropped")
+			}
+			st, err = state.NewStore(cfg.State.File).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if st.PendingReboot != nil {
+				t.Errorf("pending reboot = %+v, want cleared", st.PendingReboot)
+			}
+		})
+	}
+}
+
+func TestRunOnceWaitsOutRepoLag(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin := filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var published atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		index := "Package: doublezero\nVersion: 0.6.9-1\n"
+		if published.Load() {
+			index += "\nPackage: doublezero\nVersion: 0.8.1-1\n"
+		}
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(index))
+		gz.Close()
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+			VerifyPublished: config.VerifyPublished{Enabled: true, Repository: config.VersionSource{
+				Type: constants.VersionSourceTypeCloudsmithIndex, Format: constants.PackageFormatDeb, Arch: constants.PackageArchAMD64,
+				Distro: constants.PackageDistroAny, DistroVersion: constants.PackageDistroVersionAny, BaseURL: srv.URL, Timeout: time.Second,
+			}},
+			Commands: []sync_commands.Command{{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}}},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	m, err := New(Options{Config: cfg, Clock: fakeClock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the lag is recorded from the first cycle that found the package missing
+	for range 2 {
+		if err := m.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce() error = %v, want the lag not to fail the cycle", err)
+		}
+		st, err := state.NewStore(cfg.State.File).Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rep := st.LastReport; rep == nil || rep.Reason != report.ReasonRepoLag || rep.RepoLag == nil || !rep.RepoLag.Since.Equal(start) {
+			t.Errorf("last report = %+v, want repository lag since %s", rep, start)
+		}
+		if st.RepoLag == nil || st.RepoLag.PackageVersion != "0.8.1-1" {
+			t.Errorf("state repository lag = %+v, want 0.8.1-1", st.RepoLag)
+		}
+		fakeClock.Advance(time.Hour)
+	}
+	if contents, _ := os.ReadFile(installed); string(contents) != "0.6.9" {
+		t.Errorf("installed = %s, want the sync commands not to run before the package is published", contents)
+	}
+
+	published.Store(true)
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	st, err := state.NewStore(cfg.State.File).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.RepoLag != nil || st.LastReport.Outcome != report.OutcomeSynced {
+		t.Errorf("repository lag = %+v, outcome = %s, want the lag cleared and synced", st.RepoLag, st.LastReport.Outcome)
+	}
+}
+
+func TestRunOnceWaitsForPackage(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		publishedAt time.Duration
+		wait        time.Duration
+		wantOutcome string
+		wantDetail  string
+	}{
+		{name: "published while waiting", publishedAt: 3 * time.Minute, wait: 10 * time.Minute, wantOutcome: report.OutcomeSynced,
+			wantDetail: "0.8.1-1 is published in the deb repository after waiting 3m0s"},
+		{name: "wait runs out", publishedAt: time.Hour, wait: 150 * time.Second, wantOutcome: report.OutcomeNothingToDo,
+			wantDetail: "after waiting 2m30s, change pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			installed := filepath.Join(dir, "installed")
+			if err := os.WriteFile(installed, []byte("0.6.9"), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			bin := filepath.Join(dir, "doublezero")
+			if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// the repository publishes the target package once the fake clock reaches publishedAt
+			fakeClock := clock.NewFake(start)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				index := "Package: doublezero\nVersion: 0.6.9-1\n"
+				if !fakeClock.Now().Before(start.Add(tt.publishedAt)) {
+					index += "\nPackage: doublezero\nVersion: 0.8.1-1\n"
+				}
+				gz := gzip.NewWriter(w)
+				gz.Write([]byte(index))
+				gz.Close()
+			}))
+			defer srv.Close()
+
+			cfg := &config.Config{
+				Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+				DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+				Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+					WaitForPackage: tt.wait,
+					VerifyPublished: config.VerifyPublished{Enabled: true, Repository: config.VersionSource{
+						Type: constants.VersionSourceTypeCloudsmithIndex, Format: constants.PackageFormatDeb, Arch: constants.PackageArchAMD64,
+						Distro: constants.PackageDistroAny, DistroVersion: constants.PackageDistroVersionAny, BaseURL: srv.URL, Timeout: time.Second,
+					}},
+					Commands: []sync_commands.Command{{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}}},
+				State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+				VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+			}
+			m, err := New(Options{Config: cfg, Clock: fakeClock})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := m.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+			st, err := state.NewStore(cfg.State.File).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if st.LastReport.Outcome != tt.wantOutcome {
+				t.Errorf("outcome = %s, want %s", st.LastReport.Outcome, tt.wantOutcome)
+			}
+			detail := ""
+			for _, gate := range st.LastReport.Gates {
+				if gate.Name == report.GatePackagePublished {
+					detail = gate.Detail
+				}
+			}
+			if !strings.Contains(detail, tt.wantDetail) {
+				t.Errorf("package_published detail = %q, want it to contain %q", detail, tt.wantDetail)
+			}
+			if st.RepoLag != nil && !st.RepoLag.Since.Equal(start) {
+				t.Errorf("repository lag since %s, want %s when the wait started", st.RepoLag.Since, start)
+			}
+		})
+	}
+}
+
+// writeFakeBinary writes a doublezero binary to dir reporting the version in the returned installed file, which the
+// returned install command sets to the target version
+func writeFakeBinary(t *testing.T, dir, version string) (bin string, installed string, install sync_commands.Command) {
+	t.Helper()
+	installed = filepath.Join(dir, "installed")
+	if err := os.WriteFile(installed, []byte(version), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bin = filepath.Join(dir, "doublezero")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"DoubleZero $(cat "+installed+")\"\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return bin, installed, sync_commands.Command{Name: "install", Cmd: "sh", Args: []string{"-c", "printf %s {{ .VersionTo }} > " + installed}}
+}
+
+// gateOf returns the gate of the report with the given name
+func gateOf(rep *report.Report, name string) report.Gate {
+	for _, gate := range rep.Gates {
+		if gate.Name == name {
+			return gate
+		}
+	}
+	return report.Gate{}
+}
+
+func TestRunOnceBlocksDowngrade(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowDowngrade bool
+		wantOutcome    string
+		wantReason     string
+		wantInstalled  string
+	}{
+		{name: "downgrade not allowed", wantOutcome: report.OutcomeBlocked, wantReason: report.ReasonDowngradeNotAllowed, wantInstalled: "0.8.1"},
+		{name: "downgrade allowed", allowDowngrade: true, wantOutcome: report.OutcomeSynced, wantInstalled: "0.7.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			bin, installed, install := writeFakeBinary(t, dir, "0.8.1")
+			cfg := &config.Config{
+				Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+				DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+				Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+					AllowDowngrade: tt.allowDowngrade, Commands: []sync_commands.Command{install}},
+				State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+				VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+			}
+			m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// a blocked cycle fails the run
+			runErr := m.RunOnce(context.Background())
+			st, err := state.NewStore(cfg.State.File).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if st.LastReport.Outcome != tt.wantOutcome || st.LastReport.Reason != tt.wantReason {
+				t.Errorf("report = %s %q, want %s %q (RunOnce() error = %v)", st.LastReport.Outcome, st.LastReport.Reason, tt.wantOutcome, tt.wantReason, runErr)
+			}
+			wantVerdict := report.VerdictPass
+			if tt.wantReason != "" {
+				wantVerdict = report.VerdictBlock
+			}
+			if gate := gateOf(st.LastReport, report.GateDowngrade); gate.Verdict != wantVerdict || gate.Reason != tt.wantReason {
+				t.Errorf("downgrade gate = %+v, want %s %q", gate, wantVerdict, tt.wantReason)
+			}
+			if contents, _ := os.ReadFile(installed); string(contents) != tt.wantInstalled {
+				t.Errorf("installed = %s, want %s", contents, tt.wantInstalled)
+			}
+		})
+	}
+}
+
+func TestRunOnceSkipsConfiguredSkipVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		skipVersions  []string
+		wantOutcome   string
+		wantReason    string
+		wantInstalled string
+	}{
+		{name: "release listed", skipVersions: []string{"0.8.1-1"}, wantOutcome: report.OutcomeNothingToDo, wantReason: report.ReasonVersionSkipped, wantInstalled: "0.6.9"},
+		{name: "every release listed", skipVersions: []string{"0.7.0", "0.8.1"}, wantOutcome: report.OutcomeNothingToDo, wantReason: report.ReasonVersionSkipped, wantInstalled: "0.6.9"},
+		{name: "other release listed", skipVersions: []string{"0.8.1-2"}, wantOutcome: report.OutcomeSynced, wantInstalled: "0.8.1"},
+		{name: "nothing listed", wantOutcome: report.OutcomeSynced, wantInstalled: "0.8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			bin, installed, install := writeFakeBinary(t, dir, "0.6.9")
+			cfg := &config.Config{
+				Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+				DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}, SkipVersions: tt.skipVersions},
+				Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+					Commands: []sync_commands.Command{install}},
+				State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+				VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+			}
+			m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := m.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+			st, err := state.NewStore(cfg.State.File).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if st.LastReport.Outcome != tt.wantOutcome || st.LastReport.Reason != tt.wantReason {
+				t.Errorf("report = %s %q, want %s %q", st.LastReport.Outcome, st.LastReport.Reason, tt.wantOutcome, tt.wantReason)
+			}
+			wantVerdict := report.VerdictPass
+			if tt.wantReason != "" {
+				wantVerdict = report.VerdictDone
+			}
+			if gate := gateOf(st.LastReport, report.GateSkippedVersion); gate.Verdict != wantVerdict || gate.Reason != tt.wantReason {
+				t.Errorf("skipped_version gate = %+v, want %s %q", gate, wantVerdict, tt.wantReason)
+			}
+			if contents, _ := os.ReadFile(installed); string(contents) != tt.wantInstalled {
+				t.Errorf("installed = %s, want %s", contents, tt.wantInstalled)
+			}
+		})
+	}
+}
+
+func TestRunOnceWaitsForConfirmCycles(t *testing.T) {
+	dir := t.TempDir()
+	bin, installed, install := writeFakeBinary(t, dir, "0.6.9")
+	cfg := &config.Config{
+		Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+		DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+		Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 3,
+			Commands: []sync_commands.Command{install}},
+		State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+		VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for cycle, want := range []struct {
+		outcome   string
+		reason    string
+		verdict   string
+		installed string
+	}{
+		{report.OutcomeNothingToDo, report.ReasonRecommendationUnconfirmed, report.VerdictDone, "0.6.9"},
+		{report.OutcomeNothingToDo, report.ReasonRecommendationUnconfirmed, report.VerdictDone, "0.6.9"},
+		{report.OutcomeSynced, "", report.VerdictPass, "0.8.1"},
+	} {
+		if err := m.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce() on cycle %d error = %v", cycle+1, err)
+		}
+		st, err := state.NewStore(cfg.State.File).Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if st.LastReport.Outcome != want.outcome || st.LastReport.Reason != want.reason {
+			t.Errorf("cycle %d report = %s %q, want %s %q", cycle+1, st.LastReport.Outcome, st.LastReport.Reason, want.outcome, want.reason)
+		}
+		if gate := gateOf(st.LastReport, report.GateConfirmCycles); gate.Verdict != want.verdict || gate.Reason != want.reason {
+			t.Errorf("cycle %d confirm_cycles gate = %+v, want %s %q", cycle+1, gate, want.verdict, want.reason)
+		}
+		if contents, _ := os.ReadFile(installed); string(contents) != want.installed {
+			t.Errorf("cycle %d installed = %s, want %s", cycle+1, contents, want.installed)
+		}
+	}
+}
+
+func TestRunOnceDefersOutsideCohort(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	cohorts := map[string]time.Duration{"canary": 0, "wave-2": 2 * time.Hour}
+
+	tests := []struct {
+		name   string
+		cohort string
+		// firstObserved is how long ago the target was first recommended, not before this cycle when 0
+		firstObserved time.Duration
+		wantOutcome   string
+		wantReason    string
+		wantInstalled string
+	}{
+		{name: "inside the current cohort", cohort: "canary", wantOutcome: report.OutcomeSynced, wantInstalled: "0.8.1"},
+		{name: "outside the current cohort", cohort: "wave-2", wantOutcome: report.OutcomeNothingToDo,
+			wantReason: report.ReasonCohortPending, wantInstalled: "0.6.9"},
+		{name: "cohort delay passed", cohort: "wave-2", firstObserved: 3 * time.Hour, wantOutcome: report.OutcomeSynced, wantInstalled: "0.8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			bin, installed, install := writeFakeBinary(t, dir, "0.6.9")
+			cfg := &config.Config{
+				Cluster:    config.Cluster{Name: constants.ClusterNameTestnet},
+				DoubleZero: config.DoubleZero{Bin: bin, Daemon: config.Daemon{Check: constants.DaemonCheckNone}},
+				Sync: config.Sync{Anchor: constants.SyncAnchorMidnight, OverrunPolicy: constants.SyncOverrunPolicySkipNext, ConfirmCycles: 1,
+					Cohort: tt.cohort, Cohorts: cohorts, Commands: []sync_commands.Command{install}},
+				State:         config.State{File: filepath.Join(dir, "state.json"), HistorySize: 10},
+				VersionSource: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.8.1-1"},
+			}
+			if tt.firstObserved > 0 {
+				if err := state.NewStore(cfg.State.File).Update(func(st *state.State) error {
+					st.LastRecommendation = &state.Recommendation{PackageVersion: "0.8.1-1", Source: "static",
+						ObservedAt: now.Add(-time.Hour), FirstObservedAt: now.Add(-tt.firstObserved)}
+					return nil
+				}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			m, err := New(Options{Config: cfg, Clock: clock.NewFake(now)})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := m.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+			st, err := state.NewStore(cfg.State.File).Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if st.LastReport.Outcome != tt.wantOutcome || st.LastReport.Reason != tt.wantReason {
+				t.Errorf("report = %s %q, want %s %q", st.LastReport.Outcome, st.LastReport.Reason, tt.wantOutcome, tt.wantReason)
+			}
+			wantVerdict := report.VerdictPass
+			if tt.wantReason != "" {
+				wantVerdict = report.VerdictDone
+			}
+			if gate := gateOf(st.LastReport, report.GateCohort); gate.Verdict != wantVerdict || gate.Reason != tt.wantReason {
+				t.Errorf("cohort gate = %+v, want %s %q", gate, wantVerdict, tt.wantReason)
+			}
+			if contents, _ := os.ReadFile(installed); string(contents) != tt.wantInstalled {
+				t.Errorf("installed = %s, want %s", contents, tt.wantInstalled)
+			}
+		})
+	}
+}
+
+func TestRetryAt(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	nextSync := now.Add(time.Hour)
+	cycleErr := errors.New("cycle failed")
+
+	tests := []struct {
+		name     string
+		attempts int
+		reason   string
+		err      error
+		want     time.Duration
+		wantOK   bool
+	}{
+		{name: "succeeded", reason: report.ReasonInSync},
+		{name: "first retry", err: cycleErr, reason: report.ReasonSourceUnavailable, want: 30 * time.Second, wantOK: true},
+		{name: "backoff doubles", attempts: 2, err: cycleErr, reason: report.ReasonSourceUnavailable, want: 2 * time.Minute, wantOK: true},
+		{name: "not retryable", err: cycleErr, reason: report.ReasonConstraintUnsatisfied},
+		{name: "identity active not retryable", err: cycleErr, reason: report.ReasonIdentityActive},
+		{name: "attempts exhausted", attempts: 3, err: cycleErr, reason: report.ReasonSourceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{
+				cfg: &config.Config{Sync: config.Sync{Retry: config.SyncRetry{
+					MaxAttempts: 3, InitialBackoff: 30 * time.Second, MaxBackoff: 10 * time.Minute, Reasons: report.RetryableReasons,
+				}}},
+				logger:        log.New(io.Discard),
+				cycleRetries:  metrics.NewRegistry().NewCounter("retries", "retries", "reason"),
+				lastReason:    tt.reason,
+				retryAttempts: tt.attempts,
+			}
+			got, ok := m.retryAt(tt.err, now, nextSync)
+			if ok != tt.wantOK || (ok && !got.Equal(now.Add(tt.want))) {
+				t.Errorf("retryAt() = %s, %v, want %s, %v", got, ok, now.Add(tt.want), tt.wantOK)
+			}
+			if !ok && m.retryAttempts != 0 {
+				t.Errorf("retry attempts = %d, want reset to 0 without a retry", m.retryAttempts)
+			}
+		})
+	}
+
+	// a retry never runs after the next sync
+	m := &Manager{
+		cfg: &config.Config{Sync: config.Sync{Retry: config.SyncRetry{
+			MaxAttempts: 3, InitialBackoff: 2 * time.Hour, MaxBackoff: 2 * time.Hour, Reasons: report.RetryableReasons,
+		}}},
+		logger:     log.New(io.Discard),
+		lastReason: report.ReasonSourceUnavailable,
+	}
+	if got, ok := m.retryAt(cycleErr, now, nextSync); ok {
+		t.Errorf("retryAt() = %s, want no retry past the next sync %s", got, nextSync)
+	}
+}
+
+func TestCheckConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writes := 0
+	writeConfig := func(version string) {
+		t.Helper()
+		writes++
+		contents := "cluster:\n  name: testnet\n" +
+			"doublezero:\n  bin: " + filepath.Join(dir, "doublezero") + "\n  daemon:\n    check: none\n" +
+			"version_source:\n  type: static\n  version: " + version + "\n" +
+			"config_reload:\n  watch_interval: 10s\n"
+		if err := os.WriteFile(configFile, []byte(contents), 0o644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		// make sure the change is visible even on filesystems with coarse modification times
+		later := time.Now().Add(time.Duration(writes) * time.Minute)
+		if err := os.Chtimes(configFile, later, later); err != nil {
+			t.Fatalf("failed to touch config: %v", err)
+		}
+	}
+
+	writeConfig("0.7.1-1")
+	cfg, err := config.NewFromConfigFile(configFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dz := m.doublezero
+
+	if m.checkConfigReload() {
+		t.Fatal("checkConfigReload() = true, want false for an unchanged config file")
+	}
+
+	writeConfig("0.8.0-1")
+	if !m.checkConfigReload() {
+		t.Fatal("checkConfigReload() = false, want true for a changed config file")
+	}
+	if m.cfg.VersionSource.Version != "0.8.0-1" || m.doublezero == dz {
+		t.Errorf("config version = %s, want 0.8.0-1 applied to a fresh DoubleZero instance", m.cfg.VersionSource.Version)
+	}
+
+	// an invalid config keeps the current one
+	writeConfig("not-a-version")
+	if m.checkConfigReload() {
+		t.Fatal("checkConfigReload() = true, want false for an invalid config")
+	}
+	if m.cfg.VersionSource.Version != "0.8.0-1" {
+		t.Errorf("config version = %s, want the current 0.8.0-1 kept", m.cfg.VersionSource.Version)
+	}
+
+	// a reload signal reloads an unchanged file too
+	writeConfig("0.8.1-1")
+	m.checkConfigReload()
+	m.reloadRequested.Store(true)
+	if !m.checkConfigReload() || m.reloadRequested.Load() {
+		t.Error("checkConfigReload() = false, want a requested reload to reload the config")
+	}
+}
+
+func TestCheckConfigReload_IncludeChanged(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	overlayFile := filepath.Join(dir, "host.toml")
+	base := "cluster:\n  name: testnet\n" +
+		"doublezero:\n  bin: " + filepath.Join(dir, "doublezero") + "\n  daemon:\n    check: none\n" +
+		"version_source:\n  type: static\n  version: 0.7.1-1\n" +
+		"config_reload:\n  watch_interval: 10s\n" +
+		"include:\n  - host.toml\n"
+	if err := os.WriteFile(configFile, []byte(base), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	writeOverlay := func(version string, touch time.Duration) {
+		t.Helper()
+		if err := os.WriteFile(overlayFile, []byte("[version_source]\nversion = \""+version+"\"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write overlay: %v", err)
+		}
+		later := time.Now().Add(touch)
+		if err := os.Chtimes(overlayFile, later, later); err != nil {
+			t.Fatalf("failed to touch overlay: %v", err)
+		}
+	}
+
+	writeOverlay("0.8.0-1", time.Minute)
+	cfg, err := config.NewFromConfigFile(configFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.VersionSource.Version != "0.8.0-1" || cfg.VersionSource.Type != "static" {
+		t.Fatalf("config version_source = %s %s, want the overlay version merged over the base static source", cfg.VersionSource.Type, cfg.VersionSource.Version)
+	}
+	m, err := New(Options{Config: cfg, Clock: clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.checkConfigReload() {
+		t.Fatal("checkConfigReload() = true, want false for unchanged config files")
+	}
+
+	writeOverlay("0.8.1-1", 2*time.Minute)
+	if !m.checkConfigReload() {
+		t.Fatal("checkConfigReload() = false, want true for a changed include file")
+	}
+	if m.cfg.VersionSource.Version != "0.8.1-1" {
+		t.Errorf("config version = %s, want 0.8.1-1", m.cfg.VersionSource.Version)
+	}
+}
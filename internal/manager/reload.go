@@ -0,0 +1,181 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/audit"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/doublezero"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+	"github.com/sol-strategies/doublezero-version-sync/internal/notify"
+)
+
+// signalCheckInterval is how often waiting for the next cycle checks for a reload signal
+const signalCheckInterval = time.Second
+
+// configStamp identifies a version of the config file and its include files, to tell when one changed on disk
+type configStamp string
+
+// statConfig returns the stamp of the config files
+func statConfig(files ...string) (configStamp, error) {
+	var stamp strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&stamp, "%s %d %d\n", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return configStamp(stamp.String()), nil
+}
+
+// ReloadOnSignal reloads the config file between cycles of RunOnInterval when one of the signals is received, e.g.
+// SIGHUP
+func (m *Manager) ReloadOnSignal(signals ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	m.reloadOnSignal = true
+	go func() {
+		for sig := range ch {
+			m.logger.Info("received signal - reloading config before the next cycle", "signal", sig.String())
+			m.reloadRequested.Store(true)
+		}
+	}()
+}
+
+// checkConfigReload reloads the config file when a reload was requested or, with config_reload.watch_interval, the
+// file changed since it was last loaded. Returns true when the config was reloaded
+func (m *Manager) checkConfigReload() bool {
+	if m.reloadRequested.Swap(false) {
+		return m.reloadConfig("signal")
+	}
+	if m.cfg.ConfigReload.WatchInterval <= 0 || m.cfg.File == "" {
+		return false
+	}
+
+	stamp, err := statConfig(m.cfg.Files()...)
+	if err != nil {
+		m.logger.Warn("failed to check config file", "file", m.cfg.File, "error", err)
+		return false
+	}
+	if stamp == m.configStamp {
+		return false
+	}
+	// a broken edit isn't retried until the file changes again
+	m.configStamp = stamp
+	return m.reloadConfig("file_change")
+}
+
+// reloadConfig loads the config file again and applies it from the next cycle on, keeping the interval alignment.
+// An invalid config is logged and the current one kept
+func (m *Manager) reloadConfig(trigger string) bool {
+	cfg, err := m.cfg.Reload()
+	if err == nil {
+		err = m.applyConfig(cfg)
+	}
+	if err != nil {
+		m.configReloads.Inc("failure")
+		m.logger.Error("failed to reload config - keeping the current config", "file", m.cfg.File, "trigger", trigger, "error", err)
+		return false
+	}
+
+	m.configReloads.Inc("success")
+	m.logger.Info("reloaded config", "file", cfg.File, "trigger", trigger)
+	if restart := restartRequired(m.cfg, cfg); len(restart) > 0 {
+		m.logger.Warn("config changes that only apply on restart were not applied", "keys", restart)
+	}
+	m.cfg = cfg
+	return true
+}
+
+// applyConfig creates a fresh DoubleZero instance and notifier from the config - re-parsing the commands, reloading
+// the identities and version constraint - and swaps them in only once everything was created. The anchor and state
+// file keep their current values
+func (m *Manager) applyConfig(cfg *config.Config) error {
+	windows, err := maintenance.NewSchedule(cfg.Sync.Windows)
+	if err != nil {
+		return fmt.Errorf("invalid sync.windows%w", err)
+	}
+	var adjustTo *maintenance.Schedule
+	if cfg.Sync.UnreachableWindows == constants.SyncUnreachableWindowsAdjust {
+		adjustTo = windows
+	}
+	if windows.IsEnabled() && adjustTo == nil && m.interval > 0 {
+		if err := CheckWindows(windows, m.anchor, m.clock.Now().UTC(), m.interval).Err(m.anchor, m.interval); err != nil {
+			return err
+		}
+	}
+
+	opts := m.doublezeroOptions
+	notifier := notify.NewFromConfig(cfg.Notifications, notify.Options{
+		Cluster:   cfg.Cluster.Name,
+		Logger:    opts.Logger,
+		Clock:     opts.Clock,
+		Transport: opts.Transport,
+	})
+	notifier.AddNotifier(m.events)
+
+	var auditLog *audit.Log
+	if cfg.Audit.Enabled {
+		auditLog = audit.New(audit.Options{File: cfg.Audit.File, MaxOutputBytes: cfg.Audit.MaxOutputBytes})
+	}
+
+	opts.Cluster = cfg.Cluster.Name
+	opts.SyncConfig = cfg.Sync
+	opts.DoubleZeroConfig = cfg.DoubleZero
+	opts.ValidatorConfig = cfg.Validator
+	opts.FailoverConfig = cfg.Failover
+	opts.VersionSourceConfig = cfg.VersionSource
+	opts.RebootConfig = cfg.Reboot
+	opts.HostMaintenanceConfig = cfg.HostMaintenance
+	opts.FreezeConfig = cfg.Freeze
+	opts.FleetConfig = cfg.Fleet
+	opts.StateConfig.HistorySize = cfg.State.HistorySize
+	opts.Notifier = notifier
+	opts.AuditLog = auditLog
+	dz, err := doublezero.New(opts)
+	if err != nil {
+		return err
+	}
+
+	m.doublezeroOptions = opts
+	m.doublezero = dz
+	m.restartDoubleZero = false
+	m.notifier = notifier
+	m.windows = windows
+	m.adjustTo = adjustTo
+	m.health.setConfig(cfg.Health)
+	m.cycleOverruns.Add(0, cfg.Sync.OverrunPolicy)
+	return nil
+}
+
+// restartRequired returns the config keys changed between current and reloaded that a reload doesn't apply
+func restartRequired(current, reloaded *config.Config) []string {
+	var keys []string
+	for _, key := range []struct {
+		name             string
+		current, changed any
+	}{
+		{"log.level", current.Log.Level, reloaded.Log.Level},
+		{"log.format", current.Log.Format, reloaded.Log.Format},
+		{"log.levels", current.Log.Levels, reloaded.Log.Levels},
+		{"runtime.max_procs", current.Runtime.MaxProcs, reloaded.Runtime.MaxProcs},
+		{"runtime.gc_percent", current.Runtime.GCPercent, reloaded.Runtime.GCPercent},
+		{"runtime.memory_limit", current.Runtime.MemoryLimit, reloaded.Runtime.MemoryLimit},
+		{"state.file", current.State.File, reloaded.State.File},
+		{"sync.anchor", current.Sync.Anchor, reloaded.Sync.Anchor},
+		{"fleet.listen_address", current.Fleet.ListenAddress, reloaded.Fleet.ListenAddress},
+		{"fleet.secret_file", current.Fleet.SecretFile, reloaded.Fleet.SecretFile},
+	} {
+		if !reflect.DeepEqual(key.current, key.changed) {
+			keys = append(keys, key.name)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,197 @@
+package manager
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/events"
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/state"
+)
+
+// statusPageHistorySize is the most recent sync attempts shown on the status page
+const statusPageHistorySize = 10
+
+// statusPageTemplate is the read-only HTML status page, refreshed by the browser every minute
+var statusPageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>doublezero-version-sync · {{ .Host }}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { text-align: left; padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; vertical-align: top; }
+.pass, .done, .synced, .ok { color: #1a7f37; }
+.block, .skip, .not_ready, .nothing_to_do { color: #9a6700; }
+.fail, .failed, .unhealthy { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>doublezero-version-sync · {{ .Host }}</h1>
+<table>
+<tr><th>Cluster</th><td>{{ .Cluster }}</td></tr>
+<tr><th>Health</th><td class="{{ .Health.Status }}">{{ .Health.Status }}{{ range .Health.Problems }} - {{ . }}{{ end }}</td></tr>
+<tr><th>Installed version</th><td>{{ or .InstalledVersion "unknown" }}</td></tr>
+<tr><th>Recommended version</th><td>{{ if .Recommendation }}{{ .Recommendation.PackageVersion }} from {{ .Recommendation.Source }}{{ else }}unknown{{ end }}</td></tr>
+<tr><th>Drift</th><td>{{ if .DriftSince }}behind since {{ .DriftSince.Format "2006-01-02T15:04:05Z07:00" }} ({{ .DriftAge }}){{ else if .Recommendation }}in sync{{ else }}unknown{{ end }}</td></tr>
+{{- with .Report }}{{ with .Freeze }}
+<tr><th>Change freeze</th><td class="skip">{{ .Name }} until {{ .End.Format "2006-01-02T15:04:05Z07:00" }} - version changes deferred</td></tr>
+{{- end }}{{ with .RepoLag }}
+<tr><th>Repository lag</th><td class="skip">{{ .PackageVersion }} not in the {{ .Repository }} repository since {{ .Since.Format "2006-01-02T15:04:05Z07:00" }} - version change pending</td></tr>
+{{- end }}{{ end }}
+<tr><th>Next sync</th><td>{{ if .Health.CycleStartedAt }}cycle running since {{ .Health.CycleStartedAt.Format "2006-01-02T15:04:05Z07:00" }}{{ else if .Health.NextSyncAt }}{{ .Health.NextSyncAt.Format "2006-01-02T15:04:05Z07:00" }}{{ else }}not scheduled{{ end }}</td></tr>
+<tr><th>Consecutive failures</th><td>{{ .Health.ConsecutiveFailures }}</td></tr>
+</table>
+<h2>Last cycle</h2>
+{{- with .Report }}
+<p class="{{ .Outcome }}">{{ .Outcome }}{{ if .Reason }} ({{ .Reason }}){{ end }} at {{ .FinishedAt.Format "2006-01-02T15:04:05Z07:00" }}{{ if .Simulated }} - dry run{{ end }}{{ if .Error }} - {{ .Error }}{{ end }}</p>
+<table>
+<tr><th>Gate</th><th>Verdict</th><th>Detail</th></tr>
+{{- range .Gates }}
+<tr><td>{{ .Name }}</td><td class="{{ .Verdict }}">{{ .Verdict }}{{ if .Reason }} ({{ .Reason }}){{ end }}</td><td>{{ .Detail }}</td></tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No cycle has finished yet.</p>
+{{- end }}
+<h2>Recent syncs</h2>
+{{- if .History }}
+<table>
+<tr><th>Finished</th><th>From</th><th>To</th><th>Result</th><th>Duration</th><th>Error</th></tr>
+{{- range .History }}
+<tr><td>{{ .FinishedAt.Format "2006-01-02T15:04:05Z07:00" }}</td><td>{{ .FromVersion }}</td><td>{{ .ToVersion }}</td><td class="{{ .Result }}">{{ .Result }}</td><td>{{ .Duration }}</td><td>{{ .Error }}</td></tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No sync recorded yet.</p>
+{{- end }}
+<p><small>Generated at {{ .GeneratedAt.Format "2006-01-02T15:04:05Z07:00" }} · <a href="/healthz">/healthz</a> · <a href="/readyz">/readyz</a> · <a href="/metrics">/metrics</a></small></p>
+</body>
+</html>
+`))
+
+// statusPageData is what the status page template renders
+type statusPageData struct {
+	Host             string
+	Cluster          string
+	Health           HealthStatus
+	InstalledVersion string
+	Recommendation   *state.Recommendation
+	DriftSince       *time.Time
+	DriftAge         time.Duration
+	Report           *report.Report
+	History          []state.SyncAttempt
+	GeneratedAt      time.Time
+}
+
+// statusPage keeps what the status page shows from the last cycle and the state file as of its end
+// Cycles hold the state file lock, so nothing here reads the state file while serving a request
+type statusPage struct {
+	cluster string
+	host    string
+	health  *health
+
+	mu             sync.Mutex
+	report         *report.Report
+	recommendation *state.Recommendation
+	history        []state.SyncAttempt
+}
+
+// newStatusPage creates a new status page for the cluster
+func newStatusPage(cluster string, health *health) *statusPage {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &statusPage{cluster: cluster, host: host, health: health}
+}
+
+// recordCycle records the decision report of a finished cycle
+func (p *statusPage) recordCycle(event events.Event) {
+	if event.Type != events.CycleFinished || event.Report == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.report = event.Report
+}
+
+// recordState records the last recommendation and the most recent sync attempts read from the state file, and its
+// last report until a cycle finishes in this process
+func (p *statusPage) recordState(st state.State) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recommendation = st.LastRecommendation
+	p.history = slices.Clone(st.History[max(len(st.History)-statusPageHistorySize, 0):])
+	slices.Reverse(p.history)
+	if p.report == nil {
+		p.report = st.LastReport
+	}
+}
+
+// data returns the status page data as of now
+func (p *statusPage) data() statusPageData {
+	health := p.health.status(false)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.health.clock.Now().UTC()
+	data := statusPageData{
+		Host:           p.host,
+		Cluster:        p.cluster,
+		Health:         health,
+		Recommendation: p.recommendation,
+		Report:         p.report,
+		History:        p.history,
+		GeneratedAt:    now,
+	}
+	if p.report != nil {
+		data.InstalledVersion = p.report.InstalledVersion
+		if p.report.Outcome == report.OutcomeSynced && !p.report.Simulated {
+			data.InstalledVersion = p.report.TargetVersion()
+		}
+	}
+
+	// the installed version drifted from the recommendation since the recommendation was first observed
+	if p.recommendation != nil && data.InstalledVersion != "" && !sameCore(data.InstalledVersion, p.recommendation.PackageVersion) {
+		since := p.recommendation.FirstObservedAt
+		if since.IsZero() {
+			since = p.recommendation.ObservedAt
+		}
+		since = since.UTC()
+		data.DriftSince = &since
+		data.DriftAge = now.Sub(since).Round(time.Second)
+	}
+	return data
+}
+
+// sameCore returns true if both versions parse and have the same core (major.minor.patch)
+func sameCore(a, b string) bool {
+	va, errA := version.NewVersion(a)
+	vb, errB := version.NewVersion(b)
+	return errA == nil && errB == nil && va.Core().Equal(vb.Core())
+}
+
+// handler returns an http.Handler serving the status page as HTML
+func (p *statusPage) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := statusPageTemplate.Execute(w, p.data()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/duration"
+	"github.com/sol-strategies/doublezero-version-sync/internal/maintenance"
+)
+
+// windowsHorizon is how far ahead interval boundaries are checked against maintenance window openings - long enough
+// for weekly windows to open several times and monthly ones at least once
+const windowsHorizon = 5 * duration.Week
+
+// WindowReach is which maintenance windows cycles running on an interval fall inside
+type WindowReach struct {
+	// Reached are the windows at least one cycle runs inside within the horizon
+	Reached []string
+	// Unreached are the windows that open within the horizon but no cycle ever runs inside
+	Unreached []string
+	// Missed is the first opening of an unreached window, explaining why it is never reached
+	Missed *maintenance.Opening
+	// MissedBy is the first cycle after Missed opens, after it closes again
+	MissedBy time.Time
+}
+
+// CheckWindows returns which of the schedule's windows cycles running on the interval aligned to anchor from now fall
+// inside - a window is reached when an interval boundary falls between one of its openings and closings. A startup
+// anchor checks a process started at now
+func CheckWindows(schedule *maintenance.Schedule, anchor string, now time.Time, intervalDuration time.Duration) WindowReach {
+	var reach WindowReach
+	var missed []maintenance.Opening
+	var missedBy []time.Time
+	for _, opening := range schedule.Openings(now, windowsHorizon) {
+		if slices.Contains(reach.Reached, opening.Window) {
+			continue
+		}
+		// the first boundary at or after the window opens
+		boundary := nextRun(anchor, opening.Start.Add(-time.Nanosecond), now, intervalDuration, nil)
+		if boundary.Before(opening.End) {
+			reach.Reached = append(reach.Reached, opening.Window)
+			continue
+		}
+		missed = append(missed, opening)
+		missedBy = append(missedBy, boundary)
+	}
+
+	for i, opening := range missed {
+		if slices.Contains(reach.Reached, opening.Window) {
+			continue
+		}
+		if !slices.Contains(reach.Unreached, opening.Window) {
+			reach.Unreached = append(reach.Unreached, opening.Window)
+		}
+		if reach.Missed == nil {
+			reach.Missed, reach.MissedBy = &missed[i], missedBy[i]
+		}
+	}
+	return reach
+}
+
+// Err returns an error explaining why no cycle on the interval aligned to anchor can ever apply a change when windows
+// open but none is ever reached, nil otherwise
+func (r WindowReach) Err(anchor string, intervalDuration time.Duration) error {
+	if len(r.Reached) > 0 || len(r.Unreached) == 0 {
+		return nil
+	}
+	return fmt.Errorf("no cycle running every %s anchored to %s ever falls inside sync.windows [%s] - %s; shorten the "+
+		"interval, widen or move the windows to cover an interval boundary, or set sync.unreachable_windows to %s to also "+
+		"run a cycle when each window opens", duration.Format(intervalDuration), anchor, strings.Join(r.Unreached, ", "),
+		r.missedExplanation(), constants.SyncUnreachableWindowsAdjust)
+}
+
+// missedExplanation describes the first missed window opening
+func (r WindowReach) missedExplanation() string {
+	if r.Missed == nil {
+		return "no interval boundary falls between any window opening and closing"
+	}
+	return fmt.Sprintf("e.g. %s opens %s and closes %s, the next cycle runs %s", r.Missed.Window,
+		r.Missed.Start.UTC().Format(time.RFC3339), r.Missed.End.UTC().Format(time.RFC3339), r.MissedBy.UTC().Format(time.RFC3339))
+}
+
+// nextRun returns the first interval boundary after now aligned to anchor, or the next opening of one of the windows
+// of adjustTo when it comes first
+func nextRun(anchor string, now, startedAt time.Time, intervalDuration time.Duration, adjustTo *maintenance.Schedule) time.Time {
+	boundary := NextBoundaryFrom(AnchorTime(anchor, now, startedAt), now, intervalDuration)
+	if adjustTo == nil || !adjustTo.IsEnabled() {
+		return boundary
+	}
+	if opens := adjustTo.NextOpen(now); !opens.IsZero() && opens.Before(boundary) {
+		return opens
+	}
+	return boundary
+}
+
+// checkWindows refuses an interval none of sync.windows is ever open at a boundary of, unless
+// sync.unreachable_windows adjusts the schedule to also run a cycle when each window opens, and warns of the windows
+// never reached when some are
+func (m *Manager) checkWindows(intervalDuration time.Duration) error {
+	if m.windows == nil || !m.windows.IsEnabled() {
+		return nil
+	}
+
+	if m.adjustTo != nil {
+		m.logger.Info("also running a cycle when each of sync.windows opens", "unreachable_windows", constants.SyncUnreachableWindowsAdjust)
+		return nil
+	}
+
+	reach := CheckWindows(m.windows, m.anchor, m.clock.Now().UTC(), intervalDuration)
+	if err := reach.Err(m.anchor, intervalDuration); err != nil {
+		return err
+	}
+	if len(reach.Unreached) > 0 {
+		m.logger.Warn("no cycle ever falls inside some of sync.windows - changes are only applied in the others",
+			"unreached", reach.Unreached, "reached", reach.Reached, "interval", intervalDuration.String(), "anchor", m.anchor)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Namespace is the prefix of all metric names exported by doublezero-version-sync
+const Namespace = "doublezero_version_sync_"
+
+// metric types rendered in the TYPE line
+const (
+	typeGauge   = "gauge"
+	typeCounter = "counter"
+)
+
+// Registry is a set of gauges and counters rendered in the Prometheus text exposition format
+// It is safe for concurrent use
+type Registry struct {
+	mu     sync.Mutex
+	gauges []*Gauge
+}
+
+// Gauge is a metric family whose samples are set to arbitrary values, one sample per set of label values
+type Gauge struct {
+	registry   *Registry
+	metricType string
+	name       string
+	help       string
+	labelNames []string
+	samples    map[string]sample
+}
+
+// Counter is a metric family whose samples only increase, one sample per set of label values
+type Counter struct {
+	gauge *Gauge
+}
+
+// sample is a single gauge value with its label values
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewGauge registers a new gauge with the given name, help text and label names
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	return r.register(typeGauge, name, help, labelNames)
+}
+
+// NewCounter registers a new counter with the given name, help text and label names
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{gauge: r.register(typeCounter, name, help, labelNames)}
+}
+
+// register registers a new metric family of the given type
+func (r *Registry) register(metricType, name, help string, labelNames []string) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g := &Gauge{
+		registry:   r,
+		metricType: metricType,
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		samples:    make(map[string]sample),
+	}
+	r.gauges = append(r.gauges, g)
+	return g
+}
+
+// Set sets the sample with the given label values, which must match the gauge's label names in number and order
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	if len(labelValues) != len(g.labelNames) {
+		panic(fmt.Sprintf("metric %s: got %d label values, want %d", g.name, len(labelValues), len(g.labelNames)))
+	}
+
+	g.registry.mu.Lock()
+	defer g.registry.mu.Unlock()
+	g.samples[strings.Join(labelValues, "\xff")] = sample{labelValues: labelValues, value: value}
+}
+
+// SetBool sets the sample with the given label values to 1 when b is true, 0 otherwise
+func (g *Gauge) SetBool(b bool, labelValues ...string) {
+	value := 0.0
+	if b {
+		value = 1
+	}
+	g.Set(value, labelValues...)
+}
+
+// Add adds delta to the sample with the given label values, which must match the gauge's label names in number and
+// order - a missing sample starts at 0
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	if len(labelValues) != len(g.labelNames) {
+		panic(fmt.Sprintf("metric %s: got %d label values, want %d", g.name, len(labelValues), len(g.labelNames)))
+	}
+
+	g.registry.mu.Lock()
+	defer g.registry.mu.Unlock()
+	key := strings.Join(labelValues, "\xff")
+	g.samples[key] = sample{labelValues: labelValues, value: g.samples[key].value + delta}
+}
+
+// Add adds delta, which must not be negative, to the sample with the given label values
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metric %s: counter cannot decrease", c.gauge.name))
+	}
+	c.gauge.Add(delta, labelValues...)
+}
+
+// Inc increments the sample with the given label values by 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Reset removes all samples, e.g. before setting an info metric whose labels have changed
+func (g *Gauge) Reset() {
+	g.registry.mu.Lock()
+	defer g.registry.mu.Unlock()
+	g.samples = make(map[string]sample)
+}
+
+// Write writes all metrics with at least one sample in the Prometheus text exposition format, samples sorted by labels
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, g := range r.gauges {
+		if len(g.samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", g.name, escapeHelp(g.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", g.name, g.metricType)
+
+		keys := make([]string, 0, len(g.samples))
+		for key := range g.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := g.samples[key]
+			bw.WriteString(g.name)
+			if len(g.labelNames) > 0 {
+				pairs := make([]string, len(g.labelNames))
+				for i, labelName := range g.labelNames {
+					pairs[i] = fmt.Sprintf("%s=\"%s\"", labelName, escapeLabelValue(s.labelValues[i]))
+				}
+				bw.WriteString("{" + strings.Join(pairs, ",") + "}")
+			}
+			bw.WriteString(" " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Handler returns an http.Handler serving the registry in the Prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.Write(w)
+	})
+}
+
+// escapeHelp escapes backslashes and newlines in help text
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabelValue escapes backslashes, double quotes and newlines in label values
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
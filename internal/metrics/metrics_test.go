@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	info := r.NewGauge("test_version_info", "Installed version.", "version")
+	up := r.NewGauge("test_up", "Whether the check passed.")
+	r.NewGauge("test_unset", "Never set, not written.")
+
+	info.Set(1, `0.7.1"-1`)
+	info.Set(1, "0.6.9")
+	up.SetBool(true)
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `# HELP test_version_info Installed version.
+# TYPE test_version_info gauge
+test_version_info{version="0.6.9"} 1
+test_version_info{version="0.7.1\"-1"} 1
+# HELP test_up Whether the check passed.
+# TYPE test_up gauge
+test_up 1
+`
+	if b.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	info.Reset()
+	up.SetBool(false)
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got, want := rec.Body.String(), "# HELP test_up Whether the check passed.\n# TYPE test_up gauge\ntest_up 0\n"; got != want {
+		t.Errorf("handler body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGaugeSetPanicsOnLabelMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on label count mismatch")
+		}
+	}()
+	NewRegistry().NewGauge("test_info", "help", "a", "b").Set(1, "only-one")
+}
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+	overruns := r.NewCounter("test_overruns_total", "Cycle overruns.", "policy")
+
+	overruns.Inc("skip_next")
+	overruns.Add(2, "skip_next")
+	overruns.Add(0, "queue_one")
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `# HELP test_overruns_total Cycle overruns.
+# TYPE test_overruns_total counter
+test_overruns_total{policy="queue_one"} 0
+test_overruns_total{policy="skip_next"} 3
+`
+	if b.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"maps"
+	"strings"
+	"text/template"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+)
+
+// defaultTitles are the built-in en titles chat notifiers send each event type with, rendered with the event - other
+// event types are titled with their message
+var defaultTitles = map[string]string{
+	EventSynced:                 `{{ if eq .Fields.direction "downgrade" }}⬇ {{ .Host }} downgraded{{ else }}⬆ {{ .Host }} upgraded{{ end }} DoubleZero {{ .Fields.version_from }} → {{ .Fields.version_to }}`,
+	EventSyncFailed:             `🔴 {{ .Host }} failed to sync DoubleZero {{ .Fields.version_from }} → {{ .Fields.version_to }}`,
+	EventRebootRequired:         `🔁 {{ .Host }} requires a reboot after syncing DoubleZero {{ .Fields.version_from }} → {{ .Fields.version_to }}`,
+	EventRecommendationRollback: `↩ {{ .Cluster }} recommended DoubleZero version rolled back {{ .Fields.previous_version }} → {{ .Fields.recommended_version }}`,
+	EventVersionSkipped:         `⏭ {{ .Host }} skipped DoubleZero {{ .Fields.skipped_version }}`,
+	EventApprovalRequired:       `✋ {{ .Host }} DoubleZero sync {{ .Fields.version_from }} → {{ .Fields.version_to }} awaits approval`,
+	EventFreezeDrift:            `❄ {{ .Host }} DoubleZero {{ .Fields.version_from }} → {{ .Fields.version_to }} deferred by {{ .Fields.freeze }}`,
+	EventRepoLag:                `⏳ {{ .Host }} DoubleZero {{ .Fields.package_version }} not yet in the {{ .Fields.repository }} repository`,
+	EventSyncFailing:            `🚨 {{ .Host }} DoubleZero sync failing - {{ .Fields.consecutive_failures }} cycles in a row failed`,
+	EventSyncRecovered:          `✅ {{ .Host }} DoubleZero sync recovered after {{ .Fields.consecutive_failures }} failed cycles`,
+}
+
+// Catalog renders the user-facing titles and messages of events in one locale
+type Catalog struct {
+	titles   map[string]*template.Template
+	messages map[string]*template.Template
+}
+
+// NewCatalog returns the catalog of locale in the notifications configuration, the built-in en titles and the event
+// messages overridden by the templates of the locale. Templates are validated with the config, one that doesn't parse
+// keeps the default
+func NewCatalog(cfg config.Notifications, locale string) *Catalog {
+	catalog := &Catalog{titles: map[string]*template.Template{}, messages: map[string]*template.Template{}}
+	for eventType, text := range defaultTitles {
+		catalog.titles[eventType] = template.Must(parseTemplate(eventType, text))
+	}
+	for eventType, message := range cfg.Catalog[locale] {
+		catalog.set(catalog.titles, eventType, message.Title)
+		catalog.set(catalog.messages, eventType, message.Message)
+	}
+	return catalog
+}
+
+// WithTitles returns a copy of the catalog with the titles of event types overridden, e.g. by a notifier's templates
+func (c *Catalog) WithTitles(overrides map[string]string) *Catalog {
+	if c == nil {
+		c = NewCatalog(config.Notifications{}, "")
+	}
+	catalog := &Catalog{titles: maps.Clone(c.titles), messages: c.messages}
+	for eventType, text := range overrides {
+		catalog.set(catalog.titles, eventType, text)
+	}
+	return catalog
+}
+
+// set sets the template of an event type when text is set and parses
+func (c *Catalog) set(templates map[string]*template.Template, eventType, text string) {
+	if text == "" {
+		return
+	}
+	if tmpl, err := parseTemplate(eventType, text); err == nil {
+		templates[eventType] = tmpl
+	}
+}
+
+// parseTemplate parses a catalog template, rendering missing fields empty
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=zero").Parse(text)
+}
+
+// Title returns the title of event, its message when its type has no title or the title fails to render
+func (c *Catalog) Title(event Event) string {
+	if title, ok := render(c.titles[event.Type], event); ok {
+		return title
+	}
+	return c.Message(event)
+}
+
+// Message returns the message of event, the event's own message when its type has no message template or the
+// template fails to render
+func (c *Catalog) Message(event Event) string {
+	if message, ok := render(c.messages[event.Type], event); ok {
+		return message
+	}
+	return event.Message
+}
+
+// render renders tmpl with event, returning false when there is no template or it renders empty or fails
+func render(tmpl *template.Template, event Event) (string, bool) {
+	if tmpl == nil {
+		return "", false
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, event); err != nil || strings.TrimSpace(text.String()) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(text.String()), true
+}
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+)
+
+func TestCatalog(t *testing.T) {
+	cfg := config.Notifications{Catalog: map[string]map[string]config.NotificationMessage{
+		"de": {
+			EventSyncFailed: {
+				Title:   "🔴 {{ .Host }}: DoubleZero-Update {{ .Fields.version_from }} → {{ .Fields.version_to }} fehlgeschlagen",
+				Message: "Update fehlgeschlagen: {{ .Fields.error }}",
+			},
+			EventSyncFailing: {Message: "{{ .Fields.consecutive_failures }} Zyklen in Folge fehlgeschlagen"},
+		},
+	}}
+	failed := Event{
+		Type:    EventSyncFailed,
+		Message: "DoubleZero sync v0.7.1 -> v0.8.0 failed: exit status 1",
+		Host:    "val-1",
+		Fields:  map[string]string{"version_from": "0.7.1", "version_to": "0.8.0", "error": "exit status 1"},
+	}
+	failing := Event{Type: EventSyncFailing, Message: "DoubleZero sync failing", Host: "val-1", Fields: map[string]string{"consecutive_failures": "3"}}
+	synced := Event{Type: EventSynced, Message: "DoubleZero synced v0.7.1 -> v0.8.0", Host: "val-1", Fields: map[string]string{"version_from": "0.7.1", "version_to": "0.8.0"}}
+
+	tests := []struct {
+		name        string
+		locale      string
+		overrides   map[string]string
+		event       Event
+		wantTitle   string
+		wantMessage string
+	}{
+		{
+			name:        "locale title and message",
+			locale:      "de",
+			event:       failed,
+			wantTitle:   "🔴 val-1: DoubleZero-Update 0.7.1 → 0.8.0 fehlgeschlagen",
+			wantMessage: "Update fehlgeschlagen: exit status 1",
+		},
+		{
+			name:        "locale message keeps the built-in title",
+			locale:      "de",
+			event:       failing,
+			wantTitle:   "🚨 val-1 DoubleZero sync failing - 3 cycles in a row failed",
+			wantMessage: "3 Zyklen in Folge fehlgeschlagen",
+		},
+		{
+			name:        "event type missing from the locale falls back to en",
+			locale:      "de",
+			event:       synced,
+			wantTitle:   "⬆ val-1 upgraded DoubleZero 0.7.1 → 0.8.0",
+			wantMessage: synced.Message,
+		},
+		{
+			name:        "notifier title overrides the locale",
+			locale:      "de",
+			overrides:   map[string]string{EventSyncFailed: "{{ .Host }} kaputt"},
+			event:       failed,
+			wantTitle:   "val-1 kaputt",
+			wantMessage: "Update fehlgeschlagen: exit status 1",
+		},
+		{
+			name:        "built-in en",
+			locale:      "en",
+			event:       failed,
+			wantTitle:   "🔴 val-1 failed to sync DoubleZero 0.7.1 → 0.8.0",
+			wantMessage: failed.Message,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := NewCatalog(cfg, tt.locale).WithTitles(tt.overrides)
+			if got := catalog.Title(tt.event); got != tt.wantTitle {
+				t.Errorf("Title() = %q, want %q", got, tt.wantTitle)
+			}
+			if got := catalog.Message(tt.event); got != tt.wantMessage {
+				t.Errorf("Message() = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
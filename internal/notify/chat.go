@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// sortedFieldKeys returns the keys of the event fields in a stable order
+func sortedFieldKeys(fields map[string]string) []string {
+	return slices.Sorted(maps.Keys(fields))
+}
+
+// truncate shortens s to at most maxLength characters, marking the cut with an ellipsis
+func truncate(s string, maxLength int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLength {
+		return s
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
+
+// codeBlock wraps multi-line values in a code block so commands and diffs keep their layout, truncating them to
+// maxLength characters including the fences
+func codeBlock(value string, maxLength int) string {
+	if !strings.Contains(value, "\n") {
+		return truncate(value, maxLength)
+	}
+	return "```\n" + truncate(value, maxLength-8) + "\n```"
+}
@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestCatalogTitles(t *testing.T) {
+	synced := Event{
+		Type:    EventSynced,
+		Message: "DoubleZero synced v0.7.1 -> v0.8.0",
+		Host:    "val-1",
+		Cluster: constants.ClusterNameMainnetBeta,
+		Fields:  map[string]string{"version_from": "0.7.1", "version_to": "0.8.0", "direction": "upgrade"},
+	}
+	downgraded := synced
+	downgraded.Fields = map[string]string{"version_from": "0.8.0", "version_to": "0.7.1", "direction": "downgrade"}
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		event     Event
+		want      string
+	}{
+		{name: "upgrade", event: synced, want: "⬆ val-1 upgraded DoubleZero 0.7.1 → 0.8.0"},
+		{name: "downgrade", event: downgraded, want: "⬇ val-1 downgraded DoubleZero 0.8.0 → 0.7.1"},
+		{
+			name:      "override",
+			overrides: map[string]string{EventSynced: "{{ .Cluster }}: {{ .Host }} now on {{ .Fields.version_to }}"},
+			event:     synced,
+			want:      "mainnet-beta: val-1 now on 0.8.0",
+		},
+		{
+			name:      "empty render falls back to message",
+			overrides: map[string]string{EventSynced: "{{ .Fields.missing }}"},
+			event:     synced,
+			want:      synced.Message,
+		},
+		{name: "no template uses message", event: Event{Type: "other", Message: "something happened"}, want: "something happened"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCatalog(config.Notifications{}, "").WithTitles(tt.overrides).Title(tt.event); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatNotifiers(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := Event{
+		Type:     EventSyncFailed,
+		Severity: constants.NotificationSeverityCritical,
+		Message:  "DoubleZero sync v0.7.1 -> v0.8.0 failed: exit status 1",
+		Cluster:  constants.ClusterNameMainnetBeta,
+		Host:     "val-1",
+		Fields:   map[string]string{"version_from": "0.7.1", "version_to": "0.8.0", "diagnostics_diff": "- a\n+ <b>"},
+		Time:     time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+	cfg := config.ChatWebhook{URL: server.URL, MinSeverity: constants.NotificationSeverityInfo}
+
+	t.Run("slack", func(t *testing.T) {
+		if err := NewSlack(cfg, nil, nil).Notify(event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var message slackMessage
+		if err := json.Unmarshal(body, &message); err != nil {
+			t.Fatalf("failed to unmarshal message: %v", err)
+		}
+		if want := "🔴 val-1 failed to sync DoubleZero 0.7.1 → 0.8.0"; message.Text != want || message.Blocks[0].Text.Text != want {
+			t.Errorf("title = %q, header = %q, want %q", message.Text, message.Blocks[0].Text.Text, want)
+		}
+		if got := string(body); !strings.Contains(got, "```\\n- a\\n+ \\u0026lt;b\\u0026gt;\\n```") {
+			t.Errorf("multi-line field isn't an escaped code block: %s", got)
+		}
+		if last := message.Blocks[len(message.Blocks)-1]; last.Type != "context" {
+			t.Errorf("last block = %s, want context", last.Type)
+		}
+	})
+
+	t.Run("discord", func(t *testing.T) {
+		if err := NewDiscord(cfg, nil, nil).Notify(event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var message discordMessage
+		if err := json.Unmarshal(body, &message); err != nil {
+			t.Fatalf("failed to unmarshal message: %v", err)
+		}
+		embed := message.Embeds[0]
+		if embed.Color != discordColors[constants.NotificationSeverityCritical] {
+			t.Errorf("color = %x, want the critical color", embed.Color)
+		}
+		if embed.Description != event.Message || len(embed.Fields) != 3 || embed.Timestamp != "2025-01-01T09:00:00Z" {
+			t.Errorf("unexpected embed: %+v", embed)
+		}
+	})
+}
+
+func TestDispatcherFiltersEvents(t *testing.T) {
+	var delivered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		delivered = append(delivered, event.Type)
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(Options{}, NewWebhook(config.Webhook{URL: server.URL, MinSeverity: constants.NotificationSeverityInfo, Events: []string{EventSynced, EventSyncFailed}}, nil))
+	for _, eventType := range []string{EventSynced, EventVersionSkipped, EventSyncFailed} {
+		d.Notify(Event{Type: eventType, Severity: constants.NotificationSeverityCritical})
+	}
+
+	if got := strings.Join(delivered, ","); got != "synced,sync_failed" {
+		t.Errorf("delivered %s, want synced,sync_failed", got)
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// cloudEventsContentType is the content type of a structured mode CloudEvent
+	cloudEventsContentType = "application/cloudevents+json"
+	// cloudEventsTypePrefix prefixes the event type in the CloudEvent type, reverse-DNS as the spec recommends
+	cloudEventsTypePrefix = "com.github.sol-strategies.doublezero-version-sync."
+)
+
+// cloudEvent is a CloudEvents 1.0 event in structured mode JSON, with the event as its data
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject,omitempty"`
+	DataContentType string    `json:"datacontenttype"`
+	// Cluster and Severity are extension attributes so pipelines can filter on them without reading the data
+	Cluster  string `json:"cluster,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Data     Event  `json:"data"`
+}
+
+// newCloudEvent wraps the event in a CloudEvent sourced from the syncer on its cluster and host - the ID is derived
+// from the event so a queued event redelivered after a failure keeps it and consumers can deduplicate it
+func newCloudEvent(event Event) cloudEvent {
+	return cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              cloudEventID(event),
+		Source:          "/doublezero-version-sync/" + event.Cluster + "/" + event.Host,
+		Type:            cloudEventsTypePrefix + event.Type,
+		Time:            event.Time,
+		Subject:         event.Fields["version_to"],
+		DataContentType: "application/json",
+		Cluster:         event.Cluster,
+		Severity:        event.Severity,
+		Data:            event,
+	}
+}
+
+// cloudEventID returns a stable ID for the event, the hash of its JSON
+func cloudEventID(event Event) string {
+	body, _ := json.Marshal(event)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:16])
+}
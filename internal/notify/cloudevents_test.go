@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestWebhookCloudEvents(t *testing.T) {
+	var contentTypes []string
+	var delivered []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode CloudEvent: %v", err)
+		}
+		delivered = append(delivered, body)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(config.Webhook{URL: server.URL, MinSeverity: constants.NotificationSeverityInfo, Format: constants.WebhookFormatCloudEvents}, nil)
+	event := Event{
+		Type:     EventSynced,
+		Severity: constants.NotificationSeverityInfo,
+		Message:  "DoubleZero synced v0.7.1 -> v0.8.0",
+		Cluster:  constants.ClusterNameMainnetBeta,
+		Host:     "val-1",
+		Fields:   map[string]string{"version_from": "0.7.1", "version_to": "0.8.0"},
+		Time:     time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+	// redelivering the same event, e.g. from the queue, must keep its ID
+	for range 2 {
+		if err := webhook.Notify(event); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	if contentTypes[0] != "application/cloudevents+json" {
+		t.Errorf("Content-Type = %s, want application/cloudevents+json", contentTypes[0])
+	}
+	got := delivered[0]
+	want := map[string]any{
+		"specversion":     "1.0",
+		"source":          "/doublezero-version-sync/mainnet-beta/val-1",
+		"type":            "com.github.sol-strategies.doublezero-version-sync.synced",
+		"time":            "2025-01-01T09:00:00Z",
+		"subject":         "0.8.0",
+		"datacontenttype": "application/json",
+		"cluster":         "mainnet-beta",
+		"severity":        "info",
+	}
+	for attribute, value := range want {
+		if got[attribute] != value {
+			t.Errorf("%s = %v, want %v", attribute, got[attribute], value)
+		}
+	}
+	if data, ok := got["data"].(map[string]any); !ok || data["message"] != event.Message {
+		t.Errorf("data = %v, want the event", got["data"])
+	}
+	if id, ok := got["id"].(string); !ok || id == "" || id != delivered[1]["id"] {
+		t.Errorf("id = %v then %v, want the same non-empty ID", got["id"], delivered[1]["id"])
+	}
+}
@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+)
+
+// NewFromConfig creates a Dispatcher with the notifiers and queue from the notifications configuration
+func NewFromConfig(cfg config.Notifications, opts Options) *Dispatcher {
+	opts.Queue = NewQueue(cfg.Queue)
+	catalogs := map[string]*Catalog{}
+	catalog := func(locale string) *Catalog {
+		if catalogs[locale] == nil {
+			catalogs[locale] = NewCatalog(cfg, locale)
+		}
+		return catalogs[locale]
+	}
+
+	notifiers := make([]Notifier, 0, len(cfg.Webhooks)+len(cfg.Slack)+len(cfg.Discord)+len(cfg.PagerDuty)+len(cfg.Alertmanager))
+	for _, webhook := range cfg.Webhooks {
+		notifiers = append(notifiers, NewWebhook(webhook, opts.Transport))
+	}
+	for _, slack := range cfg.Slack {
+		notifiers = append(notifiers, NewSlack(slack, catalog(slack.Locale), opts.Transport))
+	}
+	for _, discord := range cfg.Discord {
+		notifiers = append(notifiers, NewDiscord(discord, catalog(discord.Locale), opts.Transport))
+	}
+	for _, pagerDuty := range cfg.PagerDuty {
+		notifiers = append(notifiers, NewPagerDuty(pagerDuty, catalog(pagerDuty.Locale), opts.Transport))
+	}
+	for _, alertmanager := range cfg.Alertmanager {
+		notifiers = append(notifiers, NewAlertmanager(alertmanager, catalog(alertmanager.Locale), opts.Transport))
+	}
+	return NewDispatcher(opts, notifiers...)
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+const (
+	// discordMaxTitleLength is the most characters Discord accepts in an embed title
+	discordMaxTitleLength = 256
+	// discordMaxDescriptionLength is the most characters Discord accepts in an embed description
+	discordMaxDescriptionLength = 4096
+	// discordMaxFieldNameLength is the most characters Discord accepts in an embed field name
+	discordMaxFieldNameLength = 256
+	// discordMaxFieldValueLength is the most characters Discord accepts in an embed field value
+	discordMaxFieldValueLength = 1024
+	// discordMaxFields is the most fields Discord accepts in an embed
+	discordMaxFields = 25
+)
+
+// discordColors are the embed colors of the severities
+var discordColors = map[string]int{
+	constants.NotificationSeverityInfo:     0x2ecc71,
+	constants.NotificationSeverityWarning:  0xf1c40f,
+	constants.NotificationSeverityCritical: 0xe74c3c,
+}
+
+// Discord is a notifier that POSTs events to a Discord webhook as embeds
+type Discord struct {
+	subscription
+	url     string
+	catalog *Catalog
+	client  *http.Client
+}
+
+// discordMessage is a Discord webhook payload
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+// discordEmbed is a Discord rich embed
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+// discordField is a Discord embed field
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// discordFooter is a Discord embed footer
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// NewDiscord creates a new Discord notifier sending titles and messages from catalog, with the notifier's title
+// templates - a nil catalog uses the built-in one and a nil transport uses http.DefaultTransport
+func NewDiscord(cfg config.ChatWebhook, catalog *Catalog, transport http.RoundTripper) *Discord {
+	return &Discord{
+		subscription: subscription{minSeverity: cfg.MinSeverity, events: cfg.Events},
+		url:          cfg.URL,
+		catalog:      catalog.WithTitles(cfg.Templates),
+		client:       &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// Name returns the notifier name
+func (d *Discord) Name() string {
+	return "discord"
+}
+
+// Notify POSTs the event formatted as an embed
+func (d *Discord) Notify(event Event) error {
+	return postJSON(d.client, d.url, d.message(event))
+}
+
+// message formats the event as an embed colored by its severity, titled with its title, described by its message,
+// with its fields and a footer with its severity, cluster and host
+func (d *Discord) message(event Event) discordMessage {
+	title, message := d.catalog.Title(event), d.catalog.Message(event)
+	embed := discordEmbed{
+		Title:     truncate(title, discordMaxTitleLength),
+		Color:     discordColors[event.Severity],
+		Footer:    &discordFooter{Text: fmt.Sprintf("%s · %s · %s", event.Severity, event.Cluster, event.Host)},
+		Timestamp: event.Time.UTC().Format(time.RFC3339),
+	}
+	if message != title {
+		embed.Description = truncate(message, discordMaxDescriptionLength)
+	}
+
+	for _, key := range sortedFieldKeys(event.Fields) {
+		if len(embed.Fields) == discordMaxFields {
+			break
+		}
+		value := codeBlock(event.Fields[key], discordMaxFieldValueLength)
+		if value == "" {
+			// Discord rejects empty field values
+			value = "-"
+		}
+		embed.Fields = append(embed.Fields, discordField{
+			Name:   truncate(key, discordMaxFieldNameLength),
+			Value:  value,
+			Inline: value == event.Fields[key] && len(value) <= 40,
+		})
+	}
+
+	return discordMessage{Embeds: []discordEmbed{embed}}
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// incidentEvents are the events incident notifiers deliver - sync_failing opens or updates the incident,
+// sync_recovered resolves it
+var incidentEvents = []string{EventSyncFailing, EventSyncRecovered}
+
+// incidentKey identifies the incident of a host on a cluster, so repeated sync_failing events update one incident and
+// sync_recovered resolves it
+func incidentKey(event Event) string {
+	return fmt.Sprintf("doublezero-version-sync/%s/%s", event.Cluster, event.Host)
+}
+
+// PagerDuty is a notifier that triggers a PagerDuty incident while syncs keep failing and resolves it on recovery
+type PagerDuty struct {
+	subscription
+	url        string
+	routingKey string
+	catalog    *Catalog
+	client     *http.Client
+}
+
+// pagerDutyEvent is a PagerDuty Events API v2 event
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+// pagerDutyPayload is the payload of a PagerDuty trigger event
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component"`
+	Group         string            `json:"group"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// NewPagerDuty creates a new PagerDuty notifier summarizing incidents with the messages of catalog - a nil catalog uses
+// the built-in one and a nil transport uses http.DefaultTransport
+func NewPagerDuty(cfg config.PagerDuty, catalog *Catalog, transport http.RoundTripper) *PagerDuty {
+	return &PagerDuty{
+		subscription: subscription{minSeverity: constants.NotificationSeverityInfo, events: incidentEvents},
+		url:          cfg.URL,
+		routingKey:   cfg.RoutingKey,
+		catalog:      catalog.WithTitles(nil),
+		client:       &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// Name returns the notifier name
+func (p *PagerDuty) Name() string {
+	return "pagerduty"
+}
+
+// Notify triggers the incident on sync_failing and resolves it on sync_recovered
+func (p *PagerDuty) Notify(event Event) error {
+	pdEvent := pagerDutyEvent{RoutingKey: p.routingKey, EventAction: "resolve", DedupKey: incidentKey(event)}
+	if event.Type == EventSyncFailing {
+		pdEvent.EventAction = "trigger"
+		pdEvent.Payload = &pagerDutyPayload{
+			Summary:       truncate(p.catalog.Message(event), 1024),
+			Source:        event.Host,
+			Severity:      event.Severity,
+			Timestamp:     event.Time.UTC().Format(time.RFC3339),
+			Component:     "doublezero",
+			Group:         event.Cluster,
+			CustomDetails: event.Fields,
+		}
+	}
+	return postJSON(p.client, p.url, pdEvent)
+}
+
+// Alertmanager is a notifier that fires an Alertmanager alert while syncs keep failing and resolves it on recovery
+type Alertmanager struct {
+	subscription
+	url     string
+	labels  map[string]string
+	catalog *Catalog
+	client  *http.Client
+}
+
+// alertmanagerAlert is an Alertmanager v2 API alert
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    string            `json:"startsAt,omitempty"`
+	EndsAt      string            `json:"endsAt,omitempty"`
+}
+
+// NewAlertmanager creates a new Alertmanager notifier summarizing alerts with the messages of catalog - a nil catalog
+// uses the built-in one and a nil transport uses http.DefaultTransport
+func NewAlertmanager(cfg config.Alertmanager, catalog *Catalog, transport http.RoundTripper) *Alertmanager {
+	return &Alertmanager{
+		subscription: subscription{minSeverity: constants.NotificationSeverityInfo, events: incidentEvents},
+		url:          strings.TrimSuffix(cfg.URL, "/") + "/api/v2/alerts",
+		labels:       cfg.Labels,
+		catalog:      catalog.WithTitles(nil),
+		client:       &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// Name returns the notifier name
+func (a *Alertmanager) Name() string {
+	return "alertmanager"
+}
+
+// Notify fires the alert on sync_failing and resolves it on sync_recovered by ending it. sync_failing is raised on
+// every failed cycle, refreshing the alert before Alertmanager's resolve_timeout expires it
+func (a *Alertmanager) Notify(event Event) error {
+	labels := map[string]string{
+		"alertname": "DoubleZeroVersionSyncFailing",
+		"cluster":   event.Cluster,
+		"host":      event.Host,
+		"severity":  constants.NotificationSeverityCritical,
+	}
+	maps.Copy(labels, a.labels)
+
+	annotations := map[string]string{}
+	maps.Copy(annotations, event.Fields)
+	annotations["summary"] = a.catalog.Message(event)
+	annotations["incident_key"] = incidentKey(event)
+
+	alert := alertmanagerAlert{Labels: labels, Annotations: annotations}
+	if event.Type == EventSyncFailing {
+		alert.StartsAt = event.Time.UTC().Format(time.RFC3339)
+	} else {
+		alert.EndsAt = event.Time.UTC().Format(time.RFC3339)
+	}
+	return postJSON(a.client, a.url, []alertmanagerAlert{alert})
+}
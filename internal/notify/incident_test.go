@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestIncidentNotifiers(t *testing.T) {
+	var path string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		body = raw
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	failing := Event{
+		Type:     EventSyncFailing,
+		Severity: constants.NotificationSeverityCritical,
+		Message:  "DoubleZero sync failing - last 3 sync cycles failed",
+		Cluster:  constants.ClusterNameMainnetBeta,
+		Host:     "val-1",
+		Fields:   map[string]string{"consecutive_failures": "3"},
+		Time:     time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+	recovered := failing
+	recovered.Type = EventSyncRecovered
+	recovered.Severity = constants.NotificationSeverityInfo
+	recovered.Time = failing.Time.Add(time.Hour)
+
+	t.Run("pagerduty", func(t *testing.T) {
+		pagerDuty := NewPagerDuty(config.PagerDuty{RoutingKey: "key", URL: server.URL + "/v2/enqueue"}, nil, nil)
+		tests := []struct {
+			event      Event
+			wantAction string
+		}{
+			{failing, "trigger"},
+			{recovered, "resolve"},
+		}
+		for _, tt := range tests {
+			if err := pagerDuty.Notify(tt.event); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got pagerDutyEvent
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+			if got.EventAction != tt.wantAction || got.DedupKey != "doublezero-version-sync/mainnet-beta/val-1" || got.RoutingKey != "key" {
+				t.Errorf("%s: got %+v, want %s with the host's dedup key", tt.event.Type, got, tt.wantAction)
+			}
+			if (got.Payload != nil) != (tt.wantAction == "trigger") {
+				t.Errorf("%s: payload = %+v, want one only when triggering", tt.event.Type, got.Payload)
+			}
+		}
+	})
+
+	t.Run("alertmanager", func(t *testing.T) {
+		alertmanager := NewAlertmanager(config.Alertmanager{URL: server.URL + "/", Labels: map[string]string{"team": "validators"}}, nil, nil)
+		if err := alertmanager.Notify(failing); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var alerts []alertmanagerAlert
+		if err := json.Unmarshal(body, &alerts); err != nil {
+			t.Fatalf("failed to unmarshal alerts: %v", err)
+		}
+		if path != "/api/v2/alerts" || len(alerts) != 1 || alerts[0].Labels["team"] != "validators" || alerts[0].EndsAt != "" {
+			t.Errorf("firing: path %s, alerts %+v", path, alerts)
+		}
+
+		if err := alertmanager.Notify(recovered); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := json.Unmarshal(body, &alerts); err != nil {
+			t.Fatalf("failed to unmarshal alerts: %v", err)
+		}
+		if alerts[0].EndsAt != "2025-01-01T10:00:00Z" {
+			t.Errorf("resolved alert ends at %q, want the recovery time", alerts[0].EndsAt)
+		}
+	})
+}
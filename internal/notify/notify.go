@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// event types, see constants.ValidNotificationEvents
+const (
+	// EventRecommendationRollback is raised when the recommended version decreases
+	EventRecommendationRollback = constants.NotificationEventRecommendationRollback
+	// EventVersionSkipped is raised when an operator skip prevents syncing to the recommended version
+	EventVersionSkipped = constants.NotificationEventVersionSkipped
+	// EventSynced is raised when the sync commands installed the target version
+	EventSynced = constants.NotificationEventSynced
+	// EventSyncFailed is raised when the sync commands or the daemon check after them fail
+	EventSyncFailed = constants.NotificationEventSyncFailed
+	// EventRebootRequired is raised when the sync commands leave the host requiring a reboot
+	EventRebootRequired = constants.NotificationEventRebootRequired
+	// EventApprovalRequired is raised when a sync plan is held for operator approval under sync.approval
+	EventApprovalRequired = constants.NotificationEventApprovalRequired
+	// EventFreezeDrift is raised when a change freeze period defers a version change
+	EventFreezeDrift = constants.NotificationEventFreezeDrift
+	// EventRepoLag is raised when the target package version isn't published in the package repository yet
+	EventRepoLag = constants.NotificationEventRepoLag
+	// EventSyncFailing is raised on every failed cycle once health.max_consecutive_failures cycles in a row failed when
+	// running on an interval
+	EventSyncFailing = constants.NotificationEventSyncFailing
+	// EventSyncRecovered is raised when a cycle succeeds after EventSyncFailing was raised
+	EventSyncRecovered = constants.NotificationEventSyncRecovered
+)
+
+// severityRanks orders severities for min_severity filtering
+var severityRanks = map[string]int{
+	constants.NotificationSeverityInfo:     0,
+	constants.NotificationSeverityWarning:  1,
+	constants.NotificationSeverityCritical: 2,
+}
+
+// Event is a notification event
+type Event struct {
+	// Type is the event type, e.g. recommendation_rollback
+	Type string `json:"type"`
+	// Severity is one of info, warning, critical
+	Severity string `json:"severity"`
+	// Message is a human readable summary
+	Message string `json:"message"`
+	// Cluster is the cluster the syncer runs on
+	Cluster string `json:"cluster"`
+	// Host is the host name the syncer runs on
+	Host string `json:"host"`
+	// Fields are additional event details
+	Fields map[string]string `json:"fields,omitempty"`
+	// Time is when the event was raised
+	Time time.Time `json:"time"`
+}
+
+// Notifier delivers events to an external endpoint
+type Notifier interface {
+	// Name returns the notifier name, used for logging
+	Name() string
+	// MinSeverity returns the minimum severity of events delivered by this notifier
+	MinSeverity() string
+	// Events returns the event types delivered by this notifier, empty for all
+	Events() []string
+	// Notify delivers the event
+	Notify(event Event) error
+}
+
+// subscription is the min severity and event types a notifier delivers, embedded by the notifiers to implement
+// Notifier.MinSeverity and Notifier.Events
+type subscription struct {
+	minSeverity string
+	events      []string
+}
+
+// MinSeverity returns the minimum severity of events delivered by this notifier
+func (s subscription) MinSeverity() string {
+	return s.minSeverity
+}
+
+// Events returns the event types delivered by this notifier, empty for all
+func (s subscription) Events() []string {
+	return s.events
+}
+
+// Options represents the options for creating a new Dispatcher
+type Options struct {
+	// Cluster is the cluster the syncer runs on, added to every event
+	Cluster string
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock timestamps events, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport used by HTTP notifiers, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// Queue persists events that failed to deliver for Retry, nil drops them
+	Queue *Queue
+}
+
+// Dispatcher fans events out to all configured notifiers, queueing delivery failures for retry rather than returning them
+type Dispatcher struct {
+	cluster   string
+	host      string
+	notifiers []Notifier
+	queue     *Queue
+	logger    *log.Logger
+	clock     clock.Clock
+}
+
+// NewDispatcher creates a new Dispatcher for the given notifiers
+func NewDispatcher(opts Options, notifiers ...Notifier) *Dispatcher {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &Dispatcher{
+		cluster:   opts.Cluster,
+		host:      host,
+		notifiers: notifiers,
+		queue:     opts.Queue,
+		logger:    logging.WithPrefix(opts.Logger, "notify"),
+		clock:     opts.Clock,
+	}
+}
+
+// AddNotifier adds a notifier that receives every event dispatched from now on
+func (d *Dispatcher) AddNotifier(notifier Notifier) {
+	d.notifiers = append(d.notifiers, notifier)
+}
+
+// Notify fills in the common event fields and delivers the event to every notifier whose min severity it meets and
+// that subscribes to its type
+func (d *Dispatcher) Notify(event Event) {
+	event.Cluster = d.cluster
+	event.Host = d.host
+	if event.Time.IsZero() {
+		event.Time = d.clock.Now().UTC()
+	}
+
+	d.logger.Debug("dispatching event", "type", event.Type, "severity", event.Severity, "message", event.Message)
+	var failed []Queued
+	for i, notifier := range d.notifiers {
+		if severityRanks[event.Severity] < severityRanks[notifier.MinSeverity()] {
+			continue
+		}
+		if events := notifier.Events(); len(events) > 0 && !slices.Contains(events, event.Type) {
+			continue
+		}
+		err := notifier.Notify(event)
+		if err == nil {
+			continue
+		}
+		if d.queue == nil {
+			d.logger.Error("failed to deliver notification", "notifier", notifier.Name(), "type", event.Type, "error", err)
+			continue
+		}
+		queued := Queued{Notifier: notifierKey(i, notifier), Event: event, Attempts: 1, LastError: err.Error()}
+		queued.NextAttemptAt = event.Time.Add(d.queue.backoff(queued.Attempts))
+		d.logger.Error("failed to deliver notification - queued for retry", "notifier", queued.Notifier, "type", event.Type, "next_attempt_at", queued.NextAttemptAt, "error", err)
+		failed = append(failed, queued)
+	}
+
+	if len(failed) > 0 {
+		d.enqueue(failed)
+	}
+}
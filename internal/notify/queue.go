@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+)
+
+// Queued is a notification that failed to deliver to a notifier, waiting to be retried
+type Queued struct {
+	// Notifier is the key of the notifier the event failed to deliver to, e.g. webhook[0]
+	Notifier string `json:"notifier"`
+	// Event is the notification event
+	Event Event `json:"event"`
+	// Attempts counts the failed deliveries
+	Attempts int `json:"attempts"`
+	// NextAttemptAt is when delivery is next retried
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// LastError is why the last delivery failed
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Queue persists notifications that failed to deliver to a JSON file until they are delivered or expire, so they
+// survive restarts and aren't lost while their endpoints are down
+type Queue struct {
+	path           string
+	maxSize        int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxAge         time.Duration
+	mu             sync.Mutex
+}
+
+// NewQueue creates a new Queue from the notification queue configuration, nil when queueing is disabled
+func NewQueue(cfg config.NotificationQueue) *Queue {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	return &Queue{
+		path:           cfg.File,
+		maxSize:        cfg.MaxSize,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		maxAge:         cfg.MaxAge,
+	}
+}
+
+// Len returns the number of queued notifications
+func (q *Queue) Len() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued, err := q.load()
+	return len(queued), err
+}
+
+// backoff returns how long to wait before the next delivery after the given number of failed attempts
+func (q *Queue) backoff(attempts int) time.Duration {
+	backoff := q.initialBackoff
+	for i := 1; i < attempts && backoff < q.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, q.maxBackoff)
+}
+
+// load reads the queued notifications, the caller must hold the lock
+func (q *Queue) load() ([]Queued, error) {
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification queue %s: %w", q.path, err)
+	}
+
+	var queued []Queued
+	if err := json.Unmarshal(data, &queued); err != nil {
+		return nil, fmt.Errorf("failed to parse notification queue %s: %w", q.path, err)
+	}
+	return queued, nil
+}
+
+// save atomically writes the queued notifications, the caller must hold the lock
+func (q *Queue) save(queued []Queued) error {
+	data, err := json.MarshalIndent(queued, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification queue: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create notification queue directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary notification queue file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary notification queue file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary notification queue file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), q.path)
+}
+
+// QueueDepth returns the number of notifications queued for retry, 0 when queueing is disabled
+func (d *Dispatcher) QueueDepth() (int, error) {
+	if d.queue == nil {
+		return 0, nil
+	}
+	return d.queue.Len()
+}
+
+// Retry redelivers the queued notifications that are due, dropping those older than the queue's max age or whose
+// notifier is no longer configured. A notifier that fails again isn't retried further this round, so a down endpoint
+// isn't hammered with the whole backlog
+func (d *Dispatcher) Retry() {
+	if d.queue == nil {
+		return
+	}
+
+	d.queue.mu.Lock()
+	defer d.queue.mu.Unlock()
+
+	queued, err := d.queue.load()
+	if err != nil {
+		d.logger.Error("failed to load notification queue", "error", err)
+		return
+	}
+	if len(queued) == 0 {
+		return
+	}
+
+	now := d.clock.Now().UTC()
+	notifiers := d.notifiersByKey()
+	failing := map[string]bool{}
+	kept := make([]Queued, 0, len(queued))
+	for _, entry := range queued {
+		notifier := notifiers[entry.Notifier]
+		switch {
+		case now.Sub(entry.Event.Time) > d.queue.maxAge:
+			d.logger.Warn("dropping queued notification older than max age", "notifier", entry.Notifier, "type", entry.Event.Type, "raised_at", entry.Event.Time, "attempts", entry.Attempts, "max_age", d.queue.maxAge)
+			continue
+		case notifier == nil:
+			d.logger.Warn("dropping queued notification for a notifier no longer configured", "notifier", entry.Notifier, "type", entry.Event.Type)
+			continue
+		case failing[entry.Notifier] || now.Before(entry.NextAttemptAt):
+			kept = append(kept, entry)
+			continue
+		}
+
+		if err := notifier.Notify(entry.Event); err != nil {
+			entry.Attempts++
+			entry.NextAttemptAt = now.Add(d.queue.backoff(entry.Attempts))
+			entry.LastError = err.Error()
+			failing[entry.Notifier] = true
+			d.logger.Warn("failed to redeliver queued notification", "notifier", entry.Notifier, "type", entry.Event.Type, "attempts", entry.Attempts, "next_attempt_at", entry.NextAttemptAt, "error", err)
+			kept = append(kept, entry)
+			continue
+		}
+		d.logger.Info("delivered queued notification", "notifier", entry.Notifier, "type", entry.Event.Type, "attempts", entry.Attempts+1)
+	}
+
+	if err := d.queue.save(kept); err != nil {
+		d.logger.Error("failed to save notification queue", "error", err)
+	}
+}
+
+// enqueue queues notifications that failed to deliver for retry, dropping the oldest beyond the queue's max size
+func (d *Dispatcher) enqueue(failed []Queued) {
+	d.queue.mu.Lock()
+	defer d.queue.mu.Unlock()
+
+	queued, err := d.queue.load()
+	if err != nil {
+		d.logger.Error("failed to load notification queue - dropping failed notifications", "dropped", len(failed), "error", err)
+		return
+	}
+
+	queued = append(queued, failed...)
+	if over := len(queued) - d.queue.maxSize; over > 0 {
+		d.logger.Warn("notification queue full - dropping the oldest notifications", "dropped", over, "max_size", d.queue.maxSize)
+		queued = queued[over:]
+	}
+
+	if err := d.queue.save(queued); err != nil {
+		d.logger.Error("failed to save notification queue - dropping failed notifications", "dropped", len(failed), "error", err)
+	}
+}
+
+// notifiersByKey returns the notifiers by their queue key
+func (d *Dispatcher) notifiersByKey() map[string]Notifier {
+	notifiers := make(map[string]Notifier, len(d.notifiers))
+	for i, notifier := range d.notifiers {
+		notifiers[notifierKey(i, notifier)] = notifier
+	}
+	return notifiers
+}
+
+// notifierKey identifies a notifier in the queue by its name and position, e.g. webhook[0]
+func notifierKey(i int, notifier Notifier) string {
+	return fmt.Sprintf("%s[%d]", notifier.Name(), i)
+}
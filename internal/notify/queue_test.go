@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestDispatcherQueuesAndRetriesFailedNotifications(t *testing.T) {
+	var up atomic.Bool
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		delivered.Add(1)
+	}))
+	defer server.Close()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	queueConfig := config.NotificationQueue{
+		File:           filepath.Join(t.TempDir(), "queue.json"),
+		MaxSize:        2,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Minute,
+		MaxAge:         time.Hour,
+	}
+	d := NewDispatcher(Options{Clock: fakeClock, Queue: NewQueue(queueConfig)},
+		NewWebhook(config.Webhook{URL: server.URL, MinSeverity: constants.NotificationSeverityInfo}, nil))
+
+	depth := func() int {
+		t.Helper()
+		n, err := d.QueueDepth()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return n
+	}
+
+	for _, eventType := range []string{"first", "second", "third"} {
+		d.Notify(Event{Type: eventType, Severity: constants.NotificationSeverityCritical})
+	}
+	if got := depth(); got != 2 {
+		t.Fatalf("queue depth = %d, want 2 - the oldest dropped beyond max_size", got)
+	}
+
+	// still down: retried once after the initial backoff, then backed off further
+	fakeClock.Advance(30 * time.Second)
+	d.Retry()
+	queued, err := d.queue.load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queued[0].Attempts != 2 || queued[1].Attempts != 1 {
+		t.Errorf("attempts = %d, %d - want 2, 1: a failing notifier isn't retried further in the same round", queued[0].Attempts, queued[1].Attempts)
+	}
+	if want := fakeClock.Now().Add(time.Minute); !queued[0].NextAttemptAt.Equal(want) {
+		t.Errorf("next attempt at %s, want %s", queued[0].NextAttemptAt, want)
+	}
+
+	// back up, but the backoff hasn't elapsed yet
+	up.Store(true)
+	d.Retry()
+	if got := delivered.Load(); got != 1 {
+		t.Errorf("delivered %d before the backoff elapsed, want only the one not backed off", got)
+	}
+
+	fakeClock.Advance(time.Minute)
+	d.Retry()
+	if got := depth(); got != 0 {
+		t.Errorf("queue depth = %d after the endpoint recovered, want 0", got)
+	}
+	if got := delivered.Load(); got != 2 {
+		t.Errorf("delivered %d, want 2", got)
+	}
+}
+
+func TestRetryDropsExpiredNotifications(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	d := NewDispatcher(Options{Clock: fakeClock, Queue: NewQueue(config.NotificationQueue{
+		File:           filepath.Join(t.TempDir(), "queue.json"),
+		MaxSize:        10,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+		MaxAge:         time.Hour,
+	})}, NewWebhook(config.Webhook{URL: server.URL, MinSeverity: constants.NotificationSeverityInfo}, nil))
+
+	d.Notify(Event{Type: EventSyncFailed, Severity: constants.NotificationSeverityCritical})
+	fakeClock.Advance(2 * time.Hour)
+	d.Retry()
+
+	if n, _ := d.QueueDepth(); n != 0 {
+		t.Errorf("queue depth = %d, want 0 - notifications older than max_age are dropped", n)
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+)
+
+const (
+	// slackMaxHeaderLength is the most characters Slack accepts in a header block
+	slackMaxHeaderLength = 150
+	// slackMaxTextLength is the most characters Slack accepts in a section block text
+	slackMaxTextLength = 3000
+	// slackMaxFieldLength is the most characters Slack accepts in a section block field
+	slackMaxFieldLength = 2000
+	// slackMaxFieldsPerSection is the most fields Slack accepts in a section block
+	slackMaxFieldsPerSection = 10
+)
+
+// slackEscaper escapes the characters Slack mrkdwn treats as control characters
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Slack is a notifier that POSTs events to a Slack incoming webhook as blocks
+type Slack struct {
+	subscription
+	url     string
+	catalog *Catalog
+	client  *http.Client
+}
+
+// slackMessage is a Slack incoming webhook payload
+type slackMessage struct {
+	// Text is the fallback shown in notifications
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackBlock is a Slack layout block
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackText is a Slack text object
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// NewSlack creates a new Slack notifier sending titles and messages from catalog, with the notifier's title
+// templates - a nil catalog uses the built-in one and a nil transport uses http.DefaultTransport
+func NewSlack(cfg config.ChatWebhook, catalog *Catalog, transport http.RoundTripper) *Slack {
+	return &Slack{
+		subscription: subscription{minSeverity: cfg.MinSeverity, events: cfg.Events},
+		url:          cfg.URL,
+		catalog:      catalog.WithTitles(cfg.Templates),
+		client:       &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// Name returns the notifier name
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+// Notify POSTs the event formatted as blocks
+func (s *Slack) Notify(event Event) error {
+	return postJSON(s.client, s.url, s.message(event))
+}
+
+// message formats the event as a header with its title, a section with its message, sections with its fields and a
+// context line with its severity, cluster, host and time
+func (s *Slack) message(event Event) slackMessage {
+	title, message := s.catalog.Title(event), s.catalog.Message(event)
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(title, slackMaxHeaderLength), Emoji: true}},
+	}
+	if message != title {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate(slackEscaper.Replace(message), slackMaxTextLength)}})
+	}
+
+	var fields []slackText
+	for _, key := range sortedFieldKeys(event.Fields) {
+		value := codeBlock(slackEscaper.Replace(event.Fields[key]), slackMaxFieldLength-len(key)-4)
+		fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", key, value)})
+	}
+	for chunk := range slices.Chunk(fields, slackMaxFieldsPerSection) {
+		blocks = append(blocks, slackBlock{Type: "section", Fields: chunk})
+	}
+
+	blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{{
+		Type: "mrkdwn",
+		Text: slackEscaper.Replace(fmt.Sprintf("%s · %s · %s · %s", event.Severity, event.Cluster, event.Host, event.Time.UTC().Format(time.RFC3339))),
+	}}})
+
+	return slackMessage{Text: title, Blocks: blocks}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// Webhook is a notifier that POSTs events as JSON to a URL, as is or as CloudEvents
+type Webhook struct {
+	subscription
+	url    string
+	format string
+	client *http.Client
+}
+
+// NewWebhook creates a new webhook notifier from its configuration - a nil transport uses http.DefaultTransport
+func NewWebhook(cfg config.Webhook, transport http.RoundTripper) *Webhook {
+	return &Webhook{
+		subscription: subscription{minSeverity: cfg.MinSeverity, events: cfg.Events},
+		url:          cfg.URL,
+		format:       cfg.Format,
+		client:       &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// Name returns the notifier name
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Notify POSTs the event as JSON, or as a structured mode CloudEvent with the cloudevents format
+func (w *Webhook) Notify(event Event) error {
+	if w.format == constants.WebhookFormatCloudEvents {
+		return postJSONAs(w.client, w.url, cloudEventsContentType, newCloudEvent(event))
+	}
+	return postJSON(w.client, w.url, event)
+}
+
+// postJSON POSTs payload as JSON to url, failing on a non-2xx response
+func postJSON(client *http.Client, url string, payload any) error {
+	return postJSONAs(client, url, "application/json", payload)
+}
+
+// postJSONAs POSTs payload as JSON with the given content type to url, failing on a non-2xx response
+func postJSONAs(client *http.Client, url, contentType string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
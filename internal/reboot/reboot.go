@@ -0,0 +1,126 @@
+package reboot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+var (
+	// kernelReleasePath is the file holding the running kernel release, a var for tests
+	kernelReleasePath = "/proc/sys/kernel/osrelease"
+	// modulesDir is the directory holding a directory of modules per installed kernel release, a var for tests
+	modulesDir = "/lib/modules"
+)
+
+// Status is whether the host requires a reboot and why
+type Status struct {
+	// Required is true when a reboot is required
+	Required bool `json:"required"`
+	// Reasons describe each signal that a reboot is required
+	Reasons []string `json:"reasons,omitempty"`
+	// Packages are the packages that requested the reboot, when the signal lists them
+	Packages []string `json:"packages,omitempty"`
+}
+
+// Options represents the options for creating a new reboot Checker
+type Options struct {
+	// RequiredFiles are files whose existence signals a reboot is required, e.g. /var/run/reboot-required
+	RequiredFiles []string
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+}
+
+// Checker checks whether the host requires a reboot
+type Checker struct {
+	requiredFiles []string
+	logger        *log.Logger
+}
+
+// New creates a new reboot Checker
+func New(opts Options) *Checker {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+
+	return &Checker{
+		requiredFiles: opts.RequiredFiles,
+		logger:        logging.WithPrefix(opts.Logger, "reboot"),
+	}
+}
+
+// Check returns whether the host requires a reboot - because a required file exists (with the packages listed in its
+// .pkgs companion, as written on Debian/Ubuntu), or an upgrade removed the running kernel's modules so they can no
+// longer be loaded
+func (c *Checker) Check() (Status, error) {
+	var status Status
+
+	for _, requiredFile := range c.requiredFiles {
+		_, err := os.Stat(requiredFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return status, fmt.Errorf("failed to check %s: %w", requiredFile, err)
+		}
+
+		status.Required = true
+		status.Reasons = append(status.Reasons, fmt.Sprintf("%s exists", requiredFile))
+		for _, pkg := range readPackages(requiredFile + ".pkgs") {
+			if !slices.Contains(status.Packages, pkg) {
+				status.Packages = append(status.Packages, pkg)
+			}
+		}
+	}
+
+	if release, missing := runningKernelModulesMissing(); missing {
+		status.Required = true
+		status.Reasons = append(status.Reasons, fmt.Sprintf("modules of running kernel %s are no longer installed", release))
+	}
+
+	c.logger.Debug("checked reboot required", "required", status.Required, "reasons", status.Reasons, "packages", status.Packages)
+	return status, nil
+}
+
+// runningKernelModulesMissing returns the running kernel release and true if its modules directory is missing while
+// other kernels' modules are installed
+func runningKernelModulesMissing() (string, bool) {
+	releaseBytes, err := os.ReadFile(kernelReleasePath)
+	if err != nil {
+		return "", false
+	}
+	release := strings.TrimSpace(string(releaseBytes))
+
+	entries, err := os.ReadDir(modulesDir)
+	if err != nil || len(entries) == 0 {
+		return "", false
+	}
+
+	if _, err := os.Stat(filepath.Join(modulesDir, release)); errors.Is(err, fs.ErrNotExist) {
+		return release, true
+	}
+	return "", false
+}
+
+// readPackages returns the non-empty lines of a package list file, nil when it can't be read
+func readPackages(path string) []string {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var packages []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			packages = append(packages, line)
+		}
+	}
+	return packages
+}
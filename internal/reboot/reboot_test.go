@@ -0,0 +1,85 @@
+package reboot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	previousKernelReleasePath, previousModulesDir := kernelReleasePath, modulesDir
+	defer func() { kernelReleasePath, modulesDir = previousKernelReleasePath, previousModulesDir }()
+
+	tests := []struct {
+		name         string
+		files        map[string]string
+		kernel       string
+		modules      []string
+		wantRequired bool
+		wantReasons  int
+		wantPackages []string
+	}{
+		{
+			name:    "nothing signalled",
+			kernel:  "6.8.0-45-generic",
+			modules: []string{"6.8.0-45-generic"},
+		},
+		{
+			name:         "required file with packages",
+			files:        map[string]string{"reboot-required": "*** System restart required ***\n", "reboot-required.pkgs": "doublezero\nlinux-base\ndoublezero\n"},
+			kernel:       "6.8.0-45-generic",
+			modules:      []string{"6.8.0-45-generic"},
+			wantRequired: true,
+			wantReasons:  1,
+			wantPackages: []string{"doublezero", "linux-base"},
+		},
+		{
+			name:         "running kernel modules removed",
+			kernel:       "6.8.0-45-generic",
+			modules:      []string{"6.8.0-47-generic"},
+			wantRequired: true,
+			wantReasons:  1,
+		},
+		{
+			name:   "no modules installed at all",
+			kernel: "6.8.0-45-generic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kernelReleasePath = filepath.Join(dir, "osrelease")
+			if err := os.WriteFile(kernelReleasePath, []byte(tt.kernel+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			modulesDir = filepath.Join(dir, "modules")
+			for _, release := range tt.modules {
+				if err := os.MkdirAll(filepath.Join(modulesDir, release), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			status, err := New(Options{RequiredFiles: []string{filepath.Join(dir, "reboot-required")}}).Check()
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if status.Required != tt.wantRequired {
+				t.Errorf("Check() required = %v, want %v", status.Required, tt.wantRequired)
+			}
+			if len(status.Reasons) != tt.wantReasons {
+				t.Errorf("Check() reasons = %v, want %d", status.Reasons, tt.wantReasons)
+			}
+			if !slices.Equal(status.Packages, tt.wantPackages) {
+				t.Errorf("Check() packages = %v, want %v", status.Packages, tt.wantPackages)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// gateDescriptions are the plain language descriptions of each gate
+var gateDescriptions = map[string]string{
+	GateSyncLock:               "Take the sync.lock file so only one process syncs at a time",
+	GateVersionSource:          "Fetch the recommended version",
+	GateClusterGenesis:         "Check the validator belongs to the configured cluster",
+	GateRecommendationRollback: "Check the recommendation didn't go backwards",
+	GateVersionConstraint:      "Check the target satisfies doublezero.version_constraint",
+	GateSkippedVersion:         "Check the target isn't in doublezero.skip_versions or skipped with skip-version",
+	GateSameVersion:            "Compare the installed and target versions",
+	GateDowngrade:              "Check a downgrade is allowed by sync.allow_downgrade",
+	GateSteppingStone:          "Pick the doublezero.stepping_stones release to upgrade through",
+	GateConfirmCycles:          "Confirm the target over consecutive cycles",
+	GateMinVersionAge:          "Check the target has baked for sync.min_version_age",
+	GateCohort:                 "Check this node's sync.cohort delay has passed",
+	GateFleetQuorum:            "Check enough fleet.peers report success on the target",
+	GateFreeze:                 "Check the sync isn't inside a change freeze period",
+	GateJitter:                 "Wait this node's sync.jitter delay",
+	GateHostMaintenance:        "Check whether the host is in maintenance mode under host_maintenance.policy",
+	GateMaintenanceWindow:      "Check the sync is inside a sync.windows maintenance window",
+	GatePackagePublished:       "Check the target package is published in the repository",
+	GateApproval:               "Check the sync plan is approved under sync.approval",
+	GateValidatorIdentity:      "Check the validator identity allows a sync",
+	GateDaemonPreCheck:         "Check the DoubleZero daemon is running",
+	GatePreChecks:              "Run the pre-sync health checks",
+	GateRefreshRepos:           "Refresh the package cache under sync.refresh_repos",
+	GateCommands:               "Run the sync commands",
+	GateDaemonPostCheck:        "Check the DoubleZero daemon came back",
+	GatePostChecks:             "Run the post-sync health checks",
+	GateVerifyInstalled:        "Check the target version is now installed",
+}
+
+// outcomeDescriptions are the plain language descriptions of each outcome
+var outcomeDescriptions = map[string]string{
+	OutcomeSynced:      "the sync commands ran",
+	OutcomeNothingToDo: "there was nothing to do, so no commands ran",
+	OutcomeBlocked:     "a gate blocked the sync, so no commands ran",
+	OutcomeFailed:      "the cycle failed",
+	OutcomeWouldSync:   "a real run would have run the sync commands",
+}
+
+// Explain writes a plain language walk through of the report
+func Explain(w io.Writer, r *Report) {
+	kind := "Sync cycle"
+	if r.Simulated {
+		kind = "Simulated sync cycle"
+	}
+	fmt.Fprintf(w, "%s on %s at %s (took %s)\n", kind, r.Cluster, r.StartedAt.Format(time.RFC3339), r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond))
+
+	if r.InstalledVersion != "" {
+		fmt.Fprintf(w, "Installed DoubleZero version: %s\n", r.InstalledVersion)
+	}
+
+	if rec := r.Recommendation; rec != nil {
+		for _, failed := range rec.FailedSources {
+			fmt.Fprintf(w, "Consulted %s: failed - %s\n", failed.Source, failed.Error)
+		}
+		cached := ""
+		if rec.FromCache {
+			cached = " (from cache)"
+		}
+		fmt.Fprintf(w, "Consulted %s: recommended %s%s, fetched %s from %s\n", rec.Source, rec.PackageVersion, cached, rec.FetchedAt.Format(time.RFC3339), rec.URL)
+	}
+
+	if r.Freeze != nil {
+		fmt.Fprintf(w, "Change freeze: %s until %s\n", r.Freeze.Name, r.Freeze.End.UTC().Format(time.RFC3339))
+	}
+
+	if r.RepoLag != nil {
+		fmt.Fprintf(w, "Repository lag: %s not in the %s repository since %s\n", r.RepoLag.PackageVersion, r.RepoLag.Repository, r.RepoLag.Since.UTC().Format(time.RFC3339))
+	}
+
+	if r.SteppingStone != "" {
+		fmt.Fprintf(w, "Stepping stone: targeting %s on the way to the recommendation\n", r.SteppingStone)
+	}
+
+	fmt.Fprintln(w, "\nGates:")
+	for i, gate := range r.Gates {
+		description, ok := gateDescriptions[gate.Name]
+		if !ok {
+			description = gate.Name
+		}
+		fmt.Fprintf(w, "  %d. %s: %s - %s\n", i+1, description, strings.ToUpper(gate.Verdict), gate.Detail)
+	}
+
+	if len(r.CommandPlanDiff) > 0 {
+		fmt.Fprintln(w, "\nCommand plan changed since it was last rendered for this target:")
+		for _, line := range r.CommandPlanDiff {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	if len(r.Commands) > 0 {
+		fmt.Fprintln(w, "\nCommands:")
+		for _, command := range r.Commands {
+			if command.Rendered != "" {
+				fmt.Fprintf(w, "  - %s: %s - %s\n", command.Name, command.Status, command.Rendered)
+				continue
+			}
+			fmt.Fprintf(w, "  - %s: %s\n", command.Name, command.Status)
+		}
+	}
+
+	if d := r.Diagnostics; d != nil && d.After != nil {
+		if len(d.Diff) == 0 {
+			fmt.Fprintln(w, "\nDiagnostics: no changes from before to after the sync commands")
+		} else {
+			fmt.Fprintln(w, "\nDiagnostics changed from before to after the sync commands:")
+			for _, line := range d.Diff {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+
+	if r.Reboot != nil && r.Reboot.Required {
+		fmt.Fprintf(w, "\nReboot required: %s\n", strings.Join(r.Reboot.Reasons, ", "))
+		if len(r.Reboot.Packages) > 0 {
+			fmt.Fprintf(w, "Requested by packages: %s\n", strings.Join(r.Reboot.Packages, ", "))
+		}
+	}
+
+	outcome, ok := outcomeDescriptions[r.Outcome]
+	if !ok {
+		outcome = r.Outcome
+	}
+	fmt.Fprintf(w, "\nOutcome: %s\n", outcome)
+	if r.Reason != "" {
+		fmt.Fprintf(w, "Reason: %s\n", r.Reason)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(w, "Error: %s\n", r.Error)
+	}
+}
@@ -0,0 +1,156 @@
+package report
+
+// Reason codes are stable machine-readable codes for why a cycle didn't sync, for dashboards and runbooks to key off
+// Each code keeps its exit code, new codes get new exit codes
+const (
+	// ReasonSyncLocked is recorded when another process holds the sync lock file
+	ReasonSyncLocked = "SYNC_LOCKED"
+	// ReasonSourceUnavailable is recorded when no version source returned a recommendation
+	ReasonSourceUnavailable = "SOURCE_UNAVAILABLE"
+	// ReasonClusterMismatch is recorded when the validator's genesis hash isn't the configured cluster's
+	ReasonClusterMismatch = "CLUSTER_MISMATCH"
+	// ReasonRecommendationRollback is recorded when the recommended version went backwards
+	ReasonRecommendationRollback = "RECOMMENDATION_ROLLBACK"
+	// ReasonConstraintUnsatisfied is recorded when the target doesn't satisfy doublezero.version_constraint
+	ReasonConstraintUnsatisfied = "CONSTRAINT_UNSATISFIED"
+	// ReasonVersionSkipped is recorded when the target is listed in doublezero.skip_versions or skipped by an operator
+	ReasonVersionSkipped = "VERSION_SKIPPED"
+	// ReasonInSync is recorded when the installed version is already the target
+	ReasonInSync = "IN_SYNC"
+	// ReasonDowngradeNotAllowed is recorded when the target is a downgrade and sync.allow_downgrade is false
+	ReasonDowngradeNotAllowed = "DOWNGRADE_NOT_ALLOWED"
+	// ReasonRecommendationUnconfirmed is recorded until the target is recommended on sync.confirm_cycles cycles
+	ReasonRecommendationUnconfirmed = "RECOMMENDATION_UNCONFIRMED"
+	// ReasonVersionTooNew is recorded until the target has been published for sync.min_version_age
+	ReasonVersionTooNew = "VERSION_TOO_NEW"
+	// ReasonCohortPending is recorded until this node's sync.cohort delay has passed
+	ReasonCohortPending = "COHORT_PENDING"
+	// ReasonFleetQuorumPending is recorded until fleet.quorum peers report success on the target
+	ReasonFleetQuorumPending = "FLEET_QUORUM_PENDING"
+	// ReasonChangeFreeze is recorded inside a change freeze period
+	ReasonChangeFreeze = "CHANGE_FREEZE"
+	// ReasonJitterPending is recorded until this node's sync.jitter delay has passed
+	ReasonJitterPending = "JITTER_PENDING"
+	// ReasonWindowClosed is recorded outside sync.windows
+	ReasonWindowClosed = "WINDOW_CLOSED"
+	// ReasonHostMaintenance is recorded while the host is in maintenance mode and host_maintenance.policy is block
+	ReasonHostMaintenance = "HOST_MAINTENANCE"
+	// ReasonPackageUnpublished is recorded when the target package can't be verified as published in the package
+	// repository
+	ReasonPackageUnpublished = "PACKAGE_UNPUBLISHED"
+	// ReasonRepoLag is recorded while the package repository doesn't publish the target package yet
+	ReasonRepoLag = "REPO_LAG"
+	// ReasonIdentityActive is recorded when the validator runs as its active identity and a sync isn't allowed
+	ReasonIdentityActive = "GATE_IDENTITY_ACTIVE"
+	// ReasonIdentityMismatch is recorded when the validator runs as an identity that isn't configured
+	ReasonIdentityMismatch = "IDENTITY_MISMATCH"
+	// ReasonActiveAckRequired is recorded when syncing while active needs an ack-active acknowledgement
+	ReasonActiveAckRequired = "ACTIVE_ACK_REQUIRED"
+	// ReasonValidatorUnreachable is recorded when the validator RPC is unreachable in monitor only mode
+	ReasonValidatorUnreachable = "VALIDATOR_UNREACHABLE"
+	// ReasonDaemonNotRunning is recorded when the DoubleZero daemon isn't running before the sync
+	ReasonDaemonNotRunning = "DAEMON_NOT_RUNNING"
+	// ReasonPreChecksFailed is recorded when a pre-sync health check fails
+	ReasonPreChecksFailed = "PRE_CHECKS_FAILED"
+	// ReasonCommandFailed is recorded when a sync command or the package cache refresh fails
+	ReasonCommandFailed = "COMMAND_FAILED"
+	// ReasonDaemonNotRestarted is recorded when the DoubleZero daemon isn't running after the sync
+	ReasonDaemonNotRestarted = "DAEMON_NOT_RESTARTED"
+	// ReasonPostChecksFailed is recorded when the post-sync health checks don't pass in time
+	ReasonPostChecksFailed = "POST_CHECKS_FAILED"
+	// ReasonInstalledVersionMismatch is recorded when the target version isn't installed after the sync commands
+	ReasonInstalledVersionMismatch = "INSTALLED_VERSION_MISMATCH"
+	// ReasonApprovalPending is recorded until the sync plan has the approvals sync.approval requires
+	ReasonApprovalPending = "APPROVAL_PENDING"
+)
+
+// RetryableReasons are the reason codes of transient failures sync.retry retries by default - a version source,
+// repository index or validator that couldn't be reached, or another process holding the sync lock
+var RetryableReasons = []string{ReasonSourceUnavailable, ReasonPackageUnpublished, ReasonValidatorUnreachable, ReasonSyncLocked}
+
+// gateReasons are the default reason codes of gates that end a cycle, a gate with several reasons records the others
+// with SetReason
+var gateReasons = map[string]string{
+	GateSyncLock:               ReasonSyncLocked,
+	GateVersionSource:          ReasonSourceUnavailable,
+	GateClusterGenesis:         ReasonClusterMismatch,
+	GateRecommendationRollback: ReasonRecommendationRollback,
+	GateVersionConstraint:      ReasonConstraintUnsatisfied,
+	GateSkippedVersion:         ReasonVersionSkipped,
+	GateSameVersion:            ReasonInSync,
+	GateDowngrade:              ReasonDowngradeNotAllowed,
+	GateSteppingStone:          ReasonConstraintUnsatisfied,
+	GateConfirmCycles:          ReasonRecommendationUnconfirmed,
+	GateMinVersionAge:          ReasonVersionTooNew,
+	GateCohort:                 ReasonCohortPending,
+	GateFleetQuorum:            ReasonFleetQuorumPending,
+	GateFreeze:                 ReasonChangeFreeze,
+	GateJitter:                 ReasonJitterPending,
+	GateHostMaintenance:        ReasonHostMaintenance,
+	GateMaintenanceWindow:      ReasonWindowClosed,
+	GatePackagePublished:       ReasonPackageUnpublished,
+	GateApproval:               ReasonApprovalPending,
+	GateValidatorIdentity:      ReasonIdentityActive,
+	GateDaemonPreCheck:         ReasonDaemonNotRunning,
+	GatePreChecks:              ReasonPreChecksFailed,
+	GateRefreshRepos:           ReasonCommandFailed,
+	GateCommands:               ReasonCommandFailed,
+	GateDaemonPostCheck:        ReasonDaemonNotRestarted,
+	GatePostChecks:             ReasonPostChecksFailed,
+	GateVerifyInstalled:        ReasonInstalledVersionMismatch,
+}
+
+// reasonExitCodes are the stable exit codes of each reason code, clear of the generic 1 and the check command's 0-3
+var reasonExitCodes = map[string]int{
+	ReasonInSync:                    0,
+	ReasonSourceUnavailable:         10,
+	ReasonClusterMismatch:           11,
+	ReasonRecommendationRollback:    12,
+	ReasonConstraintUnsatisfied:     13,
+	ReasonVersionSkipped:            14,
+	ReasonDowngradeNotAllowed:       15,
+	ReasonRecommendationUnconfirmed: 16,
+	ReasonVersionTooNew:             17,
+	ReasonCohortPending:             18,
+	ReasonJitterPending:             19,
+	ReasonWindowClosed:              20,
+	ReasonPackageUnpublished:        21,
+	ReasonIdentityActive:            22,
+	ReasonIdentityMismatch:          23,
+	ReasonActiveAckRequired:         24,
+	ReasonValidatorUnreachable:      25,
+	ReasonDaemonNotRunning:          26,
+	ReasonPreChecksFailed:           27,
+	ReasonCommandFailed:             28,
+	ReasonDaemonNotRestarted:        29,
+	ReasonPostChecksFailed:          30,
+	ReasonInstalledVersionMismatch:  31,
+	ReasonApprovalPending:           32,
+	ReasonHostMaintenance:           33,
+	ReasonFleetQuorumPending:        34,
+	ReasonSyncLocked:                35,
+	ReasonChangeFreeze:              36,
+	ReasonRepoLag:                   37,
+}
+
+// IsReason returns true if reason is a known reason code
+func IsReason(reason string) bool {
+	_, ok := reasonExitCodes[reason]
+	return ok
+}
+
+// ReasonExitCode returns the exit code of a reason code, 1 for an empty or unknown one
+func ReasonExitCode(reason string) int {
+	if code, ok := reasonExitCodes[reason]; ok {
+		return code
+	}
+	return 1
+}
+
+// SetReason overrides the reason code of the last gate recorded, and of the cycle when it ended there
+func (r *Report) SetReason(reason string) {
+	if len(r.Gates) == 0 {
+		return
+	}
+	r.Gates[len(r.Gates)-1].Reason = reason
+}
@@ -0,0 +1,251 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/diagnostics"
+	"github.com/sol-strategies/doublezero-version-sync/internal/freeze"
+	"github.com/sol-strategies/doublezero-version-sync/internal/reboot"
+)
+
+const (
+	// VerdictPass is the verdict of a gate that allowed the sync to continue
+	VerdictPass = "pass"
+	// VerdictBlock is the verdict of a gate that stopped the sync
+	VerdictBlock = "block"
+	// VerdictFail is the verdict of a gate that errored
+	VerdictFail = "fail"
+	// VerdictSkip is the verdict of a gate that was not evaluated or did not apply
+	VerdictSkip = "skip"
+	// VerdictDone is the verdict of a gate that ended the cycle because there was nothing to do
+	VerdictDone = "done"
+
+	// GateSyncLock takes the sync lock file so only one process syncs at a time
+	GateSyncLock = "sync_lock"
+	// GateVersionSource fetches the recommended version
+	GateVersionSource = "version_source"
+	// GateClusterGenesis checks the validator's genesis hash belongs to the configured cluster
+	GateClusterGenesis = "cluster_genesis"
+	// GateRecommendationRollback checks the recommendation didn't go backwards
+	GateRecommendationRollback = "recommendation_rollback"
+	// GateVersionConstraint checks the target satisfies doublezero.version_constraint
+	GateVersionConstraint = "version_constraint"
+	// GateSkippedVersion checks the target isn't listed in doublezero.skip_versions or skipped by an operator
+	GateSkippedVersion = "skipped_version"
+	// GateSameVersion compares the installed and target versions
+	GateSameVersion = "same_version"
+	// GateDowngrade checks a downgrade is allowed by sync.allow_downgrade
+	GateDowngrade = "downgrade"
+	// GateSteppingStone targets the next doublezero.stepping_stones release an upgrade must pass through
+	GateSteppingStone = "stepping_stone"
+	// GateConfirmCycles checks the target was recommended on enough consecutive cycles
+	GateConfirmCycles = "confirm_cycles"
+	// GateMinVersionAge checks the target has been published for at least sync.min_version_age
+	GateMinVersionAge = "min_version_age"
+	// GateCohort checks this node's sync.cohort delay has passed since the target was first observed
+	GateCohort = "cohort"
+	// GateFleetQuorum checks enough fleet.peers report success on the target version
+	GateFleetQuorum = "fleet_quorum"
+	// GateFreeze checks the sync isn't inside a change freeze period
+	GateFreeze = "freeze"
+	// GateJitter waits this node's sync.jitter delay after the target was first observed
+	GateJitter = "jitter"
+	// GateHostMaintenance checks whether the host is in maintenance mode under host_maintenance.policy
+	GateHostMaintenance = "host_maintenance"
+	// GateMaintenanceWindow checks the sync is inside one of sync.windows
+	GateMaintenanceWindow = "maintenance_window"
+	// GatePackagePublished checks the target package version is published in the package repository
+	GatePackagePublished = "package_published"
+	// GateApproval checks the sync plan has been approved by enough operators under sync.approval
+	GateApproval = "approval"
+	// GateValidatorIdentity checks the validator identity allows a sync
+	GateValidatorIdentity = "validator_identity"
+	// GateDaemonPreCheck checks the DoubleZero daemon is running before the sync
+	GateDaemonPreCheck = "daemon_pre_check"
+	// GatePreChecks runs the configured pre-sync health checks
+	GatePreChecks = "pre_checks"
+	// GateRefreshRepos refreshes the package cache under sync.refresh_repos, at most once per its min_interval
+	GateRefreshRepos = "refresh_repos"
+	// GateCommands runs the sync commands
+	GateCommands = "commands"
+	// GateDaemonPostCheck checks the DoubleZero daemon is running after the sync
+	GateDaemonPostCheck = "daemon_post_check"
+	// GatePostChecks retries the configured post-sync health checks until they pass
+	GatePostChecks = "post_checks"
+	// GateVerifyInstalled checks the installed version is the target after the sync commands ran
+	GateVerifyInstalled = "verify_installed"
+
+	// OutcomeSynced is the outcome of a cycle that ran the sync commands
+	OutcomeSynced = "synced"
+	// OutcomeNothingToDo is the outcome of a cycle that found nothing to sync
+	OutcomeNothingToDo = "nothing_to_do"
+	// OutcomeBlocked is the outcome of a cycle stopped by a gate
+	OutcomeBlocked = "blocked"
+	// OutcomeFailed is the outcome of a cycle that failed
+	OutcomeFailed = "failed"
+	// OutcomeWouldSync is the outcome of a simulated cycle that would have run the sync commands
+	OutcomeWouldSync = "would_sync"
+)
+
+// Report is a structured record of a sync cycle decision - what was consulted, each gate's verdict and the outcome
+type Report struct {
+	// StartedAt is when the cycle started
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is when the cycle finished
+	FinishedAt time.Time `json:"finished_at"`
+	// Simulated is true when the cycle was a simulation with no side effects
+	Simulated bool `json:"simulated,omitempty"`
+	// Cluster is the configured cluster
+	Cluster string `json:"cluster"`
+	// InstalledVersion is the installed DoubleZero version
+	InstalledVersion string `json:"installed_version,omitempty"`
+	// Recommendation is the recommendation the decision was based on
+	Recommendation *Recommendation `json:"recommendation,omitempty"`
+	// Freeze is the change freeze period that deferred the sync, nil outside one
+	Freeze *freeze.Period `json:"freeze,omitempty"`
+	// RepoLag is the target package the package repository doesn't publish yet, nil when it does or isn't checked
+	RepoLag *RepoLag `json:"repo_lag,omitempty"`
+	// SteppingStone is the doublezero.stepping_stones release the cycle targeted on the way to the recommendation,
+	// empty when it targeted the recommendation
+	SteppingStone string `json:"stepping_stone,omitempty"`
+	// Gates are the verdicts of each gate evaluated, in order
+	Gates []Gate `json:"gates"`
+	// CommandPlanDiff is the diff of the rendered command plan against the one last rendered for the same target,
+	// empty when unchanged or rendered for the first time
+	CommandPlanDiff []string `json:"command_plan_diff,omitempty"`
+	// Commands are the results of each sync command that was reached
+	Commands []CommandResult `json:"commands,omitempty"`
+	// Diagnostics are the diagnostic command snapshots captured around the sync commands, when configured
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+	// Reboot is whether the sync commands left the host requiring a reboot, when reboot.policy enables the check
+	Reboot *reboot.Status `json:"reboot,omitempty"`
+	// Outcome is the overall outcome of the cycle
+	Outcome string `json:"outcome"`
+	// Error is the error that ended the cycle, if any
+	Error string `json:"error,omitempty"`
+	// Reason is the reason code of the gate that ended the cycle without syncing, e.g. WINDOW_CLOSED
+	Reason string `json:"reason,omitempty"`
+}
+
+// Recommendation is the recommendation a decision was based on
+type Recommendation struct {
+	PackageVersion string    `json:"package_version"`
+	Source         string    `json:"source"`
+	URL            string    `json:"url,omitempty"`
+	FetchedAt      time.Time `json:"fetched_at"`
+	FromCache      bool      `json:"from_cache,omitempty"`
+	// FailedSources are the sources consulted before Source that failed, with their errors
+	FailedSources []FailedSource `json:"failed_sources,omitempty"`
+}
+
+// FailedSource is a version source that was consulted and failed
+type FailedSource struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// RepoLag is a target package version the package repository doesn't publish yet
+type RepoLag struct {
+	// PackageVersion is the package version missing from the repository
+	PackageVersion string `json:"package_version"`
+	// Repository is the repository index checked, e.g. deb ubuntu/noble/amd64
+	Repository string `json:"repository"`
+	// Since is when the package was first found missing
+	Since time.Time `json:"since"`
+}
+
+// Gate is the verdict of a single decision gate
+type Gate struct {
+	Name    string `json:"name"`
+	Verdict string `json:"verdict"`
+	Detail  string `json:"detail"`
+	// Reason is the reason code of a gate that ended the cycle
+	Reason string `json:"reason,omitempty"`
+}
+
+// Diagnostics are the diagnostic command snapshots captured before and after the sync commands
+type Diagnostics struct {
+	Before *diagnostics.Snapshot `json:"before"`
+	After  *diagnostics.Snapshot `json:"after,omitempty"`
+	// Diff is the line diff of each command's output that changed from before to after
+	Diff []string `json:"diff,omitempty"`
+}
+
+// CommandResult is the result of a single sync command
+type CommandResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Rendered is the command line rendered with the cycle's template data, set for simulated cycles
+	Rendered string `json:"rendered,omitempty"`
+	// ExitCode is the exit code of a command that ran, -1 when it couldn't be started or was killed by a signal
+	ExitCode *int `json:"exit_code,omitempty"`
+	// DurationSeconds is how long a command that ran took
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+}
+
+// New creates a new report for a cycle started at the given time
+func New(cluster string, simulated bool, startedAt time.Time) *Report {
+	return &Report{
+		StartedAt: startedAt.UTC(),
+		Simulated: simulated,
+		Cluster:   cluster,
+		Gates:     []Gate{},
+	}
+}
+
+// TargetVersion returns the package version the cycle targeted - its stepping stone, otherwise the recommendation -
+// empty without a recommendation
+func (r *Report) TargetVersion() string {
+	switch {
+	case r.SteppingStone != "":
+		return r.SteppingStone
+	case r.Recommendation != nil:
+		return r.Recommendation.PackageVersion
+	}
+	return ""
+}
+
+// AddGate records a gate verdict with a formatted detail
+func (r *Report) AddGate(name, verdict, format string, args ...any) {
+	gate := Gate{Name: name, Verdict: verdict, Detail: fmt.Sprintf(format, args...)}
+	if verdict == VerdictBlock || verdict == VerdictDone || verdict == VerdictFail {
+		gate.Reason = gateReasons[name]
+	}
+	r.Gates = append(r.Gates, gate)
+}
+
+// AddCommand records a sync command result
+func (r *Report) AddCommand(name, status string) {
+	r.Commands = append(r.Commands, CommandResult{Name: name, Status: status})
+}
+
+// AddExecutedCommand records the result of a sync command that ran, with its exit code and how long it took
+func (r *Report) AddExecutedCommand(name, status string, exitCode int, duration time.Duration) {
+	r.Commands = append(r.Commands, CommandResult{Name: name, Status: status, ExitCode: &exitCode, DurationSeconds: duration.Seconds()})
+}
+
+// AddRenderedCommand records a sync command result with its rendered command line
+func (r *Report) AddRenderedCommand(name, status, rendered string) {
+	r.Commands = append(r.Commands, CommandResult{Name: name, Status: status, Rendered: rendered})
+}
+
+// Finish records the outcome of the cycle finished at the given time - a cycle ending in an error is blocked when
+// its last gate blocked it, otherwise it failed
+func (r *Report) Finish(finishedAt time.Time, outcome string, err error) {
+	r.FinishedAt = finishedAt.UTC()
+	r.Outcome = outcome
+	if len(r.Gates) > 0 {
+		r.Reason = r.Gates[len(r.Gates)-1].Reason
+	}
+
+	if err == nil {
+		return
+	}
+
+	r.Error = err.Error()
+	r.Outcome = OutcomeFailed
+	if len(r.Gates) > 0 && r.Gates[len(r.Gates)-1].Verdict == VerdictBlock {
+		r.Outcome = OutcomeBlocked
+	}
+}
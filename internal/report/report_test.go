@@ -0,0 +1,107 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFinish_Outcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		verdict string
+		outcome string
+		err     error
+		want    string
+	}{
+		{name: "success keeps outcome", verdict: VerdictPass, outcome: OutcomeSynced, want: OutcomeSynced},
+		{name: "blocking gate", verdict: VerdictBlock, err: errors.New("active"), want: OutcomeBlocked},
+		{name: "failing gate", verdict: VerdictFail, err: errors.New("down"), want: OutcomeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New("testnet", false, time.Now())
+			r.AddGate(GateVersionSource, tt.verdict, "detail")
+			r.Finish(time.Now(), tt.outcome, tt.err)
+			if r.Outcome != tt.want {
+				t.Errorf("Outcome = %s, want %s", r.Outcome, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinish_Reason(t *testing.T) {
+	tests := []struct {
+		name    string
+		gate    string
+		verdict string
+		reason  string
+		want    string
+	}{
+		{name: "passing gate has no reason", gate: GateVerifyInstalled, verdict: VerdictPass},
+		{name: "waiting gate", gate: GateMaintenanceWindow, verdict: VerdictDone, want: ReasonWindowClosed},
+		{name: "blocking gate", gate: GateVersionConstraint, verdict: VerdictBlock, want: ReasonConstraintUnsatisfied},
+		{name: "overridden reason", gate: GateValidatorIdentity, verdict: VerdictBlock, reason: ReasonIdentityMismatch, want: ReasonIdentityMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New("testnet", false, time.Now())
+			r.AddGate(tt.gate, tt.verdict, "detail")
+			if tt.reason != "" {
+				r.SetReason(tt.reason)
+			}
+			r.Finish(time.Now(), OutcomeNothingToDo, nil)
+			if r.Reason != tt.want {
+				t.Errorf("Reason = %q, want %q", r.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestReasonExitCodesAreUnique(t *testing.T) {
+	seen := map[int]string{}
+	for reason, code := range reasonExitCodes {
+		if other, ok := seen[code]; ok {
+			t.Errorf("reasons %s and %s share exit code %d", reason, other, code)
+		}
+		seen[code] = reason
+	}
+	for gate, reason := range gateReasons {
+		if _, ok := reasonExitCodes[reason]; !ok {
+			t.Errorf("gate %s reason %s has no exit code", gate, reason)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	startedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := New("testnet", true, startedAt)
+	r.InstalledVersion = "0.6.9"
+	r.Recommendation = &Recommendation{
+		PackageVersion: "0.7.1-1",
+		Source:         "cloudsmith_index",
+		FailedSources:  []FailedSource{{Source: "cloudsmith_api", Error: "timeout"}},
+	}
+	r.AddGate(GateValidatorIdentity, VerdictBlock, "sync not allowed when validator is active")
+	r.Finish(startedAt.Add(1500*time.Millisecond), "", errors.New("sync not allowed when validator is active"))
+
+	var buf bytes.Buffer
+	Explain(&buf, r)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Simulated sync cycle on testnet at 2025-01-01T12:00:00Z (took 1.5s)",
+		"Consulted cloudsmith_api: failed - timeout",
+		"Consulted cloudsmith_index: recommended 0.7.1-1",
+		"1. Check the validator identity allows a sync: BLOCK - sync not allowed when validator is active",
+		"Outcome: a gate blocked the sync, so no commands ran",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Explain() output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -3,12 +3,16 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
 )
 
 // JSONRPCRequest represents a JSON-RPC request
@@ -33,26 +37,131 @@ type RPCError struct {
 	Message string `json:"message"`
 }
 
+// Options represents the options for creating a new RPC client
+type Options struct {
+	// URL is the validator RPC endpoint URL
+	URL string
+	// MaxRequestsPerSecond caps the rate of requests sent to the validator - 0 means unlimited
+	MaxRequestsPerSecond float64
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock is used for rate limiting, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport used for requests, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// Timeout is the maximum time a request may take, defaults to 30s
+	Timeout time.Duration
+}
+
 // Client represents an RPC client for communicating with the validator
 type Client struct {
 	url    string
 	client *http.Client
 	logger *log.Logger
+	clock  clock.Clock
+
+	// minRequestInterval is the minimum spacing between requests derived from MaxRequestsPerSecond
+	minRequestInterval time.Duration
+	lastRequestAt      time.Time
+	rateMu             sync.Mutex
+
+	// cache holds successful responses for the current cycle, keyed by method and params
+	cache   map[string]*JSONRPCResponse
+	cacheMu sync.Mutex
 }
 
 // NewClient creates a new RPC client
-func NewClient(url string) *Client {
-	return &Client{
-		url: url,
+func NewClient(opts Options) *Client {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	c := &Client{
+		url: opts.URL,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   opts.Timeout,
+			Transport: opts.Transport,
 		},
-		logger: log.WithPrefix("rpc"),
+		logger: logging.WithPrefix(opts.Logger, "rpc"),
+		clock:  opts.Clock,
+		cache:  make(map[string]*JSONRPCResponse),
+	}
+
+	if opts.MaxRequestsPerSecond > 0 {
+		c.minRequestInterval = time.Duration(float64(time.Second) / opts.MaxRequestsPerSecond)
 	}
+
+	return c
+}
+
+// ResetCache clears cached responses - call at the start of each sync cycle so results are reused within a cycle only
+func (c *Client) ResetCache() {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.cache = make(map[string]*JSONRPCResponse)
+}
+
+// cachedRPCCall returns the cached response for method and params if present, otherwise makes the call and caches a successful response
+func (c *Client) cachedRPCCall(ctx context.Context, method string, params []interface{}) (*JSONRPCResponse, error) {
+	keyBytes, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	key := method + string(keyBytes)
+
+	c.cacheMu.Lock()
+	cached, ok := c.cache[key]
+	c.cacheMu.Unlock()
+	if ok {
+		c.logger.Debug("using cached response", "method", method)
+		return cached, nil
+	}
+
+	resp, err := c.makeRPCCall(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cacheMu.Lock()
+	c.cache[key] = resp
+	c.cacheMu.Unlock()
+
+	return resp, nil
+}
+
+// waitForRateLimit blocks until a request may be sent without exceeding the configured max request rate
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	if c.minRequestInterval == 0 {
+		return nil
+	}
+
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+
+	wait := c.lastRequestAt.Add(c.minRequestInterval).Sub(c.clock.Now())
+	if wait > 0 {
+		c.logger.Debug("rate limiting request", "wait", wait.String())
+		if err := clock.SleepContext(ctx, c.clock, wait); err != nil {
+			return err
+		}
+	}
+
+	c.lastRequestAt = c.clock.Now()
+	return nil
 }
 
 // makeRPCCall makes a JSON-RPC call to the validator
 func (c *Client) makeRPCCall(ctx context.Context, method string, params []interface{}) (*JSONRPCResponse, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit wait interrupted: %w", err)
+	}
+
 	req := JSONRPCRequest{
 		JSONRPC: "2.0",
 		ID:      1,
@@ -96,7 +205,7 @@ func (c *Client) makeRPCCall(ctx context.Context, method string, params []interf
 
 // getIdentity gets the validator's identity public key
 func (c *Client) getIdentity(ctx context.Context) (string, error) {
-	resp, err := c.makeRPCCall(ctx, "getIdentity", []interface{}{})
+	resp, err := c.cachedRPCCall(ctx, "getIdentity", []interface{}{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get identity: %w", err)
 	}
@@ -118,9 +227,87 @@ func (c *Client) getIdentity(ctx context.Context) (string, error) {
 }
 
 // GetIdentity gets the validator's identity public key (public method)
-func (c *Client) GetIdentity() (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (c *Client) GetIdentity(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 	return c.getIdentity(ctx)
 }
 
+// GetGenesisHash gets the genesis hash of the cluster the validator belongs to
+func (c *Client) GetGenesisHash(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := c.cachedRPCCall(ctx, "getGenesisHash", []interface{}{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get genesis hash: %w", err)
+	}
+
+	genesisHash, ok := resp.Result.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid genesis hash format")
+	}
+
+	return genesisHash, nil
+}
+
+
+// GetHealth returns nil if the validator reports itself healthy, or an error describing why it is not
+// Health is never served from the per-cycle cache
+func (c *Client) GetHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := c.makeRPCCall(ctx, "getHealth", []interface{}{})
+	if err != nil {
+		return fmt.Errorf("failed to get health: %w", err)
+	}
+
+	if result, ok := resp.Result.(string); !ok || result != "ok" {
+		return fmt.Errorf("validator reported unhealthy: %v", resp.Result)
+	}
+
+	return nil
+}
+
+// GetAccountData gets the raw data of the account at address
+// Account data is never served from the per-cycle cache
+func (c *Client) GetAccountData(ctx context.Context, address string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.client.Timeout)
+	defer cancel()
+
+	resp, err := c.makeRPCCall(ctx, "getAccountInfo", []interface{}{address, map[string]string{"encoding": "base64"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account info: %w", err)
+	}
+
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid response format")
+	}
+	if result["value"] == nil {
+		return nil, fmt.Errorf("account %s not found", address)
+	}
+
+	value, ok := result["value"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid account info format")
+	}
+
+	// data is [<base64 data>, "base64"]
+	data, ok := value["data"].([]interface{})
+	if !ok || len(data) != 2 || data[1] != "base64" {
+		return nil, fmt.Errorf("invalid account data format")
+	}
+	encoded, ok := data[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid account data format")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode account data: %w", err)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+// newIdentityServer returns a test server answering getIdentity and counting requests.
+func newIdentityServer(identity string, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      1,
+			Result:  map[string]interface{}{"identity": identity},
+		})
+	}))
+}
+
+func TestGetIdentity_CachedWithinCycle(t *testing.T) {
+	var calls atomic.Int32
+	srv := newIdentityServer("abc", &calls)
+	defer srv.Close()
+
+	c := NewClient(Options{URL: srv.URL})
+	for i := 0; i < 3; i++ {
+		identity, err := c.GetIdentity(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if identity != "abc" {
+			t.Errorf("got %s, want abc", identity)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 RPC call within a cycle, got %d", calls.Load())
+	}
+
+	c.ResetCache()
+	if _, err := c.GetIdentity(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 RPC calls after cache reset, got %d", calls.Load())
+	}
+}
+
+func TestGetGenesisHash(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp := JSONRPCResponse{JSONRPC: "2.0", ID: 1}
+		if req.Method == "getGenesisHash" {
+			resp.Result = "4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY"
+		} else {
+			resp.Error = &RPCError{Code: -32601, Message: "Method not found"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	genesisHash, err := NewClient(Options{URL: srv.URL}).GetGenesisHash(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if genesisHash != "4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY" {
+		t.Errorf("got %s, want the testnet genesis hash", genesisHash)
+	}
+}
+
+func TestGetIdentity_RateLimited(t *testing.T) {
+	var calls atomic.Int32
+	srv := newIdentityServer("abc", &calls)
+	defer srv.Close()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	c := NewClient(Options{URL: srv.URL, MaxRequestsPerSecond: 20, Clock: fakeClock})
+	for i := 0; i < 3; i++ {
+		c.ResetCache()
+		if _, err := c.GetIdentity(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// 3 requests at 20/s need 2 gaps of 50ms
+	if elapsed := fakeClock.Now().Sub(start); elapsed != 100*time.Millisecond {
+		t.Errorf("expected requests to be spaced by the rate limit, took %s", elapsed)
+	}
+}
+
+func TestGetHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    JSONRPCResponse
+		wantErr bool
+	}{
+		{name: "healthy", resp: JSONRPCResponse{JSONRPC: "2.0", ID: 1, Result: "ok"}},
+		{name: "behind", resp: JSONRPCResponse{JSONRPC: "2.0", ID: 1, Error: &RPCError{Code: -32005, Message: "Node is behind by 42 slots"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(tt.resp)
+			}))
+			defer srv.Close()
+
+			err := NewClient(Options{URL: srv.URL}).GetHealth(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetHealth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetAccountData(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp := JSONRPCResponse{JSONRPC: "2.0", ID: 1}
+		if req.Method == "getAccountInfo" && req.Params[0] == "known" {
+			resp.Result = map[string]interface{}{"value": map[string]interface{}{"data": []interface{}{"MC43LjEtMQ==", "base64"}}}
+		} else {
+			resp.Result = map[string]interface{}{"value": nil}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c := NewClient(Options{URL: srv.URL})
+	data, err := c.GetAccountData(context.Background(), "known")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "0.7.1-1" {
+		t.Errorf("got %q, want 0.7.1-1", data)
+	}
+
+	if _, err := c.GetAccountData(context.Background(), "missing"); err == nil {
+		t.Error("expected error for a missing account, got nil")
+	}
+}
@@ -0,0 +1,309 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/report"
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+// State is the persistent state of the syncer, kept across runs and restarts
+type State struct {
+	// LastRecommendation is the last accepted recommendation from the version source
+	LastRecommendation *Recommendation `json:"last_recommendation,omitempty"`
+	// RollbackNotifiedFor is the package version of the last recommendation rollback that was notified, to avoid repeats
+	RollbackNotifiedFor string `json:"rollback_notified_for,omitempty"`
+	// FreezeNotifiedFor is the freeze period and target package version of the last freeze drift that was notified, to
+	// avoid repeats
+	FreezeNotifiedFor string `json:"freeze_notified_for,omitempty"`
+	// RepoLag is the target package the sync.verify_published repository didn't publish on the last check, nil once
+	// it does - its first sighting is notified
+	RepoLag *report.RepoLag `json:"repo_lag,omitempty"`
+	// ConsecutiveRecommendations counts how many cycles in a row returned the last recommendation
+	ConsecutiveRecommendations int `json:"consecutive_recommendations,omitempty"`
+	// CommandPlans are the last rendered sync command plans, keyed by target package version
+	CommandPlans map[string]sync_commands.Plan `json:"command_plans,omitempty"`
+	// LastReport is the decision report of the last sync cycle
+	LastReport *report.Report `json:"last_report,omitempty"`
+	// ActiveAck is the last operator acknowledgement allowing syncs while the validator is active
+	ActiveAck *ActiveAck `json:"active_ack,omitempty"`
+	// SkippedVersions are the versions an operator asked not to sync to, kept until a newer version is recommended
+	SkippedVersions []SkippedVersion `json:"skipped_versions,omitempty"`
+	// LastSync is the last sync cycle that ran the sync commands
+	LastSync *SyncAttempt `json:"last_sync,omitempty"`
+	// History are the most recent sync cycles that ran the sync commands, oldest first
+	History []SyncAttempt `json:"history,omitempty"`
+	// LastRepoRefresh is when the package cache was last refreshed successfully under sync.refresh_repos
+	LastRepoRefresh *time.Time `json:"last_repo_refresh,omitempty"`
+	// PendingApproval is the sync plan awaiting operator approvals under sync.approval, until it syncs or changes
+	PendingApproval *PendingApproval `json:"pending_approval,omitempty"`
+	// PendingReboot is the reboot command reboot.policy command deferred to a later cycle inside sync.windows, until
+	// it runs or the host no longer requires a reboot
+	PendingReboot *PendingReboot `json:"pending_reboot,omitempty"`
+}
+
+// SyncAttempt is a sync cycle that ran the sync commands
+type SyncAttempt struct {
+	// StartedAt is when the cycle started
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is when the cycle finished
+	FinishedAt time.Time `json:"finished_at"`
+	// FromVersion is the version installed before the sync
+	FromVersion string `json:"from_version"`
+	// ToVersion is the target package version
+	ToVersion string `json:"to_version"`
+	// Direction is upgrade or downgrade, empty for attempts recorded before it was
+	Direction string `json:"direction,omitempty"`
+	// Result is the cycle outcome - synced or failed
+	Result string `json:"result"`
+	// Error is why the sync failed, if it did
+	Error string `json:"error,omitempty"`
+	// Commands are the sync commands the cycle ran, with their status
+	Commands []report.CommandResult `json:"commands,omitempty"`
+}
+
+// Duration returns how long the sync attempt took
+func (a SyncAttempt) Duration() time.Duration {
+	return a.FinishedAt.Sub(a.StartedAt)
+}
+
+// RecordSync records the sync attempt as the last sync and appends it to the history, keeping at most historySize of
+// the most recent attempts
+func (st *State) RecordSync(attempt SyncAttempt, historySize int) {
+	st.LastSync = &attempt
+	st.History = append(st.History, attempt)
+	if len(st.History) > historySize {
+		st.History = slices.Clone(st.History[len(st.History)-historySize:])
+	}
+}
+
+// PruneCommandPlans removes the rendered command plans of every target but those kept, returning the removed targets
+// in order. Plans of targets no longer recommended are never compared against again
+func (st *State) PruneCommandPlans(keep ...string) []string {
+	var removed []string
+	for target := range st.CommandPlans {
+		if !slices.Contains(keep, target) {
+			removed = append(removed, target)
+			delete(st.CommandPlans, target)
+		}
+	}
+	slices.Sort(removed)
+	return removed
+}
+
+// PendingReboot is a reboot the sync commands left the host requiring, whose reboot command was deferred because the
+// sync finished outside sync.windows or while shutting down
+type PendingReboot struct {
+	// RequiredAt is when the sync that required the reboot finished
+	RequiredAt time.Time `json:"required_at"`
+	// FromVersion is the version installed before the sync
+	FromVersion string `json:"from_version"`
+	// ToVersion is the package version the sync installed
+	ToVersion string `json:"to_version"`
+	// Reasons describe each signal that a reboot was required
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// ActiveAck is an operator acknowledgement allowing syncs while the validator is active
+type ActiveAck struct {
+	// AckedAt is when the acknowledgement was recorded
+	AckedAt time.Time `json:"acked_at"`
+	// By is the user who recorded the acknowledgement
+	By string `json:"by,omitempty"`
+	// Reason is why syncing while active was acknowledged
+	Reason string `json:"reason,omitempty"`
+}
+
+// SkippedVersion is a version an operator asked not to sync to
+type SkippedVersion struct {
+	// Version is the skipped version - without a release (e.g. "0.8.1") every release of it is skipped
+	Version string `json:"version"`
+	// Reason is why the version is skipped
+	Reason string `json:"reason,omitempty"`
+	// SkippedAt is when the skip was recorded
+	SkippedAt time.Time `json:"skipped_at"`
+	// Notified is set once the skip has prevented a sync and been notified
+	Notified bool `json:"notified,omitempty"`
+}
+
+// Skip records a skip of the given version, replacing any existing skip of it
+func (st *State) Skip(skip SkippedVersion) {
+	st.Unskip(skip.Version)
+	st.SkippedVersions = append(st.SkippedVersions, skip)
+}
+
+// Unskip removes the skip of the given version, returning false if it wasn't skipped
+func (st *State) Unskip(version string) bool {
+	for i, skip := range st.SkippedVersions {
+		if skip.Version == version {
+			st.SkippedVersions = append(st.SkippedVersions[:i], st.SkippedVersions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PendingApproval is a sync plan held until enough operators approve it
+type PendingApproval struct {
+	// PlanID identifies the plan to operators, the first characters of PlanDigest
+	PlanID string `json:"plan_id"`
+	// PlanDigest is the hash of the cluster, versions and rendered commands approvals are signed over - any change is a
+	// new plan
+	PlanDigest string `json:"plan_digest"`
+	// FromVersion is the version installed when the plan was rendered
+	FromVersion string `json:"from_version"`
+	// ToVersion is the target package version
+	ToVersion string `json:"to_version"`
+	// Commands are the rendered command plan lines being approved
+	Commands []string `json:"commands"`
+	// RequestedAt is when the plan was first held for approval
+	RequestedAt time.Time `json:"requested_at"`
+	// Notified is set once the plan has been notified as awaiting approval
+	Notified bool `json:"notified,omitempty"`
+	// Approvals are the approvals recorded for the plan, oldest first
+	Approvals []Approval `json:"approvals,omitempty"`
+}
+
+// Approval is an operator approval of a sync plan
+type Approval struct {
+	// By is the name of the sync.approval.approvers entry who approved the plan
+	By string `json:"by"`
+	// ApprovedAt is when the approval was recorded
+	ApprovedAt time.Time `json:"approved_at"`
+	// Reason is the change reference or note recorded with the approval
+	Reason string `json:"reason,omitempty"`
+	// Signature is the approver's base58 signature of the plan, only approvals it verifies against the approver's
+	// key count
+	Signature string `json:"signature,omitempty"`
+}
+
+// Approve records an approval of the plan, returning an error if the same user already approved it
+func (p *PendingApproval) Approve(approval Approval) error {
+	if slices.ContainsFunc(p.Approvals, func(a Approval) bool { return a.By == approval.By }) {
+		return fmt.Errorf("plan %s is already approved by %s - a second approval must come from another user", p.PlanID, approval.By)
+	}
+	p.Approvals = append(p.Approvals, approval)
+	return nil
+}
+
+// ApprovedBy returns the distinct users whose approvals count, those counts accepts
+func (p *PendingApproval) ApprovedBy(counts func(approval Approval) bool) []string {
+	var approvers []string
+	for _, approval := range p.Approvals {
+		if counts(approval) && !slices.Contains(approvers, approval.By) {
+			approvers = append(approvers, approval.By)
+		}
+	}
+	return approvers
+}
+
+// Recommendation is a recorded recommendation from the version source
+type Recommendation struct {
+	// PackageVersion is the recommended package version (e.g. "0.7.1-1")
+	PackageVersion string `json:"package_version"`
+	// Source is the name of the version source that supplied it
+	Source string `json:"source"`
+	// ObservedAt is when the recommendation was observed
+	ObservedAt time.Time `json:"observed_at"`
+	// FirstObservedAt is when the package version was first observed in the current run of consecutive recommendations
+	FirstObservedAt time.Time `json:"first_observed_at,omitempty"`
+}
+
+// Store persists State to a JSON file
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a new Store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the state file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the state from disk - a missing state file is an empty state
+func (s *Store) Load() (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Update loads the state, applies fn and writes the result back atomically
+// Nothing is written if fn returns an error
+func (s *Store) Update(fn func(*State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&st); err != nil {
+		return err
+	}
+
+	return s.save(st)
+}
+
+// load reads the state file, the caller must hold the lock
+func (s *Store) load() (State, error) {
+	var st State
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("failed to read state file %s: %w", s.path, err)
+	}
+
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("failed to parse state file %s: %w", s.path, err)
+	}
+
+	return st, nil
+}
+
+// save writes the state file via a temporary file and rename so it is never left half written, the caller must hold the lock
+func (s *Store) save(st State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temporary state file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary state file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/sync_commands"
+)
+
+func TestStoreUpdateAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state.json"))
+
+	st, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() on missing file error = %v", err)
+	}
+	if st.LastRecommendation != nil {
+		t.Fatalf("Load() on missing file = %+v, want empty state", st)
+	}
+
+	err = store.Update(func(st *State) error {
+		st.LastRecommendation = &Recommendation{PackageVersion: "0.7.1-1", Source: "cloudsmith_api"}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// a failing update must not be written
+	err = store.Update(func(st *State) error {
+		st.LastRecommendation.PackageVersion = "0.7.0-1"
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("Update() error = nil, want error")
+	}
+
+	st, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if st.LastRecommendation == nil || st.LastRecommendation.PackageVersion != "0.7.1-1" {
+		t.Errorf("Load() LastRecommendation = %+v, want package version 0.7.1-1", st.LastRecommendation)
+	}
+}
+
+func TestSkipAndUnskip(t *testing.T) {
+	var st State
+	st.Skip(SkippedVersion{Version: "0.8.1", Reason: "known regression"})
+	st.Skip(SkippedVersion{Version: "0.8.2"})
+	st.Skip(SkippedVersion{Version: "0.8.1", Reason: "still broken"})
+
+	if len(st.SkippedVersions) != 2 {
+		t.Fatalf("SkippedVersions = %+v, want 2 entries", st.SkippedVersions)
+	}
+	if skip := st.SkippedVersions[1]; skip.Version != "0.8.1" || skip.Reason != "still broken" {
+		t.Errorf("re-skipping did not replace the skip: %+v", skip)
+	}
+
+	if !st.Unskip("0.8.2") || st.Unskip("0.8.2") {
+		t.Error("Unskip() should remove a skip exactly once")
+	}
+	if len(st.SkippedVersions) != 1 {
+		t.Errorf("SkippedVersions = %+v, want 1 entry", st.SkippedVersions)
+	}
+}
+
+func TestRecordSync(t *testing.T) {
+	var st State
+	for _, to := range []string{"0.7.0-1", "0.7.1-1", "0.7.2-1"} {
+		st.RecordSync(SyncAttempt{ToVersion: to, Result: "synced"}, 2)
+	}
+
+	if st.LastSync == nil || st.LastSync.ToVersion != "0.7.2-1" {
+		t.Errorf("LastSync = %+v, want the 0.7.2-1 attempt", st.LastSync)
+	}
+	if len(st.History) != 2 || st.History[0].ToVersion != "0.7.1-1" || st.History[1].ToVersion != "0.7.2-1" {
+		t.Errorf("History = %+v, want the 2 most recent attempts oldest first", st.History)
+	}
+
+	st.RecordSync(SyncAttempt{ToVersion: "0.7.3-1", Result: "failed"}, 0)
+	if len(st.History) != 0 || st.LastSync.ToVersion != "0.7.3-1" {
+		t.Errorf("with history disabled got LastSync = %+v, History = %+v, want only the last sync", st.LastSync, st.History)
+	}
+}
+
+func TestPendingApprovalApprove(t *testing.T) {
+	approval := PendingApproval{PlanID: "3f2a9c1d04be"}
+	if err := approval.Approve(Approval{By: "alice"}); err != nil {
+		t.Fatalf("first approval: unexpected error: %v", err)
+	}
+	if err := approval.Approve(Approval{By: "alice"}); err == nil {
+		t.Error("expected an error for a second approval by the same user")
+	}
+	if err := approval.Approve(Approval{By: "mallory"}); err != nil {
+		t.Fatalf("second approval: unexpected error: %v", err)
+	}
+
+	all := func(Approval) bool { return true }
+	if got := approval.ApprovedBy(all); len(got) != 2 {
+		t.Errorf("ApprovedBy() = %v, want alice and mallory", got)
+	}
+	onlyAlice := func(approval Approval) bool { return approval.By == "alice" }
+	if got := approval.ApprovedBy(onlyAlice); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("ApprovedBy() with an approver list = %v, want only alice", got)
+	}
+}
+
+func TestPruneCommandPlans(t *testing.T) {
+	st := State{CommandPlans: map[string]sync_commands.Plan{"0.7.0-1": nil, "0.7.1-1": nil, "0.7.2-1": nil}}
+
+	removed := st.PruneCommandPlans("0.7.2-1", "")
+	if len(removed) != 2 || removed[0] != "0.7.0-1" || removed[1] != "0.7.1-1" {
+		t.Errorf("PruneCommandPlans() removed %v, want [0.7.0-1 0.7.1-1]", removed)
+	}
+	if _, ok := st.CommandPlans["0.7.2-1"]; !ok || len(st.CommandPlans) != 1 {
+		t.Errorf("CommandPlans = %v, want only the 0.7.2-1 plan", st.CommandPlans)
+	}
+}
@@ -3,16 +3,30 @@ package sync_commands
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"text/template"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
 )
 
+// commandWaitDelay is how long after a command exits, or its process group is killed, its output is still read before
+// the pipes are closed - a daemon it forked off may hold them open forever
+const commandWaitDelay = 5 * time.Second
+
 var (
 	stderrStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("124"))
 	stdoutStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("28"))
@@ -30,6 +44,22 @@ type ExecOptions struct {
 	StreamOutput  bool
 }
 
+const (
+	// ResultStatusExecuted is the status of a command that ran successfully
+	ResultStatusExecuted = "executed"
+	// ResultStatusSkipped is the status of a command whose check reported it as already done
+	ResultStatusSkipped = "skipped"
+	// ResultStatusDisabled is the status of a disabled command
+	ResultStatusDisabled = "disabled"
+	// ResultStatusAllowedFailure is the status of a failed command with allow_failure enabled
+	ResultStatusAllowedFailure = "allowed_failure"
+	// ResultStatusFailed is the status of a failed command
+	ResultStatusFailed = "failed"
+)
+
+// DefaultRetryDelay is the time waited between attempts of a command with retries when retry_delay isn't set
+const DefaultRetryDelay = 10 * time.Second
+
 // Command is a command to run, contains valid templated strings
 type Command struct {
 	Name         string            `koanf:"name"`
@@ -37,16 +67,114 @@ type Command struct {
 	AllowFailure bool              `koanf:"allow_failure"`
 	Cmd          string            `koanf:"cmd"`
 	Args         []string          `koanf:"args"`
-	Environment  map[string]string `koanf:"environment"`
+	Environment  map[string]string `koanf:"environment" redact:"true"`
 	StreamOutput bool              `koanf:"stream_output"`
+	// Check is an optional probe evaluated before running the command - when it passes the command is skipped
+	Check *Check `koanf:"check"`
+	// DependsOn are the names of the sync commands that must run before this one, sync commands run in dependency order
+	DependsOn []string `koanf:"depends_on"`
+	// Verify is an optional probe evaluated after the command runs - when it doesn't pass the command failed
+	Verify *Check `koanf:"verify"`
+	// Timeout is the maximum time an attempt may run before it is killed, no limit when 0
+	Timeout time.Duration `koanf:"timeout"`
+	// Retries is how many times a failed attempt is retried before on_failure applies, none by default
+	Retries int `koanf:"retries"`
+	// RetryDelay is the time waited between attempts, defaults to DefaultRetryDelay
+	RetryDelay time.Duration `koanf:"retry_delay"`
+	// OnFailure is what a failure does once retries are exhausted - one of abort (default), continue (what
+	// allow_failure sets) or rollback, which only sync commands honour and elsewhere aborts
+	OnFailure string `koanf:"on_failure"`
 
 	logPrefix            string
+	parentLogger         *log.Logger
+	clock                clock.Clock
 	logger               *log.Logger
 	cmdTemplate          *template.Template
 	argsTemplates        []*template.Template
 	environmentTemplates map[string]*template.Template
 }
 
+// Check is a probe that reports whether a command's work is done, making re-runs safe (check) or confirming the
+// command did its work (verify). Exactly one of Cmd or FileExists must be set, both support templated strings
+type Check struct {
+	// Cmd is a command that exits 0 when the step is already done
+	Cmd string `koanf:"cmd"`
+	// Args are the arguments passed to Cmd
+	Args []string `koanf:"args"`
+	// FileExists is a path that exists when the step is already done
+	FileExists string `koanf:"file_exists"`
+
+	cmdTemplate        *template.Template
+	argsTemplates      []*template.Template
+	fileExistsTemplate *template.Template
+}
+
+// Result is the outcome of executing a command
+type Result struct {
+	// Name is the command name
+	Name string
+	// Status is one of executed, skipped, disabled, allowed_failure, failed
+	Status string
+	// Execution is how the command ran, nil when it didn't (disabled, skipped or its templates failed to render)
+	Execution *Execution
+}
+
+// Execution is the record of a command that ran
+type Execution struct {
+	// CommandLine is the rendered command and its arguments
+	CommandLine string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	// ExitCode is the exit code of the command, -1 when it couldn't be started or was killed by a signal
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// finish records the command finishing with err and the output it wrote, returning the execution
+func (e *Execution) finish(finishedAt time.Time, output *outputCapture, err error) *Execution {
+	e.FinishedAt = finishedAt.UTC()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		e.ExitCode = 0
+	case errors.As(err, &exitErr):
+		e.ExitCode = exitErr.ExitCode()
+	default:
+		e.ExitCode = -1
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	e.Stdout = output.stdout.String()
+	e.Stderr = output.stderr.String()
+	return e
+}
+
+// outputCapture records what a command wrote to stdout and stderr, each on its own and interleaved as written
+type outputCapture struct {
+	mu       sync.Mutex
+	combined bytes.Buffer
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+}
+
+// captureWriter writes to one of the streams of an outputCapture
+type captureWriter struct {
+	capture *outputCapture
+	stream  *bytes.Buffer
+}
+
+func (w captureWriter) Write(p []byte) (int, error) {
+	w.capture.mu.Lock()
+	defer w.capture.mu.Unlock()
+	w.capture.combined.Write(p)
+	return w.stream.Write(p)
+}
+
+func (o *outputCapture) stdoutWriter() io.Writer { return captureWriter{capture: o, stream: &o.stdout} }
+
+func (o *outputCapture) stderrWriter() io.Writer { return captureWriter{capture: o, stream: &o.stderr} }
+
 // CommandTemplateData represents the data available for command template interpolation
 type CommandTemplateData struct {
 	CommandIndex     int
@@ -55,6 +183,9 @@ type CommandTemplateData struct {
 	VersionFrom      string
 	VersionTo        string
 	PackageVersionTo string // The package version string for installation (e.g., "0.7.1-1" for Debian/Ubuntu)
+	// RPMPackageVersionTo is the RPM package version-release for installation on RHEL-family hosts (e.g., "0.7.1-1"),
+	// falls back to PackageVersionTo when the version source doesn't publish one
+	RPMPackageVersionTo string
 }
 
 // NewCommand creates a new Command from a config
@@ -67,6 +198,33 @@ func (c *Command) Parse() (err error) {
 	if c.Cmd == "" {
 		return fmt.Errorf("command cmd is required")
 	}
+
+	// allow_failure is the shorthand of on_failure: continue
+	if c.OnFailure == "" {
+		c.OnFailure = constants.SyncCommandOnFailureAbort
+		if c.AllowFailure {
+			c.OnFailure = constants.SyncCommandOnFailureContinue
+		}
+	}
+	if !slices.Contains(constants.ValidSyncCommandOnFailures, c.OnFailure) {
+		return fmt.Errorf("command on_failure must be one of %v - got: %s", constants.ValidSyncCommandOnFailures, c.OnFailure)
+	}
+	if c.AllowFailure && c.OnFailure != constants.SyncCommandOnFailureContinue {
+		return fmt.Errorf("command allow_failure conflicts with on_failure %s - allow_failure is on_failure: %s",
+			c.OnFailure, constants.SyncCommandOnFailureContinue)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("command timeout must be >= 0 - got: %s", c.Timeout)
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("command retries must be >= 0 - got: %d", c.Retries)
+	}
+	if c.RetryDelay < 0 {
+		return fmt.Errorf("command retry_delay must be >= 0 - got: %s", c.RetryDelay)
+	}
+	if c.RetryDelay == 0 {
+		c.RetryDelay = DefaultRetryDelay
+	}
 	c.cmdTemplate, err = template.New("cmd").Parse(c.Cmd)
 	if err != nil {
 		return fmt.Errorf("invalid golang template string: %w", err)
@@ -92,25 +250,149 @@ func (c *Command) Parse() (err error) {
 		}
 	}
 
+	// parse and store the check templates
+	if c.Check != nil {
+		if err = c.Check.parse("check"); err != nil {
+			return fmt.Errorf("invalid check: %w", err)
+		}
+	}
+
+	// parse and store the verify templates
+	if c.Verify != nil {
+		if err = c.Verify.parse("verify"); err != nil {
+			return fmt.Errorf("invalid verify: %w", err)
+		}
+	}
+
 	// create the logger
-	c.logger = log.WithPrefix(fmt.Sprintf("command[%s]", c.Name)).
+	c.logger = logging.WithPrefix(c.getParentLogger(), fmt.Sprintf("command[%s]", c.Name)).
 		With(
 			"cmd", c.Cmd,
 			"args", c.Args,
 			"environment", c.Environment,
 			"disabled", c.Disabled,
-			"allow_failure", c.AllowFailure,
+			"on_failure", c.OnFailure,
+			"timeout", c.Timeout,
+			"retries", c.Retries,
 		)
 
+	// fields that are no longer rendered fail now rather than at sync time, deprecated ones still render
+	issues, err := c.LintTemplates()
+	if err != nil {
+		return err
+	}
+	var templateErrors []string
+	for _, issue := range issues {
+		if issue.Severity == TemplateIssueError {
+			templateErrors = append(templateErrors, issue.String())
+			continue
+		}
+		c.logger.Warn("command template references a deprecated field", "template", issue.Template, "field", issue.Field, "message", issue.Message)
+	}
+	if len(templateErrors) > 0 {
+		return fmt.Errorf("invalid template data reference: %s", strings.Join(templateErrors, "; "))
+	}
+
 	return nil
 }
 
+// parse validates and parses the check templates
+func (c *Check) parse(name string) (err error) {
+	if (c.Cmd == "") == (c.FileExists == "") {
+		return fmt.Errorf("exactly one of cmd or file_exists is required")
+	}
+
+	if c.FileExists != "" {
+		c.fileExistsTemplate, err = template.New(name + ".file_exists").Parse(c.FileExists)
+		if err != nil {
+			return fmt.Errorf("invalid golang template string %s.file_exists: %w", name, err)
+		}
+		return nil
+	}
+
+	c.cmdTemplate, err = template.New(name + ".cmd").Parse(c.Cmd)
+	if err != nil {
+		return fmt.Errorf("invalid golang template string %s.cmd: %w", name, err)
+	}
+
+	c.argsTemplates = make([]*template.Template, len(c.Args))
+	for j, arg := range c.Args {
+		argTemplateName := fmt.Sprintf("%s.arg[%d]", name, j)
+		c.argsTemplates[j], err = template.New(argTemplateName).Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid golang template string %s: %w", argTemplateName, err)
+		}
+	}
+
+	return nil
+}
+
+// isDone evaluates the probe with the provided template data and returns true if the step is done
+func (c *Check) isDone(ctx context.Context, logger *log.Logger, data CommandTemplateData) (bool, error) {
+	if c.fileExistsTemplate != nil {
+		pathBuf := bytes.Buffer{}
+		if err := c.fileExistsTemplate.Execute(&pathBuf, data); err != nil {
+			return false, fmt.Errorf("failed to execute %s template: %w", c.fileExistsTemplate.Name(), err)
+		}
+		_, err := os.Stat(pathBuf.String())
+		logger.Debug("evaluated check", "file_exists", pathBuf.String(), "exists", err == nil)
+		return err == nil, nil
+	}
+
+	cmdBuf := bytes.Buffer{}
+	if err := c.cmdTemplate.Execute(&cmdBuf, data); err != nil {
+		return false, fmt.Errorf("failed to execute %s template: %w", c.cmdTemplate.Name(), err)
+	}
+
+	args := make([]string, 0, len(c.argsTemplates))
+	for _, argTemplate := range c.argsTemplates {
+		argBuf := bytes.Buffer{}
+		if err := argTemplate.Execute(&argBuf, data); err != nil {
+			return false, fmt.Errorf("failed to execute %s template: %w", argTemplate.Name(), err)
+		}
+		args = append(args, argBuf.String())
+	}
+
+	output, err := exec.CommandContext(ctx, cmdBuf.String(), args...).CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return false, ctxErr
+	}
+	logger.Debug("evaluated check", "cmd", cmdBuf.String(), "args", args, "done", err == nil, "output", strings.TrimSpace(string(output)))
+	return err == nil, nil
+}
+
+// SetLogger sets the parent logger used for command output, defaults to the global logger
+func (c *Command) SetLogger(logger *log.Logger) {
+	c.parentLogger = logger
+}
+
+// getParentLogger returns the parent logger, falling back to the global logger
+func (c *Command) getParentLogger() *log.Logger {
+	if c.parentLogger == nil {
+		return log.Default()
+	}
+	return c.parentLogger
+}
+
+// SetClock sets the clock waits between attempts sleep on and executions are timed with, defaults to the system clock
+func (c *Command) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// getClock returns the clock, falling back to the system clock
+func (c *Command) getClock() clock.Clock {
+	if c.clock == nil {
+		return clock.Real{}
+	}
+	return c.clock
+}
+
 func (c *Command) setLogPrefix(prefix string) {
 	c.logPrefix = prefix
 }
 
-// ExecuteWithData executes the command with the provided template data
-func (c *Command) ExecuteWithData(data CommandTemplateData) (err error) {
+// ExecuteWithData executes the command with the provided template data, killing it if ctx is done before it exits
+func (c *Command) ExecuteWithData(ctx context.Context, data CommandTemplateData) (result Result, err error) {
 	var (
 		compiledCmd         string
 		compiledArgs        []string
@@ -119,7 +401,8 @@ func (c *Command) ExecuteWithData(data CommandTemplateData) (err error) {
 
 	c.setLogPrefix(fmt.Sprintf("sync:commands[%d/%d %s]", data.CommandIndex+1, data.CommandsCount, c.Name))
 
-	execLogger := log.WithPrefix(c.logPrefix)
+	execLogger := logging.WithPrefix(c.getParentLogger(), c.logPrefix)
+	result.Name = c.Name
 
 	// compiled command
 	cmdBuf := bytes.Buffer{}
@@ -131,7 +414,8 @@ func (c *Command) ExecuteWithData(data CommandTemplateData) (err error) {
 	for _, argTemplate := range c.argsTemplates {
 		argBuf := bytes.Buffer{}
 		if err := argTemplate.Execute(&argBuf, data); err != nil {
-			return fmt.Errorf("failed to execute arg template: %w", err)
+			result.Status = ResultStatusFailed
+			return result, fmt.Errorf("failed to execute arg template: %w", err)
 		}
 		compiledArgs = append(compiledArgs, argBuf.String())
 	}
@@ -146,22 +430,88 @@ func (c *Command) ExecuteWithData(data CommandTemplateData) (err error) {
 
 	if c.Disabled {
 		execLogger.Warn("command is disabled, skipping")
-		return nil
+		result.Status = ResultStatusDisabled
+		return result, nil
+	}
+
+	if c.Check != nil {
+		done, err := c.Check.isDone(ctx, execLogger, data)
+		if err != nil {
+			result.Status = ResultStatusFailed
+			return result, err
+		}
+		if done {
+			execLogger.Info("check reports step already done, skipping")
+			result.Status = ResultStatusSkipped
+			return result, nil
+		}
+	}
+
+	// only the last attempt is allowed to fail, earlier failures are retried
+	attempts := c.Retries + 1
+	for attempt := 1; ; attempt++ {
+		result.Status, result.Execution, err = c.attempt(ctx, data, ExecOptions{
+			ExecLogger:    execLogger,
+			CommandIndex:  data.CommandIndex,
+			CommandsCount: data.CommandsCount,
+			AllowFailure:  c.ContinuesOnFailure() && attempt == attempts,
+			Cmd:           compiledCmd,
+			Args:          compiledArgs,
+			Environment:   compiledEnvironment,
+			StreamOutput:  c.StreamOutput,
+		})
+		if err == nil || attempt == attempts || ctx.Err() != nil {
+			return result, err
+		}
+
+		execLogger.Warn("attempt failed - retrying", "attempt", attempt, "attempts", attempts, "retry_delay", c.RetryDelay, "error", err)
+		if clock.SleepContext(ctx, c.getClock(), c.RetryDelay) != nil {
+			return result, err
+		}
+	}
+}
+
+// ContinuesOnFailure returns true if a failure of the command doesn't stop the commands after it
+func (c *Command) ContinuesOnFailure() bool {
+	return c.OnFailure == constants.SyncCommandOnFailureContinue
+}
+
+// RollsBackOnFailure returns true if a failure of the command rolls the sync back
+func (c *Command) RollsBackOnFailure() bool {
+	return c.OnFailure == constants.SyncCommandOnFailureRollback
+}
+
+// attempt runs the command once, killing it when it runs longer than the timeout, then evaluates verify
+func (c *Command) attempt(ctx context.Context, data CommandTemplateData, opts ExecOptions) (string, *Execution, error) {
+	attemptCtx := ctx
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	status, execution, err := c.exec(attemptCtx, opts)
+	if err != nil || status != ResultStatusExecuted || c.Verify == nil {
+		return status, execution, err
 	}
 
-	return c.exec(ExecOptions{
-		ExecLogger:    execLogger,
-		CommandIndex:  data.CommandIndex,
-		CommandsCount: data.CommandsCount,
-		AllowFailure:  c.AllowFailure,
-		Cmd:           compiledCmd,
-		Args:          compiledArgs,
-		Environment:   compiledEnvironment,
-		StreamOutput:  c.StreamOutput,
-	})
+	verified, err := c.Verify.isDone(ctx, opts.ExecLogger, data)
+	if err == nil && !verified {
+		err = fmt.Errorf("verify did not pass after the command ran")
+	}
+	if err == nil {
+		opts.ExecLogger.Info("verified")
+		return status, execution, nil
+	}
+	if opts.AllowFailure {
+		opts.ExecLogger.Warn("verification failed with allow failure enabled - continuing", "error", err)
+		return ResultStatusAllowedFailure, execution, nil
+	}
+	return ResultStatusFailed, execution, fmt.Errorf("failed %s: %w", c.logPrefix, err)
 }
 
-func (c *Command) exec(opts ExecOptions) error {
+// exec runs the command and returns its result status and how it ran, nil when it couldn't be set up
+func (c *Command) exec(ctx context.Context, opts ExecOptions) (string, *Execution, error) {
 	// doing something wrong here, but can't see it so make sure args exclude blank args
 	sanitizedArgs := []string{}
 	opts.ExecLogger.Debug("sanitizing args", "args", opts.Args)
@@ -181,30 +531,40 @@ func (c *Command) exec(opts ExecOptions) error {
 
 	// run it
 	var cmdErr error
-	cmd := exec.Command(opts.Cmd, sanitizedArgs...)
+	cmd := exec.CommandContext(ctx, opts.Cmd, sanitizedArgs...)
 	cmd.Env = opts.EnvironmentSlice()
+	// run it in its own process group and kill the whole group when ctx is done, so children it started (e.g. apt's
+	// dpkg, or the rest of a shell pipeline) don't keep running and holding its output pipes open
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	clk := c.getClock()
+	output := &outputCapture{}
+	execution := &Execution{
+		CommandLine: strings.Join(append([]string{opts.Cmd}, sanitizedArgs...), " "),
+		StartedAt:   clk.Now().UTC(),
+	}
 
 	if opts.StreamOutput {
-		// Capture stdout and stderr, then stream through logger
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return fmt.Errorf("failed to create stdout pipe: %w", err)
-		}
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			return fmt.Errorf("failed to create stderr pipe: %w", err)
-		}
+		// Capture stdout and stderr, then stream through logger - Wait copies the output to the pipes, giving up after
+		// WaitDelay, so a child holding them open can't block it
+		stdout, stdoutWriter := io.Pipe()
+		stderr, stderrWriter := io.Pipe()
+		cmd.Stdout = stdoutWriter
+		cmd.Stderr = stderrWriter
 
 		// Start command
-		err = cmd.Start()
+		err := cmd.Start()
 
-		if err != nil && c.AllowFailure {
+		if err != nil && opts.AllowFailure {
 			opts.ExecLogger.Warn("failed to start command with allow failure enabled - continuing", "error", err)
-			return nil
+			return ResultStatusAllowedFailure, execution.finish(clk.Now(), output, err), nil
 		}
 
 		if err != nil {
-			return fmt.Errorf("failed %s: %w", c.logPrefix, err)
+			return ResultStatusFailed, execution.finish(clk.Now(), output, err), fmt.Errorf("failed %s: %w", c.logPrefix, err)
 		}
 
 		// get the command pid (only after successful start)
@@ -221,6 +581,7 @@ func (c *Command) exec(opts ExecOptions) error {
 			defer stdout.Close()
 			scanner := bufio.NewScanner(stdout)
 			for scanner.Scan() {
+				fmt.Fprintln(output.stdoutWriter(), scanner.Text())
 				opts.ExecLogger.Info(
 					styledStreamOutputString("stdout", scanner.Text()),
 				)
@@ -236,6 +597,7 @@ func (c *Command) exec(opts ExecOptions) error {
 			defer stderr.Close()
 			scanner := bufio.NewScanner(stderr)
 			for scanner.Scan() {
+				fmt.Fprintln(output.stderrWriter(), scanner.Text())
 				opts.ExecLogger.Info(
 					styledStreamOutputString("stderr", scanner.Text()),
 				)
@@ -245,35 +607,40 @@ func (c *Command) exec(opts ExecOptions) error {
 			}
 		}()
 
-		// Wait for command to complete
+		// Wait for command to complete, then for the streaming goroutines to read the rest of its output
 		cmdErr = cmd.Wait()
-
-		// Wait for streaming goroutines to complete
+		stdoutWriter.Close()
+		stderrWriter.Close()
 		wg.Wait()
 	} else {
-		var combinedOutput []byte
-		combinedOutput, cmdErr = cmd.CombinedOutput()
-		outputMessage := "command output:\n" + string(combinedOutput)
+		cmd.Stdout = output.stdoutWriter()
+		cmd.Stderr = output.stderrWriter()
+		cmdErr = cmd.Run()
+		outputMessage := "command output:\n" + output.combined.String()
 		if cmdErr != nil {
 			opts.ExecLogger.Error(outputMessage)
 		} else {
 			opts.ExecLogger.Info(outputMessage)
 		}
 	}
+	execution.finish(clk.Now(), output, cmdErr)
+	if cmdErr != nil && c.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		cmdErr = fmt.Errorf("killed after exceeding its %s timeout: %w", c.Timeout, cmdErr)
+	}
 
 	// if failed and allowed to fail, collect stderr output into a string and return as error
 	if cmdErr != nil && opts.AllowFailure {
 		opts.ExecLogger.Warn("command failed with allow failure enabled - continuing", "error", cmdErr)
-		return nil
+		return ResultStatusAllowedFailure, execution, nil
 	}
 
 	// if failed, return error
 	if cmdErr != nil {
 		opts.ExecLogger.Error("command failed", "error", cmdErr)
-		cmdErr = fmt.Errorf("failed %s: %w", c.logPrefix, cmdErr)
+		return ResultStatusFailed, execution, fmt.Errorf("failed %s: %w", c.logPrefix, cmdErr)
 	}
 
-	return cmdErr
+	return ResultStatusExecuted, execution, nil
 }
 
 // EnvironmentSlice returns the environment variables as a slice of strings
@@ -0,0 +1,302 @@
+package sync_commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+func TestExecuteWithData_CheckSkipsWhenDone(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0.7.1.done"), nil, 0o644); err != nil {
+		t.Fatalf("failed to write marker: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		check    *Check
+		expected string
+	}{
+		{
+			name:     "file exists",
+			check:    &Check{FileExists: filepath.Join(dir, "{{ .VersionTo }}.done")},
+			expected: ResultStatusSkipped,
+		},
+		{
+			name:     "file missing",
+			check:    &Check{FileExists: filepath.Join(dir, "{{ .VersionFrom }}.done")},
+			expected: ResultStatusExecuted,
+		},
+		{
+			name:     "check cmd succeeds",
+			check:    &Check{Cmd: "true"},
+			expected: ResultStatusSkipped,
+		},
+		{
+			name:     "check cmd fails",
+			check:    &Check{Cmd: "false"},
+			expected: ResultStatusExecuted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Command{Name: "test", Cmd: "true", Check: tt.check}
+			if err := cmd.Parse(); err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1, VersionFrom: "0.7.0", VersionTo: "0.7.1"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.expected {
+				t.Errorf("Status = %s, want %s", result.Status, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteWithData_Verify(t *testing.T) {
+	tests := []struct {
+		name         string
+		verify       *Check
+		allowFailure bool
+		expected     string
+		wantErr      bool
+	}{
+		{
+			name:     "verify passes",
+			verify:   &Check{Cmd: "true"},
+			expected: ResultStatusExecuted,
+		},
+		{
+			name:     "verify fails",
+			verify:   &Check{FileExists: filepath.Join(t.TempDir(), "{{ .VersionTo }}.done")},
+			expected: ResultStatusFailed,
+			wantErr:  true,
+		},
+		{
+			name:         "verify fails with allow failure",
+			verify:       &Check{Cmd: "false"},
+			allowFailure: true,
+			expected:     ResultStatusAllowedFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Command{Name: "test", Cmd: "true", Verify: tt.verify, AllowFailure: tt.allowFailure}
+			if err := cmd.Parse(); err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1, VersionFrom: "0.7.0", VersionTo: "0.7.1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Status != tt.expected {
+				t.Errorf("Status = %s, want %s", result.Status, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParse_CheckRequiresExactlyOneProbe(t *testing.T) {
+	for _, check := range []*Check{{}, {Cmd: "true", FileExists: "/tmp/x"}} {
+		cmd := Command{Name: "test", Cmd: "true", Check: check}
+		if err := cmd.Parse(); err == nil {
+			t.Errorf("expected error for check %+v, got nil", check)
+		}
+	}
+}
+
+func TestParse_OnFailure(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       Command
+		wantOnFailure string
+		wantErr       bool
+	}{
+		{name: "defaults to abort", command: Command{Name: "test", Cmd: "true"}, wantOnFailure: "abort"},
+		{name: "allow_failure continues", command: Command{Name: "test", Cmd: "true", AllowFailure: true}, wantOnFailure: "continue"},
+		{name: "rollback", command: Command{Name: "test", Cmd: "true", OnFailure: "rollback"}, wantOnFailure: "rollback"},
+		{name: "invalid", command: Command{Name: "test", Cmd: "true", OnFailure: "ignore"}, wantErr: true},
+		{name: "allow_failure with rollback", command: Command{Name: "test", Cmd: "true", AllowFailure: true, OnFailure: "rollback"}, wantErr: true},
+		{name: "negative retries", command: Command{Name: "test", Cmd: "true", Retries: -1}, wantErr: true},
+		{name: "negative timeout", command: Command{Name: "test", Cmd: "true", Timeout: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.command
+			err := cmd.Parse()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cmd.OnFailure != tt.wantOnFailure {
+				t.Errorf("OnFailure = %q, want %q", cmd.OnFailure, tt.wantOnFailure)
+			}
+		})
+	}
+}
+
+func TestExecuteWithData_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		retries      int
+		allowFailure bool
+		wantStatus   string
+		wantErr      bool
+		wantAttempts int
+	}{
+		{name: "passes on a retry", failures: 2, retries: 2, wantStatus: ResultStatusExecuted, wantAttempts: 3},
+		{name: "retries exhausted", failures: 3, retries: 1, wantStatus: ResultStatusFailed, wantErr: true, wantAttempts: 2},
+		{name: "retries exhausted continues", failures: 3, retries: 1, allowFailure: true, wantStatus: ResultStatusAllowedFailure, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// each attempt appends a line, failing while there are fewer lines than failures
+			attemptsFile := filepath.Join(t.TempDir(), "attempts")
+			script := fmt.Sprintf(`echo x >> %s; [ "$(wc -l < %s)" -gt %d ]`, attemptsFile, attemptsFile, tt.failures)
+			cmd := Command{Name: "test", Cmd: "sh", Args: []string{"-c", script}, Retries: tt.retries, RetryDelay: time.Hour, AllowFailure: tt.allowFailure}
+			if err := cmd.Parse(); err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			// attempts wait on the fake clock, advancing it by the retry delay instead of sleeping
+			start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+			fakeClock := clock.NewFake(start)
+			cmd.SetClock(fakeClock)
+			result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1})
+			if (err != nil) != tt.wantErr || result.Status != tt.wantStatus {
+				t.Errorf("result = %+v, error = %v, want status %s, wantErr %v", result, err, tt.wantStatus, tt.wantErr)
+			}
+			contents, err := os.ReadFile(attemptsFile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if attempts := strings.Count(string(contents), "x"); attempts != tt.wantAttempts {
+				t.Errorf("ran %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			if waited := fakeClock.Now().Sub(start); waited != time.Duration(tt.wantAttempts-1)*time.Hour {
+				t.Errorf("waited %s between attempts, want %d retry delays", waited, tt.wantAttempts-1)
+			}
+		})
+	}
+}
+
+func TestExecuteWithData_Timeout(t *testing.T) {
+	cmd := Command{Name: "test", Cmd: "sleep", Args: []string{"60"}, Timeout: 50 * time.Millisecond, Retries: 1, RetryDelay: time.Millisecond}
+	if err := cmd.Parse(); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	startedAt := time.Now()
+	result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1})
+	if err == nil || result.Status != ResultStatusFailed || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("result = %+v, error = %v, want the command killed by its timeout", result, err)
+	}
+	if elapsed := time.Since(startedAt); elapsed > 10*time.Second {
+		t.Errorf("took %s, want both attempts killed right away", elapsed)
+	}
+}
+
+func TestExecuteWithData_TimeoutKillsProcessGroup(t *testing.T) {
+	for _, streamOutput := range []bool{false, true} {
+		// the backgrounded sleep inherits the output pipes, it would hold them open if only sh was killed
+		cmd := Command{Name: "test", Cmd: "sh", Args: []string{"-c", "sleep 60 & sleep 60"}, Timeout: 100 * time.Millisecond, StreamOutput: streamOutput}
+		if err := cmd.Parse(); err != nil {
+			t.Fatalf("unexpected parse error: %v", err)
+		}
+		startedAt := time.Now()
+		result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1})
+		if err == nil || result.Status != ResultStatusFailed || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("stream_output=%v: result = %+v, error = %v, want the command killed by its timeout", streamOutput, result, err)
+		}
+		if elapsed := time.Since(startedAt); elapsed > 2*time.Second {
+			t.Errorf("stream_output=%v: took %s, want the command and its children killed right away", streamOutput, elapsed)
+		}
+	}
+}
+
+func TestExecuteWithData_RecordsExecution(t *testing.T) {
+	tests := []struct {
+		name         string
+		streamOutput bool
+		cmd          string
+		args         []string
+		wantStatus   string
+		wantExitCode int
+		wantStdout   string
+		wantStderr   string
+	}{
+		{name: "captured", cmd: "sh", args: []string{"-c", "echo {{ .VersionTo }}; echo oops >&2; exit 3"},
+			wantStatus: ResultStatusAllowedFailure, wantExitCode: 3, wantStdout: "0.7.1\n", wantStderr: "oops\n"},
+		{name: "streamed", streamOutput: true, cmd: "sh", args: []string{"-c", "echo {{ .VersionTo }}; echo oops >&2"},
+			wantStatus: ResultStatusExecuted, wantStdout: "0.7.1\n", wantStderr: "oops\n"},
+		{name: "not started", cmd: filepath.Join(t.TempDir(), "missing"), wantStatus: ResultStatusAllowedFailure, wantExitCode: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Command{Name: "test", Cmd: tt.cmd, Args: tt.args, AllowFailure: true, StreamOutput: tt.streamOutput}
+			if err := cmd.Parse(); err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			// executions are timed on the command's clock
+			start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+			cmd.SetClock(clock.NewFake(start))
+			result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1, VersionTo: "0.7.1"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Execution == nil {
+				t.Fatalf("result = %+v, want status %s with its execution", result, tt.wantStatus)
+			}
+			execution := result.Execution
+			if execution.ExitCode != tt.wantExitCode || execution.Stdout != tt.wantStdout || execution.Stderr != tt.wantStderr {
+				t.Errorf("execution = %+v, want exit code %d, stdout %q, stderr %q", execution, tt.wantExitCode, tt.wantStdout, tt.wantStderr)
+			}
+			if !execution.StartedAt.Equal(start) || !execution.FinishedAt.Equal(start) {
+				t.Errorf("execution ran from %s to %s, want both at %s on the fake clock", execution.StartedAt, execution.FinishedAt, start)
+			}
+		})
+	}
+}
+
+func TestExecuteWithData_DisabledHasNoExecution(t *testing.T) {
+	cmd := Command{Name: "test", Cmd: "true", Disabled: true}
+	if err := cmd.Parse(); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	result, err := cmd.ExecuteWithData(context.Background(), CommandTemplateData{CommandsCount: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Execution != nil {
+		t.Errorf("Execution = %+v, want nil for a disabled command", result.Execution)
+	}
+}
+
+func TestExecuteWithData_KilledWhenContextDone(t *testing.T) {
+	for _, streamOutput := range []bool{false, true} {
+		cmd := Command{Name: "test", Cmd: "sleep", Args: []string{"60"}, StreamOutput: streamOutput}
+		if err := cmd.Parse(); err != nil {
+			t.Fatalf("unexpected parse error: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		result, err := cmd.ExecuteWithData(ctx, CommandTemplateData{CommandsCount: 1})
+		cancel()
+		if err == nil || result.Status != ResultStatusFailed {
+			t.Errorf("stream_output=%v: result = %+v, error = %v, want the killed command failed", streamOutput, result, err)
+		}
+		if result.Execution == nil || result.Execution.FinishedAt.Sub(result.Execution.StartedAt) > 10*time.Second {
+			t.Errorf("stream_output=%v: execution = %+v, want it killed right away", streamOutput, result.Execution)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package sync_commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Order returns the commands in dependency order: each command runs after the commands named in its depends_on, and
+// otherwise in the order they are declared. Commands without any depends_on are returned in their declared order.
+// Returns an error when a dependency isn't a command name, a name depended on is declared more than once, or the
+// dependencies form a cycle
+func Order(commands []Command) ([]Command, error) {
+	if !slices.ContainsFunc(commands, func(c Command) bool { return len(c.DependsOn) > 0 }) {
+		return commands, nil
+	}
+
+	indexes := make(map[string][]int, len(commands))
+	for i, command := range commands {
+		indexes[command.Name] = append(indexes[command.Name], i)
+	}
+
+	// dependencies[i] are the indexes of the commands command i depends on
+	dependencies := make([][]int, len(commands))
+	for i, command := range commands {
+		for _, name := range command.DependsOn {
+			switch matches := indexes[name]; {
+			case len(matches) == 0:
+				return nil, fmt.Errorf("command %d (%s) depends_on %s, which is not a command name", i, command.Name, name)
+			case len(matches) > 1:
+				return nil, fmt.Errorf("command %d (%s) depends_on %s, which is the name of %d commands", i, command.Name, name, len(matches))
+			case matches[0] == i:
+				return nil, fmt.Errorf("command %d (%s) depends_on itself", i, command.Name)
+			}
+			dependencies[i] = append(dependencies[i], indexes[name][0])
+		}
+	}
+
+	// repeatedly pick the first declared command whose dependencies have all been ordered
+	ordered := make([]Command, 0, len(commands))
+	done := make([]bool, len(commands))
+	for len(ordered) < len(commands) {
+		next := -1
+		for i := range commands {
+			if !done[i] && !slices.ContainsFunc(dependencies[i], func(d int) bool { return !done[d] }) {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var blocked []string
+			for i, command := range commands {
+				if !done[i] {
+					blocked = append(blocked, command.Name)
+				}
+			}
+			return nil, fmt.Errorf("depends_on forms a cycle, these commands can't be ordered: %s", strings.Join(blocked, ", "))
+		}
+		done[next] = true
+		ordered = append(ordered, commands[next])
+	}
+
+	return ordered, nil
+}
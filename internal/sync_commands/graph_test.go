@@ -0,0 +1,74 @@
+package sync_commands
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []Command
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "no dependencies keeps declared order",
+			commands: []Command{{Name: "b"}, {Name: "a"}, {Name: "b"}},
+			expected: []string{"b", "a", "b"},
+		},
+		{
+			name: "dependencies run first",
+			commands: []Command{
+				{Name: "restart-validator", DependsOn: []string{"restart-doublezerod"}},
+				{Name: "install-cli", DependsOn: []string{"install-doublezerod"}},
+				{Name: "restart-doublezerod", DependsOn: []string{"install-doublezerod"}},
+				{Name: "install-doublezerod"},
+			},
+			expected: []string{"install-doublezerod", "install-cli", "restart-doublezerod", "restart-validator"},
+		},
+		{
+			name:     "unknown dependency",
+			commands: []Command{{Name: "a", DependsOn: []string{"missing"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "ambiguous dependency",
+			commands: []Command{{Name: "a"}, {Name: "a"}, {Name: "b", DependsOn: []string{"a"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "self dependency",
+			commands: []Command{{Name: "a", DependsOn: []string{"a"}}},
+			wantErr:  true,
+		},
+		{
+			name: "cycle",
+			commands: []Command{
+				{Name: "a", DependsOn: []string{"c"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"b"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := Order(tt.commands)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Order() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var names []string
+			for _, command := range ordered {
+				names = append(names, command.Name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Order() = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
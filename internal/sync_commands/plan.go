@@ -0,0 +1,133 @@
+package sync_commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RenderedCommand is a command with its templates rendered for a specific sync
+type RenderedCommand struct {
+	Name         string            `json:"name"`
+	Cmd          string            `json:"cmd"`
+	Args         []string          `json:"args,omitempty"`
+	Environment  map[string]string `json:"environment,omitempty"`
+	Disabled     bool              `json:"disabled,omitempty"`
+	AllowFailure bool              `json:"allow_failure,omitempty"`
+	Rollback     bool              `json:"rollback,omitempty"`
+}
+
+// Plan is the ordered list of rendered commands a sync will execute
+type Plan []RenderedCommand
+
+// Render renders the command templates with the provided template data without executing anything
+func (c *Command) Render(data CommandTemplateData) (rendered RenderedCommand, err error) {
+	rendered = RenderedCommand{
+		Name:         c.Name,
+		Disabled:     c.Disabled,
+		AllowFailure: c.ContinuesOnFailure(),
+		Rollback:     c.RollsBackOnFailure(),
+	}
+
+	cmdBuf := bytes.Buffer{}
+	if err := c.cmdTemplate.Execute(&cmdBuf, data); err != nil {
+		return rendered, fmt.Errorf("failed to execute cmd template: %w", err)
+	}
+	rendered.Cmd = cmdBuf.String()
+
+	for _, argTemplate := range c.argsTemplates {
+		argBuf := bytes.Buffer{}
+		if err := argTemplate.Execute(&argBuf, data); err != nil {
+			return rendered, fmt.Errorf("failed to execute arg template: %w", err)
+		}
+		rendered.Args = append(rendered.Args, argBuf.String())
+	}
+
+	for envName, envTemplate := range c.environmentTemplates {
+		envBuf := bytes.Buffer{}
+		if err := envTemplate.Execute(&envBuf, data); err != nil {
+			return rendered, fmt.Errorf("failed to execute env template: %w", err)
+		}
+		if rendered.Environment == nil {
+			rendered.Environment = make(map[string]string)
+		}
+		rendered.Environment[envName] = envBuf.String()
+	}
+
+	return rendered, nil
+}
+
+// Lines returns a line-per-fact text form of the plan, suitable for diffing
+func (p Plan) Lines() []string {
+	var lines []string
+	for i, command := range p {
+		flags := ""
+		if command.Disabled {
+			flags += " (disabled)"
+		}
+		if command.AllowFailure {
+			flags += " (allow_failure)"
+		}
+		if command.Rollback {
+			flags += " (rollback)"
+		}
+		lines = append(lines, fmt.Sprintf("[%d] %s%s: %s", i+1, command.Name, flags, strings.Join(append([]string{command.Cmd}, command.Args...), " ")))
+
+		envNames := make([]string, 0, len(command.Environment))
+		for envName := range command.Environment {
+			envNames = append(envNames, envName)
+		}
+		sort.Strings(envNames)
+		for _, envName := range envNames {
+			lines = append(lines, fmt.Sprintf("[%d]   env %s=%s", i+1, envName, command.Environment[envName]))
+		}
+	}
+	return lines
+}
+
+// DiffLines returns a line diff from old to new - unchanged lines are prefixed with two spaces,
+// removed lines with "- " and added lines with "+ ". Returns nil when both are equal
+func DiffLines(old, new []string) []string {
+	// longest common subsequence table
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var (
+		diff    []string
+		changed bool
+		i, j    int
+	)
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			diff = append(diff, "  "+old[i])
+			i++
+			j++
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "- "+old[i])
+			changed = true
+			i++
+		default:
+			diff = append(diff, "+ "+new[j])
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return diff
+}
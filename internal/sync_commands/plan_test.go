@@ -0,0 +1,46 @@
+package sync_commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	old := []string{"[1] install: apt-get install doublezero=0.7.1-1", "[2] restart: systemctl restart doublezerod"}
+
+	if diff := DiffLines(old, old); diff != nil {
+		t.Errorf("DiffLines() of equal plans = %v, want nil", diff)
+	}
+
+	changed := []string{"[1] install: apt-get install -y doublezero=0.7.1-1", "[2] restart: systemctl restart doublezerod"}
+	want := []string{
+		"- [1] install: apt-get install doublezero=0.7.1-1",
+		"+ [1] install: apt-get install -y doublezero=0.7.1-1",
+		"  [2] restart: systemctl restart doublezerod",
+	}
+	diff := DiffLines(old, changed)
+	if strings.Join(diff, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DiffLines() =\n%s\nwant\n%s", strings.Join(diff, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := Command{
+		Name:        "install",
+		Cmd:         "/usr/bin/apt-get",
+		Args:        []string{"install", "doublezero={{ .PackageVersionTo }}"},
+		Environment: map[string]string{"TARGET": "{{ .VersionTo }}"},
+	}
+	if err := c.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	rendered, err := c.Render(CommandTemplateData{VersionTo: "0.7.1", PackageVersionTo: "0.7.1-1"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	got := Plan{rendered}.Lines()
+	if strings.Join(got, "\n") != "[1] install: /usr/bin/apt-get install doublezero=0.7.1-1\n[1]   env TARGET=0.7.1" {
+		t.Errorf("Lines() = %q", got)
+	}
+}
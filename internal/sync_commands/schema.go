@@ -0,0 +1,216 @@
+package sync_commands
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"text/template"
+	"text/template/parse"
+)
+
+// TemplateDataSchemaVersion is the version of the CommandTemplateData schema, bumped when fields are added, renamed or
+// removed so configs can be checked against the data they were written for
+const TemplateDataSchemaVersion = 1
+
+const (
+	// TemplateIssueWarning is the severity of a reference to a deprecated field that is still rendered
+	TemplateIssueWarning = "warning"
+	// TemplateIssueError is the severity of a reference to an unknown or removed field, which fails to render
+	TemplateIssueError = "error"
+)
+
+// TemplateField is a field of the CommandTemplateData schema
+type TemplateField struct {
+	// Name is the field name, referenced as {{ .Name }}
+	Name string
+	// Since is the schema version that introduced the field
+	Since int
+	// Description is what the field holds
+	Description string
+}
+
+// TemplateFields are the fields of the current CommandTemplateData schema
+var TemplateFields = []TemplateField{
+	{Name: "CommandIndex", Since: 1, Description: "0-based index of the command being run"},
+	{Name: "CommandsCount", Since: 1, Description: "number of sync commands"},
+	{Name: "ClusterName", Since: 1, Description: "configured cluster name"},
+	{Name: "VersionFrom", Since: 1, Description: "installed version"},
+	{Name: "VersionTo", Since: 1, Description: "target version"},
+	{Name: "PackageVersionTo", Since: 1, Description: "target package version for installation"},
+	{Name: "RPMPackageVersionTo", Since: 1, Description: "target RPM package version-release for installation"},
+}
+
+// DeprecatedTemplateField is a field of an earlier CommandTemplateData schema that was renamed or removed
+type DeprecatedTemplateField struct {
+	// Name is the field name
+	Name string
+	// Replacement is the field replacing it, empty when it was removed without one
+	Replacement string
+	// DeprecatedIn is the schema version that deprecated the field, it is still rendered until RemovedIn
+	DeprecatedIn int
+	// RemovedIn is the schema version the field was removed in, 0 while it is still rendered
+	RemovedIn int
+}
+
+// DeprecatedTemplateFields are the renamed and removed fields of earlier schema versions, kept so configs referencing
+// them get a pointer to the replacement rather than a bare render failure
+var DeprecatedTemplateFields = []DeprecatedTemplateField{}
+
+// TemplateIssue is a template reference to a field that isn't in the current CommandTemplateData schema
+type TemplateIssue struct {
+	// Template is the templated string, e.g. cmd, arg[1], env[NAME] or check.cmd
+	Template string
+	// Field is the referenced field
+	Field string
+	// Severity is one of warning, error
+	Severity string
+	// Message describes the issue and the replacement, if any
+	Message string
+}
+
+// String returns the issue as "template: message"
+func (i TemplateIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Template, i.Message)
+}
+
+// LintTemplates checks the fields the command's templated strings reference against the CommandTemplateData schema,
+// returning an issue per deprecated, removed or unknown field
+func (c *Command) LintTemplates() ([]TemplateIssue, error) {
+	templates := [][2]string{{"cmd", c.Cmd}}
+	for i, arg := range c.Args {
+		templates = append(templates, [2]string{fmt.Sprintf("arg[%d]", i), arg})
+	}
+	envNames := make([]string, 0, len(c.Environment))
+	for envName := range c.Environment {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+	for _, envName := range envNames {
+		templates = append(templates, [2]string{fmt.Sprintf("env[%s]", envName), c.Environment[envName]})
+	}
+	probes := []struct {
+		name  string
+		probe *Check
+	}{{"check", c.Check}, {"verify", c.Verify}}
+	for _, p := range probes {
+		name, probe := p.name, p.probe
+		if probe == nil {
+			continue
+		}
+		if probe.Cmd != "" {
+			templates = append(templates, [2]string{name + ".cmd", probe.Cmd})
+		}
+		for i, arg := range probe.Args {
+			templates = append(templates, [2]string{fmt.Sprintf("%s.arg[%d]", name, i), arg})
+		}
+		if probe.FileExists != "" {
+			templates = append(templates, [2]string{name + ".file_exists", probe.FileExists})
+		}
+	}
+
+	var issues []TemplateIssue
+	for _, t := range templates {
+		tmpl, err := template.New(t[0]).Parse(t[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid golang template string %s: %w", t[0], err)
+		}
+		if tmpl.Tree == nil {
+			continue
+		}
+		for _, field := range templateFieldRefs(tmpl.Tree) {
+			if issue := lintTemplateField(field); issue != nil {
+				issue.Template = t[0]
+				issues = append(issues, *issue)
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// lintTemplateField returns the issue with a referenced field, nil when it is in the current schema
+func lintTemplateField(field string) *TemplateIssue {
+	if slices.ContainsFunc(TemplateFields, func(f TemplateField) bool { return f.Name == field }) {
+		return nil
+	}
+
+	index := slices.IndexFunc(DeprecatedTemplateFields, func(f DeprecatedTemplateField) bool { return f.Name == field })
+	if index < 0 {
+		return &TemplateIssue{Field: field, Severity: TemplateIssueError,
+			Message: fmt.Sprintf(".%s is not a template data field (schema v%d)", field, TemplateDataSchemaVersion)}
+	}
+
+	deprecated := DeprecatedTemplateFields[index]
+	replacement := "it has no replacement"
+	if deprecated.Replacement != "" {
+		replacement = "use ." + deprecated.Replacement
+	}
+	if deprecated.RemovedIn > 0 {
+		return &TemplateIssue{Field: field, Severity: TemplateIssueError,
+			Message: fmt.Sprintf(".%s was removed in template data schema v%d - %s", field, deprecated.RemovedIn, replacement)}
+	}
+	return &TemplateIssue{Field: field, Severity: TemplateIssueWarning,
+		Message: fmt.Sprintf(".%s is deprecated since template data schema v%d and will be removed - %s", field, deprecated.DeprecatedIn, replacement)}
+}
+
+// templateFieldRefs returns the template data fields a template references, once each in order of appearance
+// Fields of the dot inside range and with blocks aren't template data fields, only their $-rooted references are
+func templateFieldRefs(tree *parse.Tree) []string {
+	var refs []string
+	add := func(field string) {
+		if !slices.Contains(refs, field) {
+			refs = append(refs, field)
+		}
+	}
+
+	var walk func(node parse.Node, dotIsData bool)
+	walk = func(node parse.Node, dotIsData bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, dotIsData)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, dotIsData)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg, dotIsData)
+				}
+			}
+		case *parse.FieldNode:
+			if dotIsData {
+				add(n.Ident[0])
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				add(n.Ident[1])
+			}
+		case *parse.ChainNode:
+			walk(n.Node, dotIsData)
+		case *parse.IfNode:
+			walk(n.Pipe, dotIsData)
+			walk(n.List, dotIsData)
+			walk(n.ElseList, dotIsData)
+		case *parse.RangeNode:
+			walk(n.Pipe, dotIsData)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsData)
+		case *parse.WithNode:
+			walk(n.Pipe, dotIsData)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsData)
+		case *parse.TemplateNode:
+			walk(n.Pipe, dotIsData)
+		}
+	}
+	walk(tree.Root, true)
+
+	return refs
+}
@@ -0,0 +1,86 @@
+package sync_commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateFieldsMatchCommandTemplateData(t *testing.T) {
+	dataType := reflect.TypeOf(CommandTemplateData{})
+	if dataType.NumField() != len(TemplateFields) {
+		t.Fatalf("CommandTemplateData has %d fields, the schema lists %d - update TemplateFields and TemplateDataSchemaVersion", dataType.NumField(), len(TemplateFields))
+	}
+	for _, field := range TemplateFields {
+		if _, ok := dataType.FieldByName(field.Name); !ok {
+			t.Errorf("schema field %s is not a CommandTemplateData field", field.Name)
+		}
+		if field.Since < 1 || field.Since > TemplateDataSchemaVersion {
+			t.Errorf("schema field %s since v%d, want 1..%d", field.Name, field.Since, TemplateDataSchemaVersion)
+		}
+	}
+}
+
+func TestLintTemplates(t *testing.T) {
+	saved := DeprecatedTemplateFields
+	t.Cleanup(func() { DeprecatedTemplateFields = saved })
+	DeprecatedTemplateFields = []DeprecatedTemplateField{
+		{Name: "Version", Replacement: "VersionTo", DeprecatedIn: 2},
+		{Name: "Cluster", Replacement: "ClusterName", DeprecatedIn: 2, RemovedIn: 3},
+	}
+
+	tests := []struct {
+		name    string
+		command Command
+		want    []TemplateIssue
+	}{
+		{
+			name:    "current fields",
+			command: Command{Cmd: "apt-get", Args: []string{"install", "doublezero={{ .PackageVersionTo }}", "{{ if eq .CommandIndex 0 }}{{ .VersionFrom }}{{ end }}"}},
+		},
+		{
+			name:    "unknown field",
+			command: Command{Cmd: "echo", Args: []string{"{{ .VersionTO }}"}},
+			want:    []TemplateIssue{{Template: "arg[0]", Field: "VersionTO", Severity: TemplateIssueError}},
+		},
+		{
+			name:    "dot inside with is not template data",
+			command: Command{Cmd: "echo", Args: []string{"{{ with .VersionTo }}{{ .Major }}{{ $.Bogus }}{{ end }}"}},
+			want:    []TemplateIssue{{Template: "arg[0]", Field: "Bogus", Severity: TemplateIssueError}},
+		},
+		{
+			name:    "deprecated field",
+			command: Command{Cmd: "echo", Environment: map[string]string{"TO": "{{ .Version }}"}},
+			want:    []TemplateIssue{{Template: "env[TO]", Field: "Version", Severity: TemplateIssueWarning}},
+		},
+		{
+			name:    "removed field in check",
+			command: Command{Cmd: "echo", Check: &Check{FileExists: "/tmp/{{ .Cluster }}"}},
+			want:    []TemplateIssue{{Template: "check.file_exists", Field: "Cluster", Severity: TemplateIssueError}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := tt.command.LintTemplates()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(issues) != len(tt.want) {
+				t.Fatalf("got %d issues %v, want %d", len(issues), issues, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := issues[i]
+				if got.Template != want.Template || got.Field != want.Field || got.Severity != want.Severity {
+					t.Errorf("issue %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownTemplateFields(t *testing.T) {
+	command := Command{Name: "install", Cmd: "apt-get", Args: []string{"install", "doublezero={{ .PackageVersion }}"}}
+	if err := command.Parse(); err == nil {
+		t.Error("expected an error for a template referencing an unknown field")
+	}
+}
@@ -13,6 +13,13 @@ var (
 	sameStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 )
 
+// directions returned by Direction
+const (
+	DirectionUpgrade   = "upgrade"
+	DirectionDowngrade = "downgrade"
+	DirectionNoChange  = "no change"
+)
+
 // VersionDiff represents a version difference
 type VersionDiff struct {
 	From *version.Version
@@ -30,12 +37,12 @@ func (v VersionDiff) IsSameVersion() bool {
 // Direction returns the direction of the version change
 func (v VersionDiff) Direction() string {
 	if v.IsSameVersion() {
-		return "no change"
+		return DirectionNoChange
 	}
 	if v.To.Core().GreaterThan(v.From.Core()) {
-		return "upgrade"
+		return DirectionUpgrade
 	}
-	return "downgrade"
+	return DirectionDowngrade
 }
 
 // DirectionEmoji returns an emoji representing the direction of the version change
@@ -59,3 +66,16 @@ func (v VersionDiff) String() string {
 	}
 	return fmt.Sprintf("%s -> %s", v.From.Core().String(), v.To.Core().String())
 }
+
+// Matches returns true if v is the version described by spec - a spec without a release (e.g. "0.7.2") matches every
+// release of that version, one with a release (e.g. "0.7.2-1") only that release
+func Matches(spec string, v *version.Version) bool {
+	specVersion, err := version.NewVersion(spec)
+	if err != nil || v == nil {
+		return false
+	}
+	if specVersion.Prerelease() == "" {
+		return specVersion.Core().Equal(v.Core())
+	}
+	return specVersion.Equal(v)
+}
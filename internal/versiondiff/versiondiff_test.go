@@ -85,3 +85,26 @@ func TestVersionDiff_Direction(t *testing.T) {
 		})
 	}
 }
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{spec: "0.8.1", version: "0.8.1-1", want: true},
+		{spec: "0.8.1", version: "0.8.1", want: true},
+		{spec: "0.8.1-1", version: "0.8.1-1", want: true},
+		{spec: "0.8.1-1", version: "0.8.1-2", want: false},
+		{spec: "0.8.1", version: "0.8.2-1", want: false},
+		{spec: "not-a-version", version: "0.8.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec+"/"+tt.version, func(t *testing.T) {
+			if got := Matches(tt.spec, version.Must(version.NewVersion(tt.version))); got != tt.want {
+				t.Errorf("Matches(%s, %s) = %v, want %v", tt.spec, tt.version, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package versionserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/versionsource"
+)
+
+// PathPrefix is the path the resolved versions are served under - all clusters at PathPrefix, one at PathPrefix/<cluster>
+const PathPrefix = "/versions"
+
+// Options represents the options for creating a new Server
+type Options struct {
+	// VersionSourceConfig is the version source every cluster is resolved from, its on-disk cache is not used
+	VersionSourceConfig config.VersionSource
+	// Clusters are the clusters resolved, defaults to every valid cluster
+	Clusters []string
+	// Interval is how often the versions are resolved, also the max-age of the served responses
+	Interval time.Duration
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock drives the resolution interval and timestamps, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport for the version sources, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Versions is the document served at PathPrefix
+type Versions struct {
+	// Clusters are the resolved versions by cluster name
+	Clusters map[string]*ClusterVersion `json:"clusters"`
+	// ResolvedAt is when the versions were last resolved
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// ClusterVersion is the recommended version of a cluster, served at PathPrefix/<cluster>
+type ClusterVersion struct {
+	// Version is the core version (e.g. "0.7.1")
+	Version string `json:"version,omitempty"`
+	// PackageVersion is the package version (e.g. "0.7.1-1")
+	PackageVersion string `json:"package_version,omitempty"`
+	// RPMPackageVersion is the RPM package version of the same release, empty when the source doesn't know it
+	RPMPackageVersion string `json:"rpm_package_version,omitempty"`
+	// Source is the name of the version source that supplied the version
+	Source string `json:"source,omitempty"`
+	// FetchedAt is when the version was fetched
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+	// PublishedAt is when the package was published, zero when the source doesn't know
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	// Error is the last resolution error - the last successfully resolved version keeps being served alongside it
+	Error string `json:"error,omitempty"`
+}
+
+// Server resolves the recommended versions of every cluster on an interval and serves them as JSON
+type Server struct {
+	logger   *log.Logger
+	clock    clock.Clock
+	interval time.Duration
+	clusters []string
+	sources  map[string]versionsource.VersionSource
+
+	mu       sync.RWMutex
+	versions Versions
+}
+
+// New creates a new Server with the given options
+func New(opts Options) (*Server, error) {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if len(opts.Clusters) == 0 {
+		opts.Clusters = constants.ValidClusterNames
+	}
+
+	// the served document is the cache, sharing the single-cluster cache file would mix clusters up
+	sourceConfig := opts.VersionSourceConfig
+	sourceConfig.Cache = config.VersionSourceCache{}
+
+	s := &Server{
+		logger:   logging.WithPrefix(opts.Logger, "versionserver"),
+		clock:    opts.Clock,
+		interval: opts.Interval,
+		clusters: opts.Clusters,
+		sources:  map[string]versionsource.VersionSource{},
+		versions: Versions{Clusters: map[string]*ClusterVersion{}},
+	}
+
+	for _, cluster := range opts.Clusters {
+		if err := constants.ValidateClusterName(cluster); err != nil {
+			return nil, err
+		}
+		source, err := versionsource.NewFromConfig(cluster, sourceConfig, versionsource.Options{
+			Logger:    opts.Logger,
+			Clock:     opts.Clock,
+			Transport: opts.Transport,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create version source for %s: %w", cluster, err)
+		}
+		s.sources[cluster] = source
+		s.versions.Clusters[cluster] = &ClusterVersion{}
+	}
+
+	return s, nil
+}
+
+// Run resolves the versions on the server's interval until ctx is done, the first resolution is made immediately
+func (s *Server) Run(ctx context.Context) {
+	s.logger.Info("🛰️  starting version server", "interval", s.interval.String(), "clusters", strings.Join(s.clusters, ","))
+	for {
+		s.Resolve(ctx)
+		if err := clock.SleepContext(ctx, s.clock, s.interval); err != nil {
+			return
+		}
+	}
+}
+
+// Resolve fetches the recommended version of every cluster - a cluster that fails keeps its last resolved version
+func (s *Server) Resolve(ctx context.Context) {
+	resolved := map[string]*ClusterVersion{}
+	for _, cluster := range s.clusters {
+		s.mu.RLock()
+		clusterVersion := *s.versions.Clusters[cluster]
+		s.mu.RUnlock()
+
+		recommendation, err := s.sources[cluster].GetRecommendation(ctx)
+		if err != nil {
+			s.logger.Warn("failed to resolve recommended version - serving the last resolved version", "cluster", cluster, "last_version", clusterVersion.PackageVersion, "error", err)
+			clusterVersion.Error = err.Error()
+		} else {
+			clusterVersion = ClusterVersion{
+				Version:           recommendation.Version.Core().String(),
+				PackageVersion:    recommendation.PackageVersion,
+				RPMPackageVersion: recommendation.RPMPackageVersion,
+				Source:            recommendation.Source,
+				FetchedAt:         recommendation.FetchedAt,
+				PublishedAt:       recommendation.PublishedAt,
+			}
+		}
+		resolved[cluster] = &clusterVersion
+	}
+
+	s.mu.Lock()
+	s.versions = Versions{Clusters: resolved, ResolvedAt: s.clock.Now().UTC()}
+	s.mu.Unlock()
+}
+
+// Handler returns an http.Handler serving every cluster's version at PathPrefix and one cluster's at
+// PathPrefix/<cluster>, with caching headers expiring at the next resolution
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.mu.RLock()
+		versions := s.versions
+		s.mu.RUnlock()
+
+		var document any = versions
+		switch cluster := strings.Trim(strings.TrimPrefix(r.URL.Path, PathPrefix), "/"); {
+		case versions.ResolvedAt.IsZero():
+			http.Error(w, "versions not resolved yet", http.StatusServiceUnavailable)
+			return
+		case cluster == "":
+		case versions.Clusters[cluster] == nil:
+			http.Error(w, fmt.Sprintf("unknown cluster %s - must be one of %s", cluster, strings.Join(s.clusters, ", ")), http.StatusNotFound)
+			return
+		case versions.Clusters[cluster].PackageVersion == "":
+			http.Error(w, fmt.Sprintf("no version resolved for %s yet: %s", cluster, versions.Clusters[cluster].Error), http.StatusServiceUnavailable)
+			return
+		default:
+			document = versions.Clusters[cluster]
+		}
+
+		body, err := json.Marshal(document)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+		// clients may reuse the response until the next resolution is due
+		maxAge := versions.ResolvedAt.Add(s.interval).Sub(s.clock.Now())
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", versions.ResolvedAt.Format(http.TimeFormat))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = w.Write(append(body, '\n'))
+	})
+}
@@ -0,0 +1,101 @@
+package versionserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestHandler(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	s, err := New(Options{
+		VersionSourceConfig: config.VersionSource{Type: constants.VersionSourceTypeStatic, Version: "0.7.1-1"},
+		Interval:            5 * time.Minute,
+		Clock:               fakeClock,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(PathPrefix, ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before resolving: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	s.Resolve(context.Background())
+	fakeClock.Advance(time.Minute)
+
+	rec := get(PathPrefix, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var versions Versions
+	if err := json.Unmarshal(rec.Body.Bytes(), &versions); err != nil {
+		t.Fatalf("failed to decode versions: %v", err)
+	}
+	for _, cluster := range constants.ValidClusterNames {
+		got := versions.Clusters[cluster]
+		if got == nil || got.Version != "0.7.1" || got.PackageVersion != "0.7.1-1" {
+			t.Errorf("clusters[%s] = %+v, want version 0.7.1 package 0.7.1-1", cluster, got)
+		}
+	}
+	if got, want := rec.Header().Get("Cache-Control"), "public, max-age=240"; got != want {
+		t.Errorf("Cache-Control = %q, want %q", got, want)
+	}
+
+	etag := rec.Header().Get("ETag")
+	if rec := get(PathPrefix, etag); rec.Code != http.StatusNotModified {
+		t.Errorf("matching If-None-Match: status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	rec = get(PathPrefix+"/"+constants.ClusterNameTestnet, "")
+	var clusterVersion ClusterVersion
+	if err := json.Unmarshal(rec.Body.Bytes(), &clusterVersion); err != nil {
+		t.Fatalf("failed to decode cluster version: %v", err)
+	}
+	if clusterVersion.PackageVersion != "0.7.1-1" {
+		t.Errorf("testnet package_version = %q, want 0.7.1-1", clusterVersion.PackageVersion)
+	}
+
+	if rec := get(PathPrefix+"/devnet", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown cluster: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestResolveKeepsLastVersionOnError(t *testing.T) {
+	t.Setenv("DZ_TEST_VERSION", "0.7.1-1")
+	s, err := New(Options{
+		VersionSourceConfig: config.VersionSource{Type: constants.VersionSourceTypeStatic, VersionEnv: "DZ_TEST_VERSION"},
+		Clusters:            []string{constants.ClusterNameMainnetBeta},
+		Interval:            time.Minute,
+		Clock:               clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.Resolve(context.Background())
+	t.Setenv("DZ_TEST_VERSION", "")
+	s.Resolve(context.Background())
+
+	got := s.versions.Clusters[constants.ClusterNameMainnetBeta]
+	if got.PackageVersion != "0.7.1-1" || got.Error == "" {
+		t.Errorf("after a failed resolution got %+v, want the last version 0.7.1-1 with the error", got)
+	}
+}
@@ -0,0 +1,177 @@
+package versionsource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// CachedSource wraps a version source with an on-disk cache of its last recommendation
+// A cached recommendation younger than the TTL is returned without fetching from the wrapped source, an expired one
+// younger than the max stale age is returned when fetching from the wrapped source fails
+type CachedSource struct {
+	source   VersionSource
+	cluster  string
+	key      string
+	path     string
+	ttl      time.Duration
+	maxStale time.Duration
+	logger   *log.Logger
+	clock    clock.Clock
+}
+
+// cacheEntry is the on-disk format of the version source cache
+type cacheEntry struct {
+	Cluster        string          `json:"cluster"`
+	Source         string          `json:"source"`
+	Key            string          `json:"key"`
+	Recommendation *Recommendation `json:"recommendation"`
+}
+
+// NewCached creates a new cache around the given version source, stored in the cache file. A cache written with a
+// different key, e.g. for another base URL or package name, doesn't apply
+func NewCached(source VersionSource, cluster, key string, cache config.VersionSourceCache, opts Options) *CachedSource {
+	opts = opts.withDefaults()
+	return &CachedSource{
+		source:   source,
+		cluster:  cluster,
+		key:      key,
+		path:     cache.File,
+		ttl:      cache.TTL,
+		maxStale: cache.MaxStale,
+		logger:   logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:    opts.Clock,
+	}
+}
+
+// cacheKey returns the cache key of a version source configuration - a digest of every setting but the cache's own,
+// so changing where or what the recommendation is fetched from invalidates the cache
+func cacheKey(cfg config.VersionSource) string {
+	cfg.Cache = config.VersionSourceCache{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+// Name returns the wrapped version source type name
+func (c *CachedSource) Name() string {
+	return c.source.Name()
+}
+
+// GetRecommendation returns the cached recommendation when it is fresh, otherwise fetches it live and caches it. When
+// the live fetch fails an expired recommendation within the max stale age is returned instead
+func (c *CachedSource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	cached, err := c.load()
+	if err != nil {
+		c.logger.Warn("ignoring unreadable version source cache", "path", c.path, "error", err)
+	}
+
+	if cached != nil {
+		age := c.clock.Now().Sub(cached.FetchedAt)
+		if age < c.ttl {
+			c.logger.Info("recommended version from cache", "version", cached.Version.String(), "source", cached.Source, "age", age.Round(time.Second), "ttl", c.ttl)
+			cached.FromCache = true
+			return cached, nil
+		}
+		c.logger.Debug("version source cache expired", "age", age.Round(time.Second), "ttl", c.ttl)
+	}
+
+	recommendation, err := c.source.GetRecommendation(ctx)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		age := c.clock.Now().Sub(cached.FetchedAt)
+		if age >= c.maxStale {
+			return nil, fmt.Errorf("%w - cached recommendation %s is %s old, past version_source.cache.max_stale (%s)",
+				err, cached.PackageVersion, age.Round(time.Second), c.maxStale)
+		}
+		c.logger.Warn("live fetch failed - using stale recommended version from cache", "version", cached.Version.String(),
+			"source", cached.Source, "age", age.Round(time.Second), "max_stale", c.maxStale, "error", err)
+		cached.FromCache = true
+		return cached, nil
+	}
+	c.logger.Info("recommended version from live fetch", "version", recommendation.Version.String(), "source", recommendation.Source)
+
+	if err := c.save(recommendation); err != nil {
+		c.logger.Warn("failed to write version source cache", "path", c.path, "error", err)
+	}
+
+	return recommendation, nil
+}
+
+// load reads the cached recommendation, returning nil when there is none for this cluster and source
+func (c *CachedSource) load() (*Recommendation, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", c.path, err)
+	}
+
+	// a cache written for a different cluster, source or source settings doesn't apply
+	if entry.Recommendation == nil || entry.Cluster != c.cluster || entry.Source != c.source.Name() || entry.Key != c.key {
+		return nil, nil
+	}
+
+	entry.Recommendation.Version, err = version.NewVersion(entry.Recommendation.PackageVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cached version %s: %w", entry.Recommendation.PackageVersion, err)
+	}
+
+	return entry.Recommendation, nil
+}
+
+// save atomically writes the recommendation to the cache file
+func (c *CachedSource) save(recommendation *Recommendation) error {
+	data, err := json.MarshalIndent(cacheEntry{
+		Cluster:        c.cluster,
+		Source:         c.source.Name(),
+		Key:            c.key,
+		Recommendation: recommendation,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
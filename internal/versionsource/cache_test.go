@@ -0,0 +1,124 @@
+package versionsource
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+func TestCachedSource_ServesFreshCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	live := &fakeSource{name: "live", version: "0.7.1-1", clock: fakeClock}
+	opts := Options{Clock: fakeClock}
+
+	if _, err := NewCached(live, "testnet", "", config.VersionSourceCache{File: path, TTL: time.Hour}, opts).GetRecommendation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the live source being down must not matter while the cache is fresh
+	fakeClock.Advance(59 * time.Minute)
+	live.err = errors.New("down")
+	r, err := NewCached(live, "testnet", "", config.VersionSourceCache{File: path, TTL: time.Hour}, opts).GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1", r.Version.Original())
+	}
+	if live.calls != 1 {
+		t.Errorf("expected 1 live fetch, got %d", live.calls)
+	}
+}
+
+func TestCachedSource_RefetchesWhenStaleOrOtherCluster(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	live := &fakeSource{name: "live", version: "0.7.1-1", clock: fakeClock}
+	opts := Options{Clock: fakeClock}
+
+	if _, err := NewCached(live, "testnet", "", config.VersionSourceCache{File: path, TTL: time.Hour}, opts).GetRecommendation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewCached(live, "mainnet-beta", "", config.VersionSourceCache{File: path, TTL: time.Hour}, opts).GetRecommendation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fakeClock.Advance(time.Hour)
+	if _, err := NewCached(live, "mainnet-beta", "", config.VersionSourceCache{File: path, TTL: time.Hour}, opts).GetRecommendation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if live.calls != 3 {
+		t.Errorf("expected 3 live fetches, got %d", live.calls)
+	}
+}
+
+func TestCachedSource_FallsBackToStaleCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		age     time.Duration
+		wantErr bool
+	}{
+		{name: "expired within max_stale", age: 5 * time.Hour},
+		{name: "past max_stale", age: 25 * time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.json")
+			fakeClock := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			live := &fakeSource{name: "live", version: "0.7.1-1", clock: fakeClock}
+			cache := config.VersionSourceCache{File: path, TTL: time.Hour, MaxStale: 24 * time.Hour}
+			if _, err := NewCached(live, "testnet", "key", cache, Options{Clock: fakeClock}).GetRecommendation(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// an outage outlasting the TTL is bridged by the expired recommendation until max_stale
+			fakeClock.Advance(tt.age)
+			live.err = errors.New("down")
+			r, err := NewCached(live, "testnet", "key", cache, Options{Clock: fakeClock}).GetRecommendation(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRecommendation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (r.PackageVersion != "0.7.1-1" || !r.FromCache) {
+				t.Errorf("got %+v, want 0.7.1-1 from the cache", r)
+			}
+			if live.calls != 2 {
+				t.Errorf("expected 2 live fetches, got %d", live.calls)
+			}
+		})
+	}
+}
+
+func TestCachedSource_KeyedBySourceSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	live := &fakeSource{name: "live", version: "0.7.1-1", clock: fakeClock}
+	cache := config.VersionSourceCache{File: path, TTL: time.Hour, MaxStale: 24 * time.Hour}
+
+	mirror := config.NewVersionSource(constants.VersionSourceTypeCloudsmithIndex)
+	mirror.BaseURL = "https://mirror.example.com"
+	if cacheKey(mirror) == cacheKey(config.NewVersionSource(constants.VersionSourceTypeCloudsmithIndex)) {
+		t.Fatalf("cacheKey() is the same for different base URLs")
+	}
+	withCache := mirror
+	withCache.Cache = cache
+	if cacheKey(withCache) != cacheKey(mirror) {
+		t.Errorf("cacheKey() changed with the cache settings")
+	}
+
+	if _, err := NewCached(live, "testnet", cacheKey(mirror), cache, Options{Clock: fakeClock}).GetRecommendation(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a cache written for another base URL is neither served fresh nor as a stale fallback
+	live.err = errors.New("down")
+	if _, err := NewCached(live, "testnet", cacheKey(config.NewVersionSource(constants.VersionSourceTypeCloudsmithIndex)), cache, Options{Clock: fakeClock}).GetRecommendation(context.Background()); err == nil {
+		t.Errorf("expected the live error for a cache written with other source settings")
+	}
+}
@@ -0,0 +1,56 @@
+package versionsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// ChainSource tries an ordered list of version sources, returning the first successful result
+type ChainSource struct {
+	sources []VersionSource
+	logger  *log.Logger
+}
+
+// NewChain creates a new fallback chain over the given version sources
+func NewChain(opts Options, sources ...VersionSource) *ChainSource {
+	opts = opts.withDefaults()
+	return &ChainSource{
+		sources: sources,
+		logger:  logging.WithPrefix(opts.Logger, "versionsource"),
+	}
+}
+
+// Name returns the version source type name
+func (c *ChainSource) Name() string {
+	return "chain"
+}
+
+// GetRecommendation returns the recommendation from the first source in the chain that succeeds
+func (c *ChainSource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	var (
+		errs          []error
+		failedSources []FailedSource
+	)
+	for i, source := range c.sources {
+		recommendation, err := source.GetRecommendation(ctx)
+		if err != nil && ctx.Err() != nil {
+			return nil, err
+		}
+		if err != nil {
+			c.logger.Warn("version source failed - trying next source", "source", source.Name(), "position", i+1, "of", len(c.sources), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			failedSources = append(failedSources, FailedSource{Source: source.Name(), Error: err.Error()})
+			continue
+		}
+
+		c.logger.Info("recommended version supplied by source", "source", source.Name(), "position", i+1, "of", len(c.sources), "version", recommendation.Version.String())
+		recommendation.FailedSources = failedSources
+		return recommendation, nil
+	}
+
+	return nil, fmt.Errorf("all version sources failed: %w", errors.Join(errs...))
+}
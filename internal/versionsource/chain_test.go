@@ -0,0 +1,61 @@
+package versionsource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+)
+
+// fakeSource is a VersionSource returning a fixed version or error and counting calls.
+// Recommendations are timestamped with clock when set, otherwise the system time.
+type fakeSource struct {
+	name    string
+	version string
+	err     error
+	calls   int
+	clock   clock.Clock
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) GetRecommendation(context.Context) (*Recommendation, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	now := time.Now()
+	if f.clock != nil {
+		now = f.clock.Now()
+	}
+	return newRecommendation(f.name, f.version, "", "", now)
+}
+
+func TestChainSource_FallsBackToNextSource(t *testing.T) {
+	first := &fakeSource{name: "first", err: errors.New("down")}
+	second := &fakeSource{name: "second", version: "0.7.1-1"}
+	third := &fakeSource{name: "third", version: "0.7.2-1"}
+
+	r, err := NewChain(Options{}, first, second, third).GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Version.Original() != "0.7.1-1" || r.Source != "second" {
+		t.Errorf("got %s from %s, want 0.7.1-1 from second", r.Version.Original(), r.Source)
+	}
+	if third.calls != 0 {
+		t.Errorf("expected sources after the first success not to be called, got %d calls", third.calls)
+	}
+}
+
+func TestChainSource_ErrorWhenAllSourcesFail(t *testing.T) {
+	chain := NewChain(Options{},
+		&fakeSource{name: "first", err: errors.New("down")},
+		&fakeSource{name: "second", err: errors.New("unparseable")},
+	)
+	if _, err := chain.GetRecommendation(context.Background()); err == nil {
+		t.Fatal("expected error when all sources fail, got nil")
+	}
+}
@@ -0,0 +1,255 @@
+package versionsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+const (
+	// Cloudsmith API base URL
+	cloudsmithAPIBaseURL = "https://api.cloudsmith.io/packages/malbeclabs"
+	// defaultPackageName is the package name to look for
+	defaultPackageName = "doublezero"
+)
+
+// cloudsmithRepoNames maps cluster names to their Cloudsmith repository names
+var cloudsmithRepoNames = map[string]string{
+	constants.ClusterNameMainnetBeta: "doublezero",
+	constants.ClusterNameTestnet:     "doublezero-testnet",
+}
+
+// cloudsmithPackage represents the relevant fields from the Cloudsmith API response
+type cloudsmithPackage struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Release    string `json:"release"`
+	Format     string `json:"format"`
+	StatusStr  string `json:"status_str"`
+	UploadedAt string `json:"uploaded_at"`
+}
+
+// CloudsmithAPISource is a version source that queries the Cloudsmith packages API
+type CloudsmithAPISource struct {
+	cluster     string
+	packageName string
+	logger      *log.Logger
+	clock       clock.Clock
+	client      *http.Client
+	baseURL     string // defaults to cloudsmithAPIBaseURL, overridable to point at a mirror or proxy
+}
+
+// NewCloudsmithAPI creates a new Cloudsmith packages API version source
+func NewCloudsmithAPI(cluster string, opts Options) *CloudsmithAPISource {
+	opts = opts.withDefaults()
+	s := &CloudsmithAPISource{
+		cluster:     strings.ToLower(cluster),
+		packageName: defaultPackageName,
+		logger:      logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:       opts.Clock,
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: opts.Transport},
+	}
+
+	s.logger.Debug("initialized version source", "cluster", s.cluster)
+	return s
+}
+
+// Name returns the version source type name
+func (s *CloudsmithAPISource) Name() string {
+	return constants.VersionSourceTypeCloudsmithAPI
+}
+
+// GetRecommendation gets the recommended DoubleZero version for the cluster
+// Fetches from the Cloudsmith API and returns the latest version
+func (s *CloudsmithAPISource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	packageVersion, rpmPackageVersion, evidence, apiURL, err := s.fetchLatestVersionFromCloudsmith(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendation, err := newRecommendation(s.Name(), packageVersion, apiURL, evidence, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	recommendation.RPMPackageVersion = rpmPackageVersion
+	recommendation.PublishedAt = uploadedAt(s.logger, evidence)
+
+	s.logger.Info("recommended version", "cluster", s.cluster, "version", recommendation.Version.String(), "source", s.Name())
+	return recommendation, nil
+}
+
+// fetchLatestVersionFromCloudsmith fetches the latest doublezero package version from Cloudsmith API
+// Returns the deb package version, the RPM package version of the same release if one is published (empty otherwise),
+// the raw API object the deb version was read from, and the API URL
+func (s *CloudsmithAPISource) fetchLatestVersionFromCloudsmith(ctx context.Context) (packageVersion, rpmPackageVersion, evidence, apiURL string, err error) {
+	repoName, ok := cloudsmithRepoNames[s.cluster]
+	if !ok {
+		return "", "", "", "", fmt.Errorf("unknown cluster: %s", s.cluster)
+	}
+
+	// Build the API URL with query parameters
+	// Use ^doublezero$ to match exactly the package name (not doublezero-sentinel, etc.)
+	// Note: Packages are uploaded as "any-distro" so we only filter by name - deb and rpm are split below
+	baseURL := s.baseURL
+	if baseURL == "" {
+		baseURL = cloudsmithAPIBaseURL
+	}
+
+	query := fmt.Sprintf("name:^%s$", s.packageName)
+	apiURL = fmt.Sprintf("%s/%s/?query=%s", baseURL, repoName, url.QueryEscape(query))
+
+	ctx, cancel := context.WithTimeout(ctx, s.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "doublezero-version-sync/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to fetch from Cloudsmith API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", "", "", fmt.Errorf("Cloudsmith API returned status %d for %s", resp.StatusCode, apiURL)
+	}
+
+	// Parse the JSON response, keeping each raw package object as evidence
+	var rawPackages []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&rawPackages); err != nil {
+		return "", "", "", "", fmt.Errorf("failed to parse Cloudsmith API response: %w", err)
+	}
+
+	// Filter for completed deb and rpm packages with the correct name
+	var debVersions, rpmVersions []string
+	evidenceByVersion := make(map[string]string)
+	for _, rawPackage := range rawPackages {
+		var pkg cloudsmithPackage
+		if err := json.Unmarshal(rawPackage, &pkg); err != nil {
+			return "", "", "", "", fmt.Errorf("failed to parse Cloudsmith API package: %w", err)
+		}
+		if pkg.Name != s.packageName || pkg.StatusStr != "Completed" {
+			continue
+		}
+		switch pkg.Format {
+		case constants.PackageFormatDeb:
+			debVersions = append(debVersions, pkg.Version)
+			evidenceByVersion[pkg.Version] = string(rawPackage)
+		case constants.PackageFormatRPM:
+			rpmVersions = append(rpmVersions, pkg.rpmVersion())
+		}
+	}
+
+	if len(debVersions) == 0 {
+		return "", "", "", "", fmt.Errorf("no completed deb packages found for %s in cluster %s", s.packageName, s.cluster)
+	}
+
+	// Sort versions and return the latest
+	latestVersion := findLatestVersion(s.logger, debVersions)
+	rpmPackageVersion = findMatchingRPMVersion(s.logger, latestVersion, rpmVersions)
+	s.logger.Debug("found latest version from Cloudsmith API", "cluster", s.cluster, "version", latestVersion,
+		"rpm_version", rpmPackageVersion, "totalVersions", len(debVersions))
+
+	return latestVersion, rpmPackageVersion, evidenceByVersion[latestVersion], apiURL, nil
+}
+
+// rpmVersion returns the version-release of an RPM package, the API may report the release separately
+func (p cloudsmithPackage) rpmVersion() string {
+	if p.Release == "" || strings.HasSuffix(p.Version, "-"+p.Release) {
+		return p.Version
+	}
+	return p.Version + "-" + p.Release
+}
+
+// uploadedAt returns the upload time of a raw Cloudsmith API package object, zero when missing or unparseable
+func uploadedAt(logger *log.Logger, rawPackage string) time.Time {
+	var pkg cloudsmithPackage
+	if err := json.Unmarshal([]byte(rawPackage), &pkg); err != nil || pkg.UploadedAt == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, pkg.UploadedAt)
+	if err != nil {
+		logger.Debug("skipping unparseable package upload time", "uploaded_at", pkg.UploadedAt, "error", err)
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// findMatchingRPMVersion returns the latest of the RPM versions with the same core version as the given deb version,
+// or an empty string when no RPM of that release is published
+func findMatchingRPMVersion(logger *log.Logger, debVersion string, rpmVersions []string) string {
+	target, err := version.NewVersion(debVersion)
+	if err != nil {
+		return ""
+	}
+
+	var matching []string
+	for _, rpmVersion := range rpmVersions {
+		v, err := version.NewVersion(rpmVersion)
+		if err != nil {
+			logger.Debug("skipping unparseable rpm version", "version", rpmVersion, "error", err)
+			continue
+		}
+		if v.Core().Equal(target.Core()) {
+			matching = append(matching, rpmVersion)
+		}
+	}
+
+	if len(matching) == 0 {
+		return ""
+	}
+	return findLatestVersion(logger, matching)
+}
+
+// findLatestVersion finds the latest version from a list of version strings
+// Uses semantic versioning comparison via hashicorp/go-version
+func findLatestVersion(logger *log.Logger, versionStrings []string) string {
+	if len(versionStrings) == 0 {
+		return ""
+	}
+
+	type versionEntry struct {
+		original string
+		parsed   *version.Version
+	}
+
+	var entries []versionEntry
+	for _, vs := range versionStrings {
+		v, err := version.NewVersion(vs)
+		if err != nil {
+			logger.Debug("skipping unparseable version", "version", vs, "error", err)
+			continue
+		}
+		entries = append(entries, versionEntry{original: vs, parsed: v})
+	}
+
+	if len(entries) == 0 {
+		// Fallback: return the last one in the list if none could be parsed
+		return versionStrings[len(versionStrings)-1]
+	}
+
+	// Sort by parsed version
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].parsed.LessThan(entries[j].parsed)
+	})
+
+	// Return the original string of the latest version
+	return entries[len(entries)-1].original
+}
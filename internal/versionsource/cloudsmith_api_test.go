@@ -0,0 +1,282 @@
+package versionsource
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// newTestSource creates a CloudsmithAPISource pointed at the given test server URL.
+func newTestSource(serverURL, cluster string) *CloudsmithAPISource {
+	s := NewCloudsmithAPI(cluster, Options{})
+	s.baseURL = serverURL
+	return s
+}
+
+// makeHandler returns an HTTP handler that serves the given packages list as JSON.
+func makeHandler(packages []cloudsmithPackage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(packages)
+	}
+}
+
+func TestGetRecommendedVersion_ReturnsLatest(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.7.0-1", Format: "deb", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.6.9-1", Format: "deb", StatusStr: "Completed"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	src := newTestSource(srv.URL, "mainnet-beta")
+	v, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1", v.Version.Original())
+	}
+}
+
+func TestGetRecommendedVersion_FetchesOnEveryCall(t *testing.T) {
+	// First call returns 0.7.1-1, second call returns 0.7.2-1.
+	// If the old cache were still in place the second call would return 0.7.1-1.
+	responses := [][]cloudsmithPackage{
+		{{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"}},
+		{{Name: "doublezero", Version: "0.7.2-1", Format: "deb", StatusStr: "Completed"}},
+	}
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idx := int(callCount.Add(1)) - 1
+		if idx >= len(responses) {
+			idx = len(responses) - 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(responses[idx])
+	}))
+	defer srv.Close()
+
+	src := newTestSource(srv.URL, "mainnet-beta")
+
+	v1, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("first call error: %v", err)
+	}
+	v2, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("second call error: %v", err)
+	}
+
+	if v1.Version.Original() != "0.7.1-1" {
+		t.Errorf("first call: got %s, want 0.7.1-1", v1.Version.Original())
+	}
+	if v2.Version.Original() != "0.7.2-1" {
+		t.Errorf("second call: got %s, want 0.7.2-1 (stale cache would return 0.7.1-1)", v2.Version.Original())
+	}
+	if callCount.Load() != 2 {
+		t.Errorf("expected 2 API calls, got %d", callCount.Load())
+	}
+}
+
+func TestGetRecommendedVersion_IgnoresNonCompletedPackages(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.8.0-1", Format: "deb", StatusStr: "Uploading"},
+		{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	src := newTestSource(srv.URL, "mainnet-beta")
+	v, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1 (non-completed package should be ignored)", v.Version.Original())
+	}
+}
+
+func TestGetRecommendedVersion_ErrorOnNoCompletedPackages(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Uploading"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	src := newTestSource(srv.URL, "mainnet-beta")
+	_, err := src.GetRecommendation(context.Background())
+	if err == nil {
+		t.Fatal("expected error when no completed packages, got nil")
+	}
+}
+
+func TestGetRecommendedVersion_ErrorOnNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	src := newTestSource(srv.URL, "mainnet-beta")
+	_, err := src.GetRecommendation(context.Background())
+	if err == nil {
+		t.Fatal("expected error on non-200 response, got nil")
+	}
+}
+
+func TestGetRecommendedVersion_ErrorOnUnknownCluster(t *testing.T) {
+	src := NewCloudsmithAPI("unknown-cluster", Options{})
+	_, err := src.GetRecommendation(context.Background())
+	if err == nil {
+		t.Fatal("expected error for unknown cluster, got nil")
+	}
+}
+
+func TestGetRecommendedVersion_TestnetUsesTestnetRepo(t *testing.T) {
+	var requestPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		packages := []cloudsmithPackage{
+			{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(packages)
+	}))
+	defer srv.Close()
+
+	src := newTestSource(srv.URL, "testnet")
+	_, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestPath != "/doublezero-testnet/" {
+		t.Errorf("got path %s, want /doublezero-testnet/", requestPath)
+	}
+}
+
+func TestNewFromConfig_CustomBaseURLAndPackageName(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.7.2-1", Format: "deb", StatusStr: "Completed"},
+		{Name: "doublezero-mirror", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	src, err := NewFromConfig("mainnet-beta", config.VersionSource{
+		Type:        constants.VersionSourceTypeCloudsmithAPI,
+		Format:      constants.PackageFormatDeb,
+		Timeout:     time.Second,
+		BaseURL:     srv.URL,
+		PackageName: "doublezero-mirror",
+	}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1", v.Version.Original())
+	}
+}
+
+// roundTripFunc is an http.RoundTripper backed by a function, used to serve recorded responses.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestGetRecommendedVersion_InjectedTransportAndClock(t *testing.T) {
+	fetchedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		recorded := `[{"name":"doublezero","version":"0.7.1-1","format":"deb","status_str":"Completed"}]`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(recorded)),
+			Request:    r,
+		}, nil
+	})
+
+	src := NewCloudsmithAPI("testnet", Options{Transport: transport, Clock: clock.NewFake(fetchedAt)})
+	r, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1", r.Version.Original())
+	}
+	if !r.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("FetchedAt = %s, want %s", r.FetchedAt, fetchedAt)
+	}
+}
+
+func TestGetRecommendedVersion_ResolvesMatchingRPMRelease(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.7.1", Release: "2", Format: "rpm", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.7.1-1", Format: "rpm", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.7.2-1", Format: "rpm", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.7.0-1", Format: "deb", StatusStr: "Completed"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	r, err := newTestSource(srv.URL, "mainnet-beta").GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.PackageVersion != "0.7.1-1" {
+		t.Errorf("PackageVersion = %s, want 0.7.1-1", r.PackageVersion)
+	}
+	// the rpm must be the same release as the deb, not the newer 0.7.2 rpm
+	if r.RPMPackageVersion != "0.7.1-2" {
+		t.Errorf("RPMPackageVersion = %s, want 0.7.1-2", r.RPMPackageVersion)
+	}
+}
+
+func TestGetRecommendedVersion_NoMatchingRPMRelease(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed"},
+		{Name: "doublezero", Version: "0.7.0-1", Format: "rpm", StatusStr: "Completed"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	r, err := newTestSource(srv.URL, "mainnet-beta").GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.RPMPackageVersion != "" {
+		t.Errorf("RPMPackageVersion = %s, want empty", r.RPMPackageVersion)
+	}
+}
+
+func TestGetRecommendedVersion_PublishedAt(t *testing.T) {
+	packages := []cloudsmithPackage{
+		{Name: "doublezero", Version: "0.7.1-1", Format: "deb", StatusStr: "Completed", UploadedAt: "2025-01-05T02:00:00.611651Z"},
+		{Name: "doublezero", Version: "0.7.0-1", Format: "deb", StatusStr: "Completed", UploadedAt: "2024-12-01T00:00:00Z"},
+	}
+	srv := httptest.NewServer(makeHandler(packages))
+	defer srv.Close()
+
+	r, err := newTestSource(srv.URL, "mainnet-beta").GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2025, 1, 5, 2, 0, 0, 611651000, time.UTC); !r.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %s, want %s", r.PublishedAt, want)
+	}
+}
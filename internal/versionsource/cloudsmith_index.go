@@ -0,0 +1,369 @@
+package versionsource
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+const (
+	// cloudsmithDownloadBaseURL is the Cloudsmith public package repository base URL
+	cloudsmithDownloadBaseURL = "https://dl.cloudsmith.io/public/malbeclabs"
+)
+
+// ErrNotPublished is returned by CheckPublished when the repository index was read but doesn't list the package version
+var ErrNotPublished = errors.New("not published")
+
+// CloudsmithIndexSource is a version source that reads the Cloudsmith Debian/RPM repository metadata directly
+type CloudsmithIndexSource struct {
+	cluster     string
+	format      string
+	target      Target
+	packageName string
+	logger      *log.Logger
+	clock       clock.Clock
+	client      *http.Client
+	baseURL     string // defaults to cloudsmithDownloadBaseURL, overridable to point at a mirror or proxy
+}
+
+// repomd is the relevant subset of an RPM repodata/repomd.xml document
+type repomd struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// rpmPrimary is the relevant subset of an RPM primary.xml document
+type rpmPrimary struct {
+	Packages []struct {
+		Raw     string `xml:",innerxml"`
+		Name    string `xml:"name"`
+		Arch    string `xml:"arch"`
+		Version struct {
+			Ver string `xml:"ver,attr"`
+			Rel string `xml:"rel,attr"`
+		} `xml:"version"`
+		Time struct {
+			File int64 `xml:"file,attr"`
+		} `xml:"time"`
+	} `xml:"package"`
+}
+
+// indexEntry is a doublezero package version found in a repository index with the raw entry it was read from
+type indexEntry struct {
+	version  string
+	evidence string
+	url      string
+	// publishedAt is when the package was added to the repository, zero when the index doesn't record it
+	publishedAt time.Time
+}
+
+// NewCloudsmithIndex creates a new Cloudsmith repository index version source for the given package format (deb or rpm)
+func NewCloudsmithIndex(cluster, format string, opts Options) *CloudsmithIndexSource {
+	opts = opts.withDefaults()
+	s := &CloudsmithIndexSource{
+		cluster:     strings.ToLower(cluster),
+		format:      format,
+		packageName: defaultPackageName,
+		logger:      logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:       opts.Clock,
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: opts.Transport},
+	}
+
+	s.target = Target{Distro: constants.PackageDistroAny, DistroVersion: constants.PackageDistroVersionAny, Arch: "amd64"}
+	if format == constants.PackageFormatRPM {
+		s.target.Arch = "x86_64"
+	}
+
+	s.logger.Debug("initialized repository index version source", "cluster", s.cluster, "format", s.format)
+	return s
+}
+
+// Name returns the version source type name
+func (s *CloudsmithIndexSource) Name() string {
+	return constants.VersionSourceTypeCloudsmithIndex
+}
+
+// GetRecommendation gets the latest DoubleZero package version published in the cluster's package repository
+func (s *CloudsmithIndexSource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	entries, err := s.fetchEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no %s packages found for %s in cluster %s repository index for %s/%s/%s",
+			s.format, s.packageName, s.cluster, s.target.Distro, s.target.DistroVersion, s.target.Arch)
+	}
+
+	versions := make([]string, 0, len(entries))
+	entriesByVersion := make(map[string]indexEntry, len(entries))
+	for _, entry := range entries {
+		versions = append(versions, entry.version)
+		entriesByVersion[entry.version] = entry
+	}
+
+	latest := entriesByVersion[findLatestVersion(s.logger, versions)]
+	recommendation, err := newRecommendation(s.Name(), latest.version, latest.url, latest.evidence, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if s.format == constants.PackageFormatRPM {
+		recommendation.RPMPackageVersion = latest.version
+	}
+	recommendation.PublishedAt = latest.publishedAt
+
+	s.logger.Info("recommended version", "cluster", s.cluster, "version", recommendation.Version.String(), "source", s.Name())
+	return recommendation, nil
+}
+
+// CheckPublished returns the raw index entry of the given package version, or an error if it isn't published in the
+// repository index - versions without a release (e.g. "0.7.1") match any release of that version
+func (s *CloudsmithIndexSource) CheckPublished(ctx context.Context, packageVersion string) (evidence string, err error) {
+	target, err := version.NewVersion(packageVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse package version %s: %w", packageVersion, err)
+	}
+
+	entries, err := s.fetchEntries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	indexURL := ""
+	for _, entry := range entries {
+		indexURL = entry.url
+		if entry.version == packageVersion {
+			return entry.evidence, nil
+		}
+		if target.Prerelease() == "" {
+			if v, err := version.NewVersion(entry.version); err == nil && v.Core().Equal(target) {
+				return entry.evidence, nil
+			}
+		}
+	}
+
+	if indexURL == "" {
+		indexURL = s.repoURL(cloudsmithRepoNames[s.cluster])
+	}
+	return "", fmt.Errorf("%s %s is %w in the %s repository index (%s)", s.packageName, packageVersion, ErrNotPublished, s.Repository(), indexURL)
+}
+
+// Repository describes the repository index read - its format, distribution, distribution version and architecture,
+// e.g. deb ubuntu/noble/amd64
+func (s *CloudsmithIndexSource) Repository() string {
+	return fmt.Sprintf("%s %s/%s/%s", s.format, s.target.Distro, s.target.DistroVersion, s.target.Arch)
+}
+
+// fetchEntries reads all doublezero entries from the cluster's repository index for the configured format
+func (s *CloudsmithIndexSource) fetchEntries(ctx context.Context) ([]indexEntry, error) {
+	repoName, ok := cloudsmithRepoNames[s.cluster]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster: %s", s.cluster)
+	}
+
+	switch s.format {
+	case constants.PackageFormatDeb:
+		return s.fetchDebEntries(ctx, repoName)
+	case constants.PackageFormatRPM:
+		return s.fetchRPMEntries(ctx, repoName)
+	default:
+		return nil, fmt.Errorf("unsupported package index format: %s", s.format)
+	}
+}
+
+// repoURL returns the base URL of the cluster's repository for the configured format and distribution
+func (s *CloudsmithIndexSource) repoURL(repoName string) string {
+	baseURL := s.baseURL
+	if baseURL == "" {
+		baseURL = cloudsmithDownloadBaseURL
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", baseURL, repoName, s.format, s.target.Distro)
+}
+
+// fetchDebEntries reads all doublezero entries from the Debian Packages index
+func (s *CloudsmithIndexSource) fetchDebEntries(ctx context.Context, repoName string) ([]indexEntry, error) {
+	indexURL := fmt.Sprintf("%s/dists/%s/main/binary-%s/Packages.gz", s.repoURL(repoName), s.target.DistroVersion, s.target.Arch)
+	body, err := s.fetch(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	reader, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", indexURL, err)
+	}
+	defer reader.Close()
+
+	entries, err := parseDebPackages(reader, s.packageName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].url = indexURL
+	}
+	return entries, nil
+}
+
+// parseDebPackages returns the versions and raw stanzas of all stanzas for the named package in a Debian Packages index
+func parseDebPackages(r io.Reader, packageName string) ([]indexEntry, error) {
+	var (
+		entries       []indexEntry
+		stanza        strings.Builder
+		stanzaPackage string
+		stanzaVersion string
+		flushStanza   = func() {
+			if stanzaPackage == packageName && stanzaVersion != "" {
+				entries = append(entries, indexEntry{version: stanzaVersion, evidence: strings.TrimSpace(stanza.String())})
+			}
+			stanza.Reset()
+			stanzaPackage, stanzaVersion = "", ""
+		}
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) != "" {
+			stanza.WriteString(line)
+			stanza.WriteString("\n")
+		}
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushStanza()
+		case strings.HasPrefix(line, "Package:"):
+			stanzaPackage = strings.TrimSpace(strings.TrimPrefix(line, "Package:"))
+		case strings.HasPrefix(line, "Version:"):
+			stanzaVersion = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Packages index: %w", err)
+	}
+	flushStanza()
+
+	return entries, nil
+}
+
+// fetchRPMEntries reads all doublezero entries from the RPM repomd and primary metadata
+func (s *CloudsmithIndexSource) fetchRPMEntries(ctx context.Context, repoName string) ([]indexEntry, error) {
+	archURL := fmt.Sprintf("%s/%s/%s", s.repoURL(repoName), s.target.DistroVersion, s.target.Arch)
+
+	repomdBody, err := s.fetch(ctx, archURL+"/repodata/repomd.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer repomdBody.Close()
+
+	var md repomd
+	if err := xml.NewDecoder(repomdBody).Decode(&md); err != nil {
+		return nil, fmt.Errorf("failed to parse repomd.xml: %w", err)
+	}
+
+	primaryHref := ""
+	for _, data := range md.Data {
+		if data.Type == "primary" {
+			primaryHref = data.Location.Href
+			break
+		}
+	}
+	if primaryHref == "" {
+		return nil, fmt.Errorf("no primary metadata found in repomd.xml")
+	}
+
+	primaryURL := archURL + "/" + primaryHref
+	primaryBody, err := s.fetch(ctx, primaryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer primaryBody.Close()
+
+	var primaryReader io.Reader = primaryBody
+	if strings.HasSuffix(primaryHref, ".gz") {
+		gz, err := gzip.NewReader(primaryBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", primaryHref, err)
+		}
+		defer gz.Close()
+		primaryReader = gz
+	}
+
+	var primary rpmPrimary
+	if err := xml.NewDecoder(primaryReader).Decode(&primary); err != nil {
+		return nil, fmt.Errorf("failed to parse primary metadata: %w", err)
+	}
+
+	var entries []indexEntry
+	for _, pkg := range primary.Packages {
+		if pkg.Name == s.packageName {
+			entry := indexEntry{
+				version:  fmt.Sprintf("%s-%s", pkg.Version.Ver, pkg.Version.Rel),
+				evidence: strings.TrimSpace(pkg.Raw),
+				url:      primaryURL,
+			}
+			if pkg.Time.File > 0 {
+				entry.publishedAt = time.Unix(pkg.Time.File, 0).UTC()
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// fetch GETs the given URL and returns the response body - the caller must close it
+func (s *CloudsmithIndexSource) fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.client.Timeout)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "doublezero-version-sync/1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("repository returned status %d for %s", resp.StatusCode, url)
+	}
+
+	s.logger.Debug("fetched repository metadata", "url", url)
+	return &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// cancelOnClose releases the request context when the response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the request context
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
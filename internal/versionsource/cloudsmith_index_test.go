@@ -0,0 +1,167 @@
+package versionsource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// gzipBytes returns the gzip-compressed form of s.
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatalf("failed to gzip: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newTestIndexSource creates a CloudsmithIndexSource pointed at the given test server URL.
+func newTestIndexSource(serverURL, cluster, format string) *CloudsmithIndexSource {
+	s := NewCloudsmithIndex(cluster, format, Options{})
+	s.baseURL = serverURL
+	return s
+}
+
+func TestCloudsmithIndexSource_DebReturnsLatest(t *testing.T) {
+	packages := `Package: doublezero
+Version: 0.7.0-1
+Architecture: amd64
+
+Package: doublezero-sentinel
+Version: 0.9.0-1
+Architecture: amd64
+
+Package: doublezero
+Version: 0.7.1-1
+Architecture: amd64
+`
+	var requestPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		_, _ = w.Write(gzipBytes(t, packages))
+	}))
+	defer srv.Close()
+
+	v, err := newTestIndexSource(srv.URL, "testnet", "deb").GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1", v.Version.Original())
+	}
+	if v.Evidence != "Package: doublezero\nVersion: 0.7.1-1\nArchitecture: amd64" {
+		t.Errorf("unexpected evidence %q", v.Evidence)
+	}
+	if requestPath != "/doublezero-testnet/deb/any-distro/dists/any-version/main/binary-amd64/Packages.gz" {
+		t.Errorf("unexpected request path %s", requestPath)
+	}
+}
+
+func TestCloudsmithIndexSource_RPMReturnsLatest(t *testing.T) {
+	repomdXML := `<repomd><data type="other"><location href="repodata/other.xml.gz"/></data><data type="primary"><location href="repodata/primary.xml.gz"/></data></repomd>`
+	primaryXML := `<metadata>
+<package type="rpm"><name>doublezero</name><arch>x86_64</arch><version epoch="0" ver="0.7.1" rel="1"/><time file="1736042400" build="1736040000"/></package>
+<package type="rpm"><name>doublezero</name><arch>x86_64</arch><version epoch="0" ver="0.6.9" rel="2"/></package>
+</metadata>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/doublezero/rpm/any-distro/any-version/x86_64/repodata/repomd.xml":
+			_, _ = w.Write([]byte(repomdXML))
+		case "/doublezero/rpm/any-distro/any-version/x86_64/repodata/primary.xml.gz":
+			_, _ = w.Write(gzipBytes(t, primaryXML))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	v, err := newTestIndexSource(srv.URL, "mainnet-beta", "rpm").GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version.Original() != "0.7.1-1" {
+		t.Errorf("got %s, want 0.7.1-1", v.Version.Original())
+	}
+	if v.RPMPackageVersion != "0.7.1-1" {
+		t.Errorf("RPMPackageVersion = %s, want 0.7.1-1", v.RPMPackageVersion)
+	}
+	if want := time.Date(2025, 1, 5, 2, 0, 0, 0, time.UTC); !v.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %s, want %s", v.PublishedAt, want)
+	}
+}
+
+func TestCloudsmithIndexSource_ErrorOnNoPackages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(gzipBytes(t, "Package: other\nVersion: 1.0.0\n"))
+	}))
+	defer srv.Close()
+
+	if _, err := newTestIndexSource(srv.URL, "mainnet-beta", "deb").GetRecommendation(context.Background()); err == nil {
+		t.Fatal("expected error when no doublezero packages in index, got nil")
+	}
+}
+
+func TestCloudsmithIndexSource_DebPerDistroTarget(t *testing.T) {
+	var requestPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		_, _ = w.Write(gzipBytes(t, "Package: doublezero\nVersion: 0.7.0-1\nArchitecture: arm64\n"))
+	}))
+	defer srv.Close()
+
+	src := newTestIndexSource(srv.URL, "mainnet-beta", "deb")
+	src.target = Target{Distro: "ubuntu", DistroVersion: "noble", Arch: "arm64"}
+	v, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version.Original() != "0.7.0-1" {
+		t.Errorf("got %s, want 0.7.0-1", v.Version.Original())
+	}
+	if requestPath != "/doublezero/deb/ubuntu/dists/noble/main/binary-arm64/Packages.gz" {
+		t.Errorf("unexpected request path %s", requestPath)
+	}
+}
+
+func TestCloudsmithIndexSource_CheckPublished(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(gzipBytes(t, "Package: doublezero\nVersion: 0.7.0-1\n\nPackage: doublezero\nVersion: 0.7.1-2\n"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		packageVersion string
+		wantErr        bool
+	}{
+		{packageVersion: "0.7.1-2"},
+		{packageVersion: "0.7.1"},
+		{packageVersion: "0.7.1-1", wantErr: true},
+		{packageVersion: "0.7.2", wantErr: true},
+	}
+
+	src := newTestIndexSource(srv.URL, "mainnet-beta", "deb")
+	for _, tt := range tests {
+		t.Run(tt.packageVersion, func(t *testing.T) {
+			evidence, err := src.CheckPublished(context.Background(), tt.packageVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckPublished() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNotPublished) {
+				t.Errorf("CheckPublished() error = %v, want ErrNotPublished", err)
+			}
+			if !tt.wantErr && evidence != "Package: doublezero\nVersion: 0.7.1-2" {
+				t.Errorf("unexpected evidence %q", evidence)
+			}
+		})
+	}
+}
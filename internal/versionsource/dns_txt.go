@@ -0,0 +1,104 @@
+package versionsource
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// TXTResolver looks up the TXT records of a DNS name, satisfied by *net.Resolver
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNSTXTSource is a version source that reads the version from a DNS TXT record (e.g. mainnet-beta.versions.example.com)
+// a lightweight distribution channel that works in locked-down networks and is trivially mirrorable
+type DNSTXTSource struct {
+	record     string
+	nameserver string
+	timeout    time.Duration
+	resolver   TXTResolver
+	logger     *log.Logger
+	clock      clock.Clock
+}
+
+// NewDNSTXT creates a new dns_txt version source reading the TXT record, through nameserver (host:port) when set or
+// the system resolver otherwise
+func NewDNSTXT(record, nameserver string, opts Options) *DNSTXTSource {
+	opts = opts.withDefaults()
+	s := &DNSTXTSource{
+		record:     record,
+		nameserver: nameserver,
+		timeout:    30 * time.Second,
+		resolver:   net.DefaultResolver,
+		logger:     logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:      opts.Clock,
+	}
+
+	if nameserver != "" {
+		s.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, nameserver)
+			},
+		}
+	}
+
+	s.logger.Debug("initialized dns txt version source", "record", s.record, "nameserver", s.nameserver)
+	return s
+}
+
+// Name returns the version source type name
+func (s *DNSTXTSource) Name() string {
+	return constants.VersionSourceTypeDNSTXT
+}
+
+// GetRecommendation resolves the TXT record and returns the version it holds - TXT values at the same name that aren't
+// versions are ignored, and records holding different versions are an error
+func (s *DNSTXTSource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	values, err := s.resolver.LookupTXT(ctx, s.record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up TXT record %s: %w", s.record, err)
+	}
+
+	var versions []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if _, err := version.NewVersion(value); err != nil {
+			s.logger.Debug("ignoring TXT value that is not a version", "record", s.record, "value", value)
+			continue
+		}
+		if !slices.Contains(versions, value) {
+			versions = append(versions, value)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return nil, fmt.Errorf("no TXT record at %s holds a version - got: %q", s.record, values)
+	case 1:
+	default:
+		return nil, fmt.Errorf("TXT records at %s hold conflicting versions: %s", s.record, strings.Join(versions, ", "))
+	}
+
+	recommendation, err := newRecommendation(s.Name(), versions[0], "dns:"+s.record, fmt.Sprintf("%s TXT %q", s.record, versions[0]), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("recommended version", "version", recommendation.Version.String(), "source", s.Name(), "record", s.record)
+	return recommendation, nil
+}
@@ -0,0 +1,52 @@
+package versionsource
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeTXTResolver struct {
+	values []string
+	err    error
+}
+
+func (r fakeTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.values, r.err
+}
+
+func TestDNSTXTSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{name: "single record", values: []string{"0.7.1-1"}, want: "0.7.1-1"},
+		{name: "surrounding whitespace", values: []string{" 0.7.1-1 "}, want: "0.7.1-1"},
+		{name: "non-version values ignored", values: []string{"v=spf1 -all", "0.7.2-1"}, want: "0.7.2-1"},
+		{name: "duplicate records agree", values: []string{"0.7.2-1", "0.7.2-1"}, want: "0.7.2-1"},
+		{name: "conflicting records", values: []string{"0.7.1-1", "0.7.2-1"}, wantErr: true},
+		{name: "no version", values: []string{"hello"}, wantErr: true},
+		{name: "lookup fails", err: errors.New("no such host"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := NewDNSTXT("mainnet-beta.versions.example.com", "", Options{})
+			src.resolver = fakeTXTResolver{values: tt.values, err: tt.err}
+
+			r, err := src.GetRecommendation(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRecommendation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if r.PackageVersion != tt.want || r.Source != "dns_txt" {
+				t.Errorf("got %s from %s, want %s from dns_txt", r.PackageVersion, r.Source, tt.want)
+			}
+		})
+	}
+}
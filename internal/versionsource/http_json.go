@@ -0,0 +1,106 @@
+package versionsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/jsonpath"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+const (
+	// maxHTTPJSONBodySize caps the size of the JSON document read by the http_json source
+	maxHTTPJSONBodySize = 1024 * 1024
+)
+
+// HTTPJSONSource is a version source that extracts the version from a JSON document served over HTTP(S)
+// so teams running their own version policy service can drive the sync directly
+type HTTPJSONSource struct {
+	url     string
+	path    *jsonpath.Path
+	headers map[string]string
+	logger  *log.Logger
+	clock   clock.Clock
+	client  *http.Client
+}
+
+// NewHTTPJSON creates a new http_json version source reading the version at the JQ-style path from the document at url
+func NewHTTPJSON(url, path string, headers map[string]string, opts Options) (*HTTPJSONSource, error) {
+	parsedPath, err := jsonpath.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = opts.withDefaults()
+	s := &HTTPJSONSource{
+		url:     url,
+		path:    parsedPath,
+		headers: headers,
+		logger:  logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:   opts.Clock,
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: opts.Transport},
+	}
+
+	s.logger.Debug("initialized http json version source", "url", s.url, "path", s.path.String())
+	return s, nil
+}
+
+// Name returns the version source type name
+func (s *HTTPJSONSource) Name() string {
+	return constants.VersionSourceTypeHTTPJSON
+}
+
+// GetRecommendation fetches the JSON document and returns the version at the configured path
+func (s *HTTPJSONSource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "doublezero-version-sync/1.0")
+	req.Header.Set("Accept", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", s.url, resp.StatusCode)
+	}
+
+	var document any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHTTPJSONBodySize)).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON from %s: %w", s.url, err)
+	}
+
+	value, err := s.path.Lookup(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract version from %s: %w", s.url, err)
+	}
+	packageVersion, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("value at %s in %s is a %T, want a string", s.path.String(), s.url, value)
+	}
+
+	recommendation, err := newRecommendation(s.Name(), packageVersion, s.url, fmt.Sprintf("%s = %q", s.path.String(), packageVersion), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("recommended version", "version", recommendation.Version.String(), "source", s.Name(), "url", s.url)
+	return recommendation, nil
+}
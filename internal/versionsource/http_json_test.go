@@ -0,0 +1,44 @@
+package versionsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPJSONSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"clusters": {"mainnet-beta": {"recommended": "0.7.1-1"}, "testnet": {"recommended": 7}}}`))
+	}))
+	defer srv.Close()
+
+	headers := map[string]string{"Authorization": "Bearer secret"}
+
+	src, err := NewHTTPJSON(srv.URL, `.clusters["mainnet-beta"].recommended`, headers, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Version.Original() != "0.7.1-1" || r.URL != srv.URL {
+		t.Errorf("got %s from %s, want 0.7.1-1 from %s", r.Version.Original(), r.URL, srv.URL)
+	}
+
+	// non-string values and missing credentials are errors
+	src, _ = NewHTTPJSON(srv.URL, `.clusters.testnet.recommended`, headers, Options{})
+	if _, err := src.GetRecommendation(context.Background()); err == nil {
+		t.Error("expected error for a non-string version, got nil")
+	}
+	src, _ = NewHTTPJSON(srv.URL, `.clusters["mainnet-beta"].recommended`, nil, Options{})
+	if _, err := src.GetRecommendation(context.Background()); err == nil {
+		t.Error("expected error for an unauthorized request, got nil")
+	}
+}
@@ -0,0 +1,155 @@
+package versionsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+	"github.com/sol-strategies/doublezero-version-sync/internal/rpc"
+)
+
+// SolanaAccountSource is a version source that decodes the version from the data of an account on the cluster,
+// removing any dependence on web infrastructure
+type SolanaAccountSource struct {
+	rpcURL     string
+	address    solana.PublicKey
+	dataOffset int
+	dataFormat string
+	client     *rpc.Client
+	logger     *log.Logger
+	clock      clock.Clock
+}
+
+// NewSolanaAccountFromConfig creates a solana_account version source from its config, deriving the account address
+// from the program and seeds when no account is set. The cluster's public RPC endpoint is used when rpc_url is unset
+func NewSolanaAccountFromConfig(cluster string, cfg config.VersionSource, opts Options) (*SolanaAccountSource, error) {
+	opts = opts.withDefaults()
+
+	rpcURL := cfg.RPCURL
+	if rpcURL == "" {
+		rpcURL = constants.ClusterPublicRPCURLs[cluster]
+	}
+	if rpcURL == "" {
+		return nil, fmt.Errorf("no public RPC URL known for cluster %s - set rpc_url", cluster)
+	}
+
+	address, err := accountAddress(cfg.Account, cfg.Program, cfg.Seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SolanaAccountSource{
+		rpcURL:     rpcURL,
+		address:    address,
+		dataOffset: cfg.DataOffset,
+		dataFormat: cfg.DataFormat,
+		client: rpc.NewClient(rpc.Options{
+			URL:       rpcURL,
+			Logger:    opts.Logger,
+			Clock:     opts.Clock,
+			Transport: opts.Transport,
+			Timeout:   cfg.Timeout,
+		}),
+		logger: logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:  opts.Clock,
+	}
+
+	s.logger.Debug("initialized solana account version source", "rpc_url", s.rpcURL, "account", s.address.String(), "data_format", s.dataFormat)
+	return s, nil
+}
+
+// accountAddress returns the account address, or the program-derived address of program and seeds when account is unset
+func accountAddress(account, program string, seeds []string) (solana.PublicKey, error) {
+	if account != "" {
+		address, err := solana.PublicKeyFromBase58(account)
+		if err != nil {
+			return solana.PublicKey{}, fmt.Errorf("invalid account address %s: %w", account, err)
+		}
+		return address, nil
+	}
+
+	programID, err := solana.PublicKeyFromBase58(program)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("invalid program address %s: %w", program, err)
+	}
+
+	seedBytes := make([][]byte, 0, len(seeds))
+	for _, seed := range seeds {
+		seedBytes = append(seedBytes, []byte(seed))
+	}
+
+	address, _, err := solana.FindProgramAddress(seedBytes, programID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive account address from program %s: %w", program, err)
+	}
+	return address, nil
+}
+
+// Name returns the version source type name
+func (s *SolanaAccountSource) Name() string {
+	return constants.VersionSourceTypeSolanaAccount
+}
+
+// GetRecommendation fetches the account data and returns the version decoded at the configured offset
+func (s *SolanaAccountSource) GetRecommendation(ctx context.Context) (*Recommendation, error) {
+	data, err := s.client.GetAccountData(ctx, s.address.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account %s from %s: %w", s.address, s.rpcURL, err)
+	}
+
+	packageVersion, err := decodeAccountVersion(data, s.dataOffset, s.dataFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode version from account %s: %w", s.address, err)
+	}
+
+	evidence := fmt.Sprintf("account %s data[%d:] (%s) = %q", s.address, s.dataOffset, s.dataFormat, packageVersion)
+	recommendation, err := newRecommendation(s.Name(), packageVersion, s.rpcURL, evidence, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("recommended version", "version", recommendation.Version.String(), "source", s.Name(), "account", s.address.String())
+	return recommendation, nil
+}
+
+// decodeAccountVersion decodes the version string at offset in account data in the given format
+func decodeAccountVersion(data []byte, offset int, format string) (string, error) {
+	if offset > len(data) {
+		return "", fmt.Errorf("data offset %d is beyond the account data length %d", offset, len(data))
+	}
+	data = data[offset:]
+
+	var value []byte
+	switch format {
+	case constants.AccountDataFormatBorshString:
+		if len(data) < 4 {
+			return "", fmt.Errorf("account data too short for a string length prefix")
+		}
+		length := binary.LittleEndian.Uint32(data)
+		if uint64(length) > uint64(len(data)-4) {
+			return "", fmt.Errorf("string length %d exceeds the remaining account data length %d", length, len(data)-4)
+		}
+		value = data[4 : 4+length]
+	case constants.AccountDataFormatUTF8:
+		value = data
+		if end := bytes.IndexByte(value, 0); end >= 0 {
+			value = value[:end]
+		}
+	default:
+		return "", fmt.Errorf("unknown account data format: %s", format)
+	}
+
+	packageVersion := strings.TrimSpace(string(value))
+	if packageVersion == "" {
+		return "", fmt.Errorf("account data holds an empty version")
+	}
+	return packageVersion, nil
+}
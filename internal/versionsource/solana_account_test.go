@@ -0,0 +1,124 @@
+package versionsource
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// borshString encodes s as a Borsh string - a little-endian u32 length followed by the bytes
+func borshString(s string) []byte {
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(s))), s...)
+}
+
+// newAccountServer returns a test RPC server answering getAccountInfo for address with data
+func newAccountServer(t *testing.T, address string, data []byte) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var value any
+		if req.Method == "getAccountInfo" && len(req.Params) > 0 && req.Params[0] == address {
+			value = map[string]any{"data": []any{base64.StdEncoding.EncodeToString(data), "base64"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": map[string]any{"value": value}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSolanaAccountSource(t *testing.T) {
+	account := solana.NewWallet().PublicKey()
+	program := solana.NewWallet().PublicKey()
+	derived, _, err := solana.FindProgramAddress([][]byte{[]byte("version"), []byte("mainnet-beta")}, program)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discriminator := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	tests := []struct {
+		name    string
+		cfg     config.VersionSource
+		served  string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "borsh string after a discriminator",
+			cfg:    config.VersionSource{Account: account.String(), DataOffset: 8, DataFormat: constants.AccountDataFormatBorshString},
+			served: account.String(),
+			data:   append(append([]byte{}, discriminator...), borshString("0.7.1-1")...),
+			want:   "0.7.1-1",
+		},
+		{
+			name:   "nul-padded utf8",
+			cfg:    config.VersionSource{Account: account.String(), DataFormat: constants.AccountDataFormatUTF8},
+			served: account.String(),
+			data:   append([]byte("0.7.2-1"), make([]byte, 9)...),
+			want:   "0.7.2-1",
+		},
+		{
+			name:   "program-derived address",
+			cfg:    config.VersionSource{Program: program.String(), Seeds: []string{"version", "mainnet-beta"}, DataFormat: constants.AccountDataFormatBorshString},
+			served: derived.String(),
+			data:   borshString("0.7.3-1"),
+			want:   "0.7.3-1",
+		},
+		{
+			name:    "account not found",
+			cfg:     config.VersionSource{Account: account.String(), DataFormat: constants.AccountDataFormatBorshString},
+			served:  program.String(),
+			wantErr: true,
+		},
+		{
+			name:    "string length beyond the data",
+			cfg:     config.VersionSource{Account: account.String(), DataFormat: constants.AccountDataFormatBorshString},
+			served:  account.String(),
+			data:    borshString("0.7.1-1")[:6],
+			wantErr: true,
+		},
+		{
+			name:    "offset beyond the data",
+			cfg:     config.VersionSource{Account: account.String(), DataOffset: 64, DataFormat: constants.AccountDataFormatUTF8},
+			served:  account.String(),
+			data:    []byte("0.7.1-1"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Type = constants.VersionSourceTypeSolanaAccount
+			tt.cfg.RPCURL = newAccountServer(t, tt.served, tt.data).URL
+
+			src, err := NewSolanaAccountFromConfig(constants.ClusterNameMainnetBeta, tt.cfg, Options{})
+			if err != nil {
+				t.Fatalf("NewSolanaAccountFromConfig() error = %v", err)
+			}
+
+			r, err := src.GetRecommendation(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRecommendation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if r.PackageVersion != tt.want || r.Source != "solana_account" {
+				t.Errorf("got %s from %s, want %s from solana_account", r.PackageVersion, r.Source, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package versionsource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+	"github.com/sol-strategies/doublezero-version-sync/internal/logging"
+)
+
+// StaticSource is a version source returning a version pinned in config or an environment variable
+// It never touches the network, letting change-managed environments push a specific version through the sync pipeline
+type StaticSource struct {
+	version    string
+	versionEnv string
+	logger     *log.Logger
+	clock      clock.Clock
+}
+
+// NewStatic creates a new static version source - the version is read from versionEnv on every call when set
+func NewStatic(version, versionEnv string, opts Options) *StaticSource {
+	opts = opts.withDefaults()
+	return &StaticSource{
+		version:    version,
+		versionEnv: versionEnv,
+		logger:     logging.WithPrefix(opts.Logger, "versionsource"),
+		clock:      opts.Clock,
+	}
+}
+
+// Name returns the version source type name
+func (s *StaticSource) Name() string {
+	return constants.VersionSourceTypeStatic
+}
+
+// GetRecommendation returns the pinned version
+func (s *StaticSource) GetRecommendation(context.Context) (*Recommendation, error) {
+	packageVersion, origin := s.version, "config"
+	if s.versionEnv != "" {
+		packageVersion = strings.TrimSpace(os.Getenv(s.versionEnv))
+		if packageVersion == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", s.versionEnv)
+		}
+		origin = "env:" + s.versionEnv
+	}
+
+	recommendation, err := newRecommendation(s.Name(), packageVersion, origin, fmt.Sprintf("%s=%s", origin, packageVersion), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("recommended version", "version", recommendation.Version.String(), "source", s.Name(), "from", origin)
+	return recommendation, nil
+}
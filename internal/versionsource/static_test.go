@@ -0,0 +1,34 @@
+package versionsource
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStaticSource(t *testing.T) {
+	r, err := NewStatic("0.7.1-1", "", Options{}).GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Version.Original() != "0.7.1-1" || r.Source != "static" {
+		t.Errorf("got %s from %s, want 0.7.1-1 from static", r.Version.Original(), r.Source)
+	}
+}
+
+func TestStaticSource_FromEnv(t *testing.T) {
+	src := NewStatic("", "DZ_PINNED_VERSION", Options{})
+
+	t.Setenv("DZ_PINNED_VERSION", "")
+	if _, err := src.GetRecommendation(context.Background()); err == nil {
+		t.Fatal("expected error when the environment variable is not set, got nil")
+	}
+
+	t.Setenv("DZ_PINNED_VERSION", "0.7.2-1")
+	r, err := src.GetRecommendation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Version.Original() != "0.7.2-1" {
+		t.Errorf("got %s, want 0.7.2-1", r.Version.Original())
+	}
+}
@@ -0,0 +1,140 @@
+package versionsource
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// osReleasePath is the os-release file read to detect the distribution
+var osReleasePath = "/etc/os-release"
+
+// rpmEnterpriseLinuxIDs are os-release IDs of RHEL-compatible distributions, published under the el repository distribution
+var rpmEnterpriseLinuxIDs = []string{"rhel", "centos", "rocky", "almalinux", "ol"}
+
+// Target is the platform a repository index is read for
+type Target struct {
+	// Distro is the repository distribution, e.g. any-distro, ubuntu, el
+	Distro string
+	// DistroVersion is the repository distribution version, e.g. any-version, noble, 9
+	DistroVersion string
+	// Arch is the package architecture as named in the repository, e.g. amd64 for deb, x86_64 for rpm
+	Arch string
+}
+
+// ResolveTarget resolves the repository target for the given package format from the configured hints,
+// detecting the architecture from the running binary when arch is auto and the distribution from /etc/os-release
+// when distro is auto
+func ResolveTarget(format, distro, distroVersion, arch string) (Target, error) {
+	if arch == "" || arch == constants.PackageArchAuto {
+		arch = runtime.GOARCH
+	}
+	packageArch, err := repositoryArch(format, arch)
+	if err != nil {
+		return Target{}, err
+	}
+
+	target := Target{Distro: distro, DistroVersion: distroVersion, Arch: packageArch}
+	if target.Distro == "" {
+		target.Distro = constants.PackageDistroAny
+	}
+
+	if target.Distro == constants.PackageDistroAuto {
+		f, err := os.Open(osReleasePath)
+		if err != nil {
+			return Target{}, fmt.Errorf("failed to detect distribution: %w", err)
+		}
+		defer f.Close()
+
+		target.Distro, target.DistroVersion, err = detectDistro(format, f)
+		if err != nil {
+			return Target{}, fmt.Errorf("failed to detect distribution from %s: %w", osReleasePath, err)
+		}
+	}
+
+	if target.DistroVersion == "" {
+		target.DistroVersion = constants.PackageDistroVersionAny
+	}
+
+	return target, nil
+}
+
+// repositoryArch returns the repository name of a Go architecture for the given package format
+func repositoryArch(format, arch string) (string, error) {
+	switch {
+	case arch == constants.PackageArchAMD64 && format == constants.PackageFormatRPM:
+		return "x86_64", nil
+	case arch == constants.PackageArchARM64 && format == constants.PackageFormatRPM:
+		return "aarch64", nil
+	case arch == constants.PackageArchAMD64, arch == constants.PackageArchARM64:
+		return arch, nil
+	default:
+		return "", fmt.Errorf("unsupported package architecture: %s", arch)
+	}
+}
+
+// detectDistro returns the repository distribution and version described by an os-release document
+// Debian-family distributions are published by codename (e.g. ubuntu/noble), RHEL-compatible ones as el by major version
+func detectDistro(format string, r io.Reader) (distro, distroVersion string, err error) {
+	osRelease, err := parseOSRelease(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	id := osRelease["ID"]
+	if id == "" {
+		return "", "", fmt.Errorf("no ID in os-release")
+	}
+
+	if format == constants.PackageFormatDeb {
+		codename := osRelease["VERSION_CODENAME"]
+		if codename == "" {
+			return "", "", fmt.Errorf("no VERSION_CODENAME in os-release for %s", id)
+		}
+		return id, codename, nil
+	}
+
+	// rpm repositories are published by major version
+	majorVersion, _, _ := strings.Cut(osRelease["VERSION_ID"], ".")
+	if majorVersion == "" {
+		return "", "", fmt.Errorf("no VERSION_ID in os-release for %s", id)
+	}
+
+	isEnterpriseLinux := false
+	for _, like := range append([]string{id}, strings.Fields(osRelease["ID_LIKE"])...) {
+		for _, elID := range rpmEnterpriseLinuxIDs {
+			isEnterpriseLinux = isEnterpriseLinux || like == elID
+		}
+	}
+	if isEnterpriseLinux {
+		return "el", majorVersion, nil
+	}
+
+	return id, majorVersion, nil
+}
+
+// parseOSRelease parses the KEY=value lines of an os-release document, unquoting values
+func parseOSRelease(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read os-release: %w", err)
+	}
+	return values, nil
+}
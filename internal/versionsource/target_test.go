@@ -0,0 +1,100 @@
+package versionsource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectDistro(t *testing.T) {
+	tests := []struct {
+		name              string
+		format            string
+		osRelease         string
+		wantDistro        string
+		wantDistroVersion string
+		wantErr           bool
+	}{
+		{
+			name:              "ubuntu",
+			format:            "deb",
+			osRelease:         "NAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\nVERSION_ID=\"24.04\"\nVERSION_CODENAME=noble\n",
+			wantDistro:        "ubuntu",
+			wantDistroVersion: "noble",
+		},
+		{
+			name:              "rocky is enterprise linux",
+			format:            "rpm",
+			osRelease:         "ID=\"rocky\"\nID_LIKE=\"rhel centos fedora\"\nVERSION_ID=\"9.4\"\n",
+			wantDistro:        "el",
+			wantDistroVersion: "9",
+		},
+		{
+			name:              "fedora",
+			format:            "rpm",
+			osRelease:         "# comment\nID=fedora\nVERSION_ID=40\n",
+			wantDistro:        "fedora",
+			wantDistroVersion: "40",
+		},
+		{
+			name:      "deb without codename",
+			format:    "deb",
+			osRelease: "ID=debian\nVERSION_ID=\"13\"\n",
+			wantErr:   true,
+		},
+		{
+			name:      "no id",
+			format:    "rpm",
+			osRelease: "VERSION_ID=9\n",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distro, distroVersion, err := detectDistro(tt.format, strings.NewReader(tt.osRelease))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectDistro() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if distro != tt.wantDistro || distroVersion != tt.wantDistroVersion {
+				t.Errorf("detectDistro() = %s/%s, want %s/%s", distro, distroVersion, tt.wantDistro, tt.wantDistroVersion)
+			}
+		})
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	if err := os.WriteFile(path, []byte("ID=almalinux\nVERSION_ID=\"8.10\"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write os-release: %v", err)
+	}
+	previous := osReleasePath
+	osReleasePath = path
+	defer func() { osReleasePath = previous }()
+
+	tests := []struct {
+		name                                string
+		format, distro, distroVersion, arch string
+		want                                Target
+		wantErr                             bool
+	}{
+		{name: "defaults", format: "deb", arch: "amd64", want: Target{Distro: "any-distro", DistroVersion: "any-version", Arch: "amd64"}},
+		{name: "rpm arch names", format: "rpm", arch: "arm64", want: Target{Distro: "any-distro", DistroVersion: "any-version", Arch: "aarch64"}},
+		{name: "explicit distro", format: "deb", distro: "debian", distroVersion: "bookworm", arch: "arm64", want: Target{Distro: "debian", DistroVersion: "bookworm", Arch: "arm64"}},
+		{name: "auto distro", format: "rpm", distro: "auto", arch: "amd64", want: Target{Distro: "el", DistroVersion: "8", Arch: "x86_64"}},
+		{name: "unsupported arch", format: "deb", arch: "riscv64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveTarget(tt.format, tt.distro, tt.distroVersion, tt.arch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
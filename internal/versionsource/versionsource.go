@@ -0,0 +1,178 @@
+package versionsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/go-version"
+	"github.com/sol-strategies/doublezero-version-sync/internal/clock"
+	"github.com/sol-strategies/doublezero-version-sync/internal/config"
+	"github.com/sol-strategies/doublezero-version-sync/internal/constants"
+)
+
+// VersionSource resolves the recommended DoubleZero version for a cluster
+type VersionSource interface {
+	// Name returns the version source type name, used for logging
+	Name() string
+	// GetRecommendation gets the recommended DoubleZero package version along with the evidence it was derived from,
+	// giving up when ctx is done
+	GetRecommendation(ctx context.Context) (*Recommendation, error)
+}
+
+// Recommendation is a recommended version together with an audit trail of where it came from
+type Recommendation struct {
+	// Version is the recommended package version
+	Version *version.Version `json:"-"`
+	// PackageVersion is the recommended package version string as published (e.g. "0.7.1-1")
+	PackageVersion string `json:"package_version"`
+	// RPMPackageVersion is the RPM package version (version-release) of the same release for RHEL-family hosts,
+	// empty when the source doesn't know it
+	RPMPackageVersion string `json:"rpm_package_version,omitempty"`
+	// Source is the name of the version source that supplied the recommendation
+	Source string `json:"source"`
+	// URL is the URL the recommendation was fetched from
+	URL string `json:"url"`
+	// Evidence is the raw snippet (API object, package index stanza, etc.) the version was read from
+	Evidence string `json:"evidence"`
+	// FetchedAt is when the recommendation was fetched
+	FetchedAt time.Time `json:"fetched_at"`
+	// PublishedAt is when the recommended package was published to the repository, zero when the source doesn't know
+	PublishedAt time.Time `json:"published_at,omitempty"`
+	// FailedSources are the sources of a fallback chain that were consulted and failed before Source succeeded
+	FailedSources []FailedSource `json:"failed_sources,omitempty"`
+	// FromCache is true when the recommendation was served from the on-disk cache
+	FromCache bool `json:"-"`
+}
+
+// FailedSource is a version source that was consulted and failed
+type FailedSource struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// Options represents the options shared by all version sources
+type Options struct {
+	// Logger is the parent logger, defaults to the global logger
+	Logger *log.Logger
+	// Clock timestamps recommendations and ages cache entries, defaults to the system clock
+	Clock clock.Clock
+	// Transport is the HTTP transport used by network sources, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// withDefaults returns the options with unset fields defaulted
+func (o Options) withDefaults() Options {
+	if o.Logger == nil {
+		o.Logger = log.Default()
+	}
+	if o.Clock == nil {
+		o.Clock = clock.Real{}
+	}
+	return o
+}
+
+// newRecommendation parses the package version and returns a Recommendation fetched at the given time
+func newRecommendation(source, packageVersion, url, evidence string, fetchedAt time.Time) (*Recommendation, error) {
+	v, err := version.NewVersion(packageVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recommended version %s: %w", packageVersion, err)
+	}
+
+	return &Recommendation{
+		Version:        v,
+		PackageVersion: packageVersion,
+		Source:         source,
+		URL:            url,
+		Evidence:       evidence,
+		FetchedAt:      fetchedAt.UTC(),
+	}, nil
+}
+
+// NewFromConfig creates the version source selected by version_source.type, or a fallback chain
+// when version_source.sources is configured, cached on disk when version_source.cache.ttl is set
+func NewFromConfig(cluster string, cfg config.VersionSource, opts Options) (VersionSource, error) {
+	source, err := newSourceOrChain(cluster, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Cache.IsEnabled() {
+		return NewCached(source, cluster, cacheKey(cfg), cfg.Cache, opts), nil
+	}
+
+	return source, nil
+}
+
+// newSourceOrChain creates a single version source or the fallback chain of version_source.sources
+func newSourceOrChain(cluster string, cfg config.VersionSource, opts Options) (VersionSource, error) {
+	if !cfg.IsChain() {
+		return newSource(cluster, cfg, opts)
+	}
+
+	sources := make([]VersionSource, 0, len(cfg.Sources))
+	for _, sourceConfig := range cfg.Sources {
+		source, err := newSource(cluster, sourceConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	return NewChain(opts, sources...), nil
+}
+
+// newSource creates a single version source from its config
+func newSource(cluster string, cfg config.VersionSource, opts Options) (VersionSource, error) {
+	switch cfg.Type {
+	case constants.VersionSourceTypeCloudsmithAPI:
+		s := NewCloudsmithAPI(cluster, opts)
+		s.client.Timeout = cfg.Timeout
+		s.baseURL = cfg.BaseURL
+		if cfg.PackageName != "" {
+			s.packageName = cfg.PackageName
+		}
+		return s, nil
+	case constants.VersionSourceTypeCloudsmithIndex:
+		return NewCloudsmithIndexFromConfig(cluster, cfg, opts)
+	case constants.VersionSourceTypeHTTPJSON:
+		s, err := NewHTTPJSON(cfg.URL, cfg.JSONPath, cfg.Headers, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.client.Timeout = cfg.Timeout
+		return s, nil
+	case constants.VersionSourceTypeDNSTXT:
+		s := NewDNSTXT(cfg.Record, cfg.Nameserver, opts)
+		s.timeout = cfg.Timeout
+		return s, nil
+	case constants.VersionSourceTypeSolanaAccount:
+		return NewSolanaAccountFromConfig(cluster, cfg, opts)
+	case constants.VersionSourceTypeStatic:
+		return NewStatic(cfg.Version, cfg.VersionEnv, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown version source type: %s", cfg.Type)
+	}
+}
+
+// NewCloudsmithIndexFromConfig creates a Cloudsmith repository index source from a cloudsmith_index version source
+// config, resolving its distro and arch target
+func NewCloudsmithIndexFromConfig(cluster string, cfg config.VersionSource, opts Options) (*CloudsmithIndexSource, error) {
+	target, err := ResolveTarget(cfg.Format, cfg.Distro, cfg.DistroVersion, cfg.Arch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository index target: %w", err)
+	}
+
+	s := NewCloudsmithIndex(cluster, cfg.Format, opts)
+	s.client.Timeout = cfg.Timeout
+	s.baseURL = cfg.BaseURL
+	s.target = target
+	if cfg.PackageName != "" {
+		s.packageName = cfg.PackageName
+	}
+
+	s.logger.Debug("resolved repository index target", "distro", target.Distro, "distro_version", target.DistroVersion, "arch", target.Arch)
+	return s, nil
+}